@@ -2,9 +2,12 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"os"
+	"strconv"
 
+	"evidence-wall/boards-service/internal/blob"
 	"evidence-wall/boards-service/internal/config"
 	"evidence-wall/boards-service/internal/handlers"
 	"evidence-wall/boards-service/internal/repository"
@@ -18,6 +21,7 @@ import (
 	"github.com/redis/go-redis/v9"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"gorm.io/gorm"
 
 	_ "evidence-wall/boards-service/docs" // Import generated docs
 )
@@ -74,6 +78,16 @@ func main() {
 		log.Printf("Warning: Failed to create indexes: %v", err)
 	}
 
+	// Full-text search columns and indexes
+	if err := database.CreateSearchIndexes(db); err != nil {
+		log.Printf("Warning: Failed to create search indexes: %v", err)
+	}
+
+	// Reject updates and deletes of custody log entries in the database
+	if err := database.ProtectCustodyLog(db); err != nil {
+		log.Fatalf("boards:custody log error: %v", err)
+	}
+
 	// Connect to Redis
 	rdb := redis.NewClient(&redis.Options{
 		Addr:     cfg.RedisHost + ":" + cfg.RedisPort,
@@ -96,12 +110,44 @@ func main() {
 	boardUserRepo := repository.NewBoardUserRepository(db)
 	boardItemRepo := repository.NewBoardItemRepository(db)
 	boardConnectionRepo := repository.NewBoardConnectionRepository(db)
+	templateRepo := repository.NewTemplateRepository(db)
+	tagRepo := repository.NewTagRepository(db)
+	layerRepo := repository.NewLayerRepository(db)
+	attachmentRepo := repository.NewAttachmentRepository(db)
+	custodyRepo := repository.NewCustodyRepository(db)
+	searchRepo := repository.NewSearchRepository(db)
+
+	// Initialize attachment storage
+	blobStore, err := newBlobStore(cfg)
+	if err != nil {
+		log.Fatalf("boards:attachment storage error: %v", err)
+	}
+
+	// Generate thumbnails of attached photos in the background
+	preserveEXIF, _ := strconv.ParseBool(cfg.ThumbnailPreserveEXIF)
+	thumbnailWorkers, err := strconv.Atoi(cfg.ThumbnailWorkers)
+	if err != nil || thumbnailWorkers <= 0 {
+		log.Printf("Ignoring invalid THUMBNAIL_WORKERS=%q", cfg.ThumbnailWorkers)
+		thumbnailWorkers = 1
+	}
+	previewWorker := service.NewPreviewWorker(attachmentRepo, boardItemRepo, blobStore, service.PreviewOptions{PreserveEXIF: preserveEXIF})
+	previewWorker.Start(context.Background(), thumbnailWorkers)
 
 	// Initialize services
-	boardService := service.NewBoardService(boardRepo, boardUserRepo, boardItemRepo, boardConnectionRepo, rdb)
+	boardService := service.NewBoardService(boardRepo, boardUserRepo, boardItemRepo, boardConnectionRepo, templateRepo, tagRepo, layerRepo, rdb)
+	attachmentService := service.NewAttachmentService(boardRepo, boardService.Permissions(), boardItemRepo, attachmentRepo, custodyRepo, blobStore, previewWorker, service.AttachmentLimits{
+		MaxSize:    parseByteSize(cfg.MaxAttachmentSize, "MAX_ATTACHMENT_SIZE"),
+		BoardQuota: parseByteSize(cfg.BoardAttachmentQuota, "BOARD_ATTACHMENT_QUOTA"),
+	})
+	boardService.SetAttachments(attachmentService)
+	boardService.SetHistoryTransaction(historyTransaction(db))
+	boardService.SetContentTransaction(contentTransaction(db))
+	searchService := service.NewSearchService(searchRepo)
 
 	// Initialize handlers
 	boardHandler := handlers.NewBoardHandler(boardService)
+	attachmentHandler := handlers.NewAttachmentHandler(attachmentService)
+	searchHandler := handlers.NewSearchHandler(searchService)
 
 	// Setup router
 	router := gin.Default()
@@ -143,6 +189,8 @@ func main() {
 		{
 			boards.GET("", boardHandler.ListBoards)
 			boards.POST("", boardHandler.CreateBoard)
+			boards.POST("/import", boardHandler.ImportBoard)
+			boards.POST("/import/canvas", boardHandler.ImportCanvas)
 			boards.GET("/:id", boardHandler.GetBoard)
 			boards.PUT("/:id", boardHandler.UpdateBoard)
 			boards.DELETE("/:id", boardHandler.DeleteBoard)
@@ -151,6 +199,64 @@ func main() {
 			boards.POST("/:id/share", boardHandler.ShareBoard)
 			boards.DELETE("/:id/share/:userId", boardHandler.UnshareBoard)
 			boards.PUT("/:id/users/:userId/permission", boardHandler.UpdateUserPermission)
+
+			// Duplicate or fork a board
+			boards.POST("/:id/duplicate", boardHandler.DuplicateBoard)
+
+			// Portable JSON, JSON Canvas and graph format export
+			boards.GET("/:id/export", boardHandler.ExportBoard)
+			boards.GET("/:id/export/canvas", boardHandler.ExportCanvas)
+			boards.GET("/:id/export/graph", boardHandler.ExportGraph)
+
+			// Server-side SVG/PNG rendering
+			boards.GET("/:id/render", boardHandler.RenderBoard)
+
+			// PDF case report
+			boards.GET("/:id/report.pdf", boardHandler.BoardReport)
+
+			// Timeline
+			boards.GET("/:id/timeline", boardHandler.GetTimeline)
+			boards.GET("/:id/timeline.ics", boardHandler.TimelineICS)
+
+			// Geo-located items as GeoJSON and KML
+			boards.GET("/:id/geo", boardHandler.GetGeo)
+			boards.GET("/:id/export/kml", boardHandler.ExportKML)
+			boards.POST("/:id/geo/import", boardHandler.ImportGeo)
+
+			// Graph analysis over items and connections
+			boards.GET("/:id/analysis/path", boardHandler.ShortestPath)
+			boards.GET("/:id/analysis/centrality", boardHandler.Centrality)
+			boards.GET("/:id/analysis/components", boardHandler.ConnectedComponents)
+			boards.GET("/:id/analysis/communities", boardHandler.Communities)
+			boards.GET("/:id/analysis/isolated", boardHandler.IsolatedItems)
+
+			// Automatic layout, as a preview or applied as one undoable change
+			boards.POST("/:id/layout", boardHandler.LayoutBoard)
+
+			// Undo/redo of the current user's changes
+			boards.POST("/:id/undo", boardHandler.Undo)
+			boards.POST("/:id/redo", boardHandler.Redo)
+
+			// Re-hash stored evidence against the digests taken on upload
+			boards.POST("/:id/attachments/verify", attachmentHandler.VerifyAttachments)
+
+			// Board tags, and bulk tagging of the board and its items
+			boards.GET("/:id/tags", boardHandler.ListBoardTags)
+			boards.POST("/:id/tags", boardHandler.CreateBoardTag)
+			boards.POST("/:id/tags/bulk", boardHandler.BulkTag)
+
+			// Items and connections within a viewport
+			boards.GET("/:id/viewport", boardHandler.ListViewport)
+
+			// Move items into and out of frames
+			boards.POST("/:id/frames/:frameId/members", boardHandler.SetFrameMembers)
+
+			// Layers, and moving items and connections between them
+			boards.GET("/:id/layers", boardHandler.ListLayers)
+			boards.POST("/:id/layers", boardHandler.CreateLayer)
+			boards.POST("/:id/layers/move", boardHandler.MoveToLayer)
+			boards.PUT("/:id/layers/:layerId", boardHandler.UpdateLayer)
+			boards.DELETE("/:id/layers/:layerId", boardHandler.DeleteLayer)
 		}
 
 		// Board items routes (use consistent board :id and distinct item :itemId)
@@ -160,6 +266,41 @@ func main() {
 			items.POST("", boardHandler.CreateBoardItem)
 			items.PUT("/:itemId", boardHandler.UpdateBoardItem)
 			items.DELETE("/:itemId", boardHandler.DeleteBoardItem)
+
+			// Evidence file attachments
+			items.GET("/:itemId/attachments", attachmentHandler.ListAttachments)
+			items.POST("/:itemId/attachments", attachmentHandler.UploadAttachment)
+			items.GET("/:itemId/attachments/:attachmentId", attachmentHandler.DownloadAttachment)
+			items.GET("/:itemId/attachments/:attachmentId/thumbnail", attachmentHandler.GetThumbnail)
+			items.DELETE("/:itemId/attachments/:attachmentId", attachmentHandler.DeleteAttachment)
+
+			// Chain of custody of an item's evidence
+			items.GET("/:itemId/custody", attachmentHandler.ExportCustodyLog)
+		}
+
+		// Full-text search across the user's boards and items
+		v1.GET("/search", searchHandler.Search)
+
+		// Personal tags, and renaming or merging any tag the user can change
+		tags := v1.Group("/tags")
+		{
+			tags.GET("", boardHandler.ListTags)
+			tags.POST("", boardHandler.CreateTag)
+			tags.PUT("/:tagId", boardHandler.UpdateTag)
+			tags.DELETE("/:tagId", boardHandler.DeleteTag)
+			tags.POST("/:tagId/merge", boardHandler.MergeTags)
+		}
+
+		// Item type registry with field schemas
+		v1.GET("/item-types", boardHandler.ListItemTypes)
+
+		// Board template routes
+		templates := v1.Group("/templates")
+		{
+			templates.GET("", boardHandler.ListTemplates)
+			templates.POST("", boardHandler.PublishTemplate)
+			templates.GET("/:templateId", boardHandler.GetTemplate)
+			templates.DELETE("/:templateId", boardHandler.DeleteTemplate)
 		}
 
 		// Board connections routes (use consistent board :id and distinct connection :connectionId)
@@ -174,6 +315,7 @@ func main() {
 	public.Use(middleware.OptionalAuthMiddleware(jwtManager))
 	{
 		public.GET("/boards/:id", boardHandler.GetPublicBoard)
+		public.GET("/schemas/board-archive.json", boardHandler.BoardArchiveSchema)
 	}
 
 	// Swagger documentation
@@ -193,6 +335,70 @@ func main() {
 	}
 }
 
+// newBlobStore creates the configured attachment storage backend
+func newBlobStore(cfg *config.Config) (service.BlobStore, error) {
+	switch cfg.AttachmentStorage {
+	case "local":
+		return blob.NewLocalStore(cfg.AttachmentDir)
+	case "s3":
+		pathStyle, _ := strconv.ParseBool(cfg.S3PathStyle)
+		return blob.NewS3Store(blob.S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			PathStyle: pathStyle,
+		})
+	default:
+		return nil, fmt.Errorf("unknown ATTACHMENT_STORAGE %q (expected local or s3)", cfg.AttachmentStorage)
+	}
+}
+
+// historyTransaction runs undo and redo in one database transaction, with
+// the repositories they change bound to it
+func historyTransaction(db *gorm.DB) service.HistoryTransaction {
+	return func(fn func(repos service.HistoryRepositories) error) error {
+		return db.Transaction(func(tx *gorm.DB) error {
+			return fn(service.HistoryRepositories{
+				Boards:      repository.NewBoardRepository(tx),
+				Items:       repository.NewBoardItemRepository(tx),
+				Connections: repository.NewBoardConnectionRepository(tx),
+			})
+		})
+	}
+}
+
+// contentTransaction creates board copies and imports in one database
+// transaction, with the repositories they create rows through bound to it
+func contentTransaction(db *gorm.DB) service.ContentTransaction {
+	return func(fn func(repos service.ContentRepositories) error) error {
+		return db.Transaction(func(tx *gorm.DB) error {
+			return fn(service.ContentRepositories{
+				Boards:      repository.NewBoardRepository(tx),
+				BoardUsers:  repository.NewBoardUserRepository(tx),
+				Items:       repository.NewBoardItemRepository(tx),
+				Connections: repository.NewBoardConnectionRepository(tx),
+				Layers:      repository.NewLayerRepository(tx),
+			})
+		})
+	}
+}
+
+// parseByteSize parses a size in bytes, returning 0 (the service default) when
+// the value is empty or invalid.
+func parseByteSize(value, name string) int64 {
+	if value == "" {
+		return 0
+	}
+	size, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || size <= 0 {
+		log.Printf("Ignoring invalid %s=%q", name, value)
+		return 0
+	}
+	return size
+}
+
 // splitAndTrim splits a comma-separated string and trims whitespace entries, skipping empties.
 func splitAndTrim(s string) []string {
 	res := []string{}
@@ -0,0 +1,53 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalStore(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	store, err := NewLocalStore(root)
+	assert.NoError(t, err)
+
+	key := "boards/b1/items/i1/a1"
+	assert.NoError(t, store.Put(ctx, key, strings.NewReader("evidence"), 8, "text/plain"))
+	_, err = os.Stat(filepath.Join(root, "boards", "b1", "items", "i1", "a1"))
+	assert.NoError(t, err)
+
+	rc, err := store.Get(ctx, key)
+	assert.NoError(t, err)
+	data, _ := io.ReadAll(rc)
+	rc.Close()
+	assert.Equal(t, "evidence", string(data))
+
+	// A short upload leaves nothing behind
+	err = store.Put(ctx, "boards/b1/short", strings.NewReader("abc"), 10, "")
+	assert.Error(t, err)
+	_, err = store.Get(ctx, "boards/b1/short")
+	assert.True(t, errors.Is(err, ErrNotFound))
+	entries, _ := os.ReadDir(filepath.Join(root, "boards", "b1"))
+	assert.Len(t, entries, 1)
+
+	assert.NoError(t, store.Delete(ctx, key))
+	assert.NoError(t, store.Delete(ctx, key))
+	_, err = store.Get(ctx, key)
+	assert.True(t, errors.Is(err, ErrNotFound))
+}
+
+func TestCheckKey(t *testing.T) {
+	for _, key := range []string{"a", "boards/b/items/i/x.pdf"} {
+		assert.NoError(t, checkKey(key), key)
+	}
+	for _, key := range []string{"", "/etc/passwd", "../x", "a/../../x", "a//b", "a/./b", `a\b`, "a/"} {
+		assert.True(t, errors.Is(checkKey(key), ErrInvalidKey), key)
+	}
+}
@@ -17,6 +17,23 @@ type Config struct {
 	Environment    string
 	LogLevel       string
 	TrustedProxies string
+
+	// Attachment storage: "local" keeps files in AttachmentDir, "s3" uses an
+	// S3-compatible bucket
+	AttachmentStorage    string
+	AttachmentDir        string
+	S3Endpoint           string
+	S3Region             string
+	S3Bucket             string
+	S3AccessKey          string
+	S3SecretKey          string
+	S3PathStyle          string
+	MaxAttachmentSize    string // Bytes per file; empty uses the service default
+	BoardAttachmentQuota string // Bytes per board; empty uses the service default
+
+	// Thumbnails of attached photos
+	ThumbnailWorkers      string
+	ThumbnailPreserveEXIF string // Copy EXIF (including GPS) into thumbnails
 }
 
 // Load loads configuration from environment variables
@@ -42,6 +59,20 @@ func Load() *Config {
 		Environment:    environment,
 		LogLevel:       getEnv("LOG_LEVEL", "debug"),
 		TrustedProxies: getEnv("TRUSTED_PROXIES", ""),
+
+		AttachmentStorage:    getEnv("ATTACHMENT_STORAGE", "local"),
+		AttachmentDir:        getEnv("ATTACHMENT_DIR", "./data/attachments"),
+		S3Endpoint:           getEnv("S3_ENDPOINT", ""),
+		S3Region:             getEnv("S3_REGION", "us-east-1"),
+		S3Bucket:             getEnv("S3_BUCKET", ""),
+		S3AccessKey:          getEnv("S3_ACCESS_KEY", ""),
+		S3SecretKey:          getEnv("S3_SECRET_KEY", ""),
+		S3PathStyle:          getEnv("S3_PATH_STYLE", "false"),
+		MaxAttachmentSize:    getEnv("MAX_ATTACHMENT_SIZE", ""),
+		BoardAttachmentQuota: getEnv("BOARD_ATTACHMENT_QUOTA", ""),
+
+		ThumbnailWorkers:      getEnv("THUMBNAIL_WORKERS", "2"),
+		ThumbnailPreserveEXIF: getEnv("THUMBNAIL_PRESERVE_EXIF", "false"),
 	}
 }
 
@@ -0,0 +1,144 @@
+package geo
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseGeoJSON(t *testing.T) {
+	fc, err := ParseGeoJSON([]byte(`{
+		"type": "FeatureCollection",
+		"features": [
+			{"type": "Feature", "id": 7, "geometry": {"type": "Point", "coordinates": [-0.1276, 51.5072, 11]}, "properties": {"name": "London"}},
+			{"type": "Feature", "geometry": {"type": "MultiPoint", "coordinates": [[2.35, 48.85], [4.9, 52.37]]}, "properties": null},
+			{"type": "Feature", "geometry": {"type": "Polygon", "coordinates": [[[0, 0], [1, 0], [1, 1], [0, 0]], [[0.2, 0.2], [0.4, 0.2], [0.4, 0.4], [0.2, 0.2]]]}},
+			{"type": "Feature", "geometry": null, "properties": {"name": "Nowhere"}}
+		]
+	}`))
+	assert.NoError(t, err)
+	if assert.Len(t, fc.Features, 4) {
+		assert.Equal(t, float64(7), fc.Features[0].ID)
+		assert.Equal(t, "London", fc.Features[0].String("name"))
+		assert.Equal(t, Position{-0.1276, 51.5072}, fc.Features[0].Geometry.Coords[0])
+		assert.Equal(t, TypePoint, fc.Features[2].Geometry.Type)
+		// The hole is dropped
+		assert.Equal(t, []Position{{0, 0}, {1, 0}, {1, 1}}, fc.Features[3].Geometry.Outline())
+	}
+
+	fc, err = ParseGeoJSON([]byte(`{"type": "LineString", "coordinates": [[0, 0], [1, 1]]}`))
+	assert.NoError(t, err)
+	assert.Len(t, fc.Features, 1)
+
+	for _, bad := range []string{
+		`[1, 2]`,
+		`{"type": "Circle", "coordinates": [0, 0]}`,
+		`{"type": "Point", "coordinates": [200, 0]}`,
+		`{"type": "Point", "coordinates": [1]}`,
+		`{"type": "LineString", "coordinates": [[0, 0]]}`,
+		`{"type": "Polygon", "coordinates": [[[0, 0], [1, 1], [0, 0]]]}`,
+		`{"type": "FeatureCollection", "features": [{"type": "Point", "coordinates": [0, 0]}]}`,
+	} {
+		_, err := ParseGeoJSON([]byte(bad))
+		assert.True(t, errors.Is(err, ErrInvalid), bad)
+	}
+}
+
+func TestFeatureCollection_MarshalJSON(t *testing.T) {
+	fc := FeatureCollection{Features: []Feature{
+		{ID: "a", Geometry: NewPoint(Position{1, 2})},
+		{Geometry: NewPolygon([]Position{{0, 0}, {1, 0}, {1, 1}}), Properties: map[string]interface{}{"name": "Zone"}},
+	}}
+	data, err := json.Marshal(fc)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"type": "FeatureCollection",
+		"features": [
+			{"type": "Feature", "id": "a", "geometry": {"type": "Point", "coordinates": [1, 2]}, "properties": {}},
+			{"type": "Feature", "geometry": {"type": "Polygon", "coordinates": [[[0, 0], [1, 0], [1, 1], [0, 0]]]}, "properties": {"name": "Zone"}}
+		]
+	}`, string(data))
+
+	data, err = json.Marshal(FeatureCollection{})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"type": "FeatureCollection", "features": []}`, string(data))
+}
+
+func TestGeometry_Center(t *testing.T) {
+	square := NewPolygon([]Position{{0, 0}, {2, 0}, {2, 2}, {0, 2}})
+	assert.Equal(t, Position{1, 1}, square.Center())
+	assert.Equal(t, Position{3, 4}, NewPoint(Position{3, 4}).Center())
+}
+
+func TestKMLRoundTrip(t *testing.T) {
+	fc := &FeatureCollection{Features: []Feature{
+		{ID: "pier", Geometry: NewPoint(Position{-0.0754, 51.5055}), Properties: map[string]interface{}{
+			"name": "Pier 4", "description": "Meeting place", "color": "#FF8000", "type": "location", "nested": map[string]interface{}{},
+		}},
+		{Geometry: NewLineString([]Position{{0, 0}, {1, 1}}), Properties: map[string]interface{}{"name": "Drove to", "confidence": "high"}},
+		{Geometry: NewPolygon([]Position{{0, 0}, {1, 0}, {1, 1}}), Properties: map[string]interface{}{"color": "#00ff00"}},
+	}}
+	var buf bytes.Buffer
+	assert.NoError(t, WriteKML(&buf, "Docks & harbour", fc))
+	out := buf.String()
+	assert.True(t, strings.HasPrefix(out, `<?xml version="1.0" encoding="UTF-8"?>`))
+	assert.Contains(t, out, `<kml xmlns="http://www.opengis.net/kml/2.2">`)
+	assert.Contains(t, out, `<name>Docks &amp; harbour</name>`)
+	assert.Contains(t, out, `<Placemark id="pier">`)
+	assert.Contains(t, out, `<color>ff0080ff</color>`)
+	assert.Contains(t, out, `<coordinates>-0.0754,51.5055</coordinates>`)
+	assert.NotContains(t, out, "nested")
+
+	back, err := ParseKML(buf.Bytes())
+	assert.NoError(t, err)
+	if assert.Len(t, back.Features, 3) {
+		assert.Equal(t, "pier", back.Features[0].ID)
+		assert.Equal(t, map[string]interface{}{
+			"name": "Pier 4", "description": "Meeting place", "color": "#ff8000", "type": "location",
+		}, back.Features[0].Properties)
+		assert.Equal(t, fc.Features[0].Geometry, back.Features[0].Geometry)
+		assert.Equal(t, "high", back.Features[1].String("confidence"))
+		assert.Equal(t, fc.Features[2].Geometry, back.Features[2].Geometry)
+		assert.Equal(t, "#00ff00", back.Features[2].String("color"))
+	}
+}
+
+func TestParseKML(t *testing.T) {
+	fc, err := ParseKML([]byte(`<?xml version="1.0"?>
+<kml xmlns="http://earth.google.com/kml/2.1">
+  <Folder>
+    <Folder>
+      <Placemark>
+        <name>Warehouse</name>
+        <MultiGeometry>
+          <Point><coordinates>10,20,0</coordinates></Point>
+          <Polygon><outerBoundaryIs><LinearRing><coordinates>
+            0,0 1,0 1,1 0,0
+          </coordinates></LinearRing></outerBoundaryIs></Polygon>
+        </MultiGeometry>
+      </Placemark>
+    </Folder>
+  </Folder>
+  <Placemark><name>Empty</name></Placemark>
+</kml>`))
+	assert.NoError(t, err)
+	if assert.Len(t, fc.Features, 2) {
+		assert.Equal(t, NewPoint(Position{10, 20}), fc.Features[0].Geometry)
+		assert.Equal(t, TypePolygon, fc.Features[1].Geometry.Type)
+		assert.Equal(t, "Warehouse", fc.Features[1].String("name"))
+	}
+
+	for _, bad := range []string{
+		`not xml`,
+		`<kml><Placemark><Point><coordinates>1;2</coordinates></Point></Placemark></kml>`,
+		`<kml><Placemark><Point><coordinates>0,95</coordinates></Point></Placemark></kml>`,
+		`<kml><Placemark><Point><coordinates>0,0 1,1</coordinates></Point></Placemark></kml>`,
+	} {
+		_, err := ParseKML([]byte(bad))
+		assert.True(t, errors.Is(err, ErrInvalid), bad)
+	}
+}
@@ -0,0 +1,310 @@
+// Package geo reads and writes geographic features as GeoJSON (RFC 7946) and
+// KML. Only points, line strings and polygons are modelled; multi-part
+// geometries are split into one feature per part when read, and polygon
+// holes are dropped.
+package geo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+)
+
+var ErrInvalid = errors.New("invalid geographic data")
+
+// Geometry types
+const (
+	TypePoint      = "Point"
+	TypeLineString = "LineString"
+	TypePolygon    = "Polygon"
+)
+
+// Position is a longitude, latitude pair, in GeoJSON order
+type Position [2]float64
+
+func (p Position) Lon() float64 { return p[0] }
+func (p Position) Lat() float64 { return p[1] }
+
+// Valid reports whether the position is within WGS 84 bounds
+func (p Position) Valid() bool {
+	return !math.IsNaN(p[0]) && !math.IsNaN(p[1]) && p[0] >= -180 && p[0] <= 180 && p[1] >= -90 && p[1] <= 90
+}
+
+// Geometry is a point, a line string or a polygon's outer ring. Rings are
+// kept closed, their last position repeating the first.
+type Geometry struct {
+	Type   string
+	Coords []Position
+}
+
+// NewPoint creates a point geometry
+func NewPoint(p Position) *Geometry {
+	return &Geometry{Type: TypePoint, Coords: []Position{p}}
+}
+
+// NewLineString creates a line string geometry
+func NewLineString(points []Position) *Geometry {
+	return &Geometry{Type: TypeLineString, Coords: points}
+}
+
+// NewPolygon creates a polygon from its outline, closing it when needed
+func NewPolygon(ring []Position) *Geometry {
+	if len(ring) > 0 && ring[0] != ring[len(ring)-1] {
+		ring = append(append([]Position(nil), ring...), ring[0])
+	}
+	return &Geometry{Type: TypePolygon, Coords: ring}
+}
+
+// Outline returns a polygon's ring without the closing position, or the
+// geometry's positions otherwise
+func (g *Geometry) Outline() []Position {
+	if g.Type == TypePolygon && len(g.Coords) > 1 && g.Coords[0] == g.Coords[len(g.Coords)-1] {
+		return g.Coords[:len(g.Coords)-1]
+	}
+	return g.Coords
+}
+
+// Center returns the point, or the mean of the outline's positions
+func (g *Geometry) Center() Position {
+	outline := g.Outline()
+	var c Position
+	for _, p := range outline {
+		c[0] += p[0]
+		c[1] += p[1]
+	}
+	if n := float64(len(outline)); n > 0 {
+		c[0] /= n
+		c[1] /= n
+	}
+	return c
+}
+
+// MarshalJSON writes the geometry as a GeoJSON geometry object
+func (g *Geometry) MarshalJSON() ([]byte, error) {
+	var coordinates interface{}
+	switch g.Type {
+	case TypePoint:
+		coordinates = g.Coords[0]
+	case TypeLineString:
+		coordinates = g.Coords
+	case TypePolygon:
+		coordinates = [][]Position{g.Coords}
+	default:
+		return nil, fmt.Errorf("unsupported geometry type %q", g.Type)
+	}
+	return json.Marshal(struct {
+		Type        string      `json:"type"`
+		Coordinates interface{} `json:"coordinates"`
+	}{g.Type, coordinates})
+}
+
+// Feature is a geometry with properties. ID is a string or a number.
+type Feature struct {
+	ID         interface{}            `json:"id,omitempty"`
+	Geometry   *Geometry              `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// MarshalJSON writes the feature as a GeoJSON Feature
+func (f Feature) MarshalJSON() ([]byte, error) {
+	properties := f.Properties
+	if properties == nil {
+		properties = map[string]interface{}{}
+	}
+	return json.Marshal(struct {
+		Type       string                 `json:"type"`
+		ID         interface{}            `json:"id,omitempty"`
+		Geometry   *Geometry              `json:"geometry"`
+		Properties map[string]interface{} `json:"properties"`
+	}{"Feature", f.ID, f.Geometry, properties})
+}
+
+// String returns a string property, or "" when missing or not a string
+func (f *Feature) String(key string) string {
+	s, _ := f.Properties[key].(string)
+	return s
+}
+
+// FeatureCollection is a GeoJSON FeatureCollection
+type FeatureCollection struct {
+	Features []Feature `json:"features"`
+}
+
+// MarshalJSON writes the collection as a GeoJSON FeatureCollection
+func (fc FeatureCollection) MarshalJSON() ([]byte, error) {
+	features := fc.Features
+	if features == nil {
+		features = []Feature{}
+	}
+	return json.Marshal(struct {
+		Type     string    `json:"type"`
+		Features []Feature `json:"features"`
+	}{"FeatureCollection", features})
+}
+
+// rawObject is any GeoJSON object
+type rawObject struct {
+	Type        string                 `json:"type"`
+	ID          interface{}            `json:"id"`
+	Features    []json.RawMessage      `json:"features"`
+	Geometry    json.RawMessage        `json:"geometry"`
+	Geometries  []json.RawMessage      `json:"geometries"`
+	Properties  map[string]interface{} `json:"properties"`
+	Coordinates json.RawMessage        `json:"coordinates"`
+}
+
+// ParseGeoJSON reads a FeatureCollection, a Feature or a bare geometry.
+// Multi-part geometries and geometry collections become one feature per
+// part; features without a geometry are left out.
+func ParseGeoJSON(data []byte) (*FeatureCollection, error) {
+	var obj rawObject
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	fc := &FeatureCollection{}
+	switch obj.Type {
+	case "FeatureCollection":
+		for i, raw := range obj.Features {
+			var feature rawObject
+			if err := json.Unmarshal(raw, &feature); err != nil || feature.Type != "Feature" {
+				return nil, fmt.Errorf("%w: features[%d] is not a Feature", ErrInvalid, i)
+			}
+			if err := fc.addFeature(feature); err != nil {
+				return nil, fmt.Errorf("features[%d]: %w", i, err)
+			}
+		}
+	case "Feature":
+		if err := fc.addFeature(obj); err != nil {
+			return nil, err
+		}
+	default:
+		if err := fc.addFeature(rawObject{Type: "Feature", Geometry: data}); err != nil {
+			return nil, err
+		}
+	}
+	return fc, nil
+}
+
+func (fc *FeatureCollection) addFeature(feature rawObject) error {
+	if len(feature.Geometry) == 0 || string(feature.Geometry) == "null" {
+		return nil
+	}
+	geometries, err := parseGeometry(feature.Geometry)
+	if err != nil {
+		return err
+	}
+	for _, g := range geometries {
+		fc.Features = append(fc.Features, Feature{ID: feature.ID, Geometry: g, Properties: feature.Properties})
+	}
+	return nil
+}
+
+// parseGeometry reads a geometry object into its parts
+func parseGeometry(data []byte) ([]*Geometry, error) {
+	var obj rawObject
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	var parts []*Geometry
+	switch obj.Type {
+	case TypePoint:
+		var p Position
+		if err := decodePositions(obj.Coordinates, &p); err != nil {
+			return nil, err
+		}
+		parts = append(parts, NewPoint(p))
+	case "MultiPoint":
+		var points []Position
+		if err := decodePositions(obj.Coordinates, &points); err != nil {
+			return nil, err
+		}
+		for _, p := range points {
+			parts = append(parts, NewPoint(p))
+		}
+	case TypeLineString:
+		var line []Position
+		if err := decodePositions(obj.Coordinates, &line); err != nil {
+			return nil, err
+		}
+		parts = append(parts, NewLineString(line))
+	case "MultiLineString":
+		var lines [][]Position
+		if err := decodePositions(obj.Coordinates, &lines); err != nil {
+			return nil, err
+		}
+		for _, line := range lines {
+			parts = append(parts, NewLineString(line))
+		}
+	case TypePolygon:
+		var rings [][]Position
+		if err := decodePositions(obj.Coordinates, &rings); err != nil {
+			return nil, err
+		}
+		if len(rings) > 0 {
+			parts = append(parts, NewPolygon(rings[0]))
+		}
+	case "MultiPolygon":
+		var polygons [][][]Position
+		if err := decodePositions(obj.Coordinates, &polygons); err != nil {
+			return nil, err
+		}
+		for _, rings := range polygons {
+			if len(rings) > 0 {
+				parts = append(parts, NewPolygon(rings[0]))
+			}
+		}
+	case "GeometryCollection":
+		for _, raw := range obj.Geometries {
+			more, err := parseGeometry(raw)
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, more...)
+		}
+	default:
+		return nil, fmt.Errorf("%w: unknown geometry type %q", ErrInvalid, obj.Type)
+	}
+	for _, g := range parts {
+		if err := g.validate(); err != nil {
+			return nil, err
+		}
+	}
+	return parts, nil
+}
+
+// decodePositions decodes nested coordinates into v
+func decodePositions(data json.RawMessage, v interface{}) error {
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: bad coordinates", ErrInvalid)
+	}
+	return nil
+}
+
+// UnmarshalJSON reads a position, ignoring any altitude
+func (p *Position) UnmarshalJSON(data []byte) error {
+	var values []float64
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	if len(values) < 2 {
+		return errors.New("a position needs a longitude and a latitude")
+	}
+	*p = Position{values[0], values[1]}
+	return nil
+}
+
+func (g *Geometry) validate() error {
+	for _, p := range g.Coords {
+		if !p.Valid() {
+			return fmt.Errorf("%w: position %v is out of range", ErrInvalid, [2]float64(p))
+		}
+	}
+	switch {
+	case g.Type == TypeLineString && len(g.Coords) < 2:
+		return fmt.Errorf("%w: a line string needs at least 2 positions", ErrInvalid)
+	case g.Type == TypePolygon && len(g.Outline()) < 3:
+		return fmt.Errorf("%w: a polygon needs at least 3 positions", ErrInvalid)
+	}
+	return nil
+}
@@ -0,0 +1,309 @@
+package geo
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const kmlNamespace = "http://www.opengis.net/kml/2.2"
+
+// Feature properties with a place of their own in KML placemarks; other
+// scalar properties are written as ExtendedData
+const (
+	PropertyName        = "name"
+	PropertyDescription = "description"
+	PropertyColor       = "color" // #rrggbb
+)
+
+var hexColor = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+type kmlRoot struct {
+	XMLName xml.Name `xml:"kml"`
+	Xmlns   string   `xml:"xmlns,attr,omitempty"`
+	kmlContainer
+}
+
+// kmlContainer is a Document or Folder. Placemarks directly under the kml
+// element are read the same way.
+type kmlContainer struct {
+	Name       string         `xml:"name,omitempty"`
+	Documents  []kmlContainer `xml:"Document"`
+	Folders    []kmlContainer `xml:"Folder"`
+	Placemarks []kmlPlacemark `xml:"Placemark"`
+}
+
+type kmlPlacemark struct {
+	ID           string           `xml:"id,attr,omitempty"`
+	Name         string           `xml:"name,omitempty"`
+	Description  string           `xml:"description,omitempty"`
+	Style        *kmlStyle        `xml:"Style,omitempty"`
+	ExtendedData *kmlExtendedData `xml:"ExtendedData,omitempty"`
+	kmlGeometries
+}
+
+type kmlGeometries struct {
+	Points        []kmlCoordinates `xml:"Point,omitempty"`
+	LineStrings   []kmlCoordinates `xml:"LineString,omitempty"`
+	Polygons      []kmlPolygon     `xml:"Polygon,omitempty"`
+	MultiGeometry []kmlGeometries  `xml:"MultiGeometry,omitempty"`
+}
+
+type kmlCoordinates struct {
+	Coordinates string `xml:"coordinates"`
+}
+
+type kmlPolygon struct {
+	Outer string `xml:"outerBoundaryIs>LinearRing>coordinates"`
+}
+
+type kmlStyle struct {
+	IconStyle *kmlColor `xml:"IconStyle,omitempty"`
+	LineStyle *kmlColor `xml:"LineStyle,omitempty"`
+	PolyStyle *kmlColor `xml:"PolyStyle,omitempty"`
+}
+
+type kmlColor struct {
+	Color string `xml:"color"`
+}
+
+type kmlExtendedData struct {
+	Data []kmlData `xml:"Data"`
+}
+
+type kmlData struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value"`
+}
+
+// WriteKML writes the features as a KML document with one placemark each.
+// The name, description and color properties style the placemark; other
+// scalar properties become its ExtendedData.
+func WriteKML(w io.Writer, name string, fc *FeatureCollection) error {
+	root := kmlRoot{Xmlns: kmlNamespace}
+	document := kmlContainer{Name: name}
+	for _, f := range fc.Features {
+		placemark := kmlPlacemark{
+			Name:        f.String(PropertyName),
+			Description: f.String(PropertyDescription),
+		}
+		if f.ID != nil {
+			placemark.ID = fmt.Sprint(f.ID)
+		}
+		switch f.Geometry.Type {
+		case TypePoint:
+			placemark.Points = []kmlCoordinates{{formatCoordinates(f.Geometry.Coords)}}
+		case TypeLineString:
+			placemark.LineStrings = []kmlCoordinates{{formatCoordinates(f.Geometry.Coords)}}
+		case TypePolygon:
+			placemark.Polygons = []kmlPolygon{{formatCoordinates(f.Geometry.Coords)}}
+		}
+		if color := f.String(PropertyColor); hexColor.MatchString(color) {
+			// KML colors are aabbggrr
+			c := &kmlColor{Color: strings.ToLower("ff" + color[5:7] + color[3:5] + color[1:3])}
+			placemark.Style = &kmlStyle{}
+			switch f.Geometry.Type {
+			case TypePoint:
+				placemark.Style.IconStyle = c
+			case TypeLineString:
+				placemark.Style.LineStyle = c
+			case TypePolygon:
+				placemark.Style.LineStyle = c
+				placemark.Style.PolyStyle = &kmlColor{Color: "80" + c.Color[2:]}
+			}
+		}
+		placemark.ExtendedData = extendedData(f.Properties)
+		document.Placemarks = append(document.Placemarks, placemark)
+	}
+	root.Documents = []kmlContainer{document}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(root); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// extendedData holds the scalar properties without a KML element, by key
+func extendedData(properties map[string]interface{}) *kmlExtendedData {
+	keys := make([]string, 0, len(properties))
+	for key, value := range properties {
+		switch key {
+		case PropertyName, PropertyDescription, PropertyColor:
+			continue
+		}
+		switch value.(type) {
+		case string, bool, float64, float32, int, int64:
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	sort.Strings(keys)
+	data := &kmlExtendedData{}
+	for _, key := range keys {
+		data.Data = append(data.Data, kmlData{Name: key, Value: fmt.Sprint(properties[key])})
+	}
+	return data
+}
+
+func formatCoordinates(coords []Position) string {
+	parts := make([]string, len(coords))
+	for i, p := range coords {
+		parts[i] = strconv.FormatFloat(p[0], 'f', -1, 64) + "," + strconv.FormatFloat(p[1], 'f', -1, 64)
+	}
+	return strings.Join(parts, " ")
+}
+
+// color returns the style's first color as #rrggbb
+func (s *kmlStyle) color() string {
+	if s == nil {
+		return ""
+	}
+	for _, c := range []*kmlColor{s.IconStyle, s.LineStyle, s.PolyStyle} {
+		if c == nil {
+			continue
+		}
+		// KML colors are aabbggrr
+		if value := strings.TrimSpace(c.Color); len(value) == 8 && hexColor.MatchString("#"+value[2:]) {
+			return strings.ToLower("#" + value[6:8] + value[4:6] + value[2:4])
+		}
+	}
+	return ""
+}
+
+// ParseKML reads the placemarks of a KML document, in documents and folders
+// at any depth. Their name, description, inline style color and ExtendedData
+// become properties, and placemarks with several geometries one feature per
+// geometry. KMZ archives are not supported.
+func ParseKML(data []byte) (*FeatureCollection, error) {
+	var root kmlRoot
+	if err := xml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	fc := &FeatureCollection{}
+	if err := fc.addContainer(root.kmlContainer); err != nil {
+		return nil, err
+	}
+	return fc, nil
+}
+
+func (fc *FeatureCollection) addContainer(c kmlContainer) error {
+	for _, placemark := range c.Placemarks {
+		if err := fc.addPlacemark(placemark); err != nil {
+			return err
+		}
+	}
+	for _, children := range [][]kmlContainer{c.Documents, c.Folders} {
+		for _, child := range children {
+			if err := fc.addContainer(child); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (fc *FeatureCollection) addPlacemark(placemark kmlPlacemark) error {
+	geometries, err := placemark.kmlGeometries.parse()
+	if err != nil {
+		return fmt.Errorf("placemark %q: %w", placemark.Name, err)
+	}
+	properties := map[string]interface{}{}
+	if name := strings.TrimSpace(placemark.Name); name != "" {
+		properties[PropertyName] = name
+	}
+	if description := strings.TrimSpace(placemark.Description); description != "" {
+		properties[PropertyDescription] = description
+	}
+	if color := placemark.Style.color(); color != "" {
+		properties[PropertyColor] = color
+	}
+	if placemark.ExtendedData != nil {
+		for _, d := range placemark.ExtendedData.Data {
+			if d.Name != "" {
+				properties[d.Name] = d.Value
+			}
+		}
+	}
+	var id interface{}
+	if placemark.ID != "" {
+		id = placemark.ID
+	}
+	for _, g := range geometries {
+		fc.Features = append(fc.Features, Feature{ID: id, Geometry: g, Properties: properties})
+	}
+	return nil
+}
+
+func (k kmlGeometries) parse() ([]*Geometry, error) {
+	var parts []*Geometry
+	for _, p := range k.Points {
+		coords, err := parseCoordinates(p.Coordinates)
+		if err != nil {
+			return nil, err
+		}
+		if len(coords) != 1 {
+			return nil, fmt.Errorf("%w: a point needs exactly one position", ErrInvalid)
+		}
+		parts = append(parts, NewPoint(coords[0]))
+	}
+	for _, l := range k.LineStrings {
+		coords, err := parseCoordinates(l.Coordinates)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, NewLineString(coords))
+	}
+	for _, p := range k.Polygons {
+		coords, err := parseCoordinates(p.Outer)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, NewPolygon(coords))
+	}
+	for _, m := range k.MultiGeometry {
+		more, err := m.parse()
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, more...)
+	}
+	for _, g := range parts {
+		if err := g.validate(); err != nil {
+			return nil, err
+		}
+	}
+	return parts, nil
+}
+
+// parseCoordinates reads whitespace separated lon,lat[,alt] tuples
+func parseCoordinates(value string) ([]Position, error) {
+	var coords []Position
+	for _, tuple := range strings.Fields(value) {
+		parts := strings.Split(tuple, ",")
+		if len(parts) < 2 || len(parts) > 3 {
+			return nil, fmt.Errorf("%w: bad coordinates %q", ErrInvalid, tuple)
+		}
+		lon, err := strconv.ParseFloat(parts[0], 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: bad longitude %q", ErrInvalid, parts[0])
+		}
+		lat, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: bad latitude %q", ErrInvalid, parts[1])
+		}
+		coords = append(coords, Position{lon, lat})
+	}
+	return coords, nil
+}
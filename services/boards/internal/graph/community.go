@@ -0,0 +1,193 @@
+package graph
+
+import (
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+// Communities partitions the graph into densely connected groups using the
+// Louvain method and returns them largest first, along with the modularity
+// of the partition. Isolated nodes form their own communities.
+func (g *Graph) Communities() ([][]uuid.UUID, float64) {
+	n := len(g.nodes)
+	membership := make([]int, n)
+	for i := range membership {
+		membership[i] = i
+	}
+
+	level := newWeightedGraph(g)
+	if level.total == 0 {
+		return g.groups(membership), 0
+	}
+	for {
+		community, moved := level.localMoving()
+		if !moved {
+			break
+		}
+		for i := range membership {
+			membership[i] = community[membership[i]]
+		}
+		level = level.aggregate(community)
+	}
+	return g.groups(membership), g.modularity(membership)
+}
+
+// modularity is the fraction of edges inside communities minus the fraction
+// expected if edges were placed at random with the same degrees
+func (g *Graph) modularity(membership []int) float64 {
+	var m2 float64
+	degree := make(map[int]float64)
+	var inside float64
+	for i, neighbours := range g.adj {
+		m2 += float64(len(neighbours))
+		degree[membership[i]] += float64(len(neighbours))
+		for _, j := range neighbours {
+			if membership[i] == membership[j] {
+				inside++
+			}
+		}
+	}
+	if m2 == 0 {
+		return 0
+	}
+	q := inside / m2
+	for _, d := range degree {
+		q -= (d / m2) * (d / m2)
+	}
+	return q
+}
+
+type weightedEdge struct {
+	to     int
+	weight float64
+}
+
+// weightedGraph is one level of the Louvain hierarchy. adj is a symmetric
+// matrix in sparse form; a self-loop on an aggregated node holds twice the
+// weight of the edges inside it, so degrees and total stay consistent.
+type weightedGraph struct {
+	adj    [][]weightedEdge
+	degree []float64
+	total  float64 // sum of all degrees, 2m
+}
+
+func newWeightedGraph(g *Graph) *weightedGraph {
+	adj := make([][]weightedEdge, len(g.adj))
+	for i, neighbours := range g.adj {
+		for _, j := range neighbours {
+			adj[i] = append(adj[i], weightedEdge{to: j, weight: 1})
+		}
+	}
+	return newWeightedGraphFromAdj(adj)
+}
+
+func newWeightedGraphFromAdj(adj [][]weightedEdge) *weightedGraph {
+	w := &weightedGraph{adj: adj, degree: make([]float64, len(adj))}
+	for i, edges := range adj {
+		for _, e := range edges {
+			w.degree[i] += e.weight
+		}
+		w.total += w.degree[i]
+	}
+	return w
+}
+
+// localMoving repeatedly moves single nodes to the neighbouring community
+// with the best modularity gain until nothing improves. It returns each
+// node's community, renumbered from zero, and whether anything moved.
+func (w *weightedGraph) localMoving() ([]int, bool) {
+	n := len(w.adj)
+	community := make([]int, n)
+	tot := make([]float64, n)
+	for i := range community {
+		community[i] = i
+		tot[i] = w.degree[i]
+	}
+
+	links := make(map[int]float64)
+	var candidates []int
+	movedAny := false
+	for improved := true; improved; {
+		improved = false
+		for i := 0; i < n; i++ {
+			current := community[i]
+			tot[current] -= w.degree[i]
+
+			for k := range links {
+				delete(links, k)
+			}
+			candidates = candidates[:0]
+			for _, e := range w.adj[i] {
+				if e.to == i {
+					continue
+				}
+				c := community[e.to]
+				if _, ok := links[c]; !ok {
+					candidates = append(candidates, c)
+				}
+				links[c] += e.weight
+			}
+			sort.Ints(candidates)
+
+			best := current
+			bestGain := links[current] - tot[current]*w.degree[i]/w.total
+			for _, c := range candidates {
+				gain := links[c] - tot[c]*w.degree[i]/w.total
+				if gain > bestGain+1e-12 {
+					best, bestGain = c, gain
+				}
+			}
+
+			tot[best] += w.degree[i]
+			if best != current {
+				community[i] = best
+				improved = true
+				movedAny = true
+			}
+		}
+	}
+
+	renumber := make(map[int]int)
+	for i, c := range community {
+		k, ok := renumber[c]
+		if !ok {
+			k = len(renumber)
+			renumber[c] = k
+		}
+		community[i] = k
+	}
+	return community, movedAny
+}
+
+// aggregate builds the next level, with one node per community
+func (w *weightedGraph) aggregate(community []int) *weightedGraph {
+	size := 0
+	for _, c := range community {
+		if c+1 > size {
+			size = c + 1
+		}
+	}
+	weights := make([]map[int]float64, size)
+	for i := range weights {
+		weights[i] = make(map[int]float64)
+	}
+	for i, edges := range w.adj {
+		for _, e := range edges {
+			weights[community[i]][community[e.to]] += e.weight
+		}
+	}
+
+	adj := make([][]weightedEdge, size)
+	for i, row := range weights {
+		targets := make([]int, 0, len(row))
+		for j := range row {
+			targets = append(targets, j)
+		}
+		sort.Ints(targets)
+		for _, j := range targets {
+			adj[i] = append(adj[i], weightedEdge{to: j, weight: row[j]})
+		}
+	}
+	return newWeightedGraphFromAdj(adj)
+}
@@ -0,0 +1,296 @@
+// Package graph implements the network analysis behind the board analysis
+// endpoints. Boards are treated as simple undirected graphs: items are
+// nodes, and any number of connections between two items form one edge.
+// Every algorithm is deterministic for a given node and edge order.
+package graph
+
+import (
+	"math"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+// Graph is an undirected graph over item IDs
+type Graph struct {
+	nodes []uuid.UUID
+	index map[uuid.UUID]int
+	adj   [][]int // sorted neighbour indexes
+}
+
+// Score is a node's value for some metric
+type Score struct {
+	ItemID uuid.UUID `json:"item_id"`
+	Score  float64   `json:"score"`
+}
+
+// New builds a graph. Edges that reference unknown nodes, self-loops and
+// duplicate edges are ignored.
+func New(nodes []uuid.UUID, edges [][2]uuid.UUID) *Graph {
+	g := &Graph{
+		nodes: nodes,
+		index: make(map[uuid.UUID]int, len(nodes)),
+		adj:   make([][]int, len(nodes)),
+	}
+	for i, id := range nodes {
+		g.index[id] = i
+	}
+	seen := make(map[[2]int]bool, len(edges))
+	for _, e := range edges {
+		a, okA := g.index[e[0]]
+		b, okB := g.index[e[1]]
+		if !okA || !okB || a == b {
+			continue
+		}
+		if a > b {
+			a, b = b, a
+		}
+		if seen[[2]int{a, b}] {
+			continue
+		}
+		seen[[2]int{a, b}] = true
+		g.adj[a] = append(g.adj[a], b)
+		g.adj[b] = append(g.adj[b], a)
+	}
+	for _, neighbours := range g.adj {
+		sort.Ints(neighbours)
+	}
+	return g
+}
+
+// Has reports whether id is a node of the graph
+func (g *Graph) Has(id uuid.UUID) bool {
+	_, ok := g.index[id]
+	return ok
+}
+
+// ShortestPath returns the nodes on a shortest path from one node to
+// another, both included, or nil if they are not connected
+func (g *Graph) ShortestPath(from, to uuid.UUID) []uuid.UUID {
+	src, okFrom := g.index[from]
+	dst, okTo := g.index[to]
+	if !okFrom || !okTo {
+		return nil
+	}
+
+	parent := make([]int, len(g.nodes))
+	for i := range parent {
+		parent[i] = -1
+	}
+	parent[src] = src
+	queue := []int{src}
+	for len(queue) > 0 && parent[dst] == -1 {
+		v := queue[0]
+		queue = queue[1:]
+		for _, w := range g.adj[v] {
+			if parent[w] == -1 {
+				parent[w] = v
+				queue = append(queue, w)
+			}
+		}
+	}
+	if parent[dst] == -1 {
+		return nil
+	}
+
+	var path []uuid.UUID
+	for v := dst; ; v = parent[v] {
+		path = append(path, g.nodes[v])
+		if v == src {
+			break
+		}
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+// DegreeCentrality is each node's degree divided by n-1, the largest
+// possible degree
+func (g *Graph) DegreeCentrality() []Score {
+	n := len(g.nodes)
+	values := make([]float64, n)
+	if n > 1 {
+		for i, neighbours := range g.adj {
+			values[i] = float64(len(neighbours)) / float64(n-1)
+		}
+	}
+	return g.scores(values)
+}
+
+// BetweennessCentrality is the normalized share of shortest paths between
+// other nodes that pass through each node (Brandes' algorithm)
+func (g *Graph) BetweennessCentrality() []Score {
+	n := len(g.nodes)
+	values := make([]float64, n)
+
+	sigma := make([]float64, n)
+	dist := make([]int, n)
+	delta := make([]float64, n)
+	preds := make([][]int, n)
+	for s := 0; s < n; s++ {
+		for i := range sigma {
+			sigma[i], dist[i], delta[i], preds[i] = 0, -1, 0, preds[i][:0]
+		}
+		sigma[s], dist[s] = 1, 0
+		order := []int{s}
+		for head := 0; head < len(order); head++ {
+			v := order[head]
+			for _, w := range g.adj[v] {
+				if dist[w] < 0 {
+					dist[w] = dist[v] + 1
+					order = append(order, w)
+				}
+				if dist[w] == dist[v]+1 {
+					sigma[w] += sigma[v]
+					preds[w] = append(preds[w], v)
+				}
+			}
+		}
+		for i := len(order) - 1; i > 0; i-- {
+			w := order[i]
+			for _, v := range preds[w] {
+				delta[v] += sigma[v] / sigma[w] * (1 + delta[w])
+			}
+			values[w] += delta[w]
+		}
+	}
+
+	// Each unordered pair was counted from both ends
+	if n > 2 {
+		norm := 1 / float64((n-1)*(n-2))
+		for i := range values {
+			values[i] *= norm
+		}
+	} else {
+		for i := range values {
+			values[i] = 0
+		}
+	}
+	return g.scores(values)
+}
+
+// EigenvectorCentrality scores nodes by the importance of their neighbours:
+// the principal eigenvector of the adjacency matrix, found by power
+// iteration on A+I (which converges on bipartite graphs too) and scaled to
+// unit length. Nodes without connections score zero.
+func (g *Graph) EigenvectorCentrality() []Score {
+	const (
+		maxIterations = 1000
+		tolerance     = 1e-10
+	)
+	n := len(g.nodes)
+	x := make([]float64, n)
+	next := make([]float64, n)
+	for i := range x {
+		if len(g.adj[i]) > 0 {
+			x[i] = 1
+		}
+	}
+	if !normalize(x) {
+		return g.scores(x)
+	}
+
+	for iter := 0; iter < maxIterations; iter++ {
+		for i := range next {
+			sum := x[i]
+			for _, j := range g.adj[i] {
+				sum += x[j]
+			}
+			next[i] = sum
+		}
+		normalize(next)
+		var change float64
+		for i := range x {
+			change += math.Abs(next[i] - x[i])
+		}
+		x, next = next, x
+		if change < tolerance*float64(n) {
+			break
+		}
+	}
+	return g.scores(x)
+}
+
+func normalize(x []float64) bool {
+	var sum float64
+	for _, v := range x {
+		sum += v * v
+	}
+	if sum == 0 {
+		return false
+	}
+	norm := math.Sqrt(sum)
+	for i := range x {
+		x[i] /= norm
+	}
+	return true
+}
+
+// Components returns the connected components, largest first
+func (g *Graph) Components() [][]uuid.UUID {
+	membership := make([]int, len(g.nodes))
+	for i := range membership {
+		membership[i] = -1
+	}
+	count := 0
+	for s := range g.nodes {
+		if membership[s] >= 0 {
+			continue
+		}
+		membership[s] = count
+		stack := []int{s}
+		for len(stack) > 0 {
+			v := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			for _, w := range g.adj[v] {
+				if membership[w] < 0 {
+					membership[w] = count
+					stack = append(stack, w)
+				}
+			}
+		}
+		count++
+	}
+	return g.groups(membership)
+}
+
+// Isolated returns the nodes that have no connections
+func (g *Graph) Isolated() []uuid.UUID {
+	var out []uuid.UUID
+	for i, neighbours := range g.adj {
+		if len(neighbours) == 0 {
+			out = append(out, g.nodes[i])
+		}
+	}
+	return out
+}
+
+// scores pairs values with node IDs, highest first. Ties keep node order.
+func (g *Graph) scores(values []float64) []Score {
+	out := make([]Score, len(values))
+	for i, v := range values {
+		out[i] = Score{ItemID: g.nodes[i], Score: v}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Score > out[j].Score })
+	return out
+}
+
+// groups turns a node-to-group assignment into lists of node IDs, largest
+// group first. Ties keep the order in which groups first appear.
+func (g *Graph) groups(membership []int) [][]uuid.UUID {
+	byGroup := make(map[int]int)
+	var out [][]uuid.UUID
+	for i, m := range membership {
+		k, ok := byGroup[m]
+		if !ok {
+			k = len(out)
+			byGroup[m] = k
+			out = append(out, nil)
+		}
+		out[k] = append(out[k], g.nodes[i])
+	}
+	sort.SliceStable(out, func(i, j int) bool { return len(out[i]) > len(out[j]) })
+	return out
+}
@@ -0,0 +1,122 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// ids returns n fresh node IDs
+func ids(n int) []uuid.UUID {
+	out := make([]uuid.UUID, n)
+	for i := range out {
+		out[i] = uuid.New()
+	}
+	return out
+}
+
+func edges(nodes []uuid.UUID, pairs ...[2]int) [][2]uuid.UUID {
+	out := make([][2]uuid.UUID, len(pairs))
+	for i, p := range pairs {
+		out[i] = [2]uuid.UUID{nodes[p[0]], nodes[p[1]]}
+	}
+	return out
+}
+
+func scoreOf(scores []Score, id uuid.UUID) float64 {
+	for _, s := range scores {
+		if s.ItemID == id {
+			return s.Score
+		}
+	}
+	return -1
+}
+
+func TestNewIgnoresBadEdges(t *testing.T) {
+	n := ids(3)
+	g := New(n, append(edges(n, [2]int{0, 1}, [2]int{1, 0}, [2]int{2, 2}), [2]uuid.UUID{n[0], uuid.New()}))
+	assert.Equal(t, []int{1}, g.adj[0])
+	assert.Equal(t, []int{0}, g.adj[1])
+	assert.Empty(t, g.adj[2])
+}
+
+func TestShortestPath(t *testing.T) {
+	// 0-1-2-3 and a shortcut 0-4-3, plus an unconnected 5
+	n := ids(6)
+	g := New(n, edges(n, [2]int{0, 1}, [2]int{1, 2}, [2]int{2, 3}, [2]int{0, 4}, [2]int{4, 3}))
+
+	assert.Equal(t, []uuid.UUID{n[0], n[4], n[3]}, g.ShortestPath(n[0], n[3]))
+	assert.Equal(t, []uuid.UUID{n[2]}, g.ShortestPath(n[2], n[2]))
+	assert.Nil(t, g.ShortestPath(n[0], n[5]))
+	assert.Nil(t, g.ShortestPath(n[0], uuid.New()))
+}
+
+func TestCentralityStar(t *testing.T) {
+	// A star: the hub lies on every path between leaves
+	n := ids(5)
+	g := New(n, edges(n, [2]int{0, 1}, [2]int{0, 2}, [2]int{0, 3}, [2]int{0, 4}))
+
+	degree := g.DegreeCentrality()
+	assert.Equal(t, n[0], degree[0].ItemID)
+	assert.InDelta(t, 1.0, degree[0].Score, 1e-9)
+	assert.InDelta(t, 0.25, scoreOf(degree, n[1]), 1e-9)
+
+	betweenness := g.BetweennessCentrality()
+	assert.Equal(t, n[0], betweenness[0].ItemID)
+	assert.InDelta(t, 1.0, betweenness[0].Score, 1e-9)
+	assert.InDelta(t, 0.0, scoreOf(betweenness, n[3]), 1e-9)
+
+	// Principal eigenvector of a star: hub 1/sqrt(2), leaves 1/sqrt(8)
+	eigen := g.EigenvectorCentrality()
+	assert.Equal(t, n[0], eigen[0].ItemID)
+	assert.InDelta(t, 0.70710678, eigen[0].Score, 1e-6)
+	assert.InDelta(t, 0.35355339, scoreOf(eigen, n[4]), 1e-6)
+}
+
+func TestBetweennessPath(t *testing.T) {
+	// Path 0-1-2-3: the inner nodes each sit between two of the three other pairs
+	n := ids(4)
+	g := New(n, edges(n, [2]int{0, 1}, [2]int{1, 2}, [2]int{2, 3}))
+	b := g.BetweennessCentrality()
+	assert.InDelta(t, 2.0/3, scoreOf(b, n[1]), 1e-9)
+	assert.InDelta(t, 2.0/3, scoreOf(b, n[2]), 1e-9)
+	assert.InDelta(t, 0.0, scoreOf(b, n[0]), 1e-9)
+}
+
+func TestEigenvectorWithoutEdges(t *testing.T) {
+	n := ids(3)
+	for _, s := range New(n, nil).EigenvectorCentrality() {
+		assert.Equal(t, 0.0, s.Score)
+	}
+}
+
+func TestComponentsAndIsolated(t *testing.T) {
+	n := ids(6)
+	g := New(n, edges(n, [2]int{0, 1}, [2]int{2, 3}, [2]int{3, 4}))
+
+	assert.Equal(t, [][]uuid.UUID{{n[2], n[3], n[4]}, {n[0], n[1]}, {n[5]}}, g.Components())
+	assert.Equal(t, []uuid.UUID{n[5]}, g.Isolated())
+}
+
+func TestCommunities(t *testing.T) {
+	// Two triangles joined by a single bridge, and one isolated node
+	n := ids(7)
+	g := New(n, edges(n,
+		[2]int{0, 1}, [2]int{1, 2}, [2]int{0, 2},
+		[2]int{3, 4}, [2]int{4, 5}, [2]int{3, 5},
+		[2]int{2, 3},
+	))
+
+	communities, modularity := g.Communities()
+	assert.Equal(t, [][]uuid.UUID{{n[0], n[1], n[2]}, {n[3], n[4], n[5]}, {n[6]}}, communities)
+	// 6 of 7 edges inside, each community holds half the degree: 6/7 - 2*(1/2)^2
+	assert.InDelta(t, 6.0/7-0.5, modularity, 1e-9)
+}
+
+func TestCommunitiesWithoutEdges(t *testing.T) {
+	n := ids(2)
+	communities, modularity := New(n, nil).Communities()
+	assert.Equal(t, [][]uuid.UUID{{n[0]}, {n[1]}}, communities)
+	assert.Equal(t, 0.0, modularity)
+}
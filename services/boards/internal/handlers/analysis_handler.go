@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"evidence-wall/boards-service/internal/service"
+	"evidence-wall/shared/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// analysisParams reads the user, board ID and connection filter shared by
+// all analysis endpoints
+func analysisParams(c *gin.Context) (uuid.UUID, uuid.UUID, service.ConnectionQuery, bool) {
+	var query service.ConnectionQuery
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return uuid.Nil, uuid.Nil, query, false
+	}
+
+	boardID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid board ID"})
+		return uuid.Nil, uuid.Nil, query, false
+	}
+
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return uuid.Nil, uuid.Nil, query, false
+	}
+	return userID, boardID, query, true
+}
+
+// analysisError writes the response for a failed analysis request
+func analysisError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidInput):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrBoardNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
+	case errors.Is(err, service.ErrItemNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+	case errors.Is(err, service.ErrUnauthorized):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to analyze board"})
+	}
+}
+
+// ShortestPath godoc
+// @Summary Shortest path between two items
+// @Description Find the shortest chain of connections between two items on a board
+// @Tags analysis
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Board ID"
+// @Param relationship_type query []string false "Only use connections of these relationship types" collectionFormat(multi)
+// @Param from query string true "Start item ID"
+// @Param to query string true "End item ID"
+// @Success 200 {object} service.PathResult
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /boards/{id}/analysis/path [get]
+func (h *BoardHandler) ShortestPath(c *gin.Context) {
+	userID, boardID, query, ok := analysisParams(c)
+	if !ok {
+		return
+	}
+
+	fromID, err := uuid.Parse(c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from item ID"})
+		return
+	}
+	toID, err := uuid.Parse(c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to item ID"})
+		return
+	}
+
+	result, err := h.boardService.ShortestPath(boardID, userID, fromID, toID, query)
+	if err != nil {
+		analysisError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// Centrality godoc
+// @Summary Item centrality scores
+// @Description Score every item by degree (default), betweenness or eigenvector centrality, highest first
+// @Tags analysis
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Board ID"
+// @Param relationship_type query []string false "Only use connections of these relationship types" collectionFormat(multi)
+// @Param metric query string false "Centrality metric (degree, betweenness, eigenvector)"
+// @Success 200 {object} service.CentralityResult
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /boards/{id}/analysis/centrality [get]
+func (h *BoardHandler) Centrality(c *gin.Context) {
+	userID, boardID, query, ok := analysisParams(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.boardService.Centrality(boardID, userID, service.CentralityMetric(c.Query("metric")), query)
+	if err != nil {
+		analysisError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// ConnectedComponents godoc
+// @Summary Connected components
+// @Description Group items that are linked by any chain of connections, largest group first
+// @Tags analysis
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Board ID"
+// @Param relationship_type query []string false "Only use connections of these relationship types" collectionFormat(multi)
+// @Success 200 {object} service.ComponentsResult
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /boards/{id}/analysis/components [get]
+func (h *BoardHandler) ConnectedComponents(c *gin.Context) {
+	userID, boardID, query, ok := analysisParams(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.boardService.ConnectedComponents(boardID, userID, query)
+	if err != nil {
+		analysisError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// Communities godoc
+// @Summary Community detection
+// @Description Cluster items into densely connected communities using the Louvain method
+// @Tags analysis
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Board ID"
+// @Param relationship_type query []string false "Only use connections of these relationship types" collectionFormat(multi)
+// @Success 200 {object} service.CommunitiesResult
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /boards/{id}/analysis/communities [get]
+func (h *BoardHandler) Communities(c *gin.Context) {
+	userID, boardID, query, ok := analysisParams(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.boardService.Communities(boardID, userID, query)
+	if err != nil {
+		analysisError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// IsolatedItems godoc
+// @Summary Isolated items
+// @Description List items that have no connections
+// @Tags analysis
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Board ID"
+// @Param relationship_type query []string false "Only use connections of these relationship types" collectionFormat(multi)
+// @Success 200 {object} service.IsolatedResult
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /boards/{id}/analysis/isolated [get]
+func (h *BoardHandler) IsolatedItems(c *gin.Context) {
+	userID, boardID, query, ok := analysisParams(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.boardService.IsolatedItems(boardID, userID, query)
+	if err != nil {
+		analysisError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
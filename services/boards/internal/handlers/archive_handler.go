@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"evidence-wall/boards-service/internal/service"
+	"evidence-wall/shared/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ExportBoard godoc
+// @Summary Export a board
+// @Description Export a board with its items, connections and styles as a versioned JSON archive (read permission required)
+// @Tags boards
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Board ID"
+// @Success 200 {object} service.BoardArchive
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /boards/{id}/export [get]
+func (h *BoardHandler) ExportBoard(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	boardID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid board ID"})
+		return
+	}
+
+	archive, err := h.boardService.ExportBoard(boardID, userID)
+	if err != nil {
+		switch err {
+		case service.ErrBoardNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export board"})
+		}
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="board-%s.json"`, boardID))
+	c.JSON(http.StatusOK, archive)
+}
+
+// ImportBoard godoc
+// @Summary Import a board
+// @Description Validate a board archive and create it as a new board owned by the current user. Item and connection IDs are remapped.
+// @Tags boards
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param title query string false "Override the archived board title"
+// @Param visibility query string false "Override the archived visibility (private, shared, public)"
+// @Param archive body service.BoardArchive true "Board archive"
+// @Success 201 {object} models.Board
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /boards/import [post]
+func (h *BoardHandler) ImportBoard(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var opts service.ImportBoardOptions
+	if err := c.ShouldBindQuery(&opts); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var archive service.BoardArchive
+	if err := c.ShouldBindJSON(&archive); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	board, err := h.boardService.ImportBoard(userID, &archive, opts)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidArchive) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import board"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, board)
+}
+
+// BoardArchiveSchema godoc
+// @Summary Board archive JSON Schema
+// @Description The JSON Schema describing board export documents
+// @Tags boards
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /public/schemas/board-archive.json [get]
+func (h *BoardHandler) BoardArchiveSchema(c *gin.Context) {
+	c.Data(http.StatusOK, "application/schema+json", service.BoardArchiveSchema)
+}
+
+// ExportCanvas godoc
+// @Summary Export a board as JSON Canvas
+// @Description Export a board as an Obsidian-compatible JSON Canvas (.canvas) file. Items become text nodes and connections become edges (read permission required).
+// @Tags boards
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Board ID"
+// @Success 200 {object} service.Canvas
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /boards/{id}/export/canvas [get]
+func (h *BoardHandler) ExportCanvas(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	boardID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid board ID"})
+		return
+	}
+
+	canvas, err := h.boardService.ExportCanvas(boardID, userID)
+	if err != nil {
+		switch err {
+		case service.ErrBoardNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export board"})
+		}
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="board-%s.canvas"`, boardID))
+	c.JSON(http.StatusOK, canvas)
+}
+
+// ImportCanvas godoc
+// @Summary Import a JSON Canvas file
+// @Description Create a new board owned by the current user from an Obsidian JSON Canvas (.canvas) document. Text, file and link nodes become items; group nodes are skipped.
+// @Tags boards
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param title query string false "Board title (default \"Imported canvas\")"
+// @Param visibility query string false "Board visibility (private, shared, public; default private)"
+// @Param canvas body service.Canvas true "JSON Canvas document"
+// @Success 201 {object} models.Board
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /boards/import/canvas [post]
+func (h *BoardHandler) ImportCanvas(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var opts service.ImportBoardOptions
+	if err := c.ShouldBindQuery(&opts); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var canvas service.Canvas
+	if err := c.ShouldBindJSON(&canvas); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	board, err := h.boardService.ImportCanvas(userID, &canvas, opts)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidArchive) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import canvas"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, board)
+}
+
+// graphContentTypes maps graph export formats to content type and file extension
+var graphContentTypes = map[service.GraphFormat][2]string{
+	service.GraphFormatGraphML: {"application/graphml+xml", "graphml"},
+	service.GraphFormatGEXF:    {"application/gexf+xml", "gexf"},
+	service.GraphFormatDOT:     {"text/vnd.graphviz", "dot"},
+}
+
+// ExportGraph godoc
+// @Summary Export a board as a graph
+// @Description Export a board's items as nodes and connections as edges in GraphML, GEXF (Gephi) or Graphviz DOT. Item type, content, position and style metadata become node attributes; connection style becomes edge attributes.
+// @Tags boards
+// @Produce xml
+// @Produce plain
+// @Security BearerAuth
+// @Param id path string true "Board ID"
+// @Param format query string true "Graph format (graphml, gexf, dot)"
+// @Success 200 {string} string
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /boards/{id}/export/graph [get]
+func (h *BoardHandler) ExportGraph(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	boardID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid board ID"})
+		return
+	}
+
+	format := service.GraphFormat(c.Query("format"))
+	data, err := h.boardService.ExportGraph(boardID, userID, format)
+	if err != nil {
+		switch err {
+		case service.ErrUnsupportedFormat:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported format, expected graphml, gexf or dot"})
+		case service.ErrBoardNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export board"})
+		}
+		return
+	}
+
+	contentType := graphContentTypes[format]
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="board-%s.%s"`, boardID, contentType[1]))
+	c.Data(http.StatusOK, contentType[0], data)
+}
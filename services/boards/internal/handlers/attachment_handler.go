@@ -0,0 +1,441 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"evidence-wall/boards-service/internal/service"
+	"evidence-wall/shared/middleware"
+	"evidence-wall/shared/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// multipartOverhead is the room left for multipart headers and boundaries on
+// top of the attachment size limit
+const multipartOverhead = 1 << 20
+
+// AttachmentServiceInterface defines the interface for attachment service operations
+type AttachmentServiceInterface interface {
+	Limits() service.AttachmentLimits
+	UploadAttachment(ctx context.Context, boardID, itemID, userID uuid.UUID, req service.UploadAttachmentRequest) (*models.Attachment, error)
+	ListAttachments(boardID, itemID, userID uuid.UUID) ([]models.Attachment, error)
+	OpenAttachment(ctx context.Context, boardID, itemID, attachmentID, userID uuid.UUID, access service.AttachmentAccess) (*models.Attachment, io.ReadCloser, error)
+	OpenThumbnail(ctx context.Context, boardID, itemID, attachmentID, userID uuid.UUID, size int, ifNoneMatch string) (*service.Thumbnail, error)
+	DeleteAttachment(ctx context.Context, boardID, itemID, attachmentID, userID uuid.UUID, clientIP string) error
+	VerifyAttachments(ctx context.Context, boardID, userID uuid.UUID, clientIP string) (*service.IntegrityReport, error)
+	GetCustodyLog(boardID, itemID, userID uuid.UUID) (*service.CustodyLog, error)
+}
+
+// AttachmentHandler handles item attachment HTTP requests
+type AttachmentHandler struct {
+	attachmentService AttachmentServiceInterface
+}
+
+// NewAttachmentHandler creates a new attachment handler
+func NewAttachmentHandler(attachmentService AttachmentServiceInterface) *AttachmentHandler {
+	return &AttachmentHandler{
+		attachmentService: attachmentService,
+	}
+}
+
+// UploadAttachment godoc
+// @Summary Upload an attachment
+// @Description Attach a file (photo, PDF, text, audio or video) to an item. The type is detected from the content.
+// @Tags attachments
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Board ID"
+// @Param itemId path string true "Item ID"
+// @Param file formData file true "File to attach"
+// @Success 201 {object} models.Attachment
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 413 {object} map[string]interface{}
+// @Failure 415 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /boards/{id}/items/{itemId}/attachments [post]
+func (h *AttachmentHandler) UploadAttachment(c *gin.Context) {
+	userID, boardID, itemID, ok := itemParams(c)
+	if !ok {
+		return
+	}
+
+	maxSize := h.attachmentService.Limits().MaxSize
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+multipartOverhead)
+	header, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Attachment too large", "max_size": maxSize})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A file is required in the \"file\" form field"})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded file"})
+		return
+	}
+	defer file.Close()
+
+	attachment, err := h.attachmentService.UploadAttachment(c.Request.Context(), boardID, itemID, userID, service.UploadAttachmentRequest{
+		Filename: header.Filename,
+		Size:     header.Size,
+		Content:  file,
+		ClientIP: c.ClientIP(),
+	})
+	if err != nil {
+		switch {
+		case err == service.ErrBoardNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
+		case err == service.ErrItemNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+		case err == service.ErrLayerLocked:
+			c.JSON(http.StatusLocked, gin.H{"error": "Layer is locked"})
+		case err == service.ErrUnauthorized:
+			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+		case errors.Is(err, service.ErrAttachmentTooLarge), errors.Is(err, service.ErrQuotaExceeded):
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrUnsupportedMediaType):
+			c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrInvalidInput):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload attachment"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, attachment)
+}
+
+// ListAttachments godoc
+// @Summary List attachments
+// @Description List the files attached to an item
+// @Tags attachments
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Board ID"
+// @Param itemId path string true "Item ID"
+// @Success 200 {array} models.Attachment
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /boards/{id}/items/{itemId}/attachments [get]
+func (h *AttachmentHandler) ListAttachments(c *gin.Context) {
+	userID, boardID, itemID, ok := itemParams(c)
+	if !ok {
+		return
+	}
+
+	attachments, err := h.attachmentService.ListAttachments(boardID, itemID, userID)
+	if err != nil {
+		respondAttachmentError(c, err, "Failed to list attachments")
+		return
+	}
+
+	c.JSON(http.StatusOK, attachments)
+}
+
+// DownloadAttachment godoc
+// @Summary Download an attachment
+// @Description Download the content of an attachment. Every download and view is recorded in the item's custody log.
+// @Tags attachments
+// @Produce application/octet-stream
+// @Security BearerAuth
+// @Param id path string true "Board ID"
+// @Param itemId path string true "Item ID"
+// @Param attachmentId path string true "Attachment ID"
+// @Param inline query bool false "Display in the browser instead of downloading"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /boards/{id}/items/{itemId}/attachments/{attachmentId} [get]
+func (h *AttachmentHandler) DownloadAttachment(c *gin.Context) {
+	userID, boardID, itemID, ok := itemParams(c)
+	if !ok {
+		return
+	}
+	attachmentID, err := uuid.Parse(c.Param("attachmentId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attachment ID"})
+		return
+	}
+
+	inline, _ := strconv.ParseBool(c.Query("inline"))
+	access := service.AttachmentAccess{Inline: inline, ClientIP: c.ClientIP()}
+	attachment, content, err := h.attachmentService.OpenAttachment(c.Request.Context(), boardID, itemID, attachmentID, userID, access)
+	if err != nil {
+		respondAttachmentError(c, err, "Failed to open attachment")
+		return
+	}
+	defer content.Close()
+
+	disposition := "attachment"
+	if inline {
+		disposition = "inline"
+	}
+	headers := map[string]string{
+		"Content-Disposition":    mime.FormatMediaType(disposition, map[string]string{"filename": attachment.Filename}),
+		"X-Content-Type-Options": "nosniff",
+		"Cache-Control":          "private, no-cache",
+	}
+	if digest := digestHeader(attachment.SHA256); digest != "" {
+		// Lets the recipient check the copy against the digest taken on upload
+		headers["Digest"] = "sha-256=" + digest
+	}
+	c.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, content, headers)
+}
+
+// GetThumbnail godoc
+// @Summary Get an attachment thumbnail
+// @Description Get a JPEG preview of an attached photo, upright and without EXIF data unless the server preserves it. The smallest thumbnail at least size pixels on its longest side is returned. Thumbnails never change and may be cached.
+// @Tags attachments
+// @Produce image/jpeg
+// @Security BearerAuth
+// @Param id path string true "Board ID"
+// @Param itemId path string true "Item ID"
+// @Param attachmentId path string true "Attachment ID"
+// @Param size query int false "Longest side in pixels (default 512)"
+// @Success 200 {file} file
+// @Success 304
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Failure 503 {object} map[string]interface{}
+// @Router /boards/{id}/items/{itemId}/attachments/{attachmentId}/thumbnail [get]
+func (h *AttachmentHandler) GetThumbnail(c *gin.Context) {
+	userID, boardID, itemID, ok := itemParams(c)
+	if !ok {
+		return
+	}
+	attachmentID, err := uuid.Parse(c.Param("attachmentId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attachment ID"})
+		return
+	}
+	size := 0
+	if raw := c.Query("size"); raw != "" {
+		if size, err = strconv.Atoi(raw); err != nil || size <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid size"})
+			return
+		}
+	}
+
+	thumbnail, err := h.attachmentService.OpenThumbnail(c.Request.Context(), boardID, itemID, attachmentID, userID, size, c.GetHeader("If-None-Match"))
+	if err != nil {
+		switch {
+		case err == service.ErrPreviewPending:
+			c.Header("Retry-After", "5")
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Thumbnail is still being generated"})
+		case err == service.ErrPreviewUnavailable:
+			c.JSON(http.StatusNotFound, gin.H{"error": "No thumbnail for this attachment"})
+		case errors.Is(err, service.ErrInvalidInput):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			respondAttachmentError(c, err, "Failed to open thumbnail")
+		}
+		return
+	}
+
+	// Each size of a thumbnail is made once, so its URL can be cached for good
+	c.Header("Cache-Control", "private, max-age=31536000, immutable")
+	c.Header("ETag", thumbnail.ETag)
+	if thumbnail.NotModified {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	defer thumbnail.Content.Close()
+	c.DataFromReader(http.StatusOK, -1, "image/jpeg", thumbnail.Content, map[string]string{
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+// DeleteAttachment godoc
+// @Summary Delete an attachment
+// @Description Remove a file from an item
+// @Tags attachments
+// @Security BearerAuth
+// @Param id path string true "Board ID"
+// @Param itemId path string true "Item ID"
+// @Param attachmentId path string true "Attachment ID"
+// @Success 204
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /boards/{id}/items/{itemId}/attachments/{attachmentId} [delete]
+func (h *AttachmentHandler) DeleteAttachment(c *gin.Context) {
+	userID, boardID, itemID, ok := itemParams(c)
+	if !ok {
+		return
+	}
+	attachmentID, err := uuid.Parse(c.Param("attachmentId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attachment ID"})
+		return
+	}
+
+	if err := h.attachmentService.DeleteAttachment(c.Request.Context(), boardID, itemID, attachmentID, userID, c.ClientIP()); err != nil {
+		respondAttachmentError(c, err, "Failed to delete attachment")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// VerifyAttachments godoc
+// @Summary Verify stored evidence
+// @Description Re-hash every attachment of the board and compare it with the SHA-256 digest taken on upload. Each check is recorded in the custody log.
+// @Tags attachments
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Board ID"
+// @Success 200 {object} service.IntegrityReport
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /boards/{id}/attachments/verify [post]
+func (h *AttachmentHandler) VerifyAttachments(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	boardID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid board ID"})
+		return
+	}
+
+	report, err := h.attachmentService.VerifyAttachments(c.Request.Context(), boardID, userID, c.ClientIP())
+	if err != nil {
+		respondAttachmentError(c, err, "Failed to verify attachments")
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// ExportCustodyLog godoc
+// @Summary Export an item's custody log
+// @Description Download the chain of custody of an item's evidence: uploads with their SHA-256 digests, downloads, views, verifications and deletions, linked by hash
+// @Tags attachments
+// @Produce json
+// @Produce text/csv
+// @Security BearerAuth
+// @Param id path string true "Board ID"
+// @Param itemId path string true "Item ID"
+// @Param format query string false "json (default) or csv"
+// @Success 200 {object} service.CustodyLog
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /boards/{id}/items/{itemId}/custody [get]
+func (h *AttachmentHandler) ExportCustodyLog(c *gin.Context) {
+	userID, boardID, itemID, ok := itemParams(c)
+	if !ok {
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported format, use json or csv"})
+		return
+	}
+
+	custody, err := h.attachmentService.GetCustodyLog(boardID, itemID, userID)
+	if err != nil {
+		respondAttachmentError(c, err, "Failed to export custody log")
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="item-%s-custody.%s"`, itemID, format))
+	if format == "csv" {
+		var buf bytes.Buffer
+		if err := service.WriteCustodyCSV(&buf, custody); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export custody log"})
+			return
+		}
+		c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+		return
+	}
+	c.IndentedJSON(http.StatusOK, custody)
+}
+
+// digestHeader converts a hex SHA-256 digest to the base64 form of the Digest header
+func digestHeader(hexDigest string) string {
+	sum, err := hex.DecodeString(hexDigest)
+	if err != nil || len(sum) == 0 {
+		return ""
+	}
+	return base64.StdEncoding.EncodeToString(sum)
+}
+
+// itemParams reads the user and the board and item IDs, writing the error
+// response when one is missing or malformed
+func itemParams(c *gin.Context) (userID, boardID, itemID uuid.UUID, ok bool) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	boardID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid board ID"})
+		return
+	}
+
+	itemID, err = uuid.Parse(c.Param("itemId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid item ID"})
+		return
+	}
+
+	return userID, boardID, itemID, true
+}
+
+func respondAttachmentError(c *gin.Context, err error, fallback string) {
+	switch err {
+	case service.ErrBoardNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
+	case service.ErrItemNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+	case service.ErrLayerLocked:
+		c.JSON(http.StatusLocked, gin.H{"error": "Layer is locked"})
+	case service.ErrAttachmentNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+	case service.ErrUnauthorized:
+		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
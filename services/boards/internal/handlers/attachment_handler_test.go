@@ -0,0 +1,400 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"evidence-wall/boards-service/internal/service"
+	"evidence-wall/shared/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockAttachmentService is a mock implementation of AttachmentServiceInterface
+type MockAttachmentService struct {
+	mock.Mock
+}
+
+func (m *MockAttachmentService) Limits() service.AttachmentLimits {
+	args := m.Called()
+	return args.Get(0).(service.AttachmentLimits)
+}
+
+func (m *MockAttachmentService) UploadAttachment(ctx context.Context, boardID, itemID, userID uuid.UUID, req service.UploadAttachmentRequest) (*models.Attachment, error) {
+	args := m.Called(boardID, itemID, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Attachment), args.Error(1)
+}
+
+func (m *MockAttachmentService) ListAttachments(boardID, itemID, userID uuid.UUID) ([]models.Attachment, error) {
+	args := m.Called(boardID, itemID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Attachment), args.Error(1)
+}
+
+func (m *MockAttachmentService) OpenAttachment(ctx context.Context, boardID, itemID, attachmentID, userID uuid.UUID, access service.AttachmentAccess) (*models.Attachment, io.ReadCloser, error) {
+	args := m.Called(boardID, itemID, attachmentID, userID, access)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).(*models.Attachment), args.Get(1).(io.ReadCloser), args.Error(2)
+}
+
+func (m *MockAttachmentService) OpenThumbnail(ctx context.Context, boardID, itemID, attachmentID, userID uuid.UUID, size int, ifNoneMatch string) (*service.Thumbnail, error) {
+	args := m.Called(boardID, itemID, attachmentID, userID, size, ifNoneMatch)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.Thumbnail), args.Error(1)
+}
+
+func (m *MockAttachmentService) DeleteAttachment(ctx context.Context, boardID, itemID, attachmentID, userID uuid.UUID, clientIP string) error {
+	args := m.Called(boardID, itemID, attachmentID, userID)
+	return args.Error(0)
+}
+
+func (m *MockAttachmentService) VerifyAttachments(ctx context.Context, boardID, userID uuid.UUID, clientIP string) (*service.IntegrityReport, error) {
+	args := m.Called(boardID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.IntegrityReport), args.Error(1)
+}
+
+func (m *MockAttachmentService) GetCustodyLog(boardID, itemID, userID uuid.UUID) (*service.CustodyLog, error) {
+	args := m.Called(boardID, itemID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.CustodyLog), args.Error(1)
+}
+
+func setupAttachmentRouter(m *MockAttachmentService, userID uuid.UUID) *gin.Engine {
+	router := setupTestRouter()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", userID)
+	})
+	handler := NewAttachmentHandler(m)
+	attachments := router.Group("/boards/:id/items/:itemId/attachments")
+	attachments.POST("", handler.UploadAttachment)
+	attachments.GET("", handler.ListAttachments)
+	attachments.GET("/:attachmentId", handler.DownloadAttachment)
+	attachments.GET("/:attachmentId/thumbnail", handler.GetThumbnail)
+	attachments.DELETE("/:attachmentId", handler.DeleteAttachment)
+	router.GET("/boards/:id/items/:itemId/custody", handler.ExportCustodyLog)
+	router.POST("/boards/:id/attachments/verify", handler.VerifyAttachments)
+	return router
+}
+
+func multipartBody(t *testing.T, field, filename string, content []byte) (*bytes.Buffer, string) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile(field, filename)
+	assert.NoError(t, err)
+	part.Write(content)
+	assert.NoError(t, writer.Close())
+	return &body, writer.FormDataContentType()
+}
+
+func TestAttachmentHandler_UploadAttachment(t *testing.T) {
+	userID := uuid.New()
+	boardID := uuid.New()
+	itemID := uuid.New()
+	limits := service.AttachmentLimits{MaxSize: 1 << 10, BoardQuota: 1 << 20}
+
+	tests := []struct {
+		name           string
+		field          string
+		content        []byte
+		expectedStatus int
+		expectedError  string
+		mockSetup      func(*MockAttachmentService)
+	}{
+		{
+			name:           "successful upload",
+			field:          "file",
+			content:        []byte("notes"),
+			expectedStatus: http.StatusCreated,
+			mockSetup: func(m *MockAttachmentService) {
+				m.On("UploadAttachment", boardID, itemID, userID, mock.MatchedBy(func(req service.UploadAttachmentRequest) bool {
+					data, _ := io.ReadAll(req.Content)
+					return req.Filename == "notes.txt" && req.Size == 5 && string(data) == "notes"
+				})).Return(&models.Attachment{ID: uuid.New(), Filename: "notes.txt"}, nil)
+			},
+		},
+		{
+			name:           "missing file field",
+			field:          "other",
+			content:        []byte("notes"),
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "file is required",
+			mockSetup:      func(m *MockAttachmentService) {},
+		},
+		{
+			name:           "body over the limit",
+			field:          "file",
+			content:        bytes.Repeat([]byte("a"), 2<<20),
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedError:  "Attachment too large",
+			mockSetup:      func(m *MockAttachmentService) {},
+		},
+		{
+			name:           "quota exceeded",
+			field:          "file",
+			content:        []byte("notes"),
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedError:  "quota exceeded",
+			mockSetup: func(m *MockAttachmentService) {
+				m.On("UploadAttachment", boardID, itemID, userID, mock.Anything).Return(nil, fmt.Errorf("%w (1 of 1 bytes used)", service.ErrQuotaExceeded))
+			},
+		},
+		{
+			name:           "unsupported type",
+			field:          "file",
+			content:        []byte("MZ"),
+			expectedStatus: http.StatusUnsupportedMediaType,
+			expectedError:  "unsupported attachment type",
+			mockSetup: func(m *MockAttachmentService) {
+				m.On("UploadAttachment", boardID, itemID, userID, mock.Anything).Return(nil, fmt.Errorf("%w: application/octet-stream", service.ErrUnsupportedMediaType))
+			},
+		},
+		{
+			name:           "read-only user",
+			field:          "file",
+			content:        []byte("notes"),
+			expectedStatus: http.StatusForbidden,
+			expectedError:  "Insufficient permissions",
+			mockSetup: func(m *MockAttachmentService) {
+				m.On("UploadAttachment", boardID, itemID, userID, mock.Anything).Return(nil, service.ErrUnauthorized)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockAttachmentService)
+			mockService.On("Limits").Return(limits)
+			tt.mockSetup(mockService)
+			router := setupAttachmentRouter(mockService, userID)
+
+			body, contentType := multipartBody(t, tt.field, "notes.txt", tt.content)
+			req := httptest.NewRequest("POST", fmt.Sprintf("/boards/%s/items/%s/attachments", boardID, itemID), body)
+			req.Header.Set("Content-Type", contentType)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedError != "" {
+				var response map[string]interface{}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Contains(t, response["error"].(string), tt.expectedError)
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAttachmentHandler_DownloadAttachment(t *testing.T) {
+	userID := uuid.New()
+	boardID := uuid.New()
+	itemID := uuid.New()
+	attachment := &models.Attachment{ID: uuid.New(), BoardID: boardID, ItemID: itemID,
+		Filename: "crime scene.png", ContentType: "image/png", Size: 4,
+		SHA256: "f3fa8e7a49a5dbf0b4d1e1b4d4f1a7d1bd1b2a3c4d5e6f708192a3b4c5d6e7f8"}
+
+	mockService := new(MockAttachmentService)
+	mockService.On("OpenAttachment", boardID, itemID, attachment.ID, userID, mock.MatchedBy(func(a service.AttachmentAccess) bool { return !a.Inline })).
+		Return(attachment, io.NopCloser(strings.NewReader("\x89PNG")), nil).Once()
+	mockService.On("OpenAttachment", boardID, itemID, attachment.ID, userID, mock.MatchedBy(func(a service.AttachmentAccess) bool { return a.Inline })).
+		Return(attachment, io.NopCloser(strings.NewReader("\x89PNG")), nil).Once()
+	missingID := uuid.New()
+	mockService.On("OpenAttachment", boardID, itemID, missingID, userID, mock.Anything).Return(nil, nil, service.ErrAttachmentNotFound)
+	router := setupAttachmentRouter(mockService, userID)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", fmt.Sprintf("/boards/%s/items/%s/attachments/%s", boardID, itemID, attachment.ID), nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "\x89PNG", w.Body.String())
+	assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="crime scene.png"`, w.Header().Get("Content-Disposition"))
+	assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+	assert.Equal(t, "sha-256=8/qOekml2/C00eG01PGn0b0bKjxNXm9wgZKjtMXW5/g=", w.Header().Get("Digest"))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", fmt.Sprintf("/boards/%s/items/%s/attachments/%s?inline=true", boardID, itemID, attachment.ID), nil))
+	assert.Equal(t, `inline; filename="crime scene.png"`, w.Header().Get("Content-Disposition"))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", fmt.Sprintf("/boards/%s/items/%s/attachments/%s", boardID, itemID, missingID), nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", fmt.Sprintf("/boards/%s/items/%s/attachments/nope", boardID, itemID), nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestAttachmentHandler_GetThumbnail(t *testing.T) {
+	userID := uuid.New()
+	boardID := uuid.New()
+	itemID := uuid.New()
+	attachmentID := uuid.New()
+	etag := fmt.Sprintf(`"%s-512"`, attachmentID)
+	pendingID := uuid.New()
+	documentID := uuid.New()
+
+	mockService := new(MockAttachmentService)
+	mockService.On("OpenThumbnail", boardID, itemID, attachmentID, userID, 0, "").
+		Return(&service.Thumbnail{Size: 512, ETag: etag, Content: io.NopCloser(strings.NewReader("\xff\xd8jpeg"))}, nil)
+	mockService.On("OpenThumbnail", boardID, itemID, attachmentID, userID, 400, etag).
+		Return(&service.Thumbnail{Size: 512, ETag: etag, NotModified: true}, nil)
+	mockService.On("OpenThumbnail", boardID, itemID, pendingID, userID, 0, "").Return(nil, service.ErrPreviewPending)
+	mockService.On("OpenThumbnail", boardID, itemID, documentID, userID, 0, "").Return(nil, service.ErrPreviewUnavailable)
+	router := setupAttachmentRouter(mockService, userID)
+	url := func(id uuid.UUID, query string) string {
+		return fmt.Sprintf("/boards/%s/items/%s/attachments/%s/thumbnail%s", boardID, itemID, id, query)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", url(attachmentID, ""), nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "\xff\xd8jpeg", w.Body.String())
+	assert.Equal(t, "image/jpeg", w.Header().Get("Content-Type"))
+	assert.Equal(t, etag, w.Header().Get("ETag"))
+	assert.Equal(t, "private, max-age=31536000, immutable", w.Header().Get("Cache-Control"))
+
+	req := httptest.NewRequest("GET", url(attachmentID, "?size=400"), nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", url(pendingID, ""), nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", url(documentID, ""), nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", url(attachmentID, "?size=big"), nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestAttachmentHandler_ListAndDelete(t *testing.T) {
+	userID := uuid.New()
+	boardID := uuid.New()
+	itemID := uuid.New()
+	attachmentID := uuid.New()
+
+	mockService := new(MockAttachmentService)
+	mockService.On("ListAttachments", boardID, itemID, userID).Return([]models.Attachment{{ID: attachmentID}}, nil)
+	mockService.On("DeleteAttachment", boardID, itemID, attachmentID, userID).Return(nil)
+	otherItem := uuid.New()
+	mockService.On("ListAttachments", boardID, otherItem, userID).Return(nil, errors.New("db down"))
+	router := setupAttachmentRouter(mockService, userID)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", fmt.Sprintf("/boards/%s/items/%s/attachments", boardID, itemID), nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var list []models.Attachment
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Len(t, list, 1)
+	assert.NotContains(t, w.Body.String(), "storage_key")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", fmt.Sprintf("/boards/%s/items/%s/attachments", boardID, otherItem), nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("DELETE", fmt.Sprintf("/boards/%s/items/%s/attachments/%s", boardID, itemID, attachmentID), nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestAttachmentHandler_VerifyAttachments(t *testing.T) {
+	userID := uuid.New()
+	boardID := uuid.New()
+
+	mockService := new(MockAttachmentService)
+	mockService.On("VerifyAttachments", boardID, userID).Return(&service.IntegrityReport{
+		BoardID: boardID,
+		Intact:  false,
+		Counts:  map[string]int{service.IntegrityMismatch: 1},
+		Results: []service.IntegrityResult{{Status: service.IntegrityMismatch, Expected: "aa", Actual: "bb"}},
+	}, nil)
+	readOnlyBoard := uuid.New()
+	mockService.On("VerifyAttachments", readOnlyBoard, userID).Return(nil, service.ErrUnauthorized)
+	router := setupAttachmentRouter(mockService, userID)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", fmt.Sprintf("/boards/%s/attachments/verify", boardID), nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var report map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.Equal(t, false, report["intact"])
+	results := report["results"].([]interface{})
+	assert.Equal(t, "mismatch", results[0].(map[string]interface{})["status"])
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", fmt.Sprintf("/boards/%s/attachments/verify", readOnlyBoard), nil))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestAttachmentHandler_ExportCustodyLog(t *testing.T) {
+	userID := uuid.New()
+	boardID := uuid.New()
+	itemID := uuid.New()
+	custody := &service.CustodyLog{
+		Item:        service.CustodyItem{ID: itemID, BoardID: boardID, CreatedBy: userID},
+		ChainIntact: true,
+		Events: []models.CustodyEvent{
+			{Seq: 1, ItemID: itemID, Action: models.CustodyUploaded, UserID: userID, SHA256: "aa", Hash: "h1"},
+			{Seq: 2, ItemID: itemID, Action: models.CustodyDownloaded, UserID: userID, SHA256: "aa", PrevHash: "h1", Hash: "h2"},
+		},
+	}
+	mockService := new(MockAttachmentService)
+	mockService.On("GetCustodyLog", boardID, itemID, userID).Return(custody, nil)
+	router := setupAttachmentRouter(mockService, userID)
+	url := fmt.Sprintf("/boards/%s/items/%s/custody", boardID, itemID)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", url, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, fmt.Sprintf(`attachment; filename="item-%s-custody.json"`, itemID), w.Header().Get("Content-Disposition"))
+	var exported service.CustodyLog
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &exported))
+	assert.True(t, exported.ChainIntact)
+	assert.Len(t, exported.Events, 2)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", url+"?format=csv", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/csv")
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	assert.Len(t, lines, 3)
+	assert.Contains(t, lines[2], "downloaded")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", url+"?format=xml", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -1,9 +1,12 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
+	"evidence-wall/boards-service/internal/geo"
+	"evidence-wall/boards-service/internal/itemtype"
 	"evidence-wall/boards-service/internal/service"
 	"evidence-wall/shared/middleware"
 	"evidence-wall/shared/models"
@@ -18,6 +21,7 @@ type BoardServiceInterface interface {
 	GetBoard(boardID, userID uuid.UUID) (*models.BoardResponse, error)
 	GetPublicBoard(boardID uuid.UUID) (*models.Board, error)
 	ListBoards(userID uuid.UUID, offset, limit int) ([]models.BoardResponse, int64, error)
+	ListTaggedBoards(userID uuid.UUID, tags []string, offset, limit int) ([]models.BoardResponse, int64, error)
 	UpdateBoard(boardID, userID uuid.UUID, req service.UpdateBoardRequest) (*models.Board, error)
 	DeleteBoard(boardID, userID uuid.UUID) error
 	ShareBoard(boardID, ownerID uuid.UUID, req service.ShareBoardRequest) error
@@ -25,12 +29,52 @@ type BoardServiceInterface interface {
 	UpdateUserPermission(boardID, ownerID, targetUserID uuid.UUID, req service.UpdateUserPermissionRequest) error
 	CreateBoardItem(boardID, userID uuid.UUID, req service.CreateItemRequest) (*models.BoardItem, error)
 	UpdateBoardItem(boardID, itemID, userID uuid.UUID, req service.UpdateItemRequest) (*models.BoardItem, error)
-	DeleteBoardItem(boardID, itemID, userID uuid.UUID) error
-	ListBoardItems(boardID, userID uuid.UUID) ([]models.BoardItem, error)
-	ListBoardConnections(boardID, userID uuid.UUID) ([]models.BoardConnection, error)
+	DeleteBoardItem(boardID, itemID, userID uuid.UUID, deleteChildren bool) error
+	SetFrameMembers(boardID, frameID, userID uuid.UUID, req service.FrameMembersRequest) ([]models.BoardItem, error)
+	ListBoardItems(boardID, userID uuid.UUID, query service.ItemQuery) ([]models.BoardItem, error)
+	ListViewport(boardID, userID uuid.UUID, query service.ItemQuery) (*service.Viewport, error)
+	ListBoardConnections(boardID, userID uuid.UUID, query service.ConnectionQuery) ([]models.BoardConnection, error)
 	CreateBoardConnection(boardID, userID uuid.UUID, req service.CreateConnectionRequest) (*models.BoardConnection, error)
 	UpdateBoardConnection(boardID, connectionID, userID uuid.UUID, req service.UpdateConnectionRequest) (*models.BoardConnection, error)
 	DeleteBoardConnection(boardID, connectionID, userID uuid.UUID) error
+	DuplicateBoard(boardID, userID uuid.UUID, req service.DuplicateBoardRequest) (*models.Board, error)
+	ExportBoard(boardID, userID uuid.UUID) (*service.BoardArchive, error)
+	ImportBoard(userID uuid.UUID, archive *service.BoardArchive, opts service.ImportBoardOptions) (*models.Board, error)
+	ExportCanvas(boardID, userID uuid.UUID) (*service.Canvas, error)
+	ImportCanvas(userID uuid.UUID, canvas *service.Canvas, opts service.ImportBoardOptions) (*models.Board, error)
+	ExportGraph(boardID, userID uuid.UUID, format service.GraphFormat) ([]byte, error)
+	RenderBoard(boardID, userID uuid.UUID, req service.RenderBoardRequest) ([]byte, error)
+	BoardReport(boardID, userID uuid.UUID) ([]byte, error)
+	ShortestPath(boardID, userID, fromID, toID uuid.UUID, query service.ConnectionQuery) (*service.PathResult, error)
+	Centrality(boardID, userID uuid.UUID, metric service.CentralityMetric, query service.ConnectionQuery) (*service.CentralityResult, error)
+	ConnectedComponents(boardID, userID uuid.UUID, query service.ConnectionQuery) (*service.ComponentsResult, error)
+	Communities(boardID, userID uuid.UUID, query service.ConnectionQuery) (*service.CommunitiesResult, error)
+	IsolatedItems(boardID, userID uuid.UUID, query service.ConnectionQuery) (*service.IsolatedResult, error)
+	LayoutBoard(boardID, userID uuid.UUID, req service.LayoutBoardRequest) (*service.LayoutResult, error)
+	GetTimeline(boardID, userID uuid.UUID, query service.TimelineQuery) (*service.Timeline, error)
+	TimelineICS(boardID, userID uuid.UUID, query service.TimelineQuery) ([]byte, error)
+	GetGeo(boardID, userID uuid.UUID) (*geo.FeatureCollection, error)
+	ExportKML(boardID, userID uuid.UUID) ([]byte, error)
+	ImportGeo(boardID, userID uuid.UUID, data []byte, opts service.GeoImportOptions) (*service.GeoImportResult, error)
+	ItemTypes() []*itemtype.Type
+	ListTemplates(userID uuid.UUID) ([]models.BoardTemplate, error)
+	GetTemplate(templateID, userID uuid.UUID) (*models.BoardTemplate, error)
+	PublishTemplate(userID uuid.UUID, req service.PublishTemplateRequest) (*models.BoardTemplate, error)
+	DeleteTemplate(templateID, userID uuid.UUID) error
+	ListTags(userID uuid.UUID) ([]models.Tag, error)
+	CreateTag(userID uuid.UUID, boardID *uuid.UUID, req service.CreateTagRequest) (*models.Tag, error)
+	ListBoardTags(boardID, userID uuid.UUID) (*service.BoardTags, error)
+	UpdateTag(tagID, userID uuid.UUID, req service.UpdateTagRequest) (*models.Tag, error)
+	DeleteTag(tagID, userID uuid.UUID) error
+	MergeTags(tagID, userID uuid.UUID, req service.MergeTagsRequest) (*models.Tag, error)
+	BulkTag(boardID, userID uuid.UUID, req service.BulkTagRequest) (*service.BoardTags, error)
+	ListLayers(boardID, userID uuid.UUID) ([]models.Layer, error)
+	CreateLayer(boardID, userID uuid.UUID, req service.CreateLayerRequest) (*models.Layer, error)
+	UpdateLayer(boardID, layerID, userID uuid.UUID, req service.UpdateLayerRequest) (*models.Layer, error)
+	DeleteLayer(boardID, layerID, userID uuid.UUID) error
+	MoveToLayer(boardID, userID uuid.UUID, req service.MoveToLayerRequest) (*service.MoveToLayerResult, error)
+	Undo(boardID, userID uuid.UUID) (*service.HistoryResult, error)
+	Redo(boardID, userID uuid.UUID) (*service.HistoryResult, error)
 }
 
 // BoardHandler handles board HTTP requests
@@ -73,7 +117,12 @@ func (h *BoardHandler) CreateBoard(c *gin.Context) {
 
 	board, err := h.boardService.CreateBoard(userID, req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create board"})
+		switch err {
+		case service.ErrTemplateNotFound:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Template not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create board"})
+		}
 		return
 	}
 
@@ -82,7 +131,7 @@ func (h *BoardHandler) CreateBoard(c *gin.Context) {
 
 // GetBoard godoc
 // @Summary Get a board by ID
-// @Description Get a board by ID with permission check
+// @Description Get a board by ID with permission check. Items classified above the user's clearance come back redacted. Items inside collapsed frames are left out and their connections summed up per frame in aggregated_connections.
 // @Tags boards
 // @Produce json
 // @Security BearerAuth
@@ -122,7 +171,7 @@ func (h *BoardHandler) GetBoard(c *gin.Context) {
 
 // GetPublicBoard godoc
 // @Summary Get a public board by ID
-// @Description Get a public board by ID (no authentication required)
+// @Description Get a public board by ID (no authentication required). Classified items come back redacted.
 // @Tags boards
 // @Produce json
 // @Param id path string true "Board ID"
@@ -162,9 +211,11 @@ func (h *BoardHandler) GetPublicBoard(c *gin.Context) {
 // @Security BearerAuth
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Items per page" default(20)
+// @Param tag query []string false "Only boards with any of these tag IDs"
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]interface{}
 // @Failure 401 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Router /boards [get]
 func (h *BoardHandler) ListBoards(c *gin.Context) {
@@ -186,6 +237,21 @@ func (h *BoardHandler) ListBoards(c *gin.Context) {
 
 	offset := (page - 1) * limit
 
+	if tags := c.QueryArray("tag"); len(tags) > 0 {
+		boards, total, err := h.boardService.ListTaggedBoards(userID, tags, offset, limit)
+		if err != nil {
+			respondTagError(c, err, "Failed to list boards")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"boards": boards,
+			"total":  total,
+			"page":   page,
+			"limit":  limit,
+		})
+		return
+	}
+
 	boards, total, err := h.boardService.ListBoards(userID, offset, limit)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list boards"})
@@ -202,7 +268,7 @@ func (h *BoardHandler) ListBoards(c *gin.Context) {
 
 // UpdateBoard godoc
 // @Summary Update a board
-// @Description Update a board (admin permission required)
+// @Description Update a board's details, custom field definitions or classification (admin permission required)
 // @Tags boards
 // @Accept json
 // @Produce json
@@ -236,10 +302,12 @@ func (h *BoardHandler) UpdateBoard(c *gin.Context) {
 
 	board, err := h.boardService.UpdateBoard(boardID, userID, req)
 	if err != nil {
-		switch err {
-		case service.ErrBoardNotFound:
+		switch {
+		case errors.Is(err, service.ErrInvalidInput), errors.Is(err, service.ErrInputTooLong):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case err == service.ErrBoardNotFound:
 			c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
-		case service.ErrUnauthorized:
+		case err == service.ErrUnauthorized:
 			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
 		default:
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update board"})
@@ -293,7 +361,7 @@ func (h *BoardHandler) DeleteBoard(c *gin.Context) {
 
 // ShareBoard godoc
 // @Summary Share a board with a user
-// @Description Share a board with a user (admin permission required)
+// @Description Share a board with a user (admin permission required), with the clearance that decides which classified items they see unredacted
 // @Tags boards
 // @Accept json
 // @Produce json
@@ -327,10 +395,12 @@ func (h *BoardHandler) ShareBoard(c *gin.Context) {
 
 	err = h.boardService.ShareBoard(boardID, userID, req)
 	if err != nil {
-		switch err {
-		case service.ErrBoardNotFound:
+		switch {
+		case errors.Is(err, service.ErrInvalidInput):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case err == service.ErrBoardNotFound:
 			c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
-		case service.ErrUnauthorized:
+		case err == service.ErrUnauthorized:
 			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
 		default:
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to share board"})
@@ -391,7 +461,7 @@ func (h *BoardHandler) UnshareBoard(c *gin.Context) {
 
 // UpdateUserPermission godoc
 // @Summary Update user permission for a board
-// @Description Update user permission for a board (admin permission required)
+// @Description Update user permission and clearance for a board (admin permission required)
 // @Tags boards
 // @Accept json
 // @Produce json
@@ -432,10 +502,12 @@ func (h *BoardHandler) UpdateUserPermission(c *gin.Context) {
 
 	err = h.boardService.UpdateUserPermission(boardID, userID, targetUserID, req)
 	if err != nil {
-		switch err {
-		case service.ErrBoardNotFound:
+		switch {
+		case errors.Is(err, service.ErrInvalidInput):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case err == service.ErrBoardNotFound:
 			c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
-		case service.ErrUnauthorized:
+		case err == service.ErrUnauthorized:
 			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
 		default:
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update permission"})
@@ -482,11 +554,19 @@ func (h *BoardHandler) CreateBoardItem(c *gin.Context) {
 
 	item, err := h.boardService.CreateBoardItem(boardID, userID, req)
 	if err != nil {
-		switch err {
-		case service.ErrBoardNotFound:
+		switch {
+		case err == service.ErrBoardNotFound:
 			c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
-		case service.ErrUnauthorized:
+		case err == service.ErrItemNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Frame not found"})
+		case err == service.ErrLayerNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Layer not found"})
+		case err == service.ErrLayerLocked:
+			c.JSON(http.StatusLocked, gin.H{"error": "Layer is locked"})
+		case err == service.ErrUnauthorized:
 			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+		case errors.Is(err, service.ErrInvalidInput), errors.Is(err, service.ErrInputTooLong):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		default:
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create item"})
 		}
@@ -498,11 +578,15 @@ func (h *BoardHandler) CreateBoardItem(c *gin.Context) {
 
 // ListBoardItems godoc
 // @Summary List board items
-// @Description List all items on a board
+// @Description List the items on a board, optionally filtered by type, tags and custom field values (custom[<key>]=<value>) and sorted by a custom field
 // @Tags items
 // @Produce json
 // @Security BearerAuth
 // @Param boardId path string true "Board ID"
+// @Param type query string false "Item types, comma-separated"
+// @Param tag query string false "Tag IDs, comma-separated; items with any of them"
+// @Param sort query string false "Custom field key to sort by, prefixed with - for descending order"
+// @Param bbox query string false "Only items overlapping minX,minY,maxX,maxY"
 // @Success 200 {array} models.BoardItem
 // @Failure 400 {object} map[string]interface{}
 // @Failure 401 {object} map[string]interface{}
@@ -522,22 +606,87 @@ func (h *BoardHandler) ListBoardItems(c *gin.Context) {
 		return
 	}
 
-	items, err := h.boardService.ListBoardItems(boardID, userID)
+	var query service.ItemQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if custom := c.QueryMap("custom"); len(custom) > 0 {
+		query.Custom = custom
+	}
+
+	items, err := h.boardService.ListBoardItems(boardID, userID, query)
 	if err != nil {
-		switch err {
-		case service.ErrBoardNotFound:
-			c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
-		case service.ErrUnauthorized:
-			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
-		default:
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list items"})
-		}
+		h.listItemsError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, items)
 }
 
+// ListViewport godoc
+// @Summary Get a board viewport
+// @Description Get the items overlapping a viewport, optionally filtered like the item list, along with every connection that has at least one end among them. Items inside collapsed frames are left out and their connections summed up per frame in aggregated_connections.
+// @Tags items
+// @Produce json
+// @Security BearerAuth
+// @Param boardId path string true "Board ID"
+// @Param bbox query string true "Viewport as minX,minY,maxX,maxY"
+// @Param type query string false "Item types, comma-separated"
+// @Param tag query string false "Tag IDs, comma-separated; items with any of them"
+// @Success 200 {object} service.Viewport
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /boards/{boardId}/viewport [get]
+func (h *BoardHandler) ListViewport(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	boardID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid board ID"})
+		return
+	}
+
+	var query service.ItemQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if custom := c.QueryMap("custom"); len(custom) > 0 {
+		query.Custom = custom
+	}
+
+	viewport, err := h.boardService.ListViewport(boardID, userID, query)
+	if err != nil {
+		h.listItemsError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, viewport)
+}
+
+// listItemsError responds with the status matching an error from listing items
+func (h *BoardHandler) listItemsError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidInput):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err == service.ErrTagNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tag not found"})
+	case err == service.ErrBoardNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
+	case err == service.ErrUnauthorized:
+		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list items"})
+	}
+}
+
 // UpdateBoardItem godoc
 // @Summary Update a board item
 // @Description Update a board item
@@ -581,13 +730,17 @@ func (h *BoardHandler) UpdateBoardItem(c *gin.Context) {
 
 	item, err := h.boardService.UpdateBoardItem(boardID, itemID, userID, req)
 	if err != nil {
-		switch err {
-		case service.ErrBoardNotFound:
+		switch {
+		case err == service.ErrBoardNotFound:
 			c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
-		case service.ErrItemNotFound:
+		case err == service.ErrItemNotFound:
 			c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
-		case service.ErrUnauthorized:
+		case err == service.ErrLayerLocked:
+			c.JSON(http.StatusLocked, gin.H{"error": "Layer is locked"})
+		case err == service.ErrUnauthorized:
 			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+		case errors.Is(err, service.ErrInvalidInput), errors.Is(err, service.ErrInputTooLong):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		default:
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update item"})
 		}
@@ -599,11 +752,12 @@ func (h *BoardHandler) UpdateBoardItem(c *gin.Context) {
 
 // DeleteBoardItem godoc
 // @Summary Delete a board item
-// @Description Delete a board item
+// @Description Delete a board item. A frame's contents are kept and moved onto the frame's parent unless children=delete.
 // @Tags items
 // @Security BearerAuth
 // @Param boardId path string true "Board ID"
 // @Param id path string true "Item ID"
+// @Param children query string false "What to do with a frame's contents" Enums(keep, delete)
 // @Success 204
 // @Failure 400 {object} map[string]interface{}
 // @Failure 401 {object} map[string]interface{}
@@ -630,13 +784,25 @@ func (h *BoardHandler) DeleteBoardItem(c *gin.Context) {
 		return
 	}
 
-	err = h.boardService.DeleteBoardItem(boardID, itemID, userID)
+	var deleteChildren bool
+	switch c.Query("children") {
+	case "", "keep":
+	case "delete":
+		deleteChildren = true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "children must be keep or delete"})
+		return
+	}
+
+	err = h.boardService.DeleteBoardItem(boardID, itemID, userID, deleteChildren)
 	if err != nil {
 		switch err {
 		case service.ErrBoardNotFound:
 			c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
 		case service.ErrItemNotFound:
 			c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+		case service.ErrLayerLocked:
+			c.JSON(http.StatusLocked, gin.H{"error": "Layer is locked"})
 		case service.ErrUnauthorized:
 			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
 		default:
@@ -662,12 +828,20 @@ func (h *BoardHandler) ListBoardConnections(c *gin.Context) {
 		return
 	}
 
-	connections, err := h.boardService.ListBoardConnections(boardID, userID)
+	var query service.ConnectionQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	connections, err := h.boardService.ListBoardConnections(boardID, userID, query)
 	if err != nil {
-		switch err {
-		case service.ErrBoardNotFound:
+		switch {
+		case errors.Is(err, service.ErrInvalidInput):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case err == service.ErrBoardNotFound:
 			c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
-		case service.ErrUnauthorized:
+		case err == service.ErrUnauthorized:
 			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
 		default:
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list connections"})
@@ -699,13 +873,19 @@ func (h *BoardHandler) CreateBoardConnection(c *gin.Context) {
 
 	conn, err := h.boardService.CreateBoardConnection(boardID, userID, req)
 	if err != nil {
-		switch err {
-		case service.ErrBoardNotFound:
+		switch {
+		case err == service.ErrBoardNotFound:
 			c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
-		case service.ErrUnauthorized:
+		case err == service.ErrLayerNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Layer not found"})
+		case err == service.ErrLayerLocked:
+			c.JSON(http.StatusLocked, gin.H{"error": "Layer is locked"})
+		case err == service.ErrUnauthorized:
 			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
-		case service.ErrInvalidInput:
+		case err == service.ErrInvalidInput:
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid connection input"})
+		case errors.Is(err, service.ErrInvalidInput), errors.Is(err, service.ErrInputTooLong):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		default:
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create connection"})
 		}
@@ -741,13 +921,17 @@ func (h *BoardHandler) UpdateBoardConnection(c *gin.Context) {
 
 	conn, err := h.boardService.UpdateBoardConnection(boardID, connectionID, userID, req)
 	if err != nil {
-		switch err {
-		case service.ErrBoardNotFound:
+		switch {
+		case err == service.ErrBoardNotFound:
 			c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
-		case service.ErrConnectionNotFound:
+		case err == service.ErrConnectionNotFound:
 			c.JSON(http.StatusNotFound, gin.H{"error": "Connection not found"})
-		case service.ErrUnauthorized:
+		case err == service.ErrLayerLocked:
+			c.JSON(http.StatusLocked, gin.H{"error": "Layer is locked"})
+		case err == service.ErrUnauthorized:
 			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+		case errors.Is(err, service.ErrInvalidInput), errors.Is(err, service.ErrInputTooLong):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		default:
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update connection"})
 		}
@@ -781,6 +965,8 @@ func (h *BoardHandler) DeleteBoardConnection(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
 		case service.ErrConnectionNotFound:
 			c.JSON(http.StatusNotFound, gin.H{"error": "Connection not found"})
+		case service.ErrLayerLocked:
+			c.JSON(http.StatusLocked, gin.H{"error": "Layer is locked"})
 		case service.ErrUnauthorized:
 			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
 		default:
@@ -791,3 +977,139 @@ func (h *BoardHandler) DeleteBoardConnection(c *gin.Context) {
 
 	c.Status(http.StatusNoContent)
 }
+
+// DuplicateBoard godoc
+// @Summary Duplicate or fork a board
+// @Description Deep-copy a board with its items and connections. Duplicates require write access; forks (fork=true) only need read access, record the parent board and default to private.
+// @Tags boards
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Board ID"
+// @Param request body service.DuplicateBoardRequest false "Duplication options"
+// @Success 201 {object} models.Board
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /boards/{id}/duplicate [post]
+func (h *BoardHandler) DuplicateBoard(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	boardID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid board ID"})
+		return
+	}
+
+	var req service.DuplicateBoardRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	board, err := h.boardService.DuplicateBoard(boardID, userID, req)
+	if err != nil {
+		switch err {
+		case service.ErrBoardNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
+		case service.ErrUnauthorized:
+			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to duplicate board"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, board)
+}
+
+// Undo godoc
+// @Summary Undo the last change
+// @Description Revert the current user's most recent change on a board. Fails with 409 if the target was modified by someone else since, or while another undo or redo of theirs is running.
+// @Tags history
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Board ID"
+// @Success 200 {object} service.HistoryResult
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 423 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /boards/{id}/undo [post]
+func (h *BoardHandler) Undo(c *gin.Context) {
+	h.replayHistory(c, h.boardService.Undo)
+}
+
+// Redo godoc
+// @Summary Redo the last undone change
+// @Description Re-apply the current user's most recently undone change on a board. Fails with 409 if the target was modified by someone else since, or while another undo or redo of theirs is running.
+// @Tags history
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Board ID"
+// @Success 200 {object} service.HistoryResult
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 423 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /boards/{id}/redo [post]
+func (h *BoardHandler) Redo(c *gin.Context) {
+	h.replayHistory(c, h.boardService.Redo)
+}
+
+func (h *BoardHandler) replayHistory(c *gin.Context, replay func(boardID, userID uuid.UUID) (*service.HistoryResult, error)) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	boardID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid board ID"})
+		return
+	}
+
+	result, err := replay(boardID, userID)
+	if err != nil {
+		switch err {
+		case service.ErrBoardNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
+		case service.ErrUnauthorized:
+			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+		case service.ErrNothingToUndo:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Nothing to undo"})
+		case service.ErrNothingToRedo:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Nothing to redo"})
+		case service.ErrHistoryConflict:
+			c.JSON(http.StatusConflict, gin.H{"error": "The change was modified by another user and cannot be reverted"})
+		case service.ErrHistoryBusy:
+			c.JSON(http.StatusConflict, gin.H{"error": "Another undo or redo is in progress"})
+		case service.ErrLayerLocked:
+			c.JSON(http.StatusLocked, gin.H{"error": "Layer is locked"})
+		case service.ErrItemNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+		case service.ErrConnectionNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Connection not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to apply history"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -4,10 +4,13 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"evidence-wall/boards-service/internal/geo"
+	"evidence-wall/boards-service/internal/itemtype"
 	"evidence-wall/boards-service/internal/service"
 	"evidence-wall/shared/models"
 
@@ -95,18 +98,26 @@ func (m *MockBoardService) UpdateBoardItem(boardID, itemID, userID uuid.UUID, re
 	return args.Get(0).(*models.BoardItem), args.Error(1)
 }
 
-func (m *MockBoardService) DeleteBoardItem(boardID, itemID, userID uuid.UUID) error {
-	args := m.Called(boardID, itemID, userID)
+func (m *MockBoardService) DeleteBoardItem(boardID, itemID, userID uuid.UUID, deleteChildren bool) error {
+	args := m.Called(boardID, itemID, userID, deleteChildren)
 	return args.Error(0)
 }
 
-func (m *MockBoardService) ListBoardItems(boardID, userID uuid.UUID) ([]models.BoardItem, error) {
-	args := m.Called(boardID, userID)
+func (m *MockBoardService) SetFrameMembers(boardID, frameID, userID uuid.UUID, req service.FrameMembersRequest) ([]models.BoardItem, error) {
+	args := m.Called(boardID, frameID, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
 	return args.Get(0).([]models.BoardItem), args.Error(1)
 }
 
-func (m *MockBoardService) ListBoardConnections(boardID, userID uuid.UUID) ([]models.BoardConnection, error) {
-	args := m.Called(boardID, userID)
+func (m *MockBoardService) ListBoardItems(boardID, userID uuid.UUID, query service.ItemQuery) ([]models.BoardItem, error) {
+	args := m.Called(boardID, userID, query)
+	return args.Get(0).([]models.BoardItem), args.Error(1)
+}
+
+func (m *MockBoardService) ListBoardConnections(boardID, userID uuid.UUID, query service.ConnectionQuery) ([]models.BoardConnection, error) {
+	args := m.Called(boardID, userID, query)
 	return args.Get(0).([]models.BoardConnection), args.Error(1)
 }
 
@@ -131,6 +142,305 @@ func (m *MockBoardService) DeleteBoardConnection(boardID, connectionID, userID u
 	return args.Error(0)
 }
 
+func (m *MockBoardService) DuplicateBoard(boardID, userID uuid.UUID, req service.DuplicateBoardRequest) (*models.Board, error) {
+	args := m.Called(boardID, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Board), args.Error(1)
+}
+
+func (m *MockBoardService) ExportBoard(boardID, userID uuid.UUID) (*service.BoardArchive, error) {
+	args := m.Called(boardID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.BoardArchive), args.Error(1)
+}
+
+func (m *MockBoardService) ImportBoard(userID uuid.UUID, archive *service.BoardArchive, opts service.ImportBoardOptions) (*models.Board, error) {
+	args := m.Called(userID, archive, opts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Board), args.Error(1)
+}
+
+func (m *MockBoardService) ExportCanvas(boardID, userID uuid.UUID) (*service.Canvas, error) {
+	args := m.Called(boardID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.Canvas), args.Error(1)
+}
+
+func (m *MockBoardService) ImportCanvas(userID uuid.UUID, canvas *service.Canvas, opts service.ImportBoardOptions) (*models.Board, error) {
+	args := m.Called(userID, canvas, opts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Board), args.Error(1)
+}
+
+func (m *MockBoardService) ExportGraph(boardID, userID uuid.UUID, format service.GraphFormat) ([]byte, error) {
+	args := m.Called(boardID, userID, format)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func (m *MockBoardService) RenderBoard(boardID, userID uuid.UUID, req service.RenderBoardRequest) ([]byte, error) {
+	args := m.Called(boardID, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func (m *MockBoardService) BoardReport(boardID, userID uuid.UUID) ([]byte, error) {
+	args := m.Called(boardID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func (m *MockBoardService) GetTimeline(boardID, userID uuid.UUID, query service.TimelineQuery) (*service.Timeline, error) {
+	args := m.Called(boardID, userID, query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.Timeline), args.Error(1)
+}
+
+func (m *MockBoardService) TimelineICS(boardID, userID uuid.UUID, query service.TimelineQuery) ([]byte, error) {
+	args := m.Called(boardID, userID, query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func (m *MockBoardService) GetGeo(boardID, userID uuid.UUID) (*geo.FeatureCollection, error) {
+	args := m.Called(boardID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*geo.FeatureCollection), args.Error(1)
+}
+
+func (m *MockBoardService) ExportKML(boardID, userID uuid.UUID) ([]byte, error) {
+	args := m.Called(boardID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func (m *MockBoardService) ImportGeo(boardID, userID uuid.UUID, data []byte, opts service.GeoImportOptions) (*service.GeoImportResult, error) {
+	args := m.Called(boardID, userID, data, opts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.GeoImportResult), args.Error(1)
+}
+
+func (m *MockBoardService) ShortestPath(boardID, userID, fromID, toID uuid.UUID, query service.ConnectionQuery) (*service.PathResult, error) {
+	args := m.Called(boardID, userID, fromID, toID, query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.PathResult), args.Error(1)
+}
+
+func (m *MockBoardService) Centrality(boardID, userID uuid.UUID, metric service.CentralityMetric, query service.ConnectionQuery) (*service.CentralityResult, error) {
+	args := m.Called(boardID, userID, metric, query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.CentralityResult), args.Error(1)
+}
+
+func (m *MockBoardService) ConnectedComponents(boardID, userID uuid.UUID, query service.ConnectionQuery) (*service.ComponentsResult, error) {
+	args := m.Called(boardID, userID, query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.ComponentsResult), args.Error(1)
+}
+
+func (m *MockBoardService) Communities(boardID, userID uuid.UUID, query service.ConnectionQuery) (*service.CommunitiesResult, error) {
+	args := m.Called(boardID, userID, query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.CommunitiesResult), args.Error(1)
+}
+
+func (m *MockBoardService) IsolatedItems(boardID, userID uuid.UUID, query service.ConnectionQuery) (*service.IsolatedResult, error) {
+	args := m.Called(boardID, userID, query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.IsolatedResult), args.Error(1)
+}
+
+func (m *MockBoardService) LayoutBoard(boardID, userID uuid.UUID, req service.LayoutBoardRequest) (*service.LayoutResult, error) {
+	args := m.Called(boardID, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.LayoutResult), args.Error(1)
+}
+
+func (m *MockBoardService) ItemTypes() []*itemtype.Type {
+	args := m.Called()
+	return args.Get(0).([]*itemtype.Type)
+}
+
+func (m *MockBoardService) ListTemplates(userID uuid.UUID) ([]models.BoardTemplate, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.BoardTemplate), args.Error(1)
+}
+
+func (m *MockBoardService) GetTemplate(templateID, userID uuid.UUID) (*models.BoardTemplate, error) {
+	args := m.Called(templateID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.BoardTemplate), args.Error(1)
+}
+
+func (m *MockBoardService) PublishTemplate(userID uuid.UUID, req service.PublishTemplateRequest) (*models.BoardTemplate, error) {
+	args := m.Called(userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.BoardTemplate), args.Error(1)
+}
+
+func (m *MockBoardService) DeleteTemplate(templateID, userID uuid.UUID) error {
+	args := m.Called(templateID, userID)
+	return args.Error(0)
+}
+
+func (m *MockBoardService) ListViewport(boardID, userID uuid.UUID, query service.ItemQuery) (*service.Viewport, error) {
+	args := m.Called(boardID, userID, query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.Viewport), args.Error(1)
+}
+
+func (m *MockBoardService) ListTaggedBoards(userID uuid.UUID, tags []string, offset, limit int) ([]models.BoardResponse, int64, error) {
+	args := m.Called(userID, tags, offset, limit)
+	return args.Get(0).([]models.BoardResponse), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockBoardService) ListTags(userID uuid.UUID) ([]models.Tag, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.Tag), args.Error(1)
+}
+
+func (m *MockBoardService) CreateTag(userID uuid.UUID, boardID *uuid.UUID, req service.CreateTagRequest) (*models.Tag, error) {
+	args := m.Called(userID, boardID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Tag), args.Error(1)
+}
+
+func (m *MockBoardService) ListBoardTags(boardID, userID uuid.UUID) (*service.BoardTags, error) {
+	args := m.Called(boardID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.BoardTags), args.Error(1)
+}
+
+func (m *MockBoardService) UpdateTag(tagID, userID uuid.UUID, req service.UpdateTagRequest) (*models.Tag, error) {
+	args := m.Called(tagID, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Tag), args.Error(1)
+}
+
+func (m *MockBoardService) DeleteTag(tagID, userID uuid.UUID) error {
+	args := m.Called(tagID, userID)
+	return args.Error(0)
+}
+
+func (m *MockBoardService) MergeTags(tagID, userID uuid.UUID, req service.MergeTagsRequest) (*models.Tag, error) {
+	args := m.Called(tagID, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Tag), args.Error(1)
+}
+
+func (m *MockBoardService) BulkTag(boardID, userID uuid.UUID, req service.BulkTagRequest) (*service.BoardTags, error) {
+	args := m.Called(boardID, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.BoardTags), args.Error(1)
+}
+
+func (m *MockBoardService) ListLayers(boardID, userID uuid.UUID) ([]models.Layer, error) {
+	args := m.Called(boardID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Layer), args.Error(1)
+}
+
+func (m *MockBoardService) CreateLayer(boardID, userID uuid.UUID, req service.CreateLayerRequest) (*models.Layer, error) {
+	args := m.Called(boardID, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Layer), args.Error(1)
+}
+
+func (m *MockBoardService) UpdateLayer(boardID, layerID, userID uuid.UUID, req service.UpdateLayerRequest) (*models.Layer, error) {
+	args := m.Called(boardID, layerID, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Layer), args.Error(1)
+}
+
+func (m *MockBoardService) DeleteLayer(boardID, layerID, userID uuid.UUID) error {
+	args := m.Called(boardID, layerID, userID)
+	return args.Error(0)
+}
+
+func (m *MockBoardService) MoveToLayer(boardID, userID uuid.UUID, req service.MoveToLayerRequest) (*service.MoveToLayerResult, error) {
+	args := m.Called(boardID, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.MoveToLayerResult), args.Error(1)
+}
+
+func (m *MockBoardService) Undo(boardID, userID uuid.UUID) (*service.HistoryResult, error) {
+	args := m.Called(boardID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.HistoryResult), args.Error(1)
+}
+
+func (m *MockBoardService) Redo(boardID, userID uuid.UUID) (*service.HistoryResult, error) {
+	args := m.Called(boardID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.HistoryResult), args.Error(1)
+}
+
 func setupTestRouter() *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
@@ -586,7 +896,7 @@ func TestBoardHandler_CreateBoardItem(t *testing.T) {
 					Width:     200,
 					Height:    150,
 					ZIndex:    1,
-					Color:     "#ffff00",
+					Style:     []byte(`{"color":"#ffff00"}`),
 					CreatedBy: userID,
 				}
 				m.On("CreateBoardItem", boardID, userID, expectedReq).Return(item, nil)
@@ -695,3 +1005,97 @@ func TestBoardHandler_CreateBoardItem(t *testing.T) {
 		})
 	}
 }
+
+func TestBoardHandler_ListViewport(t *testing.T) {
+	userID := uuid.New()
+	boardID := uuid.New()
+	itemID := uuid.New()
+	mockService := new(MockBoardService)
+	mockService.On("ListViewport", boardID, userID, service.ItemQuery{BBox: "0,0,1920,1080"}).
+		Return(&service.Viewport{
+			BBox:        models.BBox{MaxX: 1920, MaxY: 1080},
+			Items:       []models.BoardItem{{ID: itemID, BoardID: boardID}},
+			Connections: []models.BoardConnection{{ID: uuid.New(), FromItemID: itemID, ToItemID: uuid.New()}},
+		}, nil)
+	mockService.On("ListViewport", boardID, userID, service.ItemQuery{BBox: "0,0,1920"}).
+		Return(nil, fmt.Errorf("%w: bbox must be minX,minY,maxX,maxY", service.ErrInvalidInput))
+
+	handler := NewBoardHandler(mockService)
+	router := setupTestRouter()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", userID)
+	})
+	router.GET("/boards/:id/viewport", handler.ListViewport)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/boards/"+boardID.String()+"/viewport?bbox=0,0,1920,1080", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var viewport service.Viewport
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &viewport))
+	assert.Equal(t, float64(1920), viewport.BBox.MaxX)
+	assert.Len(t, viewport.Items, 1)
+	assert.Len(t, viewport.Connections, 1)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/boards/"+boardID.String()+"/viewport?bbox=0,0,1920", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestBoardHandler_DeleteBoardItemChildren(t *testing.T) {
+	userID := uuid.New()
+	boardID := uuid.New()
+	frameID := uuid.New()
+	mockService := new(MockBoardService)
+	mockService.On("DeleteBoardItem", boardID, frameID, userID, false).Return(nil).Once()
+	mockService.On("DeleteBoardItem", boardID, frameID, userID, true).Return(nil).Once()
+
+	handler := NewBoardHandler(mockService)
+	router := setupTestRouter()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", userID)
+	})
+	router.DELETE("/boards/:id/items/:itemId", handler.DeleteBoardItem)
+
+	path := "/boards/" + boardID.String() + "/items/" + frameID.String()
+	for query, code := range map[string]int{"": http.StatusNoContent, "?children=delete": http.StatusNoContent, "?children=all": http.StatusBadRequest} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("DELETE", path+query, nil))
+		assert.Equal(t, code, w.Code, query)
+	}
+	mockService.AssertExpectations(t)
+}
+
+func TestBoardHandler_SetFrameMembers(t *testing.T) {
+	userID := uuid.New()
+	boardID := uuid.New()
+	frameID := uuid.New()
+	itemID := uuid.New()
+	mockService := new(MockBoardService)
+	mockService.On("SetFrameMembers", boardID, frameID, userID, service.FrameMembersRequest{Add: []uuid.UUID{itemID}}).
+		Return([]models.BoardItem{{ID: itemID, BoardID: boardID, ParentID: &frameID}}, nil)
+	mockService.On("SetFrameMembers", boardID, frameID, userID, service.FrameMembersRequest{Add: []uuid.UUID{frameID}}).
+		Return(nil, fmt.Errorf("%w: a frame cannot contain itself", service.ErrInvalidInput))
+
+	handler := NewBoardHandler(mockService)
+	router := setupTestRouter()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", userID)
+	})
+	router.POST("/boards/:id/frames/:frameId/members", handler.SetFrameMembers)
+
+	path := "/boards/" + boardID.String() + "/frames/" + frameID.String() + "/members"
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", path, bytes.NewBufferString(`{"add":["`+itemID.String()+`"]}`)))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var items []models.BoardItem
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &items))
+	if assert.Len(t, items, 1) {
+		assert.Equal(t, frameID, *items[0].ParentID)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", path, bytes.NewBufferString(`{"add":["`+frameID.String()+`"]}`)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"evidence-wall/boards-service/internal/service"
+	"evidence-wall/shared/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// SetFrameMembers godoc
+// @Summary Move items into or out of a frame
+// @Description Move items into a frame, from wherever they were, and out of it onto the frame's own parent. Frames can be nested up to 8 deep; an item cannot contain itself.
+// @Tags items
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Board ID"
+// @Param frameId path string true "Frame ID"
+// @Param request body service.FrameMembersRequest true "Frame membership request"
+// @Success 200 {array} models.BoardItem
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /boards/{id}/frames/{frameId}/members [post]
+func (h *BoardHandler) SetFrameMembers(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	boardID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid board ID"})
+		return
+	}
+
+	frameID, err := uuid.Parse(c.Param("frameId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid frame ID"})
+		return
+	}
+
+	var req service.FrameMembersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	items, err := h.boardService.SetFrameMembers(boardID, frameID, userID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidInput):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case err == service.ErrBoardNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
+		case err == service.ErrItemNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+		case err == service.ErrLayerLocked:
+			c.JSON(http.StatusLocked, gin.H{"error": "Layer is locked"})
+		case err == service.ErrUnauthorized:
+			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update frame"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, items)
+}
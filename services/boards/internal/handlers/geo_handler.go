@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"evidence-wall/boards-service/internal/service"
+	"evidence-wall/shared/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// maxGeoImportSize caps the size of an uploaded GeoJSON or KML document
+const maxGeoImportSize = 20 << 20
+
+// geoParams reads the user and board ID shared by the geo endpoints
+func geoParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	boardID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid board ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	return userID, boardID, true
+}
+
+// geoError writes the response for a failed geo request
+func geoError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidInput):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrBoardNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
+	case errors.Is(err, service.ErrUnauthorized):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
+
+// GetGeo godoc
+// @Summary Get a board's geo-located items as GeoJSON
+// @Description List the board's items with a place as a GeoJSON FeatureCollection. Areas become polygons; other items become points, placed by their lat and lon fields, lat and lon in their metadata, or where their photo was taken. Connections between two placed items become line strings.
+// @Tags geo
+// @Produce application/geo+json
+// @Security BearerAuth
+// @Param id path string true "Board ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /boards/{id}/geo [get]
+func (h *BoardHandler) GetGeo(c *gin.Context) {
+	userID, boardID, ok := geoParams(c)
+	if !ok {
+		return
+	}
+
+	fc, err := h.boardService.GetGeo(boardID, userID)
+	if err != nil {
+		geoError(c, err, "Failed to export board")
+		return
+	}
+
+	data, err := json.Marshal(fc)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export board"})
+		return
+	}
+	c.Data(http.StatusOK, "application/geo+json", data)
+}
+
+// ExportKML godoc
+// @Summary Export a board's geo-located items as KML
+// @Description Download the features of GET /boards/{id}/geo as a KML document for Google Earth, one placemark per item or connection
+// @Tags geo
+// @Produce application/vnd.google-earth.kml+xml
+// @Security BearerAuth
+// @Param id path string true "Board ID"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /boards/{id}/export/kml [get]
+func (h *BoardHandler) ExportKML(c *gin.Context) {
+	userID, boardID, ok := geoParams(c)
+	if !ok {
+		return
+	}
+
+	data, err := h.boardService.ExportKML(boardID, userID)
+	if err != nil {
+		geoError(c, err, "Failed to export board")
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="board-%s.kml"`, boardID))
+	c.Data(http.StatusOK, "application/vnd.google-earth.kml+xml", data)
+}
+
+// ImportGeo godoc
+// @Summary Import GeoJSON or KML onto a board
+// @Description Turn the points and polygons of a GeoJSON or KML document into location and area items, placed on the canvas so they keep their relative positions. Line strings between imported places become connections. Requires write permission.
+// @Tags geo
+// @Accept json
+// @Accept application/geo+json
+// @Accept application/vnd.google-earth.kml+xml
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Board ID"
+// @Param format query string false "geojson or kml; detected from the document when omitted"
+// @Param x query number false "Left edge of the import on the canvas; right of the board's items by default"
+// @Param y query number false "Top edge of the import on the canvas"
+// @Param size query number false "Width and height of the square the features are projected onto (default 2000)"
+// @Param document body string true "GeoJSON or KML document"
+// @Success 201 {object} service.GeoImportResult
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 413 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /boards/{id}/geo/import [post]
+func (h *BoardHandler) ImportGeo(c *gin.Context) {
+	userID, boardID, ok := geoParams(c)
+	if !ok {
+		return
+	}
+
+	var opts service.GeoImportOptions
+	if err := c.ShouldBindQuery(&opts); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxGeoImportSize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Document too large", "max_size": maxGeoImportSize})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+		return
+	}
+
+	result, err := h.boardService.ImportGeo(boardID, userID, data, opts)
+	if err != nil {
+		geoError(c, err, "Failed to import features")
+		return
+	}
+
+	c.JSON(http.StatusCreated, result)
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"evidence-wall/boards-service/internal/geo"
+	"evidence-wall/boards-service/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupGeoRouter(userID uuid.UUID, mockService *MockBoardService) *gin.Engine {
+	handler := NewBoardHandler(mockService)
+	router := setupTestRouter()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", userID)
+	})
+	router.GET("/boards/:id/geo", handler.GetGeo)
+	router.GET("/boards/:id/export/kml", handler.ExportKML)
+	router.POST("/boards/:id/geo/import", handler.ImportGeo)
+	return router
+}
+
+func TestBoardHandler_GetGeo(t *testing.T) {
+	userID := uuid.New()
+	boardID := uuid.New()
+	mockService := new(MockBoardService)
+	mockService.On("GetGeo", boardID, userID).Return(&geo.FeatureCollection{Features: []geo.Feature{
+		{ID: "pier", Geometry: geo.NewPoint(geo.Position{3, 4})},
+	}}, nil)
+	mockService.On("ExportKML", boardID, userID).Return([]byte("<kml/>"), nil)
+	router := setupGeoRouter(userID, mockService)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/boards/"+boardID.String()+"/geo", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/geo+json", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"type":"FeatureCollection","features":[{"type":"Feature","id":"pier","geometry":{"type":"Point","coordinates":[3,4]},"properties":{}}]}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/boards/"+boardID.String()+"/export/kml", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/vnd.google-earth.kml+xml", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), ".kml")
+}
+
+func TestBoardHandler_ImportGeo(t *testing.T) {
+	userID := uuid.New()
+	boardID := uuid.New()
+	body := []byte(`{"type":"Point","coordinates":[3,4]}`)
+	size := 500.0
+	mockService := new(MockBoardService)
+	mockService.On("ImportGeo", boardID, userID, body, service.GeoImportOptions{Size: size}).
+		Return(&service.GeoImportResult{Skipped: 2}, nil)
+	mockService.On("ImportGeo", boardID, userID, []byte("nonsense"), service.GeoImportOptions{}).
+		Return(nil, service.ErrInvalidInput)
+	router := setupGeoRouter(userID, mockService)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/boards/"+boardID.String()+"/geo/import?size=500", bytes.NewReader(body)))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"skipped":2`)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/boards/"+boardID.String()+"/geo/import", bytes.NewReader([]byte("nonsense"))))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/boards/"+boardID.String()+"/geo/import", bytes.NewReader(make([]byte, maxGeoImportSize+1))))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+}
@@ -0,0 +1,20 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListItemTypes godoc
+// @Summary List item types
+// @Description List the item types that can be placed on a board, each with the JSON Schema of its structured fields
+// @Tags items
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} itemtype.Type
+// @Failure 401 {object} map[string]interface{}
+// @Router /item-types [get]
+func (h *BoardHandler) ListItemTypes(c *gin.Context) {
+	c.JSON(http.StatusOK, h.boardService.ItemTypes())
+}
@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"evidence-wall/boards-service/internal/service"
+	"evidence-wall/shared/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// respondLayerError maps layer service errors to HTTP responses
+func respondLayerError(c *gin.Context, err error, failure string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidInput):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInputTooLong):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err == service.ErrLayerNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Layer not found"})
+	case err == service.ErrLayerLocked:
+		c.JSON(http.StatusLocked, gin.H{"error": "Layer is locked"})
+	case err == service.ErrLayerNotEmpty:
+		c.JSON(http.StatusConflict, gin.H{"error": "Layer is not empty, move its contents to another layer first"})
+	case err == service.ErrBoardNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
+	case err == service.ErrItemNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+	case err == service.ErrConnectionNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Connection not found"})
+	case err == service.ErrUnauthorized:
+		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": failure})
+	}
+}
+
+// layerParams reads the authenticated user and the board and layer IDs
+func layerParams(c *gin.Context) (userID, boardID, layerID uuid.UUID, ok bool) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	boardID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid board ID"})
+		return
+	}
+
+	layerID, err = uuid.Parse(c.Param("layerId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid layer ID"})
+		return
+	}
+
+	return userID, boardID, layerID, true
+}
+
+// ListLayers godoc
+// @Summary List a board's layers
+// @Description List the layers of the board the current user can see, bottom first. Items and connections without a layer are on the base layer, which everyone sees.
+// @Tags layers
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Board ID"
+// @Success 200 {array} models.Layer
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /boards/{id}/layers [get]
+func (h *BoardHandler) ListLayers(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	boardID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid board ID"})
+		return
+	}
+
+	layers, err := h.boardService.ListLayers(boardID, userID)
+	if err != nil {
+		respondLayerError(c, err, "Failed to list layers")
+		return
+	}
+
+	c.JSON(http.StatusOK, layers)
+}
+
+// CreateLayer godoc
+// @Summary Create a layer
+// @Description Add a layer to the board (write permission required). Restricting it to a minimum permission or to some of the board's users requires admin permission.
+// @Tags layers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Board ID"
+// @Param request body service.CreateLayerRequest true "Layer creation request"
+// @Success 201 {object} models.Layer
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /boards/{id}/layers [post]
+func (h *BoardHandler) CreateLayer(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	boardID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid board ID"})
+		return
+	}
+
+	var req service.CreateLayerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	layer, err := h.boardService.CreateLayer(boardID, userID, req)
+	if err != nil {
+		respondLayerError(c, err, "Failed to create layer")
+		return
+	}
+
+	c.JSON(http.StatusCreated, layer)
+}
+
+// UpdateLayer godoc
+// @Summary Update a layer
+// @Description Rename, reorder, toggle or lock a layer (write permission required). Changing who can see it requires admin permission.
+// @Tags layers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Board ID"
+// @Param layerId path string true "Layer ID"
+// @Param request body service.UpdateLayerRequest true "Layer update request"
+// @Success 200 {object} models.Layer
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /boards/{id}/layers/{layerId} [put]
+func (h *BoardHandler) UpdateLayer(c *gin.Context) {
+	userID, boardID, layerID, ok := layerParams(c)
+	if !ok {
+		return
+	}
+
+	var req service.UpdateLayerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	layer, err := h.boardService.UpdateLayer(boardID, layerID, userID, req)
+	if err != nil {
+		respondLayerError(c, err, "Failed to update layer")
+		return
+	}
+
+	c.JSON(http.StatusOK, layer)
+}
+
+// DeleteLayer godoc
+// @Summary Delete a layer
+// @Description Delete an empty layer (write permission required)
+// @Tags layers
+// @Security BearerAuth
+// @Param id path string true "Board ID"
+// @Param layerId path string true "Layer ID"
+// @Success 204
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /boards/{id}/layers/{layerId} [delete]
+func (h *BoardHandler) DeleteLayer(c *gin.Context) {
+	userID, boardID, layerID, ok := layerParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.boardService.DeleteLayer(boardID, layerID, userID); err != nil {
+		respondLayerError(c, err, "Failed to delete layer")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// MoveToLayer godoc
+// @Summary Move items and connections onto a layer
+// @Description Move a selection of items and connections onto a layer, or back onto the base layer when layer_id is null. Locked layers cannot be moved onto or out of.
+// @Tags layers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Board ID"
+// @Param request body service.MoveToLayerRequest true "Move request"
+// @Success 200 {object} service.MoveToLayerResult
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 423 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /boards/{id}/layers/move [post]
+func (h *BoardHandler) MoveToLayer(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	boardID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid board ID"})
+		return
+	}
+
+	var req service.MoveToLayerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.boardService.MoveToLayer(boardID, userID, req)
+	if err != nil {
+		respondLayerError(c, err, "Failed to move to layer")
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
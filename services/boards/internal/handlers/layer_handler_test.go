@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"evidence-wall/boards-service/internal/service"
+	"evidence-wall/shared/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupLayerRouter(userID uuid.UUID, mockService *MockBoardService) *gin.Engine {
+	handler := NewBoardHandler(mockService)
+	router := setupTestRouter()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", userID)
+	})
+	router.POST("/boards/:id/layers", handler.CreateLayer)
+	router.POST("/boards/:id/layers/move", handler.MoveToLayer)
+	router.DELETE("/boards/:id/layers/:layerId", handler.DeleteLayer)
+	router.PUT("/boards/:id/items/:itemId", handler.UpdateBoardItem)
+	return router
+}
+
+func TestBoardHandler_CreateLayer(t *testing.T) {
+	userID := uuid.New()
+	boardID := uuid.New()
+	mockService := new(MockBoardService)
+	mockService.On("CreateLayer", boardID, userID, service.CreateLayerRequest{Name: "Timeline"}).
+		Return(&models.Layer{ID: uuid.New(), BoardID: boardID, Name: "Timeline", Visible: true}, nil)
+	mockService.On("CreateLayer", boardID, userID, service.CreateLayerRequest{Name: "Sources", MinPermission: models.PermissionAdmin}).
+		Return(nil, service.ErrUnauthorized)
+	router := setupLayerRouter(userID, mockService)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/boards/"+boardID.String()+"/layers", bytes.NewBufferString(`{"name":"Timeline"}`)))
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/boards/"+boardID.String()+"/layers", bytes.NewBufferString(`{"name":"Sources","min_permission":"admin"}`)))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/boards/"+boardID.String()+"/layers", bytes.NewBufferString(`{}`)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestBoardHandler_DeleteLayer(t *testing.T) {
+	userID := uuid.New()
+	boardID := uuid.New()
+	emptyID, fullID := uuid.New(), uuid.New()
+	mockService := new(MockBoardService)
+	mockService.On("DeleteLayer", boardID, emptyID, userID).Return(nil)
+	mockService.On("DeleteLayer", boardID, fullID, userID).Return(service.ErrLayerNotEmpty)
+	router := setupLayerRouter(userID, mockService)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("DELETE", "/boards/"+boardID.String()+"/layers/"+emptyID.String(), nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("DELETE", "/boards/"+boardID.String()+"/layers/"+fullID.String(), nil))
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("DELETE", "/boards/"+boardID.String()+"/layers/not-a-uuid", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestBoardHandler_LockedLayer(t *testing.T) {
+	userID := uuid.New()
+	boardID := uuid.New()
+	itemID := uuid.New()
+	mockService := new(MockBoardService)
+	mockService.On("MoveToLayer", boardID, userID, service.MoveToLayerRequest{ItemIDs: []uuid.UUID{itemID}}).
+		Return(nil, service.ErrLayerLocked)
+	mockService.On("UpdateBoardItem", boardID, itemID, userID, service.UpdateItemRequest{Content: "Moved"}).
+		Return(nil, service.ErrLayerLocked)
+	router := setupLayerRouter(userID, mockService)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/boards/"+boardID.String()+"/layers/move", bytes.NewBufferString(`{"layer_id":null,"item_ids":["`+itemID.String()+`"]}`)))
+	assert.Equal(t, http.StatusLocked, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("PUT", "/boards/"+boardID.String()+"/items/"+itemID.String(), bytes.NewBufferString(`{"content":"Moved"}`)))
+	assert.Equal(t, http.StatusLocked, w.Code)
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"evidence-wall/boards-service/internal/service"
+	"evidence-wall/shared/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// LayoutBoard godoc
+// @Summary Automatically arrange board items
+// @Description Compute force-directed, hierarchical, circular or grid positions for all items or a selection, based on their connections. With apply=true the positions are saved as one undoable change and broadcast; otherwise they are only returned as a preview.
+// @Tags boards
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Board ID"
+// @Param request body service.LayoutBoardRequest true "Layout request"
+// @Success 200 {object} service.LayoutResult
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /boards/{id}/layout [post]
+func (h *BoardHandler) LayoutBoard(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	boardID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid board ID"})
+		return
+	}
+
+	var req service.LayoutBoardRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.boardService.LayoutBoard(boardID, userID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidInput):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrBoardNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
+		case errors.Is(err, service.ErrItemNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+		case errors.Is(err, service.ErrLayerLocked):
+			c.JSON(http.StatusLocked, gin.H{"error": "Layer is locked"})
+		case errors.Is(err, service.ErrUnauthorized):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to lay out board"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
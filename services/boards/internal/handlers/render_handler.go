@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"evidence-wall/boards-service/internal/service"
+	"evidence-wall/shared/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RenderBoard godoc
+// @Summary Render a board as an image
+// @Description Draw a board server-side as SVG (default) or PNG. x, y, width and height select a viewport in board coordinates (default: fit all items); scale sets pixels per board unit.
+// @Tags boards
+// @Produce image/svg+xml
+// @Produce png
+// @Security BearerAuth
+// @Param id path string true "Board ID"
+// @Param format query string false "Image format (svg, png)"
+// @Param x query number false "Viewport left edge"
+// @Param y query number false "Viewport top edge"
+// @Param width query number false "Viewport width"
+// @Param height query number false "Viewport height"
+// @Param scale query number false "Pixels per board unit (default 1, max 8)"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /boards/{id}/render [get]
+func (h *BoardHandler) RenderBoard(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	boardID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid board ID"})
+		return
+	}
+
+	var req service.RenderBoardRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	data, err := h.boardService.RenderBoard(boardID, userID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUnsupportedFormat), errors.Is(err, service.ErrInvalidInput):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrBoardNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render board"})
+		}
+		return
+	}
+
+	contentType := "image/svg+xml"
+	if req.Format == "png" {
+		contentType = "image/png"
+	}
+	c.Data(http.StatusOK, contentType, data)
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"evidence-wall/boards-service/internal/service"
+	"evidence-wall/shared/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// BoardReport godoc
+// @Summary Download a PDF case report
+// @Description Generate a printable case dossier: cover page, the rendered wall, every item's content grouped by type, a connection table and the collaborators
+// @Tags boards
+// @Produce application/pdf
+// @Security BearerAuth
+// @Param id path string true "Board ID"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /boards/{id}/report.pdf [get]
+func (h *BoardHandler) BoardReport(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	boardID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid board ID"})
+		return
+	}
+
+	data, err := h.boardService.BoardReport(boardID, userID)
+	if err != nil {
+		if errors.Is(err, service.ErrBoardNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate report"})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="board-%s-report.pdf"`, boardID))
+	c.Data(http.StatusOK, "application/pdf", data)
+}
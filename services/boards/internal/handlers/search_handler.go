@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"evidence-wall/boards-service/internal/service"
+	"evidence-wall/shared/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// SearchServiceInterface defines the interface for search service operations
+type SearchServiceInterface interface {
+	Search(userID uuid.UUID, query service.SearchQuery) (*service.SearchResults, error)
+}
+
+// SearchHandler handles search HTTP requests
+type SearchHandler struct {
+	searchService SearchServiceInterface
+}
+
+// NewSearchHandler creates a new search handler
+func NewSearchHandler(searchService SearchServiceInterface) *SearchHandler {
+	return &SearchHandler{
+		searchService: searchService,
+	}
+}
+
+// Search godoc
+// @Summary Search boards and items
+// @Description Full-text search over the titles and descriptions of the boards the user owns or was shared, and over the content, fields, custom values and photo metadata of their items. Supports "quoted phrases", OR and -excluded words. Snippets are HTML-escaped with matches in <mark> elements; facets count the matching items per board and per item type.
+// @Tags search
+// @Produce json
+// @Security BearerAuth
+// @Param q query string true "Search terms"
+// @Param board_id query []string false "Only these boards"
+// @Param type query []string false "Only these item types"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} service.SearchResults
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /search [get]
+func (h *SearchHandler) Search(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var query service.SearchQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	results, err := h.searchService.Search(userID, query)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidInput):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case err == service.ErrInputTooLong:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Search query too long"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, results)
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"evidence-wall/boards-service/internal/service"
+	"evidence-wall/shared/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockSearchService is a mock implementation of SearchServiceInterface
+type MockSearchService struct {
+	mock.Mock
+}
+
+func (m *MockSearchService) Search(userID uuid.UUID, query service.SearchQuery) (*service.SearchResults, error) {
+	args := m.Called(userID, query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.SearchResults), args.Error(1)
+}
+
+func TestSearchHandler_Search(t *testing.T) {
+	userID := uuid.New()
+	boardID := uuid.New()
+
+	mockService := new(MockSearchService)
+	mockService.On("Search", userID, service.SearchQuery{Q: "blue van", BoardID: []string{boardID.String()}, Type: []string{"vehicle", "event"}, Page: 2}).
+		Return(&service.SearchResults{
+			Query: "blue van",
+			Items: []models.ItemSearchHit{{ItemID: uuid.New(), BoardID: boardID, Type: "vehicle", Snippet: "<mark>Blue</mark> <mark>van</mark>"}},
+			Total: 21,
+			Page:  2,
+		}, nil)
+	mockService.On("Search", userID, service.SearchQuery{Q: "x"}).Return(nil, service.ErrInvalidInput)
+	mockService.On("Search", userID, service.SearchQuery{Q: "down"}).Return(nil, errors.New("db down"))
+
+	router := setupTestRouter()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", userID)
+	})
+	router.GET("/search", NewSearchHandler(mockService).Search)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/search?q=blue+van&board_id="+boardID.String()+"&type=vehicle&type=event&page=2", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var results service.SearchResults
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &results))
+	assert.Equal(t, int64(21), results.Total)
+	assert.Equal(t, "<mark>Blue</mark> <mark>van</mark>", results.Items[0].Snippet)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/search?q=x", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/search?q=down", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/search?page=two", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,329 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"evidence-wall/boards-service/internal/service"
+	"evidence-wall/shared/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// respondTagError maps tag service errors to HTTP responses
+func respondTagError(c *gin.Context, err error, failure string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidInput):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInputTooLong):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err == service.ErrTagExists:
+		c.JSON(http.StatusConflict, gin.H{"error": "A tag with this name already exists, merge the tags instead"})
+	case err == service.ErrTagNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tag not found"})
+	case err == service.ErrBoardNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
+	case err == service.ErrItemNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+	case err == service.ErrUnauthorized:
+		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": failure})
+	}
+}
+
+// ListTags godoc
+// @Summary List personal tags
+// @Description List the current user's personal tags
+// @Tags tags
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.Tag
+// @Failure 401 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /tags [get]
+func (h *BoardHandler) ListTags(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	tags, err := h.boardService.ListTags(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list tags"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tags)
+}
+
+// CreateTag godoc
+// @Summary Create a personal tag
+// @Description Create a tag only the current user sees, usable on any board or item they can read
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body service.CreateTagRequest true "Tag creation request"
+// @Success 201 {object} models.Tag
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /tags [post]
+func (h *BoardHandler) CreateTag(c *gin.Context) {
+	h.createTag(c, nil)
+}
+
+// CreateBoardTag godoc
+// @Summary Create a board tag
+// @Description Create a tag shared with everyone on the board (write permission required)
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Board ID"
+// @Param request body service.CreateTagRequest true "Tag creation request"
+// @Success 201 {object} models.Tag
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /boards/{id}/tags [post]
+func (h *BoardHandler) CreateBoardTag(c *gin.Context) {
+	boardID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid board ID"})
+		return
+	}
+	h.createTag(c, &boardID)
+}
+
+func (h *BoardHandler) createTag(c *gin.Context, boardID *uuid.UUID) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req service.CreateTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tag, err := h.boardService.CreateTag(userID, boardID, req)
+	if err != nil {
+		respondTagError(c, err, "Failed to create tag")
+		return
+	}
+
+	c.JSON(http.StatusCreated, tag)
+}
+
+// ListBoardTags godoc
+// @Summary List a board's tags
+// @Description List the board's shared tags and the current user's personal tags, with where they are assigned on the board and its items
+// @Tags tags
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Board ID"
+// @Success 200 {object} service.BoardTags
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /boards/{id}/tags [get]
+func (h *BoardHandler) ListBoardTags(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	boardID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid board ID"})
+		return
+	}
+
+	tags, err := h.boardService.ListBoardTags(boardID, userID)
+	if err != nil {
+		respondTagError(c, err, "Failed to list tags")
+		return
+	}
+
+	c.JSON(http.StatusOK, tags)
+}
+
+// BulkTag godoc
+// @Summary Tag a selection
+// @Description Add and remove tags on a selection of the board's items and on the board itself. Personal tags need read access, board tags write permission.
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Board ID"
+// @Param request body service.BulkTagRequest true "Bulk tagging request"
+// @Success 200 {object} service.BoardTags
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /boards/{id}/tags/bulk [post]
+func (h *BoardHandler) BulkTag(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	boardID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid board ID"})
+		return
+	}
+
+	var req service.BulkTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tags, err := h.boardService.BulkTag(boardID, userID, req)
+	if err != nil {
+		respondTagError(c, err, "Failed to tag selection")
+		return
+	}
+
+	c.JSON(http.StatusOK, tags)
+}
+
+// UpdateTag godoc
+// @Summary Rename or recolor a tag
+// @Description Rename or recolor a personal tag, or a board tag with write permission on its board. Renaming to the name of another tag is rejected; merge the tags instead.
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param tagId path string true "Tag ID"
+// @Param request body service.UpdateTagRequest true "Tag update request"
+// @Success 200 {object} models.Tag
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /tags/{tagId} [put]
+func (h *BoardHandler) UpdateTag(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	tagID, err := uuid.Parse(c.Param("tagId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tag ID"})
+		return
+	}
+
+	var req service.UpdateTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tag, err := h.boardService.UpdateTag(tagID, userID, req)
+	if err != nil {
+		respondTagError(c, err, "Failed to update tag")
+		return
+	}
+
+	c.JSON(http.StatusOK, tag)
+}
+
+// MergeTags godoc
+// @Summary Merge tags
+// @Description Move everything tagged with a tag to another tag of the same user or board, then delete the first tag
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param tagId path string true "Tag ID to merge away"
+// @Param request body service.MergeTagsRequest true "Tag merge request"
+// @Success 200 {object} models.Tag
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /tags/{tagId}/merge [post]
+func (h *BoardHandler) MergeTags(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	tagID, err := uuid.Parse(c.Param("tagId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tag ID"})
+		return
+	}
+
+	var req service.MergeTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tag, err := h.boardService.MergeTags(tagID, userID, req)
+	if err != nil {
+		respondTagError(c, err, "Failed to merge tags")
+		return
+	}
+
+	c.JSON(http.StatusOK, tag)
+}
+
+// DeleteTag godoc
+// @Summary Delete a tag
+// @Description Delete a tag and remove it from everything it was assigned to
+// @Tags tags
+// @Security BearerAuth
+// @Param tagId path string true "Tag ID"
+// @Success 204
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /tags/{tagId} [delete]
+func (h *BoardHandler) DeleteTag(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	tagID, err := uuid.Parse(c.Param("tagId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tag ID"})
+		return
+	}
+
+	if err := h.boardService.DeleteTag(tagID, userID); err != nil {
+		respondTagError(c, err, "Failed to delete tag")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
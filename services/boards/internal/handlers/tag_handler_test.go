@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"evidence-wall/boards-service/internal/service"
+	"evidence-wall/shared/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupTagRouter(userID uuid.UUID, mockService *MockBoardService) *gin.Engine {
+	handler := NewBoardHandler(mockService)
+	router := setupTestRouter()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", userID)
+	})
+	router.GET("/boards", handler.ListBoards)
+	router.POST("/boards/:id/tags", handler.CreateBoardTag)
+	router.POST("/boards/:id/tags/bulk", handler.BulkTag)
+	router.PUT("/tags/:tagId", handler.UpdateTag)
+	router.POST("/tags/:tagId/merge", handler.MergeTags)
+	return router
+}
+
+func TestBoardHandler_ListBoardsByTag(t *testing.T) {
+	userID := uuid.New()
+	tagID := uuid.New()
+	mockService := new(MockBoardService)
+	mockService.On("ListTaggedBoards", userID, []string{tagID.String()}, 0, 20).
+		Return([]models.BoardResponse{{ID: uuid.New(), Title: "Tagged"}}, int64(1), nil)
+	mockService.On("ListTaggedBoards", userID, []string{"hidden"}, 0, 20).
+		Return([]models.BoardResponse{}, int64(0), service.ErrTagNotFound)
+	router := setupTagRouter(userID, mockService)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/boards?tag="+tagID.String(), nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, float64(1), response["total"])
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/boards?tag=hidden", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestBoardHandler_CreateBoardTag(t *testing.T) {
+	userID := uuid.New()
+	boardID := uuid.New()
+	mockService := new(MockBoardService)
+	mockService.On("CreateTag", userID, &boardID, service.CreateTagRequest{Name: "Alibi", Color: "#00ff00"}).
+		Return(&models.Tag{ID: uuid.New(), Name: "Alibi", Scope: models.TagScopeBoard, BoardID: &boardID}, nil)
+	mockService.On("CreateTag", userID, &boardID, service.CreateTagRequest{Name: "Taken"}).
+		Return(nil, service.ErrTagExists)
+	router := setupTagRouter(userID, mockService)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/boards/"+boardID.String()+"/tags", bytes.NewBufferString(`{"name":"Alibi","color":"#00ff00"}`)))
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/boards/"+boardID.String()+"/tags", bytes.NewBufferString(`{"name":"Taken"}`)))
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/boards/"+boardID.String()+"/tags", bytes.NewBufferString(`{}`)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestBoardHandler_BulkTag(t *testing.T) {
+	userID := uuid.New()
+	boardID := uuid.New()
+	tagID, itemID := uuid.New(), uuid.New()
+	mockService := new(MockBoardService)
+	mockService.On("BulkTag", boardID, userID, service.BulkTagRequest{Add: []uuid.UUID{tagID}, ItemIDs: []uuid.UUID{itemID}}).
+		Return(&service.BoardTags{
+			Tags:        []models.Tag{{ID: tagID}},
+			Assignments: []models.TagAssignment{{TagID: tagID, TargetID: itemID, TargetType: models.TagTargetItem}},
+		}, nil)
+	mockService.On("BulkTag", boardID, userID, service.BulkTagRequest{Add: []uuid.UUID{tagID}, Board: true}).
+		Return(nil, service.ErrUnauthorized)
+	router := setupTagRouter(userID, mockService)
+
+	body, _ := json.Marshal(map[string]interface{}{"add": []uuid.UUID{tagID}, "item_ids": []uuid.UUID{itemID}})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/boards/"+boardID.String()+"/tags/bulk", bytes.NewBuffer(body)))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var result service.BoardTags
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Len(t, result.Assignments, 1)
+
+	body, _ = json.Marshal(map[string]interface{}{"add": []uuid.UUID{tagID}, "board": true})
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/boards/"+boardID.String()+"/tags/bulk", bytes.NewBuffer(body)))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestBoardHandler_RenameAndMergeTags(t *testing.T) {
+	userID := uuid.New()
+	tagID, intoID := uuid.New(), uuid.New()
+	name := "Suspects"
+	mockService := new(MockBoardService)
+	mockService.On("UpdateTag", tagID, userID, service.UpdateTagRequest{Name: &name}).Return(nil, service.ErrTagExists)
+	mockService.On("MergeTags", tagID, userID, service.MergeTagsRequest{IntoID: intoID}).
+		Return(&models.Tag{ID: intoID, Name: "Suspects"}, nil)
+	router := setupTagRouter(userID, mockService)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("PUT", "/tags/"+tagID.String(), bytes.NewBufferString(`{"name":"Suspects"}`)))
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/tags/"+tagID.String()+"/merge", bytes.NewBufferString(`{"into_id":"`+intoID.String()+`"}`)))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("PUT", "/tags/not-a-uuid", bytes.NewBufferString(`{"name":"x"}`)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
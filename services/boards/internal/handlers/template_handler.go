@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"net/http"
+
+	"evidence-wall/boards-service/internal/service"
+	"evidence-wall/shared/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ListTemplates godoc
+// @Summary List board templates
+// @Description List built-in templates, organization-wide templates and the current user's templates
+// @Tags templates
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.BoardTemplate
+// @Failure 401 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /templates [get]
+func (h *BoardHandler) ListTemplates(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	templates, err := h.boardService.ListTemplates(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list templates"})
+		return
+	}
+
+	c.JSON(http.StatusOK, templates)
+}
+
+// GetTemplate godoc
+// @Summary Get a board template
+// @Description Get a template visible to the current user
+// @Tags templates
+// @Produce json
+// @Security BearerAuth
+// @Param templateId path string true "Template ID"
+// @Success 200 {object} models.BoardTemplate
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /templates/{templateId} [get]
+func (h *BoardHandler) GetTemplate(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	templateID, err := uuid.Parse(c.Param("templateId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template ID"})
+		return
+	}
+
+	template, err := h.boardService.GetTemplate(templateID, userID)
+	if err != nil {
+		switch err {
+		case service.ErrTemplateNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get template"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+// PublishTemplate godoc
+// @Summary Publish a board as a template
+// @Description Capture a board's items and connections as a personal or organization-wide template (board admin permission required)
+// @Tags templates
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body service.PublishTemplateRequest true "Template publish request"
+// @Success 201 {object} models.BoardTemplate
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /templates [post]
+func (h *BoardHandler) PublishTemplate(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req service.PublishTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	template, err := h.boardService.PublishTemplate(userID, req)
+	if err != nil {
+		switch err {
+		case service.ErrBoardNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
+		case service.ErrUnauthorized:
+			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to publish template"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, template)
+}
+
+// DeleteTemplate godoc
+// @Summary Delete a board template
+// @Description Delete a template owned by the current user
+// @Tags templates
+// @Security BearerAuth
+// @Param templateId path string true "Template ID"
+// @Success 204
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /templates/{templateId} [delete]
+func (h *BoardHandler) DeleteTemplate(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	templateID, err := uuid.Parse(c.Param("templateId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template ID"})
+		return
+	}
+
+	if err := h.boardService.DeleteTemplate(templateID, userID); err != nil {
+		switch err {
+		case service.ErrTemplateNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		case service.ErrUnauthorized:
+			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete template"})
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"evidence-wall/boards-service/internal/service"
+	"evidence-wall/shared/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// timelineParams reads the user, board ID and query shared by the timeline
+// endpoints
+func timelineParams(c *gin.Context) (uuid.UUID, uuid.UUID, service.TimelineQuery, bool) {
+	var query service.TimelineQuery
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return uuid.Nil, uuid.Nil, query, false
+	}
+
+	boardID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid board ID"})
+		return uuid.Nil, uuid.Nil, query, false
+	}
+
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return uuid.Nil, uuid.Nil, query, false
+	}
+	return userID, boardID, query, true
+}
+
+// timelineError writes the response for a failed timeline request
+func timelineError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidInput):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrBoardNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build timeline"})
+	}
+}
+
+// GetTimeline godoc
+// @Summary Get a board's timeline
+// @Description List the board's dated items in chronological order, split into lanes by item type, tag or chains of connections. Items are dated by the timestamp and end fields of events, the date of documents, timestamp and end in their metadata, or the time their photo was taken.
+// @Tags timeline
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Board ID"
+// @Param group_by query string false "Lane grouping: type (default), tag or connection"
+// @Param type query []string false "Only items of these types" collectionFormat(multi)
+// @Param from query string false "Only events ending at or after this RFC 3339 time or date"
+// @Param to query string false "Only events starting at or before this RFC 3339 time or date"
+// @Param relationship_type query []string false "Only follow connections of these relationship types for connection lanes" collectionFormat(multi)
+// @Success 200 {object} service.Timeline
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /boards/{id}/timeline [get]
+func (h *BoardHandler) GetTimeline(c *gin.Context) {
+	userID, boardID, query, ok := timelineParams(c)
+	if !ok {
+		return
+	}
+
+	timeline, err := h.boardService.GetTimeline(boardID, userID, query)
+	if err != nil {
+		timelineError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, timeline)
+}
+
+// TimelineICS godoc
+// @Summary Download a board's timeline as iCalendar
+// @Description Export the board's timeline as an .ics file with one event per dated item, categorized by its lanes. Approximate times are marked tentative.
+// @Tags timeline
+// @Produce text/calendar
+// @Security BearerAuth
+// @Param id path string true "Board ID"
+// @Param group_by query string false "Lane grouping used for event categories: type (default), tag or connection"
+// @Param type query []string false "Only items of these types" collectionFormat(multi)
+// @Param from query string false "Only events ending at or after this RFC 3339 time or date"
+// @Param to query string false "Only events starting at or before this RFC 3339 time or date"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /boards/{id}/timeline.ics [get]
+func (h *BoardHandler) TimelineICS(c *gin.Context) {
+	userID, boardID, query, ok := timelineParams(c)
+	if !ok {
+		return
+	}
+
+	data, err := h.boardService.TimelineICS(boardID, userID, query)
+	if err != nil {
+		timelineError(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="board-%s-timeline.ics"`, boardID))
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", data)
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"evidence-wall/boards-service/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupTimelineRouter(userID uuid.UUID, mockService *MockBoardService) *gin.Engine {
+	handler := NewBoardHandler(mockService)
+	router := setupTestRouter()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", userID)
+	})
+	router.GET("/boards/:id/timeline", handler.GetTimeline)
+	router.GET("/boards/:id/timeline.ics", handler.TimelineICS)
+	return router
+}
+
+func TestBoardHandler_GetTimeline(t *testing.T) {
+	userID := uuid.New()
+	boardID := uuid.New()
+	mockService := new(MockBoardService)
+	mockService.On("GetTimeline", boardID, userID, service.TimelineQuery{GroupBy: service.TimelineByTag, Type: []string{"event"}}).
+		Return(&service.Timeline{GroupBy: service.TimelineByTag, Events: []service.TimelineEvent{}, Lanes: []service.TimelineLane{}}, nil)
+	mockService.On("GetTimeline", boardID, userID, service.TimelineQuery{From: "yesterday"}).
+		Return(nil, service.ErrInvalidInput)
+	router := setupTimelineRouter(userID, mockService)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/boards/"+boardID.String()+"/timeline?group_by=tag&type=event", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"group_by":"tag"`)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/boards/"+boardID.String()+"/timeline?from=yesterday", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestBoardHandler_TimelineICS(t *testing.T) {
+	userID := uuid.New()
+	boardID := uuid.New()
+	mockService := new(MockBoardService)
+	mockService.On("TimelineICS", boardID, userID, service.TimelineQuery{}).
+		Return([]byte("BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n"), nil)
+	router := setupTimelineRouter(userID, mockService)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/boards/"+boardID.String()+"/timeline.ics", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/calendar; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "timeline.ics")
+}
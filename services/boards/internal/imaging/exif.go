@@ -0,0 +1,365 @@
+// Package imaging derives previews from attached photos: it reads the EXIF
+// metadata of JPEG files and scales images down, using only the standard
+// library.
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// ErrNoEXIF is returned when an image carries no EXIF block
+var ErrNoEXIF = errors.New("no EXIF data")
+
+// EXIF holds the tags that matter as evidence, plus what is needed to display
+// the image upright
+type EXIF struct {
+	Make        string
+	Model       string
+	Orientation int // 1-8 as defined by the TIFF specification; 0 when absent
+
+	// CapturedAt is DateTimeOriginal in RFC 3339 form. Without an offset tag
+	// the camera's local time is kept without a zone, since guessing one
+	// would misstate the evidence.
+	CapturedAt string
+
+	Latitude  *float64
+	Longitude *float64
+	Altitude  *float64 // Metres above sea level
+
+	// raw is the APP1 payload, kept so derivatives can preserve it
+	raw []byte
+	// orientationOffset locates the orientation value inside raw, or -1
+	orientationOffset int
+}
+
+// TIFF tags read from IFD0, the Exif IFD and the GPS IFD
+const (
+	tagMake             = 0x010F
+	tagModel            = 0x0110
+	tagOrientation      = 0x0112
+	tagExifIFD          = 0x8769
+	tagGPSIFD           = 0x8825
+	tagDateTimeOriginal = 0x9003
+	tagOffsetTimeOrig   = 0x9011
+	tagGPSLatitudeRef   = 0x0001
+	tagGPSLatitude      = 0x0002
+	tagGPSLongitudeRef  = 0x0003
+	tagGPSLongitude     = 0x0004
+	tagGPSAltitudeRef   = 0x0005
+	tagGPSAltitude      = 0x0006
+)
+
+// TIFF field types
+const (
+	typeByte     = 1
+	typeASCII    = 2
+	typeShort    = 3
+	typeLong     = 4
+	typeRational = 5
+)
+
+// JPEG markers
+const (
+	jpegSOI  = 0xD8
+	jpegAPP1 = 0xE1
+	jpegSOS  = 0xDA
+	jpegEOI  = 0xD9
+)
+
+const (
+	exifHeader         = "Exif\x00\x00"
+	maxIFDEntries      = 512
+	exifDateTimeLayout = "2006:01:02 15:04:05"
+)
+
+// Field sizes in bytes, including the signed and undefined types
+var typeSizes = map[uint16]int{typeByte: 1, typeASCII: 1, typeShort: 2, typeLong: 4, typeRational: 8, 7: 1, 9: 4, 10: 8}
+
+// ReadEXIF extracts EXIF metadata from a JPEG file
+func ReadEXIF(data []byte) (*EXIF, error) {
+	payload, err := findAPP1(data)
+	if err != nil {
+		return nil, err
+	}
+	x, err := parseTIFF(payload[len(exifHeader):])
+	if err != nil {
+		return nil, fmt.Errorf("invalid EXIF data: %w", err)
+	}
+	x.raw = payload
+	if x.orientationOffset >= 0 {
+		x.orientationOffset += len(exifHeader)
+	}
+	return x, nil
+}
+
+// findAPP1 walks the JPEG markers up to the image data, returning the EXIF
+// APP1 payload
+func findAPP1(data []byte) ([]byte, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != jpegSOI {
+		return nil, ErrNoEXIF
+	}
+	for pos := 2; pos+4 <= len(data); {
+		if data[pos] != 0xFF {
+			return nil, ErrNoEXIF
+		}
+		marker := data[pos+1]
+		if marker == 0xFF { // Fill byte
+			pos++
+			continue
+		}
+		if marker == jpegSOS || marker == jpegEOI {
+			break
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		if length < 2 || pos+2+length > len(data) {
+			return nil, ErrNoEXIF
+		}
+		segment := data[pos+4 : pos+2+length]
+		if marker == jpegAPP1 && bytes.HasPrefix(segment, []byte(exifHeader)) {
+			return segment, nil
+		}
+		pos += 2 + length
+	}
+	return nil, ErrNoEXIF
+}
+
+type tiffReader struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+type ifdEntry struct {
+	tag, typ uint16
+	count    uint32
+	offset   int // Offset of the value within the TIFF data
+}
+
+func parseTIFF(data []byte) (*EXIF, error) {
+	if len(data) < 8 {
+		return nil, errors.New("short TIFF header")
+	}
+	r := &tiffReader{data: data}
+	switch string(data[:2]) {
+	case "II":
+		r.order = binary.LittleEndian
+	case "MM":
+		r.order = binary.BigEndian
+	default:
+		return nil, errors.New("bad byte order")
+	}
+	if r.order.Uint16(data[2:]) != 42 {
+		return nil, errors.New("bad TIFF magic")
+	}
+
+	x := &EXIF{orientationOffset: -1}
+	ifd0, err := r.entries(int(r.order.Uint32(data[4:])))
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range ifd0 {
+		switch e.tag {
+		case tagMake:
+			x.Make = r.ascii(e)
+		case tagModel:
+			x.Model = r.ascii(e)
+		case tagOrientation:
+			if v, ok := r.uint(e); ok && v >= 1 && v <= 8 {
+				x.Orientation = int(v)
+				x.orientationOffset = e.offset
+			}
+		case tagExifIFD:
+			if offset, ok := r.uint(e); ok {
+				r.readExifIFD(int(offset), x)
+			}
+		case tagGPSIFD:
+			if offset, ok := r.uint(e); ok {
+				r.readGPSIFD(int(offset), x)
+			}
+		}
+	}
+	return x, nil
+}
+
+// entries reads the directory at offset; malformed entries are skipped
+func (r *tiffReader) entries(offset int) ([]ifdEntry, error) {
+	if offset < 8 || offset+2 > len(r.data) {
+		return nil, errors.New("IFD out of range")
+	}
+	n := int(r.order.Uint16(r.data[offset:]))
+	if n > maxIFDEntries || offset+2+12*n > len(r.data) {
+		return nil, errors.New("IFD out of range")
+	}
+	entries := make([]ifdEntry, 0, n)
+	for i := 0; i < n; i++ {
+		p := offset + 2 + 12*i
+		e := ifdEntry{
+			tag:    r.order.Uint16(r.data[p:]),
+			typ:    r.order.Uint16(r.data[p+2:]),
+			count:  r.order.Uint32(r.data[p+4:]),
+			offset: p + 8,
+		}
+		size, ok := typeSizes[e.typ]
+		if !ok || e.count > uint32(len(r.data)) {
+			continue
+		}
+		if total := size * int(e.count); total > 4 {
+			e.offset = int(r.order.Uint32(r.data[p+8:]))
+			if e.offset < 0 || e.offset+total > len(r.data) {
+				continue
+			}
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+func (r *tiffReader) readExifIFD(offset int, x *EXIF) {
+	entries, err := r.entries(offset)
+	if err != nil {
+		return
+	}
+	var original, zone string
+	for _, e := range entries {
+		switch e.tag {
+		case tagDateTimeOriginal:
+			original = r.ascii(e)
+		case tagOffsetTimeOrig:
+			zone = r.ascii(e)
+		}
+	}
+	if original == "" {
+		return
+	}
+	if zone != "" {
+		if t, err := time.Parse(exifDateTimeLayout+"-07:00", original+zone); err == nil {
+			x.CapturedAt = t.Format(time.RFC3339)
+			return
+		}
+	}
+	if t, err := time.Parse(exifDateTimeLayout, original); err == nil {
+		x.CapturedAt = t.Format("2006-01-02T15:04:05")
+	}
+}
+
+func (r *tiffReader) readGPSIFD(offset int, x *EXIF) {
+	entries, err := r.entries(offset)
+	if err != nil {
+		return
+	}
+	var latRef, lonRef string
+	var lat, lon, alt []float64
+	altBelowSea := false
+	for _, e := range entries {
+		switch e.tag {
+		case tagGPSLatitudeRef:
+			latRef = r.ascii(e)
+		case tagGPSLongitudeRef:
+			lonRef = r.ascii(e)
+		case tagGPSLatitude:
+			lat = r.rationals(e)
+		case tagGPSLongitude:
+			lon = r.rationals(e)
+		case tagGPSAltitudeRef:
+			v, ok := r.uint(e)
+			altBelowSea = ok && v == 1
+		case tagGPSAltitude:
+			alt = r.rationals(e)
+		}
+	}
+	if v, ok := degrees(lat, latRef, "S", 90); ok {
+		if w, ok := degrees(lon, lonRef, "W", 180); ok {
+			x.Latitude, x.Longitude = &v, &w
+		}
+	}
+	if len(alt) == 1 && !math.IsNaN(alt[0]) {
+		a := alt[0]
+		if altBelowSea {
+			a = -a
+		}
+		x.Altitude = &a
+	}
+}
+
+// degrees converts degrees, minutes and seconds to signed decimal degrees
+func degrees(dms []float64, ref, negative string, limit float64) (float64, bool) {
+	if len(dms) != 3 || ref == "" {
+		return 0, false
+	}
+	v := dms[0] + dms[1]/60 + dms[2]/3600
+	if math.IsNaN(v) || v > limit {
+		return 0, false
+	}
+	if strings.EqualFold(ref, negative) {
+		v = -v
+	}
+	return v, true
+}
+
+func (r *tiffReader) ascii(e ifdEntry) string {
+	if e.typ != typeASCII {
+		return ""
+	}
+	s := string(r.data[e.offset : e.offset+int(e.count)])
+	return strings.TrimSpace(strings.TrimRight(s, "\x00"))
+}
+
+func (r *tiffReader) uint(e ifdEntry) (uint32, bool) {
+	if e.count != 1 {
+		return 0, false
+	}
+	switch e.typ {
+	case typeByte:
+		return uint32(r.data[e.offset]), true
+	case typeShort:
+		return uint32(r.order.Uint16(r.data[e.offset:])), true
+	case typeLong:
+		return r.order.Uint32(r.data[e.offset:]), true
+	}
+	return 0, false
+}
+
+func (r *tiffReader) rationals(e ifdEntry) []float64 {
+	if e.typ != typeRational {
+		return nil
+	}
+	values := make([]float64, e.count)
+	for i := range values {
+		p := e.offset + 8*i
+		num := r.order.Uint32(r.data[p:])
+		den := r.order.Uint32(r.data[p+4:])
+		if den == 0 {
+			values[i] = math.NaN()
+			continue
+		}
+		values[i] = float64(num) / float64(den)
+	}
+	return values
+}
+
+// Segment returns the EXIF block as a JPEG APP1 segment for embedding in a
+// derivative image. The orientation is reset to upright, as derivatives are
+// rotated when they are made.
+func (x *EXIF) Segment() []byte {
+	if x == nil || len(x.raw) == 0 || len(x.raw)+2 > math.MaxUint16 {
+		return nil
+	}
+	payload := append([]byte(nil), x.raw...)
+	if x.orientationOffset >= 0 {
+		tiff := payload[len(exifHeader):]
+		order := binary.ByteOrder(binary.BigEndian)
+		if string(tiff[:2]) == "II" {
+			order = binary.LittleEndian
+		}
+		order.PutUint16(payload[x.orientationOffset:], 1)
+	}
+	segment := make([]byte, 4, 4+len(payload))
+	segment[0], segment[1] = 0xFF, jpegAPP1
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	return append(segment, payload...)
+}
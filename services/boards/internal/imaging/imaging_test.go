@@ -0,0 +1,266 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type tiffTag struct {
+	tag, typ uint16
+	count    uint32
+	data     []byte
+}
+
+func asciiTag(tag uint16, s string) tiffTag {
+	return tiffTag{tag, typeASCII, uint32(len(s) + 1), append([]byte(s), 0)}
+}
+
+func shortTag(order binary.ByteOrder, tag uint16, v uint16) tiffTag {
+	data := make([]byte, 2)
+	order.PutUint16(data, v)
+	return tiffTag{tag, typeShort, 1, data}
+}
+
+func longTag(order binary.ByteOrder, tag uint16, v uint32) tiffTag {
+	data := make([]byte, 4)
+	order.PutUint32(data, v)
+	return tiffTag{tag, typeLong, 1, data}
+}
+
+func rationalTag(order binary.ByteOrder, tag uint16, values ...[2]uint32) tiffTag {
+	data := make([]byte, 8*len(values))
+	for i, v := range values {
+		order.PutUint32(data[8*i:], v[0])
+		order.PutUint32(data[8*i+4:], v[1])
+	}
+	return tiffTag{tag, typeRational, uint32(len(values)), data}
+}
+
+// encodeIFD lays out a directory at offset start, followed by the values that
+// do not fit in their entries
+func encodeIFD(order binary.ByteOrder, start int, tags []tiffTag) []byte {
+	table := make([]byte, 2+12*len(tags)+4)
+	order.PutUint16(table, uint16(len(tags)))
+	var extra []byte
+	for i, t := range tags {
+		p := 2 + 12*i
+		order.PutUint16(table[p:], t.tag)
+		order.PutUint16(table[p+2:], t.typ)
+		order.PutUint32(table[p+4:], t.count)
+		if len(t.data) <= 4 {
+			copy(table[p+8:], t.data)
+		} else {
+			order.PutUint32(table[p+8:], uint32(start+len(table)+len(extra)))
+			extra = append(extra, t.data...)
+		}
+	}
+	return append(table, extra...)
+}
+
+// buildEXIF builds an APP1 payload with IFD0, an Exif IFD and a GPS IFD
+func buildEXIF(order binary.ByteOrder, ifd0, exifIFD, gpsIFD []tiffTag) []byte {
+	header := make([]byte, 8)
+	if order == binary.LittleEndian {
+		copy(header, "II")
+	} else {
+		copy(header, "MM")
+	}
+	order.PutUint16(header[2:], 42)
+	order.PutUint32(header[4:], 8)
+
+	withPointers := func(exifAt, gpsAt int) []tiffTag {
+		tags := append([]tiffTag(nil), ifd0...)
+		if exifIFD != nil {
+			tags = append(tags, longTag(order, tagExifIFD, uint32(exifAt)))
+		}
+		if gpsIFD != nil {
+			tags = append(tags, longTag(order, tagGPSIFD, uint32(gpsAt)))
+		}
+		return tags
+	}
+	size0 := len(encodeIFD(order, 8, withPointers(0, 0)))
+	exifAt := 8 + size0
+	exifBytes := encodeIFD(order, exifAt, exifIFD)
+	gpsAt := exifAt + len(exifBytes)
+	gpsBytes := encodeIFD(order, gpsAt, gpsIFD)
+
+	tiff := append(header, encodeIFD(order, 8, withPointers(exifAt, gpsAt))...)
+	if exifIFD != nil {
+		tiff = append(tiff, exifBytes...)
+	}
+	if gpsIFD != nil {
+		tiff = append(tiff, gpsBytes...)
+	}
+	return append([]byte(exifHeader), tiff...)
+}
+
+// jpegWithEXIF encodes a w x h JPEG and inserts an APP1 block after SOI
+func jpegWithEXIF(t *testing.T, w, h int, payload []byte) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	var buf bytes.Buffer
+	assert.NoError(t, jpeg.Encode(&buf, img, nil))
+	if payload == nil {
+		return buf.Bytes()
+	}
+	segment := []byte{0xFF, jpegAPP1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	data := append([]byte{}, buf.Bytes()[:2]...)
+	data = append(data, segment...)
+	data = append(data, payload...)
+	return append(data, buf.Bytes()[2:]...)
+}
+
+func sampleEXIF(order binary.ByteOrder) []byte {
+	return buildEXIF(order,
+		[]tiffTag{asciiTag(tagMake, "Canon"), asciiTag(tagModel, "EOS 5D"), shortTag(order, tagOrientation, 6)},
+		[]tiffTag{asciiTag(tagDateTimeOriginal, "2024:03:15 22:41:07"), asciiTag(tagOffsetTimeOrig, "+01:00")},
+		[]tiffTag{
+			asciiTag(tagGPSLatitudeRef, "N"),
+			rationalTag(order, tagGPSLatitude, [2]uint32{51, 1}, [2]uint32{30, 1}, [2]uint32{3600, 100}),
+			asciiTag(tagGPSLongitudeRef, "W"),
+			rationalTag(order, tagGPSLongitude, [2]uint32{0, 1}, [2]uint32{7, 1}, [2]uint32{3960, 100}),
+			{tagGPSAltitudeRef, typeByte, 1, []byte{0}},
+			rationalTag(order, tagGPSAltitude, [2]uint32{355, 10}),
+		})
+}
+
+func TestReadEXIF(t *testing.T) {
+	for _, order := range []binary.ByteOrder{binary.BigEndian, binary.LittleEndian} {
+		x, err := ReadEXIF(jpegWithEXIF(t, 8, 8, sampleEXIF(order)))
+		if !assert.NoError(t, err, order) {
+			continue
+		}
+		assert.Equal(t, "Canon", x.Make)
+		assert.Equal(t, "EOS 5D", x.Model)
+		assert.Equal(t, 6, x.Orientation)
+		assert.Equal(t, "2024-03-15T22:41:07+01:00", x.CapturedAt)
+		if assert.NotNil(t, x.Latitude) && assert.NotNil(t, x.Longitude) {
+			assert.InDelta(t, 51.51, *x.Latitude, 1e-9)
+			assert.InDelta(t, -0.1276666, *x.Longitude, 1e-6)
+		}
+		if assert.NotNil(t, x.Altitude) {
+			assert.InDelta(t, 35.5, *x.Altitude, 1e-9)
+		}
+	}
+}
+
+func TestReadEXIF_LocalTimeAndMissingData(t *testing.T) {
+	order := binary.BigEndian
+	payload := buildEXIF(order, nil, []tiffTag{asciiTag(tagDateTimeOriginal, "2023:12:01 08:00:00")}, nil)
+	x, err := ReadEXIF(jpegWithEXIF(t, 4, 4, payload))
+	assert.NoError(t, err)
+	assert.Equal(t, "2023-12-01T08:00:00", x.CapturedAt)
+	assert.Nil(t, x.Latitude)
+	assert.Equal(t, 0, x.Orientation)
+
+	_, err = ReadEXIF(jpegWithEXIF(t, 4, 4, nil))
+	assert.True(t, errors.Is(err, ErrNoEXIF))
+	_, err = ReadEXIF([]byte("\x89PNG\r\n\x1a\n"))
+	assert.True(t, errors.Is(err, ErrNoEXIF))
+}
+
+func TestReadEXIF_Malformed(t *testing.T) {
+	payload := sampleEXIF(binary.LittleEndian)
+	// Truncations and corrupted offsets must never panic
+	for cut := len(exifHeader); cut < len(payload); cut += 7 {
+		ReadEXIF(jpegWithEXIF(t, 4, 4, payload[:cut]))
+	}
+	corrupt := append([]byte(nil), payload...)
+	binary.LittleEndian.PutUint32(corrupt[len(exifHeader)+4:], 0xFFFFFF00)
+	_, err := ReadEXIF(jpegWithEXIF(t, 4, 4, corrupt))
+	assert.Error(t, err)
+}
+
+func TestEXIFSegmentResetsOrientation(t *testing.T) {
+	x, err := ReadEXIF(jpegWithEXIF(t, 8, 8, sampleEXIF(binary.LittleEndian)))
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	assert.NoError(t, EncodeJPEG(&buf, image.NewRGBA(image.Rect(0, 0, 4, 4)), 80, x))
+	copied, err := ReadEXIF(buf.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, copied.Orientation)
+	assert.Equal(t, x.CapturedAt, copied.CapturedAt)
+	assert.Equal(t, *x.Latitude, *copied.Latitude)
+	_, err = jpeg.Decode(bytes.NewReader(buf.Bytes()))
+	assert.NoError(t, err)
+
+	buf.Reset()
+	assert.NoError(t, EncodeJPEG(&buf, image.NewRGBA(image.Rect(0, 0, 4, 4)), 80, nil))
+	_, err = ReadEXIF(buf.Bytes())
+	assert.True(t, errors.Is(err, ErrNoEXIF))
+}
+
+func TestFit(t *testing.T) {
+	w, h := Fit(4000, 3000, 256)
+	assert.Equal(t, []int{256, 192}, []int{w, h})
+	w, h = Fit(3000, 4000, 256)
+	assert.Equal(t, []int{192, 256}, []int{w, h})
+	w, h = Fit(100, 50, 256)
+	assert.Equal(t, []int{100, 50}, []int{w, h})
+	w, h = Fit(10000, 1, 256)
+	assert.Equal(t, []int{256, 1}, []int{w, h})
+}
+
+func TestResize(t *testing.T) {
+	// Left half black, right half white
+	src := image.NewRGBA(image.Rect(0, 0, 8, 4))
+	for y := 0; y < 4; y++ {
+		for x := 4; x < 8; x++ {
+			src.Set(x, y, color.White)
+		}
+		for x := 0; x < 4; x++ {
+			src.Set(x, y, color.Black)
+		}
+	}
+	dst := Resize(src, 2, 1)
+	assert.Equal(t, color.RGBA{0, 0, 0, 255}, dst.RGBAAt(0, 0))
+	assert.Equal(t, color.RGBA{255, 255, 255, 255}, dst.RGBAAt(1, 0))
+
+	// Averaging a black and a white column gives grey
+	dst = Resize(src, 1, 1)
+	assert.Equal(t, uint8(127), dst.RGBAAt(0, 0).R)
+}
+
+func TestOrient(t *testing.T) {
+	// A 2x1 image: red on the left, blue on the right
+	src := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	red, blue := color.RGBA{255, 0, 0, 255}, color.RGBA{0, 0, 255, 255}
+	src.SetRGBA(0, 0, red)
+	src.SetRGBA(1, 0, blue)
+
+	rotated := Orient(src, 6)
+	assert.Equal(t, image.Rect(0, 0, 1, 2), rotated.Bounds())
+	assert.Equal(t, red, rotated.RGBAAt(0, 0))
+	assert.Equal(t, blue, rotated.RGBAAt(0, 1))
+
+	rotated = Orient(src, 8)
+	assert.Equal(t, blue, rotated.RGBAAt(0, 0))
+	assert.Equal(t, red, rotated.RGBAAt(0, 1))
+
+	mirrored := Orient(src, 2)
+	assert.Equal(t, blue, mirrored.RGBAAt(0, 0))
+	assert.Same(t, src, Orient(src, 1))
+}
+
+func TestDecodeLimitsPixels(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 100, 100))))
+	_, err := Decode(buf.Bytes(), 100*99)
+	assert.True(t, errors.Is(err, ErrTooLarge))
+	img, err := Decode(buf.Bytes(), 100*100)
+	assert.NoError(t, err)
+	assert.Equal(t, 100, img.Bounds().Dx())
+
+	// Transparent areas are flattened onto white
+	flat := Flatten(img)
+	assert.Equal(t, color.RGBA{255, 255, 255, 255}, flat.RGBAAt(0, 0))
+}
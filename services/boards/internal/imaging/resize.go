@@ -0,0 +1,171 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"io"
+
+	// Decoders for the formats previews are made from
+	_ "image/gif"
+	_ "image/png"
+)
+
+// ErrTooLarge is returned for images whose pixel count exceeds the limit, so
+// a small file cannot claim gigapixel dimensions and exhaust memory
+var ErrTooLarge = errors.New("image dimensions too large")
+
+// Decode decodes a JPEG, PNG or GIF image of at most maxPixels pixels
+func Decode(data []byte, maxPixels int) (image.Image, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxPixels {
+		return nil, fmt.Errorf("%w: %dx%d", ErrTooLarge, cfg.Width, cfg.Height)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, err
+}
+
+// Fit returns the size of an image scaled down to fit in a square of side
+// maxSide, keeping the aspect ratio. Images are never scaled up.
+func Fit(width, height, maxSide int) (int, int) {
+	if width <= maxSide && height <= maxSide {
+		return width, height
+	}
+	if width >= height {
+		h := int(float64(height)*float64(maxSide)/float64(width) + 0.5)
+		return maxSide, max(h, 1)
+	}
+	w := int(float64(width)*float64(maxSide)/float64(height) + 0.5)
+	return max(w, 1), maxSide
+}
+
+// Flatten converts an image to RGBA on a white background, so transparent
+// PNG and GIF areas do not turn black in a JPEG
+func Flatten(img image.Image) *image.RGBA {
+	b := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Over)
+	return dst
+}
+
+// Resize scales an opaque image down to width x height by averaging the
+// source pixels each target pixel covers. It works in two passes, first
+// horizontally and then vertically.
+func Resize(src *image.RGBA, width, height int) *image.RGBA {
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	if width == sw && height == sh {
+		return src
+	}
+	tmp := image.NewRGBA(image.Rect(0, 0, width, sh))
+	for y := 0; y < sh; y++ {
+		srcRow := src.Pix[y*src.Stride:]
+		dstRow := tmp.Pix[y*tmp.Stride:]
+		for x := 0; x < width; x++ {
+			x0, x1 := span(x, width, sw)
+			var r, g, b, a uint32
+			for sx := x0; sx < x1; sx++ {
+				p := srcRow[sx*4 : sx*4+4]
+				r += uint32(p[0])
+				g += uint32(p[1])
+				b += uint32(p[2])
+				a += uint32(p[3])
+			}
+			n := uint32(x1 - x0)
+			d := dstRow[x*4 : x*4+4]
+			d[0], d[1], d[2], d[3] = uint8(r/n), uint8(g/n), uint8(b/n), uint8(a/n)
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0, y1 := span(y, height, sh)
+		n := uint32(y1 - y0)
+		dstRow := dst.Pix[y*dst.Stride:]
+		for x := 0; x < width*4; x++ {
+			var sum uint32
+			for sy := y0; sy < y1; sy++ {
+				sum += uint32(tmp.Pix[sy*tmp.Stride+x])
+			}
+			dstRow[x] = uint8(sum / n)
+		}
+	}
+	return dst
+}
+
+// span is the range of source pixels covered by target pixel i
+func span(i, target, source int) (int, int) {
+	start := i * source / target
+	end := (i + 1) * source / target
+	if end <= start {
+		end = start + 1
+	}
+	return start, end
+}
+
+// Orient turns an image upright according to an EXIF orientation
+func Orient(src *image.RGBA, orientation int) *image.RGBA {
+	if orientation < 2 || orientation > 8 {
+		return src
+	}
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // Mirrored horizontally
+				dx, dy = w-1-x, y
+			case 3: // Rotated 180°
+				dx, dy = w-1-x, h-1-y
+			case 4: // Mirrored vertically
+				dx, dy = x, h-1-y
+			case 5: // Mirrored along the top-left diagonal
+				dx, dy = y, x
+			case 6: // Needs 90° clockwise rotation
+				dx, dy = h-1-y, x
+			case 7: // Mirrored along the top-right diagonal
+				dx, dy = h-1-y, w-1-x
+			case 8: // Needs 90° counter-clockwise rotation
+				dx, dy = y, w-1-x
+			}
+			copy(dst.Pix[dy*dst.Stride+dx*4:dy*dst.Stride+dx*4+4], src.Pix[y*src.Stride+x*4:y*src.Stride+x*4+4])
+		}
+	}
+	return dst
+}
+
+// EncodeJPEG writes img as a JPEG. When exif is given its block is embedded
+// right after the start-of-image marker; otherwise the output carries no
+// metadata at all.
+func EncodeJPEG(w io.Writer, img image.Image, quality int, exif *EXIF) error {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		return err
+	}
+	data := buf.Bytes()
+	segment := exif.Segment()
+	if segment == nil {
+		_, err := w.Write(data)
+		return err
+	}
+	if _, err := w.Write(data[:2]); err != nil {
+		return err
+	}
+	if _, err := w.Write(segment); err != nil {
+		return err
+	}
+	_, err := w.Write(data[2:])
+	return err
+}
@@ -0,0 +1,159 @@
+// Package itemtype is the registry of board item types. Each type describes
+// its structured fields with a JSON Schema; an item's field values are
+// validated against it and stored in the item's fields column.
+package itemtype
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"evidence-wall/shared/models"
+)
+
+var ErrUnknownType = errors.New("unknown item type")
+
+//go:embed schemas/*.json
+var schemaFiles embed.FS
+
+// Type is a registered item type
+type Type struct {
+	Name        string          `json:"name"`
+	Title       string          `json:"title"`
+	Description string          `json:"description"`
+	Schema      json.RawMessage `json:"schema"` // JSON Schema of the item's fields
+
+	schema *Schema
+	fields []Field
+}
+
+// Field is a field declared by a type's schema
+type Field struct {
+	Name  string
+	Title string
+}
+
+// Built-in types in display order
+var names = []string{
+	string(models.ItemTypePostIt),
+	string(models.ItemTypeSuspectCard),
+	string(models.ItemTypeLocation),
+	string(models.ItemTypeArea),
+	string(models.ItemTypeEvent),
+	string(models.ItemTypeDocument),
+	string(models.ItemTypePhone),
+	string(models.ItemTypeVehicle),
+	string(models.ItemTypeFrame),
+}
+
+var (
+	registry = map[string]*Type{}
+	ordered  []*Type
+)
+
+func init() {
+	for _, name := range names {
+		data, err := schemaFiles.ReadFile("schemas/" + name + ".json")
+		if err != nil {
+			panic(err)
+		}
+		t, err := newType(name, data)
+		if err != nil {
+			panic(fmt.Sprintf("item type %s: %v", name, err))
+		}
+		registry[name] = t
+		ordered = append(ordered, t)
+	}
+}
+
+func newType(name string, data []byte) (*Type, error) {
+	schema, err := ParseSchema(data)
+	if err != nil {
+		return nil, err
+	}
+	order, err := propertyOrder(data)
+	if err != nil {
+		return nil, err
+	}
+	t := &Type{
+		Name:        name,
+		Title:       schema.Title,
+		Description: schema.Description,
+		Schema:      json.RawMessage(data),
+		schema:      schema,
+	}
+	for _, key := range order {
+		title := schema.Properties[key].Title
+		if title == "" {
+			title = key
+		}
+		t.fields = append(t.fields, Field{Name: key, Title: title})
+	}
+	return t, nil
+}
+
+// Lookup returns the registered type with the given name
+func Lookup(name string) (*Type, bool) {
+	t, ok := registry[name]
+	return t, ok
+}
+
+// All returns every registered type in display order
+func All() []*Type {
+	return append([]*Type(nil), ordered...)
+}
+
+// Fields lists the type's fields in the order the schema declares them
+func (t *Type) Fields() []Field {
+	return append([]Field(nil), t.fields...)
+}
+
+// Validate checks field values against the type's schema. Nil means no
+// fields, which is valid for types without required fields.
+func (t *Type) Validate(fields map[string]interface{}) error {
+	if fields == nil {
+		fields = map[string]interface{}{}
+	}
+	return t.schema.Validate(fields)
+}
+
+// Validate checks field values against the schema of the named type
+func Validate(name string, fields map[string]interface{}) error {
+	t, ok := Lookup(name)
+	if !ok {
+		return fmt.Errorf("%w %q", ErrUnknownType, name)
+	}
+	return t.Validate(fields)
+}
+
+// propertyOrder returns the keys of a schema's "properties" object in
+// document order, which maps lose
+func propertyOrder(data []byte) ([]string, error) {
+	var top map[string]json.RawMessage
+	if err := json.Unmarshal(data, &top); err != nil {
+		return nil, err
+	}
+	props, ok := top["properties"]
+	if !ok {
+		return nil, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(props))
+	if _, err := dec.Token(); err != nil { // {
+		return nil, err
+	}
+	var keys []string
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, tok.(string))
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
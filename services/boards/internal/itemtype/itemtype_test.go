@@ -0,0 +1,80 @@
+package itemtype
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	all := All()
+	assert.Len(t, all, len(names))
+	for i, name := range names {
+		assert.Equal(t, name, all[i].Name)
+		assert.NotEmpty(t, all[i].Title)
+	}
+
+	location, ok := Lookup("location")
+	assert.True(t, ok)
+	assert.Equal(t, []Field{
+		{Name: "lat", Title: "Latitude"},
+		{Name: "lon", Title: "Longitude"},
+		{Name: "address", Title: "Address"},
+		{Name: "accuracy_m", Title: "Accuracy (metres)"},
+	}, location.Fields())
+
+	_, ok = Lookup("note")
+	assert.False(t, ok)
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		itemType string
+		fields   map[string]interface{}
+		field    string
+	}{
+		{name: "post-it without fields", itemType: "post-it"},
+		{name: "post-it rejects fields", itemType: "post-it", fields: map[string]interface{}{"x": "y"}, field: "x"},
+		{name: "location", itemType: "location", fields: map[string]interface{}{"lat": 51.5, "lon": -0.12}},
+		{name: "location missing lon", itemType: "location", fields: map[string]interface{}{"lat": 51.5}, field: "lon"},
+		{name: "location out of range", itemType: "location", fields: map[string]interface{}{"lat": 91.0, "lon": 0.0}, field: "lat"},
+		{name: "latitude as text", itemType: "location", fields: map[string]interface{}{"lat": "51.5", "lon": 0.0}, field: "lat"},
+		{name: "area", itemType: "area", fields: map[string]interface{}{"polygon": []interface{}{
+			map[string]interface{}{"lat": 51.5, "lon": -0.12}, map[string]interface{}{"lat": 51.6, "lon": -0.12}, map[string]interface{}{"lat": 51.6, "lon": -0.1},
+		}}},
+		{name: "area needs three points", itemType: "area", fields: map[string]interface{}{"polygon": []interface{}{
+			map[string]interface{}{"lat": 51.5, "lon": -0.12}, map[string]interface{}{"lat": 51.6, "lon": -0.12},
+		}}, field: "polygon"},
+		{name: "area point out of range", itemType: "area", fields: map[string]interface{}{"polygon": []interface{}{
+			map[string]interface{}{"lat": 51.5, "lon": -0.12}, map[string]interface{}{"lat": 51.6, "lon": -0.12}, map[string]interface{}{"lat": 51.6, "lon": 190.0},
+		}}, field: "polygon[2].lon"},
+		{name: "event", itemType: "event", fields: map[string]interface{}{"timestamp": "2024-05-01T21:30:00Z", "approximate": true}},
+		{name: "event bad timestamp", itemType: "event", fields: map[string]interface{}{"timestamp": "yesterday"}, field: "timestamp"},
+		{name: "suspect dob", itemType: "suspect-card", fields: map[string]interface{}{"name": "John", "dob": "1980-02-30"}, field: "dob"},
+		{name: "suspect photo scheme", itemType: "suspect-card", fields: map[string]interface{}{"photo": "javascript:alert(1)"}, field: "photo"},
+		{name: "phone", itemType: "phone", fields: map[string]interface{}{"number": "+44 20 7946 0018", "kind": "burner"}},
+		{name: "phone kind", itemType: "phone", fields: map[string]interface{}{"number": "555 0100", "kind": "pager"}, field: "kind"},
+		{name: "phone number", itemType: "phone", fields: map[string]interface{}{"number": "call me"}, field: "number"},
+		{name: "vehicle year", itemType: "vehicle", fields: map[string]interface{}{"year": 1999.5}, field: "year"},
+		{name: "vehicle vin", itemType: "vehicle", fields: map[string]interface{}{"vin": "1HGCM82633A00435"}, field: "vin"},
+		{name: "null values are ignored", itemType: "document", fields: map[string]interface{}{"title": nil}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.itemType, tt.fields)
+			if tt.field == "" {
+				assert.NoError(t, err)
+				return
+			}
+			var fieldErr *FieldError
+			if assert.True(t, errors.As(err, &fieldErr), "got %v", err) {
+				assert.Equal(t, tt.field, fieldErr.Field)
+			}
+		})
+	}
+
+	assert.True(t, errors.Is(Validate("hologram", nil), ErrUnknownType))
+}
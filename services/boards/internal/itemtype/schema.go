@@ -0,0 +1,246 @@
+package itemtype
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"sort"
+	"time"
+	"unicode/utf8"
+)
+
+// Schema is the subset of JSON Schema used to describe item fields: typed
+// properties with required keys, enums, length, range, item count, pattern
+// and format constraints. Unsupported keywords are ignored.
+type Schema struct {
+	Type                 string             `json:"type,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Format               string             `json:"format,omitempty"` // date, date-time or uri
+
+	pattern *regexp.Regexp
+}
+
+// FieldError reports the first field that failed validation
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return e.Field + ": " + e.Message
+}
+
+// ParseSchema decodes a schema and compiles its patterns
+func ParseSchema(data []byte) (*Schema, error) {
+	var s Schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	if err := s.compile(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (s *Schema) compile() error {
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", s.Pattern, err)
+		}
+		s.pattern = re
+	}
+	for _, prop := range s.Properties {
+		if err := prop.compile(); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil {
+		return s.Items.compile()
+	}
+	return nil
+}
+
+// Validate checks a decoded JSON value (as produced by encoding/json into an
+// interface{}) against the schema
+func (s *Schema) Validate(value interface{}) error {
+	return s.validate("", value)
+}
+
+func (s *Schema) validate(path string, value interface{}) error {
+	fail := func(format string, args ...interface{}) error {
+		return &FieldError{Field: path, Message: fmt.Sprintf(format, args...)}
+	}
+
+	if len(s.Enum) > 0 {
+		found := false
+		for _, option := range s.Enum {
+			if equalJSON(option, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fail("must be one of %v", s.Enum)
+		}
+	}
+
+	switch s.Type {
+	case "", "any":
+		return nil
+
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return fail("must be an object")
+		}
+		for _, key := range s.Required {
+			if v, ok := obj[key]; !ok || v == nil {
+				return &FieldError{Field: join(path, key), Message: "is required"}
+			}
+		}
+		keys := make([]string, 0, len(obj))
+		for key := range obj {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			prop, ok := s.Properties[key]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					return &FieldError{Field: join(path, key), Message: "is not a known field"}
+				}
+				continue
+			}
+			if obj[key] == nil {
+				continue
+			}
+			if err := prop.validate(join(path, key), obj[key]); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case "array":
+		arr, ok := value.([]interface{})
+		if !ok {
+			return fail("must be an array")
+		}
+		if s.MinItems != nil && len(arr) < *s.MinItems {
+			return fail("must have at least %d entries", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(arr) > *s.MaxItems {
+			return fail("must have at most %d entries", *s.MaxItems)
+		}
+		if s.Items != nil {
+			for i, v := range arr {
+				if err := s.Items.validate(fmt.Sprintf("%s[%d]", path, i), v); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return fail("must be a string")
+		}
+		length := utf8.RuneCountInString(str)
+		if s.MinLength != nil && length < *s.MinLength {
+			return fail("must be at least %d characters", *s.MinLength)
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			return fail("must be at most %d characters", *s.MaxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(str) {
+			return fail("has an invalid format")
+		}
+		return validateFormat(s.Format, str, fail)
+
+	case "number", "integer":
+		num, ok := value.(float64)
+		if !ok {
+			return fail("must be a number")
+		}
+		if s.Type == "integer" && num != math.Trunc(num) {
+			return fail("must be a whole number")
+		}
+		if s.Minimum != nil && num < *s.Minimum {
+			return fail("must be at least %v", *s.Minimum)
+		}
+		if s.Maximum != nil && num > *s.Maximum {
+			return fail("must be at most %v", *s.Maximum)
+		}
+		return nil
+
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fail("must be true or false")
+		}
+		return nil
+	}
+	return fail("has unsupported schema type %q", s.Type)
+}
+
+func validateFormat(format, value string, fail func(string, ...interface{}) error) error {
+	switch format {
+	case "date":
+		if _, err := time.Parse("2006-01-02", value); err != nil {
+			return fail("must be a date (YYYY-MM-DD)")
+		}
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			return fail("must be an RFC 3339 timestamp")
+		}
+	case "uri":
+		u, err := url.Parse(value)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fail("must be an http or https URL")
+		}
+	}
+	return nil
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// equalJSON compares two decoded JSON scalars
+func equalJSON(a, b interface{}) bool {
+	switch av := a.(type) {
+	case string:
+		bv, ok := b.(string)
+		return ok && av == bv
+	case float64:
+		bv, ok := b.(float64)
+		return ok && av == bv
+	case bool:
+		bv, ok := b.(bool)
+		return ok && av == bv
+	case nil:
+		return b == nil
+	}
+	return false
+}
@@ -0,0 +1,433 @@
+// Package layout computes automatic arrangements for board items. Items are
+// boxes of known size; connections are undirected edges between them. Every
+// algorithm is deterministic and keeps the arrangement anchored at the
+// top-left corner of the items' current bounding box, so a laid out
+// selection stays where it was on the board.
+package layout
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+// Algorithm names a layout strategy
+type Algorithm string
+
+const (
+	Force        Algorithm = "force"
+	Hierarchical Algorithm = "hierarchical"
+	Circular     Algorithm = "circular"
+	Grid         Algorithm = "grid"
+)
+
+var ErrUnknownAlgorithm = errors.New("unknown layout algorithm")
+
+// DefaultSpacing is the gap between items, in board units
+const DefaultSpacing = 40.0
+
+// Node is an item to be placed; X and Y are its current top-left corner
+type Node struct {
+	ID     uuid.UUID
+	X, Y   float64
+	Width  float64
+	Height float64
+}
+
+// Edge connects two nodes. Edges with an endpoint outside the node set are
+// ignored, which lets callers pass every connection on the board when only
+// a selection is being laid out.
+type Edge struct {
+	From, To uuid.UUID
+}
+
+// Position is the new top-left corner of a node
+type Position struct {
+	ID uuid.UUID `json:"item_id"`
+	X  float64   `json:"x"`
+	Y  float64   `json:"y"`
+}
+
+// Options tunes a layout
+type Options struct {
+	Spacing float64 // Gap between items; 0 means DefaultSpacing
+}
+
+// Compute returns new positions for nodes, in the same order as nodes
+func Compute(algorithm Algorithm, nodes []Node, edges []Edge, opts Options) ([]Position, error) {
+	if opts.Spacing <= 0 {
+		opts.Spacing = DefaultSpacing
+	}
+	g := newGraph(nodes, edges)
+
+	// Layouts produce centre points
+	var centres []point
+	switch algorithm {
+	case Force:
+		centres = forceDirected(g, opts)
+	case Hierarchical:
+		centres = hierarchical(g, opts)
+	case Circular:
+		centres = circular(g, opts)
+	case Grid:
+		centres = grid(g, opts)
+	default:
+		return nil, ErrUnknownAlgorithm
+	}
+
+	// Anchor the new arrangement where the old one was
+	oldX, oldY := math.Inf(1), math.Inf(1)
+	newX, newY := math.Inf(1), math.Inf(1)
+	for i, n := range nodes {
+		oldX, oldY = math.Min(oldX, n.X), math.Min(oldY, n.Y)
+		newX = math.Min(newX, centres[i].x-n.Width/2)
+		newY = math.Min(newY, centres[i].y-n.Height/2)
+	}
+
+	out := make([]Position, len(nodes))
+	for i, n := range nodes {
+		out[i] = Position{
+			ID: n.ID,
+			X:  math.Round(centres[i].x - n.Width/2 - newX + oldX),
+			Y:  math.Round(centres[i].y - n.Height/2 - newY + oldY),
+		}
+	}
+	return out, nil
+}
+
+type point struct{ x, y float64 }
+
+// graph is the node set with adjacency by index
+type graph struct {
+	nodes []Node
+	adj   [][]int // sorted, without duplicates or self-loops
+}
+
+func newGraph(nodes []Node, edges []Edge) *graph {
+	index := make(map[uuid.UUID]int, len(nodes))
+	for i, n := range nodes {
+		index[n.ID] = i
+	}
+	g := &graph{nodes: nodes, adj: make([][]int, len(nodes))}
+	seen := make(map[[2]int]bool)
+	for _, e := range edges {
+		a, okA := index[e.From]
+		b, okB := index[e.To]
+		if !okA || !okB || a == b {
+			continue
+		}
+		if a > b {
+			a, b = b, a
+		}
+		if seen[[2]int{a, b}] {
+			continue
+		}
+		seen[[2]int{a, b}] = true
+		g.adj[a] = append(g.adj[a], b)
+		g.adj[b] = append(g.adj[b], a)
+	}
+	for _, neighbours := range g.adj {
+		sort.Ints(neighbours)
+	}
+	return g
+}
+
+func (g *graph) centre(i int) point {
+	n := g.nodes[i]
+	return point{n.X + n.Width/2, n.Y + n.Height/2}
+}
+
+// readingOrder returns node indexes sorted top to bottom, then left to right
+func (g *graph) readingOrder() []int {
+	order := make([]int, len(g.nodes))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		na, nb := g.nodes[order[a]], g.nodes[order[b]]
+		if na.Y != nb.Y {
+			return na.Y < nb.Y
+		}
+		return na.X < nb.X
+	})
+	return order
+}
+
+// traversalOrder lists nodes component by component in breadth-first order,
+// starting each component from its best connected node, so connected items
+// end up next to each other
+func (g *graph) traversalOrder() []int {
+	visited := make([]bool, len(g.nodes))
+	var order []int
+	starts := g.readingOrder()
+	sort.SliceStable(starts, func(a, b int) bool { return len(g.adj[starts[a]]) > len(g.adj[starts[b]]) })
+	for _, s := range starts {
+		if visited[s] {
+			continue
+		}
+		visited[s] = true
+		queue := []int{s}
+		for len(queue) > 0 {
+			v := queue[0]
+			queue = queue[1:]
+			order = append(order, v)
+			for _, w := range g.adj[v] {
+				if !visited[w] {
+					visited[w] = true
+					queue = append(queue, w)
+				}
+			}
+		}
+	}
+	return order
+}
+
+// grid places nodes row by row in reading order, in equal cells sized for
+// the largest node
+func grid(g *graph, opts Options) []point {
+	n := len(g.nodes)
+	out := make([]point, n)
+	if n == 0 {
+		return out
+	}
+	var cellW, cellH float64
+	for _, node := range g.nodes {
+		cellW = math.Max(cellW, node.Width)
+		cellH = math.Max(cellH, node.Height)
+	}
+	cellW += opts.Spacing
+	cellH += opts.Spacing
+	cols := int(math.Ceil(math.Sqrt(float64(n))))
+	for k, i := range g.readingOrder() {
+		out[i] = point{float64(k%cols)*cellW + cellW/2, float64(k/cols)*cellH + cellH/2}
+	}
+	return out
+}
+
+// circular places nodes evenly around a circle, starting at the top and
+// going clockwise in traversal order. The radius leaves room for every node.
+func circular(g *graph, opts Options) []point {
+	n := len(g.nodes)
+	out := make([]point, n)
+	if n < 2 {
+		return out
+	}
+	var circumference, largest float64
+	for _, node := range g.nodes {
+		size := math.Hypot(node.Width, node.Height)
+		circumference += size + opts.Spacing
+		largest = math.Max(largest, size)
+	}
+	radius := math.Max(circumference/(2*math.Pi), largest)
+	for k, i := range g.traversalOrder() {
+		angle := 2*math.Pi*float64(k)/float64(n) - math.Pi/2
+		out[i] = point{radius * math.Cos(angle), radius * math.Sin(angle)}
+	}
+	return out
+}
+
+// hierarchical arranges each connected component in layers by distance from
+// its best connected node, orders every layer by the barycentre of its
+// neighbours in the layer above to reduce crossings, and places components
+// side by side
+func hierarchical(g *graph, opts Options) []point {
+	n := len(g.nodes)
+	out := make([]point, n)
+	depth := make([]int, n)
+	for i := range depth {
+		depth[i] = -1
+	}
+
+	var offsetX float64
+	order := g.traversalOrder()
+	for start := 0; start < len(order); {
+		// Collect one component; traversal order lists components contiguously
+		root := order[start]
+		depth[root] = 0
+		var layers [][]int
+		queue := []int{root}
+		end := start
+		for len(queue) > 0 {
+			v := queue[0]
+			queue = queue[1:]
+			end++
+			for len(layers) <= depth[v] {
+				layers = append(layers, nil)
+			}
+			layers[depth[v]] = append(layers[depth[v]], v)
+			for _, w := range g.adj[v] {
+				if depth[w] < 0 {
+					depth[w] = depth[v] + 1
+					queue = append(queue, w)
+				}
+			}
+		}
+		start = end
+
+		// Barycentre ordering: position of each node is its index in its layer
+		rank := make(map[int]float64)
+		for i, v := range layers[0] {
+			rank[v] = float64(i)
+		}
+		for d := 1; d < len(layers); d++ {
+			bary := make(map[int]float64, len(layers[d]))
+			for _, v := range layers[d] {
+				var sum float64
+				var count int
+				for _, w := range g.adj[v] {
+					if depth[w] == d-1 {
+						sum += rank[w]
+						count++
+					}
+				}
+				bary[v] = sum / float64(count)
+			}
+			layer := layers[d]
+			sort.SliceStable(layer, func(a, b int) bool { return bary[layer[a]] < bary[layer[b]] })
+			for i, v := range layer {
+				rank[v] = float64(i)
+			}
+		}
+
+		// Place layers top to bottom, each centred on the widest layer
+		widths := make([]float64, len(layers))
+		var maxWidth float64
+		for d, layer := range layers {
+			for _, v := range layer {
+				widths[d] += g.nodes[v].Width + opts.Spacing
+			}
+			widths[d] -= opts.Spacing
+			maxWidth = math.Max(maxWidth, widths[d])
+		}
+		var y float64
+		for d, layer := range layers {
+			var height float64
+			for _, v := range layer {
+				height = math.Max(height, g.nodes[v].Height)
+			}
+			x := offsetX + (maxWidth-widths[d])/2
+			for _, v := range layer {
+				node := g.nodes[v]
+				out[v] = point{x + node.Width/2, y + height/2}
+				x += node.Width + opts.Spacing
+			}
+			y += height + 2*opts.Spacing
+		}
+		offsetX += maxWidth + 2*opts.Spacing
+	}
+	return out
+}
+
+// forceDirected runs a Fruchterman-Reingold simulation starting from the
+// current positions: connected nodes attract, all nodes repel, and the step
+// size cools every iteration. A final pass pushes apart overlapping boxes.
+func forceDirected(g *graph, opts Options) []point {
+	const iterations = 300
+	n := len(g.nodes)
+	pos := make([]point, n)
+	if n == 0 {
+		return pos
+	}
+
+	var size float64
+	for i := range g.nodes {
+		pos[i] = g.centre(i)
+		size += math.Hypot(g.nodes[i].Width, g.nodes[i].Height)
+	}
+	// Ideal edge length: a typical item diagonal plus the spacing
+	k := size/float64(n) + opts.Spacing
+	temperature := k * math.Sqrt(float64(n))
+
+	disp := make([]point, n)
+	for iter := 0; iter < iterations; iter++ {
+		for i := range disp {
+			disp[i] = point{}
+		}
+		for i := 0; i < n; i++ {
+			for j := i + 1; j < n; j++ {
+				dx, dy := pos[i].x-pos[j].x, pos[i].y-pos[j].y
+				dist := math.Hypot(dx, dy)
+				if dist < 0.01 {
+					// Separate coincident nodes in a fixed direction
+					angle := float64(i*7+j*13) * 0.7
+					dx, dy, dist = math.Cos(angle)*0.01, math.Sin(angle)*0.01, 0.01
+				}
+				force := k * k / dist
+				fx, fy := dx/dist*force, dy/dist*force
+				disp[i].x += fx
+				disp[i].y += fy
+				disp[j].x -= fx
+				disp[j].y -= fy
+			}
+		}
+		for i, neighbours := range g.adj {
+			for _, j := range neighbours {
+				if j < i {
+					continue
+				}
+				dx, dy := pos[i].x-pos[j].x, pos[i].y-pos[j].y
+				dist := math.Max(math.Hypot(dx, dy), 0.01)
+				force := dist * dist / k
+				fx, fy := dx/dist*force, dy/dist*force
+				disp[i].x -= fx
+				disp[i].y -= fy
+				disp[j].x += fx
+				disp[j].y += fy
+			}
+		}
+		for i := range pos {
+			length := math.Hypot(disp[i].x, disp[i].y)
+			if length > 0 {
+				step := math.Min(length, temperature)
+				pos[i].x += disp[i].x / length * step
+				pos[i].y += disp[i].y / length * step
+			}
+		}
+		temperature *= 0.97
+	}
+
+	removeOverlaps(g, pos, opts.Spacing)
+	return pos
+}
+
+// removeOverlaps nudges overlapping boxes apart along the axis of least
+// overlap until none overlap or the pass limit is reached
+func removeOverlaps(g *graph, pos []point, spacing float64) {
+	const passes = 50
+	half := spacing / 2
+	for pass := 0; pass < passes; pass++ {
+		moved := false
+		for i := range pos {
+			for j := i + 1; j < len(pos); j++ {
+				a, b := g.nodes[i], g.nodes[j]
+				dx, dy := pos[j].x-pos[i].x, pos[j].y-pos[i].y
+				overlapX := (a.Width+b.Width)/2 + half - math.Abs(dx)
+				overlapY := (a.Height+b.Height)/2 + half - math.Abs(dy)
+				if overlapX <= 0 || overlapY <= 0 {
+					continue
+				}
+				moved = true
+				if overlapX < overlapY {
+					shift := overlapX / 2
+					if dx < 0 {
+						shift = -shift
+					}
+					pos[i].x -= shift
+					pos[j].x += shift
+				} else {
+					shift := overlapY / 2
+					if dy < 0 {
+						shift = -shift
+					}
+					pos[i].y -= shift
+					pos[j].y += shift
+				}
+			}
+		}
+		if !moved {
+			return
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package layout
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// testNodes returns n 200x200 items stacked on the same spot
+func testNodes(n int) []Node {
+	nodes := make([]Node, n)
+	for i := range nodes {
+		nodes[i] = Node{ID: uuid.New(), X: 100, Y: 50, Width: 200, Height: 200}
+	}
+	return nodes
+}
+
+func chain(nodes []Node) []Edge {
+	var edges []Edge
+	for i := 1; i < len(nodes); i++ {
+		edges = append(edges, Edge{From: nodes[i-1].ID, To: nodes[i].ID})
+	}
+	return edges
+}
+
+func assertNoOverlap(t *testing.T, nodes []Node, positions []Position) {
+	t.Helper()
+	for i := range positions {
+		for j := i + 1; j < len(positions); j++ {
+			a, b := positions[i], positions[j]
+			overlapX := a.X < b.X+nodes[j].Width && b.X < a.X+nodes[i].Width
+			overlapY := a.Y < b.Y+nodes[j].Height && b.Y < a.Y+nodes[i].Height
+			assert.False(t, overlapX && overlapY, "items %d and %d overlap", i, j)
+		}
+	}
+}
+
+func assertAnchored(t *testing.T, positions []Position, x, y float64) {
+	t.Helper()
+	minX, minY := math.Inf(1), math.Inf(1)
+	for _, p := range positions {
+		minX, minY = math.Min(minX, p.X), math.Min(minY, p.Y)
+	}
+	assert.InDelta(t, x, minX, 1)
+	assert.InDelta(t, y, minY, 1)
+}
+
+func TestAllAlgorithms(t *testing.T) {
+	for _, algorithm := range []Algorithm{Force, Hierarchical, Circular, Grid} {
+		t.Run(string(algorithm), func(t *testing.T) {
+			nodes := testNodes(7)
+			edges := append(chain(nodes[:4]), Edge{From: nodes[4].ID, To: nodes[5].ID})
+
+			positions, err := Compute(algorithm, nodes, edges, Options{})
+			assert.NoError(t, err)
+			assert.Len(t, positions, len(nodes))
+			for i, p := range positions {
+				assert.Equal(t, nodes[i].ID, p.ID)
+			}
+			assertNoOverlap(t, nodes, positions)
+			assertAnchored(t, positions, 100, 50)
+
+			// Deterministic
+			again, _ := Compute(algorithm, nodes, edges, Options{})
+			assert.Equal(t, positions, again)
+		})
+	}
+}
+
+func TestUnknownAlgorithm(t *testing.T) {
+	_, err := Compute("spiral", testNodes(2), nil, Options{})
+	assert.Equal(t, ErrUnknownAlgorithm, err)
+}
+
+func TestGrid(t *testing.T) {
+	nodes := testNodes(4)
+	// Reading order follows the current positions
+	for i := range nodes {
+		nodes[i].X = float64(300 - i*100)
+		nodes[i].Y = 0
+	}
+	positions, err := Compute(Grid, nodes, nil, Options{Spacing: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, Position{ID: nodes[3].ID, X: 0, Y: 0}, positions[3])
+	assert.Equal(t, Position{ID: nodes[2].ID, X: 210, Y: 0}, positions[2])
+	assert.Equal(t, Position{ID: nodes[1].ID, X: 0, Y: 210}, positions[1])
+	assert.Equal(t, Position{ID: nodes[0].ID, X: 210, Y: 210}, positions[0])
+}
+
+func TestHierarchicalLayers(t *testing.T) {
+	// A root with two children, one of which has a child
+	nodes := testNodes(4)
+	edges := []Edge{
+		{From: nodes[0].ID, To: nodes[1].ID},
+		{From: nodes[0].ID, To: nodes[2].ID},
+		{From: nodes[1].ID, To: nodes[3].ID},
+	}
+	positions, err := Compute(Hierarchical, nodes, edges, Options{})
+	assert.NoError(t, err)
+	assert.Less(t, positions[0].Y, positions[1].Y)
+	assert.Equal(t, positions[1].Y, positions[2].Y)
+	assert.Less(t, positions[1].Y, positions[3].Y)
+}
+
+func TestForceKeepsNeighboursClose(t *testing.T) {
+	// Two separate pairs: each item should end up nearer its partner
+	nodes := testNodes(4)
+	for i := range nodes {
+		nodes[i].X = float64(i * 50)
+	}
+	edges := []Edge{{From: nodes[0].ID, To: nodes[2].ID}, {From: nodes[1].ID, To: nodes[3].ID}}
+	positions, err := Compute(Force, nodes, edges, Options{})
+	assert.NoError(t, err)
+
+	dist := func(a, b Position) float64 { return math.Hypot(a.X-b.X, a.Y-b.Y) }
+	assert.Less(t, dist(positions[0], positions[2]), dist(positions[0], positions[1]))
+	assert.Less(t, dist(positions[1], positions[3]), dist(positions[1], positions[2]))
+}
+
+func TestEdgesOutsideSelectionIgnored(t *testing.T) {
+	nodes := testNodes(2)
+	edges := []Edge{{From: nodes[0].ID, To: uuid.New()}}
+	positions, err := Compute(Circular, nodes, edges, Options{})
+	assert.NoError(t, err)
+	assert.Len(t, positions, 2)
+}
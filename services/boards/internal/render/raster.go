@@ -0,0 +1,325 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"sort"
+
+	"evidence-wall/shared/models"
+)
+
+// PNG writes the board as a PNG image
+func PNG(w io.Writer, board *models.Board, opts Options) error {
+	img, err := Image(board, opts)
+	if err != nil {
+		return err
+	}
+	return png.Encode(w, img)
+}
+
+// Image rasterizes the board. Shapes are filled with an anti-aliased
+// non-zero winding scanline rasterizer; text uses a built-in bitmap font.
+func Image(board *models.Board, opts Options) (*image.RGBA, error) {
+	view, scale, err := resolve(board, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	width := int(math.Ceil(view.Width * scale))
+	height := int(math.Ceil(view.Height * scale))
+	p := &rasterPainter{
+		img:   image.NewRGBA(image.Rect(0, 0, width, height)),
+		view:  view,
+		scale: scale,
+		cover: make([]float32, width+2),
+	}
+	draw(p, board, view)
+	return p.img, nil
+}
+
+// subsamples is the number of sample rows per pixel row
+const subsamples = 4
+
+type point struct{ X, Y float64 }
+
+type rasterPainter struct {
+	img   *image.RGBA
+	view  Rect
+	scale float64
+	cover []float32
+}
+
+// device maps a board coordinate, rotated by rot, to image pixels
+func (p *rasterPainter) device(x, y float64, rot rotation) point {
+	x, y = rot.apply(x, y)
+	return point{(x - p.view.X) * p.scale, (y - p.view.Y) * p.scale}
+}
+
+func (p *rasterPainter) rect(r Rect, radius float64, fill color.NRGBA, rot rotation) {
+	p.fill([][]point{p.roundedRect(r, radius, rot)}, fill)
+}
+
+func (p *rasterPainter) roundedRect(r Rect, radius float64, rot rotation) []point {
+	radius = math.Min(radius, math.Min(r.Width, r.Height)/2)
+	if radius*p.scale < 0.5 {
+		return []point{
+			p.device(r.X, r.Y, rot), p.device(r.X+r.Width, r.Y, rot),
+			p.device(r.X+r.Width, r.Y+r.Height, rot), p.device(r.X, r.Y+r.Height, rot),
+		}
+	}
+	corners := []struct{ cx, cy, start float64 }{
+		{r.X + r.Width - radius, r.Y + radius, -90},
+		{r.X + r.Width - radius, r.Y + r.Height - radius, 0},
+		{r.X + radius, r.Y + r.Height - radius, 90},
+		{r.X + radius, r.Y + radius, 180},
+	}
+	var poly []point
+	for _, c := range corners {
+		for i := 0; i <= 4; i++ {
+			a := (c.start + float64(i)*22.5) * math.Pi / 180
+			poly = append(poly, p.device(c.cx+radius*math.Cos(a), c.cy+radius*math.Sin(a), rot))
+		}
+	}
+	return poly
+}
+
+func (p *rasterPainter) circle(cx, cy, radius float64, fill color.NRGBA, rot rotation) {
+	c := p.device(cx, cy, rot)
+	p.fill([][]point{circlePoly(c, radius*p.scale)}, fill)
+}
+
+func circlePoly(c point, radius float64) []point {
+	n := int(math.Max(8, math.Min(64, radius*2)))
+	poly := make([]point, n)
+	for i := range poly {
+		a := 2 * math.Pi * float64(i) / float64(n)
+		poly[i] = point{c.X + radius*math.Cos(a), c.Y + radius*math.Sin(a)}
+	}
+	return poly
+}
+
+// curve strokes a quadratic Bézier by flattening it into segments, each
+// filled as a quad with round joins
+func (p *rasterPainter) curve(x1, y1, cx, cy, x2, y2, width float64, stroke color.NRGBA) {
+	p0 := p.device(x1, y1, rotation{})
+	p1 := p.device(cx, cy, rotation{})
+	p2 := p.device(x2, y2, rotation{})
+	half := math.Max(width*p.scale, 1) / 2
+
+	length := math.Hypot(p1.X-p0.X, p1.Y-p0.Y) + math.Hypot(p2.X-p1.X, p2.Y-p1.Y)
+	n := int(math.Max(4, math.Min(200, length/8)))
+	pts := make([]point, n+1)
+	for i := range pts {
+		t := float64(i) / float64(n)
+		u := 1 - t
+		pts[i] = point{
+			u*u*p0.X + 2*u*t*p1.X + t*t*p2.X,
+			u*u*p0.Y + 2*u*t*p1.Y + t*t*p2.Y,
+		}
+	}
+
+	polys := [][]point{circlePoly(pts[0], half)}
+	for i := 1; i < len(pts); i++ {
+		a, b := pts[i-1], pts[i]
+		dx, dy := b.X-a.X, b.Y-a.Y
+		l := math.Hypot(dx, dy)
+		if l == 0 {
+			continue
+		}
+		nx, ny := -dy/l*half, dx/l*half
+		polys = append(polys,
+			[]point{{a.X + nx, a.Y + ny}, {b.X + nx, b.Y + ny}, {b.X - nx, b.Y - ny}, {a.X - nx, a.Y - ny}},
+			circlePoly(b, half),
+		)
+	}
+	p.fill(polys, stroke)
+}
+
+// text draws lines with the bitmap font. Each lit glyph column run becomes a
+// rectangle; bold text is drawn twice with a small horizontal offset.
+func (p *rasterPainter) text(x, y float64, lines []string, bold bool, fill color.NRGBA, rot rotation) {
+	// Skip text that would be illegibly small
+	if len(lines) == 0 || fontSize*p.scale < 4 {
+		return
+	}
+	pw := charWidth / glyphCellWidth
+	ph := glyphPixelHeight
+	top := (lineHeight - glyphRows*ph) / 2
+
+	for i, line := range lines {
+		var polys [][]point
+		ly := y + float64(i)*lineHeight + top
+		col := 0
+		for _, ch := range line {
+			glyph := glyphFor(ch)
+			gx := x + float64(col)*charWidth
+			col++
+			for c, bits := range glyph {
+				for row := 0; row < glyphRows; {
+					if bits&(1<<row) == 0 {
+						row++
+						continue
+					}
+					start := row
+					for row < glyphRows && bits&(1<<row) != 0 {
+						row++
+					}
+					r := Rect{X: gx + float64(c)*pw, Y: ly + float64(start)*ph, Width: pw, Height: float64(row-start) * ph}
+					polys = append(polys, p.roundedRect(r, 0, rot))
+					if bold {
+						r.X += pw * 0.6
+						polys = append(polys, p.roundedRect(r, 0, rot))
+					}
+				}
+			}
+		}
+		p.fill(polys, fill)
+	}
+}
+
+type edge struct {
+	x0, y0, x1, y1 float64
+	dir            int
+}
+
+type crossing struct {
+	x   float64
+	dir int
+}
+
+// fill composites the union of polys (non-zero winding) onto the image
+func (p *rasterPainter) fill(polys [][]point, c color.NRGBA) {
+	bounds := p.img.Bounds()
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	var edges []edge
+	for _, poly := range polys {
+		if len(poly) < 3 {
+			continue
+		}
+		// Orient every polygon the same way so overlaps never cancel out
+		if signedArea(poly) < 0 {
+			for i, j := 0, len(poly)-1; i < j; i, j = i+1, j-1 {
+				poly[i], poly[j] = poly[j], poly[i]
+			}
+		}
+		for i := range poly {
+			a, b := poly[i], poly[(i+1)%len(poly)]
+			minX, maxX = math.Min(minX, a.X), math.Max(maxX, a.X)
+			minY, maxY = math.Min(minY, a.Y), math.Max(maxY, a.Y)
+			if a.Y == b.Y {
+				continue
+			}
+			if a.Y < b.Y {
+				edges = append(edges, edge{a.X, a.Y, b.X, b.Y, 1})
+			} else {
+				edges = append(edges, edge{b.X, b.Y, a.X, a.Y, -1})
+			}
+		}
+	}
+	if len(edges) == 0 {
+		return
+	}
+
+	x0 := int(math.Max(math.Floor(minX), float64(bounds.Min.X)))
+	x1 := int(math.Min(math.Ceil(maxX), float64(bounds.Max.X)))
+	y0 := int(math.Max(math.Floor(minY), float64(bounds.Min.Y)))
+	y1 := int(math.Min(math.Ceil(maxY), float64(bounds.Max.Y)))
+	if x0 >= x1 || y0 >= y1 {
+		return
+	}
+
+	sort.Slice(edges, func(i, j int) bool { return edges[i].y0 < edges[j].y0 })
+	var active []edge
+	var crossings []crossing
+	next := 0
+	cover := p.cover[:x1-x0+1]
+	weight := float32(1) / subsamples
+
+	for py := y0; py < y1; py++ {
+		for i := range cover {
+			cover[i] = 0
+		}
+		for s := 0; s < subsamples; s++ {
+			sy := float64(py) + (float64(s)+0.5)/subsamples
+			for next < len(edges) && edges[next].y0 <= sy {
+				active = append(active, edges[next])
+				next++
+			}
+			crossings = crossings[:0]
+			kept := active[:0]
+			for _, e := range active {
+				if e.y1 <= sy {
+					continue
+				}
+				kept = append(kept, e)
+				if e.y0 <= sy {
+					crossings = append(crossings, crossing{e.x0 + (sy-e.y0)*(e.x1-e.x0)/(e.y1-e.y0), e.dir})
+				}
+			}
+			active = kept
+			sort.Slice(crossings, func(i, j int) bool { return crossings[i].x < crossings[j].x })
+
+			winding := 0
+			var start float64
+			for _, cr := range crossings {
+				before := winding
+				winding += cr.dir
+				if before == 0 && winding != 0 {
+					start = cr.x
+				} else if before != 0 && winding == 0 {
+					addSpan(cover, start-float64(x0), cr.x-float64(x0), weight)
+				}
+			}
+		}
+		p.blendRow(py, x0, cover[:x1-x0], c)
+	}
+}
+
+// addSpan adds horizontal coverage for [a, b) to the row accumulator
+func addSpan(cover []float32, a, b float64, weight float32) {
+	n := float64(len(cover) - 1)
+	a, b = math.Max(a, 0), math.Min(b, n)
+	if a >= b {
+		return
+	}
+	ia, ib := int(a), int(b)
+	if ia == ib {
+		cover[ia] += float32(b-a) * weight
+		return
+	}
+	cover[ia] += float32(float64(ia+1)-a) * weight
+	for i := ia + 1; i < ib; i++ {
+		cover[i] += weight
+	}
+	cover[ib] += float32(b-float64(ib)) * weight
+}
+
+func (p *rasterPainter) blendRow(y, x0 int, cover []float32, c color.NRGBA) {
+	alpha := float32(c.A) / 255
+	row := p.img.Pix[y*p.img.Stride:]
+	for i, cv := range cover {
+		if cv <= 0 {
+			continue
+		}
+		a := alpha * float32(math.Min(float64(cv), 1))
+		off := (x0 + i) * 4
+		px := row[off : off+4 : off+4]
+		px[0] = uint8(float32(c.R)*a + float32(px[0])*(1-a) + 0.5)
+		px[1] = uint8(float32(c.G)*a + float32(px[1])*(1-a) + 0.5)
+		px[2] = uint8(float32(c.B)*a + float32(px[2])*(1-a) + 0.5)
+		px[3] = uint8(255*a + float32(px[3])*(1-a) + 0.5)
+	}
+}
+
+func signedArea(poly []point) float64 {
+	var area float64
+	for i := range poly {
+		a, b := poly[i], poly[(i+1)%len(poly)]
+		area += a.X*b.Y - b.X*a.Y
+	}
+	return area / 2
+}
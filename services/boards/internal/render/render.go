@@ -0,0 +1,328 @@
+// Package render draws boards to SVG and PNG without a browser. The output
+// mirrors the web client: a cork background, items rotated about their centre
+// with a pushpin at the top, and sagging red strings between the pins.
+package render
+
+import (
+	"encoding/json"
+	"errors"
+	"html"
+	"image/color"
+	"math"
+	"sort"
+	"strings"
+
+	"evidence-wall/shared/models"
+)
+
+var ErrTooLarge = errors.New("rendered image too large")
+
+// MaxDimension is the largest width or height, in pixels, that will be rendered
+const MaxDimension = 8192
+
+// Rect is an axis-aligned rectangle in board coordinates
+type Rect struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// Options controls which part of the board is drawn and at what size
+type Options struct {
+	Viewport *Rect   // Region of the board to draw; nil fits all items
+	Scale    float64 // Output pixels per board unit; 0 means 1
+}
+
+// Appearance constants, matching the web client
+const (
+	fontSize      = 14.0
+	lineHeight    = fontSize * 1.4
+	charWidth     = fontSize * 0.6 // Courier New advance width
+	fitPadding    = 40.0
+	pinRadius     = 8.0
+	stringWidth   = 2.0
+	maxStringSag  = 30.0
+	photoHeight   = 100.0
+	cardPadding   = 16.0
+	defaultWidth  = 800.0
+	defaultHeight = 600.0
+)
+
+var (
+	corkColor      = rgb(0xc4a484)
+	stringColor    = rgb(0xcc0000)
+	pinColor       = rgb(0xdd2222)
+	textColor      = rgb(0x333333)
+	cardBorder     = rgb(0xdddddd)
+	photoColor     = rgb(0xe0e0e0)
+	photoIconColor = rgb(0x999999)
+	shadowColor    = color.NRGBA{A: 0x33}
+	postItColor    = rgb(0xffeb3b)
+	cardColor      = rgb(0xf5f5f5)
+)
+
+func rgb(v uint32) color.NRGBA {
+	return color.NRGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}
+}
+
+// rotation rotates a shape by Deg degrees clockwise about (CX, CY)
+type rotation struct {
+	Deg, CX, CY float64
+}
+
+// painter is implemented by the SVG and raster backends. Coordinates are in
+// board units; rot is applied before the viewport transform.
+type painter interface {
+	rect(r Rect, radius float64, fill color.NRGBA, rot rotation)
+	circle(cx, cy, radius float64, fill color.NRGBA, rot rotation)
+	curve(x1, y1, cx, cy, x2, y2, width float64, stroke color.NRGBA)
+	text(x, y float64, lines []string, bold bool, fill color.NRGBA, rot rotation)
+}
+
+// resolve validates options and returns the viewport and scale to use
+func resolve(board *models.Board, opts Options) (Rect, float64, error) {
+	scale := opts.Scale
+	if scale <= 0 {
+		scale = 1
+	}
+	view := fitItems(board.Items)
+	if opts.Viewport != nil {
+		view = *opts.Viewport
+	}
+	if view.Width <= 0 || view.Height <= 0 {
+		return Rect{}, 0, errors.New("viewport must have a positive size")
+	}
+	if view.Width*scale > MaxDimension || view.Height*scale > MaxDimension {
+		return Rect{}, 0, ErrTooLarge
+	}
+	return view, scale, nil
+}
+
+// Bounds returns the region drawn when no viewport is given: all items plus
+// some padding, or a default sized area for an empty board
+func Bounds(board *models.Board) Rect {
+	return fitItems(board.Items)
+}
+
+// fitItems returns the bounding box of all (rotated) items plus padding
+func fitItems(items []models.BoardItem) Rect {
+	if len(items) == 0 {
+		return Rect{Width: defaultWidth, Height: defaultHeight}
+	}
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, item := range items {
+		rot := itemRotation(item)
+		corners := [][2]float64{
+			{item.X, item.Y - pinRadius}, {item.X + item.Width, item.Y - pinRadius},
+			{item.X, item.Y + item.Height}, {item.X + item.Width, item.Y + item.Height},
+		}
+		for _, c := range corners {
+			x, y := rot.apply(c[0], c[1])
+			minX, minY = math.Min(minX, x), math.Min(minY, y)
+			maxX, maxY = math.Max(maxX, x), math.Max(maxY, y)
+		}
+	}
+	return Rect{
+		X:      math.Floor(minX - fitPadding),
+		Y:      math.Floor(minY - fitPadding),
+		Width:  math.Ceil(maxX-minX) + 2*fitPadding,
+		Height: math.Ceil(maxY-minY) + 2*fitPadding,
+	}
+}
+
+func (r rotation) apply(x, y float64) (float64, float64) {
+	if r.Deg == 0 {
+		return x, y
+	}
+	sin, cos := math.Sincos(r.Deg * math.Pi / 180)
+	dx, dy := x-r.CX, y-r.CY
+	return r.CX + dx*cos - dy*sin, r.CY + dx*sin + dy*cos
+}
+
+func itemRotation(item models.BoardItem) rotation {
+	return rotation{Deg: item.Rotation, CX: item.X + item.Width/2, CY: item.Y + item.Height/2}
+}
+
+// draw paints a board: items in z-order, then connections on top
+func draw(p painter, board *models.Board, view Rect) {
+	p.rect(view, 0, corkColor, rotation{})
+
+	items := append([]models.BoardItem(nil), board.Items...)
+	sort.SliceStable(items, func(i, j int) bool { return items[i].ZIndex < items[j].ZIndex })
+	for _, item := range items {
+		drawItem(p, item)
+	}
+
+	byID := make(map[string]models.BoardItem, len(items))
+	for _, item := range items {
+		byID[item.ID.String()] = item
+	}
+	for _, conn := range board.Connections {
+		from, okFrom := byID[conn.FromItemID.String()]
+		to, okTo := byID[conn.ToItemID.String()]
+		if !okFrom || !okTo {
+			continue
+		}
+		drawConnection(p, from, to, conn)
+	}
+}
+
+// drawConnection draws a string between the top centres of two items with a
+// sag proportional to its length, like the web client
+func drawConnection(p painter, from, to models.BoardItem, conn models.BoardConnection) {
+	x1, y1 := from.X+from.Width/2, from.Y
+	x2, y2 := to.X+to.Width/2, to.Y
+	sag := math.Min(math.Hypot(x2-x1, y2-y1)*0.1, maxStringSag)
+	stroke := stringColor
+	if c, ok := styleColor([]byte(conn.Style)); ok {
+		stroke = c
+	}
+	p.curve(x1, y1, (x1+x2)/2, (y1+y2)/2+sag, x2, y2, stringWidth, stroke)
+}
+
+func drawItem(p painter, item models.BoardItem) {
+	rot := itemRotation(item)
+	box := Rect{X: item.X, Y: item.Y, Width: item.Width, Height: item.Height}
+	content := html.UnescapeString(item.Content)
+
+	// Drop shadow
+	p.rect(Rect{X: box.X, Y: box.Y + 4, Width: box.Width, Height: box.Height}, 4, shadowColor, rot)
+
+	if isSuspectCard(item) {
+		fill := cardColor
+		if c, ok := styleColor(item.Style); ok {
+			fill = c
+		}
+		p.rect(box, 4, cardBorder, rot)
+		p.rect(Rect{X: box.X + 1, Y: box.Y + 1, Width: box.Width - 2, Height: box.Height - 2}, 3, fill, rot)
+
+		photo := Rect{X: box.X + cardPadding, Y: box.Y + cardPadding, Width: box.Width - 2*cardPadding, Height: photoHeight}
+		if photo.Width > 0 && photo.Y+photo.Height < box.Y+box.Height {
+			p.rect(photo, 0, photoColor, rot)
+			p.rect(Rect{X: photo.X, Y: photo.Y + photo.Height - 1, Width: photo.Width, Height: 1}, 0, cardBorder, rot)
+			cx, cy := photo.X+photo.Width/2, photo.Y+photo.Height/2
+			p.circle(cx, cy-12, 14, photoIconColor, rot)
+			p.rect(Rect{X: cx - 24, Y: cy + 6, Width: 48, Height: 26}, 12, photoIconColor, rot)
+		}
+
+		textBox := Rect{X: photo.X, Y: photo.Y + photoHeight + 8, Width: photo.Width}
+		textBox.Height = box.Y + box.Height - cardPadding - textBox.Y
+		lines := layoutText(content, textBox)
+		if len(lines) > 0 {
+			p.text(textBox.X, textBox.Y, lines[:1], true, textColor, rot)
+			p.text(textBox.X, textBox.Y+lineHeight, lines[1:], false, textColor, rot)
+		}
+	} else {
+		fill := postItColor
+		if c, ok := styleColor(item.Style); ok {
+			fill = c
+		}
+		p.rect(box, 2, fill, rot)
+		textBox := Rect{X: box.X + 15, Y: box.Y + 20, Width: box.Width - 30, Height: box.Height - 35}
+		p.text(textBox.X, textBox.Y, layoutText(content, textBox), false, textColor, rot)
+	}
+
+	// Pushpin
+	p.circle(box.X+box.Width/2, box.Y, pinRadius, pinColor, rot)
+}
+
+func isSuspectCard(item models.BoardItem) bool {
+	if item.Type == string(models.ItemTypeSuspectCard) {
+		return true
+	}
+	var style struct {
+		Metadata struct {
+			Variant string `json:"variant"`
+		} `json:"metadata"`
+	}
+	json.Unmarshal(item.Style, &style)
+	return style.Metadata.Variant == string(models.ItemTypeSuspectCard)
+}
+
+// styleColor reads the "color" of a style JSON object
+func styleColor(style []byte) (color.NRGBA, bool) {
+	var s struct {
+		Color string `json:"color"`
+	}
+	if len(style) == 0 || json.Unmarshal(style, &s) != nil {
+		return color.NRGBA{}, false
+	}
+	return parseColor(s.Color)
+}
+
+// parseColor parses #rgb and #rrggbb colors
+func parseColor(s string) (color.NRGBA, bool) {
+	if !strings.HasPrefix(s, "#") {
+		return color.NRGBA{}, false
+	}
+	hex := s[1:]
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 {
+		return color.NRGBA{}, false
+	}
+	var v uint32
+	for _, c := range hex {
+		var d uint32
+		switch {
+		case c >= '0' && c <= '9':
+			d = uint32(c - '0')
+		case c >= 'a' && c <= 'f':
+			d = uint32(c-'a') + 10
+		case c >= 'A' && c <= 'F':
+			d = uint32(c-'A') + 10
+		default:
+			return color.NRGBA{}, false
+		}
+		v = v<<4 | d
+	}
+	return rgb(v), true
+}
+
+// layoutText word-wraps text to the box width (monospace) and drops lines
+// that don't fit its height
+func layoutText(text string, box Rect) []string {
+	maxChars := int(box.Width/charWidth + 1e-9)
+	maxLines := int(box.Height/lineHeight + 1e-9)
+	if maxChars < 1 || maxLines < 1 {
+		return nil
+	}
+
+	var lines []string
+	for _, paragraph := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		lines = append(lines, wrapLine(paragraph, maxChars)...)
+		if len(lines) >= maxLines {
+			break
+		}
+	}
+	if len(lines) > maxLines {
+		lines = lines[:maxLines]
+	}
+	return lines
+}
+
+func wrapLine(line string, maxChars int) []string {
+	runes := []rune(strings.TrimRight(line, " \t"))
+	if len(runes) <= maxChars {
+		return []string{string(runes)}
+	}
+	var out []string
+	for len(runes) > maxChars {
+		cut := maxChars
+		for i := maxChars; i > 0; i-- {
+			if runes[i] == ' ' {
+				cut = i
+				break
+			}
+		}
+		out = append(out, strings.TrimRight(string(runes[:cut]), " "))
+		runes = []rune(strings.TrimLeft(string(runes[cut:]), " "))
+	}
+	if len(runes) > 0 {
+		out = append(out, string(runes))
+	}
+	return out
+}
@@ -0,0 +1,102 @@
+package render
+
+import (
+	"bytes"
+	"encoding/xml"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func testBoard() *models.Board {
+	suspect := uuid.New()
+	note := uuid.New()
+	return &models.Board{
+		ID:    uuid.New(),
+		Title: "Case",
+		Items: []models.BoardItem{
+			{ID: suspect, Type: "suspect-card", X: 100, Y: 100, Width: 280, Height: 400, Rotation: -3, ZIndex: 1,
+				Content: "John &quot;Smithy&quot; Smith\nAge: 44\nLast seen: docks",
+				Style:   []byte(`{"color":"#f5f5f5","metadata":{"variant":"suspect-card"}}`)},
+			{ID: note, Type: "post-it", X: 500, Y: 150, Width: 200, Height: 200, Rotation: 2, ZIndex: 2,
+				Content: "Seen at the docks on Tuesday night & again Friday",
+				Style:   []byte(`{"color":"#4caf50","metadata":{"variant":"post-it"}}`)},
+		},
+		Connections: []models.BoardConnection{{ID: uuid.New(), FromItemID: suspect, ToItemID: note}},
+	}
+}
+
+func TestSVG(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, SVG(&buf, testBoard(), Options{}))
+	out := buf.String()
+
+	// Well-formed XML
+	var doc struct {
+		XMLName xml.Name `xml:"svg"`
+		ViewBox string   `xml:"viewBox,attr"`
+	}
+	assert.NoError(t, xml.Unmarshal(buf.Bytes(), &doc))
+	assert.NotEmpty(t, doc.ViewBox)
+
+	assert.Contains(t, out, `fill="#4caf50"`)
+	assert.Contains(t, out, `transform="rotate(-3 240 300)"`)
+	assert.Contains(t, out, `<path d="M 240 100 Q`)
+	assert.Contains(t, out, `stroke="#cc0000"`)
+	// Stored content is unescaped, then escaped once for XML
+	assert.Contains(t, out, `John &#34;Smithy&#34; Smith</tspan>`)
+	assert.Contains(t, out, `font-weight="bold"`)
+	assert.Contains(t, out, `night &amp;</tspan>`)
+}
+
+func TestSVGViewportAndScale(t *testing.T) {
+	var buf bytes.Buffer
+	err := SVG(&buf, testBoard(), Options{Viewport: &Rect{X: 50, Y: 60, Width: 400, Height: 300}, Scale: 2})
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(buf.String(), `<svg xmlns="http://www.w3.org/2000/svg" width="800" height="600" viewBox="50 60 400 300">`))
+}
+
+func TestPNG(t *testing.T) {
+	var buf bytes.Buffer
+	opts := Options{Viewport: &Rect{X: 0, Y: 0, Width: 800, Height: 600}, Scale: 0.5}
+	assert.NoError(t, PNG(&buf, testBoard(), opts))
+
+	img, err := png.Decode(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, 400, img.Bounds().Dx())
+	assert.Equal(t, 300, img.Bounds().Dy())
+
+	at := func(x, y float64) color.RGBA {
+		return color.RGBAModel.Convert(img.At(int(x*opts.Scale), int(y*opts.Scale))).(color.RGBA)
+	}
+	// Cork background in an empty corner
+	assert.Equal(t, color.RGBA{0xc4, 0xa4, 0x84, 0xff}, at(20, 580))
+	// Post-it fill below its text
+	assert.Equal(t, color.RGBA{0x4c, 0xaf, 0x50, 0xff}, at(600, 330))
+	// Suspect card photo area
+	assert.Equal(t, color.RGBA{0xe0, 0xe0, 0xe0, 0xff}, at(140, 140))
+}
+
+func TestImageTooLarge(t *testing.T) {
+	_, err := Image(testBoard(), Options{Viewport: &Rect{Width: 5000, Height: 100}, Scale: 2})
+	assert.Equal(t, ErrTooLarge, err)
+}
+
+func TestFitEmptyBoard(t *testing.T) {
+	img, err := Image(&models.Board{}, Options{})
+	assert.NoError(t, err)
+	assert.Equal(t, 800, img.Bounds().Dx())
+}
+
+func TestLayoutText(t *testing.T) {
+	box := Rect{Width: charWidth * 10, Height: lineHeight * 3}
+	lines := layoutText("one two three four five six seven eight", box)
+	assert.Equal(t, []string{"one two", "three four", "five six"}, lines)
+	assert.Equal(t, []string{"abcdefghij", "klm"}, layoutText("abcdefghijklm", box))
+}
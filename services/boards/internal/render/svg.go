@@ -0,0 +1,98 @@
+package render
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"image/color"
+	"io"
+	"strconv"
+	"strings"
+
+	"evidence-wall/shared/models"
+)
+
+// SVG writes the board as an SVG document. The viewport becomes the viewBox
+// and the scale sets the document's pixel size.
+func SVG(w io.Writer, board *models.Board, opts Options) error {
+	view, scale, err := resolve(board, opts)
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%s" height="%s" viewBox="%s %s %s %s">`+"\n",
+		num(view.Width*scale), num(view.Height*scale), num(view.X), num(view.Y), num(view.Width), num(view.Height))
+	draw(&svgPainter{w: bw}, board, view)
+	bw.WriteString("</svg>\n")
+	return bw.Flush()
+}
+
+type svgPainter struct {
+	w *bufio.Writer
+}
+
+func (p *svgPainter) rect(r Rect, radius float64, fill color.NRGBA, rot rotation) {
+	fmt.Fprintf(p.w, `<rect x="%s" y="%s" width="%s" height="%s"`, num(r.X), num(r.Y), num(r.Width), num(r.Height))
+	if radius > 0 {
+		fmt.Fprintf(p.w, ` rx="%s"`, num(radius))
+	}
+	p.w.WriteString(fillAttrs(fill) + transform(rot) + "/>\n")
+}
+
+func (p *svgPainter) circle(cx, cy, radius float64, fill color.NRGBA, rot rotation) {
+	fmt.Fprintf(p.w, `<circle cx="%s" cy="%s" r="%s"%s%s/>`+"\n", num(cx), num(cy), num(radius), fillAttrs(fill), transform(rot))
+}
+
+func (p *svgPainter) curve(x1, y1, cx, cy, x2, y2, width float64, stroke color.NRGBA) {
+	fmt.Fprintf(p.w, `<path d="M %s %s Q %s %s %s %s" fill="none" stroke="%s" stroke-width="%s" stroke-linecap="round"/>`+"\n",
+		num(x1), num(y1), num(cx), num(cy), num(x2), num(y2), hexColor(stroke), num(width))
+}
+
+func (p *svgPainter) text(x, y float64, lines []string, bold bool, fill color.NRGBA, rot rotation) {
+	if len(lines) == 0 {
+		return
+	}
+	fmt.Fprintf(p.w, `<text font-family="'Courier New', monospace" font-size="%s" xml:space="preserve"%s%s`, num(fontSize), fillAttrs(fill), transform(rot))
+	if bold {
+		p.w.WriteString(` font-weight="bold"`)
+	}
+	p.w.WriteString(">")
+	for i, line := range lines {
+		// Baseline sits roughly at the middle of the line box plus a third of the font size
+		baseline := y + float64(i)*lineHeight + lineHeight/2 + fontSize*0.35
+		fmt.Fprintf(p.w, `<tspan x="%s" y="%s">`, num(x), num(baseline))
+		xml.EscapeText(p.w, []byte(line))
+		p.w.WriteString("</tspan>")
+	}
+	p.w.WriteString("</text>\n")
+}
+
+func fillAttrs(c color.NRGBA) string {
+	attrs := fmt.Sprintf(` fill="%s"`, hexColor(c))
+	if c.A != 0xff {
+		attrs += fmt.Sprintf(` fill-opacity="%s"`, num(float64(c.A)/255))
+	}
+	return attrs
+}
+
+func transform(rot rotation) string {
+	if rot.Deg == 0 {
+		return ""
+	}
+	return fmt.Sprintf(` transform="rotate(%s %s %s)"`, num(rot.Deg), num(rot.CX), num(rot.CY))
+}
+
+func hexColor(c color.NRGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// num formats a coordinate compactly with at most two decimals
+func num(f float64) string {
+	s := strconv.FormatFloat(f, 'f', 2, 64)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "-0" || s == "" {
+		return "0"
+	}
+	return s
+}
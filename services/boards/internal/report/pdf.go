@@ -0,0 +1,257 @@
+package report
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// A minimal PDF 1.4 writer: pages with text in the standard 14 fonts, filled
+// rectangles, lines and RGB images. Nothing is embedded except images, so
+// documents stay small and need no font files.
+
+// Page sizes in points
+const (
+	a4Width  = 595.28
+	a4Height = 841.89
+)
+
+// Standard fonts, referenced from content streams by resource name
+type font struct {
+	name     string // resource name
+	baseFont string
+	widths   *[95]int // advance widths for ASCII 32..126, per 1000 em
+}
+
+var (
+	fontRegular = &font{name: "F1", baseFont: "Helvetica", widths: &helveticaWidths}
+	fontBold    = &font{name: "F2", baseFont: "Helvetica-Bold", widths: &helveticaBoldWidths}
+	fontMono    = &font{name: "F3", baseFont: "Courier"}
+)
+
+var allFonts = []*font{fontRegular, fontBold, fontMono}
+
+// width returns the width of s in points at the given size
+func (f *font) width(s string, size float64) float64 {
+	var units int
+	for _, b := range winAnsi(s) {
+		switch {
+		case f.widths == nil:
+			units += 600
+		case b >= 32 && b <= 126:
+			units += f.widths[b-32]
+		default:
+			units += 556
+		}
+	}
+	return float64(units) * size / 1000
+}
+
+type pdfPage struct {
+	width, height float64
+	content       bytes.Buffer
+	images        []int // indexes into pdfDoc.images used on the page
+}
+
+type pdfImage struct {
+	width, height int
+	data          []byte // zlib compressed RGB
+}
+
+type pdfDoc struct {
+	title  string
+	pages  []*pdfPage
+	images []pdfImage
+}
+
+func (d *pdfDoc) addPage(width, height float64) *pdfPage {
+	p := &pdfPage{width: width, height: height}
+	d.pages = append(d.pages, p)
+	return p
+}
+
+// addImage stores an image as a compressed RGB XObject and returns its index
+func (d *pdfDoc) addImage(img image.Image) (int, error) {
+	b := img.Bounds()
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	row := make([]byte, 0, b.Dx()*3)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		row = row[:0]
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bl, _ := img.At(x, y).RGBA()
+			row = append(row, byte(r>>8), byte(g>>8), byte(bl>>8))
+		}
+		if _, err := zw.Write(row); err != nil {
+			return 0, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return 0, err
+	}
+	d.images = append(d.images, pdfImage{width: b.Dx(), height: b.Dy(), data: buf.Bytes()})
+	return len(d.images) - 1, nil
+}
+
+// Drawing operations take top-left based coordinates in points
+
+func (p *pdfPage) text(f *font, size, x, y float64, s string) {
+	fmt.Fprintf(&p.content, "BT /%s %s Tf %s %s Td (%s) Tj ET\n",
+		f.name, num(size), num(x), num(p.height-y), escapeString(winAnsi(s)))
+}
+
+func (p *pdfPage) fillRect(x, y, w, h float64, gray float64) {
+	fmt.Fprintf(&p.content, "%s g %s %s %s %s re f 0 g\n", num(gray), num(x), num(p.height-y-h), num(w), num(h))
+}
+
+func (p *pdfPage) line(x1, y1, x2, y2, width, gray float64) {
+	fmt.Fprintf(&p.content, "%s G %s w %s %s m %s %s l S 0 G\n",
+		num(gray), num(width), num(x1), num(p.height-y1), num(x2), num(p.height-y2))
+}
+
+func (p *pdfPage) image(index int, x, y, w, h float64) {
+	p.images = append(p.images, index)
+	fmt.Fprintf(&p.content, "q %s 0 0 %s %s %s cm /Im%d Do Q\n", num(w), num(h), num(x), num(p.height-y-h), index)
+}
+
+// write serializes the document. Object numbers: 1 catalog, 2 page tree,
+// 3 info, then fonts, images and one page plus content stream per page.
+func (d *pdfDoc) write(w io.Writer) error {
+	var buf bytes.Buffer
+	var offsets []int
+	obj := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+	stream := func(dict string, data []byte) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n<< %s /Length %d >>\nstream\n", len(offsets), dict, len(data))
+		buf.Write(data)
+		buf.WriteString("\nendstream\nendobj\n")
+	}
+
+	fontObj := 4
+	imageObj := fontObj + len(allFonts)
+	pageObj := imageObj + len(d.images)
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", pageObj+2*i)
+	}
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+	obj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	obj(fmt.Sprintf("<< /Title (%s) /Producer (Evidence Wall) >>", escapeString(winAnsi(d.title))))
+
+	var fontRes []string
+	for i, f := range allFonts {
+		obj(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", f.baseFont))
+		fontRes = append(fontRes, fmt.Sprintf("/%s %d 0 R", f.name, fontObj+i))
+	}
+	for _, img := range d.images {
+		stream(fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /FlateDecode",
+			img.width, img.height), img.data)
+	}
+
+	for i, p := range d.pages {
+		var xobjects []string
+		for _, index := range p.images {
+			xobjects = append(xobjects, fmt.Sprintf("/Im%d %d 0 R", index, imageObj+index))
+		}
+		resources := fmt.Sprintf("/Font << %s >>", strings.Join(fontRes, " "))
+		if len(xobjects) > 0 {
+			resources += fmt.Sprintf(" /XObject << %s >>", strings.Join(xobjects, " "))
+		}
+		obj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << %s >> /Contents %d 0 R >>",
+			num(p.width), num(p.height), resources, pageObj+2*i+1))
+		stream("", p.content.Bytes())
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R /Info 3 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func num(f float64) string {
+	s := strconv.FormatFloat(f, 'f', 2, 64)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "" || s == "-0" {
+		return "0"
+	}
+	return s
+}
+
+// escapeString escapes a PDF literal string
+func escapeString(b []byte) string {
+	var sb strings.Builder
+	for _, c := range b {
+		switch c {
+		case '\\', '(', ')':
+			sb.WriteByte('\\')
+			sb.WriteByte(c)
+		case '\r', '\n', '\t':
+			sb.WriteByte(' ')
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
+
+// winAnsiSpecials maps characters outside Latin-1 to their WinAnsiEncoding codes
+var winAnsiSpecials = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87,
+	'ˆ': 0x88, '‰': 0x89, 'Š': 0x8a, '‹': 0x8b, 'Œ': 0x8c, 'Ž': 0x8e,
+	'‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97,
+	'˜': 0x98, '™': 0x99, 'š': 0x9a, '›': 0x9b, 'œ': 0x9c, 'ž': 0x9e, 'Ÿ': 0x9f,
+}
+
+// winAnsi encodes s for the standard fonts; unsupported characters become '?'
+func winAnsi(s string) []byte {
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r >= 0x20 && r < 0x7f, r >= 0xa0 && r <= 0xff:
+			out = append(out, byte(r))
+		case r == '\t':
+			out = append(out, ' ')
+		default:
+			if b, ok := winAnsiSpecials[r]; ok {
+				out = append(out, b)
+			} else {
+				out = append(out, '?')
+			}
+		}
+	}
+	return out
+}
+
+// Advance widths from the Adobe Helvetica and Helvetica-Bold AFM files
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278, // space - /
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556, // 0 - ?
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778, // @ - O
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556, // P - _
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556, // ` - o
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584, // p - ~
+}
+
+var helveticaBoldWidths = [95]int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
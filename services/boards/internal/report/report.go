@@ -0,0 +1,474 @@
+// Package report builds a printable PDF case dossier for a board: a cover
+// page, the rendered wall, every item's content grouped by type, a table of
+// connections and the list of collaborators. Everything is produced in Go;
+// the wall image comes from the render package.
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"evidence-wall/boards-service/internal/itemtype"
+	"evidence-wall/boards-service/internal/render"
+	"evidence-wall/shared/models"
+)
+
+// Layout constants, in points
+const (
+	margin      = 56.0
+	bodySize    = 10.0
+	bodyLeading = 14.0
+	cellPadding = 4.0
+
+	// wallPixels caps the longest side of the embedded wall image
+	wallPixels = 1600.0
+)
+
+// Write renders the case report for a fully loaded board (items,
+// connections and users with their User) to w
+func Write(w io.Writer, board *models.Board, generatedAt time.Time) error {
+	r := &reporter{doc: &pdfDoc{title: html.UnescapeString(board.Title)}, board: board}
+	r.names = make(map[string]string, len(board.Items))
+	for _, item := range board.Items {
+		r.names[item.ID.String()] = itemName(item)
+	}
+
+	r.cover(generatedAt)
+	if err := r.wall(); err != nil {
+		return err
+	}
+	r.items()
+	r.connections()
+	r.collaborators()
+	r.pageNumbers()
+
+	return r.doc.write(w)
+}
+
+type reporter struct {
+	doc   *pdfDoc
+	board *models.Board
+	names map[string]string // item ID to display name
+
+	page *pdfPage
+	y    float64 // cursor from the top of the page
+}
+
+func (r *reporter) newPage() {
+	r.page = r.doc.addPage(a4Width, a4Height)
+	r.y = margin
+}
+
+// ensure starts a new page unless h more points fit on the current one
+func (r *reporter) ensure(h float64) {
+	if r.page == nil || r.y+h > r.page.height-margin {
+		r.newPage()
+	}
+}
+
+func (r *reporter) contentWidth() float64 {
+	return a4Width - 2*margin
+}
+
+func (r *reporter) heading(text string) {
+	r.ensure(60)
+	r.page.text(fontBold, 16, margin, r.y+16, text)
+	r.y += 22
+	r.page.line(margin, r.y, a4Width-margin, r.y, 0.75, 0.6)
+	r.y += 14
+}
+
+func (r *reporter) subheading(text string) {
+	r.ensure(40)
+	r.page.text(fontBold, 12, margin, r.y+12, text)
+	r.y += 20
+}
+
+// paragraph writes wrapped text, breaking pages between lines as needed
+func (r *reporter) paragraph(f *font, size, leading, indent float64, text string) {
+	for _, line := range wrapText(f, size, text, r.contentWidth()-indent) {
+		r.ensure(leading)
+		r.page.text(f, size, margin+indent, r.y+size, line)
+		r.y += leading
+	}
+}
+
+func (r *reporter) cover(generatedAt time.Time) {
+	r.newPage()
+	r.y = a4Height / 3
+	r.page.text(fontRegular, 11, margin, r.y, "CASE REPORT")
+	r.y += 16
+	for _, line := range wrapText(fontBold, 28, html.UnescapeString(r.board.Title), r.contentWidth()) {
+		r.page.text(fontBold, 28, margin, r.y+28, line)
+		r.y += 34
+	}
+	r.y += 6
+	r.page.line(margin, r.y, a4Width-margin, r.y, 1.5, 0)
+	r.y += 18
+	if r.board.Description != "" {
+		r.paragraph(fontRegular, 12, 17, 0, html.UnescapeString(r.board.Description))
+		r.y += 12
+	}
+
+	facts := [][2]string{
+		{"Board ID", r.board.ID.String()},
+		{"Visibility", string(r.board.Visibility)},
+	}
+	if r.board.Classification.Rank() > 0 {
+		facts = append(facts, [2]string{"Classification", marking(r.board.Classification)})
+	}
+	facts = append(facts, [][2]string{
+		{"Items", fmt.Sprint(len(r.board.Items))},
+		{"Connections", fmt.Sprint(len(r.board.Connections))},
+		{"Collaborators", fmt.Sprint(len(r.board.Users))},
+	}...)
+	if !r.board.CreatedAt.IsZero() {
+		facts = append(facts, [2]string{"Created", r.board.CreatedAt.UTC().Format("2006-01-02 15:04 MST")})
+	}
+	if !r.board.UpdatedAt.IsZero() {
+		facts = append(facts, [2]string{"Last updated", r.board.UpdatedAt.UTC().Format("2006-01-02 15:04 MST")})
+	}
+	facts = append(facts, [2]string{"Generated", generatedAt.UTC().Format("2006-01-02 15:04 MST")})
+	for _, fact := range facts {
+		r.ensure(bodyLeading)
+		r.page.text(fontBold, bodySize, margin, r.y+bodySize, fact[0])
+		r.page.text(fontRegular, bodySize, margin+100, r.y+bodySize, fact[1])
+		r.y += bodyLeading
+	}
+}
+
+// wall places the rendered board on its own page, turned to landscape when
+// the board is wider than it is tall
+func (r *reporter) wall() error {
+	bounds := render.Bounds(r.board)
+	scale := math.Min(1, wallPixels/math.Max(bounds.Width, bounds.Height))
+	img, err := render.Image(r.board, render.Options{Viewport: &bounds, Scale: scale})
+	if err != nil {
+		return fmt.Errorf("failed to render board: %w", err)
+	}
+	index, err := r.doc.addImage(img)
+	if err != nil {
+		return fmt.Errorf("failed to encode board image: %w", err)
+	}
+
+	width, height := a4Width, a4Height
+	if bounds.Width > bounds.Height {
+		width, height = height, width
+	}
+	r.page = r.doc.addPage(width, height)
+	r.page.text(fontBold, 16, margin, margin+16, "The wall")
+	top := margin + 30
+	boxW, boxH := width-2*margin, height-top-margin
+	fit := math.Min(boxW/bounds.Width, boxH/bounds.Height)
+	w, h := bounds.Width*fit, bounds.Height*fit
+	r.page.image(index, margin+(boxW-w)/2, top, w, h)
+	r.page = nil
+	return nil
+}
+
+func (r *reporter) items() {
+	r.newPage()
+	r.heading("Appendix A: Items")
+	if len(r.board.Items) == 0 {
+		r.paragraph(fontRegular, bodySize, bodyLeading, 0, "This board has no items.")
+		return
+	}
+
+	groups := make(map[string][]models.BoardItem)
+	for _, item := range r.board.Items {
+		t := itemType(item)
+		groups[t] = append(groups[t], item)
+	}
+	types := make([]string, 0, len(groups))
+	for t := range groups {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+
+	for _, t := range types {
+		items := groups[t]
+		sort.SliceStable(items, func(i, j int) bool {
+			if items[i].Y != items[j].Y {
+				return items[i].Y < items[j].Y
+			}
+			return items[i].X < items[j].X
+		})
+		r.subheading(fmt.Sprintf("%s (%d)", typeTitle(t), len(items)))
+		for i, item := range items {
+			r.ensure(2 * bodyLeading)
+			r.page.text(fontBold, bodySize, margin, r.y+bodySize, fmt.Sprintf("%d.", i+1))
+			content := strings.TrimSpace(html.UnescapeString(item.Content))
+			if item.Redacted {
+				content = "(redacted)"
+			} else if content == "" {
+				content = "(empty)"
+			}
+			if item.Classification.Rank() > 0 {
+				content = "[" + marking(item.Classification) + "] " + content
+			}
+			r.paragraph(fontRegular, bodySize, bodyLeading, 20, content)
+			for _, line := range fieldLines(t, item.Fields) {
+				r.paragraph(fontRegular, bodySize, bodyLeading, 20, line)
+			}
+			r.y += 6
+		}
+		r.y += 8
+	}
+}
+
+func (r *reporter) connections() {
+	r.y += 10
+	r.heading("Appendix B: Connections")
+	if len(r.board.Connections) == 0 {
+		r.paragraph(fontRegular, bodySize, bodyLeading, 0, "This board has no connections.")
+		return
+	}
+	rows := make([][]string, 0, len(r.board.Connections))
+	for _, conn := range r.board.Connections {
+		rows = append(rows, []string{
+			r.name(conn.FromItemID.String()),
+			r.name(conn.ToItemID.String()),
+			conn.RelationshipType,
+			string(conn.Confidence),
+			connectionLabel(conn),
+		})
+	}
+	r.table([]string{"From", "To", "Relationship", "Confidence", "Label"}, []float64{0.25, 0.25, 0.16, 0.12, 0.22}, rows)
+}
+
+func (r *reporter) collaborators() {
+	r.y += 10
+	r.heading("Appendix C: Collaborators")
+	if len(r.board.Users) == 0 {
+		r.paragraph(fontRegular, bodySize, bodyLeading, 0, "No collaborators are recorded for this board.")
+		return
+	}
+	users := append([]models.BoardUser(nil), r.board.Users...)
+	rank := map[models.PermissionLevel]int{models.PermissionAdmin: 0, models.PermissionWrite: 1, models.PermissionRead: 2}
+	sort.SliceStable(users, func(i, j int) bool {
+		return rank[users[i].Permission] < rank[users[j].Permission]
+	})
+	rows := make([][]string, 0, len(users))
+	for _, u := range users {
+		name := u.User.Name
+		if name == "" {
+			name = u.UserID.String()
+		}
+		rows = append(rows, []string{name, u.User.Email, string(u.Permission)})
+	}
+	r.table([]string{"Name", "Email", "Permission"}, []float64{0.36, 0.44, 0.2}, rows)
+}
+
+// table draws a simple ruled table; cells wrap and the header repeats on
+// every page the table spans
+func (r *reporter) table(header []string, fractions []float64, rows [][]string) {
+	widths := make([]float64, len(fractions))
+	for i, f := range fractions {
+		widths[i] = f * r.contentWidth()
+	}
+
+	drawHeader := func() {
+		h := bodyLeading + 2*cellPadding
+		r.page.fillRect(margin, r.y, r.contentWidth(), h, 0.9)
+		x := margin
+		for i, title := range header {
+			r.page.text(fontBold, bodySize, x+cellPadding, r.y+cellPadding+bodySize, title)
+			x += widths[i]
+		}
+		r.y += h
+	}
+
+	r.ensure(3 * (bodyLeading + 2*cellPadding))
+	drawHeader()
+	for _, row := range rows {
+		cells := make([][]string, len(row))
+		lines := 1
+		for i, cell := range row {
+			cells[i] = wrapText(fontRegular, bodySize, cell, widths[i]-2*cellPadding)
+			if len(cells[i]) > lines {
+				lines = len(cells[i])
+			}
+		}
+		h := float64(lines)*bodyLeading + 2*cellPadding
+		if r.y+h > r.page.height-margin {
+			r.newPage()
+			drawHeader()
+		}
+		x := margin
+		for i, cell := range cells {
+			for j, line := range cell {
+				r.page.text(fontRegular, bodySize, x+cellPadding, r.y+cellPadding+bodySize+float64(j)*bodyLeading, line)
+			}
+			x += widths[i]
+		}
+		r.y += h
+		r.page.line(margin, r.y, a4Width-margin, r.y, 0.5, 0.75)
+	}
+}
+
+// pageNumbers stamps "Page n of m" on every page except the cover
+func (r *reporter) pageNumbers() {
+	total := len(r.doc.pages)
+	for i, p := range r.doc.pages[1:] {
+		label := fmt.Sprintf("Page %d of %d", i+2, total)
+		p.text(fontRegular, 8, p.width-margin-fontRegular.width(label, 8), p.height-margin/2, label)
+	}
+}
+
+func (r *reporter) name(id string) string {
+	if name, ok := r.names[id]; ok {
+		return name
+	}
+	return "(deleted item)"
+}
+
+// itemType returns the concrete type of an item; generic "note" items carry
+// it as the style's metadata variant
+func itemType(item models.BoardItem) string {
+	if item.Type != models.ItemTypeNote {
+		return item.Type
+	}
+	var style struct {
+		Metadata struct {
+			Variant string `json:"variant"`
+		} `json:"metadata"`
+	}
+	if json.Unmarshal(item.Style, &style) == nil && style.Metadata.Variant != "" {
+		return style.Metadata.Variant
+	}
+	return string(models.ItemTypePostIt)
+}
+
+// typeTitle pluralizes a registered type's title, or turns an unknown type
+// such as "suspect-card" into "Suspect cards"
+func typeTitle(t string) string {
+	if registered, ok := itemtype.Lookup(t); ok && registered.Title != "" {
+		return registered.Title + "s"
+	}
+	words := strings.Fields(strings.NewReplacer("-", " ", "_", " ").Replace(t))
+	if len(words) == 0 {
+		return "Other items"
+	}
+	words[0] = strings.ToUpper(words[0][:1]) + words[0][1:]
+	return strings.Join(words, " ") + "s"
+}
+
+// fieldLines renders an item's field values as "Title: value" lines in the
+// order its type declares them
+func fieldLines(t string, data json.RawMessage) []string {
+	var values map[string]interface{}
+	if len(data) == 0 || json.Unmarshal(data, &values) != nil || len(values) == 0 {
+		return nil
+	}
+	var fields []itemtype.Field
+	if registered, ok := itemtype.Lookup(t); ok {
+		fields = registered.Fields()
+	}
+	var lines []string
+	for _, field := range fields {
+		if value, ok := values[field.Name]; ok && value != nil {
+			lines = append(lines, field.Title+": "+fieldText(value))
+		}
+	}
+	return lines
+}
+
+func fieldText(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return html.UnescapeString(v)
+	case bool:
+		if v {
+			return "Yes"
+		}
+		return "No"
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+// itemName is the first non-empty line of an item's content, shortened
+func itemName(item models.BoardItem) string {
+	if item.Redacted {
+		return "(redacted " + itemType(item) + ")"
+	}
+	for _, line := range strings.Split(html.UnescapeString(item.Content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if runes := []rune(line); len(runes) > 60 {
+			line = string(runes[:59]) + "…"
+		}
+		return line
+	}
+	return "(untitled " + itemType(item) + ")"
+}
+
+// marking spells out a classification the way it is printed on documents
+func marking(level models.ClassificationLevel) string {
+	return strings.ToUpper(strings.ReplaceAll(string(level), "_", " "))
+}
+
+// connectionLabel returns a connection's label, falling back to the label
+// kept in its style by older clients
+func connectionLabel(conn models.BoardConnection) string {
+	if conn.Label != "" {
+		return html.UnescapeString(conn.Label)
+	}
+	var style struct {
+		Label string `json:"label"`
+	}
+	if conn.Style == "" || json.Unmarshal([]byte(conn.Style), &style) != nil {
+		return ""
+	}
+	return html.UnescapeString(style.Label)
+}
+
+// wrapText breaks text into lines no wider than width, keeping explicit line
+// breaks and splitting words that are too long on their own
+func wrapText(f *font, size float64, text string, width float64) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		words := strings.Fields(paragraph)
+		if len(words) == 0 {
+			lines = append(lines, "")
+			continue
+		}
+		current := ""
+		for _, word := range words {
+			candidate := word
+			if current != "" {
+				candidate = current + " " + word
+			}
+			if f.width(candidate, size) <= width {
+				current = candidate
+				continue
+			}
+			if current != "" {
+				lines = append(lines, current)
+			}
+			current = word
+			for f.width(current, size) > width {
+				runes := []rune(current)
+				cut := len(runes) - 1
+				for cut > 1 && f.width(string(runes[:cut]), size) > width {
+					cut--
+				}
+				lines = append(lines, string(runes[:cut]))
+				current = string(runes[cut:])
+			}
+		}
+		lines = append(lines, current)
+	}
+	return lines
+}
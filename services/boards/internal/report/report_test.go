@@ -0,0 +1,131 @@
+package report
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func testBoard() *models.Board {
+	suspect := uuid.New()
+	note := uuid.New()
+	return &models.Board{
+		ID:          uuid.New(),
+		Title:       "Harbour &amp; Docks",
+		Description: "Thefts along the waterfront (2024)",
+		Visibility:  models.VisibilityPrivate,
+		Items: []models.BoardItem{
+			{ID: suspect, Type: "suspect-card", X: 100, Y: 100, Width: 280, Height: 400,
+				Content: "John &quot;Smithy&quot; Smith\nAge: 44",
+				Style:   []byte(`{"color":"#f5f5f5","metadata":{"variant":"suspect-card"}}`)},
+			{ID: note, Type: models.ItemTypeNote, X: 500, Y: 150, Width: 200, Height: 200,
+				Content: "Seen at the docks on Tuesday night",
+				Style:   []byte(`{"color":"#4caf50","metadata":{"variant":"post-it"}}`)},
+			{ID: uuid.New(), Type: string(models.ItemTypeLocation), X: 900, Y: 150, Width: 200, Height: 200,
+				Content: "Warehouse 7",
+				Fields:  []byte(`{"lat":51.5,"lon":-0.12,"address":"Pier Rd &amp; Dock St"}`)},
+		},
+		Connections: []models.BoardConnection{
+			{ID: uuid.New(), FromItemID: suspect, ToItemID: note, Style: `{"color":"#cc0000","label":"witnessed"}`},
+			{ID: uuid.New(), FromItemID: note, ToItemID: suspect, Label: "Cash &amp; goods", RelationshipType: "paid",
+				Direction: models.DirectionForward, Confidence: models.ConfidenceHigh},
+		},
+		Users: []models.BoardUser{
+			{UserID: uuid.New(), Permission: models.PermissionRead, User: models.User{Name: "Ann Reader", Email: "ann@example.com"}},
+			{UserID: uuid.New(), Permission: models.PermissionAdmin, User: models.User{Name: "Olive Owner", Email: "olive@example.com"}},
+		},
+	}
+}
+
+func TestWrite(t *testing.T) {
+	var buf bytes.Buffer
+	generated := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	assert.NoError(t, Write(&buf, testBoard(), generated))
+	out := buf.String()
+
+	assert.True(t, strings.HasPrefix(out, "%PDF-1.4\n"))
+	assert.True(t, strings.HasSuffix(out, "%%EOF\n"))
+
+	// Cover, wall and at least one appendix page
+	count := regexp.MustCompile(`/Count (\d+)`).FindStringSubmatch(out)
+	pages, _ := strconv.Atoi(count[1])
+	assert.GreaterOrEqual(t, pages, 3)
+
+	// Stored HTML escaping is undone and PDF string delimiters are escaped
+	assert.Contains(t, out, "(Harbour & Docks) Tj")
+	assert.Contains(t, out, `(Thefts along the waterfront \(2024\)) Tj`)
+	assert.Contains(t, out, "/Subtype /Image")
+	assert.Contains(t, out, "(Post-its \\(1\\)) Tj")
+	assert.Contains(t, out, "(Locations \\(1\\)) Tj")
+	assert.Contains(t, out, "(Latitude: 51.5) Tj")
+	assert.Contains(t, out, "(Address: Pier Rd & Dock St) Tj")
+	assert.Contains(t, out, "(Suspect cards \\(1\\)) Tj")
+	assert.Contains(t, out, `(John "Smithy" Smith) Tj`)
+	assert.Contains(t, out, "(witnessed) Tj")
+	assert.Contains(t, out, "(Cash & goods) Tj")
+	assert.Contains(t, out, "(paid) Tj")
+	assert.Contains(t, out, "(high) Tj")
+	assert.Contains(t, out, "(olive@example.com) Tj")
+	assert.Contains(t, out, "(Generated) Tj")
+
+	// Admins are listed before readers
+	assert.Less(t, strings.Index(out, "(Olive Owner) Tj"), strings.Index(out, "(Ann Reader) Tj"))
+}
+
+func TestWriteClassified(t *testing.T) {
+	board := testBoard()
+	board.Classification = models.ClassificationSecret
+	board.Items[0] = models.BoardItem{ID: board.Items[0].ID, Type: "suspect-card", X: 100, Y: 100, Width: 280, Height: 400,
+		Classification: models.ClassificationTopSecret, Redacted: true}
+	board.Items[1].Classification = models.ClassificationRestricted
+
+	var buf bytes.Buffer
+	assert.NoError(t, Write(&buf, board, time.Now()))
+	out := buf.String()
+
+	assert.Contains(t, out, "(SECRET) Tj")
+	assert.Contains(t, out, `([TOP SECRET] \(redacted\)) Tj`)
+	assert.Contains(t, out, "([RESTRICTED] Seen at the docks on Tuesday night) Tj")
+	assert.Contains(t, out, `(\(redacted suspect-card\)) Tj`)
+	assert.NotContains(t, out, "Smithy")
+}
+
+func TestWriteXref(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, Write(&buf, &models.Board{ID: uuid.New(), Title: "Empty"}, time.Now()))
+	out := buf.String()
+
+	// Every xref entry points at the start of its object
+	start := strings.Index(out, "xref\n")
+	lines := strings.Split(out[start:], "\n")
+	n, _ := strconv.Atoi(strings.Fields(lines[1])[1])
+	for i := 1; i < n; i++ {
+		offset, err := strconv.Atoi(lines[2+i][:10])
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(out[offset:], strconv.Itoa(i)+" 0 obj\n"), "object %d", i)
+	}
+	assert.Contains(t, out, "(This board has no items.) Tj")
+}
+
+func TestWrapText(t *testing.T) {
+	lines := wrapText(fontRegular, 10, "one two three\n\nfour", fontRegular.width("one two", 10))
+	assert.Equal(t, []string{"one two", "three", "", "four"}, lines)
+
+	// Words longer than the width are split
+	for _, line := range wrapText(fontMono, 10, strings.Repeat("x", 25), 60) {
+		assert.LessOrEqual(t, fontMono.width(line, 10), 60.0)
+	}
+}
+
+func TestWinAnsi(t *testing.T) {
+	assert.Equal(t, []byte("caf\xe9 \x93q\x94 ?"), winAnsi("café “q” 漢"))
+	assert.Equal(t, `a\(b\)\\`, escapeString([]byte(`a(b)\`)))
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"errors"
+
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AttachmentRepository handles attachment metadata; contents live in blob storage
+type AttachmentRepository struct {
+	db *gorm.DB
+}
+
+// NewAttachmentRepository creates a new attachment repository
+func NewAttachmentRepository(db *gorm.DB) *AttachmentRepository {
+	return &AttachmentRepository{db: db}
+}
+
+// Create creates a new attachment
+func (r *AttachmentRepository) Create(attachment *models.Attachment) error {
+	return r.db.Create(attachment).Error
+}
+
+// GetByID retrieves an attachment by ID
+func (r *AttachmentRepository) GetByID(id uuid.UUID) (*models.Attachment, error) {
+	var attachment models.Attachment
+	err := r.db.Where("id = ?", id).First(&attachment).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+// ListByItem retrieves the attachments of an item, oldest first
+func (r *AttachmentRepository) ListByItem(itemID uuid.UUID) ([]models.Attachment, error) {
+	var attachments []models.Attachment
+	err := r.db.Where("item_id = ?", itemID).Order("created_at ASC").Find(&attachments).Error
+	return attachments, err
+}
+
+// ListByBoard retrieves all attachments of a board
+func (r *AttachmentRepository) ListByBoard(boardID uuid.UUID) ([]models.Attachment, error) {
+	var attachments []models.Attachment
+	err := r.db.Where("board_id = ?", boardID).Order("created_at ASC").Find(&attachments).Error
+	return attachments, err
+}
+
+// ListPendingPreviews retrieves attachments still waiting for previews,
+// oldest first
+func (r *AttachmentRepository) ListPendingPreviews(limit int) ([]models.Attachment, error) {
+	var attachments []models.Attachment
+	err := r.db.Where("preview_status = ?", models.PreviewPending).
+		Order("created_at ASC").Limit(limit).Find(&attachments).Error
+	return attachments, err
+}
+
+// UpdatePreview writes the preview state of an attachment; the evidence
+// fields are never touched
+func (r *AttachmentRepository) UpdatePreview(attachment *models.Attachment) error {
+	return r.db.Model(&models.Attachment{ID: attachment.ID}).
+		Select("preview_status", "thumbnails", "metadata").
+		Updates(attachment).Error
+}
+
+// TotalSizeByBoard sums the sizes of a board's attachments
+func (r *AttachmentRepository) TotalSizeByBoard(boardID uuid.UUID) (int64, error) {
+	var total int64
+	err := r.db.Model(&models.Attachment{}).
+		Where("board_id = ?", boardID).
+		Select("COALESCE(SUM(size), 0)").
+		Scan(&total).Error
+	return total, err
+}
+
+// Delete soft-deletes an attachment
+func (r *AttachmentRepository) Delete(id uuid.UUID) error {
+	return r.db.Where("id = ?", id).Delete(&models.Attachment{}).Error
+}
+
+// DeleteByBoard permanently deletes all attachments for a board, including
+// soft-deleted ones
+func (r *AttachmentRepository) DeleteByBoard(boardID uuid.UUID) error {
+	return r.db.Unscoped().Where("board_id = ?", boardID).Delete(&models.Attachment{}).Error
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupAttachmentTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+
+	err = db.Exec(`
+		CREATE TABLE attachments (
+			id TEXT PRIMARY KEY,
+			board_id TEXT NOT NULL,
+			item_id TEXT NOT NULL,
+			filename TEXT NOT NULL,
+			content_type TEXT NOT NULL,
+			size INTEGER NOT NULL,
+			sha256 TEXT,
+			preview_status TEXT,
+			thumbnails TEXT,
+			metadata TEXT,
+			storage_key TEXT NOT NULL,
+			uploaded_by TEXT NOT NULL,
+			created_at DATETIME,
+			deleted_at DATETIME
+		)
+	`).Error
+	assert.NoError(t, err)
+
+	return db
+}
+
+func TestAttachmentRepository(t *testing.T) {
+	db := setupAttachmentTestDB(t)
+	repo := NewAttachmentRepository(db)
+
+	boardID := uuid.New()
+	itemID := uuid.New()
+	otherItemID := uuid.New()
+	newAttachment := func(item uuid.UUID, name string, size int64, created time.Time) *models.Attachment {
+		a := &models.Attachment{BoardID: boardID, ItemID: item, Filename: name, ContentType: "image/png",
+			Size: size, StorageKey: "k/" + name, UploadedBy: uuid.New(), CreatedAt: created}
+		assert.NoError(t, repo.Create(a))
+		return a
+	}
+	now := time.Now()
+	second := newAttachment(itemID, "second.png", 200, now)
+	first := newAttachment(itemID, "first.png", 100, now.Add(-time.Minute))
+	newAttachment(otherItemID, "other.png", 50, now)
+
+	found, err := repo.GetByID(first.ID)
+	assert.NoError(t, err)
+	if assert.NotNil(t, found) {
+		assert.Equal(t, "first.png", found.Filename)
+		assert.Equal(t, "k/first.png", found.StorageKey)
+	}
+
+	missing, err := repo.GetByID(uuid.New())
+	assert.NoError(t, err)
+	assert.Nil(t, missing)
+
+	list, err := repo.ListByItem(itemID)
+	assert.NoError(t, err)
+	if assert.Len(t, list, 2) {
+		assert.Equal(t, first.ID, list[0].ID)
+		assert.Equal(t, second.ID, list[1].ID)
+	}
+
+	first.PreviewStatus = models.PreviewPending
+	second.PreviewStatus = models.PreviewPending
+	assert.NoError(t, repo.UpdatePreview(first))
+	assert.NoError(t, repo.UpdatePreview(second))
+	pending, err := repo.ListPendingPreviews(1)
+	assert.NoError(t, err)
+	if assert.Len(t, pending, 1) {
+		assert.Equal(t, first.ID, pending[0].ID)
+	}
+
+	// Only the preview columns are written
+	first.PreviewStatus = models.PreviewReady
+	first.Thumbnails = []int{128, 512}
+	first.Metadata = &models.ImageMetadata{Width: 4000, Height: 3000, Make: "Canon"}
+	first.Filename = "renamed.png"
+	assert.NoError(t, repo.UpdatePreview(first))
+	found, err = repo.GetByID(first.ID)
+	assert.NoError(t, err)
+	if assert.NotNil(t, found) {
+		assert.Equal(t, models.PreviewReady, found.PreviewStatus)
+		assert.Equal(t, []int{128, 512}, found.Thumbnails)
+		assert.Equal(t, "Canon", found.Metadata.Make)
+		assert.Equal(t, "first.png", found.Filename)
+	}
+	pending, err = repo.ListPendingPreviews(10)
+	assert.NoError(t, err)
+	assert.Len(t, pending, 1)
+
+	onBoard, err := repo.ListByBoard(boardID)
+	assert.NoError(t, err)
+	assert.Len(t, onBoard, 3)
+
+	total, err := repo.TotalSizeByBoard(boardID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(350), total)
+
+	assert.NoError(t, repo.Delete(second.ID))
+	deleted, err := repo.GetByID(second.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, deleted)
+	total, err = repo.TotalSizeByBoard(boardID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(150), total)
+
+	total, err = repo.TotalSizeByBoard(uuid.New())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+}
@@ -2,6 +2,8 @@ package repository
 
 import (
 	"errors"
+	"strings"
+
 	"evidence-wall/shared/models"
 
 	"github.com/google/uuid"
@@ -43,16 +45,177 @@ func (r *BoardItemRepository) ListByBoard(boardID uuid.UUID) ([]models.BoardItem
 	return items, err
 }
 
+// ListByBoardInBBox retrieves the items of a board whose bounds overlap the box
+func (r *BoardItemRepository) ListByBoardInBBox(boardID uuid.UUID, box models.BBox) ([]models.BoardItem, error) {
+	condition, args := bboxCondition(r.db, box)
+	var items []models.BoardItem
+	err := r.db.Where("board_id = ?", boardID).
+		Where(condition, args...).
+		Order("z_index ASC, created_at ASC").
+		Find(&items).Error
+	return items, err
+}
+
+// bboxCondition matches items whose bounds overlap a box. On Postgres it is
+// the expression indexed by idx_board_items_bbox, so viewport queries use
+// the GiST index rather than reading the whole board.
+func bboxCondition(db *gorm.DB, box models.BBox) (string, []interface{}) {
+	if db.Dialector.Name() == "postgres" {
+		return "box(point(x, y), point(x + width, y + height)) && box(point(?, ?), point(?, ?))",
+			[]interface{}{box.MinX, box.MinY, box.MaxX, box.MaxY}
+	}
+	return "x <= ? AND x + width >= ? AND y <= ? AND y + height >= ?",
+		[]interface{}{box.MaxX, box.MinX, box.MaxY, box.MinY}
+}
+
+// ListByIDs retrieves the board's items with the given IDs. IDs of items on
+// other boards or that do not exist are skipped.
+func (r *BoardItemRepository) ListByIDs(boardID uuid.UUID, ids []uuid.UUID) ([]models.BoardItem, error) {
+	var items []models.BoardItem
+	if len(ids) == 0 {
+		return items, nil
+	}
+	err := r.db.Where("board_id = ? AND id IN ?", boardID, ids).Find(&items).Error
+	return items, err
+}
+
+// ListDescendants retrieves the items inside a frame, including those in
+// nested frames
+func (r *BoardItemRepository) ListDescendants(frameID uuid.UUID) ([]models.BoardItem, error) {
+	// UNION rather than UNION ALL so a corrupted cycle cannot recurse forever
+	tree := `WITH RECURSIVE tree(id) AS (
+		SELECT id FROM board_items WHERE parent_id = ? AND deleted_at IS NULL
+		UNION
+		SELECT board_items.id FROM board_items JOIN tree ON board_items.parent_id = tree.id WHERE board_items.deleted_at IS NULL
+	) SELECT id FROM tree`
+	var items []models.BoardItem
+	err := r.db.Where("id IN ("+tree+")", frameID).
+		Order("z_index ASC, created_at ASC").
+		Find(&items).Error
+	return items, err
+}
+
+// ListFrameMembership retrieves the frames of a board and the items inside
+// frames, with only their ID, parent, layer, type and fields loaded
+func (r *BoardItemRepository) ListFrameMembership(boardID uuid.UUID) ([]models.BoardItem, error) {
+	var items []models.BoardItem
+	err := r.db.Select("id", "board_id", "parent_id", "layer_id", "type", "fields").
+		Where("board_id = ?", boardID).
+		Where("parent_id IS NOT NULL OR type = ?", models.ItemTypeFrame).
+		Find(&items).Error
+	return items, err
+}
+
+// ListIDsByLayers retrieves the IDs of a board's items on the given layers
+func (r *BoardItemRepository) ListIDsByLayers(boardID uuid.UUID, layerIDs []uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	if len(layerIDs) == 0 {
+		return ids, nil
+	}
+	err := r.db.Model(&models.BoardItem{}).
+		Where("board_id = ? AND layer_id IN ?", boardID, layerIDs).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// ListClassified retrieves the IDs and classifications of a board's items
+// that are classified above unclassified
+func (r *BoardItemRepository) ListClassified(boardID uuid.UUID) ([]models.BoardItem, error) {
+	var items []models.BoardItem
+	err := r.db.Select("id", "classification").
+		Where("board_id = ? AND COALESCE(classification, '') NOT IN ?", boardID, []string{"", string(models.ClassificationUnclassified)}).
+		Find(&items).Error
+	return items, err
+}
+
+// SetLayer moves items onto a layer, or onto the base layer when layerID is nil
+func (r *BoardItemRepository) SetLayer(ids []uuid.UUID, layerID *uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.Model(&models.BoardItem{}).Where("id IN ?", ids).
+		Update("layer_id", layerID).Error
+}
+
+// SetParent moves items into a frame, or out of any frame when parentID is nil
+func (r *BoardItemRepository) SetParent(ids []uuid.UUID, parentID *uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.Model(&models.BoardItem{}).Where("id IN ?", ids).
+		Update("parent_id", parentID).Error
+}
+
+// SetFrameMembers moves items into a frame and others out of it onto
+// parentID, the frame's parent, in one transaction
+func (r *BoardItemRepository) SetFrameMembers(frameID uuid.UUID, added, removed []uuid.UUID, parentID *uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		repo := NewBoardItemRepository(tx)
+		if err := repo.SetParent(added, &frameID); err != nil {
+			return err
+		}
+		return repo.SetParent(removed, parentID)
+	})
+}
+
 // Update updates a board item
 func (r *BoardItemRepository) Update(item *models.BoardItem) error {
 	return r.db.Save(item).Error
 }
 
+// UpdatePositions moves several items in one transaction; only X and Y are written
+func (r *BoardItemRepository) UpdatePositions(items []models.BoardItem) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, item := range items {
+			err := tx.Model(&models.BoardItem{}).Where("id = ?", item.ID).
+				Updates(map[string]interface{}{"x": item.X, "y": item.Y}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// UpdateWithPositions saves an item and moves others in one transaction,
+// as when a frame is moved with its contents
+func (r *BoardItemRepository) UpdateWithPositions(item *models.BoardItem, moved []models.BoardItem) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(item).Error; err != nil {
+			return err
+		}
+		return NewBoardItemRepository(tx).UpdatePositions(moved)
+	})
+}
+
+// UpdateEvidenceMetadata writes only an item's evidence metadata, so it does
+// not race with users editing the item
+func (r *BoardItemRepository) UpdateEvidenceMetadata(id uuid.UUID, metadata *models.EvidenceMetadata) error {
+	return r.db.Model(&models.BoardItem{ID: id}).Select("evidence_metadata").
+		Updates(&models.BoardItem{EvidenceMetadata: metadata}).Error
+}
+
 // Delete permanently deletes a board item
 func (r *BoardItemRepository) Delete(id uuid.UUID) error {
 	return r.db.Unscoped().Where("id = ?", id).Delete(&models.BoardItem{}).Error
 }
 
+// DeleteItems permanently deletes items and the connections touching them
+// in one transaction
+func (r *BoardItemRepository) DeleteItems(ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().Where("from_item_id IN ? OR to_item_id IN ?", ids, ids).
+			Delete(&models.BoardConnection{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("id IN ?", ids).Delete(&models.BoardItem{}).Error
+	})
+}
+
 // DeleteByBoard permanently deletes all items for a board
 func (r *BoardItemRepository) DeleteByBoard(boardID uuid.UUID) error {
 	return r.db.Unscoped().Where("board_id = ?", boardID).Delete(&models.BoardItem{}).Error
@@ -93,6 +256,74 @@ func (r *BoardConnectionRepository) ListByBoard(boardID uuid.UUID) ([]models.Boa
 	return connections, err
 }
 
+// ListByBoardFiltered retrieves the connections of a board that match the filter
+func (r *BoardConnectionRepository) ListByBoardFiltered(boardID uuid.UUID, filter models.ConnectionFilter) ([]models.BoardConnection, error) {
+	query := r.db.Where("board_id = ?", boardID)
+	if len(filter.RelationshipTypes) > 0 {
+		query = query.Where("relationship_type IN ?", filter.RelationshipTypes)
+	}
+	if len(filter.Directions) > 0 {
+		query = query.Where("direction IN ?", filter.Directions)
+	}
+	if len(filter.Confidences) > 0 {
+		query = query.Where("confidence IN ?", filter.Confidences)
+	}
+	if filter.ItemID != nil {
+		query = query.Where("from_item_id = ? OR to_item_id = ?", *filter.ItemID, *filter.ItemID)
+	}
+	if filter.Label != "" {
+		pattern := "%" + likeEscaper.Replace(strings.ToLower(filter.Label)) + "%"
+		query = query.Where(`LOWER(label) LIKE ? ESCAPE '\'`, pattern)
+	}
+
+	var connections []models.BoardConnection
+	err := query.Order("created_at ASC").Find(&connections).Error
+	return connections, err
+}
+
+// ListByBoardInBBox retrieves the connections of a board with either end
+// on an item whose bounds overlap the box
+func (r *BoardConnectionRepository) ListByBoardInBBox(boardID uuid.UUID, box models.BBox) ([]models.BoardConnection, error) {
+	condition, args := bboxCondition(r.db, box)
+	visible := r.db.Model(&models.BoardItem{}).Select("id").
+		Where("board_id = ?", boardID).
+		Where(condition, args...)
+
+	var connections []models.BoardConnection
+	err := r.db.Where("board_id = ?", boardID).
+		Where(r.db.Where("from_item_id IN (?)", visible).Or("to_item_id IN (?)", visible)).
+		Order("created_at ASC").
+		Find(&connections).Error
+	return connections, err
+}
+
+// ListByItems retrieves the connections of a board with either end on one
+// of the items
+func (r *BoardConnectionRepository) ListByItems(boardID uuid.UUID, itemIDs []uuid.UUID) ([]models.BoardConnection, error) {
+	var connections []models.BoardConnection
+	if len(itemIDs) == 0 {
+		return connections, nil
+	}
+	err := r.db.Where("board_id = ?", boardID).
+		Where("from_item_id IN ? OR to_item_id IN ?", itemIDs, itemIDs).
+		Order("created_at ASC").
+		Find(&connections).Error
+	return connections, err
+}
+
+// SetLayer moves connections onto a layer, or onto the base layer when
+// layerID is nil
+func (r *BoardConnectionRepository) SetLayer(ids []uuid.UUID, layerID *uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.Model(&models.BoardConnection{}).Where("id IN ?", ids).
+		Update("layer_id", layerID).Error
+}
+
+// likeEscaper escapes LIKE wildcards so user input matches literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
 // Update updates a board connection
 func (r *BoardConnectionRepository) Update(connection *models.BoardConnection) error {
 	return r.db.Save(connection).Error
@@ -112,5 +343,3 @@ func (r *BoardConnectionRepository) DeleteByBoard(boardID uuid.UUID) error {
 func (r *BoardConnectionRepository) DeleteByItem(itemID uuid.UUID) error {
 	return r.db.Unscoped().Where("from_item_id = ? OR to_item_id = ?", itemID, itemID).Delete(&models.BoardConnection{}).Error
 }
-
-
@@ -20,6 +20,8 @@ func setupItemTestDB(t *testing.T) *gorm.DB {
 		CREATE TABLE board_items (
 			id TEXT PRIMARY KEY,
 			board_id TEXT NOT NULL,
+			parent_id TEXT,
+			layer_id TEXT,
 			type TEXT NOT NULL,
 			x REAL NOT NULL,
 			y REAL NOT NULL,
@@ -29,6 +31,10 @@ func setupItemTestDB(t *testing.T) *gorm.DB {
 			z_index INTEGER DEFAULT 1,
 			content TEXT,
 			style TEXT,
+			fields TEXT,
+			custom_values TEXT,
+			evidence_metadata TEXT,
+			classification TEXT,
 			created_by TEXT NOT NULL,
 			created_at DATETIME,
 			updated_at DATETIME,
@@ -43,6 +49,11 @@ func setupItemTestDB(t *testing.T) *gorm.DB {
 			board_id TEXT NOT NULL,
 			from_item_id TEXT NOT NULL,
 			to_item_id TEXT NOT NULL,
+			layer_id TEXT,
+			label TEXT,
+			direction TEXT NOT NULL DEFAULT 'none',
+			relationship_type TEXT,
+			confidence TEXT,
 			style TEXT,
 			created_by TEXT NOT NULL,
 			created_at DATETIME,
@@ -69,7 +80,7 @@ func TestBoardItemRepository_Create(t *testing.T) {
 		Height:    150.0,
 		ZIndex:    1,
 		Content:   "Test Note",
-		Color:     "#ffff00",
+		Style:     []byte(`{"color":"#ffff00"}`),
 		CreatedBy: uuid.New(),
 	}
 
@@ -249,6 +260,61 @@ func TestBoardItemRepository_Update(t *testing.T) {
 	assert.Equal(t, 5, foundItem.ZIndex)
 }
 
+func TestBoardItemRepository_UpdatePositions(t *testing.T) {
+	db := setupItemTestDB(t)
+	repo := NewBoardItemRepository(db)
+
+	boardID := uuid.New()
+	first := &models.BoardItem{ID: uuid.New(), BoardID: boardID, Type: models.ItemTypeNote, X: 10, Y: 20, Width: 200, Height: 200, Content: "First", CreatedBy: uuid.New()}
+	second := &models.BoardItem{ID: uuid.New(), BoardID: boardID, Type: models.ItemTypeNote, X: 30, Y: 40, Width: 200, Height: 200, Content: "Second", CreatedBy: uuid.New()}
+	assert.NoError(t, db.Create(first).Error)
+	assert.NoError(t, db.Create(second).Error)
+
+	// Only positions are written, even if other fields differ
+	err := repo.UpdatePositions([]models.BoardItem{
+		{ID: first.ID, X: 500, Y: 600, Content: "ignored"},
+		{ID: second.ID, X: -5, Y: 7.5},
+	})
+	assert.NoError(t, err)
+
+	var found models.BoardItem
+	assert.NoError(t, db.First(&found, "id = ?", first.ID).Error)
+	assert.Equal(t, 500.0, found.X)
+	assert.Equal(t, 600.0, found.Y)
+	assert.Equal(t, "First", found.Content)
+	assert.Equal(t, 200.0, found.Width)
+
+	var moved models.BoardItem
+	assert.NoError(t, db.First(&moved, "id = ?", second.ID).Error)
+	assert.Equal(t, -5.0, moved.X)
+	assert.Equal(t, 7.5, moved.Y)
+}
+
+func TestBoardItemRepository_UpdateEvidenceMetadata(t *testing.T) {
+	db := setupItemTestDB(t)
+	repo := NewBoardItemRepository(db)
+
+	item := &models.BoardItem{ID: uuid.New(), BoardID: uuid.New(), Type: models.ItemTypeNote, X: 10, Y: 20, Content: "Photo", CreatedBy: uuid.New()}
+	assert.NoError(t, db.Create(item).Error)
+
+	lat, lon := 48.8584, 2.2945
+	source := uuid.New()
+	err := repo.UpdateEvidenceMetadata(item.ID, &models.EvidenceMetadata{
+		CapturedAt: "2024-03-15T22:41:07+01:00", Latitude: &lat, Longitude: &lon, SourceAttachmentID: &source,
+	})
+	assert.NoError(t, err)
+
+	found, err := repo.GetByID(item.ID)
+	assert.NoError(t, err)
+	if assert.NotNil(t, found) && assert.NotNil(t, found.EvidenceMetadata) {
+		assert.Equal(t, "2024-03-15T22:41:07+01:00", found.EvidenceMetadata.CapturedAt)
+		assert.Equal(t, lat, *found.EvidenceMetadata.Latitude)
+		assert.Equal(t, source, *found.EvidenceMetadata.SourceAttachmentID)
+		assert.Equal(t, "Photo", found.Content)
+		assert.Equal(t, 10.0, found.X)
+	}
+}
+
 func TestBoardItemRepository_Delete(t *testing.T) {
 	db := setupItemTestDB(t)
 	repo := NewBoardItemRepository(db)
@@ -479,6 +545,55 @@ func TestBoardConnectionRepository_ListByBoard(t *testing.T) {
 	}
 }
 
+func TestBoardConnectionRepository_ListByBoardFiltered(t *testing.T) {
+	db := setupItemTestDB(t)
+	repo := NewBoardConnectionRepository(db)
+
+	boardID := uuid.New()
+	itemA, itemB, itemC := uuid.New(), uuid.New(), uuid.New()
+	create := func(from, to uuid.UUID, relType string, dir models.ConnectionDirection, conf models.ConfidenceLevel, label string) uuid.UUID {
+		conn := &models.BoardConnection{ID: uuid.New(), BoardID: boardID, FromItemID: from, ToItemID: to,
+			RelationshipType: relType, Direction: dir, Confidence: conf, Label: label, CreatedBy: uuid.New()}
+		assert.NoError(t, db.Create(conn).Error)
+		return conn.ID
+	}
+	called := create(itemA, itemB, "called", models.DirectionForward, models.ConfidenceHigh, "Burner phone 100%")
+	paid := create(itemB, itemC, "paid", models.DirectionForward, models.ConfidenceLow, "Cash")
+	knows := create(itemA, itemC, "knows", models.DirectionNone, "", "")
+	// Another board
+	db.Create(&models.BoardConnection{ID: uuid.New(), BoardID: uuid.New(), FromItemID: itemA, ToItemID: itemB, RelationshipType: "called", CreatedBy: uuid.New()})
+
+	ids := func(conns []models.BoardConnection) []uuid.UUID {
+		out := []uuid.UUID{}
+		for _, c := range conns {
+			out = append(out, c.ID)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name     string
+		filter   models.ConnectionFilter
+		expected []uuid.UUID
+	}{
+		{"no filter", models.ConnectionFilter{}, []uuid.UUID{called, paid, knows}},
+		{"relationship types", models.ConnectionFilter{RelationshipTypes: []string{"called", "paid"}}, []uuid.UUID{called, paid}},
+		{"direction", models.ConnectionFilter{Directions: []models.ConnectionDirection{models.DirectionNone}}, []uuid.UUID{knows}},
+		{"confidence", models.ConnectionFilter{Confidences: []models.ConfidenceLevel{models.ConfidenceLow}}, []uuid.UUID{paid}},
+		{"item", models.ConnectionFilter{ItemID: &itemC}, []uuid.UUID{paid, knows}},
+		{"item and type", models.ConnectionFilter{ItemID: &itemA, RelationshipTypes: []string{"knows"}}, []uuid.UUID{knows}},
+		{"label substring", models.ConnectionFilter{Label: "PHONE"}, []uuid.UUID{called}},
+		{"label wildcard is literal", models.ConnectionFilter{Label: "%"}, []uuid.UUID{called}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := repo.ListByBoardFiltered(boardID, tt.filter)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, ids(result))
+		})
+	}
+}
+
 func TestBoardConnectionRepository_Update(t *testing.T) {
 	db := setupItemTestDB(t)
 	repo := NewBoardConnectionRepository(db)
@@ -648,3 +763,160 @@ func TestBoardConnectionRepository_DeleteByItem(t *testing.T) {
 	assert.Len(t, remainingConnections, 1)
 	assert.Equal(t, conn3.ID, remainingConnections[0].ID)
 }
+
+func TestBoardItemRepository_ListByBoardInBBox(t *testing.T) {
+	db := setupItemTestDB(t)
+	repo := NewBoardItemRepository(db)
+	connRepo := NewBoardConnectionRepository(db)
+
+	boardID := uuid.New()
+	userID := uuid.New()
+	newItem := func(x, y float64) *models.BoardItem {
+		item := &models.BoardItem{ID: uuid.New(), BoardID: boardID, Type: models.ItemTypeNote, X: x, Y: y, Width: 100, Height: 50, CreatedBy: userID}
+		assert.NoError(t, db.Create(item).Error)
+		return item
+	}
+	inside := newItem(100, 100)
+	overlapping := newItem(-50, 480) // Straddles the bottom-left corner
+	touching := newItem(500, 200)    // Left edge on the box's right edge
+	outside := newItem(601, 100)     // Just right of the box
+	above := newItem(200, -51)       // Bottom edge just above the box
+	other := &models.BoardItem{ID: uuid.New(), BoardID: uuid.New(), Type: models.ItemTypeNote, X: 100, Y: 100, Width: 100, Height: 50, CreatedBy: userID}
+	assert.NoError(t, db.Create(other).Error)
+
+	box := models.BBox{MinX: 0, MinY: 0, MaxX: 500, MaxY: 500}
+	items, err := repo.ListByBoardInBBox(boardID, box)
+	assert.NoError(t, err)
+	ids := make([]uuid.UUID, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ID)
+	}
+	assert.ElementsMatch(t, []uuid.UUID{inside.ID, overlapping.ID, touching.ID}, ids)
+
+	connect := func(from, to *models.BoardItem) *models.BoardConnection {
+		conn := &models.BoardConnection{ID: uuid.New(), BoardID: boardID, FromItemID: from.ID, ToItemID: to.ID, Direction: models.DirectionNone, CreatedBy: userID}
+		assert.NoError(t, db.Create(conn).Error)
+		return conn
+	}
+	fromVisible := connect(inside, outside)
+	toVisible := connect(above, touching)
+	connect(outside, above)
+
+	connections, err := connRepo.ListByBoardInBBox(boardID, box)
+	assert.NoError(t, err)
+	connIDs := make([]uuid.UUID, 0, len(connections))
+	for _, conn := range connections {
+		connIDs = append(connIDs, conn.ID)
+	}
+	assert.ElementsMatch(t, []uuid.UUID{fromVisible.ID, toVisible.ID}, connIDs)
+}
+
+func TestBoardItemRepository_Frames(t *testing.T) {
+	db := setupItemTestDB(t)
+	repo := NewBoardItemRepository(db)
+	connRepo := NewBoardConnectionRepository(db)
+
+	boardID := uuid.New()
+	userID := uuid.New()
+	newItem := func(itemType models.ItemType, parent *models.BoardItem) *models.BoardItem {
+		item := &models.BoardItem{ID: uuid.New(), BoardID: boardID, Type: string(itemType), X: 10, Y: 10, Width: 100, Height: 100, CreatedBy: userID}
+		if parent != nil {
+			item.ParentID = &parent.ID
+		}
+		assert.NoError(t, db.Create(item).Error)
+		return item
+	}
+	ids := func(items []models.BoardItem) []uuid.UUID {
+		out := make([]uuid.UUID, 0, len(items))
+		for _, item := range items {
+			out = append(out, item.ID)
+		}
+		return out
+	}
+
+	// outer > (note, inner > nested)
+	outer := newItem(models.ItemTypeFrame, nil)
+	note := newItem(models.ItemTypePostIt, outer)
+	inner := newItem(models.ItemTypeFrame, outer)
+	nested := newItem(models.ItemTypePostIt, inner)
+	loose := newItem(models.ItemTypePostIt, nil)
+
+	descendants, err := repo.ListDescendants(outer.ID)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []uuid.UUID{note.ID, inner.ID, nested.ID}, ids(descendants))
+
+	descendants, err = repo.ListDescendants(loose.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, descendants)
+
+	membership, err := repo.ListFrameMembership(boardID)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []uuid.UUID{outer.ID, note.ID, inner.ID, nested.ID}, ids(membership))
+
+	// Moving out of and into frames
+	assert.NoError(t, repo.SetParent([]uuid.UUID{nested.ID}, nil))
+	assert.NoError(t, repo.SetParent([]uuid.UUID{loose.ID}, &inner.ID))
+	get := func(id uuid.UUID) models.BoardItem {
+		var found models.BoardItem
+		assert.NoError(t, db.First(&found, "id = ?", id).Error)
+		return found
+	}
+	assert.Nil(t, get(nested.ID).ParentID)
+	assert.Equal(t, &inner.ID, get(loose.ID).ParentID)
+
+	// Members join and leave a frame together
+	assert.NoError(t, repo.SetFrameMembers(inner.ID, []uuid.UUID{nested.ID}, []uuid.UUID{loose.ID}, &outer.ID))
+	assert.Equal(t, &inner.ID, get(nested.ID).ParentID)
+	assert.Equal(t, &outer.ID, get(loose.ID).ParentID)
+
+	// A frame is saved together with its moved contents
+	outer.X, outer.Content = 110, "Associates"
+	assert.NoError(t, repo.UpdateWithPositions(outer, []models.BoardItem{{ID: note.ID, X: 110, Y: 10}}))
+	assert.Equal(t, "Associates", get(outer.ID).Content)
+	assert.Equal(t, 110.0, get(note.ID).X)
+
+	// Deleting items takes their connections along
+	kept := &models.BoardConnection{ID: uuid.New(), BoardID: boardID, FromItemID: outer.ID, ToItemID: nested.ID, Direction: models.DirectionNone, CreatedBy: userID}
+	dropped := &models.BoardConnection{ID: uuid.New(), BoardID: boardID, FromItemID: note.ID, ToItemID: nested.ID, Direction: models.DirectionNone, CreatedBy: userID}
+	assert.NoError(t, db.Create(kept).Error)
+	assert.NoError(t, db.Create(dropped).Error)
+
+	connections, err := connRepo.ListByItems(boardID, []uuid.UUID{note.ID, loose.ID})
+	assert.NoError(t, err)
+	assert.Len(t, connections, 1)
+
+	assert.NoError(t, repo.DeleteItems([]uuid.UUID{note.ID, inner.ID, loose.ID}))
+	remaining, err := repo.ListByBoard(boardID)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []uuid.UUID{outer.ID, nested.ID}, ids(remaining))
+	connections, err = connRepo.ListByBoard(boardID)
+	assert.NoError(t, err)
+	if assert.Len(t, connections, 1) {
+		assert.Equal(t, kept.ID, connections[0].ID)
+	}
+}
+
+func TestBoardItemRepository_ListClassified(t *testing.T) {
+	db := setupItemTestDB(t)
+	repo := NewBoardItemRepository(db)
+
+	boardID := uuid.New()
+	userID := uuid.New()
+	secret := &models.BoardItem{BoardID: boardID, Type: models.ItemTypeNote, Content: "Informant", Classification: models.ClassificationSecret, CreatedBy: userID}
+	for _, item := range []*models.BoardItem{
+		secret,
+		{BoardID: boardID, Type: models.ItemTypeNote, Content: "Unmarked", CreatedBy: userID},
+		{BoardID: boardID, Type: models.ItemTypeNote, Content: "Open", Classification: models.ClassificationUnclassified, CreatedBy: userID},
+		{BoardID: uuid.New(), Type: models.ItemTypeNote, Content: "Elsewhere", Classification: models.ClassificationTopSecret, CreatedBy: userID},
+	} {
+		assert.NoError(t, repo.Create(item))
+	}
+
+	items, err := repo.ListClassified(boardID)
+	assert.NoError(t, err)
+	if assert.Len(t, items, 1) {
+		assert.Equal(t, secret.ID, items[0].ID)
+		assert.Equal(t, models.ClassificationSecret, items[0].Classification)
+		assert.Empty(t, items[0].Content, "only the ID and classification are loaded")
+	}
+}
@@ -41,13 +41,33 @@ func (r *BoardRepository) GetByID(id uuid.UUID) (*models.Board, error) {
 	return &board, nil
 }
 
-// GetByIDWithPermission retrieves a board by ID and checks user permission
+// GetRowByID retrieves a board by ID without its users, items or connections
+func (r *BoardRepository) GetRowByID(id uuid.UUID) (*models.Board, error) {
+	var board models.Board
+	err := r.db.Where("id = ?", id).First(&board).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &board, nil
+}
+
+// GetByIDWithPermission retrieves a board by ID and checks user permission.
+// Items and connections are not loaded; see GetByIDWithContents.
 func (r *BoardRepository) GetByIDWithPermission(boardID, userID uuid.UUID) (*models.Board, models.PermissionLevel, error) {
+	return r.getWithPermission(r.db.Preload("Users.User"), boardID, userID)
+}
+
+// GetByIDWithContents retrieves a board by ID with its items and connections and checks user permission
+func (r *BoardRepository) GetByIDWithContents(boardID, userID uuid.UUID) (*models.Board, models.PermissionLevel, error) {
+	return r.getWithPermission(r.db.Preload("Users.User").Preload("Items").Preload("Connections"), boardID, userID)
+}
+
+func (r *BoardRepository) getWithPermission(query *gorm.DB, boardID, userID uuid.UUID) (*models.Board, models.PermissionLevel, error) {
 	var board models.Board
-	err := r.db.Preload("Users.User").
-		Preload("Items").
-		Preload("Connections").
-		Where("id = ?", boardID).
+	err := query.Where("id = ?", boardID).
 		First(&board).Error
 
 	if err != nil {
@@ -80,6 +100,40 @@ func (r *BoardRepository) GetByIDWithPermission(boardID, userID uuid.UUID) (*mod
 	return &board, boardUser.Permission, nil
 }
 
+// GetPermission resolves whether a board exists and the user's permission on
+// it from the boards and board_users tables alone. The permission is empty
+// when the user has no access.
+func (r *BoardRepository) GetPermission(boardID, userID uuid.UUID) (bool, models.PermissionLevel, error) {
+	var access struct {
+		OwnerID    uuid.UUID
+		Visibility models.BoardVisibility
+		Permission *models.PermissionLevel
+	}
+	result := r.db.Model(&models.Board{}).
+		Select("boards.owner_id, boards.visibility, board_users.permission").
+		Joins("LEFT JOIN board_users ON board_users.board_id = boards.id AND board_users.user_id = ?", userID).
+		Where("boards.id = ?", boardID).
+		Limit(1).
+		Scan(&access)
+	if result.Error != nil {
+		return false, "", result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, "", nil
+	}
+
+	// Same precedence as GetByIDWithPermission
+	switch {
+	case access.OwnerID == userID:
+		return true, models.PermissionAdmin, nil
+	case access.Visibility == models.VisibilityPublic:
+		return true, models.PermissionRead, nil
+	case access.Permission != nil:
+		return true, *access.Permission, nil
+	}
+	return true, "", nil
+}
+
 // ListByUser retrieves boards accessible by a user
 func (r *BoardRepository) ListByUser(userID uuid.UUID, offset, limit int) ([]models.Board, int64, error) {
 	var boards []models.Board
@@ -106,6 +160,30 @@ func (r *BoardRepository) ListByUser(userID uuid.UUID, offset, limit int) ([]mod
 	return boards, total, err
 }
 
+// ListByUserAndTags retrieves boards accessible by a user that carry any of the given tags
+func (r *BoardRepository) ListByUserAndTags(userID uuid.UUID, tagIDs []uuid.UUID, offset, limit int) ([]models.Board, int64, error) {
+	var boards []models.Board
+	var total int64
+
+	query := r.db.Model(&models.Board{}).
+		Joins("LEFT JOIN board_users ON boards.id = board_users.board_id").
+		Where("boards.owner_id = ? OR board_users.user_id = ?", userID, userID).
+		Where("EXISTS (SELECT 1 FROM tag_assignments WHERE tag_assignments.target_id = boards.id AND tag_assignments.target_type = ? AND tag_assignments.tag_id IN ?)", models.TagTargetBoard, tagIDs).
+		Group("boards.id")
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Preload("Users.User").
+		Offset(offset).
+		Limit(limit).
+		Order("boards.updated_at DESC").
+		Find(&boards).Error
+
+	return boards, total, err
+}
+
 // ListPublic retrieves public boards
 func (r *BoardRepository) ListPublic(offset, limit int) ([]models.Board, int64, error) {
 	var boards []models.Board
@@ -181,5 +259,3 @@ func (r *BoardUserRepository) ListByBoard(boardID uuid.UUID) ([]models.BoardUser
 	err := r.db.Preload("User").Where("board_id = ?", boardID).Find(&boardUsers).Error
 	return boardUsers, err
 }
-
-
@@ -40,6 +40,9 @@ func setupTestDB(t *testing.T) *gorm.DB {
 			description TEXT,
 			visibility TEXT DEFAULT 'private',
 			owner_id TEXT NOT NULL,
+			parent_board_id TEXT,
+			custom_fields TEXT,
+			classification TEXT,
 			created_at DATETIME,
 			updated_at DATETIME,
 			deleted_at DATETIME
@@ -53,6 +56,7 @@ func setupTestDB(t *testing.T) *gorm.DB {
 			board_id TEXT NOT NULL,
 			user_id TEXT NOT NULL,
 			permission TEXT NOT NULL,
+			clearance TEXT,
 			created_at DATETIME,
 			updated_at DATETIME
 		)
@@ -63,6 +67,8 @@ func setupTestDB(t *testing.T) *gorm.DB {
 		CREATE TABLE board_items (
 			id TEXT PRIMARY KEY,
 			board_id TEXT NOT NULL,
+			parent_id TEXT,
+			layer_id TEXT,
 			type TEXT NOT NULL,
 			x REAL NOT NULL,
 			y REAL NOT NULL,
@@ -87,6 +93,11 @@ func setupTestDB(t *testing.T) *gorm.DB {
 			board_id TEXT NOT NULL,
 			from_item_id TEXT NOT NULL,
 			to_item_id TEXT NOT NULL,
+			layer_id TEXT,
+			label TEXT,
+			direction TEXT NOT NULL DEFAULT 'none',
+			relationship_type TEXT,
+			confidence TEXT,
 			style TEXT,
 			created_by TEXT NOT NULL,
 			created_at DATETIME,
@@ -273,6 +284,47 @@ func TestBoardRepository_GetByIDWithPermission(t *testing.T) {
 	}
 }
 
+func TestBoardRepository_GetPermission(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewBoardRepository(db)
+
+	ownerID := uuid.New()
+	writerID := uuid.New()
+	privateID := uuid.New()
+	publicID := uuid.New()
+	deletedID := uuid.New()
+
+	assert.NoError(t, db.Create(&models.Board{ID: privateID, Title: "Private", Visibility: models.VisibilityPrivate, OwnerID: ownerID}).Error)
+	assert.NoError(t, db.Create(&models.Board{ID: publicID, Title: "Public", Visibility: models.VisibilityPublic, OwnerID: ownerID}).Error)
+	assert.NoError(t, db.Create(&models.Board{ID: deletedID, Title: "Deleted", Visibility: models.VisibilityPublic, OwnerID: ownerID}).Error)
+	assert.NoError(t, db.Delete(&models.Board{}, "id = ?", deletedID).Error)
+	assert.NoError(t, db.Create(&models.BoardUser{ID: uuid.New(), BoardID: privateID, UserID: writerID, Permission: models.PermissionWrite}).Error)
+
+	tests := []struct {
+		name       string
+		boardID    uuid.UUID
+		userID     uuid.UUID
+		exists     bool
+		permission models.PermissionLevel
+	}{
+		{"owner", privateID, ownerID, true, models.PermissionAdmin},
+		{"shared user", privateID, writerID, true, models.PermissionWrite},
+		{"stranger on private board", privateID, uuid.New(), true, ""},
+		{"stranger on public board", publicID, uuid.New(), true, models.PermissionRead},
+		{"deleted board", deletedID, ownerID, false, ""},
+		{"non-existing board", uuid.New(), ownerID, false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exists, permission, err := repo.GetPermission(tt.boardID, tt.userID)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.exists, exists)
+			assert.Equal(t, tt.permission, permission)
+		})
+	}
+}
+
 func TestBoardRepository_ListByUser(t *testing.T) {
 	db := setupTestDB(t)
 	repo := NewBoardRepository(db)
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"errors"
+
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CustodyRepository handles the custody log. It only ever appends; there is
+// deliberately no update, and events are only deleted with their board.
+type CustodyRepository struct {
+	db *gorm.DB
+}
+
+// NewCustodyRepository creates a new custody repository
+func NewCustodyRepository(db *gorm.DB) *CustodyRepository {
+	return &CustodyRepository{db: db}
+}
+
+// Create appends an event. It fails if another event already took the same
+// sequence number for the item.
+func (r *CustodyRepository) Create(event *models.CustodyEvent) error {
+	return r.db.Create(event).Error
+}
+
+// Last retrieves the latest event of an item
+func (r *CustodyRepository) Last(itemID uuid.UUID) (*models.CustodyEvent, error) {
+	var event models.CustodyEvent
+	err := r.db.Where("item_id = ?", itemID).Order("seq DESC").First(&event).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &event, nil
+}
+
+// ListByItem retrieves the events of an item in order
+func (r *CustodyRepository) ListByItem(itemID uuid.UUID) ([]models.CustodyEvent, error) {
+	var events []models.CustodyEvent
+	err := r.db.Where("item_id = ?", itemID).Order("seq ASC").Find(&events).Error
+	return events, err
+}
+
+// DeleteByBoard deletes the custody logs of a board's items, once the board
+// itself is deleted
+func (r *CustodyRepository) DeleteByBoard(boardID uuid.UUID) error {
+	return r.db.Where("board_id = ?", boardID).Delete(&models.CustodyEvent{}).Error
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"testing"
+
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupCustodyTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+
+	err = db.Exec(`
+		CREATE TABLE custody_events (
+			id TEXT PRIMARY KEY,
+			board_id TEXT NOT NULL,
+			item_id TEXT NOT NULL,
+			seq INTEGER NOT NULL,
+			attachment_id TEXT NOT NULL,
+			action TEXT NOT NULL,
+			user_id TEXT NOT NULL,
+			sha256 TEXT,
+			client_ip TEXT,
+			detail TEXT,
+			prev_hash TEXT,
+			hash TEXT NOT NULL,
+			created_at DATETIME,
+			UNIQUE (item_id, seq)
+		)
+	`).Error
+	assert.NoError(t, err)
+
+	return db
+}
+
+func TestCustodyRepository(t *testing.T) {
+	db := setupCustodyTestDB(t)
+	repo := NewCustodyRepository(db)
+	itemID := uuid.New()
+
+	last, err := repo.Last(itemID)
+	assert.NoError(t, err)
+	assert.Nil(t, last)
+
+	event := func(seq int64, action models.CustodyAction) *models.CustodyEvent {
+		return &models.CustodyEvent{BoardID: uuid.New(), ItemID: itemID, Seq: seq, AttachmentID: uuid.New(),
+			Action: action, UserID: uuid.New(), Hash: string(action)}
+	}
+	assert.NoError(t, repo.Create(event(2, models.CustodyDownloaded)))
+	assert.NoError(t, repo.Create(event(1, models.CustodyUploaded)))
+	assert.NoError(t, repo.Create(&models.CustodyEvent{ItemID: uuid.New(), Seq: 1, Hash: "other"}))
+
+	// A second writer that read the same last event loses the race
+	assert.Error(t, repo.Create(event(2, models.CustodyViewed)))
+
+	last, err = repo.Last(itemID)
+	assert.NoError(t, err)
+	if assert.NotNil(t, last) {
+		assert.Equal(t, models.CustodyDownloaded, last.Action)
+	}
+
+	events, err := repo.ListByItem(itemID)
+	assert.NoError(t, err)
+	if assert.Len(t, events, 2) {
+		assert.Equal(t, int64(1), events[0].Seq)
+		assert.Equal(t, int64(2), events[1].Seq)
+	}
+}
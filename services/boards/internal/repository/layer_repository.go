@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"errors"
+
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// LayerRepository handles board layer data operations
+type LayerRepository struct {
+	db *gorm.DB
+}
+
+// NewLayerRepository creates a new layer repository
+func NewLayerRepository(db *gorm.DB) *LayerRepository {
+	return &LayerRepository{db: db}
+}
+
+// Create creates a new layer
+func (r *LayerRepository) Create(layer *models.Layer) error {
+	return r.db.Create(layer).Error
+}
+
+// GetByID retrieves a layer by ID
+func (r *LayerRepository) GetByID(id uuid.UUID) (*models.Layer, error) {
+	var layer models.Layer
+	err := r.db.Where("id = ?", id).First(&layer).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &layer, nil
+}
+
+// ListByBoard retrieves the layers of a board in stacking order
+func (r *LayerRepository) ListByBoard(boardID uuid.UUID) ([]models.Layer, error) {
+	var layers []models.Layer
+	err := r.db.Where("board_id = ?", boardID).
+		Order("position ASC, created_at ASC").
+		Find(&layers).Error
+	return layers, err
+}
+
+// Update updates a layer
+func (r *LayerRepository) Update(layer *models.Layer) error {
+	return r.db.Save(layer).Error
+}
+
+// CountContents counts the items and connections on a layer
+func (r *LayerRepository) CountContents(id uuid.UUID) (int64, error) {
+	var items, connections int64
+	if err := r.db.Model(&models.BoardItem{}).Where("layer_id = ?", id).Count(&items).Error; err != nil {
+		return 0, err
+	}
+	if err := r.db.Model(&models.BoardConnection{}).Where("layer_id = ?", id).Count(&connections).Error; err != nil {
+		return 0, err
+	}
+	return items + connections, nil
+}
+
+// Delete deletes a layer
+func (r *LayerRepository) Delete(id uuid.UUID) error {
+	return r.db.Delete(&models.Layer{}, "id = ?", id).Error
+}
+
+// DeleteByBoard deletes all layers of a board
+func (r *LayerRepository) DeleteByBoard(boardID uuid.UUID) error {
+	return r.db.Delete(&models.Layer{}, "board_id = ?", boardID).Error
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"testing"
+
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func setupLayerTestDB(t *testing.T) *gorm.DB {
+	db := setupItemTestDB(t)
+
+	err := db.Exec(`
+		CREATE TABLE layers (
+			id TEXT PRIMARY KEY,
+			board_id TEXT NOT NULL,
+			name TEXT NOT NULL,
+			position INTEGER NOT NULL DEFAULT 0,
+			visible BOOLEAN NOT NULL,
+			locked BOOLEAN NOT NULL DEFAULT false,
+			min_permission TEXT,
+			user_ids TEXT,
+			created_by TEXT NOT NULL,
+			created_at DATETIME,
+			updated_at DATETIME
+		)
+	`).Error
+	assert.NoError(t, err)
+
+	return db
+}
+
+func TestLayerRepository_ListAndUpdate(t *testing.T) {
+	db := setupLayerTestDB(t)
+	repo := NewLayerRepository(db)
+
+	boardID := uuid.New()
+	userID := uuid.New()
+	top := &models.Layer{BoardID: boardID, Name: "Informants", Position: 2, Visible: false, UserIDs: []uuid.UUID{userID}, CreatedBy: userID}
+	bottom := &models.Layer{BoardID: boardID, Name: "Timeline", Position: 1, Visible: true, CreatedBy: userID}
+	other := &models.Layer{BoardID: uuid.New(), Name: "Elsewhere", Visible: true, CreatedBy: userID}
+	for _, layer := range []*models.Layer{top, bottom, other} {
+		assert.NoError(t, repo.Create(layer))
+	}
+
+	layers, err := repo.ListByBoard(boardID)
+	assert.NoError(t, err)
+	if assert.Len(t, layers, 2) {
+		assert.Equal(t, bottom.ID, layers[0].ID)
+		assert.Equal(t, top.ID, layers[1].ID)
+		assert.False(t, layers[1].Visible, "a hidden layer stays hidden when created")
+		assert.Equal(t, []uuid.UUID{userID}, layers[1].UserIDs)
+	}
+
+	top.Locked = true
+	top.UserIDs = nil
+	assert.NoError(t, repo.Update(top))
+	found, err := repo.GetByID(top.ID)
+	assert.NoError(t, err)
+	if assert.NotNil(t, found) {
+		assert.True(t, found.Locked)
+		assert.Empty(t, found.UserIDs)
+	}
+
+	found, err = repo.GetByID(uuid.New())
+	assert.NoError(t, err)
+	assert.Nil(t, found)
+}
+
+func TestLayerRepository_Contents(t *testing.T) {
+	db := setupLayerTestDB(t)
+	repo := NewLayerRepository(db)
+	itemRepo := NewBoardItemRepository(db)
+	connRepo := NewBoardConnectionRepository(db)
+
+	boardID := uuid.New()
+	userID := uuid.New()
+	layer := &models.Layer{BoardID: boardID, Name: "Informants", Visible: true, CreatedBy: userID}
+	assert.NoError(t, repo.Create(layer))
+
+	base := &models.BoardItem{BoardID: boardID, Type: models.ItemTypeNote, Content: "Base", CreatedBy: userID}
+	secret := &models.BoardItem{BoardID: boardID, Type: models.ItemTypeNote, Content: "Secret", CreatedBy: userID}
+	assert.NoError(t, itemRepo.Create(base))
+	assert.NoError(t, itemRepo.Create(secret))
+	conn := &models.BoardConnection{BoardID: boardID, FromItemID: base.ID, ToItemID: secret.ID, CreatedBy: userID}
+	assert.NoError(t, connRepo.Create(conn))
+
+	count, err := repo.CountContents(layer.ID)
+	assert.NoError(t, err)
+	assert.Zero(t, count)
+
+	assert.NoError(t, itemRepo.SetLayer([]uuid.UUID{secret.ID}, &layer.ID))
+	assert.NoError(t, connRepo.SetLayer([]uuid.UUID{conn.ID}, &layer.ID))
+	count, err = repo.CountContents(layer.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	ids, err := itemRepo.ListIDsByLayers(boardID, []uuid.UUID{layer.ID})
+	assert.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{secret.ID}, ids)
+
+	assert.NoError(t, itemRepo.SetLayer([]uuid.UUID{secret.ID}, nil))
+	moved, err := itemRepo.GetByID(secret.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, moved.LayerID)
+
+	assert.NoError(t, repo.Delete(layer.ID))
+	found, err := repo.GetByID(layer.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, found)
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"testing"
+
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// Permission checks for a collaborator on the 50,000 item load test board:
+// loading the board with its contents, as every mutation used to, against
+// resolving the permission from boards and board_users alone.
+
+func setupPermissionBenchmark(b *testing.B) (*BoardRepository, uuid.UUID, uuid.UUID) {
+	db := setupLoadTestDB(b)
+	board, _ := seedLargeBoard(b, db)
+	userID := uuid.New()
+	require.NoError(b, db.Create(&models.BoardUser{ID: uuid.New(), BoardID: board.ID, UserID: userID, Permission: models.PermissionWrite}).Error)
+	return NewBoardRepository(db), board.ID, userID
+}
+
+func BenchmarkBoardRepository_GetByIDWithContents(b *testing.B) {
+	repo, boardID, userID := setupPermissionBenchmark(b)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := repo.GetByIDWithContents(boardID, userID); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkBoardRepository_GetPermission(b *testing.B) {
+	repo, boardID, userID := setupPermissionBenchmark(b)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := repo.GetPermission(boardID, userID); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"fmt"
+
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// searchConfig must match the configuration of the generated search_vector
+// columns created by database.CreateSearchIndexes
+const searchConfig = "english"
+
+// headlineOptions configures ts_headline snippets
+const headlineOptions = `StartSel="` + models.HighlightStart + `", StopSel="` + models.HighlightStop + `"` +
+	`, MaxWords=30, MinWords=10, MaxFragments=2, FragmentDelimiter=" … "`
+
+// itemSearchText is the text snippets of an item are cut from: its content
+// followed by the values of its fields and custom fields
+const itemSearchText = `concat_ws(' ', board_items.content,
+	(SELECT string_agg(value, ' ') FROM jsonb_each_text(CASE WHEN jsonb_typeof(board_items.fields) = 'object' THEN board_items.fields ELSE '{}' END)),
+	(SELECT string_agg(value, ' ') FROM jsonb_each_text(CASE WHEN jsonb_typeof(board_items.custom_values) = 'object' THEN board_items.custom_values ELSE '{}' END)))`
+
+// accessibleBoard restricts a query to boards the user owns or was shared,
+// as in BoardRepository.ListByUser
+const accessibleBoard = `(boards.owner_id = ? OR EXISTS (
+	SELECT 1 FROM board_users WHERE board_users.board_id = boards.id AND board_users.user_id = ?))`
+
+// accessibleLayer restricts a query of items to those on the base layer or on
+// a layer the user can see, as in service.layerAllows. Board admins see every
+// layer; the owner's admin row may predate board_users, hence the owner check.
+const accessibleLayer = `(board_items.layer_id IS NULL OR boards.owner_id = ? OR EXISTS (
+	SELECT 1 FROM layers JOIN board_users ON board_users.board_id = layers.board_id AND board_users.user_id = ?
+	WHERE layers.id = board_items.layer_id AND (board_users.permission = 'admin' OR (
+		(COALESCE(layers.min_permission, '') IN ('', 'read') OR (layers.min_permission = 'write' AND board_users.permission = 'write'))
+		AND (layers.user_ids IS NULL OR layers.user_ids IN ('null', '[]') OR layers.user_ids @> jsonb_build_array(?::text))))))`
+
+// clearedItem restricts a query of items to those within the user's
+// clearance, as in service.boardAccess.cleared. Board admins see everything.
+var clearedItem = `(COALESCE(board_items.classification, '') IN ('', 'unclassified') OR boards.owner_id = ? OR EXISTS (
+	SELECT 1 FROM board_users WHERE board_users.board_id = board_items.board_id AND board_users.user_id = ?
+	AND (board_users.permission = 'admin' OR ` + classificationRank("board_users.clearance") + ` >= ` + classificationRank("board_items.classification") + `)))`
+
+// classificationRank is an SQL expression ranking a classification column
+// like models.ClassificationLevel.Rank
+func classificationRank(column string) string {
+	expr := "CASE " + column
+	for rank, level := range models.ClassificationLevels {
+		expr += fmt.Sprintf(" WHEN '%s' THEN %d", level, rank)
+	}
+	return expr + " ELSE 0 END"
+}
+
+// SearchRepository runs full-text searches over boards and items. It needs
+// the search_vector columns and so only works on PostgreSQL.
+type SearchRepository struct {
+	db *gorm.DB
+}
+
+// NewSearchRepository creates a new search repository
+func NewSearchRepository(db *gorm.DB) *SearchRepository {
+	return &SearchRepository{db: db}
+}
+
+// SearchBoards retrieves the boards whose title or description match, best
+// match first
+func (r *SearchRepository) SearchBoards(userID uuid.UUID, filter models.SearchFilter) ([]models.BoardSearchHit, error) {
+	query := r.db.Table("boards").
+		Where("boards.deleted_at IS NULL").
+		Where(accessibleBoard, userID, userID).
+		Where("boards.search_vector @@ websearch_to_tsquery(?, ?)", searchConfig, filter.Query)
+	if len(filter.BoardIDs) > 0 {
+		query = query.Where("boards.id IN ?", filter.BoardIDs)
+	}
+
+	var hits []models.BoardSearchHit
+	err := query.Select(`boards.id AS board_id, boards.title,
+		ts_headline(?, concat_ws(' — ', boards.title, NULLIF(boards.description, '')), websearch_to_tsquery(?, ?), ?) AS snippet,
+		ts_rank_cd(boards.search_vector, websearch_to_tsquery(?, ?)) AS rank`,
+		searchConfig, searchConfig, filter.Query, headlineOptions, searchConfig, filter.Query).
+		Order("rank DESC, boards.updated_at DESC").
+		Offset(filter.Offset).
+		Limit(filter.Limit).
+		Scan(&hits).Error
+	return hits, err
+}
+
+// SearchItems retrieves a page of the items whose content, fields or
+// metadata match, best match first, with the total number of matches
+func (r *SearchRepository) SearchItems(userID uuid.UUID, filter models.SearchFilter) ([]models.ItemSearchHit, int64, error) {
+	query := func() *gorm.DB {
+		return filterItemTypes(filterBoards(r.matchingItems(userID, filter.Query), filter.BoardIDs), filter.ItemTypes)
+	}
+
+	var total int64
+	if err := query().Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var hits []models.ItemSearchHit
+	err := query().Select(`board_items.id AS item_id, board_items.board_id, boards.title AS board_title, board_items.type,
+		ts_headline(?, `+itemSearchText+`, websearch_to_tsquery(?, ?), ?) AS snippet,
+		ts_rank_cd(board_items.search_vector, websearch_to_tsquery(?, ?)) AS rank`,
+		searchConfig, searchConfig, filter.Query, headlineOptions, searchConfig, filter.Query).
+		Order("rank DESC, board_items.updated_at DESC").
+		Offset(filter.Offset).
+		Limit(filter.Limit).
+		Scan(&hits).Error
+	return hits, total, err
+}
+
+// ItemFacets counts the matching items per board and per item type. Each
+// facet applies the other's filter but not its own, so the counts show what
+// choosing a value would give.
+func (r *SearchRepository) ItemFacets(userID uuid.UUID, filter models.SearchFilter) ([]models.SearchFacet, []models.SearchFacet, error) {
+	var boards []models.SearchFacet
+	err := filterItemTypes(r.matchingItems(userID, filter.Query), filter.ItemTypes).
+		Select("board_items.board_id::text AS value, boards.title AS label, COUNT(*) AS count").
+		Group("board_items.board_id, boards.title").
+		Order("count DESC, boards.title ASC").
+		Scan(&boards).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var types []models.SearchFacet
+	err = filterBoards(r.matchingItems(userID, filter.Query), filter.BoardIDs).
+		Select("board_items.type AS value, COUNT(*) AS count").
+		Group("board_items.type").
+		Order("count DESC, board_items.type ASC").
+		Scan(&types).Error
+	if err != nil {
+		return nil, nil, err
+	}
+	return boards, types, nil
+}
+
+// matchingItems selects the live items on accessible boards and layers,
+// within the user's clearance, that match query
+func (r *SearchRepository) matchingItems(userID uuid.UUID, query string) *gorm.DB {
+	return r.db.Table("board_items").
+		Joins("JOIN boards ON boards.id = board_items.board_id AND boards.deleted_at IS NULL").
+		Where("board_items.deleted_at IS NULL").
+		Where(accessibleBoard, userID, userID).
+		Where(accessibleLayer, userID, userID, userID.String()).
+		Where(clearedItem, userID, userID).
+		Where("board_items.search_vector @@ websearch_to_tsquery(?, ?)", searchConfig, query)
+}
+
+func filterBoards(query *gorm.DB, boardIDs []uuid.UUID) *gorm.DB {
+	if len(boardIDs) == 0 {
+		return query
+	}
+	return query.Where("board_items.board_id IN ?", boardIDs)
+}
+
+func filterItemTypes(query *gorm.DB, types []string) *gorm.DB {
+	if len(types) == 0 {
+		return query
+	}
+	return query.Where("board_items.type IN ?", types)
+}
@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"errors"
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TagRepository handles tag and tag assignment data operations
+type TagRepository struct {
+	db *gorm.DB
+}
+
+// NewTagRepository creates a new tag repository
+func NewTagRepository(db *gorm.DB) *TagRepository {
+	return &TagRepository{db: db}
+}
+
+// Create creates a new tag
+func (r *TagRepository) Create(tag *models.Tag) error {
+	return r.db.Create(tag).Error
+}
+
+// GetByID retrieves a tag by ID
+func (r *TagRepository) GetByID(id uuid.UUID) (*models.Tag, error) {
+	var tag models.Tag
+	err := r.db.Where("id = ?", id).First(&tag).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// ListByIDs retrieves the tags with the given IDs
+func (r *TagRepository) ListByIDs(ids []uuid.UUID) ([]models.Tag, error) {
+	var tags []models.Tag
+	if len(ids) == 0 {
+		return tags, nil
+	}
+	err := r.db.Where("id IN ?", ids).Find(&tags).Error
+	return tags, err
+}
+
+// ListByOwner retrieves a user's personal tags
+func (r *TagRepository) ListByOwner(userID uuid.UUID) ([]models.Tag, error) {
+	var tags []models.Tag
+	err := r.db.Where("scope = ? AND owner_id = ?", models.TagScopeUser, userID).
+		Order("name ASC").
+		Find(&tags).Error
+	return tags, err
+}
+
+// ListByBoard retrieves the tags shared on a board
+func (r *TagRepository) ListByBoard(boardID uuid.UUID) ([]models.Tag, error) {
+	var tags []models.Tag
+	err := r.db.Where("scope = ? AND board_id = ?", models.TagScopeBoard, boardID).
+		Order("name ASC").
+		Find(&tags).Error
+	return tags, err
+}
+
+// FindByName retrieves a tag by case-insensitive name among the personal
+// tags of a user or the tags of a board, depending on the scope
+func (r *TagRepository) FindByName(scope models.TagScope, scopeID uuid.UUID, name string) (*models.Tag, error) {
+	column := "owner_id"
+	if scope == models.TagScopeBoard {
+		column = "board_id"
+	}
+
+	var tag models.Tag
+	err := r.db.Where("scope = ? AND "+column+" = ? AND LOWER(name) = LOWER(?)", scope, scopeID, name).
+		First(&tag).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// Update updates a tag
+func (r *TagRepository) Update(tag *models.Tag) error {
+	return r.db.Save(tag).Error
+}
+
+// Delete deletes a tag and its assignments
+func (r *TagRepository) Delete(id uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tag_id = ?", id).Delete(&models.TagAssignment{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", id).Delete(&models.Tag{}).Error
+	})
+}
+
+// DeleteByBoard deletes a board's tags and every tag assignment on the board
+// and its items
+func (r *TagRepository) DeleteByBoard(boardID uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("board_id = ?", boardID).Delete(&models.TagAssignment{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("scope = ? AND board_id = ?", models.TagScopeBoard, boardID).Delete(&models.Tag{}).Error
+	})
+}
+
+// Merge moves the assignments of one tag to another and deletes the first.
+// Targets that already carry both tags keep a single assignment.
+func (r *TagRepository) Merge(fromID, intoID uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`
+			INSERT INTO tag_assignments (tag_id, target_id, target_type, board_id, created_by, created_at)
+			SELECT ?, target_id, target_type, board_id, created_by, created_at
+			FROM tag_assignments WHERE tag_id = ?
+			ON CONFLICT DO NOTHING`, intoID, fromID).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("tag_id = ?", fromID).Delete(&models.TagAssignment{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", fromID).Delete(&models.Tag{}).Error
+	})
+}
+
+// Assign creates tag assignments, skipping the ones that already exist
+func (r *TagRepository) Assign(assignments []models.TagAssignment) error {
+	if len(assignments) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&assignments).Error
+}
+
+// Unassign removes the given tags from the given targets
+func (r *TagRepository) Unassign(tagIDs, targetIDs []uuid.UUID) error {
+	if len(tagIDs) == 0 || len(targetIDs) == 0 {
+		return nil
+	}
+	return r.db.Where("tag_id IN ? AND target_id IN ?", tagIDs, targetIDs).
+		Delete(&models.TagAssignment{}).Error
+}
+
+// ListAssignments retrieves the assignments of the given tags on a board and its items
+func (r *TagRepository) ListAssignments(boardID uuid.UUID, tagIDs []uuid.UUID) ([]models.TagAssignment, error) {
+	var assignments []models.TagAssignment
+	if len(tagIDs) == 0 {
+		return assignments, nil
+	}
+	err := r.db.Where("board_id = ? AND tag_id IN ?", boardID, tagIDs).
+		Order("created_at ASC").
+		Find(&assignments).Error
+	return assignments, err
+}
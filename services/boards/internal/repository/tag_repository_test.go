@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"testing"
+
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func setupTagTestDB(t *testing.T) *gorm.DB {
+	db := setupTestDB(t)
+
+	err := db.Exec(`
+		CREATE TABLE tags (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			color TEXT NOT NULL,
+			scope TEXT NOT NULL DEFAULT 'user',
+			owner_id TEXT,
+			board_id TEXT,
+			created_by TEXT NOT NULL,
+			created_at DATETIME,
+			updated_at DATETIME
+		)
+	`).Error
+	assert.NoError(t, err)
+
+	err = db.Exec(`
+		CREATE TABLE tag_assignments (
+			tag_id TEXT NOT NULL,
+			target_id TEXT NOT NULL,
+			target_type TEXT NOT NULL,
+			board_id TEXT NOT NULL,
+			created_by TEXT NOT NULL,
+			created_at DATETIME,
+			PRIMARY KEY (tag_id, target_id)
+		)
+	`).Error
+	assert.NoError(t, err)
+
+	return db
+}
+
+func TestTagRepository_Scopes(t *testing.T) {
+	db := setupTagTestDB(t)
+	repo := NewTagRepository(db)
+
+	userID := uuid.New()
+	boardID := uuid.New()
+	personal := &models.Tag{Name: "Follow up", Color: "#ff0000", Scope: models.TagScopeUser, OwnerID: &userID, CreatedBy: userID}
+	shared := &models.Tag{Name: "Alibi", Color: "#00ff00", Scope: models.TagScopeBoard, BoardID: &boardID, CreatedBy: userID}
+	assert.NoError(t, repo.Create(personal))
+	assert.NoError(t, repo.Create(shared))
+	assert.NotEqual(t, uuid.Nil, personal.ID)
+
+	tags, err := repo.ListByOwner(userID)
+	assert.NoError(t, err)
+	if assert.Len(t, tags, 1) {
+		assert.Equal(t, personal.ID, tags[0].ID)
+	}
+
+	tags, err = repo.ListByBoard(boardID)
+	assert.NoError(t, err)
+	if assert.Len(t, tags, 1) {
+		assert.Equal(t, shared.ID, tags[0].ID)
+	}
+
+	found, err := repo.FindByName(models.TagScopeUser, userID, "FOLLOW UP")
+	assert.NoError(t, err)
+	if assert.NotNil(t, found) {
+		assert.Equal(t, personal.ID, found.ID)
+	}
+	found, err = repo.FindByName(models.TagScopeBoard, userID, "Follow up")
+	assert.NoError(t, err)
+	assert.Nil(t, found)
+
+	tags, err = repo.ListByIDs([]uuid.UUID{personal.ID, shared.ID, uuid.New()})
+	assert.NoError(t, err)
+	assert.Len(t, tags, 2)
+
+	missing, err := repo.GetByID(uuid.New())
+	assert.NoError(t, err)
+	assert.Nil(t, missing)
+}
+
+func TestTagRepository_AssignAndMerge(t *testing.T) {
+	db := setupTagTestDB(t)
+	repo := NewTagRepository(db)
+
+	userID := uuid.New()
+	boardID := uuid.New()
+	itemA, itemB := uuid.New(), uuid.New()
+	from := &models.Tag{Name: "suspect", Color: "#ff0000", Scope: models.TagScopeBoard, BoardID: &boardID, CreatedBy: userID}
+	into := &models.Tag{Name: "Suspect", Color: "#00ff00", Scope: models.TagScopeBoard, BoardID: &boardID, CreatedBy: userID}
+	assert.NoError(t, repo.Create(from))
+	assert.NoError(t, repo.Create(into))
+
+	assign := func(tagID, targetID uuid.UUID) models.TagAssignment {
+		return models.TagAssignment{TagID: tagID, TargetID: targetID, TargetType: models.TagTargetItem, BoardID: boardID, CreatedBy: userID}
+	}
+	assert.NoError(t, repo.Assign([]models.TagAssignment{assign(from.ID, itemA), assign(from.ID, itemB), assign(into.ID, itemA)}))
+	// Assigning again is a no-op
+	assert.NoError(t, repo.Assign([]models.TagAssignment{assign(from.ID, itemA)}))
+
+	assignments, err := repo.ListAssignments(boardID, []uuid.UUID{from.ID, into.ID})
+	assert.NoError(t, err)
+	assert.Len(t, assignments, 3)
+
+	assert.NoError(t, repo.Merge(from.ID, into.ID))
+	assignments, err = repo.ListAssignments(boardID, []uuid.UUID{from.ID, into.ID})
+	assert.NoError(t, err)
+	assert.Len(t, assignments, 2)
+	for _, a := range assignments {
+		assert.Equal(t, into.ID, a.TagID)
+	}
+	gone, err := repo.GetByID(from.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, gone)
+
+	assert.NoError(t, repo.Unassign([]uuid.UUID{into.ID}, []uuid.UUID{itemA}))
+	assignments, err = repo.ListAssignments(boardID, []uuid.UUID{into.ID})
+	assert.NoError(t, err)
+	if assert.Len(t, assignments, 1) {
+		assert.Equal(t, itemB, assignments[0].TargetID)
+	}
+
+	assert.NoError(t, repo.Delete(into.ID))
+	assignments, err = repo.ListAssignments(boardID, []uuid.UUID{into.ID})
+	assert.NoError(t, err)
+	assert.Empty(t, assignments)
+}
+
+func TestTagRepository_DeleteByBoard(t *testing.T) {
+	db := setupTagTestDB(t)
+	repo := NewTagRepository(db)
+
+	userID := uuid.New()
+	boardID, otherBoardID := uuid.New(), uuid.New()
+	personal := &models.Tag{Name: "Follow up", Color: "#ff0000", Scope: models.TagScopeUser, OwnerID: &userID, CreatedBy: userID}
+	shared := &models.Tag{Name: "Alibi", Color: "#00ff00", Scope: models.TagScopeBoard, BoardID: &boardID, CreatedBy: userID}
+	other := &models.Tag{Name: "Alibi", Color: "#00ff00", Scope: models.TagScopeBoard, BoardID: &otherBoardID, CreatedBy: userID}
+	for _, tag := range []*models.Tag{personal, shared, other} {
+		assert.NoError(t, repo.Create(tag))
+	}
+	assert.NoError(t, repo.Assign([]models.TagAssignment{
+		{TagID: personal.ID, TargetID: boardID, TargetType: models.TagTargetBoard, BoardID: boardID, CreatedBy: userID},
+		{TagID: shared.ID, TargetID: uuid.New(), TargetType: models.TagTargetItem, BoardID: boardID, CreatedBy: userID},
+		{TagID: personal.ID, TargetID: otherBoardID, TargetType: models.TagTargetBoard, BoardID: otherBoardID, CreatedBy: userID},
+	}))
+
+	assert.NoError(t, repo.DeleteByBoard(boardID))
+	assignments, err := repo.ListAssignments(boardID, []uuid.UUID{personal.ID, shared.ID})
+	assert.NoError(t, err)
+	assert.Empty(t, assignments)
+	gone, err := repo.GetByID(shared.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, gone)
+
+	// Personal tags and other boards are left alone
+	kept, err := repo.ListByIDs([]uuid.UUID{personal.ID, other.ID})
+	assert.NoError(t, err)
+	assert.Len(t, kept, 2)
+	assignments, err = repo.ListAssignments(otherBoardID, []uuid.UUID{personal.ID})
+	assert.NoError(t, err)
+	assert.Len(t, assignments, 1)
+}
+
+func TestBoardRepository_ListByUserAndTags(t *testing.T) {
+	db := setupTagTestDB(t)
+	boardRepo := NewBoardRepository(db)
+	tagRepo := NewTagRepository(db)
+
+	userID := uuid.New()
+	tagged := &models.Board{ID: uuid.New(), Title: "Tagged", OwnerID: userID}
+	untagged := &models.Board{ID: uuid.New(), Title: "Untagged", OwnerID: userID}
+	otherUsers := &models.Board{ID: uuid.New(), Title: "Not shared", OwnerID: uuid.New()}
+	for _, b := range []*models.Board{tagged, untagged, otherUsers} {
+		assert.NoError(t, db.Create(b).Error)
+	}
+
+	tag := &models.Tag{Name: "Open", Color: "#0000ff", Scope: models.TagScopeUser, OwnerID: &userID, CreatedBy: userID}
+	assert.NoError(t, tagRepo.Create(tag))
+	for _, b := range []*models.Board{tagged, otherUsers} {
+		assert.NoError(t, tagRepo.Assign([]models.TagAssignment{{TagID: tag.ID, TargetID: b.ID, TargetType: models.TagTargetBoard, BoardID: b.ID, CreatedBy: userID}}))
+	}
+
+	boards, total, err := boardRepo.ListByUserAndTags(userID, []uuid.UUID{tag.ID}, 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	if assert.Len(t, boards, 1) {
+		assert.Equal(t, tagged.ID, boards[0].ID)
+	}
+}
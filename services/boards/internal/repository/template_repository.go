@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"errors"
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TemplateRepository handles board template data operations
+type TemplateRepository struct {
+	db *gorm.DB
+}
+
+// NewTemplateRepository creates a new template repository
+func NewTemplateRepository(db *gorm.DB) *TemplateRepository {
+	return &TemplateRepository{db: db}
+}
+
+// Create creates a new template
+func (r *TemplateRepository) Create(template *models.BoardTemplate) error {
+	return r.db.Create(template).Error
+}
+
+// GetByID retrieves a template by ID
+func (r *TemplateRepository) GetByID(id uuid.UUID) (*models.BoardTemplate, error) {
+	var template models.BoardTemplate
+	err := r.db.Where("id = ?", id).First(&template).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &template, nil
+}
+
+// ListVisible retrieves organization-wide templates and templates owned by the user
+func (r *TemplateRepository) ListVisible(userID uuid.UUID) ([]models.BoardTemplate, error) {
+	var templates []models.BoardTemplate
+	err := r.db.Where("scope = ? OR owner_id = ?", models.TemplateScopeOrganization, userID).
+		Order("name ASC").
+		Find(&templates).Error
+	return templates, err
+}
+
+// Delete permanently deletes a template
+func (r *TemplateRepository) Delete(id uuid.UUID) error {
+	return r.db.Unscoped().Where("id = ?", id).Delete(&models.BoardTemplate{}).Error
+}
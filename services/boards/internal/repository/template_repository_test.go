@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"testing"
+
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupTemplateTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+
+	err = db.Exec(`
+		CREATE TABLE board_templates (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			description TEXT,
+			scope TEXT NOT NULL DEFAULT 'user',
+			owner_id TEXT,
+			source_board_id TEXT,
+			content TEXT,
+			created_at DATETIME,
+			updated_at DATETIME,
+			deleted_at DATETIME
+		)
+	`).Error
+	assert.NoError(t, err)
+
+	return db
+}
+
+func TestTemplateRepository_CreateAndGetByID(t *testing.T) {
+	db := setupTemplateTestDB(t)
+	repo := NewTemplateRepository(db)
+
+	ownerID := uuid.New()
+	template := &models.BoardTemplate{
+		Name:    "Suspects",
+		Scope:   models.TemplateScopeUser,
+		OwnerID: &ownerID,
+		Content: models.TemplateContent{
+			Items: []models.TemplateItem{
+				{Key: "a", Type: "suspect-card", X: 10, Y: 20, Width: 200, Height: 200, Placeholder: true},
+				{Key: "b", Type: "post-it", X: 300, Y: 20, Width: 200, Height: 200, Content: "Alibi"},
+			},
+			Connections: []models.TemplateConnection{{From: "a", To: "b"}},
+		},
+	}
+
+	err := repo.Create(template)
+	assert.NoError(t, err)
+	assert.NotEqual(t, uuid.Nil, template.ID)
+
+	found, err := repo.GetByID(template.ID)
+	assert.NoError(t, err)
+	if assert.NotNil(t, found) {
+		assert.Equal(t, "Suspects", found.Name)
+		assert.Len(t, found.Content.Items, 2)
+		assert.True(t, found.Content.Items[0].Placeholder)
+		assert.Equal(t, []models.TemplateConnection{{From: "a", To: "b"}}, found.Content.Connections)
+	}
+
+	missing, err := repo.GetByID(uuid.New())
+	assert.NoError(t, err)
+	assert.Nil(t, missing)
+}
+
+func TestTemplateRepository_ListVisible(t *testing.T) {
+	db := setupTemplateTestDB(t)
+	repo := NewTemplateRepository(db)
+
+	userID := uuid.New()
+	otherID := uuid.New()
+
+	assert.NoError(t, repo.Create(&models.BoardTemplate{Name: "Mine", Scope: models.TemplateScopeUser, OwnerID: &userID}))
+	assert.NoError(t, repo.Create(&models.BoardTemplate{Name: "Org", Scope: models.TemplateScopeOrganization, OwnerID: &otherID}))
+	assert.NoError(t, repo.Create(&models.BoardTemplate{Name: "Theirs", Scope: models.TemplateScopeUser, OwnerID: &otherID}))
+
+	templates, err := repo.ListVisible(userID)
+	assert.NoError(t, err)
+	names := []string{}
+	for _, tpl := range templates {
+		names = append(names, tpl.Name)
+	}
+	assert.Equal(t, []string{"Mine", "Org"}, names)
+}
+
+func TestTemplateRepository_Delete(t *testing.T) {
+	db := setupTemplateTestDB(t)
+	repo := NewTemplateRepository(db)
+
+	ownerID := uuid.New()
+	template := &models.BoardTemplate{Name: "Temp", Scope: models.TemplateScopeUser, OwnerID: &ownerID}
+	assert.NoError(t, repo.Create(template))
+
+	assert.NoError(t, repo.Delete(template.ID))
+
+	found, err := repo.GetByID(template.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, found)
+}
@@ -0,0 +1,225 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Load test board: a grid of 250 by 200 items 200 units apart, each
+// connected to its right-hand neighbour
+const (
+	loadGridColumns = 250
+	loadGridRows    = 200
+	loadGridSpacing = 200.0
+	loadItemSize    = 100.0
+)
+
+func setupLoadTestDB(tb testing.TB) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	require.NoError(tb, err)
+
+	statements := []string{
+		`CREATE TABLE users (
+			id TEXT PRIMARY KEY,
+			email TEXT UNIQUE NOT NULL,
+			name TEXT NOT NULL,
+			avatar TEXT,
+			password TEXT NOT NULL,
+			google_id TEXT UNIQUE,
+			verified INTEGER DEFAULT 0,
+			active INTEGER DEFAULT 1,
+			created_at DATETIME,
+			updated_at DATETIME,
+			deleted_at DATETIME
+		)`,
+		`CREATE TABLE boards (
+			id TEXT PRIMARY KEY,
+			title TEXT NOT NULL,
+			description TEXT,
+			visibility TEXT DEFAULT 'private',
+			owner_id TEXT NOT NULL,
+			parent_board_id TEXT,
+			custom_fields TEXT,
+			classification TEXT,
+			created_at DATETIME,
+			updated_at DATETIME,
+			deleted_at DATETIME
+		)`,
+		`CREATE TABLE board_users (
+			id TEXT PRIMARY KEY,
+			board_id TEXT NOT NULL,
+			user_id TEXT NOT NULL,
+			permission TEXT NOT NULL,
+			clearance TEXT,
+			created_at DATETIME,
+			updated_at DATETIME
+		)`,
+		`CREATE TABLE board_items (
+			id TEXT PRIMARY KEY,
+			board_id TEXT NOT NULL,
+			parent_id TEXT,
+			layer_id TEXT,
+			type TEXT NOT NULL,
+			x REAL NOT NULL,
+			y REAL NOT NULL,
+			width REAL DEFAULT 200,
+			height REAL DEFAULT 200,
+			rotation REAL DEFAULT 0,
+			z_index INTEGER DEFAULT 1,
+			content TEXT,
+			style TEXT,
+			fields TEXT,
+			custom_values TEXT,
+			evidence_metadata TEXT,
+			classification TEXT,
+			created_by TEXT NOT NULL,
+			created_at DATETIME,
+			updated_at DATETIME,
+			deleted_at DATETIME
+		)`,
+		`CREATE TABLE board_connections (
+			id TEXT PRIMARY KEY,
+			board_id TEXT NOT NULL,
+			from_item_id TEXT NOT NULL,
+			to_item_id TEXT NOT NULL,
+			layer_id TEXT,
+			label TEXT,
+			direction TEXT NOT NULL DEFAULT 'none',
+			relationship_type TEXT,
+			confidence TEXT,
+			style TEXT,
+			created_by TEXT NOT NULL,
+			created_at DATETIME,
+			updated_at DATETIME,
+			deleted_at DATETIME
+		)`,
+		"CREATE INDEX idx_board_items_board_id ON board_items(board_id)",
+		"CREATE INDEX idx_board_connections_board_id ON board_connections(board_id)",
+	}
+	for _, statement := range statements {
+		require.NoError(tb, db.Exec(statement).Error)
+	}
+	return db
+}
+
+// seedLargeBoard creates a 50,000 item board with 49,800 connections
+func seedLargeBoard(tb testing.TB, db *gorm.DB) (*models.Board, uuid.UUID) {
+	tb.Helper()
+	ownerID := uuid.New()
+	board := &models.Board{ID: uuid.New(), Title: "Large board", OwnerID: ownerID}
+	require.NoError(tb, db.Create(board).Error)
+
+	grid := make([][]uuid.UUID, loadGridColumns)
+	items := make([]models.BoardItem, 0, loadGridColumns*loadGridRows)
+	for i := range grid {
+		grid[i] = make([]uuid.UUID, loadGridRows)
+		for j := range grid[i] {
+			grid[i][j] = uuid.New()
+			items = append(items, models.BoardItem{
+				ID:        grid[i][j],
+				BoardID:   board.ID,
+				Type:      models.ItemTypeNote,
+				X:         float64(i) * loadGridSpacing,
+				Y:         float64(j) * loadGridSpacing,
+				Width:     loadItemSize,
+				Height:    loadItemSize,
+				CreatedBy: ownerID,
+			})
+		}
+	}
+	require.NoError(tb, db.CreateInBatches(items, 1000).Error)
+
+	connections := make([]models.BoardConnection, 0, (loadGridColumns-1)*loadGridRows)
+	for i := 0; i < loadGridColumns-1; i++ {
+		for j := 0; j < loadGridRows; j++ {
+			connections = append(connections, models.BoardConnection{
+				ID:         uuid.New(),
+				BoardID:    board.ID,
+				FromItemID: grid[i][j],
+				ToItemID:   grid[i+1][j],
+				Direction:  models.DirectionNone,
+				CreatedBy:  ownerID,
+			})
+		}
+	}
+	require.NoError(tb, db.CreateInBatches(connections, 1000).Error)
+
+	return board, ownerID
+}
+
+func TestViewportQueries_LargeBoard(t *testing.T) {
+	if testing.Short() {
+		t.Skip("load test")
+	}
+	db := setupLoadTestDB(t)
+	board, ownerID := seedLargeBoard(t, db)
+	boardRepo := NewBoardRepository(db)
+	itemRepo := NewBoardItemRepository(db)
+	connRepo := NewBoardConnectionRepository(db)
+
+	// The permission check no longer loads the whole board
+	start := time.Now()
+	loaded, permission, err := boardRepo.GetByIDWithPermission(board.ID, ownerID)
+	require.NoError(t, err)
+	assert.Equal(t, models.PermissionAdmin, permission)
+	assert.Empty(t, loaded.Items)
+	assert.Empty(t, loaded.Connections)
+	t.Logf("permission check: %v", time.Since(start))
+
+	// Columns 0-5 and rows 0-4 overlap the box, the connections from them
+	// include the ones out to column 6
+	box := models.BBox{MinX: 0, MinY: 0, MaxX: 1000, MaxY: 800}
+	start = time.Now()
+	items, err := itemRepo.ListByBoardInBBox(board.ID, box)
+	require.NoError(t, err)
+	connections, err := connRepo.ListByBoardInBBox(board.ID, box)
+	require.NoError(t, err)
+	t.Logf("viewport of %d items and %d connections: %v", len(items), len(connections), time.Since(start))
+	assert.Len(t, items, 6*5)
+	assert.Len(t, connections, 6*5)
+
+	// Zoomed all the way out everything is visible
+	items, err = itemRepo.ListByBoardInBBox(board.ID, models.BBox{MinX: -1, MinY: -1, MaxX: 1e6, MaxY: 1e6})
+	require.NoError(t, err)
+	assert.Len(t, items, loadGridColumns*loadGridRows)
+}
+
+func BenchmarkBoardItemRepository_ListByBoard(b *testing.B) {
+	db := setupLoadTestDB(b)
+	board, _ := seedLargeBoard(b, db)
+	repo := NewBoardItemRepository(db)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := repo.ListByBoard(board.ID); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkBoardItemRepository_ListByBoardInBBox(b *testing.B) {
+	db := setupLoadTestDB(b)
+	board, _ := seedLargeBoard(b, db)
+	itemRepo := NewBoardItemRepository(db)
+	connRepo := NewBoardConnectionRepository(db)
+	box := models.BBox{MinX: 10000, MinY: 10000, MaxX: 11920, MaxY: 11080} // A 1920x1080 screen
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := itemRepo.ListByBoardInBBox(board.ID, box); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := connRepo.ListByBoardInBBox(board.ID, box); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"fmt"
+
+	"evidence-wall/boards-service/internal/graph"
+
+	"github.com/google/uuid"
+)
+
+// CentralityMetric selects how item importance is measured
+type CentralityMetric string
+
+const (
+	CentralityDegree      CentralityMetric = "degree"
+	CentralityBetweenness CentralityMetric = "betweenness"
+	CentralityEigenvector CentralityMetric = "eigenvector"
+)
+
+// PathResult is a shortest path between two items. Path lists the item IDs
+// from start to end and is empty when the items are not connected.
+type PathResult struct {
+	From   uuid.UUID   `json:"from"`
+	To     uuid.UUID   `json:"to"`
+	Found  bool        `json:"found"`
+	Length int         `json:"length"` // Number of connections on the path
+	Path   []uuid.UUID `json:"path"`
+}
+
+// CentralityResult lists every item's score, highest first
+type CentralityResult struct {
+	Metric CentralityMetric `json:"metric"`
+	Scores []graph.Score    `json:"scores"`
+}
+
+// ItemGroup is a set of items found by component or community detection
+type ItemGroup struct {
+	Size    int         `json:"size"`
+	ItemIDs []uuid.UUID `json:"item_ids"`
+}
+
+// ComponentsResult lists the connected components, largest first
+type ComponentsResult struct {
+	Count      int         `json:"count"`
+	Components []ItemGroup `json:"components"`
+}
+
+// CommunitiesResult lists the detected communities, largest first, and the
+// modularity of the partition (higher means more clearly separated groups)
+type CommunitiesResult struct {
+	Count       int         `json:"count"`
+	Modularity  float64     `json:"modularity"`
+	Communities []ItemGroup `json:"communities"`
+}
+
+// IsolatedResult lists the items that have no connections
+type IsolatedResult struct {
+	Count   int         `json:"count"`
+	ItemIDs []uuid.UUID `json:"item_ids"`
+}
+
+// loadBoardGraph builds the connection graph of a board the user can read,
+// using only the connections that match the query
+func (s *BoardService) loadBoardGraph(boardID, userID uuid.UUID, query ConnectionQuery) (*graph.Graph, error) {
+	items, err := s.ListBoardItems(boardID, userID, ItemQuery{})
+	if err != nil {
+		return nil, err
+	}
+	connections, _, err := s.listBoardConnections(boardID, userID, query)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make([]uuid.UUID, len(items))
+	for i, item := range items {
+		nodes[i] = item.ID
+	}
+	edges := make([][2]uuid.UUID, len(connections))
+	for i, conn := range connections {
+		edges[i] = [2]uuid.UUID{conn.FromItemID, conn.ToItemID}
+	}
+	return graph.New(nodes, edges), nil
+}
+
+// ShortestPath finds the shortest chain of connections between two items
+func (s *BoardService) ShortestPath(boardID, userID, fromID, toID uuid.UUID, query ConnectionQuery) (*PathResult, error) {
+	g, err := s.loadBoardGraph(boardID, userID, query)
+	if err != nil {
+		return nil, err
+	}
+	if !g.Has(fromID) || !g.Has(toID) {
+		return nil, ErrItemNotFound
+	}
+
+	result := &PathResult{From: fromID, To: toID, Path: []uuid.UUID{}}
+	if path := g.ShortestPath(fromID, toID); path != nil {
+		result.Found = true
+		result.Length = len(path) - 1
+		result.Path = path
+	}
+	return result, nil
+}
+
+// Centrality scores every item on the board by the given metric
+func (s *BoardService) Centrality(boardID, userID uuid.UUID, metric CentralityMetric, query ConnectionQuery) (*CentralityResult, error) {
+	if metric == "" {
+		metric = CentralityDegree
+	}
+	var score func(*graph.Graph) []graph.Score
+	switch metric {
+	case CentralityDegree:
+		score = (*graph.Graph).DegreeCentrality
+	case CentralityBetweenness:
+		score = (*graph.Graph).BetweennessCentrality
+	case CentralityEigenvector:
+		score = (*graph.Graph).EigenvectorCentrality
+	default:
+		return nil, fmt.Errorf("%w: unknown centrality metric %q", ErrInvalidInput, metric)
+	}
+
+	g, err := s.loadBoardGraph(boardID, userID, query)
+	if err != nil {
+		return nil, err
+	}
+	return &CentralityResult{Metric: metric, Scores: score(g)}, nil
+}
+
+// ConnectedComponents groups items that are linked by any chain of connections
+func (s *BoardService) ConnectedComponents(boardID, userID uuid.UUID, query ConnectionQuery) (*ComponentsResult, error) {
+	g, err := s.loadBoardGraph(boardID, userID, query)
+	if err != nil {
+		return nil, err
+	}
+	groups := itemGroups(g.Components())
+	return &ComponentsResult{Count: len(groups), Components: groups}, nil
+}
+
+// Communities groups items into densely connected clusters (Louvain method)
+func (s *BoardService) Communities(boardID, userID uuid.UUID, query ConnectionQuery) (*CommunitiesResult, error) {
+	g, err := s.loadBoardGraph(boardID, userID, query)
+	if err != nil {
+		return nil, err
+	}
+	communities, modularity := g.Communities()
+	groups := itemGroups(communities)
+	return &CommunitiesResult{Count: len(groups), Modularity: modularity, Communities: groups}, nil
+}
+
+// IsolatedItems returns the items that have no connections
+func (s *BoardService) IsolatedItems(boardID, userID uuid.UUID, query ConnectionQuery) (*IsolatedResult, error) {
+	g, err := s.loadBoardGraph(boardID, userID, query)
+	if err != nil {
+		return nil, err
+	}
+	ids := g.Isolated()
+	if ids == nil {
+		ids = []uuid.UUID{}
+	}
+	return &IsolatedResult{Count: len(ids), ItemIDs: ids}, nil
+}
+
+func itemGroups(groups [][]uuid.UUID) []ItemGroup {
+	out := make([]ItemGroup, len(groups))
+	for i, ids := range groups {
+		out[i] = ItemGroup{Size: len(ids), ItemIDs: ids}
+	}
+	return out
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// newAnalysisTestService serves a board whose items form the chain a-b-c plus
+// an unconnected item d. Filtering on the "knows" relationship leaves only a-b.
+func newAnalysisTestService(userID uuid.UUID) (*BoardService, uuid.UUID, []uuid.UUID) {
+	boardID := uuid.New()
+	ids := []uuid.UUID{uuid.New(), uuid.New(), uuid.New(), uuid.New()}
+	items := make([]models.BoardItem, len(ids))
+	for i, id := range ids {
+		items[i] = models.BoardItem{ID: id, BoardID: boardID, Type: "post-it"}
+	}
+	connections := []models.BoardConnection{
+		{ID: uuid.New(), BoardID: boardID, FromItemID: ids[0], ToItemID: ids[1], RelationshipType: "knows"},
+		{ID: uuid.New(), BoardID: boardID, FromItemID: ids[1], ToItemID: ids[2], RelationshipType: "called"},
+	}
+
+	mockBoardRepo := new(MockBoardRepository)
+	mockItemRepo := new(MockBoardItemRepository)
+	mockConnRepo := new(MockBoardConnectionRepository)
+	mockBoardRepo.On("GetPermission", boardID, userID).Return(true, models.PermissionRead, nil)
+	mockItemRepo.On("ListByBoard", boardID).Return(items, nil)
+	mockItemRepo.On("ListClassified", boardID).Return([]models.BoardItem{}, nil)
+	mockConnRepo.On("ListByBoardFiltered", boardID, models.ConnectionFilter{}).Return(connections, nil)
+	mockConnRepo.On("ListByBoardFiltered", boardID, models.ConnectionFilter{RelationshipTypes: []string{"knows"}}).Return(connections[:1], nil)
+	svc := NewBoardService(mockBoardRepo, new(MockBoardUserRepository), mockItemRepo, mockConnRepo, nil, nil, nil, nil)
+	return svc, boardID, ids
+}
+
+func TestBoardService_ShortestPath(t *testing.T) {
+	userID := uuid.New()
+	svc, boardID, ids := newAnalysisTestService(userID)
+
+	result, err := svc.ShortestPath(boardID, userID, ids[0], ids[2], ConnectionQuery{})
+	assert.NoError(t, err)
+	assert.True(t, result.Found)
+	assert.Equal(t, 2, result.Length)
+	assert.Equal(t, []uuid.UUID{ids[0], ids[1], ids[2]}, result.Path)
+
+	result, err = svc.ShortestPath(boardID, userID, ids[0], ids[3], ConnectionQuery{})
+	assert.NoError(t, err)
+	assert.False(t, result.Found)
+	assert.Empty(t, result.Path)
+
+	_, err = svc.ShortestPath(boardID, userID, ids[0], uuid.New(), ConnectionQuery{})
+	assert.Equal(t, ErrItemNotFound, err)
+}
+
+func TestBoardService_AnalysisRelationshipFilter(t *testing.T) {
+	userID := uuid.New()
+	svc, boardID, ids := newAnalysisTestService(userID)
+	knows := ConnectionQuery{RelationshipType: []string{"Knows"}}
+
+	result, err := svc.ShortestPath(boardID, userID, ids[0], ids[2], knows)
+	assert.NoError(t, err)
+	assert.False(t, result.Found)
+
+	isolated, err := svc.IsolatedItems(boardID, userID, knows)
+	assert.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{ids[2], ids[3]}, isolated.ItemIDs)
+
+	_, err = svc.IsolatedItems(boardID, userID, ConnectionQuery{Direction: []string{"sideways"}})
+	assert.True(t, errors.Is(err, ErrInvalidInput))
+}
+
+func TestBoardService_Centrality(t *testing.T) {
+	userID := uuid.New()
+	svc, boardID, ids := newAnalysisTestService(userID)
+
+	for _, metric := range []CentralityMetric{"", CentralityDegree, CentralityBetweenness, CentralityEigenvector} {
+		result, err := svc.Centrality(boardID, userID, metric, ConnectionQuery{})
+		assert.NoError(t, err, metric)
+		assert.Len(t, result.Scores, 4)
+		assert.Equal(t, ids[1], result.Scores[0].ItemID, "middle item ranks first by %q", metric)
+	}
+
+	_, err := svc.Centrality(boardID, userID, "pagerank", ConnectionQuery{})
+	assert.True(t, errors.Is(err, ErrInvalidInput))
+}
+
+func TestBoardService_ComponentsCommunitiesIsolated(t *testing.T) {
+	userID := uuid.New()
+	svc, boardID, ids := newAnalysisTestService(userID)
+
+	components, err := svc.ConnectedComponents(boardID, userID, ConnectionQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, components.Count)
+	assert.Equal(t, ItemGroup{Size: 3, ItemIDs: ids[:3]}, components.Components[0])
+
+	communities, err := svc.Communities(boardID, userID, ConnectionQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, communities.Count, len(communities.Communities))
+
+	isolated, err := svc.IsolatedItems(boardID, userID, ConnectionQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{ids[3]}, isolated.ItemIDs)
+}
+
+func TestBoardService_AnalysisNoAccess(t *testing.T) {
+	boardID := uuid.New()
+	userID := uuid.New()
+	mockBoardRepo := new(MockBoardRepository)
+	mockBoardRepo.On("GetPermission", boardID, userID).Return(true, models.PermissionLevel(""), nil)
+	svc := NewBoardService(mockBoardRepo, new(MockBoardUserRepository), new(MockBoardItemRepository), new(MockBoardConnectionRepository), nil, nil, nil, nil)
+
+	_, err := svc.IsolatedItems(boardID, userID, ConnectionQuery{})
+	assert.Equal(t, ErrBoardNotFound, err)
+}
@@ -0,0 +1,572 @@
+package service
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"evidence-wall/boards-service/internal/itemtype"
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidArchive = errors.New("invalid board archive")
+
+// Board archive format identifiers. Bump BoardArchiveVersion whenever the
+// document layout changes incompatibly and keep importing older versions.
+const (
+	BoardArchiveFormat   = "evidence-wall.board-archive"
+	BoardArchiveVersion  = 1
+	BoardArchiveSchemaID = "https://evidence-wall.app/schemas/board-archive/v1.json"
+	MaxArchiveItems      = 10000
+	MaxArchiveConns      = 20000
+)
+
+// BoardArchiveSchema is the JSON Schema describing BoardArchive documents
+//
+//go:embed board_archive.schema.json
+var BoardArchiveSchema []byte
+
+// BoardArchive is the portable JSON representation of a board, its layers,
+// items and connections. IDs are preserved for reference but are remapped on
+// import.
+type BoardArchive struct {
+	Schema      string              `json:"$schema,omitempty"`
+	Format      string              `json:"format"`
+	Version     int                 `json:"version"`
+	ExportedAt  time.Time           `json:"exported_at"`
+	Board       ArchiveBoard        `json:"board"`
+	Layers      []ArchiveLayer      `json:"layers,omitempty"`
+	Items       []ArchiveItem       `json:"items"`
+	Connections []ArchiveConnection `json:"connections"`
+}
+
+// ArchiveBoard holds the board level fields of an archive
+type ArchiveBoard struct {
+	ID             uuid.UUID                  `json:"id"`
+	Title          string                     `json:"title"`
+	Description    string                     `json:"description"`
+	Visibility     models.BoardVisibility     `json:"visibility"`
+	CustomFields   []models.CustomField       `json:"custom_fields,omitempty"`
+	Classification models.ClassificationLevel `json:"classification,omitempty"`
+	CreatedAt      time.Time                  `json:"created_at"`
+	UpdatedAt      time.Time                  `json:"updated_at"`
+}
+
+// ArchiveLayer is a layer in an archive. Only the layers the exporting user
+// can see are exported. User restrictions keep the exported user IDs, so a
+// restricted layer stays restricted on import.
+type ArchiveLayer struct {
+	ID            uuid.UUID              `json:"id"`
+	Name          string                 `json:"name"`
+	Position      int                    `json:"position"`
+	Visible       bool                   `json:"visible"`
+	Locked        bool                   `json:"locked,omitempty"`
+	MinPermission models.PermissionLevel `json:"min_permission,omitempty"`
+	UserIDs       []uuid.UUID            `json:"user_ids,omitempty"`
+	CreatedAt     time.Time              `json:"created_at"`
+}
+
+// ArchiveItem is a board item in an archive; Style is the item's style JSON
+// (color and metadata) embedded as-is. Items above the exporting user's
+// clearance are exported redacted.
+type ArchiveItem struct {
+	ID             uuid.UUID                  `json:"id"`
+	ParentID       *uuid.UUID                 `json:"parent_id,omitempty"` // Frame containing the item
+	LayerID        *uuid.UUID                 `json:"layer_id,omitempty"`  // Layer the item is on, the base layer when unset
+	Type           string                     `json:"type"`
+	X              float64                    `json:"x"`
+	Y              float64                    `json:"y"`
+	Width          float64                    `json:"width"`
+	Height         float64                    `json:"height"`
+	Rotation       float64                    `json:"rotation"`
+	ZIndex         int                        `json:"z_index"`
+	Content        string                     `json:"content"`
+	Style          json.RawMessage            `json:"style,omitempty"`
+	Fields         json.RawMessage            `json:"fields,omitempty"`        // Structured values of the item type
+	CustomValues   json.RawMessage            `json:"custom_values,omitempty"` // Values of the board's custom fields
+	Classification models.ClassificationLevel `json:"classification,omitempty"`
+	Redacted       bool                       `json:"redacted,omitempty"`
+	CreatedAt      time.Time                  `json:"created_at"`
+	UpdatedAt      time.Time                  `json:"updated_at"`
+}
+
+// ArchiveConnection is a board connection in an archive
+type ArchiveConnection struct {
+	ID               uuid.UUID                  `json:"id"`
+	FromItemID       uuid.UUID                  `json:"from_item_id"`
+	ToItemID         uuid.UUID                  `json:"to_item_id"`
+	LayerID          *uuid.UUID                 `json:"layer_id,omitempty"`
+	Label            string                     `json:"label,omitempty"`
+	Direction        models.ConnectionDirection `json:"direction,omitempty"`
+	RelationshipType string                     `json:"relationship_type,omitempty"`
+	Confidence       models.ConfidenceLevel     `json:"confidence,omitempty"`
+	Style            json.RawMessage            `json:"style,omitempty"`
+	Redacted         bool                       `json:"redacted,omitempty"`
+	CreatedAt        time.Time                  `json:"created_at"`
+}
+
+// ImportBoardOptions overrides archive fields on import
+type ImportBoardOptions struct {
+	Title      string                 `form:"title"`
+	Visibility models.BoardVisibility `form:"visibility" binding:"omitempty,oneof=private shared public"`
+}
+
+// ExportBoard builds a portable archive of a board. Read access is sufficient.
+func (s *BoardService) ExportBoard(boardID, userID uuid.UUID) (*BoardArchive, error) {
+	board, _, _, err := s.getBoardContents(boardID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	archive := &BoardArchive{
+		Schema:     BoardArchiveSchemaID,
+		Format:     BoardArchiveFormat,
+		Version:    BoardArchiveVersion,
+		ExportedAt: time.Now().UTC(),
+		Board: ArchiveBoard{
+			ID:             board.ID,
+			Title:          board.Title,
+			Description:    board.Description,
+			Visibility:     board.Visibility,
+			CustomFields:   board.CustomFields,
+			Classification: board.Classification,
+			CreatedAt:      board.CreatedAt,
+			UpdatedAt:      board.UpdatedAt,
+		},
+		Items:       make([]ArchiveItem, 0, len(board.Items)),
+		Connections: make([]ArchiveConnection, 0, len(board.Connections)),
+	}
+
+	for _, layer := range board.Layers {
+		archive.Layers = append(archive.Layers, ArchiveLayer{
+			ID:            layer.ID,
+			Name:          layer.Name,
+			Position:      layer.Position,
+			Visible:       layer.Visible,
+			Locked:        layer.Locked,
+			MinPermission: layer.MinPermission,
+			UserIDs:       layer.UserIDs,
+			CreatedAt:     layer.CreatedAt,
+		})
+	}
+	for _, item := range board.Items {
+		archive.Items = append(archive.Items, ArchiveItem{
+			ID:             item.ID,
+			ParentID:       item.ParentID,
+			LayerID:        item.LayerID,
+			Type:           item.Type,
+			X:              item.X,
+			Y:              item.Y,
+			Width:          item.Width,
+			Height:         item.Height,
+			Rotation:       item.Rotation,
+			ZIndex:         item.ZIndex,
+			Content:        item.Content,
+			Style:          rawJSON([]byte(item.Style)),
+			Fields:         rawJSON(item.Fields),
+			CustomValues:   rawJSON(item.CustomValues),
+			Classification: item.Classification,
+			Redacted:       item.Redacted,
+			CreatedAt:      item.CreatedAt,
+			UpdatedAt:      item.UpdatedAt,
+		})
+	}
+	for _, conn := range board.Connections {
+		archive.Connections = append(archive.Connections, ArchiveConnection{
+			ID:               conn.ID,
+			FromItemID:       conn.FromItemID,
+			ToItemID:         conn.ToItemID,
+			LayerID:          conn.LayerID,
+			Label:            conn.Label,
+			Direction:        conn.Direction,
+			RelationshipType: conn.RelationshipType,
+			Confidence:       conn.Confidence,
+			Style:            rawJSON([]byte(conn.Style)),
+			Redacted:         conn.Redacted,
+			CreatedAt:        conn.CreatedAt,
+		})
+	}
+
+	return archive, nil
+}
+
+// ImportBoard validates an archive and recreates it as a new board owned by
+// the caller, with fresh IDs for the board, its layers, items and
+// connections. The board is created in one database transaction, so a
+// failure part way leaves no half-imported board behind.
+func (s *BoardService) ImportBoard(userID uuid.UUID, archive *BoardArchive, opts ImportBoardOptions) (*models.Board, error) {
+	if err := validateArchive(archive); err != nil {
+		return nil, err
+	}
+
+	title, err := importText(archive.Board.Title, MaxTitleLength, "title")
+	if err != nil {
+		return nil, err
+	}
+	if opts.Title != "" {
+		if title, err = validateTitle(opts.Title); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+		}
+	}
+	if title == "" {
+		return nil, fmt.Errorf("%w: board title is empty", ErrInvalidArchive)
+	}
+	description, err := importText(archive.Board.Description, MaxDescriptionLength, "description")
+	if err != nil {
+		return nil, err
+	}
+
+	customFields, err := normalizeCustomFields(archive.Board.CustomFields, importText)
+	if err != nil {
+		return nil, archiveError(err)
+	}
+
+	visibility := archive.Board.Visibility
+	if visibility == "" {
+		visibility = models.VisibilityPrivate
+	}
+	if opts.Visibility != "" {
+		visibility = opts.Visibility
+	}
+
+	layers := make([]models.Layer, 0, len(archive.Layers))
+	for i, al := range archive.Layers {
+		name, err := importText(al.Name, MaxLayerNameLength, fmt.Sprintf("layer %d name", i))
+		if err != nil {
+			return nil, err
+		}
+		if name == "" {
+			return nil, fmt.Errorf("%w: layer %d has no name", ErrInvalidArchive, i)
+		}
+		layers = append(layers, models.Layer{
+			ID:            al.ID,
+			Name:          name,
+			Position:      al.Position,
+			Visible:       al.Visible,
+			Locked:        al.Locked,
+			MinPermission: al.MinPermission,
+			UserIDs:       al.UserIDs,
+		})
+	}
+
+	items := make([]models.BoardItem, 0, len(archive.Items))
+	for i, ai := range archive.Items {
+		content, err := importText(ai.Content, MaxContentLength, fmt.Sprintf("item %d content", i))
+		if err != nil {
+			return nil, err
+		}
+		fields, err := importFields(ai.Fields)
+		if err != nil {
+			return nil, err
+		}
+		customValues, err := importFields(ai.CustomValues)
+		if err != nil {
+			return nil, err
+		}
+		if !ai.Classification.Valid() {
+			return nil, fmt.Errorf("%w: item %d has an unknown classification", ErrInvalidArchive, i)
+		}
+		items = append(items, models.BoardItem{
+			ID:             ai.ID,
+			ParentID:       ai.ParentID,
+			LayerID:        ai.LayerID,
+			Type:           ai.Type,
+			X:              ai.X,
+			Y:              ai.Y,
+			Width:          ai.Width,
+			Height:         ai.Height,
+			Rotation:       ai.Rotation,
+			ZIndex:         ai.ZIndex,
+			Content:        content,
+			Style:          []byte(ai.Style),
+			Fields:         fields,
+			CustomValues:   customValues,
+			Classification: ai.Classification,
+		})
+	}
+	connections := make([]models.BoardConnection, 0, len(archive.Connections))
+	for i, ac := range archive.Connections {
+		label, err := importText(ac.Label, MaxLabelLength, fmt.Sprintf("connection %d label", i))
+		if err != nil {
+			return nil, err
+		}
+		direction, _ := validateDirection(ac.Direction)
+		relType, _ := normalizeRelationshipType(ac.RelationshipType)
+		connections = append(connections, models.BoardConnection{
+			FromItemID:       ac.FromItemID,
+			ToItemID:         ac.ToItemID,
+			LayerID:          ac.LayerID,
+			Label:            label,
+			Direction:        direction,
+			RelationshipType: relType,
+			Confidence:       ac.Confidence,
+			Style:            string(ac.Style),
+		})
+	}
+
+	if !archive.Board.Classification.Valid() {
+		return nil, fmt.Errorf("%w: the board has an unknown classification", ErrInvalidArchive)
+	}
+	board := &models.Board{
+		Title:          title,
+		Description:    description,
+		Visibility:     visibility,
+		OwnerID:        userID,
+		CustomFields:   customFields,
+		Classification: archive.Board.Classification,
+	}
+	err = s.inContentTransaction(func(repos ContentRepositories) error {
+		if err := repos.Boards.Create(board); err != nil {
+			return fmt.Errorf("failed to create board: %w", err)
+		}
+
+		// Fatal, as in DuplicateBoard
+		if err := repos.BoardUsers.Create(&models.BoardUser{
+			BoardID:    board.ID,
+			UserID:     userID,
+			Permission: models.PermissionAdmin,
+		}); err != nil {
+			return fmt.Errorf("failed to add owner: %w", err)
+		}
+
+		layerIDs, err := copyLayers(repos, board.ID, userID, layers)
+		if err != nil {
+			return err
+		}
+		_, err = copyBoardContents(repos, board.ID, userID, items, connections, layerIDs)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return board, nil
+}
+
+// validateArchive checks an archive's header and referential integrity
+func validateArchive(archive *BoardArchive) error {
+	if archive == nil {
+		return ErrInvalidArchive
+	}
+	if archive.Format != BoardArchiveFormat {
+		return fmt.Errorf("%w: unknown format %q", ErrInvalidArchive, archive.Format)
+	}
+	if archive.Version < 1 || archive.Version > BoardArchiveVersion {
+		return fmt.Errorf("%w: unsupported version %d", ErrInvalidArchive, archive.Version)
+	}
+	switch archive.Board.Visibility {
+	case "", models.VisibilityPrivate, models.VisibilityShared, models.VisibilityPublic:
+	default:
+		return fmt.Errorf("%w: invalid visibility %q", ErrInvalidArchive, archive.Board.Visibility)
+	}
+	if len(archive.Items) > MaxArchiveItems || len(archive.Connections) > MaxArchiveConns {
+		return fmt.Errorf("%w: too many items or connections", ErrInvalidArchive)
+	}
+	if len(archive.Layers) > MaxLayersPerBoard {
+		return fmt.Errorf("%w: too many layers", ErrInvalidArchive)
+	}
+
+	layers := make(map[uuid.UUID]bool, len(archive.Layers))
+	for i, layer := range archive.Layers {
+		if layer.ID == uuid.Nil || layers[layer.ID] {
+			return fmt.Errorf("%w: layer %d has a missing or duplicate id", ErrInvalidArchive, i)
+		}
+		layers[layer.ID] = true
+		if _, ok := permissionRank[layer.MinPermission]; layer.MinPermission != "" && !ok {
+			return fmt.Errorf("%w: layer %d has invalid min_permission %q", ErrInvalidArchive, i, layer.MinPermission)
+		}
+	}
+
+	customFields := make(map[string]models.CustomField, len(archive.Board.CustomFields))
+	for _, field := range archive.Board.CustomFields {
+		customFields[field.Key] = field
+	}
+
+	ids := make(map[uuid.UUID]bool, len(archive.Items))
+	for i, item := range archive.Items {
+		if item.ID == uuid.Nil || ids[item.ID] {
+			return fmt.Errorf("%w: item %d has a missing or duplicate id", ErrInvalidArchive, i)
+		}
+		ids[item.ID] = true
+		if item.LayerID != nil && !layers[*item.LayerID] {
+			return fmt.Errorf("%w: item %d is on an unknown layer", ErrInvalidArchive, i)
+		}
+		t, ok := itemtype.Lookup(item.Type)
+		if !ok {
+			return fmt.Errorf("%w: item %d has unknown type %q", ErrInvalidArchive, i, item.Type)
+		}
+		if len(item.Fields) > 0 && !isJSONObject(item.Fields) {
+			return fmt.Errorf("%w: item %d fields are not a JSON object", ErrInvalidArchive, i)
+		}
+		if err := t.Validate(decodeFields(item.Fields)); err != nil {
+			return fmt.Errorf("%w: item %d fields.%v", ErrInvalidArchive, i, err)
+		}
+		if len(item.CustomValues) > 0 && !isJSONObject(item.CustomValues) {
+			return fmt.Errorf("%w: item %d custom values are not a JSON object", ErrInvalidArchive, i)
+		}
+		for key, value := range decodeFields(item.CustomValues) {
+			field, ok := customFields[key]
+			if !ok || !field.AppliesTo(item.Type) {
+				return fmt.Errorf("%w: item %d has a value for unknown custom field %q", ErrInvalidArchive, i, key)
+			}
+			if !archivedCustomValue(field, value) {
+				return fmt.Errorf("%w: item %d has an invalid value for custom field %q", ErrInvalidArchive, i, key)
+			}
+		}
+		if item.Width < 10 || item.Height < 10 {
+			return fmt.Errorf("%w: item %d is smaller than 10x10", ErrInvalidArchive, i)
+		}
+		if len(item.Style) > 0 && !isJSONObject(item.Style) {
+			return fmt.Errorf("%w: item %d style is not a JSON object", ErrInvalidArchive, i)
+		}
+	}
+	if err := validateArchiveFrames(archive.Items); err != nil {
+		return err
+	}
+	for i, conn := range archive.Connections {
+		if !ids[conn.FromItemID] || !ids[conn.ToItemID] {
+			return fmt.Errorf("%w: connection %d references an unknown item", ErrInvalidArchive, i)
+		}
+		if conn.LayerID != nil && !layers[*conn.LayerID] {
+			return fmt.Errorf("%w: connection %d is on an unknown layer", ErrInvalidArchive, i)
+		}
+		if conn.FromItemID == conn.ToItemID {
+			return fmt.Errorf("%w: connection %d connects an item to itself", ErrInvalidArchive, i)
+		}
+		if len(conn.Style) > 0 && !isJSONObject(conn.Style) {
+			return fmt.Errorf("%w: connection %d style is not a JSON object", ErrInvalidArchive, i)
+		}
+		if _, err := validateDirection(conn.Direction); err != nil {
+			return fmt.Errorf("%w: connection %d has invalid direction %q", ErrInvalidArchive, i, conn.Direction)
+		}
+		if _, err := normalizeRelationshipType(conn.RelationshipType); err != nil {
+			return fmt.Errorf("%w: connection %d has invalid relationship type %q", ErrInvalidArchive, i, conn.RelationshipType)
+		}
+		if err := validateConfidence(conn.Confidence); err != nil {
+			return fmt.Errorf("%w: connection %d has invalid confidence %q", ErrInvalidArchive, i, conn.Confidence)
+		}
+	}
+	return nil
+}
+
+// validateArchiveFrames checks that items are only placed in frames of the
+// archive, nested no deeper than frames on a board can be
+func validateArchiveFrames(items []ArchiveItem) error {
+	parents := make(map[uuid.UUID]*uuid.UUID, len(items))
+	frames := make(map[uuid.UUID]bool)
+	for _, item := range items {
+		parents[item.ID] = item.ParentID
+		if item.Type == string(models.ItemTypeFrame) {
+			frames[item.ID] = true
+		}
+	}
+	for i, item := range items {
+		// Frames count themselves towards the depth
+		depth := 0
+		if frames[item.ID] {
+			depth = 1
+		}
+		for parentID := item.ParentID; parentID != nil; parentID = parents[*parentID] {
+			if !frames[*parentID] {
+				return fmt.Errorf("%w: item %d is in an unknown frame", ErrInvalidArchive, i)
+			}
+			// A cycle never reaches the board, so it ends up too deep as well
+			if depth++; depth > MaxFrameDepth {
+				return fmt.Errorf("%w: item %d is nested in frames more than %d deep", ErrInvalidArchive, i, MaxFrameDepth)
+			}
+		}
+	}
+	return nil
+}
+
+// archivedCustomValue checks the type of an archived custom field value.
+// User references are not checked, as the users may not exist here.
+func archivedCustomValue(field models.CustomField, value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	if field.Type == models.CustomFieldNumber {
+		_, ok := value.(float64)
+		return ok
+	}
+	str, ok := value.(string)
+	if !ok {
+		return false
+	}
+	switch field.Type {
+	case models.CustomFieldDate:
+		_, err := time.Parse("2006-01-02", str)
+		return err == nil
+	case models.CustomFieldEnum:
+		for _, option := range field.Options {
+			if option == str {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// archiveError marks a validation error as an archive error
+func archiveError(err error) error {
+	if errors.Is(err, ErrInvalidArchive) {
+		return err
+	}
+	return fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+}
+
+// importFields applies the importText rules to every text field value
+func importFields(data json.RawMessage) (json.RawMessage, error) {
+	fields := decodeFields(data)
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	for key, value := range fields {
+		text, ok := value.(string)
+		if !ok {
+			continue
+		}
+		imported, err := importText(text, len(text), "field "+key)
+		if err != nil {
+			return nil, err
+		}
+		fields[key] = imported
+	}
+	out, err := json.Marshal(fields)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	return out, nil
+}
+
+// importText accepts archived text that is already in the escaped form the
+// service stores (no markup characters) verbatim, so a round trip is lossless,
+// and sanitizes anything else like regular user input
+func importText(text string, maxLength int, fieldName string) (string, error) {
+	if len(text) > maxLength {
+		return "", fmt.Errorf("%w: %s is too long (max %d characters)", ErrInvalidArchive, fieldName, maxLength)
+	}
+	if !strings.ContainsAny(text, "<>\"'") {
+		return strings.TrimSpace(text), nil
+	}
+	return validateAndSanitizeString(text, maxLength, fieldName)
+}
+
+// rawJSON returns data as embeddable JSON, or nil when it isn't valid JSON
+func rawJSON(data []byte) json.RawMessage {
+	if len(data) == 0 || !json.Valid(data) {
+		return nil
+	}
+	return json.RawMessage(data)
+}
+
+func isJSONObject(data []byte) bool {
+	var obj map[string]interface{}
+	return json.Unmarshal(data, &obj) == nil && obj != nil
+}
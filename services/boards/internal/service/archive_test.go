@@ -0,0 +1,331 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestBoardService_ExportImportRoundTrip(t *testing.T) {
+	ownerID := uuid.New()
+	importerID := uuid.New()
+	boardID := uuid.New()
+	suspectID := uuid.New()
+	noteID := uuid.New()
+
+	source := &models.Board{
+		ID:          boardID,
+		Title:       "Tom &amp; Jerry",
+		Description: "Cat &lt;-&gt; mouse",
+		Visibility:  models.VisibilityShared,
+		OwnerID:     ownerID,
+		CustomFields: []models.CustomField{
+			{Key: "case_number", Label: "Case &quot;number&quot;", Type: models.CustomFieldText},
+			{Key: "reliability", Label: "Reliability", Type: models.CustomFieldEnum, Options: []string{"A", "B &amp; C"}, ItemTypes: []string{"suspect-card"}},
+		},
+		Items: []models.BoardItem{
+			{ID: suspectID, BoardID: boardID, Type: "suspect-card", X: 10, Y: 20, Width: 280, Height: 400, Rotation: -2.5, ZIndex: 3,
+				Content:      "Name: Tom\nAlias: &quot;The Cat&quot;",
+				Style:        []byte(`{"color":"#f5f5f5","metadata":{"variant":"suspect-card","alias":"The Cat"}}`),
+				Fields:       []byte(`{"name":"Tom","alias":"&quot;The Cat&quot;","dob":"1940-02-10"}`),
+				CustomValues: []byte(`{"case_number":"1940/1","reliability":"B &amp; C"}`)},
+			{ID: noteID, BoardID: boardID, Type: "post-it", X: 400, Y: 20, Width: 200, Height: 200, ZIndex: 1,
+				Content: "Seen at 5 &amp; 6",
+				Style:   []byte(`{"color":"#ffeb3b","metadata":{"variant":"post-it"}}`)},
+		},
+		Connections: []models.BoardConnection{
+			{ID: uuid.New(), BoardID: boardID, FromItemID: suspectID, ToItemID: noteID, Label: "Seen &amp; heard",
+				Direction: models.DirectionForward, RelationshipType: "was at", Confidence: models.ConfidenceMedium, Style: `{"color":"#ff0000"}`},
+		},
+	}
+
+	mockBoardRepo := new(MockBoardRepository)
+	mockBoardUserRepo := new(MockBoardUserRepository)
+	mockBoardItemRepo := new(MockBoardItemRepository)
+	mockConnectionRepo := new(MockBoardConnectionRepository)
+	svc := NewBoardService(mockBoardRepo, mockBoardUserRepo, mockBoardItemRepo, mockConnectionRepo, nil, nil, nil, nil)
+
+	mockBoardRepo.On("GetByIDWithContents", boardID, ownerID).Return(source, models.PermissionAdmin, nil)
+
+	archive, err := svc.ExportBoard(boardID, ownerID)
+	assert.NoError(t, err)
+
+	// The archive survives serialization unchanged
+	data, err := json.Marshal(archive)
+	assert.NoError(t, err)
+	var decoded BoardArchive
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, BoardArchiveFormat, decoded.Format)
+	assert.Equal(t, BoardArchiveVersion, decoded.Version)
+
+	var created *models.Board
+	mockBoardRepo.On("Create", mock.AnythingOfType("*models.Board")).Run(func(args mock.Arguments) {
+		created = args.Get(0).(*models.Board)
+		created.ID = uuid.New()
+	}).Return(nil)
+	mockBoardUserRepo.On("Create", mock.AnythingOfType("*models.BoardUser")).Return(nil)
+	var items []*models.BoardItem
+	mockBoardItemRepo.On("Create", mock.AnythingOfType("*models.BoardItem")).Run(func(args mock.Arguments) {
+		items = append(items, args.Get(0).(*models.BoardItem))
+	}).Return(nil)
+	var conns []*models.BoardConnection
+	mockConnectionRepo.On("Create", mock.AnythingOfType("*models.BoardConnection")).Run(func(args mock.Arguments) {
+		conns = append(conns, args.Get(0).(*models.BoardConnection))
+	}).Return(nil)
+
+	board, err := svc.ImportBoard(importerID, &decoded, ImportBoardOptions{})
+	assert.NoError(t, err)
+	assert.NotEqual(t, boardID, board.ID)
+	assert.Equal(t, importerID, created.OwnerID)
+	assert.Equal(t, source.Title, created.Title)
+	assert.Equal(t, source.Description, created.Description)
+	assert.Equal(t, source.Visibility, created.Visibility)
+	assert.Equal(t, source.CustomFields, created.CustomFields)
+
+	if assert.Len(t, items, 2) && assert.Len(t, conns, 1) {
+		for i, item := range items {
+			src := source.Items[i]
+			assert.NotEqual(t, src.ID, item.ID)
+			assert.Equal(t, board.ID, item.BoardID)
+			assert.Equal(t, importerID, item.CreatedBy)
+			assert.Equal(t, src.Type, item.Type)
+			assert.Equal(t, src.X, item.X)
+			assert.Equal(t, src.Y, item.Y)
+			assert.Equal(t, src.Width, item.Width)
+			assert.Equal(t, src.Height, item.Height)
+			assert.Equal(t, src.Rotation, item.Rotation)
+			assert.Equal(t, src.ZIndex, item.ZIndex)
+			assert.Equal(t, src.Content, item.Content)
+			assert.JSONEq(t, string(src.Style), string(item.Style))
+		}
+		assert.JSONEq(t, string(source.Items[0].Fields), string(items[0].Fields))
+		assert.Nil(t, items[1].Fields)
+		assert.JSONEq(t, string(source.Items[0].CustomValues), string(items[0].CustomValues))
+		assert.Equal(t, items[0].ID, conns[0].FromItemID)
+		assert.Equal(t, items[1].ID, conns[0].ToItemID)
+		assert.JSONEq(t, source.Connections[0].Style, conns[0].Style)
+		assert.Equal(t, "Seen &amp; heard", conns[0].Label)
+		assert.Equal(t, models.DirectionForward, conns[0].Direction)
+		assert.Equal(t, "was at", conns[0].RelationshipType)
+		assert.Equal(t, models.ConfidenceMedium, conns[0].Confidence)
+	}
+}
+
+func TestBoardService_ExportImportFrames(t *testing.T) {
+	userID := uuid.New()
+	boardID := uuid.New()
+	frameID := uuid.New()
+	source := &models.Board{
+		ID: boardID, Title: "Case 42",
+		Items: []models.BoardItem{
+			{ID: uuid.New(), BoardID: boardID, Type: "post-it", Width: 200, Height: 200, ParentID: &frameID},
+			{ID: frameID, BoardID: boardID, Type: "frame", Width: 800, Height: 600},
+		},
+	}
+
+	mockBoardRepo := new(MockBoardRepository)
+	mockBoardUserRepo := new(MockBoardUserRepository)
+	mockBoardItemRepo := new(MockBoardItemRepository)
+	svc := NewBoardService(mockBoardRepo, mockBoardUserRepo, mockBoardItemRepo, new(MockBoardConnectionRepository), nil, nil, nil, nil)
+	mockBoardRepo.On("GetByIDWithContents", boardID, userID).Return(source, models.PermissionRead, nil)
+
+	archive, err := svc.ExportBoard(boardID, userID)
+	assert.NoError(t, err)
+	data, err := json.Marshal(archive)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"parent_id":"`+frameID.String()+`"`)
+
+	var decoded BoardArchive
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	mockBoardRepo.On("Create", mock.AnythingOfType("*models.Board")).Return(nil)
+	mockBoardUserRepo.On("Create", mock.AnythingOfType("*models.BoardUser")).Return(nil)
+	var items []*models.BoardItem
+	mockBoardItemRepo.On("Create", mock.AnythingOfType("*models.BoardItem")).Run(func(args mock.Arguments) {
+		items = append(items, args.Get(0).(*models.BoardItem))
+	}).Return(nil)
+	mockBoardItemRepo.On("SetParent", mock.Anything, mock.Anything).Return(nil)
+
+	_, err = svc.ImportBoard(userID, &decoded, ImportBoardOptions{})
+	assert.NoError(t, err)
+	if assert.Len(t, items, 2) {
+		mockBoardItemRepo.AssertCalled(t, "SetParent", []uuid.UUID{items[0].ID}, &items[1].ID)
+	}
+}
+
+func TestBoardService_ExportImportLayers(t *testing.T) {
+	userID := uuid.New()
+	informant := uuid.New()
+	boardID := uuid.New()
+	suspectID := uuid.New()
+	noteID := uuid.New()
+	secret := models.Layer{ID: uuid.New(), BoardID: boardID, Name: "Informants", Position: 1, Visible: true, Locked: true, UserIDs: []uuid.UUID{userID, informant}}
+	source := &models.Board{
+		ID: boardID, Title: "Case 42",
+		Items: []models.BoardItem{
+			{ID: suspectID, BoardID: boardID, Type: "suspect-card", Width: 280, Height: 400, LayerID: &secret.ID},
+			{ID: noteID, BoardID: boardID, Type: "post-it", Width: 200, Height: 200},
+		},
+		Connections: []models.BoardConnection{
+			{ID: uuid.New(), BoardID: boardID, FromItemID: suspectID, ToItemID: noteID, LayerID: &secret.ID},
+		},
+	}
+
+	mockBoardRepo := new(MockBoardRepository)
+	mockLayerRepo := new(MockLayerRepository)
+	svc := NewBoardService(mockBoardRepo, new(MockBoardUserRepository), new(MockBoardItemRepository), new(MockBoardConnectionRepository), nil, nil, mockLayerRepo, nil)
+	mockBoardRepo.On("GetByIDWithContents", boardID, userID).Return(source, models.PermissionAdmin, nil)
+	mockLayerRepo.On("ListByBoard", boardID).Return([]models.Layer{secret}, nil)
+
+	archive, err := svc.ExportBoard(boardID, userID)
+	assert.NoError(t, err)
+	data, err := json.Marshal(archive)
+	assert.NoError(t, err)
+	var decoded BoardArchive
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+
+	// The import is created through the transaction's repositories
+	txBoardRepo := new(MockBoardRepository)
+	txBoardUserRepo := new(MockBoardUserRepository)
+	txItemRepo := new(MockBoardItemRepository)
+	txConnectionRepo := new(MockBoardConnectionRepository)
+	txLayerRepo := new(MockLayerRepository)
+	txBoardRepo.On("Create", mock.AnythingOfType("*models.Board")).Return(nil)
+	txBoardUserRepo.On("Create", mock.AnythingOfType("*models.BoardUser")).Return(nil)
+	var layers []*models.Layer
+	txLayerRepo.On("Create", mock.AnythingOfType("*models.Layer")).Run(func(args mock.Arguments) {
+		layer := args.Get(0).(*models.Layer)
+		layer.ID = uuid.New()
+		layers = append(layers, layer)
+	}).Return(nil)
+	var items []*models.BoardItem
+	txItemRepo.On("Create", mock.AnythingOfType("*models.BoardItem")).Run(func(args mock.Arguments) {
+		items = append(items, args.Get(0).(*models.BoardItem))
+	}).Return(nil)
+	txConnectionRepo.On("Create", mock.AnythingOfType("*models.BoardConnection")).Return(errors.New("connection reset")).Once()
+	var conns []*models.BoardConnection
+	txConnectionRepo.On("Create", mock.AnythingOfType("*models.BoardConnection")).Run(func(args mock.Arguments) {
+		conns = append(conns, args.Get(0).(*models.BoardConnection))
+	}).Return(nil)
+	transactions := 0
+	svc.SetContentTransaction(func(fn func(repos ContentRepositories) error) error {
+		transactions++
+		return fn(ContentRepositories{Boards: txBoardRepo, BoardUsers: txBoardUserRepo, Items: txItemRepo, Connections: txConnectionRepo, Layers: txLayerRepo})
+	})
+
+	// A failure part way fails the whole import, to be rolled back
+	_, err = svc.ImportBoard(userID, &decoded, ImportBoardOptions{})
+	assert.ErrorContains(t, err, "connection reset")
+
+	layers, items = nil, nil
+	_, err = svc.ImportBoard(userID, &decoded, ImportBoardOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, transactions)
+	mockBoardRepo.AssertNotCalled(t, "Create", mock.Anything)
+	mockLayerRepo.AssertNotCalled(t, "Create", mock.Anything)
+	if assert.Len(t, layers, 1) && assert.Len(t, items, 2) && assert.Len(t, conns, 1) {
+		assert.Equal(t, "Informants", layers[0].Name)
+		assert.Equal(t, 1, layers[0].Position)
+		assert.True(t, layers[0].Locked)
+		assert.Equal(t, []uuid.UUID{userID, informant}, layers[0].UserIDs, "the layer stays restricted")
+		if assert.NotNil(t, items[0].LayerID) {
+			assert.Equal(t, layers[0].ID, *items[0].LayerID)
+		}
+		assert.Nil(t, items[1].LayerID)
+		if assert.NotNil(t, conns[0].LayerID) {
+			assert.Equal(t, layers[0].ID, *conns[0].LayerID)
+		}
+	}
+}
+
+func TestBoardService_ExportBoardNotFound(t *testing.T) {
+	boardID := uuid.New()
+	userID := uuid.New()
+
+	mockBoardRepo := new(MockBoardRepository)
+	svc := NewBoardService(mockBoardRepo, new(MockBoardUserRepository), new(MockBoardItemRepository), new(MockBoardConnectionRepository), nil, nil, nil, nil)
+	mockBoardRepo.On("GetByIDWithContents", boardID, userID).Return(&models.Board{ID: boardID}, models.PermissionLevel(""), nil)
+
+	archive, err := svc.ExportBoard(boardID, userID)
+	assert.Equal(t, ErrBoardNotFound, err)
+	assert.Nil(t, archive)
+}
+
+func TestBoardService_ImportBoardValidation(t *testing.T) {
+	itemA := uuid.New()
+	itemB := uuid.New()
+	valid := func() *BoardArchive {
+		return &BoardArchive{
+			Format:  BoardArchiveFormat,
+			Version: BoardArchiveVersion,
+			Board:   ArchiveBoard{Title: "Imported", Visibility: models.VisibilityPrivate},
+			Items: []ArchiveItem{
+				{ID: itemA, Type: "post-it", Width: 200, Height: 200},
+				{ID: itemB, Type: "suspect-card", Width: 280, Height: 400},
+			},
+			Connections: []ArchiveConnection{{FromItemID: itemA, ToItemID: itemB}},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(a *BoardArchive)
+	}{
+		{name: "wrong format", modify: func(a *BoardArchive) { a.Format = "something-else" }},
+		{name: "future version", modify: func(a *BoardArchive) { a.Version = BoardArchiveVersion + 1 }},
+		{name: "empty title", modify: func(a *BoardArchive) { a.Board.Title = " " }},
+		{name: "bad visibility", modify: func(a *BoardArchive) { a.Board.Visibility = "everyone" }},
+		{name: "unknown item type", modify: func(a *BoardArchive) { a.Items[0].Type = "photo" }},
+		{name: "duplicate item id", modify: func(a *BoardArchive) { a.Items[1].ID = itemA }},
+		{name: "tiny item", modify: func(a *BoardArchive) { a.Items[0].Width = 1 }},
+		{name: "fields not an object", modify: func(a *BoardArchive) { a.Items[1].Fields = json.RawMessage(`["Tom"]`) }},
+		{name: "invalid field value", modify: func(a *BoardArchive) { a.Items[1].Fields = json.RawMessage(`{"dob":"last year"}`) }},
+		{name: "invalid custom field", modify: func(a *BoardArchive) {
+			a.Board.CustomFields = []models.CustomField{{Key: "Case", Label: "Case", Type: models.CustomFieldText}}
+		}},
+		{name: "value for unknown custom field", modify: func(a *BoardArchive) { a.Items[0].CustomValues = json.RawMessage(`{"case":"1"}`) }},
+		{name: "invalid custom value", modify: func(a *BoardArchive) {
+			a.Board.CustomFields = []models.CustomField{{Key: "amount", Label: "Amount", Type: models.CustomFieldNumber}}
+			a.Items[0].CustomValues = json.RawMessage(`{"amount":"lots"}`)
+		}},
+		{name: "style not an object", modify: func(a *BoardArchive) { a.Items[0].Style = json.RawMessage(`"red"`) }},
+		{name: "dangling connection", modify: func(a *BoardArchive) { a.Connections[0].ToItemID = uuid.New() }},
+		{name: "self connection", modify: func(a *BoardArchive) { a.Connections[0].ToItemID = itemA }},
+		{name: "bad direction", modify: func(a *BoardArchive) { a.Connections[0].Direction = "up" }},
+		{name: "bad confidence", modify: func(a *BoardArchive) { a.Connections[0].Confidence = "sure" }},
+		{name: "unknown frame", modify: func(a *BoardArchive) { a.Items[0].ParentID = &itemB }},
+		{name: "duplicate layer id", modify: func(a *BoardArchive) {
+			layerID := uuid.New()
+			a.Layers = []ArchiveLayer{{ID: layerID, Name: "A"}, {ID: layerID, Name: "B"}}
+		}},
+		{name: "unnamed layer", modify: func(a *BoardArchive) { a.Layers = []ArchiveLayer{{ID: uuid.New(), Name: " "}} }},
+		{name: "bad layer permission", modify: func(a *BoardArchive) {
+			a.Layers = []ArchiveLayer{{ID: uuid.New(), Name: "A", MinPermission: "owner"}}
+		}},
+		{name: "item on unknown layer", modify: func(a *BoardArchive) { layerID := uuid.New(); a.Items[0].LayerID = &layerID }},
+		{name: "connection on unknown layer", modify: func(a *BoardArchive) { layerID := uuid.New(); a.Connections[0].LayerID = &layerID }},
+		{name: "frame cycle", modify: func(a *BoardArchive) {
+			a.Items[0].Type, a.Items[1].Type = "frame", "frame"
+			a.Items[0].ParentID, a.Items[1].ParentID = &itemB, &itemA
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockBoardRepo := new(MockBoardRepository)
+			svc := NewBoardService(mockBoardRepo, new(MockBoardUserRepository), new(MockBoardItemRepository), new(MockBoardConnectionRepository), nil, nil, nil, nil)
+
+			archive := valid()
+			tt.modify(archive)
+			board, err := svc.ImportBoard(uuid.New(), archive, ImportBoardOptions{})
+			assert.True(t, errors.Is(err, ErrInvalidArchive), "unexpected error: %v", err)
+			assert.Nil(t, board)
+			mockBoardRepo.AssertNotCalled(t, "Create", mock.Anything)
+		})
+	}
+}
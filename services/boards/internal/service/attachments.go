@@ -0,0 +1,376 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"unicode"
+
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrAttachmentNotFound   = errors.New("attachment not found")
+	ErrAttachmentTooLarge   = errors.New("attachment too large")
+	ErrQuotaExceeded        = errors.New("board attachment quota exceeded")
+	ErrUnsupportedMediaType = errors.New("unsupported attachment type")
+)
+
+// Attachment limits used when the configuration leaves them unset
+const (
+	DefaultMaxAttachmentSize    int64 = 25 << 20
+	DefaultBoardAttachmentQuota int64 = 500 << 20
+)
+
+// sniffLength is how much of an upload http.DetectContentType looks at
+const sniffLength = 512
+
+// allowedAttachmentTypes lists the sniffed media types accepted as evidence
+var allowedAttachmentTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"image/bmp":       true,
+	"application/pdf": true,
+	"text/plain":      true,
+	"video/mp4":       true,
+	"video/webm":      true,
+	"audio/mpeg":      true,
+	"audio/wave":      true,
+}
+
+// AttachmentLimits bounds the size of single uploads and the total size of a
+// board's attachments
+type AttachmentLimits struct {
+	MaxSize    int64
+	BoardQuota int64
+}
+
+// AttachmentService handles files attached to board items
+type AttachmentService struct {
+	permissions    *PermissionResolver
+	boardItemRepo  BoardItemRepositoryInterface
+	attachmentRepo AttachmentRepositoryInterface
+	custodyRepo    CustodyRepositoryInterface
+	store          BlobStore
+	previews       PreviewQueue
+	limits         AttachmentLimits
+}
+
+// NewAttachmentService creates a new attachment service; zero limits fall
+// back to the defaults. Without a preview queue, photos stay pending until
+// a worker sweeps them up. Pass the board service's permission resolver so
+// sharing changes apply to attachments at once; without one the service
+// resolves permissions on its own.
+func NewAttachmentService(
+	boardRepo BoardRepositoryInterface,
+	permissions *PermissionResolver,
+	boardItemRepo BoardItemRepositoryInterface,
+	attachmentRepo AttachmentRepositoryInterface,
+	custodyRepo CustodyRepositoryInterface,
+	store BlobStore,
+	previews PreviewQueue,
+	limits AttachmentLimits,
+) *AttachmentService {
+	if limits.MaxSize <= 0 {
+		limits.MaxSize = DefaultMaxAttachmentSize
+	}
+	if limits.BoardQuota <= 0 {
+		limits.BoardQuota = DefaultBoardAttachmentQuota
+	}
+	if permissions == nil {
+		permissions = NewPermissionResolver(boardRepo, nil)
+	}
+	return &AttachmentService{
+		permissions:    permissions,
+		boardItemRepo:  boardItemRepo,
+		attachmentRepo: attachmentRepo,
+		custodyRepo:    custodyRepo,
+		store:          store,
+		previews:       previews,
+		limits:         limits,
+	}
+}
+
+// Limits returns the effective attachment limits
+func (s *AttachmentService) Limits() AttachmentLimits {
+	return s.limits
+}
+
+// UploadAttachmentRequest carries an uploaded file. Size is the declared
+// length of Content.
+type UploadAttachmentRequest struct {
+	Filename string
+	Size     int64
+	Content  io.Reader
+	ClientIP string
+}
+
+// AttachmentAccess describes how an attachment is read, for the custody log
+type AttachmentAccess struct {
+	Inline   bool // Displayed in the browser rather than downloaded
+	ClientIP string
+}
+
+// UploadAttachment stores a file, hashes it on the way in and attaches it to
+// an item. The upload opens the file's chain of custody.
+func (s *AttachmentService) UploadAttachment(ctx context.Context, boardID, itemID, userID uuid.UUID, req UploadAttachmentRequest) (*models.Attachment, error) {
+	if _, err := s.checkItem(boardID, itemID, userID, true); err != nil {
+		return nil, err
+	}
+
+	if req.Size <= 0 {
+		return nil, fmt.Errorf("%w: file is empty", ErrInvalidInput)
+	}
+	if req.Size > s.limits.MaxSize {
+		return nil, fmt.Errorf("%w (max %d bytes)", ErrAttachmentTooLarge, s.limits.MaxSize)
+	}
+	used, err := s.attachmentRepo.TotalSizeByBoard(boardID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attachment usage: %w", err)
+	}
+	if used+req.Size > s.limits.BoardQuota {
+		return nil, fmt.Errorf("%w (%d of %d bytes used)", ErrQuotaExceeded, used, s.limits.BoardQuota)
+	}
+
+	// The stored type comes from the content, never from the client
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(req.Content, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, fmt.Errorf("failed to read attachment: %w", err)
+	}
+	head = head[:n]
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	if !allowedAttachmentTypes[contentType] {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedMediaType, contentType)
+	}
+
+	attachment := &models.Attachment{
+		ID:          uuid.New(),
+		BoardID:     boardID,
+		ItemID:      itemID,
+		Filename:    sanitizeFilename(req.Filename),
+		ContentType: contentType,
+		Size:        req.Size,
+		UploadedBy:  userID,
+	}
+	attachment.StorageKey = fmt.Sprintf("boards/%s/items/%s/%s", boardID, itemID, attachment.ID)
+	if previewableTypes[contentType] {
+		attachment.PreviewStatus = models.PreviewPending
+	} else if strings.HasPrefix(contentType, "image/") {
+		attachment.PreviewStatus = models.PreviewUnsupported
+	}
+
+	hash := sha256.New()
+	content := io.TeeReader(io.MultiReader(bytes.NewReader(head), req.Content), hash)
+	if err := s.store.Put(ctx, attachment.StorageKey, content, req.Size, contentType); err != nil {
+		return nil, fmt.Errorf("failed to store attachment: %w", err)
+	}
+	attachment.SHA256 = hex.EncodeToString(hash.Sum(nil))
+
+	if err := s.attachmentRepo.Create(attachment); err != nil {
+		s.removeBlob(ctx, attachment.StorageKey)
+		return nil, fmt.Errorf("failed to create attachment: %w", err)
+	}
+	err = s.recordCustody(attachment, models.CustodyUploaded, userID, attachment.SHA256, req.ClientIP,
+		fmt.Sprintf("%s (%s, %d bytes)", attachment.Filename, attachment.ContentType, attachment.Size))
+	if err != nil {
+		// Evidence without a custody record is not kept
+		if delErr := s.attachmentRepo.Delete(attachment.ID); delErr != nil {
+			log.Printf("Failed to remove attachment %s without custody record: %v", attachment.ID, delErr)
+		}
+		s.removeBlob(ctx, attachment.StorageKey)
+		return nil, err
+	}
+
+	if attachment.PreviewStatus == models.PreviewPending && s.previews != nil {
+		s.previews.Enqueue(attachment.ID)
+	}
+	return attachment, nil
+}
+
+// ListAttachments lists the attachments of an item
+func (s *AttachmentService) ListAttachments(boardID, itemID, userID uuid.UUID) ([]models.Attachment, error) {
+	if _, err := s.checkItem(boardID, itemID, userID, false); err != nil {
+		return nil, err
+	}
+	attachments, err := s.attachmentRepo.ListByItem(itemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list attachments: %w", err)
+	}
+	return attachments, nil
+}
+
+// OpenAttachment returns an attachment with a reader for its content; the
+// caller closes the reader. Every access is recorded in the custody log, and
+// content is only handed out once the record is written.
+func (s *AttachmentService) OpenAttachment(ctx context.Context, boardID, itemID, attachmentID, userID uuid.UUID, access AttachmentAccess) (*models.Attachment, io.ReadCloser, error) {
+	attachment, err := s.getAttachment(boardID, itemID, attachmentID, userID, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	content, err := s.store.Get(ctx, attachment.StorageKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open attachment: %w", err)
+	}
+
+	action := models.CustodyDownloaded
+	if access.Inline {
+		action = models.CustodyViewed
+	}
+	if err := s.recordCustody(attachment, action, userID, attachment.SHA256, access.ClientIP, ""); err != nil {
+		content.Close()
+		return nil, nil, err
+	}
+	return attachment, content, nil
+}
+
+// DeleteAttachment removes an attachment and its content. The custody log
+// keeps the record of the file and of its deletion.
+func (s *AttachmentService) DeleteAttachment(ctx context.Context, boardID, itemID, attachmentID, userID uuid.UUID, clientIP string) error {
+	attachment, err := s.getAttachment(boardID, itemID, attachmentID, userID, true)
+	if err != nil {
+		return err
+	}
+	return s.removeAttachment(ctx, attachment, userID, clientIP)
+}
+
+// DeleteItemAttachments removes the attachments of items that are being
+// deleted, recording each deletion in the item's custody log. The caller has
+// already checked that the user may delete the items.
+func (s *AttachmentService) DeleteItemAttachments(ctx context.Context, itemIDs []uuid.UUID, userID uuid.UUID) error {
+	for _, itemID := range itemIDs {
+		attachments, err := s.attachmentRepo.ListByItem(itemID)
+		if err != nil {
+			return fmt.Errorf("failed to list attachments: %w", err)
+		}
+		for i := range attachments {
+			if err := s.removeAttachment(ctx, &attachments[i], userID, ""); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// DeleteBoardAttachments removes the files of a board that is being deleted,
+// along with their records and custody logs, which end with the board
+func (s *AttachmentService) DeleteBoardAttachments(ctx context.Context, boardID uuid.UUID) error {
+	attachments, err := s.attachmentRepo.ListByBoard(boardID)
+	if err != nil {
+		return fmt.Errorf("failed to list attachments: %w", err)
+	}
+	for i := range attachments {
+		s.removeBlob(ctx, attachments[i].StorageKey)
+		for _, size := range attachments[i].Thumbnails {
+			s.removeBlob(ctx, thumbnailKey(attachments[i].StorageKey, size))
+		}
+	}
+	if err := s.attachmentRepo.DeleteByBoard(boardID); err != nil {
+		return fmt.Errorf("failed to delete attachments: %w", err)
+	}
+	if err := s.custodyRepo.DeleteByBoard(boardID); err != nil {
+		return fmt.Errorf("failed to delete custody log: %w", err)
+	}
+	return nil
+}
+
+func (s *AttachmentService) removeAttachment(ctx context.Context, attachment *models.Attachment, userID uuid.UUID, clientIP string) error {
+	if err := s.attachmentRepo.Delete(attachment.ID); err != nil {
+		return fmt.Errorf("failed to delete attachment: %w", err)
+	}
+	// The record is gone either way; a leftover blob is only wasted space
+	s.removeBlob(ctx, attachment.StorageKey)
+	for _, size := range attachment.Thumbnails {
+		s.removeBlob(ctx, thumbnailKey(attachment.StorageKey, size))
+	}
+	return s.recordCustody(attachment, models.CustodyDeleted, userID, attachment.SHA256, clientIP, attachment.Filename)
+}
+
+func (s *AttachmentService) removeBlob(ctx context.Context, key string) {
+	if err := s.store.Delete(ctx, key); err != nil {
+		log.Printf("Failed to delete blob %s: %v", key, err)
+	}
+}
+
+// checkItem verifies the user's access to the board and that the item is on
+// it, on a layer they can see, within their clearance and, to write, on a
+// layer that is not locked
+func (s *AttachmentService) checkItem(boardID, itemID, userID uuid.UUID, write bool) (*models.BoardItem, error) {
+	required := models.PermissionRead
+	if write {
+		required = models.PermissionWrite
+	}
+	access, err := s.permissions.authorizeAccess(context.Background(), boardID, userID, required)
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := s.boardItemRepo.GetByID(itemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get item: %w", err)
+	}
+	if item == nil || item.BoardID != boardID || !access.canSeeItem(item) {
+		return nil, ErrItemNotFound
+	}
+	if !access.cleared(item.Classification) {
+		return nil, ErrUnauthorized
+	}
+	if write {
+		if err := access.checkItem(item); err != nil {
+			return nil, err
+		}
+	}
+	return item, nil
+}
+
+func (s *AttachmentService) getAttachment(boardID, itemID, attachmentID, userID uuid.UUID, write bool) (*models.Attachment, error) {
+	if _, err := s.checkItem(boardID, itemID, userID, write); err != nil {
+		return nil, err
+	}
+	attachment, err := s.attachmentRepo.GetByID(attachmentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attachment: %w", err)
+	}
+	if attachment == nil || attachment.ItemID != itemID || attachment.BoardID != boardID {
+		return nil, ErrAttachmentNotFound
+	}
+	return attachment, nil
+}
+
+// sanitizeFilename keeps the base name of an uploaded file without control
+// characters or markup, so it is safe to display and to send back in a
+// Content-Disposition header
+func sanitizeFilename(name string) string {
+	name = path.Base(strings.ReplaceAll(name, `\`, "/"))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || strings.ContainsRune(`<>"`, r) {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || name == "/" {
+		name = "attachment"
+	}
+	if len(name) > 255 {
+		ext := path.Ext(name)
+		if len(ext) > 20 {
+			ext = ""
+		}
+		name = strings.ToValidUTF8(name[:255-len(ext)], "") + ext
+	}
+	return name
+}
@@ -0,0 +1,289 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"evidence-wall/boards-service/internal/blob"
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockAttachmentRepository is a mock implementation of AttachmentRepository
+type MockAttachmentRepository struct {
+	mock.Mock
+}
+
+func (m *MockAttachmentRepository) Create(attachment *models.Attachment) error {
+	args := m.Called(attachment)
+	return args.Error(0)
+}
+
+func (m *MockAttachmentRepository) GetByID(id uuid.UUID) (*models.Attachment, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Attachment), args.Error(1)
+}
+
+func (m *MockAttachmentRepository) ListByItem(itemID uuid.UUID) ([]models.Attachment, error) {
+	args := m.Called(itemID)
+	return args.Get(0).([]models.Attachment), args.Error(1)
+}
+
+func (m *MockAttachmentRepository) ListByBoard(boardID uuid.UUID) ([]models.Attachment, error) {
+	args := m.Called(boardID)
+	return args.Get(0).([]models.Attachment), args.Error(1)
+}
+
+func (m *MockAttachmentRepository) ListPendingPreviews(limit int) ([]models.Attachment, error) {
+	args := m.Called(limit)
+	return args.Get(0).([]models.Attachment), args.Error(1)
+}
+
+func (m *MockAttachmentRepository) UpdatePreview(attachment *models.Attachment) error {
+	args := m.Called(attachment)
+	return args.Error(0)
+}
+
+func (m *MockAttachmentRepository) TotalSizeByBoard(boardID uuid.UUID) (int64, error) {
+	args := m.Called(boardID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockAttachmentRepository) Delete(id uuid.UUID) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockAttachmentRepository) DeleteByBoard(boardID uuid.UUID) error {
+	args := m.Called(boardID)
+	return args.Error(0)
+}
+
+// pngHeader is enough of a PNG file for content sniffing
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+type attachmentFixture struct {
+	*serviceFixture
+	attachmentRepo *MockAttachmentRepository
+	custodyRepo    *memoryCustodyRepository
+	store          *blob.LocalStore
+	attachments    *AttachmentService
+	item           *models.BoardItem
+}
+
+func newAttachmentFixture(t *testing.T, permission models.PermissionLevel, limits AttachmentLimits) *attachmentFixture {
+	store, err := blob.NewLocalStore(t.TempDir())
+	assert.NoError(t, err)
+	f := &attachmentFixture{
+		serviceFixture: newServiceFixture(&models.Board{ID: uuid.New()}, permission, ""),
+		attachmentRepo: new(MockAttachmentRepository),
+		custodyRepo:    &memoryCustodyRepository{},
+		store:          store,
+	}
+	f.item = &models.BoardItem{ID: uuid.New(), BoardID: f.board.ID}
+	f.attachments = NewAttachmentService(f.boardRepo, nil, f.itemRepo, f.attachmentRepo, f.custodyRepo, store, nil, limits)
+	// Uploaders, readers and deleters are all users of the board
+	f.boardRepo.On("GetPermission", f.board.ID, mock.Anything).Return(true, permission, nil)
+	f.itemRepo.On("GetByID", f.item.ID).Return(f.item, nil)
+	return f
+}
+
+func TestAttachmentService_UploadAndDownload(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	f := newAttachmentFixture(t, models.PermissionWrite, AttachmentLimits{})
+	assert.Equal(t, DefaultMaxAttachmentSize, f.attachments.Limits().MaxSize)
+
+	content := append(append([]byte{}, pngHeader...), bytes.Repeat([]byte{0}, 1000)...)
+	f.attachmentRepo.On("TotalSizeByBoard", f.board.ID).Return(int64(0), nil)
+	var created *models.Attachment
+	f.attachmentRepo.On("Create", mock.AnythingOfType("*models.Attachment")).Run(func(args mock.Arguments) {
+		created = args.Get(0).(*models.Attachment)
+	}).Return(nil)
+
+	attachment, err := f.attachments.UploadAttachment(ctx, f.board.ID, f.item.ID, userID, UploadAttachmentRequest{
+		Filename: `C:\evidence\<scene>.png`,
+		Size:     int64(len(content)),
+		Content:  bytes.NewReader(content),
+	})
+	assert.NoError(t, err)
+	assert.Same(t, created, attachment)
+	assert.Equal(t, "scene.png", attachment.Filename)
+	assert.Equal(t, "image/png", attachment.ContentType)
+	assert.Equal(t, userID, attachment.UploadedBy)
+	assert.Equal(t, "boards/"+f.board.ID.String()+"/items/"+f.item.ID.String()+"/"+attachment.ID.String(), attachment.StorageKey)
+
+	f.attachmentRepo.On("GetByID", attachment.ID).Return(attachment, nil)
+	got, rc, err := f.attachments.OpenAttachment(ctx, f.board.ID, f.item.ID, attachment.ID, userID, AttachmentAccess{})
+	assert.NoError(t, err)
+	assert.Equal(t, attachment.ID, got.ID)
+	data, _ := io.ReadAll(rc)
+	rc.Close()
+	assert.Equal(t, content, data)
+
+	// The attachment is addressed through its own item only
+	otherItem := &models.BoardItem{ID: uuid.New(), BoardID: f.board.ID}
+	f.itemRepo.On("GetByID", otherItem.ID).Return(otherItem, nil)
+	_, _, err = f.attachments.OpenAttachment(ctx, f.board.ID, otherItem.ID, attachment.ID, userID, AttachmentAccess{})
+	assert.Equal(t, ErrAttachmentNotFound, err)
+
+	f.attachmentRepo.On("Delete", attachment.ID).Return(nil)
+	assert.NoError(t, f.attachments.DeleteAttachment(ctx, f.board.ID, f.item.ID, attachment.ID, userID, ""))
+	_, err = f.store.Get(ctx, attachment.StorageKey)
+	assert.True(t, errors.Is(err, blob.ErrNotFound))
+}
+
+func TestAttachmentService_UploadRejections(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	f := newAttachmentFixture(t, models.PermissionWrite, AttachmentLimits{MaxSize: 100, BoardQuota: 150})
+	upload := func(content []byte) error {
+		_, err := f.attachments.UploadAttachment(ctx, f.board.ID, f.item.ID, userID, UploadAttachmentRequest{
+			Filename: "file", Size: int64(len(content)), Content: bytes.NewReader(content),
+		})
+		return err
+	}
+
+	assert.True(t, errors.Is(upload(nil), ErrInvalidInput))
+	assert.True(t, errors.Is(upload(bytes.Repeat([]byte("a"), 101)), ErrAttachmentTooLarge))
+
+	f.attachmentRepo.On("TotalSizeByBoard", f.board.ID).Return(int64(100), nil)
+	assert.True(t, errors.Is(upload(bytes.Repeat([]byte("a"), 60)), ErrQuotaExceeded))
+
+	// Executables and HTML are refused whatever the file is called
+	assert.True(t, errors.Is(upload([]byte("MZ\x90\x00\x03\x00\x00\x00\x04\x00\x00\x00\xff\xff")), ErrUnsupportedMediaType))
+	assert.True(t, errors.Is(upload([]byte("<html><script>alert(1)</script>")), ErrUnsupportedMediaType))
+	f.attachmentRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestAttachmentService_CreateFailureRemovesBlob(t *testing.T) {
+	ctx := context.Background()
+	f := newAttachmentFixture(t, models.PermissionAdmin, AttachmentLimits{})
+	f.attachmentRepo.On("TotalSizeByBoard", f.board.ID).Return(int64(0), nil)
+	var key string
+	f.attachmentRepo.On("Create", mock.AnythingOfType("*models.Attachment")).Run(func(args mock.Arguments) {
+		key = args.Get(0).(*models.Attachment).StorageKey
+	}).Return(errors.New("db down"))
+
+	_, err := f.attachments.UploadAttachment(ctx, f.board.ID, f.item.ID, uuid.New(), UploadAttachmentRequest{
+		Filename: "notes.txt", Size: 5, Content: strings.NewReader("notes"),
+	})
+	assert.Error(t, err)
+	_, err = f.store.Get(ctx, key)
+	assert.True(t, errors.Is(err, blob.ErrNotFound))
+}
+
+// recordingItemAttachments records the items and boards whose attachments
+// are deleted
+type recordingItemAttachments struct {
+	deleted       []uuid.UUID
+	deletedBoards []uuid.UUID
+}
+
+func (r *recordingItemAttachments) DeleteItemAttachments(ctx context.Context, itemIDs []uuid.UUID, userID uuid.UUID) error {
+	r.deleted = append(r.deleted, itemIDs...)
+	return nil
+}
+
+func (r *recordingItemAttachments) DeleteBoardAttachments(ctx context.Context, boardID uuid.UUID) error {
+	r.deletedBoards = append(r.deletedBoards, boardID)
+	return nil
+}
+
+func TestAttachmentService_DeleteItemAttachments(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	f := newAttachmentFixture(t, models.PermissionWrite, AttachmentLimits{})
+	attachment := models.Attachment{ID: uuid.New(), BoardID: f.board.ID, ItemID: f.item.ID, Filename: "scene.png",
+		StorageKey: "boards/scene", SHA256: "abc"}
+	assert.NoError(t, f.store.Put(ctx, attachment.StorageKey, strings.NewReader("png"), 3, "image/png"))
+	f.attachmentRepo.On("ListByItem", f.item.ID).Return([]models.Attachment{attachment}, nil)
+	f.attachmentRepo.On("Delete", attachment.ID).Return(nil)
+
+	assert.NoError(t, f.attachments.DeleteItemAttachments(ctx, []uuid.UUID{f.item.ID}, userID))
+	f.attachmentRepo.AssertExpectations(t)
+	_, err := f.store.Get(ctx, attachment.StorageKey)
+	assert.True(t, errors.Is(err, blob.ErrNotFound))
+
+	// The item's custody log records the deletion
+	events, err := f.custodyRepo.ListByItem(f.item.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, models.CustodyDeleted, events[0].Action)
+		assert.Equal(t, attachment.ID, events[0].AttachmentID)
+		assert.Equal(t, userID, events[0].UserID)
+	}
+}
+
+func TestAttachmentService_DeleteBoardAttachments(t *testing.T) {
+	ctx := context.Background()
+	f := newAttachmentFixture(t, models.PermissionAdmin, AttachmentLimits{})
+	f.attachmentRepo.On("TotalSizeByBoard", f.board.ID).Return(int64(0), nil)
+	f.attachmentRepo.On("Create", mock.Anything).Return(nil)
+	attachment := f.upload(t, uuid.New(), "evidence")
+	f.attachmentRepo.On("ListByBoard", f.board.ID).Return([]models.Attachment{*attachment}, nil)
+	f.attachmentRepo.On("DeleteByBoard", f.board.ID).Return(nil)
+
+	assert.NoError(t, f.attachments.DeleteBoardAttachments(ctx, f.board.ID))
+	f.attachmentRepo.AssertExpectations(t)
+	_, err := f.store.Get(ctx, attachment.StorageKey)
+	assert.True(t, errors.Is(err, blob.ErrNotFound))
+	// The custody log ends with the board
+	events, err := f.custodyRepo.ListByItem(f.item.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+}
+
+func TestAttachmentService_Permissions(t *testing.T) {
+	ctx := context.Background()
+	f := newAttachmentFixture(t, models.PermissionRead, AttachmentLimits{})
+
+	_, err := f.attachments.UploadAttachment(ctx, f.board.ID, f.item.ID, uuid.New(), UploadAttachmentRequest{
+		Filename: "notes.txt", Size: 5, Content: strings.NewReader("notes"),
+	})
+	assert.Equal(t, ErrUnauthorized, err)
+
+	// Readers can list
+	f.attachmentRepo.On("ListByItem", f.item.ID).Return([]models.Attachment{{ID: uuid.New()}}, nil)
+	list, err := f.attachments.ListAttachments(f.board.ID, f.item.ID, uuid.New())
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+
+	assert.Equal(t, ErrUnauthorized, f.attachments.DeleteAttachment(ctx, f.board.ID, f.item.ID, uuid.New(), uuid.New(), ""))
+
+	// Items on other boards are not reachable through this board
+	foreign := &models.BoardItem{ID: uuid.New(), BoardID: uuid.New()}
+	f.itemRepo.On("GetByID", foreign.ID).Return(foreign, nil)
+	_, err = f.attachments.ListAttachments(f.board.ID, foreign.ID, uuid.New())
+	assert.Equal(t, ErrItemNotFound, err)
+
+	// Private boards are not revealed to users without access
+	noAccess := newAttachmentFixture(t, "", AttachmentLimits{})
+	_, err = noAccess.attachments.ListAttachments(noAccess.board.ID, noAccess.item.ID, uuid.New())
+	assert.Equal(t, ErrBoardNotFound, err)
+}
+
+func TestSanitizeFilename(t *testing.T) {
+	cases := map[string]string{
+		"photo.jpg":                       "photo.jpg",
+		"../../etc/passwd":                "passwd",
+		`C:\Users\a\scan.pdf`:             "scan.pdf",
+		"bad\x00\nname\".txt":             "badname.txt",
+		"":                                "attachment",
+		"   ":                             "attachment",
+		strings.Repeat("x", 300) + ".pdf": strings.Repeat("x", 251) + ".pdf",
+	}
+	for in, want := range cases {
+		assert.Equal(t, want, sanitizeFilename(in), in)
+	}
+}
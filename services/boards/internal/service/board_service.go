@@ -75,7 +75,15 @@ type BoardService struct {
 	boardUserRepo  BoardUserRepositoryInterface
 	boardItemRepo  BoardItemRepositoryInterface
 	connectionRepo BoardConnectionRepositoryInterface
+	templateRepo   TemplateRepositoryInterface
+	tagRepo        TagRepositoryInterface
+	layerRepo      LayerRepositoryInterface
 	redis          *redis.Client
+	permissions    *PermissionResolver
+	attachments    ItemAttachments
+	history        HistoryStore
+	transaction    HistoryTransaction
+	contentTx      ContentTransaction
 }
 
 // NewBoardService creates a new board service
@@ -84,14 +92,64 @@ func NewBoardService(
 	boardUserRepo BoardUserRepositoryInterface,
 	boardItemRepo BoardItemRepositoryInterface,
 	connectionRepo BoardConnectionRepositoryInterface,
+	templateRepo TemplateRepositoryInterface,
+	tagRepo TagRepositoryInterface,
+	layerRepo LayerRepositoryInterface,
 	redis *redis.Client,
 ) *BoardService {
+	// Undo history is shared through Redis when available so that any
+	// replica can serve a user's undo request
+	var history HistoryStore = NewMemoryHistoryStore()
+	if redis != nil {
+		history = NewRedisHistoryStore(redis)
+	}
+
 	return &BoardService{
 		boardRepo:      boardRepo,
 		boardUserRepo:  boardUserRepo,
 		boardItemRepo:  boardItemRepo,
 		connectionRepo: connectionRepo,
+		templateRepo:   templateRepo,
+		tagRepo:        tagRepo,
+		layerRepo:      layerRepo,
 		redis:          redis,
+		permissions:    NewPermissionResolver(boardRepo, redis).WithLayers(layerRepo, boardItemRepo).WithClearances(boardUserRepo),
+		history:        history,
+	}
+}
+
+// Permissions returns the resolver the service checks permissions with, for
+// other services that must see its cache invalidations
+func (s *BoardService) Permissions() *PermissionResolver {
+	return s.permissions
+}
+
+// SetAttachments sets where the attachments of deleted items and boards are
+// removed; without it they are left in place
+func (s *BoardService) SetAttachments(attachments ItemAttachments) {
+	s.attachments = attachments
+}
+
+// SetHistoryTransaction sets how undo and redo run in one database
+// transaction; without it their changes are applied one by one
+func (s *BoardService) SetHistoryTransaction(transaction HistoryTransaction) {
+	s.transaction = transaction
+}
+
+// SetContentTransaction sets how board copies and imports are created in
+// one database transaction; without it their rows are created one by one
+func (s *BoardService) SetContentTransaction(transaction ContentTransaction) {
+	s.contentTx = transaction
+}
+
+// deleteAttachments removes the attachments of deleted items. The items are
+// gone already, so a failure only leaves the files behind.
+func (s *BoardService) deleteAttachments(itemIDs []uuid.UUID, userID uuid.UUID) {
+	if s.attachments == nil {
+		return
+	}
+	if err := s.attachments.DeleteItemAttachments(context.Background(), itemIDs, userID); err != nil {
+		log.Printf("Failed to delete attachments of deleted items: %v", err)
 	}
 }
 
@@ -100,13 +158,17 @@ type CreateBoardRequest struct {
 	Title       string                 `json:"title" binding:"required,min=1,max=200"`
 	Description string                 `json:"description" binding:"max=1000"`
 	Visibility  models.BoardVisibility `json:"visibility" binding:"required,oneof=private shared public"`
+	TemplateID  *uuid.UUID             `json:"template_id"` // Optional template to instantiate on the new board
 }
 
 // UpdateBoardRequest represents a board update request
 type UpdateBoardRequest struct {
-	Title       string                 `json:"title" binding:"omitempty,min=1,max=200"`
-	Description string                 `json:"description" binding:"omitempty,max=1000"`
-	Visibility  models.BoardVisibility `json:"visibility" binding:"omitempty,oneof=private shared public"`
+	Title        string                 `json:"title" binding:"omitempty,min=1,max=200"`
+	Description  string                 `json:"description" binding:"omitempty,max=1000"`
+	Visibility   models.BoardVisibility `json:"visibility" binding:"omitempty,oneof=private shared public"`
+	CustomFields *[]models.CustomField  `json:"custom_fields"` // Replaces the board's custom fields when present
+	// Classification marks the board and is the default for its new items
+	Classification *models.ClassificationLevel `json:"classification"`
 }
 
 // CreateBoard creates a new board
@@ -122,12 +184,31 @@ func (s *BoardService) CreateBoard(userID uuid.UUID, req CreateBoardRequest) (*m
 		return nil, fmt.Errorf("description validation failed: %w", err)
 	}
 
+	// Resolve and check the template before creating anything so an unknown
+	// or invalid template fails cleanly
+	var template *models.BoardTemplate
+	var items []models.BoardItem
+	var connections []models.BoardConnection
+	if req.TemplateID != nil {
+		template, err = s.GetTemplate(*req.TemplateID, userID)
+		if err != nil {
+			return nil, err
+		}
+		items, connections, err = templateContents(template)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	board := &models.Board{
 		Title:       title,
 		Description: description,
 		Visibility:  req.Visibility,
 		OwnerID:     userID,
 	}
+	if template != nil {
+		board.CustomFields = template.Content.CustomFields
+	}
 
 	if err := s.boardRepo.Create(board); err != nil {
 		return nil, fmt.Errorf("failed to create board: %w", err)
@@ -142,20 +223,34 @@ func (s *BoardService) CreateBoard(userID uuid.UUID, req CreateBoardRequest) (*m
 		// Non-fatal; log in real app. Continue returning created board.
 	}
 
+	if template != nil {
+		if _, err := copyBoardContents(s.contentRepositories(), board.ID, userID, items, connections, nil); err != nil {
+			return nil, err
+		}
+	}
+
 	return board, nil
 }
 
-// GetBoard retrieves a board by ID with permission check
+// GetBoard retrieves a board by ID with permission check. Items inside
+// collapsed frames are left out and their connections summed up per frame.
 func (s *BoardService) GetBoard(boardID, userID uuid.UUID) (*models.BoardResponse, error) {
-	board, permission, err := s.boardRepo.GetByIDWithPermission(boardID, userID)
+	board, permission, access, err := s.getBoardContents(boardID, userID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get board: %w", err)
+		return nil, err
 	}
-	if board == nil || permission == "" {
-		return nil, ErrBoardNotFound
+	hidden, err := s.collapsedFrames(boardID, access)
+	if err != nil {
+		return nil, err
 	}
+	var aggregated []models.AggregatedConnection
+	board.Items, board.Connections, aggregated = collapseFrames(board.Items, board.Connections, hidden)
 
 	response := board.ToResponse(permission)
+	response.AggregatedConnections = aggregated
+	if len(access.redacted) > 0 {
+		response.Clearance = access.viewerClearance()
+	}
 	return &response, nil
 }
 
@@ -172,6 +267,15 @@ func (s *BoardService) GetPublicBoard(boardID uuid.UUID) (*models.Board, error)
 		return nil, ErrUnauthorized
 	}
 
+	access, err := s.permissions.boardAccess(boardID, uuid.Nil, models.PermissionRead)
+	if err != nil {
+		return nil, err
+	}
+	board.Items = access.filterItems(board.Items)
+	board.Connections = access.filterConnections(board.Connections)
+	board.Layers = access.visible
+	access.redactContents(board)
+
 	return board, nil
 }
 
@@ -182,6 +286,11 @@ func (s *BoardService) ListBoards(userID uuid.UUID, offset, limit int) ([]models
 		return nil, 0, fmt.Errorf("failed to list boards: %w", err)
 	}
 
+	return boardResponses(boards, userID), total, nil
+}
+
+// boardResponses converts listed boards with the user's permission on each
+func boardResponses(boards []models.Board, userID uuid.UUID) []models.BoardResponse {
 	responses := make([]models.BoardResponse, 0, len(boards))
 	for _, b := range boards {
 		// Determine permission for current user
@@ -199,22 +308,21 @@ func (s *BoardService) ListBoards(userID uuid.UUID, offset, limit int) ([]models
 		responses = append(responses, b.ToResponse(perm))
 	}
 
-	return responses, total, nil
+	return responses
 }
 
 // UpdateBoard updates a board
 func (s *BoardService) UpdateBoard(boardID, userID uuid.UUID, req UpdateBoardRequest) (*models.Board, error) {
-	board, permission, err := s.boardRepo.GetByIDWithPermission(boardID, userID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get board: %w", err)
-	}
-	if board == nil {
-		return nil, ErrBoardNotFound
+	if err := s.permissions.Authorize(context.Background(), boardID, userID, models.PermissionAdmin); err != nil {
+		return nil, err
 	}
-	if permission != models.PermissionAdmin {
-		return nil, ErrUnauthorized
+	board, err := s.getBoardRow(boardID)
+	if err != nil {
+		return nil, err
 	}
 
+	before := stripBoard(board)
+
 	// Update fields if provided
 	if req.Title != "" {
 		board.Title = req.Title
@@ -225,58 +333,95 @@ func (s *BoardService) UpdateBoard(boardID, userID uuid.UUID, req UpdateBoardReq
 	if req.Visibility != "" {
 		board.Visibility = req.Visibility
 	}
+	if req.CustomFields != nil {
+		customFields, err := validateCustomFields(*req.CustomFields)
+		if err != nil {
+			return nil, err
+		}
+		board.CustomFields = customFields
+	}
+	if req.Classification != nil {
+		if err := validateClassification(*req.Classification, "classification"); err != nil {
+			return nil, err
+		}
+		board.Classification = *req.Classification
+	}
 
 	if err := s.boardRepo.Update(board); err != nil {
 		return nil, fmt.Errorf("failed to update board: %w", err)
 	}
+	if board.Visibility != before.Visibility {
+		s.permissions.Invalidate(context.Background(), boardID)
+	}
+
+	s.recordHistory(boardID, userID, "board_updated", boardChange(before, board))
 
 	return board, nil
 }
 
-// DeleteBoard deletes a board
-func (s *BoardService) DeleteBoard(boardID, userID uuid.UUID) error {
-	board, permission, err := s.boardRepo.GetByIDWithPermission(boardID, userID)
+// getBoardRow loads a board without its users or contents, for mutations
+// that have already checked the caller's permission
+func (s *BoardService) getBoardRow(boardID uuid.UUID) (*models.Board, error) {
+	board, err := s.boardRepo.GetRowByID(boardID)
 	if err != nil {
-		return fmt.Errorf("failed to get board: %w", err)
+		return nil, fmt.Errorf("failed to get board: %w", err)
 	}
 	if board == nil {
-		return ErrBoardNotFound
+		return nil, ErrBoardNotFound
 	}
-	if permission != models.PermissionAdmin {
-		return ErrUnauthorized
+	return board, nil
+}
+
+// DeleteBoard deletes a board along with its attachments, tags and layers
+func (s *BoardService) DeleteBoard(boardID, userID uuid.UUID) error {
+	if err := s.permissions.Authorize(context.Background(), boardID, userID, models.PermissionAdmin); err != nil {
+		return err
 	}
 
 	// Delete all related data
+	if s.attachments != nil {
+		if err := s.attachments.DeleteBoardAttachments(context.Background(), boardID); err != nil {
+			return fmt.Errorf("failed to delete board attachments: %w", err)
+		}
+	}
+	if s.tagRepo != nil {
+		if err := s.tagRepo.DeleteByBoard(boardID); err != nil {
+			return fmt.Errorf("failed to delete board tags: %w", err)
+		}
+	}
 	if err := s.connectionRepo.DeleteByBoard(boardID); err != nil {
 		return fmt.Errorf("failed to delete board connections: %w", err)
 	}
 	if err := s.boardItemRepo.DeleteByBoard(boardID); err != nil {
 		return fmt.Errorf("failed to delete board items: %w", err)
 	}
+	if s.layerRepo != nil {
+		if err := s.layerRepo.DeleteByBoard(boardID); err != nil {
+			return fmt.Errorf("failed to delete board layers: %w", err)
+		}
+	}
 	if err := s.boardRepo.Delete(boardID); err != nil {
 		return fmt.Errorf("failed to delete board: %w", err)
 	}
+	s.permissions.Invalidate(context.Background(), boardID)
 
 	return nil
 }
 
 // ShareBoardRequest represents a board sharing request
 type ShareBoardRequest struct {
-	UserID     uuid.UUID              `json:"user_id" binding:"required"`
-	Permission models.PermissionLevel `json:"permission" binding:"required,oneof=read write admin"`
+	UserID     uuid.UUID                  `json:"user_id" binding:"required"`
+	Permission models.PermissionLevel     `json:"permission" binding:"required,oneof=read write admin"`
+	Clearance  models.ClassificationLevel `json:"clearance"` // Unclassified when omitted
 }
 
 // ShareBoard shares a board with a user
 func (s *BoardService) ShareBoard(boardID, ownerID uuid.UUID, req ShareBoardRequest) error {
-	board, permission, err := s.boardRepo.GetByIDWithPermission(boardID, ownerID)
-	if err != nil {
-		return fmt.Errorf("failed to get board: %w", err)
+	if err := s.permissions.Authorize(context.Background(), boardID, ownerID, models.PermissionAdmin); err != nil {
+		return err
 	}
-	if board == nil {
-		return ErrBoardNotFound
-	}
-	if permission != models.PermissionAdmin {
-		return ErrUnauthorized
+	if err := validateClassification(req.Clearance, "clearance"); err != nil {
+		return err
 	}
 
 	// Check if user already has access
@@ -288,51 +433,54 @@ func (s *BoardService) ShareBoard(boardID, ownerID uuid.UUID, req ShareBoardRequ
 	if existing != nil {
 		// Update existing permission
 		existing.Permission = req.Permission
-		return s.boardUserRepo.Update(existing)
+		existing.Clearance = req.Clearance
+		err = s.boardUserRepo.Update(existing)
+	} else {
+		// Create new board user relationship
+		err = s.boardUserRepo.Create(&models.BoardUser{
+			BoardID:    boardID,
+			UserID:     req.UserID,
+			Permission: req.Permission,
+			Clearance:  req.Clearance,
+		})
 	}
-
-	// Create new board user relationship
-	boardUser := &models.BoardUser{
-		BoardID:    boardID,
-		UserID:     req.UserID,
-		Permission: req.Permission,
+	if err != nil {
+		return err
 	}
 
-	return s.boardUserRepo.Create(boardUser)
+	s.permissions.Invalidate(context.Background(), boardID, req.UserID)
+	return nil
 }
 
 // UnshareBoard removes a user's access to a board
 func (s *BoardService) UnshareBoard(boardID, ownerID, targetUserID uuid.UUID) error {
-	board, permission, err := s.boardRepo.GetByIDWithPermission(boardID, ownerID)
-	if err != nil {
-		return fmt.Errorf("failed to get board: %w", err)
+	if err := s.permissions.Authorize(context.Background(), boardID, ownerID, models.PermissionAdmin); err != nil {
+		return err
 	}
-	if board == nil {
-		return ErrBoardNotFound
-	}
-	if permission != models.PermissionAdmin {
-		return ErrUnauthorized
+
+	if err := s.boardUserRepo.Delete(boardID, targetUserID); err != nil {
+		return err
 	}
 
-	return s.boardUserRepo.Delete(boardID, targetUserID)
+	s.permissions.Invalidate(context.Background(), boardID, targetUserID)
+	return nil
 }
 
 // UpdateUserPermissionRequest represents a permission update request
 type UpdateUserPermissionRequest struct {
-	Permission models.PermissionLevel `json:"permission" binding:"required,oneof=read write admin"`
+	Permission models.PermissionLevel      `json:"permission" binding:"required,oneof=read write admin"`
+	Clearance  *models.ClassificationLevel `json:"clearance"` // Left unchanged when omitted
 }
 
 // UpdateUserPermission updates a user's permission for a board
 func (s *BoardService) UpdateUserPermission(boardID, ownerID, targetUserID uuid.UUID, req UpdateUserPermissionRequest) error {
-	board, permission, err := s.boardRepo.GetByIDWithPermission(boardID, ownerID)
-	if err != nil {
-		return fmt.Errorf("failed to get board: %w", err)
-	}
-	if board == nil {
-		return ErrBoardNotFound
+	if err := s.permissions.Authorize(context.Background(), boardID, ownerID, models.PermissionAdmin); err != nil {
+		return err
 	}
-	if permission != models.PermissionAdmin {
-		return ErrUnauthorized
+	if req.Clearance != nil {
+		if err := validateClassification(*req.Clearance, "clearance"); err != nil {
+			return err
+		}
 	}
 
 	boardUser, err := s.boardUserRepo.GetByBoardAndUser(boardID, targetUserID)
@@ -344,33 +492,56 @@ func (s *BoardService) UpdateUserPermission(boardID, ownerID, targetUserID uuid.
 	}
 
 	boardUser.Permission = req.Permission
-	return s.boardUserRepo.Update(boardUser)
+	if req.Clearance != nil {
+		boardUser.Clearance = *req.Clearance
+	}
+	if err := s.boardUserRepo.Update(boardUser); err != nil {
+		return err
+	}
+
+	s.permissions.Invalidate(context.Background(), boardID, targetUserID)
+	return nil
 }
 
 // CreateItemRequest represents a board item creation request
 type CreateItemRequest struct {
-	Type     string                 `json:"type" binding:"required,oneof=post-it suspect-card note"`
-	Content  string                 `json:"content" binding:"required"`
-	X        float64                `json:"x" binding:"required"`
-	Y        float64                `json:"y" binding:"required"`
-	Width    float64                `json:"width" binding:"required,min=10"`
-	Height   float64                `json:"height" binding:"required,min=10"`
-	ZIndex   int                    `json:"z_index"`
-	Color    string                 `json:"color"`
-	Metadata map[string]interface{} `json:"metadata"`
+	Type         string                 `json:"type" binding:"required,max=50"` // A registered item type, or "note" with metadata.variant
+	Content      string                 `json:"content" binding:"required"`
+	X            float64                `json:"x" binding:"required"`
+	Y            float64                `json:"y" binding:"required"`
+	Width        float64                `json:"width" binding:"required,min=10"`
+	Height       float64                `json:"height" binding:"required,min=10"`
+	ZIndex       int                    `json:"z_index"`
+	Color        string                 `json:"color"`
+	Metadata     map[string]interface{} `json:"metadata"`
+	Fields       map[string]interface{} `json:"fields"`        // Structured values, validated against the item type's schema
+	CustomValues map[string]interface{} `json:"custom_values"` // Values of the board's custom fields, keyed by field key
+	ParentID     *uuid.UUID             `json:"parent_id"`     // Frame to create the item in
+	LayerID      *uuid.UUID             `json:"layer_id"`      // Layer to create the item on; the base layer when omitted
+	// Classification marks the item, up to the creator's own clearance. The
+	// board's classification is used when omitted.
+	Classification models.ClassificationLevel `json:"classification"`
 }
 
 // CreateBoardItem creates a new board item
 func (s *BoardService) CreateBoardItem(boardID, userID uuid.UUID, req CreateItemRequest) (*models.BoardItem, error) {
-	board, permission, err := s.boardRepo.GetByIDWithPermission(boardID, userID)
+	access, err := s.permissions.authorizeAccess(context.Background(), boardID, userID, models.PermissionWrite)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get board: %w", err)
+		return nil, err
 	}
-	if board == nil {
-		return nil, ErrBoardNotFound
+	board, err := s.getBoardRow(boardID)
+	if err != nil {
+		return nil, err
 	}
-	if permission == "" || permission == models.PermissionRead {
-		return nil, ErrUnauthorized
+	if err := access.checkLayer(req.LayerID); err != nil {
+		return nil, err
+	}
+	classification := req.Classification
+	if classification == "" {
+		classification = board.Classification
+	}
+	if err := access.checkClassification(classification); err != nil {
+		return nil, err
 	}
 
 	// Validate and sanitize content
@@ -379,31 +550,22 @@ func (s *BoardService) CreateBoardItem(boardID, userID uuid.UUID, req CreateItem
 		return nil, fmt.Errorf("content validation failed: %w", err)
 	}
 
-	// Determine persisted item type to satisfy DB constraints.
-	// Frontend may send req.Type = "note" and specify UI variant in metadata.variant.
-	// Map to DB types: 'post-it' | 'suspect-card'. Default to 'post-it'.
-	persistedType := "post-it"
-	if req.Type == "note" {
-		// For "note" type, check metadata.variant to determine actual type
-		if req.Metadata != nil {
-			if v, ok := req.Metadata["variant"]; ok {
-				if vs, ok := v.(string); ok {
-					switch vs {
-					case "suspect-card":
-						persistedType = "suspect-card"
-					case "post-it":
-						persistedType = "post-it"
-					}
-				}
-			}
-		}
-	} else {
-		// Direct mapping for explicit types
-		switch req.Type {
-		case "post-it":
-			persistedType = "post-it"
-		case "suspect-card":
-			persistedType = "suspect-card"
+	itemType, err := resolveItemType(req.Type, req.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := validateFields(itemType, req.Fields)
+	if err != nil {
+		return nil, err
+	}
+	customValues, err := s.validateCustomValues(board, itemType, req.CustomValues)
+	if err != nil {
+		return nil, err
+	}
+	if req.ParentID != nil {
+		// A new item has no contents, so only the frame's own depth matters
+		if err := s.checkFrameParent(boardID, *req.ParentID, &models.BoardItem{Type: itemType}, access); err != nil {
+			return nil, err
 		}
 	}
 
@@ -422,16 +584,21 @@ func (s *BoardService) CreateBoardItem(boardID, userID uuid.UUID, req CreateItem
 	}
 
 	item := &models.BoardItem{
-		BoardID:   boardID,
-		Type:      persistedType,
-		Content:   content,
-		X:         req.X,
-		Y:         req.Y,
-		Width:     req.Width,
-		Height:    req.Height,
-		ZIndex:    req.ZIndex,
-		Style:     styleJSON,
-		CreatedBy: userID,
+		BoardID:        boardID,
+		Type:           itemType,
+		Content:        content,
+		X:              req.X,
+		Y:              req.Y,
+		Width:          req.Width,
+		Height:         req.Height,
+		ZIndex:         req.ZIndex,
+		Style:          styleJSON,
+		Fields:         fields,
+		CustomValues:   customValues,
+		ParentID:       req.ParentID,
+		LayerID:        req.LayerID,
+		Classification: classification,
+		CreatedBy:      userID,
 	}
 
 	if err := s.boardItemRepo.Create(item); err != nil {
@@ -440,33 +607,32 @@ func (s *BoardService) CreateBoardItem(boardID, userID uuid.UUID, req CreateItem
 
 	// Publish real-time update
 	s.publishBoardUpdate(boardID, "item_created", item)
+	s.recordHistory(boardID, userID, "item_created", itemChange(nil, item))
 
 	return item, nil
 }
 
 // UpdateItemRequest represents a board item update request
 type UpdateItemRequest struct {
-	Content  string                 `json:"content"`
-	X        *float64               `json:"x"`
-	Y        *float64               `json:"y"`
-	Width    *float64               `json:"width"`
-	Height   *float64               `json:"height"`
-	ZIndex   *int                   `json:"z_index"`
-	Color    string                 `json:"color"`
-	Metadata map[string]interface{} `json:"metadata"`
+	Content      string                 `json:"content"`
+	X            *float64               `json:"x"`
+	Y            *float64               `json:"y"`
+	Width        *float64               `json:"width"`
+	Height       *float64               `json:"height"`
+	ZIndex       *int                   `json:"z_index"`
+	Color        string                 `json:"color"`
+	Metadata     map[string]interface{} `json:"metadata"`
+	Fields       map[string]interface{} `json:"fields"`        // Replaces all field values when present
+	CustomValues map[string]interface{} `json:"custom_values"` // Replaces all custom field values when present
+	// Classification re-marks the item, up to the user's own clearance
+	Classification *models.ClassificationLevel `json:"classification"`
 }
 
 // UpdateBoardItem updates a board item
 func (s *BoardService) UpdateBoardItem(boardID, itemID, userID uuid.UUID, req UpdateItemRequest) (*models.BoardItem, error) {
-	board, permission, err := s.boardRepo.GetByIDWithPermission(boardID, userID)
+	access, err := s.permissions.authorizeAccess(context.Background(), boardID, userID, models.PermissionWrite)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get board: %w", err)
-	}
-	if board == nil {
-		return nil, ErrBoardNotFound
-	}
-	if permission == "" || permission == models.PermissionRead {
-		return nil, ErrUnauthorized
+		return nil, err
 	}
 
 	item, err := s.boardItemRepo.GetByID(itemID)
@@ -476,6 +642,10 @@ func (s *BoardService) UpdateBoardItem(boardID, itemID, userID uuid.UUID, req Up
 	if item == nil || item.BoardID != boardID {
 		return nil, ErrItemNotFound
 	}
+	if err := access.checkItem(item); err != nil {
+		return nil, err
+	}
+	before := stripItem(item)
 
 	// Update fields if provided
 	if req.Content != "" {
@@ -500,6 +670,31 @@ func (s *BoardService) UpdateBoardItem(boardID, itemID, userID uuid.UUID, req Up
 	if req.ZIndex != nil {
 		item.ZIndex = *req.ZIndex
 	}
+	if req.Classification != nil {
+		if err := access.checkClassification(*req.Classification); err != nil {
+			return nil, err
+		}
+		item.Classification = *req.Classification
+	}
+	if req.Fields != nil {
+		fields, err := validateFields(item.Type, req.Fields)
+		if err != nil {
+			return nil, err
+		}
+		item.Fields = fields
+	}
+	if req.CustomValues != nil {
+		// Custom values are checked against the board's field definitions
+		board, err := s.getBoardRow(boardID)
+		if err != nil {
+			return nil, err
+		}
+		customValues, err := s.validateCustomValues(board, item.Type, req.CustomValues)
+		if err != nil {
+			return nil, err
+		}
+		item.CustomValues = customValues
+	}
 
 	// Update style field if color or metadata provided
 	if req.Color != "" || req.Metadata != nil {
@@ -527,27 +722,32 @@ func (s *BoardService) UpdateBoardItem(boardID, itemID, userID uuid.UUID, req Up
 		item.Style = styleJSON
 	}
 
+	// A frame's contents move with it
+	if isFrame(item) && (item.X != before.X || item.Y != before.Y) {
+		if err := s.moveFrame(boardID, userID, before, item, access); err != nil {
+			return nil, err
+		}
+		return item, nil
+	}
+
 	if err := s.boardItemRepo.Update(item); err != nil {
 		return nil, fmt.Errorf("failed to update item: %w", err)
 	}
 
 	// Publish real-time update
 	s.publishBoardUpdate(boardID, "item_updated", item)
+	s.recordHistory(boardID, userID, "item_updated", itemChange(before, item))
 
 	return item, nil
 }
 
-// DeleteBoardItem deletes a board item
-func (s *BoardService) DeleteBoardItem(boardID, itemID, userID uuid.UUID) error {
-	board, permission, err := s.boardRepo.GetByIDWithPermission(boardID, userID)
+// DeleteBoardItem deletes a board item. Deleting a frame deletes its
+// contents along with it when deleteChildren is set, and otherwise moves them
+// onto the frame's parent.
+func (s *BoardService) DeleteBoardItem(boardID, itemID, userID uuid.UUID, deleteChildren bool) error {
+	access, err := s.permissions.authorizeAccess(context.Background(), boardID, userID, models.PermissionWrite)
 	if err != nil {
-		return fmt.Errorf("failed to get board: %w", err)
-	}
-	if board == nil {
-		return ErrBoardNotFound
-	}
-	if permission == "" || permission == models.PermissionRead {
-		return ErrUnauthorized
+		return err
 	}
 
 	item, err := s.boardItemRepo.GetByID(itemID)
@@ -557,6 +757,23 @@ func (s *BoardService) DeleteBoardItem(boardID, itemID, userID uuid.UUID) error
 	if item == nil || item.BoardID != boardID {
 		return ErrItemNotFound
 	}
+	if err := access.checkItem(item); err != nil {
+		return err
+	}
+	if isFrame(item) {
+		return s.deleteFrame(boardID, userID, item, deleteChildren, access)
+	}
+
+	// Capture related connections so the deletion can be undone as a whole
+	var changes []HistoryChange
+	if connections, err := s.connectionRepo.ListByBoard(boardID); err == nil {
+		for i := range connections {
+			if connections[i].FromItemID == itemID || connections[i].ToItemID == itemID {
+				changes = append(changes, connectionChange(&connections[i], nil))
+			}
+		}
+	}
+	changes = append(changes, itemChange(item, nil))
 
 	// Delete related connections first
 	if err := s.connectionRepo.DeleteByItem(itemID); err != nil {
@@ -566,36 +783,97 @@ func (s *BoardService) DeleteBoardItem(boardID, itemID, userID uuid.UUID) error
 	if err := s.boardItemRepo.Delete(itemID); err != nil {
 		return fmt.Errorf("failed to delete item: %w", err)
 	}
+	s.deleteAttachments([]uuid.UUID{itemID}, userID)
 
 	// Publish real-time update
-	s.publishBoardUpdate(boardID, "item_deleted", map[string]interface{}{"id": itemID})
+	s.publishLayerUpdate(boardID, item.LayerID, "item_deleted", map[string]interface{}{"id": itemID})
+	s.recordHistory(boardID, userID, "item_deleted", changes...)
 
 	return nil
 }
 
-// ListBoardItems retrieves all items for a board
-func (s *BoardService) ListBoardItems(boardID, userID uuid.UUID) ([]models.BoardItem, error) {
-	board, permission, err := s.boardRepo.GetByIDWithPermission(boardID, userID)
+// ListBoardItems retrieves the items of a board, optionally limited to a
+// viewport, filtered and sorted by type, tags and custom field values
+func (s *BoardService) ListBoardItems(boardID, userID uuid.UUID, query ItemQuery) ([]models.BoardItem, error) {
+	items, _, err := s.listBoardItems(boardID, userID, query)
+	return items, err
+}
+
+// listBoardItems lists the items the user can see, redacted above their
+// clearance, and returns their access to the board with them
+func (s *BoardService) listBoardItems(boardID, userID uuid.UUID, query ItemQuery) ([]models.BoardItem, *boardAccess, error) {
+	access, err := s.permissions.authorizeAccess(context.Background(), boardID, userID, models.PermissionRead)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get board: %w", err)
+		return nil, nil, err
 	}
-	if board == nil {
-		return nil, ErrBoardNotFound
+
+	var items []models.BoardItem
+	if query.BBox != "" {
+		box, err := parseBBox(query.BBox)
+		if err != nil {
+			return nil, nil, err
+		}
+		items, err = s.boardItemRepo.ListByBoardInBBox(boardID, box)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to list items: %w", err)
+		}
+	} else {
+		items, err = s.boardItemRepo.ListByBoard(boardID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to list items: %w", err)
+		}
 	}
-	if permission == "" {
-		return nil, ErrUnauthorized
+	// Redacted before filtering so that hidden values cannot be matched on
+	items = access.redactItems(access.filterItems(items))
+	if len(query.Tag) > 0 {
+		if items, err = s.itemsWithTags(boardID, userID, items, query.Tag); err != nil {
+			return nil, nil, err
+		}
 	}
-
-	items, err := s.boardItemRepo.ListByBoard(boardID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to list items: %w", err)
+	if query.empty() {
+		return items, access, nil
 	}
 
-	return items, nil
+	// Custom value filters and sorting use the board's field definitions
+	board := &models.Board{ID: boardID}
+	if len(query.Custom) > 0 || query.Sort != "" {
+		if board, err = s.getBoardRow(boardID); err != nil {
+			return nil, nil, err
+		}
+	}
+	items, err = filterItems(board, items, query)
+	return items, access, err
 }
 
-// Helper function to publish real-time updates
+// Helper function to publish real-time updates. Items and connections on
+// restricted layers are only published to the users who can see them, and
+// classified ones are redacted for users without the clearance.
 func (s *BoardService) publishBoardUpdate(boardID uuid.UUID, event string, data interface{}) {
+	switch v := data.(type) {
+	case *models.BoardItem:
+		if v.Classification.Rank() > 0 {
+			s.publishClassifiedItem(boardID, event, v)
+			return
+		}
+		s.publishLayerUpdate(boardID, v.LayerID, event, data)
+	case *models.BoardConnection:
+		s.publishConnectionUpdate(boardID, event, v)
+	default:
+		s.publish(boardChannel(boardID), boardID, event, data)
+	}
+}
+
+// boardChannel is the Redis channel of updates for everyone on a board
+func boardChannel(boardID uuid.UUID) string {
+	return fmt.Sprintf("board:%s", boardID)
+}
+
+// userChannel is the Redis channel of updates for one user on a board
+func userChannel(boardID, userID uuid.UUID) string {
+	return fmt.Sprintf("board:%s:user:%s", boardID, userID)
+}
+
+func (s *BoardService) publish(channel string, boardID uuid.UUID, event string, data interface{}) {
 	if s.redis == nil {
 		return
 	}
@@ -607,7 +885,6 @@ func (s *BoardService) publishBoardUpdate(boardID uuid.UUID, event string, data
 	}
 
 	updateJSON, _ := json.Marshal(update)
-	channel := fmt.Sprintf("board:%s", boardID)
 
 	result := s.redis.Publish(context.Background(), channel, updateJSON)
 	if err := result.Err(); err != nil {
@@ -620,42 +897,95 @@ func (s *BoardService) publishBoardUpdate(boardID uuid.UUID, event string, data
 
 // CreateConnectionRequest represents a request to create a connection
 type CreateConnectionRequest struct {
-	FromItemID uuid.UUID      `json:"from_item_id" binding:"required"`
-	ToItemID   uuid.UUID      `json:"to_item_id" binding:"required"`
-	Style      map[string]any `json:"style"`
+	FromItemID       uuid.UUID                  `json:"from_item_id" binding:"required"`
+	ToItemID         uuid.UUID                  `json:"to_item_id" binding:"required"`
+	Label            string                     `json:"label"`
+	Direction        models.ConnectionDirection `json:"direction"`         // none (default), forward or both
+	RelationshipType string                     `json:"relationship_type"` // e.g. "knows", "called", "paid", "was at"
+	Confidence       models.ConfidenceLevel     `json:"confidence"`        // low, medium, high or confirmed
+	Style            map[string]any             `json:"style"`
+	LayerID          *uuid.UUID                 `json:"layer_id"` // Layer to create the connection on; the base layer when omitted
 }
 
-// UpdateConnectionRequest represents a request to update a connection
+// UpdateConnectionRequest represents a request to update a connection.
+// Omitted fields are left unchanged; an empty string clears a field.
 type UpdateConnectionRequest struct {
-	Style map[string]any `json:"style"`
+	Label            *string                     `json:"label"`
+	Direction        *models.ConnectionDirection `json:"direction"`
+	RelationshipType *string                     `json:"relationship_type"`
+	Confidence       *models.ConfidenceLevel     `json:"confidence"`
+	Style            map[string]any              `json:"style"`
 }
 
-// ListBoardConnections returns all connections for a board
-func (s *BoardService) ListBoardConnections(boardID, userID uuid.UUID) ([]models.BoardConnection, error) {
-	board, permission, err := s.boardRepo.GetByIDWithPermission(boardID, userID)
+// ListBoardConnections returns the connections of a board that match the
+// query. Connections into collapsed frames are left out: GetBoard and the
+// viewport sum them up per frame, and listed one by one they would reveal
+// the frames' contents.
+func (s *BoardService) ListBoardConnections(boardID, userID uuid.UUID, query ConnectionQuery) ([]models.BoardConnection, error) {
+	connections, access, err := s.listBoardConnections(boardID, userID, query)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get board: %w", err)
+		return nil, err
 	}
-	if board == nil {
-		return nil, ErrBoardNotFound
+	hidden, err := s.collapsedFrames(boardID, access)
+	if err != nil {
+		return nil, err
 	}
-	if permission == "" {
-		return nil, ErrUnauthorized
+	if len(hidden) == 0 {
+		return connections, nil
+	}
+	shown := connections[:0:0]
+	for _, conn := range connections {
+		_, fromHidden := hidden[conn.FromItemID]
+		_, toHidden := hidden[conn.ToItemID]
+		if !fromHidden && !toHidden {
+			shown = append(shown, conn)
+		}
 	}
-	return s.connectionRepo.ListByBoard(boardID)
+	return shown, nil
+}
+
+// listBoardConnections returns every connection that matches the query and
+// that the user can see, collapsed frames or not
+func (s *BoardService) listBoardConnections(boardID, userID uuid.UUID, query ConnectionQuery) ([]models.BoardConnection, *boardAccess, error) {
+	filter, err := query.filter()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	access, err := s.permissions.authorizeAccess(context.Background(), boardID, userID, models.PermissionRead)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	connections, err := s.connectionRepo.ListByBoardFiltered(boardID, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := access.loadClassified(); err != nil {
+		return nil, nil, err
+	}
+	connections = access.redactConnections(access.filterConnections(connections))
+	if filter.Label != "" || len(filter.RelationshipTypes) > 0 {
+		// Redacted connections were matched on what they no longer show
+		matched := connections[:0:0]
+		for _, conn := range connections {
+			if !conn.Redacted {
+				matched = append(matched, conn)
+			}
+		}
+		connections = matched
+	}
+	return connections, access, nil
 }
 
 // CreateBoardConnection creates a new connection between two items
 func (s *BoardService) CreateBoardConnection(boardID, userID uuid.UUID, req CreateConnectionRequest) (*models.BoardConnection, error) {
-	board, permission, err := s.boardRepo.GetByIDWithPermission(boardID, userID)
+	access, err := s.permissions.authorizeAccess(context.Background(), boardID, userID, models.PermissionWrite)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get board: %w", err)
+		return nil, err
 	}
-	if board == nil {
-		return nil, ErrBoardNotFound
-	}
-	if permission == "" || permission == models.PermissionRead {
-		return nil, ErrUnauthorized
+	if err := access.checkLayer(req.LayerID); err != nil {
+		return nil, err
 	}
 
 	// Validate items belong to the same board
@@ -673,6 +1003,25 @@ func (s *BoardService) CreateBoardConnection(boardID, userID uuid.UUID, req Crea
 	if fromItem.ID == toItem.ID {
 		return nil, ErrInvalidInput
 	}
+	if !access.canSeeItem(fromItem) || !access.canSeeItem(toItem) {
+		return nil, ErrInvalidInput
+	}
+
+	label, err := validateLabel(req.Label)
+	if err != nil {
+		return nil, err
+	}
+	direction, err := validateDirection(req.Direction)
+	if err != nil {
+		return nil, err
+	}
+	relType, err := normalizeRelationshipType(req.RelationshipType)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateConfidence(req.Confidence); err != nil {
+		return nil, err
+	}
 
 	var styleJSON []byte
 	if req.Style != nil {
@@ -680,31 +1029,31 @@ func (s *BoardService) CreateBoardConnection(boardID, userID uuid.UUID, req Crea
 	}
 
 	conn := &models.BoardConnection{
-		BoardID:    boardID,
-		FromItemID: req.FromItemID,
-		ToItemID:   req.ToItemID,
-		Style:      string(styleJSON),
-		CreatedBy:  userID,
+		BoardID:          boardID,
+		FromItemID:       req.FromItemID,
+		ToItemID:         req.ToItemID,
+		Label:            label,
+		Direction:        direction,
+		RelationshipType: relType,
+		Confidence:       req.Confidence,
+		Style:            string(styleJSON),
+		LayerID:          req.LayerID,
+		CreatedBy:        userID,
 	}
 	if err := s.connectionRepo.Create(conn); err != nil {
 		return nil, fmt.Errorf("failed to create connection: %w", err)
 	}
 
 	s.publishBoardUpdate(boardID, "connection_created", conn)
+	s.recordHistory(boardID, userID, "connection_created", connectionChange(nil, conn))
 	return conn, nil
 }
 
-// UpdateBoardConnection updates connection style
+// UpdateBoardConnection updates a connection's attributes and style
 func (s *BoardService) UpdateBoardConnection(boardID, connectionID, userID uuid.UUID, req UpdateConnectionRequest) (*models.BoardConnection, error) {
-	board, permission, err := s.boardRepo.GetByIDWithPermission(boardID, userID)
+	access, err := s.permissions.authorizeAccess(context.Background(), boardID, userID, models.PermissionWrite)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get board: %w", err)
-	}
-	if board == nil {
-		return nil, ErrBoardNotFound
-	}
-	if permission == "" || permission == models.PermissionRead {
-		return nil, ErrUnauthorized
+		return nil, err
 	}
 
 	conn, err := s.connectionRepo.GetByID(connectionID)
@@ -714,7 +1063,38 @@ func (s *BoardService) UpdateBoardConnection(boardID, connectionID, userID uuid.
 	if conn == nil || conn.BoardID != boardID {
 		return nil, ErrConnectionNotFound
 	}
+	if err := access.checkConnection(conn); err != nil {
+		return nil, err
+	}
+	before := stripConnection(conn)
 
+	if req.Label != nil {
+		label, err := validateLabel(*req.Label)
+		if err != nil {
+			return nil, err
+		}
+		conn.Label = label
+	}
+	if req.Direction != nil {
+		direction, err := validateDirection(*req.Direction)
+		if err != nil {
+			return nil, err
+		}
+		conn.Direction = direction
+	}
+	if req.RelationshipType != nil {
+		relType, err := normalizeRelationshipType(*req.RelationshipType)
+		if err != nil {
+			return nil, err
+		}
+		conn.RelationshipType = relType
+	}
+	if req.Confidence != nil {
+		if err := validateConfidence(*req.Confidence); err != nil {
+			return nil, err
+		}
+		conn.Confidence = *req.Confidence
+	}
 	if req.Style != nil {
 		styleJSON, _ := json.Marshal(req.Style)
 		conn.Style = string(styleJSON)
@@ -725,20 +1105,15 @@ func (s *BoardService) UpdateBoardConnection(boardID, connectionID, userID uuid.
 	}
 
 	s.publishBoardUpdate(boardID, "connection_updated", conn)
+	s.recordHistory(boardID, userID, "connection_updated", connectionChange(before, conn))
 	return conn, nil
 }
 
 // DeleteBoardConnection deletes a connection
 func (s *BoardService) DeleteBoardConnection(boardID, connectionID, userID uuid.UUID) error {
-	board, permission, err := s.boardRepo.GetByIDWithPermission(boardID, userID)
+	access, err := s.permissions.authorizeAccess(context.Background(), boardID, userID, models.PermissionWrite)
 	if err != nil {
-		return fmt.Errorf("failed to get board: %w", err)
-	}
-	if board == nil {
-		return ErrBoardNotFound
-	}
-	if permission == "" || permission == models.PermissionRead {
-		return ErrUnauthorized
+		return err
 	}
 
 	conn, err := s.connectionRepo.GetByID(connectionID)
@@ -748,11 +1123,15 @@ func (s *BoardService) DeleteBoardConnection(boardID, connectionID, userID uuid.
 	if conn == nil || conn.BoardID != boardID {
 		return ErrConnectionNotFound
 	}
+	if err := access.checkConnection(conn); err != nil {
+		return err
+	}
 
 	if err := s.connectionRepo.Delete(connectionID); err != nil {
 		return fmt.Errorf("failed to delete connection: %w", err)
 	}
 
-	s.publishBoardUpdate(boardID, "connection_deleted", map[string]interface{}{"id": connectionID})
+	s.publishLayerUpdate(boardID, conn.LayerID, "connection_deleted", map[string]interface{}{"id": connectionID})
+	s.recordHistory(boardID, userID, "connection_deleted", connectionChange(conn, nil))
 	return nil
 }
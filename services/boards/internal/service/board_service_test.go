@@ -29,6 +29,14 @@ func (m *MockBoardRepository) GetByID(id uuid.UUID) (*models.Board, error) {
 	return args.Get(0).(*models.Board), args.Error(1)
 }
 
+func (m *MockBoardRepository) GetRowByID(id uuid.UUID) (*models.Board, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Board), args.Error(1)
+}
+
 func (m *MockBoardRepository) GetByIDWithPermission(boardID, userID uuid.UUID) (*models.Board, models.PermissionLevel, error) {
 	args := m.Called(boardID, userID)
 	if args.Get(0) == nil {
@@ -37,11 +45,29 @@ func (m *MockBoardRepository) GetByIDWithPermission(boardID, userID uuid.UUID) (
 	return args.Get(0).(*models.Board), args.Get(1).(models.PermissionLevel), args.Error(2)
 }
 
+func (m *MockBoardRepository) GetPermission(boardID, userID uuid.UUID) (bool, models.PermissionLevel, error) {
+	args := m.Called(boardID, userID)
+	return args.Bool(0), args.Get(1).(models.PermissionLevel), args.Error(2)
+}
+
+func (m *MockBoardRepository) GetByIDWithContents(boardID, userID uuid.UUID) (*models.Board, models.PermissionLevel, error) {
+	args := m.Called(boardID, userID)
+	if args.Get(0) == nil {
+		return nil, "", args.Error(2)
+	}
+	return args.Get(0).(*models.Board), args.Get(1).(models.PermissionLevel), args.Error(2)
+}
+
 func (m *MockBoardRepository) ListByUser(userID uuid.UUID, offset, limit int) ([]models.Board, int64, error) {
 	args := m.Called(userID, offset, limit)
 	return args.Get(0).([]models.Board), args.Get(1).(int64), args.Error(2)
 }
 
+func (m *MockBoardRepository) ListByUserAndTags(userID uuid.UUID, tagIDs []uuid.UUID, offset, limit int) ([]models.Board, int64, error) {
+	args := m.Called(userID, tagIDs, offset, limit)
+	return args.Get(0).([]models.Board), args.Get(1).(int64), args.Error(2)
+}
+
 func (m *MockBoardRepository) ListPublic(offset, limit int) ([]models.Board, int64, error) {
 	args := m.Called(offset, limit)
 	return args.Get(0).([]models.Board), args.Get(1).(int64), args.Error(2)
@@ -113,11 +139,31 @@ func (m *MockBoardItemRepository) ListByBoard(boardID uuid.UUID) ([]models.Board
 	return args.Get(0).([]models.BoardItem), args.Error(1)
 }
 
+func (m *MockBoardItemRepository) ListByBoardInBBox(boardID uuid.UUID, box models.BBox) ([]models.BoardItem, error) {
+	args := m.Called(boardID, box)
+	return args.Get(0).([]models.BoardItem), args.Error(1)
+}
+
+func (m *MockBoardItemRepository) ListByIDs(boardID uuid.UUID, ids []uuid.UUID) ([]models.BoardItem, error) {
+	args := m.Called(boardID, ids)
+	return args.Get(0).([]models.BoardItem), args.Error(1)
+}
+
 func (m *MockBoardItemRepository) Update(item *models.BoardItem) error {
 	args := m.Called(item)
 	return args.Error(0)
 }
 
+func (m *MockBoardItemRepository) UpdatePositions(items []models.BoardItem) error {
+	args := m.Called(items)
+	return args.Error(0)
+}
+
+func (m *MockBoardItemRepository) UpdateEvidenceMetadata(id uuid.UUID, metadata *models.EvidenceMetadata) error {
+	args := m.Called(id, metadata)
+	return args.Error(0)
+}
+
 func (m *MockBoardItemRepository) Delete(id uuid.UUID) error {
 	args := m.Called(id)
 	return args.Error(0)
@@ -128,6 +174,51 @@ func (m *MockBoardItemRepository) DeleteByBoard(boardID uuid.UUID) error {
 	return args.Error(0)
 }
 
+func (m *MockBoardItemRepository) ListDescendants(frameID uuid.UUID) ([]models.BoardItem, error) {
+	args := m.Called(frameID)
+	return args.Get(0).([]models.BoardItem), args.Error(1)
+}
+
+func (m *MockBoardItemRepository) ListFrameMembership(boardID uuid.UUID) ([]models.BoardItem, error) {
+	args := m.Called(boardID)
+	return args.Get(0).([]models.BoardItem), args.Error(1)
+}
+
+func (m *MockBoardItemRepository) ListIDsByLayers(boardID uuid.UUID, layerIDs []uuid.UUID) ([]uuid.UUID, error) {
+	args := m.Called(boardID, layerIDs)
+	return args.Get(0).([]uuid.UUID), args.Error(1)
+}
+
+func (m *MockBoardItemRepository) ListClassified(boardID uuid.UUID) ([]models.BoardItem, error) {
+	args := m.Called(boardID)
+	return args.Get(0).([]models.BoardItem), args.Error(1)
+}
+
+func (m *MockBoardItemRepository) SetParent(ids []uuid.UUID, parentID *uuid.UUID) error {
+	args := m.Called(ids, parentID)
+	return args.Error(0)
+}
+
+func (m *MockBoardItemRepository) SetFrameMembers(frameID uuid.UUID, added, removed []uuid.UUID, parentID *uuid.UUID) error {
+	args := m.Called(frameID, added, removed, parentID)
+	return args.Error(0)
+}
+
+func (m *MockBoardItemRepository) SetLayer(ids []uuid.UUID, layerID *uuid.UUID) error {
+	args := m.Called(ids, layerID)
+	return args.Error(0)
+}
+
+func (m *MockBoardItemRepository) UpdateWithPositions(item *models.BoardItem, moved []models.BoardItem) error {
+	args := m.Called(item, moved)
+	return args.Error(0)
+}
+
+func (m *MockBoardItemRepository) DeleteItems(ids []uuid.UUID) error {
+	args := m.Called(ids)
+	return args.Error(0)
+}
+
 // MockBoardConnectionRepository is a mock implementation of BoardConnectionRepository
 type MockBoardConnectionRepository struct {
 	mock.Mock
@@ -151,6 +242,16 @@ func (m *MockBoardConnectionRepository) ListByBoard(boardID uuid.UUID) ([]models
 	return args.Get(0).([]models.BoardConnection), args.Error(1)
 }
 
+func (m *MockBoardConnectionRepository) ListByBoardFiltered(boardID uuid.UUID, filter models.ConnectionFilter) ([]models.BoardConnection, error) {
+	args := m.Called(boardID, filter)
+	return args.Get(0).([]models.BoardConnection), args.Error(1)
+}
+
+func (m *MockBoardConnectionRepository) ListByBoardInBBox(boardID uuid.UUID, box models.BBox) ([]models.BoardConnection, error) {
+	args := m.Called(boardID, box)
+	return args.Get(0).([]models.BoardConnection), args.Error(1)
+}
+
 func (m *MockBoardConnectionRepository) Update(connection *models.BoardConnection) error {
 	args := m.Called(connection)
 	return args.Error(0)
@@ -171,6 +272,65 @@ func (m *MockBoardConnectionRepository) DeleteByItem(itemID uuid.UUID) error {
 	return args.Error(0)
 }
 
+func (m *MockBoardConnectionRepository) ListByItems(boardID uuid.UUID, itemIDs []uuid.UUID) ([]models.BoardConnection, error) {
+	args := m.Called(boardID, itemIDs)
+	return args.Get(0).([]models.BoardConnection), args.Error(1)
+}
+
+func (m *MockBoardConnectionRepository) SetLayer(ids []uuid.UUID, layerID *uuid.UUID) error {
+	args := m.Called(ids, layerID)
+	return args.Error(0)
+}
+
+// serviceFixture is a board service on mock repositories, for a user with a
+// given permission and clearance on a board. The mocks hand out the board
+// itself, so contents added to it later show up too.
+type serviceFixture struct {
+	svc       *BoardService
+	boardRepo *MockBoardRepository
+	userRepo  *MockBoardUserRepository
+	itemRepo  *MockBoardItemRepository
+	connRepo  *MockBoardConnectionRepository
+	layerRepo *MockLayerRepository // Only set when the board has layers
+	userID    uuid.UUID
+	board     *models.Board
+}
+
+// newServiceFixture sets up the lookups every board operation makes: the
+// user's permission and clearance, the board with its contents, its layers
+// and its classified items. Tests add the lookups of the items they change.
+func newServiceFixture(board *models.Board, permission models.PermissionLevel, clearance models.ClassificationLevel, layers ...models.Layer) *serviceFixture {
+	f := &serviceFixture{
+		boardRepo: new(MockBoardRepository),
+		userRepo:  new(MockBoardUserRepository),
+		itemRepo:  new(MockBoardItemRepository),
+		connRepo:  new(MockBoardConnectionRepository),
+		userID:    uuid.New(),
+		board:     board,
+	}
+	f.boardRepo.On("GetPermission", board.ID, f.userID).Return(true, permission, nil)
+	f.boardRepo.On("GetRowByID", board.ID).Return(board, nil)
+	f.boardRepo.On("GetByIDWithContents", board.ID, f.userID).Return(board, permission, nil)
+	f.userRepo.On("GetByBoardAndUser", board.ID, f.userID).Return(&models.BoardUser{BoardID: board.ID, UserID: f.userID, Permission: permission, Clearance: clearance}, nil)
+
+	classified := []models.BoardItem{}
+	for _, item := range board.Items {
+		if !models.ClassificationUnclassified.Covers(item.Classification) {
+			classified = append(classified, models.BoardItem{ID: item.ID, Classification: item.Classification})
+		}
+	}
+	f.itemRepo.On("ListClassified", board.ID).Return(classified, nil)
+
+	var layerRepo LayerRepositoryInterface
+	if len(layers) > 0 {
+		f.layerRepo = new(MockLayerRepository)
+		f.layerRepo.On("ListByBoard", board.ID).Return(layers, nil)
+		layerRepo = f.layerRepo
+	}
+	f.svc = NewBoardService(f.boardRepo, f.userRepo, f.itemRepo, f.connRepo, nil, nil, layerRepo, nil)
+	return f
+}
+
 func TestBoardService_CreateBoard(t *testing.T) {
 	userID := uuid.New()
 
@@ -223,7 +383,7 @@ func TestBoardService_CreateBoard(t *testing.T) {
 			mockBoardItemRepo := new(MockBoardItemRepository)
 			mockConnectionRepo := new(MockBoardConnectionRepository)
 
-			service := NewBoardService(mockBoardRepo, mockBoardUserRepo, mockBoardItemRepo, mockConnectionRepo, nil)
+			service := NewBoardService(mockBoardRepo, mockBoardUserRepo, mockBoardItemRepo, mockConnectionRepo, nil, nil, nil, nil)
 
 			// Setup mocks
 			mockBoardRepo.On("Create", mock.AnythingOfType("*models.Board")).Return(tt.createErr)
@@ -305,10 +465,11 @@ func TestBoardService_GetBoard(t *testing.T) {
 			mockBoardItemRepo := new(MockBoardItemRepository)
 			mockConnectionRepo := new(MockBoardConnectionRepository)
 
-			service := NewBoardService(mockBoardRepo, mockBoardUserRepo, mockBoardItemRepo, mockConnectionRepo, nil)
+			service := NewBoardService(mockBoardRepo, mockBoardUserRepo, mockBoardItemRepo, mockConnectionRepo, nil, nil, nil, nil)
 
 			// Setup mocks
-			mockBoardRepo.On("GetByIDWithPermission", tt.boardID, tt.userID).Return(tt.board, tt.permission, tt.repoErr)
+			mockBoardRepo.On("GetByIDWithContents", tt.boardID, tt.userID).Return(tt.board, tt.permission, tt.repoErr)
+			mockBoardItemRepo.On("ListFrameMembership", tt.boardID).Return([]models.BoardItem{}, nil)
 
 			// Call method
 			result, err := service.GetBoard(tt.boardID, tt.userID)
@@ -378,7 +539,7 @@ func TestBoardService_GetPublicBoard(t *testing.T) {
 			mockBoardItemRepo := new(MockBoardItemRepository)
 			mockConnectionRepo := new(MockBoardConnectionRepository)
 
-			service := NewBoardService(mockBoardRepo, mockBoardUserRepo, mockBoardItemRepo, mockConnectionRepo, nil)
+			service := NewBoardService(mockBoardRepo, mockBoardUserRepo, mockBoardItemRepo, mockConnectionRepo, nil, nil, nil, nil)
 
 			// Setup mocks
 			mockBoardRepo.On("GetByID", tt.boardID).Return(tt.board, tt.repoErr)
@@ -475,11 +636,12 @@ func TestBoardService_UpdateBoard(t *testing.T) {
 			mockBoardItemRepo := new(MockBoardItemRepository)
 			mockConnectionRepo := new(MockBoardConnectionRepository)
 
-			service := NewBoardService(mockBoardRepo, mockBoardUserRepo, mockBoardItemRepo, mockConnectionRepo, nil)
+			service := NewBoardService(mockBoardRepo, mockBoardUserRepo, mockBoardItemRepo, mockConnectionRepo, nil, nil, nil, nil)
 
 			// Setup mocks
-			mockBoardRepo.On("GetByIDWithPermission", tt.boardID, tt.userID).Return(tt.board, tt.permission, tt.repoErr)
+			mockBoardRepo.On("GetPermission", tt.boardID, tt.userID).Return(tt.board != nil, tt.permission, tt.repoErr)
 			if tt.board != nil && tt.permission == models.PermissionAdmin {
+				mockBoardRepo.On("GetRowByID", tt.boardID).Return(tt.board, nil)
 				mockBoardRepo.On("Update", mock.AnythingOfType("*models.Board")).Return(tt.updateErr)
 			}
 
@@ -583,10 +745,10 @@ func TestBoardService_DeleteBoard(t *testing.T) {
 			mockBoardItemRepo := new(MockBoardItemRepository)
 			mockConnectionRepo := new(MockBoardConnectionRepository)
 
-			service := NewBoardService(mockBoardRepo, mockBoardUserRepo, mockBoardItemRepo, mockConnectionRepo, nil)
+			service := NewBoardService(mockBoardRepo, mockBoardUserRepo, mockBoardItemRepo, mockConnectionRepo, nil, nil, nil, nil)
 
 			// Setup mocks
-			mockBoardRepo.On("GetByIDWithPermission", tt.boardID, tt.userID).Return(tt.board, tt.permission, tt.repoErr)
+			mockBoardRepo.On("GetPermission", tt.boardID, tt.userID).Return(tt.board != nil, tt.permission, tt.repoErr)
 			if tt.board != nil && tt.permission == models.PermissionAdmin {
 				mockConnectionRepo.On("DeleteByBoard", tt.boardID).Return(tt.connectionErr)
 				if tt.connectionErr == nil {
@@ -622,6 +784,32 @@ func TestBoardService_DeleteBoard(t *testing.T) {
 	}
 }
 
+func TestBoardService_DeleteBoardRemovesBoardData(t *testing.T) {
+	boardID := uuid.New()
+	userID := uuid.New()
+	mockBoardRepo := new(MockBoardRepository)
+	mockBoardItemRepo := new(MockBoardItemRepository)
+	mockConnectionRepo := new(MockBoardConnectionRepository)
+	mockTagRepo := new(MockTagRepository)
+	mockLayerRepo := new(MockLayerRepository)
+	attachments := &recordingItemAttachments{}
+	service := NewBoardService(mockBoardRepo, new(MockBoardUserRepository), mockBoardItemRepo, mockConnectionRepo, nil, mockTagRepo, mockLayerRepo, nil)
+	service.SetAttachments(attachments)
+
+	mockBoardRepo.On("GetPermission", boardID, userID).Return(true, models.PermissionAdmin, nil)
+	mockTagRepo.On("DeleteByBoard", boardID).Return(nil)
+	mockConnectionRepo.On("DeleteByBoard", boardID).Return(nil)
+	mockBoardItemRepo.On("DeleteByBoard", boardID).Return(nil)
+	mockLayerRepo.On("DeleteByBoard", boardID).Return(nil)
+	mockBoardRepo.On("Delete", boardID).Return(nil)
+
+	assert.NoError(t, service.DeleteBoard(boardID, userID))
+	assert.Equal(t, []uuid.UUID{boardID}, attachments.deletedBoards)
+	mockTagRepo.AssertExpectations(t)
+	mockLayerRepo.AssertExpectations(t)
+	mockBoardRepo.AssertExpectations(t)
+}
+
 func TestBoardService_ShareBoard(t *testing.T) {
 	boardID := uuid.New()
 	ownerID := uuid.New()
@@ -710,10 +898,10 @@ func TestBoardService_ShareBoard(t *testing.T) {
 			mockBoardItemRepo := new(MockBoardItemRepository)
 			mockConnectionRepo := new(MockBoardConnectionRepository)
 
-			service := NewBoardService(mockBoardRepo, mockBoardUserRepo, mockBoardItemRepo, mockConnectionRepo, nil)
+			service := NewBoardService(mockBoardRepo, mockBoardUserRepo, mockBoardItemRepo, mockConnectionRepo, nil, nil, nil, nil)
 
 			// Setup mocks
-			mockBoardRepo.On("GetByIDWithPermission", tt.boardID, tt.ownerID).Return(tt.board, tt.permission, tt.repoErr)
+			mockBoardRepo.On("GetPermission", tt.boardID, tt.ownerID).Return(tt.board != nil, tt.permission, tt.repoErr)
 			if tt.board != nil && tt.permission == models.PermissionAdmin {
 				mockBoardUserRepo.On("GetByBoardAndUser", tt.boardID, tt.request.UserID).Return(tt.existingUser, tt.existingErr)
 				if tt.existingUser != nil {
@@ -820,11 +1008,12 @@ func TestBoardService_CreateBoardItem(t *testing.T) {
 			mockBoardItemRepo := new(MockBoardItemRepository)
 			mockConnectionRepo := new(MockBoardConnectionRepository)
 
-			service := NewBoardService(mockBoardRepo, mockBoardUserRepo, mockBoardItemRepo, mockConnectionRepo, nil)
+			service := NewBoardService(mockBoardRepo, mockBoardUserRepo, mockBoardItemRepo, mockConnectionRepo, nil, nil, nil, nil)
 
 			// Setup mocks
-			mockBoardRepo.On("GetByIDWithPermission", tt.boardID, tt.userID).Return(tt.board, tt.permission, tt.repoErr)
+			mockBoardRepo.On("GetPermission", tt.boardID, tt.userID).Return(tt.board != nil, tt.permission, tt.repoErr)
 			if tt.board != nil && tt.permission != "" && tt.permission != models.PermissionRead {
+				mockBoardRepo.On("GetRowByID", tt.boardID).Return(tt.board, nil)
 				mockBoardItemRepo.On("Create", mock.AnythingOfType("*models.BoardItem")).Return(tt.createErr)
 			}
 
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+)
+
+// Default item appearance, matching what the frontend creates
+const (
+	postItColor       = "#ffeb3b"
+	suspectCardColor  = "#f5f5f5"
+	postItSize        = 200
+	suspectCardWidth  = 280
+	suspectCardHeight = 400
+)
+
+// suspectCardPlaceholder is the content of an unfilled suspect card
+const suspectCardPlaceholder = "Suspect Name:\nAlias:\nDate of birth:\nLast seen:\nNotes:"
+
+// builtinTemplateID derives a stable ID for a built-in template from its slug
+func builtinTemplateID(slug string) uuid.UUID {
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte("evidence-wall:template:"+slug))
+}
+
+func itemStyle(color, variant string) json.RawMessage {
+	data, _ := json.Marshal(map[string]interface{}{
+		"color":    color,
+		"metadata": map[string]interface{}{"variant": variant},
+	})
+	return data
+}
+
+func postItItem(key string, x, y float64, content string, placeholder bool) models.TemplateItem {
+	return models.TemplateItem{
+		Key: key, Type: string(models.ItemTypePostIt),
+		X: x, Y: y, Width: postItSize, Height: postItSize, ZIndex: 1,
+		Content: content, Style: itemStyle(postItColor, string(models.ItemTypePostIt)),
+		Placeholder: placeholder,
+	}
+}
+
+func suspectCardItem(key string, x, y float64) models.TemplateItem {
+	return models.TemplateItem{
+		Key: key, Type: string(models.ItemTypeSuspectCard),
+		X: x, Y: y, Width: suspectCardWidth, Height: suspectCardHeight, ZIndex: 1,
+		Content: suspectCardPlaceholder, Style: itemStyle(suspectCardColor, string(models.ItemTypeSuspectCard)),
+		Placeholder: true,
+	}
+}
+
+// builtinTemplates returns the templates that ship with the service
+func builtinTemplates() []models.BoardTemplate {
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	builtin := func(slug, name, description string, content models.TemplateContent) models.BoardTemplate {
+		return models.BoardTemplate{
+			ID:          builtinTemplateID(slug),
+			Name:        name,
+			Description: description,
+			Scope:       models.TemplateScopeBuiltin,
+			Content:     content,
+			CreatedAt:   created,
+			UpdatedAt:   created,
+		}
+	}
+
+	// Case timeline: a left-to-right chain of events
+	timeline := models.TemplateContent{}
+	timeline.Items = append(timeline.Items, postItItem("title", 100, 100, "Case timeline", false))
+	for i := 1; i <= 5; i++ {
+		key := fmt.Sprintf("event-%d", i)
+		timeline.Items = append(timeline.Items, postItItem(key, float64(100+(i-1)*260), 400, "Date:\nEvent:", true))
+		if i > 1 {
+			timeline.Connections = append(timeline.Connections, models.TemplateConnection{
+				From:      fmt.Sprintf("event-%d", i-1),
+				To:        key,
+				Direction: models.DirectionForward,
+				Label:     "then",
+			})
+		}
+	}
+
+	// Suspect matrix: suspects as rows, motive/means/opportunity as columns
+	matrix := models.TemplateContent{}
+	columns := []string{"Motive", "Means", "Opportunity"}
+	for c, column := range columns {
+		matrix.Items = append(matrix.Items, postItItem(fmt.Sprintf("header-%d", c), float64(440+c*260), 100, column, false))
+	}
+	for r := 0; r < 3; r++ {
+		y := float64(360 + r*460)
+		suspect := fmt.Sprintf("suspect-%d", r)
+		matrix.Items = append(matrix.Items, suspectCardItem(suspect, 100, y))
+		for c := range columns {
+			cell := fmt.Sprintf("cell-%d-%d", r, c)
+			matrix.Items = append(matrix.Items, postItItem(cell, float64(440+c*260), y+100, "", true))
+			matrix.Connections = append(matrix.Connections, models.TemplateConnection{From: suspect, To: cell})
+		}
+	}
+
+	// Link analysis: a subject surrounded by associated entities
+	links := models.TemplateContent{}
+	links.Items = append(links.Items, suspectCardItem("subject", 560, 400))
+	associates := []struct {
+		key, label string
+		x, y       float64
+	}{
+		{"associate", "Associate:", 160, 100},
+		{"location", "Location:", 1060, 100},
+		{"phone", "Phone number:", 160, 900},
+		{"vehicle", "Vehicle:", 1060, 900},
+	}
+	for _, a := range associates {
+		links.Items = append(links.Items, postItItem(a.key, a.x, a.y, a.label, true))
+		links.Connections = append(links.Connections, models.TemplateConnection{From: "subject", To: a.key})
+	}
+
+	return []models.BoardTemplate{
+		builtin("case-timeline", "Case timeline", "Chronological chain of events for a case", timeline),
+		builtin("suspect-matrix", "Suspect matrix", "Compare suspects by motive, means and opportunity", matrix),
+		builtin("link-analysis", "Link analysis", "A subject with associates, locations, phones and vehicles", links),
+	}
+}
@@ -0,0 +1,306 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"math"
+	"sort"
+	"strings"
+
+	"evidence-wall/boards-service/internal/itemtype"
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+)
+
+// JSON Canvas (https://jsoncanvas.org, used by Obsidian .canvas files) types.
+// Only the parts of the 1.0 spec that map onto boards are modelled.
+const (
+	CanvasNodeText  = "text"
+	CanvasNodeFile  = "file"
+	CanvasNodeLink  = "link"
+	CanvasNodeGroup = "group"
+)
+
+// Canvas is a JSON Canvas document
+type Canvas struct {
+	Nodes []CanvasNode `json:"nodes"`
+	Edges []CanvasEdge `json:"edges"`
+}
+
+// CanvasNode is a JSON Canvas node. EvidenceWall is an extension field that
+// carries the item type and full style so a board survives a round trip;
+// other canvas tools ignore it.
+type CanvasNode struct {
+	ID     string `json:"id"`
+	Type   string `json:"type"`
+	X      int    `json:"x"`
+	Y      int    `json:"y"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Color  string `json:"color,omitempty"`
+	Text   string `json:"text,omitempty"`
+	File   string `json:"file,omitempty"`
+	URL    string `json:"url,omitempty"`
+	Label  string `json:"label,omitempty"`
+
+	EvidenceWall *CanvasItemExtension `json:"evidencewall,omitempty"`
+}
+
+// CanvasItemExtension holds board item fields that JSON Canvas has no place for
+type CanvasItemExtension struct {
+	Type     string          `json:"type"`
+	Rotation float64         `json:"rotation,omitempty"`
+	Style    json.RawMessage `json:"style,omitempty"`
+	Fields   json.RawMessage `json:"fields,omitempty"`
+}
+
+// CanvasEdge is a JSON Canvas edge. Its ends carry the connection direction;
+// EvidenceWall carries the connection fields JSON Canvas has no place for.
+type CanvasEdge struct {
+	ID       string `json:"id"`
+	FromNode string `json:"fromNode"`
+	FromSide string `json:"fromSide,omitempty"`
+	FromEnd  string `json:"fromEnd,omitempty"`
+	ToNode   string `json:"toNode"`
+	ToSide   string `json:"toSide,omitempty"`
+	ToEnd    string `json:"toEnd,omitempty"` // The spec default is "arrow"
+	Color    string `json:"color,omitempty"`
+	Label    string `json:"label,omitempty"`
+
+	EvidenceWall *CanvasEdgeExtension `json:"evidencewall,omitempty"`
+}
+
+// CanvasEdgeExtension holds board connection fields that JSON Canvas has no place for
+type CanvasEdgeExtension struct {
+	RelationshipType string                 `json:"relationship_type,omitempty"`
+	Confidence       models.ConfidenceLevel `json:"confidence,omitempty"`
+}
+
+const (
+	canvasEndNone  = "none"
+	canvasEndArrow = "arrow"
+)
+
+// canvasPresetColors maps the JSON Canvas preset colors to hex values
+var canvasPresetColors = map[string]string{
+	"1": "#ff5252", // red
+	"2": "#ffa726", // orange
+	"3": "#ffeb3b", // yellow
+	"4": "#66bb6a", // green
+	"5": "#26c6da", // cyan
+	"6": "#ab47bc", // purple
+}
+
+// ExportCanvas converts a board to a JSON Canvas document. Items become text
+// nodes (in z-order) and connections become edges. Read access is sufficient.
+func (s *BoardService) ExportCanvas(boardID, userID uuid.UUID) (*Canvas, error) {
+	archive, err := s.ExportBoard(boardID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := append([]ArchiveItem(nil), archive.Items...)
+	sort.SliceStable(items, func(i, j int) bool { return items[i].ZIndex < items[j].ZIndex })
+
+	canvas := &Canvas{
+		Nodes: make([]CanvasNode, 0, len(items)),
+		Edges: make([]CanvasEdge, 0, len(archive.Connections)),
+	}
+	for _, item := range items {
+		canvas.Nodes = append(canvas.Nodes, CanvasNode{
+			ID:     item.ID.String(),
+			Type:   CanvasNodeText,
+			X:      int(math.Round(item.X)),
+			Y:      int(math.Round(item.Y)),
+			Width:  int(math.Round(item.Width)),
+			Height: int(math.Round(item.Height)),
+			Color:  styleString(item.Style, "color"),
+			Text:   html.UnescapeString(item.Content),
+			EvidenceWall: &CanvasItemExtension{
+				Type:     item.Type,
+				Rotation: item.Rotation,
+				Style:    item.Style,
+				Fields:   item.Fields,
+			},
+		})
+	}
+	for _, conn := range archive.Connections {
+		edge := CanvasEdge{
+			ID:       conn.ID.String(),
+			FromNode: conn.FromItemID.String(),
+			ToNode:   conn.ToItemID.String(),
+			FromEnd:  canvasEndNone,
+			ToEnd:    canvasEndNone,
+			Color:    styleString(conn.Style, "color"),
+			Label:    connectionLabel(conn.Label, conn.Style),
+		}
+		switch conn.Direction {
+		case models.DirectionForward:
+			edge.ToEnd = canvasEndArrow
+		case models.DirectionBoth:
+			edge.FromEnd, edge.ToEnd = canvasEndArrow, canvasEndArrow
+		}
+		if conn.RelationshipType != "" || conn.Confidence != "" {
+			edge.EvidenceWall = &CanvasEdgeExtension{RelationshipType: conn.RelationshipType, Confidence: conn.Confidence}
+		}
+		canvas.Edges = append(canvas.Edges, edge)
+	}
+
+	return canvas, nil
+}
+
+// ImportCanvas creates a new board owned by the caller from a JSON Canvas
+// document. Text, file and link nodes become post-its (or the item type
+// recorded by ExportCanvas); group nodes are skipped along with edges that
+// reference nodes which were not imported.
+func (s *BoardService) ImportCanvas(userID uuid.UUID, canvas *Canvas, opts ImportBoardOptions) (*models.Board, error) {
+	if canvas == nil {
+		return nil, ErrInvalidArchive
+	}
+	if opts.Title == "" {
+		opts.Title = "Imported canvas"
+	}
+
+	archive := &BoardArchive{
+		Format:  BoardArchiveFormat,
+		Version: BoardArchiveVersion,
+		Board:   ArchiveBoard{Visibility: models.VisibilityPrivate},
+	}
+
+	ids := make(map[string]uuid.UUID, len(canvas.Nodes))
+	for i, node := range canvas.Nodes {
+		var text string
+		switch node.Type {
+		case CanvasNodeText:
+			text = node.Text
+		case CanvasNodeFile:
+			text = node.File
+		case CanvasNodeLink:
+			text = node.URL
+		default:
+			continue
+		}
+		if node.ID == "" {
+			return nil, fmt.Errorf("%w: node %d has no id", ErrInvalidArchive, i)
+		}
+		if _, dup := ids[node.ID]; dup {
+			return nil, fmt.Errorf("%w: duplicate node id %q", ErrInvalidArchive, node.ID)
+		}
+
+		// Canvas text is plain markdown; sanitize it into the stored form
+		content, err := validateContent(text)
+		if err != nil {
+			return nil, fmt.Errorf("%w: node %q: %v", ErrInvalidArchive, node.ID, err)
+		}
+
+		item := ArchiveItem{
+			ID:      uuid.New(),
+			Type:    string(models.ItemTypePostIt),
+			X:       float64(node.X),
+			Y:       float64(node.Y),
+			Width:   math.Max(float64(node.Width), 10),
+			Height:  math.Max(float64(node.Height), 10),
+			ZIndex:  i + 1,
+			Content: content,
+		}
+		if ext := node.EvidenceWall; ext != nil {
+			if _, ok := itemtype.Lookup(ext.Type); ok {
+				item.Type = ext.Type
+				item.Fields = ext.Fields
+			}
+		}
+		item.Rotation, item.Style = canvasItemStyle(node, item.Type)
+
+		ids[node.ID] = item.ID
+		archive.Items = append(archive.Items, item)
+	}
+
+	for _, edge := range canvas.Edges {
+		from, okFrom := ids[edge.FromNode]
+		to, okTo := ids[edge.ToNode]
+		if !okFrom || !okTo || from == to {
+			continue
+		}
+		label, err := validateLabel(edge.Label)
+		if err != nil {
+			return nil, fmt.Errorf("%w: edge %q: %v", ErrInvalidArchive, edge.ID, err)
+		}
+		conn := ArchiveConnection{FromItemID: from, ToItemID: to, Label: label}
+		fromArrow := edge.FromEnd == canvasEndArrow
+		toArrow := edge.ToEnd == "" || edge.ToEnd == canvasEndArrow
+		switch {
+		case fromArrow && toArrow:
+			conn.Direction = models.DirectionBoth
+		case toArrow:
+			conn.Direction = models.DirectionForward
+		case fromArrow:
+			conn.FromItemID, conn.ToItemID = to, from
+			conn.Direction = models.DirectionForward
+		default:
+			conn.Direction = models.DirectionNone
+		}
+		if ext := edge.EvidenceWall; ext != nil {
+			conn.RelationshipType, conn.Confidence = ext.RelationshipType, ext.Confidence
+		}
+		if color := canvasColor(edge.Color); color != "" {
+			conn.Style, _ = json.Marshal(map[string]interface{}{"color": color})
+		}
+		archive.Connections = append(archive.Connections, conn)
+	}
+
+	return s.ImportBoard(userID, archive, opts)
+}
+
+// canvasItemStyle builds an item's rotation and style from a canvas node,
+// preferring the style recorded by ExportCanvas and applying the node color
+func canvasItemStyle(node CanvasNode, itemType string) (float64, json.RawMessage) {
+	style := map[string]interface{}{}
+	var rotation float64
+	if ext := node.EvidenceWall; ext != nil {
+		rotation = ext.Rotation
+		if len(ext.Style) > 0 {
+			json.Unmarshal(ext.Style, &style)
+		}
+	}
+
+	if color := canvasColor(node.Color); color != "" {
+		style["color"] = color
+	} else if _, ok := style["color"]; !ok {
+		style["color"] = postItColor
+		if itemType == string(models.ItemTypeSuspectCard) {
+			style["color"] = suspectCardColor
+		}
+	}
+	metadata, _ := style["metadata"].(map[string]interface{})
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+	metadata["variant"] = itemType
+	style["metadata"] = metadata
+
+	data, _ := json.Marshal(style)
+	return rotation, data
+}
+
+// canvasColor resolves a JSON Canvas color (preset number or hex) to hex
+func canvasColor(color string) string {
+	if hex, ok := canvasPresetColors[color]; ok {
+		return hex
+	}
+	if strings.HasPrefix(color, "#") && (len(color) == 4 || len(color) == 7) {
+		return strings.ToLower(color)
+	}
+	return ""
+}
+
+// styleString returns a top level string value from a style JSON object
+func styleString(style json.RawMessage, key string) string {
+	var data map[string]interface{}
+	if len(style) == 0 || json.Unmarshal(style, &data) != nil {
+		return ""
+	}
+	value, _ := data[key].(string)
+	return value
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// captureImport wires the repository mocks used by ImportBoard and collects what gets created
+func captureImport(boardRepo *MockBoardRepository, boardUserRepo *MockBoardUserRepository, itemRepo *MockBoardItemRepository, connRepo *MockBoardConnectionRepository) (*models.Board, *[]*models.BoardItem, *[]*models.BoardConnection) {
+	created := &models.Board{}
+	items := &[]*models.BoardItem{}
+	conns := &[]*models.BoardConnection{}
+	boardRepo.On("Create", mock.AnythingOfType("*models.Board")).Run(func(args mock.Arguments) {
+		board := args.Get(0).(*models.Board)
+		board.ID = uuid.New()
+		*created = *board
+	}).Return(nil)
+	boardUserRepo.On("Create", mock.AnythingOfType("*models.BoardUser")).Return(nil)
+	itemRepo.On("Create", mock.AnythingOfType("*models.BoardItem")).Run(func(args mock.Arguments) {
+		*items = append(*items, args.Get(0).(*models.BoardItem))
+	}).Return(nil)
+	connRepo.On("Create", mock.AnythingOfType("*models.BoardConnection")).Run(func(args mock.Arguments) {
+		*conns = append(*conns, args.Get(0).(*models.BoardConnection))
+	}).Return(nil)
+	return created, items, conns
+}
+
+func TestBoardService_CanvasRoundTrip(t *testing.T) {
+	userID := uuid.New()
+	boardID := uuid.New()
+	suspectID := uuid.New()
+	noteID := uuid.New()
+
+	source := &models.Board{
+		ID:    boardID,
+		Title: "Case",
+		Items: []models.BoardItem{
+			{ID: noteID, Type: "post-it", X: 400, Y: 20, Width: 200, Height: 200, ZIndex: 2,
+				Content: "Seen at 5 &amp; 6",
+				Style:   []byte(`{"color":"#ffeb3b","metadata":{"variant":"post-it"}}`)},
+			{ID: suspectID, Type: "suspect-card", X: 10, Y: 20, Width: 280, Height: 400, Rotation: 3, ZIndex: 1,
+				Content: "Name: Tom",
+				Style:   []byte(`{"color":"#f5f5f5","metadata":{"variant":"suspect-card","alias":"The Cat"}}`)},
+		},
+		Connections: []models.BoardConnection{
+			{ID: uuid.New(), FromItemID: suspectID, ToItemID: noteID, Label: "seen", Direction: models.DirectionForward,
+				RelationshipType: "was at", Confidence: models.ConfidenceHigh, Style: `{"color":"#ff0000"}`},
+		},
+	}
+
+	mockBoardRepo := new(MockBoardRepository)
+	mockBoardUserRepo := new(MockBoardUserRepository)
+	mockBoardItemRepo := new(MockBoardItemRepository)
+	mockConnectionRepo := new(MockBoardConnectionRepository)
+	svc := NewBoardService(mockBoardRepo, mockBoardUserRepo, mockBoardItemRepo, mockConnectionRepo, nil, nil, nil, nil)
+	mockBoardRepo.On("GetByIDWithContents", boardID, userID).Return(source, models.PermissionRead, nil)
+
+	canvas, err := svc.ExportCanvas(boardID, userID)
+	assert.NoError(t, err)
+	if assert.Len(t, canvas.Nodes, 2) && assert.Len(t, canvas.Edges, 1) {
+		// Nodes are ordered by z-index and carry unescaped text
+		assert.Equal(t, suspectID.String(), canvas.Nodes[0].ID)
+		assert.Equal(t, "Seen at 5 & 6", canvas.Nodes[1].Text)
+		assert.Equal(t, "#ffeb3b", canvas.Nodes[1].Color)
+		assert.Equal(t, CanvasNodeText, canvas.Nodes[1].Type)
+		assert.Equal(t, "#ff0000", canvas.Edges[0].Color)
+		assert.Equal(t, "seen", canvas.Edges[0].Label)
+		assert.Equal(t, "none", canvas.Edges[0].FromEnd)
+		assert.Equal(t, "arrow", canvas.Edges[0].ToEnd)
+	}
+
+	data, err := json.Marshal(canvas)
+	assert.NoError(t, err)
+	var decoded Canvas
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+
+	created, items, conns := captureImport(mockBoardRepo, mockBoardUserRepo, mockBoardItemRepo, mockConnectionRepo)
+	_, err = svc.ImportCanvas(userID, &decoded, ImportBoardOptions{Title: "From canvas"})
+	assert.NoError(t, err)
+	assert.Equal(t, "From canvas", created.Title)
+	assert.Equal(t, models.VisibilityPrivate, created.Visibility)
+
+	if assert.Len(t, *items, 2) && assert.Len(t, *conns, 1) {
+		suspect, note := (*items)[0], (*items)[1]
+		assert.Equal(t, "suspect-card", suspect.Type)
+		assert.Equal(t, 3.0, suspect.Rotation)
+		assert.Equal(t, "Name: Tom", suspect.Content)
+		assert.JSONEq(t, string(source.Items[1].Style), string(suspect.Style))
+		assert.Equal(t, "post-it", note.Type)
+		assert.Equal(t, source.Items[0].Content, note.Content)
+		assert.Equal(t, 400.0, note.X)
+
+		assert.Equal(t, suspect.ID, (*conns)[0].FromItemID)
+		assert.Equal(t, note.ID, (*conns)[0].ToItemID)
+		assert.JSONEq(t, source.Connections[0].Style, (*conns)[0].Style)
+		assert.Equal(t, "seen", (*conns)[0].Label)
+		assert.Equal(t, models.DirectionForward, (*conns)[0].Direction)
+		assert.Equal(t, "was at", (*conns)[0].RelationshipType)
+		assert.Equal(t, models.ConfidenceHigh, (*conns)[0].Confidence)
+	}
+}
+
+func TestBoardService_ImportObsidianCanvas(t *testing.T) {
+	userID := uuid.New()
+	canvas := &Canvas{
+		Nodes: []CanvasNode{
+			{ID: "a1b2c3d4e5f60718", Type: CanvasNodeText, X: -100, Y: 50, Width: 250, Height: 60, Color: "4", Text: "# Lead\n<b>bold</b> claim"},
+			{ID: "0f1e2d3c4b5a6978", Type: CanvasNodeLink, X: 300, Y: 50, Width: 400, Height: 5, URL: "https://example.com"},
+			{ID: "group1", Type: CanvasNodeGroup, X: -200, Y: 0, Width: 1000, Height: 400, Label: "Leads"},
+		},
+		Edges: []CanvasEdge{
+			{ID: "e1", FromNode: "a1b2c3d4e5f60718", ToNode: "0f1e2d3c4b5a6978", Color: "1", Label: "source"},
+			{ID: "e3", FromNode: "a1b2c3d4e5f60718", ToNode: "0f1e2d3c4b5a6978", FromEnd: "arrow", ToEnd: "none"},
+			{ID: "e2", FromNode: "a1b2c3d4e5f60718", ToNode: "group1"},
+		},
+	}
+
+	mockBoardRepo := new(MockBoardRepository)
+	mockBoardUserRepo := new(MockBoardUserRepository)
+	mockBoardItemRepo := new(MockBoardItemRepository)
+	mockConnectionRepo := new(MockBoardConnectionRepository)
+	svc := NewBoardService(mockBoardRepo, mockBoardUserRepo, mockBoardItemRepo, mockConnectionRepo, nil, nil, nil, nil)
+	created, items, conns := captureImport(mockBoardRepo, mockBoardUserRepo, mockBoardItemRepo, mockConnectionRepo)
+
+	_, err := svc.ImportCanvas(userID, canvas, ImportBoardOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "Imported canvas", created.Title)
+
+	// The group and the edge into it are skipped
+	if assert.Len(t, *items, 2) && assert.Len(t, *conns, 2) {
+		lead := (*items)[0]
+		assert.Equal(t, "post-it", lead.Type)
+		assert.Equal(t, "# Lead\nbold claim", lead.Content)
+		assert.JSONEq(t, `{"color":"#66bb6a","metadata":{"variant":"post-it"}}`, string(lead.Style))
+
+		link := (*items)[1]
+		assert.Equal(t, "https://example.com", link.Content)
+		assert.Equal(t, 10.0, link.Height)
+
+		// Edges point at their target unless the ends say otherwise
+		assert.JSONEq(t, `{"color":"#ff5252"}`, (*conns)[0].Style)
+		assert.Equal(t, "source", (*conns)[0].Label)
+		assert.Equal(t, models.DirectionForward, (*conns)[0].Direction)
+		assert.Equal(t, link.ID, (*conns)[1].FromItemID)
+		assert.Equal(t, lead.ID, (*conns)[1].ToItemID)
+		assert.Equal(t, models.DirectionForward, (*conns)[1].Direction)
+	}
+}
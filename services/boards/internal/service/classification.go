@@ -0,0 +1,243 @@
+package service
+
+import (
+	"fmt"
+	"log"
+
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+)
+
+// validateClassification checks a classification marking or clearance
+func validateClassification(level models.ClassificationLevel, field string) error {
+	if !level.Valid() {
+		return fmt.Errorf("%w: %s must be one of unclassified, restricted, confidential, secret or top_secret", ErrInvalidInput, field)
+	}
+	return nil
+}
+
+// viewerClearance returns the user's clearance on the board, looking it up
+// the first time. Anonymous viewers and users whose clearance cannot be
+// looked up are treated as uncleared.
+func (a *boardAccess) viewerClearance() models.ClassificationLevel {
+	if a.clearance != nil {
+		return *a.clearance
+	}
+	clearance := models.ClassificationUnclassified
+	if a.userID != uuid.Nil && a.resolver != nil && a.resolver.boardUserRepo != nil {
+		bu, err := a.resolver.boardUserRepo.GetByBoardAndUser(a.boardID, a.userID)
+		if err != nil {
+			log.Printf("Failed to get clearance on board %s: %v", a.boardID, err)
+		} else if bu != nil && bu.Clearance != "" {
+			clearance = bu.Clearance
+		}
+	}
+	a.clearance = &clearance
+	return clearance
+}
+
+// cleared reports whether the user may see material of the classification.
+// Board admins see everything so that they can manage the board.
+func (a *boardAccess) cleared(classification models.ClassificationLevel) bool {
+	if classification.Rank() == 0 || a.permission == models.PermissionAdmin {
+		return true
+	}
+	return a.viewerClearance().Covers(classification)
+}
+
+// redactItem leaves an item's place on the board and blanks what it says
+func redactItem(item models.BoardItem) models.BoardItem {
+	return models.BoardItem{
+		ID:             item.ID,
+		BoardID:        item.BoardID,
+		ParentID:       item.ParentID,
+		LayerID:        item.LayerID,
+		Type:           item.Type,
+		X:              item.X,
+		Y:              item.Y,
+		Width:          item.Width,
+		Height:         item.Height,
+		Rotation:       item.Rotation,
+		ZIndex:         item.ZIndex,
+		Classification: item.Classification,
+		Redacted:       true,
+		CreatedBy:      item.CreatedBy,
+		CreatedAt:      item.CreatedAt,
+		UpdatedAt:      item.UpdatedAt,
+	}
+}
+
+// redactConnection blanks what a connection says about its ends
+func redactConnection(conn models.BoardConnection) models.BoardConnection {
+	conn.Label = ""
+	conn.RelationshipType = ""
+	conn.Redacted = true
+	return conn
+}
+
+// redactItems redacts the items above the user's clearance and remembers
+// them for redactConnections. The slice is copied before anything changes.
+func (a *boardAccess) redactItems(items []models.BoardItem) []models.BoardItem {
+	var redacted []models.BoardItem
+	for i := range items {
+		if a.cleared(items[i].Classification) {
+			continue
+		}
+		if redacted == nil {
+			redacted = append([]models.BoardItem(nil), items...)
+		}
+		redacted[i] = redactItem(items[i])
+		a.markRedacted(items[i].ID)
+	}
+	if redacted == nil {
+		return items
+	}
+	return redacted
+}
+
+func (a *boardAccess) markRedacted(id uuid.UUID) {
+	if a.redacted == nil {
+		a.redacted = map[uuid.UUID]bool{}
+	}
+	a.redacted[id] = true
+}
+
+// redactConnections redacts the connections with an end redacted by
+// redactItems or loadClassified
+func (a *boardAccess) redactConnections(connections []models.BoardConnection) []models.BoardConnection {
+	if len(a.redacted) == 0 {
+		return connections
+	}
+	var redacted []models.BoardConnection
+	for i, conn := range connections {
+		if !a.redacted[conn.FromItemID] && !a.redacted[conn.ToItemID] {
+			continue
+		}
+		if redacted == nil {
+			redacted = append([]models.BoardConnection(nil), connections...)
+		}
+		redacted[i] = redactConnection(conn)
+	}
+	if redacted == nil {
+		return connections
+	}
+	return redacted
+}
+
+// redactContents redacts a board's items and the connections between them
+func (a *boardAccess) redactContents(board *models.Board) {
+	board.Items = a.redactItems(board.Items)
+	board.Connections = a.redactConnections(board.Connections)
+}
+
+// loadClassified looks up the board's classified items, for redacting
+// connections listed without their ends. Admins skip the lookup.
+func (a *boardAccess) loadClassified() error {
+	if a.classifiedLoaded || a.permission == models.PermissionAdmin || a.resolver == nil || a.resolver.boardItemRepo == nil {
+		return nil
+	}
+	items, err := a.resolver.boardItemRepo.ListClassified(a.boardID)
+	if err != nil {
+		return fmt.Errorf("failed to list classified items: %w", err)
+	}
+	a.classifiedLoaded = true
+	for _, item := range items {
+		if !a.cleared(item.Classification) {
+			a.markRedacted(item.ID)
+		}
+	}
+	return nil
+}
+
+// checkClassification verifies that the user may mark an item with the
+// classification, which must be within their own clearance
+func (a *boardAccess) checkClassification(classification models.ClassificationLevel) error {
+	if err := validateClassification(classification, "classification"); err != nil {
+		return err
+	}
+	if !a.cleared(classification) {
+		return ErrUnauthorized
+	}
+	return nil
+}
+
+// clearedFor reports whether a board user may see material of the
+// classification, like boardAccess.cleared
+func clearedFor(bu *models.BoardUser, classification models.ClassificationLevel) bool {
+	return bu.Permission == models.PermissionAdmin || bu.Clearance.Covers(classification)
+}
+
+// publishClassifiedItem publishes an update about a classified item on each
+// board user's own channel: in full to those cleared for it and redacted to
+// the rest
+func (s *BoardService) publishClassifiedItem(boardID uuid.UUID, event string, item *models.BoardItem) {
+	redacted := redactItem(*item)
+	s.publishPerUser(boardID, item.LayerID, event, func(bu *models.BoardUser) interface{} {
+		if clearedFor(bu, item.Classification) {
+			return item
+		}
+		return &redacted
+	})
+}
+
+// publishConnectionUpdate publishes an update about a connection. When
+// either end is classified it is redacted for users not cleared for both.
+func (s *BoardService) publishConnectionUpdate(boardID uuid.UUID, event string, conn *models.BoardConnection) {
+	if s.redis == nil {
+		return
+	}
+	var levels []models.ClassificationLevel
+	for _, id := range []uuid.UUID{conn.FromItemID, conn.ToItemID} {
+		item, err := s.boardItemRepo.GetByID(id)
+		if err != nil || item == nil {
+			log.Printf("publishConnectionUpdate: Error getting item %s: %v", id, err)
+			levels = append(levels, models.ClassificationTopSecret)
+		} else if item.Classification.Rank() > 0 {
+			levels = append(levels, item.Classification)
+		}
+	}
+	if len(levels) == 0 {
+		s.publishLayerUpdate(boardID, conn.LayerID, event, conn)
+		return
+	}
+	redacted := redactConnection(*conn)
+	s.publishPerUser(boardID, conn.LayerID, event, func(bu *models.BoardUser) interface{} {
+		for _, level := range levels {
+			if !clearedFor(bu, level) {
+				return &redacted
+			}
+		}
+		return conn
+	})
+}
+
+// publishPerUser publishes an update on the own channel of each board user
+// who can see the layer, with the payload chosen for them. Public viewers
+// who are not board users do not get it.
+func (s *BoardService) publishPerUser(boardID uuid.UUID, layerID *uuid.UUID, event string, payload func(bu *models.BoardUser) interface{}) {
+	if s.redis == nil {
+		return
+	}
+	var layer *models.Layer
+	if layerID != nil && s.layerRepo != nil {
+		found, err := s.layerRepo.GetByID(*layerID)
+		if err != nil || found == nil {
+			log.Printf("publishPerUser: Error getting layer %s: %v", *layerID, err)
+			return
+		}
+		layer = found
+	}
+	users, err := s.boardUserRepo.ListByBoard(boardID)
+	if err != nil {
+		log.Printf("publishPerUser: Error listing board users: %v", err)
+		return
+	}
+	for i := range users {
+		bu := &users[i]
+		if layer != nil && !layerAllows(layer, bu.UserID, bu.Permission) {
+			continue
+		}
+		s.publish(userChannel(boardID, bu.UserID), boardID, event, payload(bu))
+	}
+}
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// classificationFixture is a board with an unclassified note, a secret
+// note and a connection between them, viewed by a user with the given
+// permission and clearance
+type classificationFixture struct {
+	*serviceFixture
+	open   models.BoardItem
+	secret models.BoardItem
+	conn   models.BoardConnection
+}
+
+func newClassificationFixture(permission models.PermissionLevel, clearance models.ClassificationLevel) *classificationFixture {
+	f := &classificationFixture{}
+	board := &models.Board{ID: uuid.New(), Title: "Case", Visibility: models.VisibilityPublic}
+	boardID := board.ID
+	f.open = models.BoardItem{ID: uuid.New(), BoardID: boardID, Type: "note", Content: "Open", X: 10, Y: 20, Width: 200, Height: 200}
+	f.secret = models.BoardItem{ID: uuid.New(), BoardID: boardID, Type: "note", Content: "Informant", X: 300, Y: 20, Width: 200, Height: 200,
+		Style: []byte(`{"color":"#ff0000"}`), Fields: []byte(`{"name":"Jo"}`), Classification: models.ClassificationSecret}
+	f.conn = models.BoardConnection{ID: uuid.New(), BoardID: boardID, FromItemID: f.open.ID, ToItemID: f.secret.ID, Label: "paid", RelationshipType: "paid"}
+	board.Items = []models.BoardItem{f.open, f.secret}
+	board.Connections = []models.BoardConnection{f.conn}
+
+	f.serviceFixture = newServiceFixture(board, permission, clearance)
+	f.itemRepo.On("GetByID", f.open.ID).Return(&f.open, nil)
+	f.itemRepo.On("GetByID", f.secret.ID).Return(&f.secret, nil)
+	f.itemRepo.On("ListFrameMembership", boardID).Return([]models.BoardItem{}, nil)
+	return f
+}
+
+func TestClassificationLevel(t *testing.T) {
+	assert.True(t, models.ClassificationSecret.Covers(models.ClassificationConfidential))
+	assert.True(t, models.ClassificationSecret.Covers(models.ClassificationSecret))
+	assert.False(t, models.ClassificationConfidential.Covers(models.ClassificationSecret))
+	assert.True(t, models.ClassificationLevel("").Covers(models.ClassificationUnclassified), "empty is unclassified")
+	assert.False(t, models.ClassificationLevel("").Covers(models.ClassificationRestricted))
+	assert.True(t, models.ClassificationLevel("").Valid())
+	assert.False(t, models.ClassificationLevel("cosmic").Valid())
+}
+
+func TestBoardService_GetBoard_RedactsAboveClearance(t *testing.T) {
+	f := newClassificationFixture(models.PermissionRead, models.ClassificationConfidential)
+
+	board, err := f.svc.GetBoard(f.board.ID, f.userID)
+	assert.NoError(t, err)
+	if assert.Len(t, board.Items, 2) {
+		assert.Equal(t, f.open, board.Items[0])
+		redacted := board.Items[1]
+		assert.True(t, redacted.Redacted)
+		assert.Equal(t, f.secret.ID, redacted.ID)
+		assert.Equal(t, f.secret.X, redacted.X, "the item keeps its place on the board")
+		assert.Equal(t, models.ClassificationSecret, redacted.Classification)
+		assert.Empty(t, redacted.Content)
+		assert.Empty(t, redacted.Style)
+		assert.Empty(t, redacted.Fields)
+	}
+	if assert.Len(t, board.Connections, 1) {
+		assert.True(t, board.Connections[0].Redacted)
+		assert.Empty(t, board.Connections[0].Label)
+		assert.Empty(t, board.Connections[0].RelationshipType)
+	}
+	assert.Equal(t, models.ClassificationConfidential, board.Clearance)
+
+	cleared := newClassificationFixture(models.PermissionRead, models.ClassificationTopSecret)
+	board, err = cleared.svc.GetBoard(cleared.board.ID, cleared.userID)
+	assert.NoError(t, err)
+	assert.Equal(t, cleared.board.Items, board.Items)
+	assert.Equal(t, cleared.board.Connections, board.Connections)
+
+	// Admins see everything without their clearance being looked up
+	admin := newClassificationFixture(models.PermissionAdmin, "")
+	board, err = admin.svc.GetBoard(admin.board.ID, admin.userID)
+	assert.NoError(t, err)
+	assert.Equal(t, admin.board.Items, board.Items)
+	admin.userRepo.AssertNotCalled(t, "GetByBoardAndUser", mock.Anything, mock.Anything)
+}
+
+func TestBoardService_GetPublicBoard_RedactsClassifiedItems(t *testing.T) {
+	f := newClassificationFixture(models.PermissionRead, models.ClassificationTopSecret)
+	f.boardRepo.On("GetByID", f.board.ID).Return(f.board, nil)
+
+	board, err := f.svc.GetPublicBoard(f.board.ID)
+	assert.NoError(t, err)
+	assert.False(t, board.Items[0].Redacted)
+	assert.True(t, board.Items[1].Redacted)
+	assert.Empty(t, board.Items[1].Content)
+	assert.True(t, board.Connections[0].Redacted)
+}
+
+func TestBoardService_ListBoardConnections_RedactsClassifiedEnds(t *testing.T) {
+	f := newClassificationFixture(models.PermissionRead, "")
+	f.connRepo.On("ListByBoardFiltered", f.board.ID, models.ConnectionFilter{}).Return(f.board.Connections, nil)
+	f.connRepo.On("ListByBoardFiltered", f.board.ID, models.ConnectionFilter{Label: "paid"}).Return(f.board.Connections, nil)
+
+	connections, err := f.svc.ListBoardConnections(f.board.ID, f.userID, ConnectionQuery{})
+	assert.NoError(t, err)
+	if assert.Len(t, connections, 1) {
+		assert.True(t, connections[0].Redacted)
+		assert.Empty(t, connections[0].Label)
+	}
+
+	// Matching on a redacted label would give it away
+	connections, err = f.svc.ListBoardConnections(f.board.ID, f.userID, ConnectionQuery{Label: "paid"})
+	assert.NoError(t, err)
+	assert.Empty(t, connections)
+}
+
+func TestBoardService_ItemsAboveClearanceAreReadOnly(t *testing.T) {
+	f := newClassificationFixture(models.PermissionWrite, models.ClassificationConfidential)
+	f.board.Classification = models.ClassificationRestricted
+
+	_, err := f.svc.UpdateBoardItem(f.board.ID, f.secret.ID, f.userID, UpdateItemRequest{Content: "Changed"})
+	assert.Equal(t, ErrUnauthorized, err)
+	err = f.svc.DeleteBoardItem(f.board.ID, f.secret.ID, f.userID, false)
+	assert.Equal(t, ErrUnauthorized, err)
+
+	// Items cannot be marked above the user's own clearance
+	secret := models.ClassificationSecret
+	_, err = f.svc.UpdateBoardItem(f.board.ID, f.open.ID, f.userID, UpdateItemRequest{Classification: &secret})
+	assert.Equal(t, ErrUnauthorized, err)
+	req := CreateItemRequest{Type: "note", Content: "Tip", X: 1, Y: 1, Width: 100, Height: 100, Classification: secret}
+	_, err = f.svc.CreateBoardItem(f.board.ID, f.userID, req)
+	assert.Equal(t, ErrUnauthorized, err)
+	req.Classification = "cosmic"
+	_, err = f.svc.CreateBoardItem(f.board.ID, f.userID, req)
+	assert.True(t, errors.Is(err, ErrInvalidInput))
+
+	// New items take the board's classification by default
+	f.itemRepo.On("Create", mock.AnythingOfType("*models.BoardItem")).Return(nil)
+	req.Classification = ""
+	item, err := f.svc.CreateBoardItem(f.board.ID, f.userID, req)
+	assert.NoError(t, err)
+	assert.Equal(t, models.ClassificationRestricted, item.Classification)
+	f.itemRepo.AssertNotCalled(t, "Update", mock.Anything)
+	f.itemRepo.AssertNotCalled(t, "Delete", mock.Anything)
+}
+
+func TestBoardService_ShareBoard_Clearance(t *testing.T) {
+	f := newClassificationFixture(models.PermissionAdmin, "")
+	target := uuid.New()
+	f.userRepo.On("GetByBoardAndUser", f.board.ID, target).Return(nil, nil)
+	f.userRepo.On("Create", &models.BoardUser{BoardID: f.board.ID, UserID: target, Permission: models.PermissionRead, Clearance: models.ClassificationSecret}).Return(nil)
+
+	err := f.svc.ShareBoard(f.board.ID, f.userID, ShareBoardRequest{UserID: target, Permission: models.PermissionRead, Clearance: models.ClassificationSecret})
+	assert.NoError(t, err)
+
+	err = f.svc.ShareBoard(f.board.ID, f.userID, ShareBoardRequest{UserID: target, Permission: models.PermissionRead, Clearance: "cosmic"})
+	assert.True(t, errors.Is(err, ErrInvalidInput))
+	f.userRepo.AssertNumberOfCalls(t, "Create", 1)
+}
+
+func TestBoardService_PublishTemplate_ClassifiedItemsArePlaceholders(t *testing.T) {
+	f := newClassificationFixture(models.PermissionAdmin, "")
+	templateRepo := new(MockTemplateRepository)
+	templateRepo.On("Create", mock.AnythingOfType("*models.BoardTemplate")).Return(nil)
+	svc := NewBoardService(f.boardRepo, f.userRepo, f.itemRepo, f.connRepo, templateRepo, nil, nil, nil)
+
+	template, err := svc.PublishTemplate(f.userID, PublishTemplateRequest{BoardID: f.board.ID, Name: "Informants"})
+	assert.NoError(t, err)
+	if assert.Len(t, template.Content.Items, 2) {
+		assert.False(t, template.Content.Items[0].Placeholder)
+		assert.True(t, template.Content.Items[1].Placeholder)
+		assert.Empty(t, template.Content.Items[1].Content)
+		assert.Nil(t, template.Content.Items[1].Fields)
+	}
+}
+
+func TestBoardService_UndoClassification(t *testing.T) {
+	f := newClassificationFixture(models.PermissionAdmin, models.ClassificationSecret)
+	f.itemRepo.On("Update", mock.AnythingOfType("*models.BoardItem")).Return(nil)
+	f.boardRepo.On("GetByID", f.board.ID).Return(f.board, nil)
+	f.boardRepo.On("Update", mock.AnythingOfType("*models.Board")).Return(nil)
+
+	confidential := models.ClassificationConfidential
+	_, err := f.svc.UpdateBoardItem(f.board.ID, f.secret.ID, f.userID, UpdateItemRequest{Classification: &confidential})
+	assert.NoError(t, err)
+	_, err = f.svc.UpdateBoard(f.board.ID, f.userID, UpdateBoardRequest{Classification: &confidential})
+	assert.NoError(t, err)
+
+	_, err = f.svc.Undo(f.board.ID, f.userID)
+	assert.NoError(t, err)
+	assert.Empty(t, f.board.Classification)
+	_, err = f.svc.Undo(f.board.ID, f.userID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.ClassificationSecret, f.secret.Classification)
+
+	_, err = f.svc.Redo(f.board.ID, f.userID)
+	assert.NoError(t, err)
+	assert.Equal(t, confidential, f.secret.Classification)
+}
+
+func TestBoardService_UndoAboveClearance(t *testing.T) {
+	f := newClassificationFixture(models.PermissionWrite, models.ClassificationConfidential)
+	f.itemRepo.On("Update", mock.AnythingOfType("*models.BoardItem")).Return(nil)
+
+	// Recorded before the user's clearance was lowered
+	before := f.secret
+	f.secret.Content = "Edited"
+	f.svc.recordHistory(f.board.ID, f.userID, "item_updated", itemChange(&before, &f.secret))
+
+	_, err := f.svc.Undo(f.board.ID, f.userID)
+	assert.Equal(t, ErrUnauthorized, err)
+	assert.Equal(t, "Edited", f.secret.Content)
+	f.itemRepo.AssertNotCalled(t, "Update", mock.Anything)
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+)
+
+// Connection attribute limits
+const (
+	MaxLabelLength            = 200
+	MaxRelationshipTypeLength = 50
+)
+
+// Relationship types are lower-case words separated by single spaces,
+// hyphens or underscores, e.g. "was at" or "works-for"
+var relationshipTypeRegex = regexp.MustCompile(`^[a-z0-9]+([ _-][a-z0-9]+)*$`)
+
+func validateLabel(label string) (string, error) {
+	return validateAndSanitizeString(label, MaxLabelLength, "label")
+}
+
+// normalizeRelationshipType lower-cases a relationship type and collapses
+// runs of whitespace. An empty type is allowed and means untyped.
+func normalizeRelationshipType(relType string) (string, error) {
+	normalized := strings.Join(strings.Fields(strings.ToLower(relType)), " ")
+	if normalized == "" {
+		return "", nil
+	}
+	if len(normalized) > MaxRelationshipTypeLength {
+		return "", fmt.Errorf("relationship_type: %w (max %d characters)", ErrInputTooLong, MaxRelationshipTypeLength)
+	}
+	if !relationshipTypeRegex.MatchString(normalized) {
+		return "", fmt.Errorf("%w: relationship_type may only contain letters, digits, spaces, hyphens and underscores", ErrInvalidInput)
+	}
+	return normalized, nil
+}
+
+// validateDirection checks a direction; empty means none
+func validateDirection(direction models.ConnectionDirection) (models.ConnectionDirection, error) {
+	switch direction {
+	case "":
+		return models.DirectionNone, nil
+	case models.DirectionNone, models.DirectionForward, models.DirectionBoth:
+		return direction, nil
+	}
+	return "", fmt.Errorf("%w: direction must be none, forward or both", ErrInvalidInput)
+}
+
+// validateConfidence checks a confidence level; empty means not assessed
+func validateConfidence(confidence models.ConfidenceLevel) error {
+	switch confidence {
+	case "", models.ConfidenceLow, models.ConfidenceMedium, models.ConfidenceHigh, models.ConfidenceConfirmed:
+		return nil
+	}
+	return fmt.Errorf("%w: confidence must be low, medium, high or confirmed", ErrInvalidInput)
+}
+
+// ConnectionQuery filters connection listings and the graph analysis
+// endpoints. Each list accepts repeated parameters or comma-separated values.
+type ConnectionQuery struct {
+	RelationshipType []string `form:"relationship_type"`
+	Direction        []string `form:"direction"`
+	Confidence       []string `form:"confidence"`
+	ItemID           string   `form:"item_id"` // Only connections touching this item
+	Label            string   `form:"label"`   // Case-insensitive label substring
+}
+
+// filter validates the query and converts it to a repository filter
+func (q ConnectionQuery) filter() (models.ConnectionFilter, error) {
+	var f models.ConnectionFilter
+	for _, value := range splitValues(q.RelationshipType) {
+		relType, err := normalizeRelationshipType(value)
+		if err != nil {
+			return f, err
+		}
+		if relType != "" {
+			f.RelationshipTypes = append(f.RelationshipTypes, relType)
+		}
+	}
+	for _, value := range splitValues(q.Direction) {
+		direction, err := validateDirection(models.ConnectionDirection(value))
+		if err != nil {
+			return f, err
+		}
+		f.Directions = append(f.Directions, direction)
+	}
+	for _, value := range splitValues(q.Confidence) {
+		confidence := models.ConfidenceLevel(value)
+		if err := validateConfidence(confidence); err != nil || confidence == "" {
+			return f, fmt.Errorf("%w: confidence must be low, medium, high or confirmed", ErrInvalidInput)
+		}
+		f.Confidences = append(f.Confidences, confidence)
+	}
+	if q.ItemID != "" {
+		id, err := uuid.Parse(q.ItemID)
+		if err != nil {
+			return f, fmt.Errorf("%w: invalid item_id", ErrInvalidInput)
+		}
+		f.ItemID = &id
+	}
+	// Labels are stored escaped, so search for the escaped form
+	f.Label = html.EscapeString(strings.TrimSpace(q.Label))
+	return f, nil
+}
+
+// splitValues flattens repeated and comma-separated query values
+func splitValues(values []string) []string {
+	var out []string
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				out = append(out, part)
+			}
+		}
+	}
+	return out
+}
+
+// connectionLabel returns the display text of a connection's stored label,
+// falling back to the "label" style key used before labels were a field
+func connectionLabel(label string, style json.RawMessage) string {
+	if label != "" {
+		return html.UnescapeString(label)
+	}
+	return styleString(style, "label")
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestBoardService_CreateBoardConnectionAttributes(t *testing.T) {
+	boardID := uuid.New()
+	userID := uuid.New()
+	fromID := uuid.New()
+	toID := uuid.New()
+
+	tests := []struct {
+		name        string
+		request     CreateConnectionRequest
+		expected    *models.BoardConnection
+		expectedErr error
+	}{
+		{
+			name:     "defaults to an untyped undirected connection",
+			request:  CreateConnectionRequest{FromItemID: fromID, ToItemID: toID},
+			expected: &models.BoardConnection{Direction: models.DirectionNone},
+		},
+		{
+			name: "typed directed connection",
+			request: CreateConnectionRequest{
+				FromItemID:       fromID,
+				ToItemID:         toID,
+				Label:            "Wired <b>$5k</b>",
+				Direction:        models.DirectionForward,
+				RelationshipType: "  Paid ",
+				Confidence:       models.ConfidenceConfirmed,
+			},
+			expected: &models.BoardConnection{
+				Label:            "Wired $5k",
+				Direction:        models.DirectionForward,
+				RelationshipType: "paid",
+				Confidence:       models.ConfidenceConfirmed,
+			},
+		},
+		{
+			name:        "invalid direction",
+			request:     CreateConnectionRequest{FromItemID: fromID, ToItemID: toID, Direction: "backward"},
+			expectedErr: ErrInvalidInput,
+		},
+		{
+			name:        "invalid confidence",
+			request:     CreateConnectionRequest{FromItemID: fromID, ToItemID: toID, Confidence: "certain"},
+			expectedErr: ErrInvalidInput,
+		},
+		{
+			name:        "invalid relationship type",
+			request:     CreateConnectionRequest{FromItemID: fromID, ToItemID: toID, RelationshipType: "knows!"},
+			expectedErr: ErrInvalidInput,
+		},
+		{
+			name:        "label too long",
+			request:     CreateConnectionRequest{FromItemID: fromID, ToItemID: toID, Label: strings.Repeat("a", MaxLabelLength+1)},
+			expectedErr: ErrInputTooLong,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockBoardRepo := new(MockBoardRepository)
+			mockBoardItemRepo := new(MockBoardItemRepository)
+			mockConnectionRepo := new(MockBoardConnectionRepository)
+			svc := NewBoardService(mockBoardRepo, new(MockBoardUserRepository), mockBoardItemRepo, mockConnectionRepo, nil, nil, nil, nil)
+
+			mockBoardRepo.On("GetPermission", boardID, userID).Return(true, models.PermissionWrite, nil)
+			mockBoardItemRepo.On("GetByID", fromID).Return(&models.BoardItem{ID: fromID, BoardID: boardID}, nil)
+			mockBoardItemRepo.On("GetByID", toID).Return(&models.BoardItem{ID: toID, BoardID: boardID}, nil)
+			mockConnectionRepo.On("Create", mock.AnythingOfType("*models.BoardConnection")).Return(nil)
+
+			conn, err := svc.CreateBoardConnection(boardID, userID, tt.request)
+			if tt.expectedErr != nil {
+				assert.True(t, errors.Is(err, tt.expectedErr), "got %v", err)
+				mockConnectionRepo.AssertNotCalled(t, "Create", mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected.Label, conn.Label)
+			assert.Equal(t, tt.expected.Direction, conn.Direction)
+			assert.Equal(t, tt.expected.RelationshipType, conn.RelationshipType)
+			assert.Equal(t, tt.expected.Confidence, conn.Confidence)
+		})
+	}
+}
+
+func TestBoardService_UpdateBoardConnectionAttributes(t *testing.T) {
+	boardID := uuid.New()
+	userID := uuid.New()
+	connID := uuid.New()
+
+	setup := func() (*BoardService, *MockBoardConnectionRepository) {
+		mockBoardRepo := new(MockBoardRepository)
+		mockConnectionRepo := new(MockBoardConnectionRepository)
+		mockBoardRepo.On("GetPermission", boardID, userID).Return(true, models.PermissionWrite, nil)
+		mockConnectionRepo.On("GetByID", connID).Return(&models.BoardConnection{
+			ID:               connID,
+			BoardID:          boardID,
+			Label:            "Old",
+			Direction:        models.DirectionNone,
+			RelationshipType: "knows",
+			Confidence:       models.ConfidenceLow,
+		}, nil)
+		mockConnectionRepo.On("Update", mock.AnythingOfType("*models.BoardConnection")).Return(nil)
+		return NewBoardService(mockBoardRepo, new(MockBoardUserRepository), new(MockBoardItemRepository), mockConnectionRepo, nil, nil, nil, nil), mockConnectionRepo
+	}
+
+	t.Run("omitted fields are kept", func(t *testing.T) {
+		svc, _ := setup()
+		both := models.DirectionBoth
+		conn, err := svc.UpdateBoardConnection(boardID, connID, userID, UpdateConnectionRequest{Direction: &both})
+		assert.NoError(t, err)
+		assert.Equal(t, models.DirectionBoth, conn.Direction)
+		assert.Equal(t, "Old", conn.Label)
+		assert.Equal(t, "knows", conn.RelationshipType)
+		assert.Equal(t, models.ConfidenceLow, conn.Confidence)
+	})
+
+	t.Run("empty strings clear fields", func(t *testing.T) {
+		svc, _ := setup()
+		empty := ""
+		var noConfidence models.ConfidenceLevel
+		conn, err := svc.UpdateBoardConnection(boardID, connID, userID, UpdateConnectionRequest{
+			Label:            &empty,
+			RelationshipType: &empty,
+			Confidence:       &noConfidence,
+		})
+		assert.NoError(t, err)
+		assert.Empty(t, conn.Label)
+		assert.Empty(t, conn.RelationshipType)
+		assert.Empty(t, conn.Confidence)
+	})
+
+	t.Run("invalid values are rejected", func(t *testing.T) {
+		svc, mockConnectionRepo := setup()
+		sideways := models.ConnectionDirection("sideways")
+		_, err := svc.UpdateBoardConnection(boardID, connID, userID, UpdateConnectionRequest{Direction: &sideways})
+		assert.True(t, errors.Is(err, ErrInvalidInput))
+		mockConnectionRepo.AssertNotCalled(t, "Update", mock.Anything)
+	})
+}
+
+func TestBoardService_ListBoardConnectionsFiltered(t *testing.T) {
+	boardID := uuid.New()
+	userID := uuid.New()
+	itemID := uuid.New()
+	mockBoardRepo := new(MockBoardRepository)
+	mockItemRepo := new(MockBoardItemRepository)
+	mockConnectionRepo := new(MockBoardConnectionRepository)
+	svc := NewBoardService(mockBoardRepo, new(MockBoardUserRepository), mockItemRepo, mockConnectionRepo, nil, nil, nil, nil)
+
+	expectedFilter := models.ConnectionFilter{
+		RelationshipTypes: []string{"was at", "paid"},
+		Directions:        []models.ConnectionDirection{models.DirectionForward},
+		Confidences:       []models.ConfidenceLevel{models.ConfidenceHigh, models.ConfidenceConfirmed},
+		ItemID:            &itemID,
+		Label:             "cash &amp; goods",
+	}
+	connections := []models.BoardConnection{{ID: uuid.New(), BoardID: boardID, RelationshipType: "paid"}}
+	mockBoardRepo.On("GetPermission", boardID, userID).Return(true, models.PermissionRead, nil)
+	mockConnectionRepo.On("ListByBoardFiltered", boardID, expectedFilter).Return(connections, nil)
+	mockItemRepo.On("ListClassified", boardID).Return([]models.BoardItem{}, nil)
+	mockItemRepo.On("ListFrameMembership", boardID).Return([]models.BoardItem{}, nil)
+
+	result, err := svc.ListBoardConnections(boardID, userID, ConnectionQuery{
+		RelationshipType: []string{"Was  At", "paid"},
+		Direction:        []string{"forward"},
+		Confidence:       []string{"high,confirmed"},
+		ItemID:           itemID.String(),
+		Label:            " cash & goods ",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, connections, result)
+
+	for _, query := range []ConnectionQuery{
+		{Direction: []string{"up"}},
+		{Confidence: []string{"sure"}},
+		{RelationshipType: []string{"<script>"}},
+		{ItemID: "not-a-uuid"},
+	} {
+		_, err := svc.ListBoardConnections(boardID, userID, query)
+		assert.True(t, errors.Is(err, ErrInvalidInput), "%+v", query)
+	}
+}
@@ -0,0 +1,303 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"evidence-wall/boards-service/internal/blob"
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+)
+
+// custodyAppendAttempts bounds retries when concurrent writers race for the
+// next sequence number of an item's chain
+const custodyAppendAttempts = 5
+
+// Integrity statuses reported by VerifyAttachments
+const (
+	IntegrityOK       = "ok"
+	IntegrityMismatch = "mismatch"
+	IntegrityMissing  = "missing"
+	IntegrityUnhashed = "unhashed" // Uploaded before hashing on ingest existed
+)
+
+// recordCustody appends an event to the item's custody chain
+func (s *AttachmentService) recordCustody(attachment *models.Attachment, action models.CustodyAction, userID uuid.UUID, digest, clientIP, detail string) error {
+	var err error
+	for attempt := 0; attempt < custodyAppendAttempts; attempt++ {
+		var last *models.CustodyEvent
+		last, err = s.custodyRepo.Last(attachment.ItemID)
+		if err != nil {
+			break
+		}
+		event := &models.CustodyEvent{
+			ID:           uuid.New(),
+			BoardID:      attachment.BoardID,
+			ItemID:       attachment.ItemID,
+			Seq:          1,
+			AttachmentID: attachment.ID,
+			Action:       action,
+			UserID:       userID,
+			SHA256:       digest,
+			ClientIP:     clientIP,
+			Detail:       truncateDetail(detail),
+			// Databases keep microseconds; hash what will be read back
+			CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+		}
+		if last != nil {
+			event.Seq = last.Seq + 1
+			event.PrevHash = last.Hash
+		}
+		event.Hash = custodyHash(event)
+		if err = s.custodyRepo.Create(event); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("failed to record custody event: %w", err)
+}
+
+func truncateDetail(detail string) string {
+	if len(detail) <= 500 {
+		return detail
+	}
+	return strings.ToValidUTF8(detail[:500], "")
+}
+
+// custodyHash seals an event together with the hash of its predecessor
+func custodyHash(e *models.CustodyEvent) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		e.PrevHash,
+		strconv.FormatInt(e.Seq, 10),
+		e.BoardID.String(),
+		e.ItemID.String(),
+		e.AttachmentID.String(),
+		string(e.Action),
+		e.UserID.String(),
+		e.SHA256,
+		e.ClientIP,
+		e.Detail,
+		e.CreatedAt.UTC().Format(time.RFC3339Nano),
+	}, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// verifyCustodyChain returns the sequence number of the first event that does
+// not fit the chain, or 0 when the chain is intact
+func verifyCustodyChain(events []models.CustodyEvent) int64 {
+	prev := ""
+	for i := range events {
+		e := &events[i]
+		if e.Seq != int64(i+1) || e.PrevHash != prev || e.Hash != custodyHash(e) {
+			if e.Seq > 0 {
+				return e.Seq
+			}
+			return int64(i + 1)
+		}
+		prev = e.Hash
+	}
+	return 0
+}
+
+// IntegrityResult is the verification outcome for one attachment
+type IntegrityResult struct {
+	AttachmentID uuid.UUID `json:"attachment_id"`
+	ItemID       uuid.UUID `json:"item_id"`
+	Filename     string    `json:"filename"`
+	Expected     string    `json:"expected_sha256"`
+	Actual       string    `json:"actual_sha256,omitempty"`
+	Status       string    `json:"status"`
+}
+
+// IntegrityReport is the outcome of re-hashing a board's stored evidence
+type IntegrityReport struct {
+	BoardID   uuid.UUID         `json:"board_id"`
+	CheckedAt time.Time         `json:"checked_at"`
+	CheckedBy uuid.UUID         `json:"checked_by"`
+	Intact    bool              `json:"intact"`
+	Counts    map[string]int    `json:"counts"`
+	Results   []IntegrityResult `json:"results"`
+}
+
+// VerifyAttachments re-hashes every stored attachment of a board and compares
+// it with the digest taken on ingest. Each check is recorded in the custody
+// log of the attachment's item. Attachments of items on layers the user
+// cannot see, or above their clearance, are left out.
+func (s *AttachmentService) VerifyAttachments(ctx context.Context, boardID, userID uuid.UUID, clientIP string) (*IntegrityReport, error) {
+	access, err := s.permissions.authorizeAccess(ctx, boardID, userID, models.PermissionWrite)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := access.loadClassified(); err != nil {
+		return nil, err
+	}
+
+	attachments, err := s.attachmentRepo.ListByBoard(boardID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list attachments: %w", err)
+	}
+
+	report := &IntegrityReport{
+		BoardID:   boardID,
+		CheckedAt: time.Now().UTC(),
+		CheckedBy: userID,
+		Intact:    true,
+		Counts:    map[string]int{},
+		Results:   make([]IntegrityResult, 0, len(attachments)),
+	}
+	for i := range attachments {
+		attachment := &attachments[i]
+		if access.hiddenItems[attachment.ItemID] || access.redacted[attachment.ItemID] {
+			continue
+		}
+		result, err := s.verifyAttachment(ctx, attachment)
+		if err != nil {
+			return nil, err
+		}
+		if result.Status != IntegrityUnhashed {
+			detail := result.Status
+			if result.Status == IntegrityMismatch {
+				detail = fmt.Sprintf("mismatch: expected %s", result.Expected)
+			}
+			if err := s.recordCustody(attachment, models.CustodyVerified, userID, result.Actual, clientIP, detail); err != nil {
+				return nil, err
+			}
+		}
+		if result.Status != IntegrityOK {
+			report.Intact = false
+		}
+		report.Counts[result.Status]++
+		report.Results = append(report.Results, result)
+	}
+	return report, nil
+}
+
+func (s *AttachmentService) verifyAttachment(ctx context.Context, attachment *models.Attachment) (IntegrityResult, error) {
+	result := IntegrityResult{
+		AttachmentID: attachment.ID,
+		ItemID:       attachment.ItemID,
+		Filename:     attachment.Filename,
+		Expected:     attachment.SHA256,
+	}
+	if attachment.SHA256 == "" {
+		result.Status = IntegrityUnhashed
+		return result, nil
+	}
+
+	content, err := s.store.Get(ctx, attachment.StorageKey)
+	if errors.Is(err, blob.ErrNotFound) {
+		result.Status = IntegrityMissing
+		return result, nil
+	}
+	if err != nil {
+		return result, fmt.Errorf("failed to open attachment %s: %w", attachment.ID, err)
+	}
+	defer content.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, content); err != nil {
+		return result, fmt.Errorf("failed to read attachment %s: %w", attachment.ID, err)
+	}
+	result.Actual = hex.EncodeToString(hash.Sum(nil))
+	if result.Actual == attachment.SHA256 {
+		result.Status = IntegrityOK
+	} else {
+		result.Status = IntegrityMismatch
+	}
+	return result, nil
+}
+
+// CustodyItem identifies the item a custody log belongs to
+type CustodyItem struct {
+	ID        uuid.UUID `json:"id"`
+	BoardID   uuid.UUID `json:"board_id"`
+	Type      string    `json:"type"`
+	Content   string    `json:"content"`
+	CreatedBy uuid.UUID `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CustodyLog is the exportable chain of custody of an item's evidence
+type CustodyLog struct {
+	Item        CustodyItem           `json:"item"`
+	Attachments []models.Attachment   `json:"attachments"`
+	Events      []models.CustodyEvent `json:"events"`
+	ChainIntact bool                  `json:"chain_intact"`
+	BrokenAtSeq int64                 `json:"broken_at_seq,omitempty"`
+	GeneratedAt time.Time             `json:"generated_at"`
+	GeneratedBy uuid.UUID             `json:"generated_by"`
+}
+
+// GetCustodyLog returns an item's custody log with the state of its hash chain
+func (s *AttachmentService) GetCustodyLog(boardID, itemID, userID uuid.UUID) (*CustodyLog, error) {
+	item, err := s.checkItem(boardID, itemID, userID, false)
+	if err != nil {
+		return nil, err
+	}
+	attachments, err := s.attachmentRepo.ListByItem(itemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list attachments: %w", err)
+	}
+	events, err := s.custodyRepo.ListByItem(itemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list custody events: %w", err)
+	}
+
+	broken := verifyCustodyChain(events)
+	return &CustodyLog{
+		Item: CustodyItem{
+			ID:        item.ID,
+			BoardID:   item.BoardID,
+			Type:      item.Type,
+			Content:   html.UnescapeString(item.Content),
+			CreatedBy: item.CreatedBy,
+			CreatedAt: item.CreatedAt,
+		},
+		Attachments: attachments,
+		Events:      events,
+		ChainIntact: broken == 0,
+		BrokenAtSeq: broken,
+		GeneratedAt: time.Now().UTC(),
+		GeneratedBy: userID,
+	}, nil
+}
+
+// WriteCustodyCSV writes the events of a custody log as CSV, one row per event
+func WriteCustodyCSV(w io.Writer, custody *CustodyLog) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"seq", "time", "action", "user_id", "attachment_id", "sha256", "client_ip", "detail", "prev_hash", "hash"})
+	for _, e := range custody.Events {
+		cw.Write([]string{
+			strconv.FormatInt(e.Seq, 10),
+			e.CreatedAt.UTC().Format(time.RFC3339Nano),
+			string(e.Action),
+			e.UserID.String(),
+			e.AttachmentID.String(),
+			e.SHA256,
+			e.ClientIP,
+			csvSafe(e.Detail),
+			e.PrevHash,
+			e.Hash,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvSafe keeps spreadsheet applications from evaluating a cell as a formula
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
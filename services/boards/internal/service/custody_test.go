@@ -0,0 +1,257 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// memoryCustodyRepository is an in-memory custody log that, like the
+// database, rejects a second event with the same sequence number for an item
+type memoryCustodyRepository struct {
+	mu        sync.Mutex
+	events    []models.CustodyEvent
+	createErr error
+}
+
+func (r *memoryCustodyRepository) Create(event *models.CustodyEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.createErr != nil {
+		return r.createErr
+	}
+	for _, e := range r.events {
+		if e.ItemID == event.ItemID && e.Seq == event.Seq {
+			return errors.New("duplicate key value violates unique constraint")
+		}
+	}
+	r.events = append(r.events, *event)
+	return nil
+}
+
+func (r *memoryCustodyRepository) Last(itemID uuid.UUID) (*models.CustodyEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var last *models.CustodyEvent
+	for i := range r.events {
+		if r.events[i].ItemID == itemID && (last == nil || r.events[i].Seq > last.Seq) {
+			e := r.events[i]
+			last = &e
+		}
+	}
+	return last, nil
+}
+
+func (r *memoryCustodyRepository) ListByItem(itemID uuid.UUID) ([]models.CustodyEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var events []models.CustodyEvent
+	for _, e := range r.events {
+		if e.ItemID == itemID {
+			events = append(events, e)
+		}
+	}
+	for i := 1; i < len(events); i++ {
+		for j := i; j > 0 && events[j].Seq < events[j-1].Seq; j-- {
+			events[j], events[j-1] = events[j-1], events[j]
+		}
+	}
+	return events, nil
+}
+
+func (r *memoryCustodyRepository) DeleteByBoard(boardID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	kept := r.events[:0]
+	for _, e := range r.events {
+		if e.BoardID != boardID {
+			kept = append(kept, e)
+		}
+	}
+	r.events = kept
+	return nil
+}
+
+func (f *attachmentFixture) upload(t *testing.T, userID uuid.UUID, content string) *models.Attachment {
+	attachment, err := f.attachments.UploadAttachment(context.Background(), f.board.ID, f.item.ID, userID, UploadAttachmentRequest{
+		Filename: "notes.txt", Size: int64(len(content)), Content: strings.NewReader(content), ClientIP: "10.0.0.1",
+	})
+	assert.NoError(t, err)
+	return attachment
+}
+
+func TestCustody_ChainOfCustody(t *testing.T) {
+	ctx := context.Background()
+	uploader := uuid.New()
+	reader := uuid.New()
+	f := newAttachmentFixture(t, models.PermissionWrite, AttachmentLimits{})
+	f.item.CreatedBy = uploader
+	f.attachmentRepo.On("TotalSizeByBoard", f.board.ID).Return(int64(0), nil)
+	f.attachmentRepo.On("Create", mock.Anything).Return(nil)
+
+	attachment := f.upload(t, uploader, "witness statement")
+	sum := sha256.Sum256([]byte("witness statement"))
+	assert.Equal(t, hex.EncodeToString(sum[:]), attachment.SHA256)
+
+	f.attachmentRepo.On("GetByID", attachment.ID).Return(attachment, nil)
+	_, rc, err := f.attachments.OpenAttachment(ctx, f.board.ID, f.item.ID, attachment.ID, reader, AttachmentAccess{Inline: true, ClientIP: "10.0.0.2"})
+	assert.NoError(t, err)
+	rc.Close()
+	_, rc, err = f.attachments.OpenAttachment(ctx, f.board.ID, f.item.ID, attachment.ID, reader, AttachmentAccess{})
+	assert.NoError(t, err)
+	rc.Close()
+	f.attachmentRepo.On("Delete", attachment.ID).Return(nil)
+	assert.NoError(t, f.attachments.DeleteAttachment(ctx, f.board.ID, f.item.ID, attachment.ID, uploader, "10.0.0.1"))
+
+	f.attachmentRepo.On("ListByItem", f.item.ID).Return([]models.Attachment{}, nil)
+	custody, err := f.attachments.GetCustodyLog(f.board.ID, f.item.ID, reader)
+	assert.NoError(t, err)
+	assert.Equal(t, uploader, custody.Item.CreatedBy)
+	assert.True(t, custody.ChainIntact)
+	if assert.Len(t, custody.Events, 4) {
+		var actions []models.CustodyAction
+		for _, e := range custody.Events {
+			actions = append(actions, e.Action)
+			assert.Equal(t, attachment.SHA256, e.SHA256)
+		}
+		assert.Equal(t, []models.CustodyAction{models.CustodyUploaded, models.CustodyViewed, models.CustodyDownloaded, models.CustodyDeleted}, actions)
+		assert.Equal(t, uploader, custody.Events[0].UserID)
+		assert.Equal(t, "10.0.0.1", custody.Events[0].ClientIP)
+		assert.Equal(t, "notes.txt (text/plain, 17 bytes)", custody.Events[0].Detail)
+		assert.Equal(t, reader, custody.Events[1].UserID)
+		assert.Equal(t, "", custody.Events[0].PrevHash)
+		assert.Equal(t, custody.Events[0].Hash, custody.Events[1].PrevHash)
+	}
+
+	// Editing any recorded field breaks the chain at that event
+	tampered := append([]models.CustodyEvent(nil), custody.Events...)
+	tampered[2].UserID = uploader
+	assert.Equal(t, int64(3), verifyCustodyChain(tampered))
+	// So does removing an event
+	assert.Equal(t, int64(3), verifyCustodyChain(append(append([]models.CustodyEvent(nil), custody.Events[:1]...), custody.Events[2:]...)))
+	assert.Equal(t, int64(0), verifyCustodyChain(nil))
+}
+
+func TestCustody_VerifyAttachments(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	f := newAttachmentFixture(t, models.PermissionWrite, AttachmentLimits{})
+	f.attachmentRepo.On("TotalSizeByBoard", f.board.ID).Return(int64(0), nil)
+	f.attachmentRepo.On("Create", mock.Anything).Return(nil)
+
+	intact := f.upload(t, userID, "intact")
+	altered := f.upload(t, userID, "original")
+	missing := f.upload(t, userID, "missing")
+	legacy := &models.Attachment{ID: uuid.New(), BoardID: f.board.ID, ItemID: f.item.ID, StorageKey: "legacy"}
+	f.attachmentRepo.On("ListByBoard", f.board.ID).Return([]models.Attachment{*intact, *altered, *missing, *legacy}, nil)
+
+	assert.NoError(t, f.store.Put(ctx, altered.StorageKey, strings.NewReader("forged!!"), 8, ""))
+	assert.NoError(t, f.store.Delete(ctx, missing.StorageKey))
+
+	report, err := f.attachments.VerifyAttachments(ctx, f.board.ID, userID, "10.0.0.3")
+	assert.NoError(t, err)
+	assert.False(t, report.Intact)
+	assert.Equal(t, map[string]int{IntegrityOK: 1, IntegrityMismatch: 1, IntegrityMissing: 1, IntegrityUnhashed: 1}, report.Counts)
+	statuses := map[uuid.UUID]string{}
+	for _, r := range report.Results {
+		statuses[r.AttachmentID] = r.Status
+	}
+	assert.Equal(t, IntegrityOK, statuses[intact.ID])
+	assert.Equal(t, IntegrityMismatch, statuses[altered.ID])
+	assert.Equal(t, IntegrityMissing, statuses[missing.ID])
+	assert.Equal(t, IntegrityUnhashed, statuses[legacy.ID])
+
+	// Every hashed attachment got a verification entry
+	events, _ := f.custodyRepo.ListByItem(f.item.ID)
+	var details []string
+	for _, e := range events {
+		if e.Action == models.CustodyVerified {
+			details = append(details, e.Detail)
+		}
+	}
+	assert.Equal(t, []string{"ok", "mismatch: expected " + altered.SHA256, "missing"}, details)
+	assert.Equal(t, int64(0), verifyCustodyChain(events))
+
+	readOnly := newAttachmentFixture(t, models.PermissionRead, AttachmentLimits{})
+	_, err = readOnly.attachments.VerifyAttachments(ctx, readOnly.board.ID, userID, "")
+	assert.Equal(t, ErrUnauthorized, err)
+}
+
+func TestCustody_FailsClosed(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	f := newAttachmentFixture(t, models.PermissionWrite, AttachmentLimits{})
+	f.attachmentRepo.On("TotalSizeByBoard", f.board.ID).Return(int64(0), nil)
+	f.attachmentRepo.On("Create", mock.Anything).Return(nil)
+	attachment := f.upload(t, userID, "evidence")
+	f.attachmentRepo.On("GetByID", attachment.ID).Return(attachment, nil)
+
+	f.custodyRepo.createErr = errors.New("db down")
+
+	// No content without a custody record
+	_, rc, err := f.attachments.OpenAttachment(ctx, f.board.ID, f.item.ID, attachment.ID, userID, AttachmentAccess{})
+	assert.Error(t, err)
+	assert.Nil(t, rc)
+
+	// No evidence without a custody record
+	var removed *models.Attachment
+	f.attachmentRepo.On("Delete", mock.Anything).Run(func(args mock.Arguments) {
+		removed = &models.Attachment{ID: args.Get(0).(uuid.UUID)}
+	}).Return(nil)
+	_, err = f.attachments.UploadAttachment(ctx, f.board.ID, f.item.ID, userID, UploadAttachmentRequest{
+		Filename: "x.txt", Size: 4, Content: strings.NewReader("more"),
+	})
+	assert.Error(t, err)
+	assert.NotNil(t, removed)
+}
+
+func TestCustody_ConcurrentAppends(t *testing.T) {
+	f := newAttachmentFixture(t, models.PermissionWrite, AttachmentLimits{})
+	attachment := &models.Attachment{ID: uuid.New(), BoardID: f.board.ID, ItemID: f.item.ID}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- f.attachments.recordCustody(attachment, models.CustodyDownloaded, uuid.New(), "", "", "")
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+
+	events, _ := f.custodyRepo.ListByItem(f.item.ID)
+	assert.Len(t, events, 3)
+	assert.Equal(t, int64(0), verifyCustodyChain(events))
+}
+
+func TestWriteCustodyCSV(t *testing.T) {
+	event := models.CustodyEvent{ID: uuid.New(), Seq: 1, Action: models.CustodyUploaded, Detail: "=HYPERLINK(\"x\")", Hash: "abc"}
+	var buf bytes.Buffer
+	assert.NoError(t, WriteCustodyCSV(&buf, &CustodyLog{Events: []models.CustodyEvent{event}}))
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	assert.NoError(t, err)
+	if assert.Len(t, rows, 2) {
+		assert.Equal(t, "seq", rows[0][0])
+		assert.Equal(t, "uploaded", rows[1][2])
+		assert.Equal(t, "'=HYPERLINK(\"x\")", rows[1][7])
+		assert.Equal(t, "abc", rows[1][9])
+	}
+}
@@ -0,0 +1,439 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"evidence-wall/boards-service/internal/itemtype"
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+)
+
+// Custom field limits
+const (
+	MaxCustomFields           = 50
+	MaxCustomFieldLabelLength = 100
+	MaxCustomFieldOptions     = 100
+	MaxCustomFieldTextLength  = 1000
+)
+
+// Custom field keys are lower-case identifiers, e.g. "case_number"
+var customFieldKeyRegex = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
+// textValidator validates and normalizes a piece of user-supplied text
+type textValidator func(text string, maxLength int, fieldName string) (string, error)
+
+// validateCustomFields checks a board's custom field definitions and returns
+// them with labels and options sanitized for storage
+func validateCustomFields(fields []models.CustomField) ([]models.CustomField, error) {
+	return normalizeCustomFields(fields, validateAndSanitizeString)
+}
+
+func normalizeCustomFields(fields []models.CustomField, text textValidator) ([]models.CustomField, error) {
+	if len(fields) > MaxCustomFields {
+		return nil, fmt.Errorf("%w: at most %d custom fields per board", ErrInvalidInput, MaxCustomFields)
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	out := make([]models.CustomField, 0, len(fields))
+	keys := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		if !customFieldKeyRegex.MatchString(field.Key) {
+			return nil, fmt.Errorf("%w: custom field key %q must be a lower-case identifier", ErrInvalidInput, field.Key)
+		}
+		if keys[field.Key] {
+			return nil, fmt.Errorf("%w: duplicate custom field %q", ErrInvalidInput, field.Key)
+		}
+		keys[field.Key] = true
+
+		label, err := text(field.Label, MaxCustomFieldLabelLength, "custom field "+field.Key+" label")
+		if err != nil {
+			return nil, err
+		}
+		if label == "" {
+			return nil, fmt.Errorf("%w: custom field %s needs a label", ErrInvalidInput, field.Key)
+		}
+
+		switch field.Type {
+		case models.CustomFieldText, models.CustomFieldNumber, models.CustomFieldDate, models.CustomFieldUser:
+			if len(field.Options) > 0 {
+				return nil, fmt.Errorf("%w: only enum custom fields have options", ErrInvalidInput)
+			}
+		case models.CustomFieldEnum:
+			if len(field.Options) == 0 || len(field.Options) > MaxCustomFieldOptions {
+				return nil, fmt.Errorf("%w: custom field %s needs 1 to %d options", ErrInvalidInput, field.Key, MaxCustomFieldOptions)
+			}
+		default:
+			return nil, fmt.Errorf("%w: custom field %s has unknown type %q", ErrInvalidInput, field.Key, field.Type)
+		}
+
+		var options []string
+		seen := make(map[string]bool, len(field.Options))
+		for _, option := range field.Options {
+			option, err := text(option, MaxCustomFieldLabelLength, "custom field "+field.Key+" option")
+			if err != nil {
+				return nil, err
+			}
+			if option == "" || seen[option] {
+				return nil, fmt.Errorf("%w: custom field %s has an empty or duplicate option", ErrInvalidInput, field.Key)
+			}
+			seen[option] = true
+			options = append(options, option)
+		}
+
+		var itemTypes []string
+		for _, t := range field.ItemTypes {
+			if _, ok := itemtype.Lookup(t); !ok {
+				return nil, fmt.Errorf("%w: custom field %s applies to unknown item type %q", ErrInvalidInput, field.Key, t)
+			}
+			itemTypes = append(itemTypes, t)
+		}
+
+		out = append(out, models.CustomField{
+			Key:       field.Key,
+			Label:     label,
+			Type:      field.Type,
+			ItemTypes: itemTypes,
+			Options:   options,
+			Required:  field.Required,
+		})
+	}
+	return out, nil
+}
+
+// validateCustomValues checks an item's custom field values against the
+// board's fields for its type and returns them normalized for storage. Null
+// values are dropped; no values are stored as NULL.
+func (s *BoardService) validateCustomValues(board *models.Board, itemType string, values map[string]interface{}) (json.RawMessage, error) {
+	defined := make(map[string]models.CustomField, len(board.CustomFields))
+	for _, field := range board.CustomFields {
+		if field.AppliesTo(itemType) {
+			defined[field.Key] = field
+		}
+	}
+
+	out := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		field, ok := defined[key]
+		if !ok {
+			return nil, fmt.Errorf("%w: custom_values.%s is not a custom field of %s items", ErrInvalidInput, key, itemType)
+		}
+		if value == nil {
+			continue
+		}
+		normalized, err := s.customValue(board, field, value)
+		if err != nil {
+			return nil, err
+		}
+		if normalized != "" {
+			out[key] = normalized
+		}
+	}
+	for _, field := range board.CustomFields {
+		if _, ok := out[field.Key]; !ok && field.Required && field.AppliesTo(itemType) {
+			return nil, fmt.Errorf("%w: custom_values.%s is required", ErrInvalidInput, field.Key)
+		}
+	}
+
+	if len(out) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(out)
+	if err != nil {
+		return nil, fmt.Errorf("%w: custom_values: %v", ErrInvalidInput, err)
+	}
+	return data, nil
+}
+
+// customValue validates a single value; an empty text value is returned as
+// "" and means unset
+func (s *BoardService) customValue(board *models.Board, field models.CustomField, value interface{}) (interface{}, error) {
+	invalid := func(expected string) error {
+		return fmt.Errorf("%w: custom_values.%s must be %s", ErrInvalidInput, field.Key, expected)
+	}
+
+	if field.Type == models.CustomFieldNumber {
+		num, ok := value.(float64)
+		if !ok {
+			return nil, invalid("a number")
+		}
+		return num, nil
+	}
+
+	str, ok := value.(string)
+	if !ok {
+		return nil, invalid("a string")
+	}
+	switch field.Type {
+	case models.CustomFieldText:
+		text, err := validateAndSanitizeString(str, MaxCustomFieldTextLength, "custom_values."+field.Key)
+		if err != nil {
+			return nil, err
+		}
+		return text, nil
+	case models.CustomFieldDate:
+		if _, err := time.Parse("2006-01-02", str); err != nil {
+			return nil, invalid("a date (YYYY-MM-DD)")
+		}
+		return str, nil
+	case models.CustomFieldEnum:
+		// Options are stored escaped, so compare the escaped form
+		option := html.EscapeString(strings.TrimSpace(str))
+		for _, o := range field.Options {
+			if o == option {
+				return option, nil
+			}
+		}
+		return nil, invalid("one of the field's options")
+	case models.CustomFieldUser:
+		id, err := uuid.Parse(str)
+		if err != nil {
+			return nil, invalid("a user ID")
+		}
+		member, err := s.isBoardUser(board, id)
+		if err != nil {
+			return nil, err
+		}
+		if !member {
+			return nil, invalid("a user with access to the board")
+		}
+		return id.String(), nil
+	}
+	return nil, invalid("a supported value")
+}
+
+// isBoardUser reports whether the user owns the board or has been given access to it
+func (s *BoardService) isBoardUser(board *models.Board, userID uuid.UUID) (bool, error) {
+	if board.OwnerID == userID {
+		return true, nil
+	}
+	for _, bu := range board.Users {
+		if bu.UserID == userID {
+			return true, nil
+		}
+	}
+	bu, err := s.boardUserRepo.GetByBoardAndUser(board.ID, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get board user: %w", err)
+	}
+	return bu != nil, nil
+}
+
+// ItemQuery filters and sorts item listings. Custom holds filters on custom
+// field values keyed by field key (custom[<key>]=<value> in the URL): text
+// fields match a case-insensitive substring, enum and user fields any of a
+// comma-separated list, and number and date fields a value or an inclusive
+// "min..max" range with either end optional. Tag keeps items carrying any of
+// the given tag IDs, and BBox ("minX,minY,maxX,maxY") the items overlapping
+// a viewport.
+type ItemQuery struct {
+	Type   []string          `form:"type"`
+	Tag    []string          `form:"tag"`
+	BBox   string            `form:"bbox"`
+	Custom map[string]string `form:"-"`
+	Sort   string            `form:"sort"` // Custom field key, prefixed with "-" to sort descending
+}
+
+func (q ItemQuery) empty() bool {
+	return len(q.Type) == 0 && len(q.Custom) == 0 && q.Sort == ""
+}
+
+// customFilter matches a single decoded custom value
+type customFilter struct {
+	key   string
+	match func(value interface{}) bool
+}
+
+// filterItems applies the query to a board's items
+func filterItems(board *models.Board, items []models.BoardItem, q ItemQuery) ([]models.BoardItem, error) {
+	fields := make(map[string]models.CustomField, len(board.CustomFields))
+	for _, field := range board.CustomFields {
+		fields[field.Key] = field
+	}
+
+	types := make(map[string]bool)
+	for _, t := range splitValues(q.Type) {
+		types[t] = true
+	}
+
+	filters := make([]customFilter, 0, len(q.Custom))
+	for key, value := range q.Custom {
+		field, ok := fields[key]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown custom field %q", ErrInvalidInput, key)
+		}
+		match, err := customMatcher(field, value)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, customFilter{key: key, match: match})
+	}
+
+	var sortField models.CustomField
+	descending := strings.HasPrefix(q.Sort, "-")
+	if q.Sort != "" {
+		field, ok := fields[strings.TrimPrefix(q.Sort, "-")]
+		if !ok {
+			return nil, fmt.Errorf("%w: cannot sort by unknown custom field %q", ErrInvalidInput, strings.TrimPrefix(q.Sort, "-"))
+		}
+		sortField = field
+	}
+
+	type entry struct {
+		item   models.BoardItem
+		values map[string]interface{}
+	}
+	entries := make([]entry, 0, len(items))
+	for _, item := range items {
+		if len(types) > 0 && !types[item.Type] {
+			continue
+		}
+		values := decodeFields(item.CustomValues)
+		matched := true
+		for _, f := range filters {
+			if value, ok := values[f.key]; !ok || !f.match(value) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			entries = append(entries, entry{item: item, values: values})
+		}
+	}
+
+	if sortField.Key != "" {
+		// Items without a value come last in either direction
+		sort.SliceStable(entries, func(i, j int) bool {
+			a, okA := entries[i].values[sortField.Key]
+			b, okB := entries[j].values[sortField.Key]
+			if !okA || !okB {
+				return okA && !okB
+			}
+			cmp := compareCustomValues(sortField.Type, a, b)
+			if descending {
+				return cmp > 0
+			}
+			return cmp < 0
+		})
+	}
+
+	out := make([]models.BoardItem, 0, len(entries))
+	for _, e := range entries {
+		out = append(out, e.item)
+	}
+	return out, nil
+}
+
+// customMatcher parses a filter value for the field's type
+func customMatcher(field models.CustomField, value string) (func(interface{}) bool, error) {
+	value = strings.TrimSpace(value)
+	switch field.Type {
+	case models.CustomFieldText:
+		// Text is stored escaped, so search for the escaped form
+		needle := strings.ToLower(html.EscapeString(value))
+		return func(v interface{}) bool {
+			s, ok := v.(string)
+			return ok && strings.Contains(strings.ToLower(s), needle)
+		}, nil
+
+	case models.CustomFieldEnum, models.CustomFieldUser:
+		options := make(map[string]bool)
+		for _, option := range splitValues([]string{value}) {
+			if field.Type == models.CustomFieldEnum {
+				option = html.EscapeString(option)
+			}
+			options[option] = true
+		}
+		return func(v interface{}) bool {
+			s, ok := v.(string)
+			return ok && options[s]
+		}, nil
+
+	case models.CustomFieldNumber:
+		min, max, err := parseRange(value, func(s string) (interface{}, error) {
+			return strconv.ParseFloat(s, 64)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%w: custom[%s] must be a number or a min..max range", ErrInvalidInput, field.Key)
+		}
+		return rangeMatcher(field.Type, min, max), nil
+
+	case models.CustomFieldDate:
+		min, max, err := parseRange(value, func(s string) (interface{}, error) {
+			_, err := time.Parse("2006-01-02", s)
+			return s, err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%w: custom[%s] must be a date or a min..max range of dates", ErrInvalidInput, field.Key)
+		}
+		return rangeMatcher(field.Type, min, max), nil
+	}
+	return nil, fmt.Errorf("%w: custom field %s cannot be filtered", ErrInvalidInput, field.Key)
+}
+
+// parseRange parses "value", "min..max", "min.." or "..max"; nil bounds are open
+func parseRange(value string, parse func(string) (interface{}, error)) (min, max interface{}, err error) {
+	lo, hi, isRange := strings.Cut(value, "..")
+	if !isRange {
+		hi = lo
+	}
+	if lo == "" && hi == "" {
+		return nil, nil, fmt.Errorf("empty range")
+	}
+	if lo != "" {
+		if min, err = parse(strings.TrimSpace(lo)); err != nil {
+			return nil, nil, err
+		}
+	}
+	if hi != "" {
+		if max, err = parse(strings.TrimSpace(hi)); err != nil {
+			return nil, nil, err
+		}
+	}
+	return min, max, nil
+}
+
+func rangeMatcher(fieldType models.CustomFieldType, min, max interface{}) func(interface{}) bool {
+	return func(v interface{}) bool {
+		if min != nil && compareCustomValues(fieldType, v, min) < 0 {
+			return false
+		}
+		if max != nil && compareCustomValues(fieldType, v, max) > 0 {
+			return false
+		}
+		return true
+	}
+}
+
+// compareCustomValues orders two decoded values of a field type; values of
+// the wrong JSON type compare as equal
+func compareCustomValues(fieldType models.CustomFieldType, a, b interface{}) int {
+	if fieldType == models.CustomFieldNumber {
+		x, okA := a.(float64)
+		y, okB := b.(float64)
+		switch {
+		case !okA || !okB || x == y:
+			return 0
+		case x < y:
+			return -1
+		}
+		return 1
+	}
+	x, okA := a.(string)
+	y, okB := b.(string)
+	if !okA || !okB {
+		return 0
+	}
+	if fieldType == models.CustomFieldText {
+		x, y = strings.ToLower(x), strings.ToLower(y)
+	}
+	return strings.Compare(x, y)
+}
@@ -0,0 +1,233 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestValidateCustomFields(t *testing.T) {
+	fields, err := validateCustomFields([]models.CustomField{
+		{Key: "case_number", Label: "Case <b>number</b>", Type: models.CustomFieldText, Required: true},
+		{Key: "reliability", Label: "Informant reliability", Type: models.CustomFieldEnum, Options: []string{"A", "B & C"},
+			ItemTypes: []string{"suspect-card"}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "Case number", fields[0].Label)
+	assert.Equal(t, []string{"A", "B &amp; C"}, fields[1].Options)
+
+	tests := []struct {
+		name  string
+		field models.CustomField
+	}{
+		{name: "bad key", field: models.CustomField{Key: "Case Number", Label: "Case", Type: models.CustomFieldText}},
+		{name: "missing label", field: models.CustomField{Key: "case", Type: models.CustomFieldText}},
+		{name: "unknown type", field: models.CustomField{Key: "case", Label: "Case", Type: "money"}},
+		{name: "enum without options", field: models.CustomField{Key: "grade", Label: "Grade", Type: models.CustomFieldEnum}},
+		{name: "options on text", field: models.CustomField{Key: "case", Label: "Case", Type: models.CustomFieldText, Options: []string{"a"}}},
+		{name: "duplicate option", field: models.CustomField{Key: "grade", Label: "Grade", Type: models.CustomFieldEnum, Options: []string{"a", "a"}}},
+		{name: "unknown item type", field: models.CustomField{Key: "case", Label: "Case", Type: models.CustomFieldText, ItemTypes: []string{"photo"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := validateCustomFields([]models.CustomField{tt.field})
+			assert.True(t, errors.Is(err, ErrInvalidInput), "got %v", err)
+		})
+	}
+
+	_, err = validateCustomFields([]models.CustomField{
+		{Key: "case", Label: "Case", Type: models.CustomFieldText},
+		{Key: "case", Label: "Case again", Type: models.CustomFieldNumber},
+	})
+	assert.True(t, errors.Is(err, ErrInvalidInput))
+}
+
+func customFieldsBoard(boardID, ownerID uuid.UUID) *models.Board {
+	return &models.Board{ID: boardID, OwnerID: ownerID, CustomFields: []models.CustomField{
+		{Key: "case_number", Label: "Case number", Type: models.CustomFieldText, Required: true},
+		{Key: "reliability", Label: "Reliability", Type: models.CustomFieldEnum, Options: []string{"A", "B &amp; C"},
+			ItemTypes: []string{"suspect-card"}},
+		{Key: "amount", Label: "Amount", Type: models.CustomFieldNumber},
+		{Key: "seen_on", Label: "Seen on", Type: models.CustomFieldDate},
+		{Key: "handler", Label: "Handler", Type: models.CustomFieldUser},
+	}}
+}
+
+func TestBoardService_CreateBoardItemCustomValues(t *testing.T) {
+	boardID := uuid.New()
+	userID := uuid.New()
+	memberID := uuid.New()
+	strangerID := uuid.New()
+
+	tests := []struct {
+		name        string
+		itemType    string
+		values      map[string]interface{}
+		expected    string
+		expectedErr error
+	}{
+		{
+			name:     "valid values",
+			itemType: "suspect-card",
+			values: map[string]interface{}{
+				"case_number": "2024/<i>117</i>",
+				"reliability": "B & C",
+				"amount":      5000.0,
+				"seen_on":     "2024-05-01",
+				"handler":     memberID.String(),
+			},
+			expected: `{"case_number":"2024/117","reliability":"B &amp; C","amount":5000,"seen_on":"2024-05-01","handler":"` + memberID.String() + `"}`,
+		},
+		{
+			name:     "owner is a valid user",
+			itemType: "post-it",
+			values:   map[string]interface{}{"case_number": "1", "handler": userID.String(), "amount": nil},
+			expected: `{"case_number":"1","handler":"` + userID.String() + `"}`,
+		},
+		{name: "missing required value", itemType: "post-it", values: map[string]interface{}{"amount": 1.0}, expectedErr: ErrInvalidInput},
+		{name: "blank required value", itemType: "post-it", values: map[string]interface{}{"case_number": "  "}, expectedErr: ErrInvalidInput},
+		{name: "field not defined for type", itemType: "post-it", values: map[string]interface{}{"case_number": "1", "reliability": "A"}, expectedErr: ErrInvalidInput},
+		{name: "unknown field", itemType: "post-it", values: map[string]interface{}{"case_number": "1", "colour": "red"}, expectedErr: ErrInvalidInput},
+		{name: "invalid option", itemType: "suspect-card", values: map[string]interface{}{"case_number": "1", "reliability": "Z"}, expectedErr: ErrInvalidInput},
+		{name: "number as text", itemType: "post-it", values: map[string]interface{}{"case_number": "1", "amount": "5000"}, expectedErr: ErrInvalidInput},
+		{name: "invalid date", itemType: "post-it", values: map[string]interface{}{"case_number": "1", "seen_on": "01/05/2024"}, expectedErr: ErrInvalidInput},
+		{name: "user without access", itemType: "post-it", values: map[string]interface{}{"case_number": "1", "handler": strangerID.String()}, expectedErr: ErrInvalidInput},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockBoardRepo := new(MockBoardRepository)
+			mockBoardUserRepo := new(MockBoardUserRepository)
+			mockBoardItemRepo := new(MockBoardItemRepository)
+			svc := NewBoardService(mockBoardRepo, mockBoardUserRepo, mockBoardItemRepo, new(MockBoardConnectionRepository), nil, nil, nil, nil)
+
+			mockBoardRepo.On("GetPermission", boardID, userID).Return(true, models.PermissionWrite, nil)
+			mockBoardRepo.On("GetRowByID", boardID).Return(customFieldsBoard(boardID, userID), nil)
+			mockBoardUserRepo.On("GetByBoardAndUser", boardID, memberID).Return(&models.BoardUser{BoardID: boardID, UserID: memberID}, nil)
+			mockBoardUserRepo.On("GetByBoardAndUser", boardID, strangerID).Return(nil, nil)
+			mockBoardItemRepo.On("Create", mock.AnythingOfType("*models.BoardItem")).Return(nil)
+
+			item, err := svc.CreateBoardItem(boardID, userID, CreateItemRequest{
+				Type:         tt.itemType,
+				Content:      "Item",
+				Width:        200,
+				Height:       200,
+				CustomValues: tt.values,
+			})
+			if tt.expectedErr != nil {
+				assert.True(t, errors.Is(err, tt.expectedErr), "got %v", err)
+				mockBoardItemRepo.AssertNotCalled(t, "Create", mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			assert.JSONEq(t, tt.expected, string(item.CustomValues))
+		})
+	}
+}
+
+func TestBoardService_UpdateBoardCustomFields(t *testing.T) {
+	boardID := uuid.New()
+	userID := uuid.New()
+
+	setup := func(permission models.PermissionLevel) (*BoardService, *MockBoardRepository) {
+		mockBoardRepo := new(MockBoardRepository)
+		mockBoardRepo.On("GetPermission", boardID, userID).Return(true, permission, nil)
+		mockBoardRepo.On("GetRowByID", boardID).Return(customFieldsBoard(boardID, userID), nil)
+		mockBoardRepo.On("Update", mock.AnythingOfType("*models.Board")).Return(nil)
+		return NewBoardService(mockBoardRepo, new(MockBoardUserRepository), new(MockBoardItemRepository), new(MockBoardConnectionRepository), nil, nil, nil, nil), mockBoardRepo
+	}
+
+	t.Run("omitted definitions are kept", func(t *testing.T) {
+		svc, _ := setup(models.PermissionAdmin)
+		board, err := svc.UpdateBoard(boardID, userID, UpdateBoardRequest{Title: "Renamed"})
+		assert.NoError(t, err)
+		assert.Len(t, board.CustomFields, 5)
+	})
+
+	t.Run("definitions are replaced", func(t *testing.T) {
+		svc, _ := setup(models.PermissionAdmin)
+		fields := []models.CustomField{{Key: "priority", Label: "Priority", Type: models.CustomFieldNumber}}
+		board, err := svc.UpdateBoard(boardID, userID, UpdateBoardRequest{CustomFields: &fields})
+		assert.NoError(t, err)
+		assert.Equal(t, fields, board.CustomFields)
+	})
+
+	t.Run("invalid definitions are rejected", func(t *testing.T) {
+		svc, mockBoardRepo := setup(models.PermissionAdmin)
+		fields := []models.CustomField{{Key: "priority", Type: models.CustomFieldNumber}}
+		_, err := svc.UpdateBoard(boardID, userID, UpdateBoardRequest{CustomFields: &fields})
+		assert.True(t, errors.Is(err, ErrInvalidInput))
+		mockBoardRepo.AssertNotCalled(t, "Update", mock.Anything)
+	})
+
+	t.Run("only admins define fields", func(t *testing.T) {
+		svc, _ := setup(models.PermissionWrite)
+		fields := []models.CustomField{}
+		_, err := svc.UpdateBoard(boardID, userID, UpdateBoardRequest{CustomFields: &fields})
+		assert.Equal(t, ErrUnauthorized, err)
+	})
+}
+
+func TestBoardService_ListBoardItemsByCustomFields(t *testing.T) {
+	boardID := uuid.New()
+	userID := uuid.New()
+	a := models.BoardItem{ID: uuid.New(), Type: "suspect-card", CustomValues: []byte(`{"case_number":"2024/117","reliability":"A","amount":500,"seen_on":"2024-05-01"}`)}
+	b := models.BoardItem{ID: uuid.New(), Type: "suspect-card", CustomValues: []byte(`{"case_number":"2024/118","reliability":"B &amp; C","amount":5000}`)}
+	c := models.BoardItem{ID: uuid.New(), Type: "post-it", CustomValues: []byte(`{"case_number":"2023/9","amount":50,"seen_on":"2023-12-24"}`)}
+	d := models.BoardItem{ID: uuid.New(), Type: "post-it"}
+
+	mockBoardRepo := new(MockBoardRepository)
+	mockBoardItemRepo := new(MockBoardItemRepository)
+	svc := NewBoardService(mockBoardRepo, new(MockBoardUserRepository), mockBoardItemRepo, new(MockBoardConnectionRepository), nil, nil, nil, nil)
+	mockBoardRepo.On("GetPermission", boardID, userID).Return(true, models.PermissionRead, nil)
+	mockBoardRepo.On("GetRowByID", boardID).Return(customFieldsBoard(boardID, userID), nil)
+	mockBoardItemRepo.On("ListByBoard", boardID).Return([]models.BoardItem{a, b, c, d}, nil)
+
+	ids := func(items []models.BoardItem) []uuid.UUID {
+		out := make([]uuid.UUID, 0, len(items))
+		for _, item := range items {
+			out = append(out, item.ID)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name     string
+		query    ItemQuery
+		expected []uuid.UUID
+	}{
+		{name: "no query", query: ItemQuery{}, expected: []uuid.UUID{a.ID, b.ID, c.ID, d.ID}},
+		{name: "by type", query: ItemQuery{Type: []string{"post-it"}}, expected: []uuid.UUID{c.ID, d.ID}},
+		{name: "text substring", query: ItemQuery{Custom: map[string]string{"case_number": "2024/"}}, expected: []uuid.UUID{a.ID, b.ID}},
+		{name: "enum options", query: ItemQuery{Custom: map[string]string{"reliability": "Z, B & C"}}, expected: []uuid.UUID{b.ID}},
+		{name: "number range", query: ItemQuery{Custom: map[string]string{"amount": "100..1000"}}, expected: []uuid.UUID{a.ID}},
+		{name: "open number range", query: ItemQuery{Custom: map[string]string{"amount": "..500"}}, expected: []uuid.UUID{a.ID, c.ID}},
+		{name: "exact number", query: ItemQuery{Custom: map[string]string{"amount": "5000"}}, expected: []uuid.UUID{b.ID}},
+		{name: "date range", query: ItemQuery{Custom: map[string]string{"seen_on": "2024-01-01.."}}, expected: []uuid.UUID{a.ID}},
+		{name: "sort ascending", query: ItemQuery{Sort: "amount"}, expected: []uuid.UUID{c.ID, a.ID, b.ID, d.ID}},
+		{name: "sort descending keeps missing values last", query: ItemQuery{Sort: "-seen_on"}, expected: []uuid.UUID{a.ID, c.ID, b.ID, d.ID}},
+		{name: "filter and sort", query: ItemQuery{Type: []string{"suspect-card"}, Sort: "-amount"}, expected: []uuid.UUID{b.ID, a.ID}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, err := svc.ListBoardItems(boardID, userID, tt.query)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, ids(items))
+		})
+	}
+
+	for _, query := range []ItemQuery{
+		{Custom: map[string]string{"colour": "red"}},
+		{Custom: map[string]string{"amount": "lots"}},
+		{Custom: map[string]string{"seen_on": "yesterday.."}},
+		{Sort: "-colour"},
+	} {
+		_, err := svc.ListBoardItems(boardID, userID, query)
+		assert.True(t, errors.Is(err, ErrInvalidInput), "%+v", query)
+	}
+}
@@ -0,0 +1,260 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+)
+
+// DuplicateBoardRequest represents a board duplication or fork request
+type DuplicateBoardRequest struct {
+	Title       string                 `json:"title" binding:"omitempty,min=1,max=200"`
+	Visibility  models.BoardVisibility `json:"visibility" binding:"omitempty,oneof=private shared public"`
+	CopySharing bool                   `json:"copy_sharing"`
+	Fork        bool                   `json:"fork"`
+}
+
+// DuplicateBoard deep-copies a board with its items and connections into a new
+// board owned by the caller.
+//
+// A plain duplicate requires write access and keeps the source visibility
+// unless overridden; only admins may copy the sharing list. A fork only
+// requires read access (so viewers of a public board can fork it), records the
+// source as parent board, defaults to private and never copies sharing.
+//
+// The copy is created in one database transaction, so a failure part way
+// leaves no half-copied board behind.
+func (s *BoardService) DuplicateBoard(boardID, userID uuid.UUID, req DuplicateBoardRequest) (*models.Board, error) {
+	source, permission, _, err := s.getBoardContents(boardID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !req.Fork && permission == models.PermissionRead {
+		return nil, ErrUnauthorized
+	}
+	if req.CopySharing && (req.Fork || permission != models.PermissionAdmin) {
+		return nil, ErrUnauthorized
+	}
+
+	title := source.Title + " (copy)"
+	if len(title) > MaxTitleLength {
+		title = source.Title
+	}
+	if req.Title != "" {
+		title, err = validateTitle(req.Title)
+		if err != nil {
+			return nil, fmt.Errorf("title validation failed: %w", err)
+		}
+	}
+
+	visibility := source.Visibility
+	if req.Fork {
+		visibility = models.VisibilityPrivate
+	}
+	if req.Visibility != "" {
+		visibility = req.Visibility
+	}
+
+	board := &models.Board{
+		Title:          title,
+		Description:    source.Description,
+		Visibility:     visibility,
+		OwnerID:        userID,
+		CustomFields:   source.CustomFields,
+		Classification: source.Classification,
+	}
+	if req.Fork {
+		parentID := source.ID
+		board.ParentBoardID = &parentID
+	}
+
+	err = s.inContentTransaction(func(repos ContentRepositories) error {
+		if err := repos.Boards.Create(board); err != nil {
+			return fmt.Errorf("failed to create board: %w", err)
+		}
+
+		// Unlike in CreateBoard a failure here is fatal, as it aborts the
+		// transaction
+		if err := repos.BoardUsers.Create(&models.BoardUser{
+			BoardID:    board.ID,
+			UserID:     userID,
+			Permission: models.PermissionAdmin,
+		}); err != nil {
+			return fmt.Errorf("failed to add owner: %w", err)
+		}
+
+		layerIDs, err := copyLayers(repos, board.ID, userID, source.Layers)
+		if err != nil {
+			return err
+		}
+		if _, err := copyBoardContents(repos, board.ID, userID, source.Items, source.Connections, layerIDs); err != nil {
+			return err
+		}
+
+		if req.CopySharing {
+			for _, bu := range source.Users {
+				if bu.UserID == userID {
+					continue
+				}
+				if err := repos.BoardUsers.Create(&models.BoardUser{
+					BoardID:    board.ID,
+					UserID:     bu.UserID,
+					Permission: bu.Permission,
+					Clearance:  bu.Clearance,
+				}); err != nil {
+					return fmt.Errorf("failed to copy sharing: %w", err)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return board, nil
+}
+
+// inContentTransaction runs fn in a database transaction when the service
+// has one, and on its own repositories otherwise
+func (s *BoardService) inContentTransaction(fn func(repos ContentRepositories) error) error {
+	if s.contentTx == nil {
+		return fn(s.contentRepositories())
+	}
+	return s.contentTx(fn)
+}
+
+// contentRepositories returns the service's own repositories, for copies
+// made outside a transaction
+func (s *BoardService) contentRepositories() ContentRepositories {
+	return ContentRepositories{
+		Boards:      s.boardRepo,
+		BoardUsers:  s.boardUserRepo,
+		Items:       s.boardItemRepo,
+		Connections: s.connectionRepo,
+		Layers:      s.layerRepo,
+	}
+}
+
+// copyBoardContents creates copies of items and connections on the target
+// board, assigning new IDs and remapping connection endpoints and frame
+// membership. Connections whose endpoints are not part of items are skipped,
+// and items whose frame is not part of items go onto the board itself. Items and connections
+// go onto the copies of their layers given in layerIDs, or onto the base
+// layer. It returns the mapping from source item IDs to new item IDs.
+func copyBoardContents(repos ContentRepositories, targetBoardID, userID uuid.UUID, items []models.BoardItem, connections []models.BoardConnection, layerIDs map[uuid.UUID]uuid.UUID) (map[uuid.UUID]uuid.UUID, error) {
+	idMap := make(map[uuid.UUID]uuid.UUID, len(items))
+	for _, src := range items {
+		item := &models.BoardItem{
+			ID:             uuid.New(),
+			BoardID:        targetBoardID,
+			Type:           src.Type,
+			X:              src.X,
+			Y:              src.Y,
+			Width:          src.Width,
+			Height:         src.Height,
+			Rotation:       src.Rotation,
+			ZIndex:         src.ZIndex,
+			Content:        src.Content,
+			Style:          append([]byte(nil), src.Style...),
+			Fields:         append(json.RawMessage(nil), src.Fields...),
+			CustomValues:   append(json.RawMessage(nil), src.CustomValues...),
+			LayerID:        copiedLayer(layerIDs, src.LayerID),
+			Classification: src.Classification,
+			CreatedBy:      userID,
+		}
+		if err := repos.Items.Create(item); err != nil {
+			return nil, fmt.Errorf("failed to copy item: %w", err)
+		}
+		idMap[src.ID] = item.ID
+	}
+
+	// Frames may come after their members, so members join the copies of
+	// their frames once every item exists
+	var parents []uuid.UUID
+	members := make(map[uuid.UUID][]uuid.UUID)
+	for _, src := range items {
+		if src.ParentID == nil {
+			continue
+		}
+		parentID, ok := idMap[*src.ParentID]
+		if !ok {
+			continue
+		}
+		if _, seen := members[parentID]; !seen {
+			parents = append(parents, parentID)
+		}
+		members[parentID] = append(members[parentID], idMap[src.ID])
+	}
+	for i := range parents {
+		if err := repos.Items.SetParent(members[parents[i]], &parents[i]); err != nil {
+			return nil, fmt.Errorf("failed to copy frame members: %w", err)
+		}
+	}
+
+	for _, src := range connections {
+		fromID, okFrom := idMap[src.FromItemID]
+		toID, okTo := idMap[src.ToItemID]
+		if !okFrom || !okTo {
+			continue
+		}
+		direction, _ := validateDirection(src.Direction)
+		conn := &models.BoardConnection{
+			BoardID:          targetBoardID,
+			FromItemID:       fromID,
+			ToItemID:         toID,
+			Label:            src.Label,
+			Direction:        direction,
+			RelationshipType: src.RelationshipType,
+			Confidence:       src.Confidence,
+			Style:            src.Style,
+			LayerID:          copiedLayer(layerIDs, src.LayerID),
+			CreatedBy:        userID,
+		}
+		if err := repos.Connections.Create(conn); err != nil {
+			return nil, fmt.Errorf("failed to copy connection: %w", err)
+		}
+	}
+
+	return idMap, nil
+}
+
+// copyLayers creates copies of layers on the target board and returns the
+// mapping from source layer IDs to new layer IDs
+func copyLayers(repos ContentRepositories, targetBoardID, userID uuid.UUID, layers []models.Layer) (map[uuid.UUID]uuid.UUID, error) {
+	layerIDs := make(map[uuid.UUID]uuid.UUID, len(layers))
+	if repos.Layers == nil {
+		return layerIDs, nil
+	}
+	for _, src := range layers {
+		layer := &models.Layer{
+			BoardID:       targetBoardID,
+			Name:          src.Name,
+			Position:      src.Position,
+			Visible:       src.Visible,
+			Locked:        src.Locked,
+			MinPermission: src.MinPermission,
+			UserIDs:       append([]uuid.UUID(nil), src.UserIDs...),
+			CreatedBy:     userID,
+		}
+		if err := repos.Layers.Create(layer); err != nil {
+			return nil, fmt.Errorf("failed to copy layer: %w", err)
+		}
+		layerIDs[src.ID] = layer.ID
+	}
+	return layerIDs, nil
+}
+
+// copiedLayer returns the copy of a layer, or nil for the base layer
+func copiedLayer(layerIDs map[uuid.UUID]uuid.UUID, layerID *uuid.UUID) *uuid.UUID {
+	if layerID == nil {
+		return nil
+	}
+	if id, ok := layerIDs[*layerID]; ok {
+		return &id
+	}
+	return nil
+}
@@ -0,0 +1,259 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestBoardService_DuplicateBoard(t *testing.T) {
+	boardID := uuid.New()
+	ownerID := uuid.New()
+	viewerID := uuid.New()
+	itemA := uuid.New()
+	itemB := uuid.New()
+
+	source := &models.Board{
+		ID:         boardID,
+		Title:      "Case 42",
+		Visibility: models.VisibilityPublic,
+		OwnerID:    ownerID,
+		Users: []models.BoardUser{
+			{BoardID: boardID, UserID: ownerID, Permission: models.PermissionAdmin},
+			{BoardID: boardID, UserID: viewerID, Permission: models.PermissionRead},
+		},
+		Items: []models.BoardItem{
+			{ID: itemA, BoardID: boardID, Type: "post-it", Content: "A", X: 1, Y: 2},
+			{ID: itemB, BoardID: boardID, Type: "suspect-card", Content: "B", X: 3, Y: 4},
+		},
+		Connections: []models.BoardConnection{
+			{ID: uuid.New(), BoardID: boardID, FromItemID: itemA, ToItemID: itemB, Style: `{"color":"red"}`},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		userID        uuid.UUID
+		permission    models.PermissionLevel
+		request       DuplicateBoardRequest
+		expectedErr   error
+		expectedVis   models.BoardVisibility
+		expectParent  bool
+		expectedUsers int
+	}{
+		{
+			name:          "admin duplicate with sharing",
+			userID:        ownerID,
+			permission:    models.PermissionAdmin,
+			request:       DuplicateBoardRequest{CopySharing: true},
+			expectedVis:   models.VisibilityPublic,
+			expectedUsers: 2, // caller as admin plus the copied viewer
+		},
+		{
+			name:          "read-only viewer forks public board",
+			userID:        viewerID,
+			permission:    models.PermissionRead,
+			request:       DuplicateBoardRequest{Fork: true},
+			expectedVis:   models.VisibilityPrivate,
+			expectParent:  true,
+			expectedUsers: 1,
+		},
+		{
+			name:        "read-only viewer cannot duplicate",
+			userID:      viewerID,
+			permission:  models.PermissionRead,
+			request:     DuplicateBoardRequest{},
+			expectedErr: ErrUnauthorized,
+		},
+		{
+			name:        "fork cannot copy sharing",
+			userID:      viewerID,
+			permission:  models.PermissionRead,
+			request:     DuplicateBoardRequest{Fork: true, CopySharing: true},
+			expectedErr: ErrUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockBoardRepo := new(MockBoardRepository)
+			mockBoardUserRepo := new(MockBoardUserRepository)
+			mockBoardItemRepo := new(MockBoardItemRepository)
+			mockConnectionRepo := new(MockBoardConnectionRepository)
+			svc := NewBoardService(mockBoardRepo, mockBoardUserRepo, mockBoardItemRepo, mockConnectionRepo, nil, nil, nil, nil)
+
+			mockBoardRepo.On("GetByIDWithContents", boardID, tt.userID).Return(source, tt.permission, nil)
+
+			var created *models.Board
+			mockBoardRepo.On("Create", mock.AnythingOfType("*models.Board")).Run(func(args mock.Arguments) {
+				created = args.Get(0).(*models.Board)
+				created.ID = uuid.New()
+			}).Return(nil)
+			var users []*models.BoardUser
+			mockBoardUserRepo.On("Create", mock.AnythingOfType("*models.BoardUser")).Run(func(args mock.Arguments) {
+				users = append(users, args.Get(0).(*models.BoardUser))
+			}).Return(nil)
+			var items []*models.BoardItem
+			mockBoardItemRepo.On("Create", mock.AnythingOfType("*models.BoardItem")).Run(func(args mock.Arguments) {
+				items = append(items, args.Get(0).(*models.BoardItem))
+			}).Return(nil)
+			var conns []*models.BoardConnection
+			mockConnectionRepo.On("Create", mock.AnythingOfType("*models.BoardConnection")).Run(func(args mock.Arguments) {
+				conns = append(conns, args.Get(0).(*models.BoardConnection))
+			}).Return(nil)
+
+			board, err := svc.DuplicateBoard(boardID, tt.userID, tt.request)
+			if tt.expectedErr != nil {
+				assert.Equal(t, tt.expectedErr, err)
+				assert.Nil(t, created)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.userID, board.OwnerID)
+			assert.Equal(t, tt.expectedVis, board.Visibility)
+			assert.Equal(t, "Case 42 (copy)", board.Title)
+			if tt.expectParent {
+				if assert.NotNil(t, board.ParentBoardID) {
+					assert.Equal(t, boardID, *board.ParentBoardID)
+				}
+			} else {
+				assert.Nil(t, board.ParentBoardID)
+			}
+			assert.Len(t, users, tt.expectedUsers)
+
+			if assert.Len(t, items, 2) && assert.Len(t, conns, 1) {
+				for _, item := range items {
+					assert.Equal(t, board.ID, item.BoardID)
+					assert.NotEqual(t, itemA, item.ID)
+					assert.NotEqual(t, itemB, item.ID)
+				}
+				assert.Equal(t, items[0].ID, conns[0].FromItemID)
+				assert.Equal(t, items[1].ID, conns[0].ToItemID)
+				assert.Equal(t, board.ID, conns[0].BoardID)
+				assert.Equal(t, `{"color":"red"}`, conns[0].Style)
+			}
+		})
+	}
+}
+
+func TestBoardService_DuplicateBoardKeepsFrames(t *testing.T) {
+	boardID := uuid.New()
+	userID := uuid.New()
+	frameID := uuid.New()
+	nestedID := uuid.New()
+	// Members listed before their frames, as boards list items in z-order
+	source := &models.Board{
+		ID: boardID, Title: "Case 42",
+		Items: []models.BoardItem{
+			{ID: uuid.New(), BoardID: boardID, Type: "post-it", ParentID: &nestedID},
+			{ID: nestedID, BoardID: boardID, Type: "frame", ParentID: &frameID},
+			{ID: uuid.New(), BoardID: boardID, Type: "post-it", ParentID: &frameID},
+			{ID: frameID, BoardID: boardID, Type: "frame"},
+		},
+	}
+
+	mockBoardRepo := new(MockBoardRepository)
+	mockBoardUserRepo := new(MockBoardUserRepository)
+	mockBoardItemRepo := new(MockBoardItemRepository)
+	svc := NewBoardService(mockBoardRepo, mockBoardUserRepo, mockBoardItemRepo, new(MockBoardConnectionRepository), nil, nil, nil, nil)
+	mockBoardRepo.On("GetByIDWithContents", boardID, userID).Return(source, models.PermissionWrite, nil)
+	mockBoardRepo.On("Create", mock.AnythingOfType("*models.Board")).Return(nil)
+	mockBoardUserRepo.On("Create", mock.AnythingOfType("*models.BoardUser")).Return(nil)
+	var items []*models.BoardItem
+	mockBoardItemRepo.On("Create", mock.AnythingOfType("*models.BoardItem")).Run(func(args mock.Arguments) {
+		item := args.Get(0).(*models.BoardItem)
+		assert.Nil(t, item.ParentID, "frames are joined once every copy exists")
+		items = append(items, item)
+	}).Return(nil)
+	mockBoardItemRepo.On("SetParent", mock.Anything, mock.Anything).Return(nil)
+
+	_, err := svc.DuplicateBoard(boardID, userID, DuplicateBoardRequest{})
+	assert.NoError(t, err)
+	if assert.Len(t, items, 4) {
+		mockBoardItemRepo.AssertCalled(t, "SetParent", []uuid.UUID{items[0].ID}, &items[1].ID)
+		mockBoardItemRepo.AssertCalled(t, "SetParent", []uuid.UUID{items[1].ID, items[2].ID}, &items[3].ID)
+		mockBoardItemRepo.AssertNumberOfCalls(t, "SetParent", 2)
+	}
+}
+
+func TestBoardService_DuplicateBoardKeepsLayerRestrictions(t *testing.T) {
+	boardID := uuid.New()
+	userID := uuid.New()
+	informant := uuid.New()
+	secret := models.Layer{ID: uuid.New(), BoardID: boardID, Name: "Informants", Visible: true, UserIDs: []uuid.UUID{userID, informant}}
+	source := &models.Board{
+		ID: boardID, Title: "Case 42",
+		Users: []models.BoardUser{
+			{BoardID: boardID, UserID: userID, Permission: models.PermissionAdmin},
+			{BoardID: boardID, UserID: uuid.New(), Permission: models.PermissionRead},
+		},
+		Layers: []models.Layer{secret},
+	}
+
+	mockBoardRepo := new(MockBoardRepository)
+	mockBoardUserRepo := new(MockBoardUserRepository)
+	mockLayerRepo := new(MockLayerRepository)
+	svc := NewBoardService(mockBoardRepo, mockBoardUserRepo, new(MockBoardItemRepository), new(MockBoardConnectionRepository), nil, nil, mockLayerRepo, nil)
+	mockBoardRepo.On("GetByIDWithContents", boardID, userID).Return(source, models.PermissionAdmin, nil)
+	mockBoardRepo.On("Create", mock.AnythingOfType("*models.Board")).Return(nil)
+	mockBoardUserRepo.On("Create", mock.AnythingOfType("*models.BoardUser")).Return(nil)
+	mockLayerRepo.On("ListByBoard", boardID).Return([]models.Layer{secret}, nil)
+	var layers []*models.Layer
+	mockLayerRepo.On("Create", mock.AnythingOfType("*models.Layer")).Run(func(args mock.Arguments) {
+		layers = append(layers, args.Get(0).(*models.Layer))
+	}).Return(nil)
+
+	// Sharing the copy must not open the layer to everyone it is shared with
+	_, err := svc.DuplicateBoard(boardID, userID, DuplicateBoardRequest{CopySharing: true})
+	assert.NoError(t, err)
+	if assert.Len(t, layers, 1) {
+		assert.Equal(t, []uuid.UUID{userID, informant}, layers[0].UserIDs)
+	}
+}
+
+func TestBoardService_DuplicateBoardInTransaction(t *testing.T) {
+	boardID := uuid.New()
+	userID := uuid.New()
+	itemID := uuid.New()
+	source := &models.Board{
+		ID: boardID, Title: "Case 42",
+		Items: []models.BoardItem{{ID: itemID, BoardID: boardID, Type: "post-it"}},
+	}
+
+	mockBoardRepo := new(MockBoardRepository)
+	mockBoardItemRepo := new(MockBoardItemRepository)
+	svc := NewBoardService(mockBoardRepo, new(MockBoardUserRepository), mockBoardItemRepo, new(MockBoardConnectionRepository), nil, nil, nil, nil)
+	mockBoardRepo.On("GetByIDWithContents", boardID, userID).Return(source, models.PermissionWrite, nil)
+
+	// The copy is created through the transaction's repositories
+	txBoardRepo := new(MockBoardRepository)
+	txBoardUserRepo := new(MockBoardUserRepository)
+	txItemRepo := new(MockBoardItemRepository)
+	txBoardRepo.On("Create", mock.AnythingOfType("*models.Board")).Return(nil)
+	txBoardUserRepo.On("Create", mock.AnythingOfType("*models.BoardUser")).Return(nil)
+	txItemRepo.On("Create", mock.AnythingOfType("*models.BoardItem")).Return(errors.New("connection reset")).Once()
+	txItemRepo.On("Create", mock.AnythingOfType("*models.BoardItem")).Return(nil).Once()
+	transactions := 0
+	svc.SetContentTransaction(func(fn func(repos ContentRepositories) error) error {
+		transactions++
+		return fn(ContentRepositories{Boards: txBoardRepo, BoardUsers: txBoardUserRepo, Items: txItemRepo, Connections: new(MockBoardConnectionRepository)})
+	})
+
+	// A failed copy fails the whole duplicate, to be rolled back
+	_, err := svc.DuplicateBoard(boardID, userID, DuplicateBoardRequest{})
+	assert.ErrorContains(t, err, "connection reset")
+
+	board, err := svc.DuplicateBoard(boardID, userID, DuplicateBoardRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, "Case 42 (copy)", board.Title)
+	assert.Equal(t, 2, transactions)
+	mockBoardRepo.AssertNotCalled(t, "Create", mock.Anything)
+	mockBoardItemRepo.AssertNotCalled(t, "Create", mock.Anything)
+	txItemRepo.AssertExpectations(t)
+}
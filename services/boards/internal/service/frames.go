@@ -0,0 +1,451 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+)
+
+// Frame limits
+const (
+	MaxFrameDepth   = 8   // Frames nested in one another, the outermost included
+	MaxFrameMembers = 500 // Items moved into or out of a frame in one request
+)
+
+// FrameMembersRequest moves items into a frame, from wherever they were, and
+// out of it, onto the frame's own parent
+type FrameMembersRequest struct {
+	Add    []uuid.UUID `json:"add"`
+	Remove []uuid.UUID `json:"remove"`
+}
+
+func isFrame(item *models.BoardItem) bool {
+	return item.Type == string(models.ItemTypeFrame)
+}
+
+// frameCollapsed reports whether a frame hides its contents
+func frameCollapsed(item *models.BoardItem) bool {
+	var fields struct {
+		Collapsed bool `json:"collapsed"`
+	}
+	if len(item.Fields) == 0 || json.Unmarshal(item.Fields, &fields) != nil {
+		return false
+	}
+	return fields.Collapsed
+}
+
+// getFrame loads a frame of the board that the user can see
+func (s *BoardService) getFrame(boardID, frameID uuid.UUID, access *boardAccess) (*models.BoardItem, error) {
+	frame, err := s.boardItemRepo.GetByID(frameID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get item: %w", err)
+	}
+	if frame == nil || frame.BoardID != boardID || !access.canSeeItem(frame) {
+		return nil, ErrItemNotFound
+	}
+	if !isFrame(frame) {
+		return nil, fmt.Errorf("%w: item %s is not a frame", ErrInvalidInput, frameID)
+	}
+	return frame, nil
+}
+
+// frameAncestry returns the IDs of a frame and of the frames containing it,
+// innermost first
+func (s *BoardService) frameAncestry(frame *models.BoardItem) ([]uuid.UUID, error) {
+	ancestry := []uuid.UUID{frame.ID}
+	parentID := frame.ParentID
+	for parentID != nil {
+		if len(ancestry) >= MaxFrameDepth {
+			return nil, fmt.Errorf("%w: frames can be nested at most %d deep", ErrInvalidInput, MaxFrameDepth)
+		}
+		parent, err := s.boardItemRepo.GetByID(*parentID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get item: %w", err)
+		}
+		if parent == nil {
+			break
+		}
+		ancestry = append(ancestry, parent.ID)
+		parentID = parent.ParentID
+	}
+	return ancestry, nil
+}
+
+// frameHeight is the number of nested frame levels an item brings along into
+// a frame: 0 for other items, 1 for a frame without frames inside, and so on
+func (s *BoardService) frameHeight(item *models.BoardItem) (int, error) {
+	if !isFrame(item) {
+		return 0, nil
+	}
+	if item.ID == uuid.Nil {
+		return 1, nil // Not created yet, so nothing inside
+	}
+	descendants, err := s.boardItemRepo.ListDescendants(item.ID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list frame contents: %w", err)
+	}
+	parents := make(map[uuid.UUID]uuid.UUID, len(descendants))
+	for _, d := range descendants {
+		if d.ParentID != nil {
+			parents[d.ID] = *d.ParentID
+		}
+	}
+	height := 1
+	for _, d := range descendants {
+		if !isFrame(&d) {
+			continue
+		}
+		levels := 2
+		for id := parents[d.ID]; id != item.ID && levels <= MaxFrameDepth; id = parents[id] {
+			levels++
+		}
+		if levels > height {
+			height = levels
+		}
+	}
+	return height, nil
+}
+
+// checkFrameParent verifies that an item can be placed in the frame
+func (s *BoardService) checkFrameParent(boardID, frameID uuid.UUID, item *models.BoardItem, access *boardAccess) error {
+	frame, err := s.getFrame(boardID, frameID, access)
+	if err != nil {
+		return err
+	}
+	ancestry, err := s.frameAncestry(frame)
+	if err != nil {
+		return err
+	}
+	return s.checkFrameMember(ancestry, item)
+}
+
+// checkFrameMember verifies that an item can go into the innermost frame of
+// the ancestry without forming a cycle or nesting frames too deep
+func (s *BoardService) checkFrameMember(ancestry []uuid.UUID, item *models.BoardItem) error {
+	for _, id := range ancestry {
+		if id == item.ID {
+			return fmt.Errorf("%w: a frame cannot contain itself", ErrInvalidInput)
+		}
+	}
+	height, err := s.frameHeight(item)
+	if err != nil {
+		return err
+	}
+	if len(ancestry)+height > MaxFrameDepth {
+		return fmt.Errorf("%w: frames can be nested at most %d deep", ErrInvalidInput, MaxFrameDepth)
+	}
+	return nil
+}
+
+// SetFrameMembers moves items into and out of a frame. Items moved into a
+// frame keep their position; moving a frame moves them along from then on.
+func (s *BoardService) SetFrameMembers(boardID, frameID, userID uuid.UUID, req FrameMembersRequest) ([]models.BoardItem, error) {
+	access, err := s.permissions.authorizeAccess(context.Background(), boardID, userID, models.PermissionWrite)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.Add) == 0 && len(req.Remove) == 0 {
+		return nil, fmt.Errorf("%w: no items to add or remove", ErrInvalidInput)
+	}
+	if len(req.Add)+len(req.Remove) > MaxFrameMembers {
+		return nil, fmt.Errorf("%w: at most %d items can be moved at once", ErrInvalidInput, MaxFrameMembers)
+	}
+
+	frame, err := s.getFrame(boardID, frameID, access)
+	if err != nil {
+		return nil, err
+	}
+	ancestry, err := s.frameAncestry(frame)
+	if err != nil {
+		return nil, err
+	}
+
+	var added, removed []uuid.UUID
+	var updated []models.BoardItem
+	var changes []HistoryChange
+	seen := make(map[uuid.UUID]bool, len(req.Add)+len(req.Remove))
+	move := func(id uuid.UUID, adding bool) error {
+		if seen[id] {
+			return fmt.Errorf("%w: item %s is listed twice", ErrInvalidInput, id)
+		}
+		seen[id] = true
+		item, err := s.boardItemRepo.GetByID(id)
+		if err != nil {
+			return fmt.Errorf("failed to get item: %w", err)
+		}
+		if item == nil || item.BoardID != boardID {
+			return ErrItemNotFound
+		}
+		if err := access.checkItem(item); err != nil {
+			return err
+		}
+		before := stripItem(item)
+		if adding {
+			if item.ParentID != nil && *item.ParentID == frame.ID {
+				return nil
+			}
+			if err := s.checkFrameMember(ancestry, item); err != nil {
+				return err
+			}
+			item.ParentID = &frame.ID
+			added = append(added, id)
+		} else {
+			if item.ParentID == nil || *item.ParentID != frame.ID {
+				return fmt.Errorf("%w: item %s is not in the frame", ErrInvalidInput, id)
+			}
+			item.ParentID = frame.ParentID
+			removed = append(removed, id)
+		}
+		updated = append(updated, *item)
+		changes = append(changes, itemChange(before, item))
+		return nil
+	}
+	for _, id := range req.Add {
+		if err := move(id, true); err != nil {
+			return nil, err
+		}
+	}
+	for _, id := range req.Remove {
+		if err := move(id, false); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.boardItemRepo.SetFrameMembers(frame.ID, added, removed, frame.ParentID); err != nil {
+		return nil, fmt.Errorf("failed to move frame members: %w", err)
+	}
+
+	for i := range updated {
+		s.publishBoardUpdate(boardID, "item_updated", &updated[i])
+	}
+	if len(changes) > 0 {
+		s.recordHistory(boardID, userID, "frame_updated", changes...)
+	}
+	if updated == nil {
+		updated = []models.BoardItem{}
+	}
+	return updated, nil
+}
+
+// moveFrame saves a moved frame and shifts everything inside it by the same
+// offset, in one transaction and as one undoable change. Contents the user
+// cannot change, on hidden or locked layers or above their clearance, stay
+// where they are.
+func (s *BoardService) moveFrame(boardID, userID uuid.UUID, before, frame *models.BoardItem, access *boardAccess) error {
+	contents, err := s.boardItemRepo.ListDescendants(frame.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list frame contents: %w", err)
+	}
+	descendants := make([]models.BoardItem, 0, len(contents))
+	for i := range contents {
+		if access.checkItem(&contents[i]) == nil {
+			descendants = append(descendants, contents[i])
+		}
+	}
+
+	dx, dy := frame.X-before.X, frame.Y-before.Y
+	changes := []HistoryChange{itemChange(before, frame)}
+	for i := range descendants {
+		item := &descendants[i]
+		itemBefore := stripItem(item)
+		item.X += dx
+		item.Y += dy
+		changes = append(changes, itemChange(itemBefore, item))
+	}
+
+	if err := s.boardItemRepo.UpdateWithPositions(frame, descendants); err != nil {
+		return fmt.Errorf("failed to update item: %w", err)
+	}
+
+	s.publishBoardUpdate(boardID, "item_updated", frame)
+	for i := range descendants {
+		s.publishBoardUpdate(boardID, "item_updated", &descendants[i])
+	}
+	s.recordHistory(boardID, userID, "item_updated", changes...)
+	return nil
+}
+
+// deleteFrame deletes a frame and either its contents or, when kept, moves
+// its direct members onto the frame's parent. Contents the user cannot
+// change, on hidden or locked layers, are always kept.
+func (s *BoardService) deleteFrame(boardID, userID uuid.UUID, frame *models.BoardItem, deleteChildren bool, access *boardAccess) error {
+	descendants, err := s.boardItemRepo.ListDescendants(frame.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list frame contents: %w", err)
+	}
+
+	deleted := []uuid.UUID{frame.ID}
+	gone := map[uuid.UUID]bool{frame.ID: true}
+	for i := range descendants {
+		if deleteChildren && access.checkItem(&descendants[i]) == nil {
+			deleted = append(deleted, descendants[i].ID)
+			gone[descendants[i].ID] = true
+		}
+	}
+	var kept []models.BoardItem
+	for _, item := range descendants {
+		if !gone[item.ID] && item.ParentID != nil && gone[*item.ParentID] {
+			kept = append(kept, item)
+		}
+	}
+
+	// Capture everything the deletion touches so it can be undone as a whole
+	var changes []HistoryChange
+	connections, err := s.connectionRepo.ListByItems(boardID, deleted)
+	if err != nil {
+		return fmt.Errorf("failed to list connections: %w", err)
+	}
+	for i := range connections {
+		changes = append(changes, connectionChange(&connections[i], nil))
+	}
+	for i := range descendants {
+		if gone[descendants[i].ID] {
+			changes = append(changes, itemChange(&descendants[i], nil))
+		}
+	}
+	keptIDs := make([]uuid.UUID, len(kept))
+	for i := range kept {
+		before := stripItem(&kept[i])
+		kept[i].ParentID = frame.ParentID
+		keptIDs[i] = kept[i].ID
+		changes = append(changes, itemChange(before, &kept[i]))
+	}
+	changes = append(changes, itemChange(frame, nil))
+
+	if err := s.boardItemRepo.SetParent(keptIDs, frame.ParentID); err != nil {
+		return fmt.Errorf("failed to move items out of frame: %w", err)
+	}
+	if err := s.boardItemRepo.DeleteItems(deleted); err != nil {
+		return fmt.Errorf("failed to delete item: %w", err)
+	}
+	s.deleteAttachments(deleted, userID)
+
+	for i := range kept {
+		s.publishBoardUpdate(boardID, "item_updated", &kept[i])
+	}
+	layers := make(map[uuid.UUID]*uuid.UUID, len(descendants)+1)
+	layers[frame.ID] = frame.LayerID
+	for i := range descendants {
+		layers[descendants[i].ID] = descendants[i].LayerID
+	}
+	for _, id := range deleted {
+		s.publishLayerUpdate(boardID, layers[id], "item_deleted", map[string]interface{}{"id": id})
+	}
+	s.recordHistory(boardID, userID, "item_deleted", changes...)
+	return nil
+}
+
+// collapsedAncestors maps each item hidden in a collapsed frame to the
+// outermost collapsed frame containing it
+func collapsedAncestors(membership []models.BoardItem) map[uuid.UUID]uuid.UUID {
+	parents := make(map[uuid.UUID]uuid.UUID, len(membership))
+	collapsed := make(map[uuid.UUID]bool)
+	for i := range membership {
+		item := &membership[i]
+		if item.ParentID != nil {
+			parents[item.ID] = *item.ParentID
+		}
+		if isFrame(item) && frameCollapsed(item) {
+			collapsed[item.ID] = true
+		}
+	}
+
+	hidden := make(map[uuid.UUID]uuid.UUID)
+	if len(collapsed) == 0 {
+		return hidden
+	}
+	for id := range parents {
+		ancestor, ok := parents[id]
+		for depth := 0; ok && depth < MaxFrameDepth; depth++ {
+			if collapsed[ancestor] {
+				hidden[id] = ancestor
+			}
+			ancestor, ok = parents[ancestor]
+		}
+	}
+	return hidden
+}
+
+// collapsedFrames maps the items of a board hidden in collapsed frames to
+// the outermost collapsed frame containing them, or to uuid.Nil when the
+// user cannot see that frame
+func (s *BoardService) collapsedFrames(boardID uuid.UUID, access *boardAccess) (map[uuid.UUID]uuid.UUID, error) {
+	membership, err := s.boardItemRepo.ListFrameMembership(boardID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list frames: %w", err)
+	}
+	hidden := collapsedAncestors(membership)
+	if len(hidden) == 0 {
+		return hidden, nil
+	}
+	frames := make(map[uuid.UUID]*models.BoardItem)
+	for i := range membership {
+		if isFrame(&membership[i]) {
+			frames[membership[i].ID] = &membership[i]
+		}
+	}
+	for id, frameID := range hidden {
+		if frame := frames[frameID]; frame == nil || !access.canSeeItem(frame) {
+			hidden[id] = uuid.Nil
+		}
+	}
+	return hidden, nil
+}
+
+// collapseFrames leaves out the items hidden in collapsed frames and moves
+// the ends of connections to them onto the frames standing in for them,
+// summed up per pair of ends. Connections are kept when an end is among the
+// remaining items; those within one collapsed frame, or into a frame the user
+// cannot see, are dropped.
+func collapseFrames(items []models.BoardItem, connections []models.BoardConnection, hidden map[uuid.UUID]uuid.UUID) ([]models.BoardItem, []models.BoardConnection, []models.AggregatedConnection) {
+	shown := make([]models.BoardItem, 0, len(items))
+	visible := make(map[uuid.UUID]bool, len(items))
+	for _, item := range items {
+		if _, ok := hidden[item.ID]; !ok {
+			shown = append(shown, item)
+			visible[item.ID] = true
+		}
+	}
+
+	direct := make([]models.BoardConnection, 0, len(connections))
+	aggregated := []models.AggregatedConnection{}
+	index := make(map[[2]uuid.UUID]int)
+	for _, conn := range connections {
+		from, to := conn.FromItemID, conn.ToItemID
+		if frameID, ok := hidden[from]; ok {
+			from = frameID
+		}
+		if frameID, ok := hidden[to]; ok {
+			to = frameID
+		}
+		if from == uuid.Nil || to == uuid.Nil {
+			continue
+		}
+		// Connections to items the filters left out are not visible either
+		if !visible[from] && !visible[to] {
+			continue
+		}
+		if from == conn.FromItemID && to == conn.ToItemID {
+			direct = append(direct, conn)
+			continue
+		}
+		if from == to {
+			continue // Both ends inside the same collapsed frame
+		}
+
+		key := [2]uuid.UUID{from, to}
+		i, ok := index[key]
+		if !ok {
+			i = len(aggregated)
+			index[key] = i
+			aggregated = append(aggregated, models.AggregatedConnection{FromItemID: from, ToItemID: to})
+		}
+		aggregated[i].Count++
+		aggregated[i].ConnectionIDs = append(aggregated[i].ConnectionIDs, conn.ID)
+	}
+	return shown, direct, aggregated
+}
@@ -0,0 +1,298 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newFrame(boardID uuid.UUID, parentID *uuid.UUID, collapsed bool) *models.BoardItem {
+	fields, _ := json.Marshal(map[string]interface{}{"collapsed": collapsed})
+	return &models.BoardItem{ID: uuid.New(), BoardID: boardID, ParentID: parentID, Type: string(models.ItemTypeFrame), Fields: fields}
+}
+
+func TestBoardService_CreateBoardItemInFrame(t *testing.T) {
+	boardID := uuid.New()
+	userID := uuid.New()
+	svc, mockBoardRepo, mockBoardItemRepo, _ := newHistoryTestService()
+	mockBoardRepo.On("GetPermission", boardID, userID).Return(true, models.PermissionWrite, nil)
+	mockBoardRepo.On("GetRowByID", boardID).Return(&models.Board{ID: boardID}, nil)
+	mockBoardItemRepo.On("Create", mock.AnythingOfType("*models.BoardItem")).Return(nil)
+
+	// Frames nested as deep as allowed
+	var innermost *models.BoardItem
+	for depth := 0; depth < MaxFrameDepth; depth++ {
+		var parentID *uuid.UUID
+		if innermost != nil {
+			parentID = &innermost.ID
+		}
+		innermost = newFrame(boardID, parentID, false)
+		mockBoardItemRepo.On("GetByID", innermost.ID).Return(innermost, nil)
+	}
+
+	item, err := svc.CreateBoardItem(boardID, userID, CreateItemRequest{Type: models.ItemTypeNote, Content: "note", ParentID: &innermost.ID})
+	assert.NoError(t, err)
+	assert.Equal(t, innermost.ID, *item.ParentID)
+
+	// Another frame would nest one level too deep
+	_, err = svc.CreateBoardItem(boardID, userID, CreateItemRequest{Type: string(models.ItemTypeFrame), ParentID: &innermost.ID})
+	assert.True(t, errors.Is(err, ErrInvalidInput))
+
+	// Only frames hold items
+	note := &models.BoardItem{ID: uuid.New(), BoardID: boardID, Type: models.ItemTypeNote}
+	mockBoardItemRepo.On("GetByID", note.ID).Return(note, nil)
+	_, err = svc.CreateBoardItem(boardID, userID, CreateItemRequest{Type: models.ItemTypeNote, ParentID: &note.ID})
+	assert.True(t, errors.Is(err, ErrInvalidInput))
+
+	missing := uuid.New()
+	mockBoardItemRepo.On("GetByID", missing).Return(nil, nil)
+	_, err = svc.CreateBoardItem(boardID, userID, CreateItemRequest{Type: models.ItemTypeNote, ParentID: &missing})
+	assert.Equal(t, ErrItemNotFound, err)
+}
+
+func TestBoardService_SetFrameMembers(t *testing.T) {
+	boardID := uuid.New()
+	userID := uuid.New()
+	svc, mockBoardRepo, mockBoardItemRepo, _ := newHistoryTestService()
+	mockBoardRepo.On("GetPermission", boardID, userID).Return(true, models.PermissionWrite, nil)
+
+	outer := newFrame(boardID, nil, false)
+	inner := newFrame(boardID, &outer.ID, false)
+	note := &models.BoardItem{ID: uuid.New(), BoardID: boardID, Type: models.ItemTypeNote}
+	member := &models.BoardItem{ID: uuid.New(), BoardID: boardID, ParentID: &inner.ID, Type: models.ItemTypeNote}
+	for _, item := range []*models.BoardItem{outer, inner, note, member} {
+		mockBoardItemRepo.On("GetByID", item.ID).Return(item, nil)
+	}
+
+	// Added items join the frame, removed ones go up to the frame's parent
+	mockBoardItemRepo.On("SetFrameMembers", inner.ID, []uuid.UUID{note.ID}, []uuid.UUID{member.ID}, &outer.ID).Return(nil).Once()
+	items, err := svc.SetFrameMembers(boardID, inner.ID, userID, FrameMembersRequest{Add: []uuid.UUID{note.ID}, Remove: []uuid.UUID{member.ID}})
+	assert.NoError(t, err)
+	if assert.Len(t, items, 2) {
+		assert.Equal(t, inner.ID, *items[0].ParentID)
+		assert.Equal(t, outer.ID, *items[1].ParentID)
+	}
+
+	// A frame cannot end up inside itself
+	_, err = svc.SetFrameMembers(boardID, inner.ID, userID, FrameMembersRequest{Add: []uuid.UUID{outer.ID}})
+	assert.True(t, errors.Is(err, ErrInvalidInput))
+	_, err = svc.SetFrameMembers(boardID, inner.ID, userID, FrameMembersRequest{Add: []uuid.UUID{inner.ID}})
+	assert.True(t, errors.Is(err, ErrInvalidInput))
+
+	// Only members can be removed
+	_, err = svc.SetFrameMembers(boardID, outer.ID, userID, FrameMembersRequest{Remove: []uuid.UUID{note.ID}})
+	assert.True(t, errors.Is(err, ErrInvalidInput))
+
+	_, err = svc.SetFrameMembers(boardID, note.ID, userID, FrameMembersRequest{Add: []uuid.UUID{member.ID}})
+	assert.True(t, errors.Is(err, ErrInvalidInput))
+
+	mockBoardItemRepo.AssertExpectations(t)
+}
+
+func TestBoardService_MoveFrame(t *testing.T) {
+	boardID := uuid.New()
+	userID := uuid.New()
+	svc, mockBoardRepo, mockBoardItemRepo, _ := newHistoryTestService()
+	mockBoardRepo.On("GetPermission", boardID, userID).Return(true, models.PermissionWrite, nil)
+
+	frame := newFrame(boardID, nil, false)
+	frame.X, frame.Y = 100, 100
+	child := models.BoardItem{ID: uuid.New(), BoardID: boardID, ParentID: &frame.ID, Type: models.ItemTypeNote, X: 150, Y: 120}
+	mockBoardItemRepo.On("GetByID", frame.ID).Return(frame, nil)
+	mockBoardItemRepo.On("ListDescendants", frame.ID).Return([]models.BoardItem{child}, nil)
+
+	var moved []models.BoardItem
+	mockBoardItemRepo.On("UpdateWithPositions", frame, mock.Anything).Run(func(args mock.Arguments) {
+		moved = args.Get(1).([]models.BoardItem)
+	}).Return(nil)
+
+	x, y := 300.0, 50.0
+	_, err := svc.UpdateBoardItem(boardID, frame.ID, userID, UpdateItemRequest{X: &x, Y: &y})
+	assert.NoError(t, err)
+	if assert.Len(t, moved, 1) {
+		assert.Equal(t, 350.0, moved[0].X)
+		assert.Equal(t, 70.0, moved[0].Y)
+	}
+	mockBoardItemRepo.AssertNotCalled(t, "Update", mock.Anything)
+
+	// The frame and its contents are undone as one change
+	entry, err := svc.history.Pop(context.Background(), HistoryStackUndo, boardID, userID)
+	assert.NoError(t, err)
+	if assert.NotNil(t, entry) {
+		assert.Len(t, entry.Changes, 2)
+	}
+}
+
+func TestBoardService_MoveFrameKeepsRestrictedContents(t *testing.T) {
+	f := newLayerFixture(models.PermissionWrite)
+	frame := newFrame(f.board.ID, nil, false)
+	f.itemRepo.On("GetByID", frame.ID).Return(frame, nil)
+	var contents []models.BoardItem
+	for _, item := range []models.BoardItem{f.openItem, f.lockedItem, f.secretItem} {
+		item.ParentID = &frame.ID
+		contents = append(contents, item)
+	}
+	f.itemRepo.On("ListDescendants", frame.ID).Return(contents, nil)
+	var moved []models.BoardItem
+	f.itemRepo.On("UpdateWithPositions", frame, mock.Anything).Run(func(args mock.Arguments) {
+		moved = args.Get(1).([]models.BoardItem)
+	}).Return(nil)
+
+	x := frame.X + 100
+	_, err := f.svc.UpdateBoardItem(f.board.ID, frame.ID, f.userID, UpdateItemRequest{X: &x})
+	assert.NoError(t, err)
+	// Items on locked and hidden layers stay where they are
+	if assert.Len(t, moved, 1) {
+		assert.Equal(t, f.openItem.ID, moved[0].ID)
+		assert.Equal(t, f.openItem.X+100, moved[0].X)
+	}
+}
+
+func TestBoardService_DeleteFrame(t *testing.T) {
+	boardID := uuid.New()
+	userID := uuid.New()
+
+	outer := newFrame(boardID, nil, false)
+	frame := newFrame(boardID, &outer.ID, false)
+	nested := newFrame(boardID, &frame.ID, false)
+	child := models.BoardItem{ID: uuid.New(), BoardID: boardID, ParentID: &frame.ID, Type: models.ItemTypeNote}
+	grandchild := models.BoardItem{ID: uuid.New(), BoardID: boardID, ParentID: &nested.ID, Type: models.ItemTypeNote}
+	descendants := []models.BoardItem{*nested, child, grandchild}
+
+	t.Run("keep children", func(t *testing.T) {
+		svc, mockBoardRepo, mockBoardItemRepo, mockConnectionRepo := newHistoryTestService()
+		mockBoardRepo.On("GetPermission", boardID, userID).Return(true, models.PermissionWrite, nil)
+		mockBoardItemRepo.On("GetByID", frame.ID).Return(frame, nil)
+		mockBoardItemRepo.On("ListDescendants", frame.ID).Return(descendants, nil)
+		mockConnectionRepo.On("ListByItems", boardID, []uuid.UUID{frame.ID}).Return([]models.BoardConnection{}, nil)
+		// Only direct members move up; the nested frame keeps its own
+		mockBoardItemRepo.On("SetParent", []uuid.UUID{nested.ID, child.ID}, &outer.ID).Return(nil)
+		mockBoardItemRepo.On("DeleteItems", []uuid.UUID{frame.ID}).Return(nil)
+
+		assert.NoError(t, svc.DeleteBoardItem(boardID, frame.ID, userID, false))
+		mockBoardItemRepo.AssertExpectations(t)
+	})
+
+	t.Run("delete children", func(t *testing.T) {
+		svc, mockBoardRepo, mockBoardItemRepo, mockConnectionRepo := newHistoryTestService()
+		deleted := []uuid.UUID{frame.ID, nested.ID, child.ID, grandchild.ID}
+		conn := models.BoardConnection{ID: uuid.New(), BoardID: boardID, FromItemID: child.ID, ToItemID: outer.ID}
+		mockBoardRepo.On("GetPermission", boardID, userID).Return(true, models.PermissionWrite, nil)
+		mockBoardItemRepo.On("GetByID", frame.ID).Return(frame, nil)
+		mockBoardItemRepo.On("ListDescendants", frame.ID).Return(descendants, nil)
+		mockConnectionRepo.On("ListByItems", boardID, deleted).Return([]models.BoardConnection{conn}, nil)
+		mockBoardItemRepo.On("SetParent", []uuid.UUID{}, &outer.ID).Return(nil)
+		mockBoardItemRepo.On("DeleteItems", deleted).Return(nil)
+		attachments := &recordingItemAttachments{}
+		svc.SetAttachments(attachments)
+
+		assert.NoError(t, svc.DeleteBoardItem(boardID, frame.ID, userID, true))
+		mockBoardItemRepo.AssertExpectations(t)
+		assert.Equal(t, deleted, attachments.deleted, "attachments go with their items")
+
+		// Everything, the connection included, comes back with one undo
+		entry, err := svc.history.Pop(context.Background(), HistoryStackUndo, boardID, userID)
+		assert.NoError(t, err)
+		if assert.NotNil(t, entry) {
+			assert.Len(t, entry.Changes, 5)
+		}
+	})
+}
+
+func TestCollapsedAncestors(t *testing.T) {
+	boardID := uuid.New()
+	outer := newFrame(boardID, nil, true)
+	inner := newFrame(boardID, &outer.ID, true)
+	open := newFrame(boardID, nil, false)
+	deep := models.BoardItem{ID: uuid.New(), BoardID: boardID, ParentID: &inner.ID}
+	shown := models.BoardItem{ID: uuid.New(), BoardID: boardID, ParentID: &open.ID}
+
+	hidden := collapsedAncestors([]models.BoardItem{*outer, *inner, *open, deep, shown})
+	assert.Equal(t, map[uuid.UUID]uuid.UUID{inner.ID: outer.ID, deep.ID: outer.ID}, hidden)
+}
+
+func TestBoardService_ListViewportCollapsedFrame(t *testing.T) {
+	userID := uuid.New()
+	boardID := uuid.New()
+	frame := newFrame(boardID, nil, true)
+	a := models.BoardItem{ID: uuid.New(), BoardID: boardID, ParentID: &frame.ID, Type: models.ItemTypeNote}
+	b := models.BoardItem{ID: uuid.New(), BoardID: boardID, ParentID: &frame.ID, Type: models.ItemTypeNote}
+	suspect := models.BoardItem{ID: uuid.New(), BoardID: boardID, Type: string(models.ItemTypeSuspectCard)}
+	box := models.BBox{MinX: 0, MinY: 0, MaxX: 1920, MaxY: 1080}
+
+	svc, mockBoardRepo, mockItemRepo, mockConnRepo := newHistoryTestService()
+	mockBoardRepo.On("GetPermission", boardID, userID).Return(true, models.PermissionRead, nil)
+	mockItemRepo.On("ListByBoardInBBox", boardID, box).Return([]models.BoardItem{*frame, a, suspect}, nil)
+	mockItemRepo.On("ListFrameMembership", boardID).Return([]models.BoardItem{*frame, a, b}, nil)
+	mockItemRepo.On("ListClassified", boardID).Return([]models.BoardItem{}, nil)
+
+	// b lies outside the box but its connections still show through the frame
+	fromA := models.BoardConnection{ID: uuid.New(), FromItemID: a.ID, ToItemID: suspect.ID}
+	fromB := models.BoardConnection{ID: uuid.New(), FromItemID: b.ID, ToItemID: suspect.ID}
+	within := models.BoardConnection{ID: uuid.New(), FromItemID: a.ID, ToItemID: b.ID}
+	mockConnRepo.On("ListByBoardInBBox", boardID, box).Return([]models.BoardConnection{fromA, within}, nil)
+	mockConnRepo.On("ListByItems", boardID, mock.Anything).Return([]models.BoardConnection{fromA, fromB, within}, nil)
+
+	viewport, err := svc.ListViewport(boardID, userID, ItemQuery{BBox: "0,0,1920,1080"})
+	assert.NoError(t, err)
+	assert.Len(t, viewport.Items, 2)
+	assert.Empty(t, viewport.Connections)
+	if assert.Len(t, viewport.AggregatedConnections, 1) {
+		agg := viewport.AggregatedConnections[0]
+		assert.Equal(t, frame.ID, agg.FromItemID)
+		assert.Equal(t, suspect.ID, agg.ToItemID)
+		assert.Equal(t, 2, agg.Count)
+		assert.ElementsMatch(t, []uuid.UUID{fromA.ID, fromB.ID}, agg.ConnectionIDs)
+	}
+}
+
+func TestBoardService_GetBoardCollapsedFrame(t *testing.T) {
+	userID := uuid.New()
+	boardID := uuid.New()
+	frame := newFrame(boardID, nil, true)
+	a := models.BoardItem{ID: uuid.New(), BoardID: boardID, ParentID: &frame.ID, Type: models.ItemTypeNote}
+	suspect := models.BoardItem{ID: uuid.New(), BoardID: boardID, Type: string(models.ItemTypeSuspectCard)}
+	note := models.BoardItem{ID: uuid.New(), BoardID: boardID, Type: models.ItemTypeNote}
+	fromA := models.BoardConnection{ID: uuid.New(), BoardID: boardID, FromItemID: a.ID, ToItemID: suspect.ID}
+	direct := models.BoardConnection{ID: uuid.New(), BoardID: boardID, FromItemID: suspect.ID, ToItemID: note.ID}
+	board := &models.Board{ID: boardID, Items: []models.BoardItem{*frame, a, suspect, note}, Connections: []models.BoardConnection{fromA, direct}}
+
+	svc, mockBoardRepo, mockItemRepo, mockConnRepo := newHistoryTestService()
+	mockBoardRepo.On("GetByIDWithContents", boardID, userID).Return(board, models.PermissionRead, nil)
+	mockBoardRepo.On("GetPermission", boardID, userID).Return(true, models.PermissionRead, nil)
+	mockItemRepo.On("ListFrameMembership", boardID).Return([]models.BoardItem{*frame, a}, nil)
+	mockItemRepo.On("ListClassified", boardID).Return([]models.BoardItem{}, nil)
+	mockConnRepo.On("ListByBoardFiltered", boardID, mock.Anything).Return([]models.BoardConnection{fromA, direct}, nil)
+
+	response, err := svc.GetBoard(boardID, userID)
+	assert.NoError(t, err)
+	assert.Len(t, response.Items, 3)
+	assert.Equal(t, []models.BoardConnection{direct}, response.Connections)
+	assert.Equal(t, []models.AggregatedConnection{
+		{FromItemID: frame.ID, ToItemID: suspect.ID, Count: 1, ConnectionIDs: []uuid.UUID{fromA.ID}},
+	}, response.AggregatedConnections)
+
+	connections, err := svc.ListBoardConnections(boardID, userID, ConnectionQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.BoardConnection{direct}, connections)
+}
+
+func TestCollapseFramesUnseenFrame(t *testing.T) {
+	a, b, c := uuid.New(), uuid.New(), uuid.New()
+	items := []models.BoardItem{{ID: a}, {ID: b}, {ID: c}}
+	connections := []models.BoardConnection{{ID: uuid.New(), FromItemID: a, ToItemID: b}, {ID: uuid.New(), FromItemID: b, ToItemID: c}}
+
+	// b is hidden in a collapsed frame the user cannot see, which must not
+	// show up as an end
+	shown, direct, aggregated := collapseFrames(items, connections, map[uuid.UUID]uuid.UUID{b: uuid.Nil})
+	assert.Equal(t, []models.BoardItem{{ID: a}, {ID: c}}, shown)
+	assert.Empty(t, direct)
+	assert.Empty(t, aggregated)
+}
@@ -0,0 +1,457 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"math"
+	"strings"
+
+	"evidence-wall/boards-service/internal/geo"
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+)
+
+// Geo import formats
+const (
+	GeoFormatGeoJSON = "geojson"
+	GeoFormatKML     = "kml"
+)
+
+const (
+	defaultGeoImportSize = 2000
+	maxGeoImportSize     = 20000
+	geoImportMargin      = 100
+	geoPointWidth        = 200
+	geoPointHeight       = 100
+	minGeoAreaSize       = 40
+	maxMercatorLatitude  = 85.05112878
+)
+
+// GeoImportOptions controls where imported features land on the canvas. The
+// features keep their relative positions, projected onto a square of Size
+// canvas units with its top left corner at X, Y; by default the square is
+// placed to the right of the board's items.
+type GeoImportOptions struct {
+	Format string   `form:"format"` // geojson or kml; detected from the data when empty
+	X      *float64 `form:"x"`
+	Y      *float64 `form:"y"`
+	Size   float64  `form:"size"`
+}
+
+// GeoImportResult lists what an import created. Skipped counts the features
+// that became neither an item nor a connection.
+type GeoImportResult struct {
+	Items       []models.BoardItem       `json:"items"`
+	Connections []models.BoardConnection `json:"connections"`
+	Skipped     int                      `json:"skipped"`
+}
+
+// GetGeo returns the board's geo-located items as a GeoJSON feature
+// collection: areas as polygons and other items as points, taken from their
+// lat and lon fields, their metadata or the place their photo was taken.
+// Connections between two geo-located items become line strings.
+func (s *BoardService) GetGeo(boardID, userID uuid.UUID) (*geo.FeatureCollection, error) {
+	_, fc, err := s.geoFeatures(boardID, userID)
+	return fc, err
+}
+
+// ExportKML returns the features of GetGeo as a KML document for Google
+// Earth and other GIS tools
+func (s *BoardService) ExportKML(boardID, userID uuid.UUID) ([]byte, error) {
+	board, fc, err := s.geoFeatures(boardID, userID)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := geo.WriteKML(&buf, board.Title, fc); err != nil {
+		return nil, fmt.Errorf("failed to write KML: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *BoardService) geoFeatures(boardID, userID uuid.UUID) (*models.Board, *geo.FeatureCollection, error) {
+	board, _, _, err := s.getBoardContents(boardID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fc := &geo.FeatureCollection{Features: []geo.Feature{}}
+	located := make(map[uuid.UUID]*geo.Geometry, len(board.Items))
+	for _, item := range board.Items {
+		g := itemGeometry(item)
+		if g == nil {
+			continue
+		}
+		located[item.ID] = g
+		properties := map[string]interface{}{
+			"kind":                  "item",
+			"item_id":               item.ID.String(),
+			"type":                  item.Type,
+			geo.PropertyName:        timelineTitle(item),
+			geo.PropertyDescription: html.UnescapeString(item.Content),
+		}
+		if color := styleString(item.Style, "color"); color != "" {
+			properties[geo.PropertyColor] = color
+		}
+		if address, _ := decodeFields(item.Fields)["address"].(string); address != "" {
+			properties["address"] = html.UnescapeString(address)
+		}
+		if item.Classification != "" {
+			properties["classification"] = string(item.Classification)
+		}
+		fc.Features = append(fc.Features, geo.Feature{ID: item.ID.String(), Geometry: g, Properties: properties})
+	}
+
+	for _, conn := range board.Connections {
+		from, okFrom := located[conn.FromItemID]
+		to, okTo := located[conn.ToItemID]
+		if !okFrom || !okTo || conn.Redacted {
+			continue
+		}
+		properties := map[string]interface{}{
+			"kind":          "connection",
+			"connection_id": conn.ID.String(),
+			"from_item_id":  conn.FromItemID.String(),
+			"to_item_id":    conn.ToItemID.String(),
+			"direction":     string(conn.Direction),
+		}
+		if conn.Label != "" {
+			properties[geo.PropertyName] = html.UnescapeString(conn.Label)
+		}
+		if conn.RelationshipType != "" {
+			properties["relationship_type"] = conn.RelationshipType
+		}
+		if conn.Confidence != "" {
+			properties["confidence"] = string(conn.Confidence)
+		}
+		if color := styleString(json.RawMessage(conn.Style), "color"); color != "" {
+			properties[geo.PropertyColor] = color
+		}
+		line := geo.NewLineString([]geo.Position{from.Center(), to.Center()})
+		fc.Features = append(fc.Features, geo.Feature{ID: conn.ID.String(), Geometry: line, Properties: properties})
+	}
+	return board, fc, nil
+}
+
+// itemGeometry places an item on the map: an area's polygon, or the lat and
+// lon of its fields, its metadata or its photo, in that order
+func itemGeometry(item models.BoardItem) *geo.Geometry {
+	if item.Redacted {
+		return nil
+	}
+	fields := decodeFields(item.Fields)
+	if polygon, ok := fields["polygon"].([]interface{}); ok {
+		ring := make([]geo.Position, 0, len(polygon))
+		for _, vertex := range polygon {
+			v, _ := vertex.(map[string]interface{})
+			if p, ok := latLon(v); ok {
+				ring = append(ring, p)
+			}
+		}
+		if len(ring) >= 3 {
+			return geo.NewPolygon(ring)
+		}
+	}
+	if p, ok := latLon(fields); ok {
+		return geo.NewPoint(p)
+	}
+	var style struct {
+		Metadata map[string]interface{} `json:"metadata"`
+	}
+	if len(item.Style) > 0 {
+		json.Unmarshal(item.Style, &style)
+	}
+	if p, ok := latLon(style.Metadata); ok {
+		return geo.NewPoint(p)
+	}
+	if m := item.EvidenceMetadata; m != nil && m.Latitude != nil && m.Longitude != nil {
+		if p := (geo.Position{*m.Longitude, *m.Latitude}); p.Valid() {
+			return geo.NewPoint(p)
+		}
+	}
+	return nil
+}
+
+// latLon reads numeric lat and lon values
+func latLon(values map[string]interface{}) (geo.Position, bool) {
+	lat, okLat := values["lat"].(float64)
+	lon, okLon := values["lon"].(float64)
+	p := geo.Position{lon, lat}
+	return p, okLat && okLon && p.Valid()
+}
+
+// geoImportItem is an item to create and the geometry it came from
+type geoImportItem struct {
+	item     *models.BoardItem
+	geometry *geo.Geometry
+}
+
+// ImportGeo turns the points and polygons of a GeoJSON or KML document into
+// location and area items on the board, projected so they keep their
+// relative positions. Line strings whose ends are imported points or the
+// centers of imported areas become connections between them; other line
+// strings are skipped. Requires write access.
+func (s *BoardService) ImportGeo(boardID, userID uuid.UUID, data []byte, opts GeoImportOptions) (*GeoImportResult, error) {
+	fc, err := parseGeo(data, opts.Format)
+	if err != nil {
+		return nil, err
+	}
+	if len(fc.Features) > MaxArchiveItems {
+		return nil, fmt.Errorf("%w: more than %d features", ErrInvalidInput, MaxArchiveItems)
+	}
+	size := opts.Size
+	if size == 0 {
+		size = defaultGeoImportSize
+	}
+	if size < minGeoAreaSize || size > maxGeoImportSize {
+		return nil, fmt.Errorf("%w: size must be between %d and %d", ErrInvalidInput, minGeoAreaSize, maxGeoImportSize)
+	}
+
+	board, permission, access, err := s.getBoardContents(boardID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if permission == models.PermissionRead {
+		return nil, ErrUnauthorized
+	}
+	if err := access.checkClassification(board.Classification); err != nil {
+		return nil, err
+	}
+
+	result := &GeoImportResult{Items: []models.BoardItem{}, Connections: []models.BoardConnection{}}
+	var items []geoImportItem
+	var lines []*models.BoardConnection
+	var lineEnds [][2]geo.Position
+	for i, f := range fc.Features {
+		switch f.Geometry.Type {
+		case geo.TypePoint, geo.TypePolygon:
+			item, err := geoItem(f)
+			if err != nil {
+				return nil, fmt.Errorf("features[%d]: %w", i, err)
+			}
+			item.BoardID = boardID
+			item.Classification = board.Classification
+			item.CreatedBy = userID
+			items = append(items, geoImportItem{item: item, geometry: f.Geometry})
+		case geo.TypeLineString:
+			conn, err := geoConnection(f)
+			if err != nil {
+				return nil, fmt.Errorf("features[%d]: %w", i, err)
+			}
+			conn.BoardID = boardID
+			conn.CreatedBy = userID
+			coords := f.Geometry.Coords
+			lines = append(lines, conn)
+			lineEnds = append(lineEnds, [2]geo.Position{coords[0], coords[len(coords)-1]})
+		}
+	}
+
+	x, y := geoImportOrigin(board.Items, opts)
+	placeGeoItems(items, x, y, size)
+
+	byPosition := make(map[geo.Position]uuid.UUID, len(items))
+	for _, it := range items {
+		if err := s.boardItemRepo.Create(it.item); err != nil {
+			return nil, fmt.Errorf("failed to create item: %w", err)
+		}
+		result.Items = append(result.Items, *it.item)
+		if _, taken := byPosition[it.geometry.Center()]; !taken {
+			byPosition[it.geometry.Center()] = it.item.ID
+		}
+	}
+	for i, conn := range lines {
+		from, okFrom := byPosition[lineEnds[i][0]]
+		to, okTo := byPosition[lineEnds[i][1]]
+		if !okFrom || !okTo || from == to {
+			result.Skipped++
+			continue
+		}
+		conn.FromItemID, conn.ToItemID = from, to
+		if err := s.connectionRepo.Create(conn); err != nil {
+			return nil, fmt.Errorf("failed to create connection: %w", err)
+		}
+		result.Connections = append(result.Connections, *conn)
+	}
+
+	changes := make([]HistoryChange, 0, len(result.Items)+len(result.Connections))
+	for i := range result.Items {
+		s.publishBoardUpdate(boardID, "item_created", &result.Items[i])
+		changes = append(changes, itemChange(nil, &result.Items[i]))
+	}
+	for i := range result.Connections {
+		s.publishBoardUpdate(boardID, "connection_created", &result.Connections[i])
+		changes = append(changes, connectionChange(nil, &result.Connections[i]))
+	}
+	s.recordHistory(boardID, userID, "geo_imported", changes...)
+
+	return result, nil
+}
+
+// parseGeo reads GeoJSON or KML, telling them apart by the leading < of XML
+// when the format is not given
+func parseGeo(data []byte, format string) (*geo.FeatureCollection, error) {
+	if format == "" {
+		format = GeoFormatGeoJSON
+		if bytes.HasPrefix(bytes.TrimSpace(data), []byte("<")) {
+			format = GeoFormatKML
+		}
+	}
+	var fc *geo.FeatureCollection
+	var err error
+	switch format {
+	case GeoFormatGeoJSON:
+		fc, err = geo.ParseGeoJSON(data)
+	case GeoFormatKML:
+		fc, err = geo.ParseKML(data)
+	default:
+		return nil, fmt.Errorf("%w: unknown format %q", ErrInvalidInput, format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	return fc, nil
+}
+
+// geoItem builds a location item from a point or an area item from a
+// polygon, titled with the feature's name above its description
+func geoItem(f geo.Feature) (*models.BoardItem, error) {
+	text := strings.TrimSpace(f.String(geo.PropertyName))
+	if description := strings.TrimSpace(f.String(geo.PropertyDescription)); description != "" {
+		text = strings.TrimSpace(text + "\n" + description)
+	}
+	content, err := validateContent(text)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+
+	var itemType models.ItemType
+	values := map[string]interface{}{}
+	if f.Geometry.Type == geo.TypePoint {
+		itemType = models.ItemTypeLocation
+		p := f.Geometry.Coords[0]
+		values["lat"], values["lon"] = p.Lat(), p.Lon()
+	} else {
+		itemType = models.ItemTypeArea
+		outline := f.Geometry.Outline()
+		polygon := make([]interface{}, len(outline))
+		for i, p := range outline {
+			polygon[i] = map[string]interface{}{"lat": p.Lat(), "lon": p.Lon()}
+		}
+		values["polygon"] = polygon
+	}
+	if address := strings.TrimSpace(f.String("address")); address != "" {
+		values["address"] = address
+	}
+	fields, err := validateFields(string(itemType), values)
+	if err != nil {
+		return nil, err
+	}
+
+	item := &models.BoardItem{
+		ID:      uuid.New(),
+		Type:    string(itemType),
+		Content: content,
+		Fields:  fields,
+		ZIndex:  1,
+	}
+	if color := canvasColor(f.String(geo.PropertyColor)); color != "" {
+		item.Style, _ = json.Marshal(map[string]interface{}{"color": color})
+	}
+	return item, nil
+}
+
+// geoConnection builds a connection from a line string, keeping the label,
+// direction, relationship type and confidence of features exported by
+// GetGeo. Values other tools put in those properties are ignored.
+func geoConnection(f geo.Feature) (*models.BoardConnection, error) {
+	label, err := validateLabel(f.String(geo.PropertyName))
+	if err != nil {
+		return nil, err
+	}
+	conn := &models.BoardConnection{Label: label, Direction: models.DirectionNone}
+	if direction, err := validateDirection(models.ConnectionDirection(f.String("direction"))); err == nil && direction != "" {
+		conn.Direction = direction
+	}
+	if relType, err := normalizeRelationshipType(f.String("relationship_type")); err == nil {
+		conn.RelationshipType = relType
+	}
+	if confidence := models.ConfidenceLevel(f.String("confidence")); validateConfidence(confidence) == nil {
+		conn.Confidence = confidence
+	}
+	if color := canvasColor(f.String(geo.PropertyColor)); color != "" {
+		style, _ := json.Marshal(map[string]interface{}{"color": color})
+		conn.Style = string(style)
+	}
+	return conn, nil
+}
+
+// geoImportOrigin is the top left corner of the import, by default right of
+// the board's existing items
+func geoImportOrigin(existing []models.BoardItem, opts GeoImportOptions) (float64, float64) {
+	var x, y float64
+	for i, item := range existing {
+		if i == 0 || item.X+item.Width+geoImportMargin > x {
+			x = item.X + item.Width + geoImportMargin
+		}
+		if i == 0 || item.Y < y {
+			y = item.Y
+		}
+	}
+	if opts.X != nil {
+		x = *opts.X
+	}
+	if opts.Y != nil {
+		y = *opts.Y
+	}
+	return x, y
+}
+
+// mercator projects a position onto the Web Mercator plane, y pointing north
+func mercator(p geo.Position) (float64, float64) {
+	lat := math.Max(-maxMercatorLatitude, math.Min(maxMercatorLatitude, p.Lat()))
+	return p.Lon() * math.Pi / 180, math.Log(math.Tan(math.Pi/4 + lat*math.Pi/360))
+}
+
+// placeGeoItems projects the items into a size by size square at x, y, north
+// up. Points are centered on their position and areas cover their outline.
+func placeGeoItems(items []geoImportItem, x, y, size float64) {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, it := range items {
+		for _, p := range it.geometry.Coords {
+			px, py := mercator(p)
+			minX, maxX = math.Min(minX, px), math.Max(maxX, px)
+			minY, maxY = math.Min(minY, py), math.Max(maxY, py)
+		}
+	}
+	scale := 1.0
+	if extent := math.Max(maxX-minX, maxY-minY); extent > 0 {
+		scale = size / extent
+	}
+	project := func(p geo.Position) (float64, float64) {
+		px, py := mercator(p)
+		return x + (px-minX)*scale, y + (maxY-py)*scale
+	}
+
+	for _, it := range items {
+		if it.geometry.Type == geo.TypePoint {
+			cx, cy := project(it.geometry.Coords[0])
+			it.item.X, it.item.Y = cx-geoPointWidth/2, cy-geoPointHeight/2
+			it.item.Width, it.item.Height = geoPointWidth, geoPointHeight
+			continue
+		}
+		left, top := math.Inf(1), math.Inf(1)
+		right, bottom := math.Inf(-1), math.Inf(-1)
+		for _, p := range it.geometry.Coords {
+			px, py := project(p)
+			left, right = math.Min(left, px), math.Max(right, px)
+			top, bottom = math.Min(top, py), math.Max(bottom, py)
+		}
+		it.item.X, it.item.Y = left, top
+		it.item.Width = math.Max(right-left, minGeoAreaSize)
+		it.item.Height = math.Max(bottom-top, minGeoAreaSize)
+	}
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"evidence-wall/boards-service/internal/geo"
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// geoFixture is a board with a location, an area, a photo taken at the
+// location, a suspect with no place and a redacted location
+type geoFixture struct {
+	*serviceFixture
+	pier  models.BoardItem
+	docks models.BoardItem
+	photo models.BoardItem
+}
+
+func newGeoFixture(permission models.PermissionLevel) *geoFixture {
+	f := &geoFixture{}
+	board := &models.Board{ID: uuid.New(), Title: "Docks"}
+	lat, lon := 51.5, -0.07
+	f.pier = models.BoardItem{ID: uuid.New(), Type: "location", Content: "Pier 4\nMeeting place", X: 0, Y: 0, Width: 200, Height: 100,
+		Style: []byte(`{"color":"#ff8000"}`), Fields: []byte(`{"lat":51.5055,"lon":-0.0754,"address":"Pier 4 &amp; 5"}`)}
+	f.docks = models.BoardItem{ID: uuid.New(), Type: "area", Content: "Docks", X: 300, Y: -50, Width: 400, Height: 400,
+		Fields: []byte(`{"polygon":[{"lat":0,"lon":0},{"lat":0,"lon":2},{"lat":2,"lon":2},{"lat":2,"lon":0}]}`)}
+	f.photo = models.BoardItem{ID: uuid.New(), Type: "post-it", Content: "CCTV still",
+		EvidenceMetadata: &models.EvidenceMetadata{Latitude: &lat, Longitude: &lon}}
+	suspect := models.BoardItem{ID: uuid.New(), Type: "suspect-card", Content: "John Smith"}
+	redacted := models.BoardItem{ID: uuid.New(), Type: "location", Classification: models.ClassificationSecret,
+		Fields: []byte(`{"lat":1,"lon":1}`)}
+	board.Items = []models.BoardItem{f.pier, f.docks, f.photo, suspect, redacted}
+	board.Connections = []models.BoardConnection{
+		{ID: uuid.New(), FromItemID: f.pier.ID, ToItemID: f.docks.ID, Label: "Walked to", Direction: models.DirectionForward, RelationshipType: "went to"},
+		{ID: uuid.New(), FromItemID: suspect.ID, ToItemID: f.pier.ID, RelationshipType: "was at"},
+	}
+	f.serviceFixture = newServiceFixture(board, permission, "")
+	return f
+}
+
+func TestBoardService_GetGeo(t *testing.T) {
+	f := newGeoFixture(models.PermissionRead)
+
+	fc, err := f.svc.GetGeo(f.board.ID, f.userID)
+	assert.NoError(t, err)
+	if !assert.Len(t, fc.Features, 4) {
+		return
+	}
+
+	pier, docks, photo, walk := fc.Features[0], fc.Features[1], fc.Features[2], fc.Features[3]
+	assert.Equal(t, f.pier.ID.String(), pier.ID)
+	assert.Equal(t, geo.NewPoint(geo.Position{-0.0754, 51.5055}), pier.Geometry)
+	assert.Equal(t, map[string]interface{}{
+		"kind": "item", "item_id": f.pier.ID.String(), "type": "location", "name": "Pier 4",
+		"description": "Pier 4\nMeeting place", "color": "#ff8000", "address": "Pier 4 & 5",
+	}, pier.Properties)
+	assert.Equal(t, geo.TypePolygon, docks.Geometry.Type)
+	assert.Len(t, docks.Geometry.Coords, 5, "the ring is closed")
+	assert.Equal(t, geo.NewPoint(geo.Position{-0.07, 51.5}), photo.Geometry)
+
+	// Only the connection between two places becomes a line
+	assert.Equal(t, geo.NewLineString([]geo.Position{{-0.0754, 51.5055}, {1, 1}}), walk.Geometry)
+	assert.Equal(t, "connection", walk.Properties["kind"])
+	assert.Equal(t, "Walked to", walk.Properties["name"])
+	assert.Equal(t, "forward", walk.Properties["direction"])
+	assert.Equal(t, "went to", walk.Properties["relationship_type"])
+}
+
+func TestBoardService_ExportKML(t *testing.T) {
+	f := newGeoFixture(models.PermissionRead)
+
+	data, err := f.svc.ExportKML(f.board.ID, f.userID)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "<name>Docks</name>")
+	assert.Equal(t, 4, bytes.Count(data, []byte("<Placemark")))
+
+	fc, err := geo.ParseKML(data)
+	assert.NoError(t, err)
+	assert.Len(t, fc.Features, 4)
+}
+
+func TestBoardService_ImportGeo(t *testing.T) {
+	f := newGeoFixture(models.PermissionWrite)
+	f.itemRepo.On("Create", mock.Anything).Return(nil)
+	f.connRepo.On("Create", mock.Anything).Return(nil)
+
+	data := []byte(`{"type": "FeatureCollection", "features": [
+		{"type": "Feature", "geometry": {"type": "Point", "coordinates": [0, 0]}, "properties": {"name": "Safe house", "description": "Second floor", "address": "1 High St"}},
+		{"type": "Feature", "geometry": {"type": "Point", "coordinates": [1, 0]}, "properties": {"name": "Garage", "color": "#00FF00"}},
+		{"type": "Feature", "geometry": {"type": "Polygon", "coordinates": [[[0, 0.5], [1, 0.5], [1, 0.9], [0, 0.9], [0, 0.5]]]}, "properties": {"name": "Estate"}},
+		{"type": "Feature", "geometry": {"type": "LineString", "coordinates": [[0, 0], [0.5, 0.1], [1, 0]]}, "properties": {"name": "Drove to", "direction": "forward", "confidence": "sure"}},
+		{"type": "Feature", "geometry": {"type": "LineString", "coordinates": [[0, 0], [5, 5]]}}
+	]}`)
+	size := 1000.0
+	result, err := f.svc.ImportGeo(f.board.ID, f.userID, data, GeoImportOptions{Size: size})
+	assert.NoError(t, err)
+	if !assert.Len(t, result.Items, 3) || !assert.Len(t, result.Connections, 1) {
+		return
+	}
+	assert.Equal(t, 1, result.Skipped)
+
+	house, garage, estate := result.Items[0], result.Items[1], result.Items[2]
+	assert.Equal(t, "location", house.Type)
+	assert.Equal(t, "Safe house\nSecond floor", house.Content)
+	assert.JSONEq(t, `{"lat":0,"lon":0,"address":"1 High St"}`, string(house.Fields))
+	assert.JSONEq(t, `{"color":"#00ff00"}`, string(garage.Style))
+	assert.Equal(t, "area", estate.Type)
+	assert.Equal(t, f.userID, estate.CreatedBy)
+
+	// The import lands right of the existing items, north up, a degree of
+	// longitude spanning the whole square
+	origin := f.docks.X + f.docks.Width + geoImportMargin
+	assert.InDelta(t, origin-geoPointWidth/2, house.X, 0.001)
+	assert.InDelta(t, origin+size-geoPointWidth/2, garage.X, 0.001)
+	assert.InDelta(t, house.Y, garage.Y, 0.001)
+	assert.InDelta(t, origin, estate.X, 0.001)
+	assert.InDelta(t, size, estate.Width, 0.001)
+	assert.Less(t, estate.Y+estate.Height, house.Y+geoPointHeight/2)
+
+	conn := result.Connections[0]
+	assert.Equal(t, house.ID, conn.FromItemID)
+	assert.Equal(t, garage.ID, conn.ToItemID)
+	assert.Equal(t, "Drove to", conn.Label)
+	assert.Equal(t, models.DirectionForward, conn.Direction)
+	assert.Empty(t, conn.Confidence, "unknown values are dropped")
+
+	// KML is recognised without a format, at the given origin
+	kml := []byte(`<kml><Placemark><name>Pier</name><Point><coordinates>3,4</coordinates></Point></Placemark></kml>`)
+	x, y := 10.0, 20.0
+	result, err = f.svc.ImportGeo(f.board.ID, f.userID, kml, GeoImportOptions{X: &x, Y: &y})
+	assert.NoError(t, err)
+	if assert.Len(t, result.Items, 1) {
+		assert.Equal(t, 10.0-geoPointWidth/2, result.Items[0].X)
+		assert.Equal(t, 20.0-geoPointHeight/2, result.Items[0].Y)
+		var fields map[string]float64
+		json.Unmarshal(result.Items[0].Fields, &fields)
+		assert.Equal(t, map[string]float64{"lat": 4, "lon": 3}, fields)
+	}
+}
+
+func TestBoardService_ImportGeo_Rejected(t *testing.T) {
+	f := newGeoFixture(models.PermissionWrite)
+	point := []byte(`{"type": "Point", "coordinates": [0, 0]}`)
+
+	for _, tc := range []struct {
+		data []byte
+		opts GeoImportOptions
+	}{
+		{[]byte(`{"type": "Point", "coordinates": [0, 100]}`), GeoImportOptions{}},
+		{point, GeoImportOptions{Format: "shapefile"}},
+		{point, GeoImportOptions{Format: GeoFormatKML}},
+		{point, GeoImportOptions{Size: 1e6}},
+	} {
+		_, err := f.svc.ImportGeo(f.board.ID, f.userID, tc.data, tc.opts)
+		assert.True(t, errors.Is(err, ErrInvalidInput), "%s %+v", tc.data, tc.opts)
+	}
+	f.itemRepo.AssertNotCalled(t, "Create", mock.Anything)
+
+	f = newGeoFixture(models.PermissionRead)
+	_, err := f.svc.ImportGeo(f.board.ID, f.userID, point, GeoImportOptions{})
+	assert.Equal(t, ErrUnauthorized, err)
+}
@@ -0,0 +1,558 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"sort"
+	"strconv"
+	"strings"
+
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported export format")
+
+// GraphFormat is a standard graph file format a board can be exported to
+type GraphFormat string
+
+const (
+	GraphFormatGraphML GraphFormat = "graphml"
+	GraphFormatGEXF    GraphFormat = "gexf"
+	GraphFormatDOT     GraphFormat = "dot"
+)
+
+// graphAttr is a typed node or edge attribute declaration
+type graphAttr struct {
+	Name string
+	Type string // string, double or int
+}
+
+// graphNode and graphEdge are the format independent view of a board that
+// the GraphML, GEXF and DOT writers render
+type graphNode struct {
+	ID    string
+	Label string
+	X, Y  float64
+	W, H  float64
+	Color string
+	Attrs map[string]string
+}
+
+type graphEdge struct {
+	ID        string
+	Source    string
+	Target    string
+	Label     string
+	Color     string
+	Direction models.ConnectionDirection
+	Attrs     map[string]string
+}
+
+type boardGraph struct {
+	Title     string
+	Nodes     []graphNode
+	Edges     []graphEdge
+	NodeAttrs []graphAttr
+	EdgeAttrs []graphAttr
+}
+
+// Node attributes every item has, in declaration order
+var fixedNodeAttrs = []graphAttr{
+	{"type", "string"},
+	{"content", "string"},
+	{"x", "double"},
+	{"y", "double"},
+	{"width", "double"},
+	{"height", "double"},
+	{"rotation", "double"},
+	{"z_index", "int"},
+	{"color", "string"},
+}
+
+// Edge attributes every connection has, in declaration order
+var fixedEdgeAttrs = []graphAttr{
+	{"label", "string"},
+	{"relationship_type", "string"},
+	{"direction", "string"},
+	{"confidence", "string"},
+}
+
+// ExportGraph renders a board as a graph file: items become nodes carrying
+// their type, content, geometry and style metadata as attributes, and
+// connections become edges carrying their label, relationship type,
+// direction, confidence and style. Read access is sufficient.
+func (s *BoardService) ExportGraph(boardID, userID uuid.UUID, format GraphFormat) ([]byte, error) {
+	var render func(*boardGraph) ([]byte, error)
+	switch format {
+	case GraphFormatGraphML:
+		render = renderGraphML
+	case GraphFormatGEXF:
+		render = renderGEXF
+	case GraphFormatDOT:
+		render = renderDOT
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+
+	archive, err := s.ExportBoard(boardID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return render(buildBoardGraph(archive))
+}
+
+// buildBoardGraph flattens an archive into nodes and edges with string
+// attributes. Item fields become "fields.<key>" attributes, item style
+// metadata becomes "metadata.<key>" attributes and connection style keys
+// become edge attributes. An edge is labelled with its
+// connection label, or failing that its relationship type.
+func buildBoardGraph(archive *BoardArchive) *boardGraph {
+	g := &boardGraph{Title: html.UnescapeString(archive.Board.Title)}
+
+	metaKeys := map[string]bool{}
+	for _, item := range archive.Items {
+		content := html.UnescapeString(item.Content)
+		node := graphNode{
+			ID:    item.ID.String(),
+			Label: graphLabel(content),
+			X:     item.X,
+			Y:     item.Y,
+			W:     item.Width,
+			H:     item.Height,
+			Color: styleString(item.Style, "color"),
+			Attrs: map[string]string{
+				"type":     item.Type,
+				"content":  content,
+				"x":        formatFloat(item.X),
+				"y":        formatFloat(item.Y),
+				"width":    formatFloat(item.Width),
+				"height":   formatFloat(item.Height),
+				"rotation": formatFloat(item.Rotation),
+				"z_index":  strconv.Itoa(item.ZIndex),
+			},
+		}
+		if node.Color != "" {
+			node.Attrs["color"] = node.Color
+		}
+
+		var style struct {
+			Metadata map[string]interface{} `json:"metadata"`
+		}
+		if len(item.Style) > 0 {
+			json.Unmarshal(item.Style, &style)
+		}
+		for key, value := range style.Metadata {
+			name := "metadata." + key
+			node.Attrs[name] = attrString(value)
+			metaKeys[name] = true
+		}
+		for key, value := range decodeFields(item.Fields) {
+			name := "fields." + key
+			node.Attrs[name] = html.UnescapeString(attrString(value))
+			metaKeys[name] = true
+		}
+		g.Nodes = append(g.Nodes, node)
+	}
+	g.NodeAttrs = append(append([]graphAttr(nil), fixedNodeAttrs...), sortedAttrs(metaKeys)...)
+
+	edgeKeys := map[string]bool{}
+	for _, conn := range archive.Connections {
+		direction, _ := validateDirection(conn.Direction)
+		edge := graphEdge{
+			ID:        conn.ID.String(),
+			Source:    conn.FromItemID.String(),
+			Target:    conn.ToItemID.String(),
+			Direction: direction,
+			Attrs:     map[string]string{"direction": string(direction)},
+		}
+		var style map[string]interface{}
+		if len(conn.Style) > 0 {
+			json.Unmarshal(conn.Style, &style)
+		}
+		for key, value := range style {
+			if key == "label" {
+				continue
+			}
+			edge.Attrs[key] = attrString(value)
+			edgeKeys[key] = true
+		}
+		if label := connectionLabel(conn.Label, conn.Style); label != "" {
+			edge.Attrs["label"] = label
+		}
+		if conn.RelationshipType != "" {
+			edge.Attrs["relationship_type"] = conn.RelationshipType
+		}
+		if conn.Confidence != "" {
+			edge.Attrs["confidence"] = string(conn.Confidence)
+		}
+		edge.Label = edge.Attrs["label"]
+		if edge.Label == "" {
+			edge.Label = conn.RelationshipType
+		}
+		edge.Color = edge.Attrs["color"]
+		g.Edges = append(g.Edges, edge)
+	}
+	for _, attr := range fixedEdgeAttrs {
+		delete(edgeKeys, attr.Name)
+	}
+	g.EdgeAttrs = append(append([]graphAttr(nil), fixedEdgeAttrs...), sortedAttrs(edgeKeys)...)
+
+	return g
+}
+
+func sortedAttrs(keys map[string]bool) []graphAttr {
+	attrs := make([]graphAttr, 0, len(keys))
+	for key := range keys {
+		attrs = append(attrs, graphAttr{Name: key, Type: "string"})
+	}
+	sort.Slice(attrs, func(i, j int) bool { return attrs[i].Name < attrs[j].Name })
+	return attrs
+}
+
+// attrString renders a JSON value as an attribute string; strings are used
+// as-is and anything else is JSON encoded
+func attrString(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+// graphLabel uses the first line of an item's content as its node label
+func graphLabel(content string) string {
+	label := strings.TrimSpace(strings.SplitN(strings.TrimSpace(content), "\n", 2)[0])
+	if runes := []rune(label); len(runes) > 60 {
+		label = string(runes[:60]) + "…"
+	}
+	return label
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// parseHexColor parses #rgb or #rrggbb
+func parseHexColor(color string) (r, g, b uint8, ok bool) {
+	hex := strings.TrimPrefix(color, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 || !strings.HasPrefix(color, "#") {
+		return 0, 0, 0, false
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return 0, 0, 0, false
+	}
+	return uint8(v >> 16), uint8(v >> 8), uint8(v), true
+}
+
+// GraphML (http://graphml.graphdrawing.org)
+
+type graphMLDoc struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID       string `xml:"id,attr"`
+	For      string `xml:"for,attr"`
+	AttrName string `xml:"attr.name,attr"`
+	AttrType string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Data        []graphMLData `xml:"data"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	ID       string        `xml:"id,attr"`
+	Source   string        `xml:"source,attr"`
+	Target   string        `xml:"target,attr"`
+	Directed string        `xml:"directed,attr,omitempty"`
+	Data     []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+func renderGraphML(g *boardGraph) ([]byte, error) {
+	doc := graphMLDoc{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys:  []graphMLKey{{ID: "g_title", For: "graph", AttrName: "title", AttrType: "string"}},
+		Graph: graphMLGraph{
+			ID:          "board",
+			EdgeDefault: "undirected",
+			Data:        []graphMLData{{Key: "g_title", Value: g.Title}},
+		},
+	}
+
+	nodeKeys := make(map[string]string, len(g.NodeAttrs))
+	for i, attr := range g.NodeAttrs {
+		id := fmt.Sprintf("n%d", i)
+		nodeKeys[attr.Name] = id
+		doc.Keys = append(doc.Keys, graphMLKey{ID: id, For: "node", AttrName: attr.Name, AttrType: attr.Type})
+	}
+	edgeKeys := make(map[string]string, len(g.EdgeAttrs))
+	for i, attr := range g.EdgeAttrs {
+		id := fmt.Sprintf("e%d", i)
+		edgeKeys[attr.Name] = id
+		doc.Keys = append(doc.Keys, graphMLKey{ID: id, For: "edge", AttrName: attr.Name, AttrType: attr.Type})
+	}
+
+	for _, n := range g.Nodes {
+		node := graphMLNode{ID: n.ID}
+		for _, attr := range g.NodeAttrs {
+			if value, ok := n.Attrs[attr.Name]; ok {
+				node.Data = append(node.Data, graphMLData{Key: nodeKeys[attr.Name], Value: value})
+			}
+		}
+		doc.Graph.Nodes = append(doc.Graph.Nodes, node)
+	}
+	for _, e := range g.Edges {
+		edge := graphMLEdge{ID: e.ID, Source: e.Source, Target: e.Target}
+		// GraphML has no two-way edges; those stay undirected with direction=both
+		if e.Direction == models.DirectionForward {
+			edge.Directed = "true"
+		}
+		for _, attr := range g.EdgeAttrs {
+			if value, ok := e.Attrs[attr.Name]; ok {
+				edge.Data = append(edge.Data, graphMLData{Key: edgeKeys[attr.Name], Value: value})
+			}
+		}
+		doc.Graph.Edges = append(doc.Graph.Edges, edge)
+	}
+
+	return marshalXML(doc)
+}
+
+// GEXF 1.3 (https://gexf.net) with viz extensions for position, size and color
+
+type gexfDoc struct {
+	XMLName xml.Name  `xml:"gexf"`
+	XMLNS   string    `xml:"xmlns,attr"`
+	VizNS   string    `xml:"xmlns:viz,attr"`
+	Version string    `xml:"version,attr"`
+	Meta    gexfMeta  `xml:"meta"`
+	Graph   gexfGraph `xml:"graph"`
+}
+
+type gexfMeta struct {
+	Creator     string `xml:"creator"`
+	Description string `xml:"description"`
+}
+
+type gexfGraph struct {
+	DefaultEdgeType string           `xml:"defaultedgetype,attr"`
+	Mode            string           `xml:"mode,attr"`
+	Attributes      []gexfAttributes `xml:"attributes"`
+	Nodes           []gexfNode       `xml:"nodes>node"`
+	Edges           []gexfEdge       `xml:"edges>edge"`
+}
+
+type gexfAttributes struct {
+	Class     string          `xml:"class,attr"`
+	Attribute []gexfAttribute `xml:"attribute"`
+}
+
+type gexfAttribute struct {
+	ID    string `xml:"id,attr"`
+	Title string `xml:"title,attr"`
+	Type  string `xml:"type,attr"`
+}
+
+type gexfNode struct {
+	ID        string         `xml:"id,attr"`
+	Label     string         `xml:"label,attr"`
+	AttValues []gexfAttValue `xml:"attvalues>attvalue,omitempty"`
+	Position  gexfPosition   `xml:"viz:position"`
+	Size      *gexfSize      `xml:"viz:size,omitempty"`
+	Color     *gexfColor     `xml:"viz:color,omitempty"`
+}
+
+type gexfEdge struct {
+	ID        string         `xml:"id,attr"`
+	Source    string         `xml:"source,attr"`
+	Target    string         `xml:"target,attr"`
+	Type      string         `xml:"type,attr,omitempty"`
+	Label     string         `xml:"label,attr,omitempty"`
+	AttValues []gexfAttValue `xml:"attvalues>attvalue,omitempty"`
+	Color     *gexfColor     `xml:"viz:color,omitempty"`
+}
+
+// gexfEdgeTypes maps connection directions to GEXF edge types
+var gexfEdgeTypes = map[models.ConnectionDirection]string{
+	models.DirectionForward: "directed",
+	models.DirectionBoth:    "mutual",
+}
+
+type gexfAttValue struct {
+	For   string `xml:"for,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type gexfPosition struct {
+	X float64 `xml:"x,attr"`
+	Y float64 `xml:"y,attr"`
+	Z float64 `xml:"z,attr"`
+}
+
+type gexfSize struct {
+	Value float64 `xml:"value,attr"`
+}
+
+type gexfColor struct {
+	R uint8 `xml:"r,attr"`
+	G uint8 `xml:"g,attr"`
+	B uint8 `xml:"b,attr"`
+}
+
+func gexfColorOf(color string) *gexfColor {
+	r, g, b, ok := parseHexColor(color)
+	if !ok {
+		return nil
+	}
+	return &gexfColor{R: r, G: g, B: b}
+}
+
+func renderGEXF(g *boardGraph) ([]byte, error) {
+	doc := gexfDoc{
+		XMLNS:   "http://gexf.net/1.3",
+		VizNS:   "http://gexf.net/1.3/viz",
+		Version: "1.3",
+		Meta:    gexfMeta{Creator: "Evidence Wall", Description: g.Title},
+		Graph:   gexfGraph{DefaultEdgeType: "undirected", Mode: "static"},
+	}
+
+	gexfType := map[string]string{"string": "string", "double": "double", "int": "integer"}
+	nodeAttrs := gexfAttributes{Class: "node"}
+	for i, attr := range g.NodeAttrs {
+		nodeAttrs.Attribute = append(nodeAttrs.Attribute, gexfAttribute{ID: strconv.Itoa(i), Title: attr.Name, Type: gexfType[attr.Type]})
+	}
+	edgeAttrs := gexfAttributes{Class: "edge"}
+	for i, attr := range g.EdgeAttrs {
+		edgeAttrs.Attribute = append(edgeAttrs.Attribute, gexfAttribute{ID: strconv.Itoa(i), Title: attr.Name, Type: gexfType[attr.Type]})
+	}
+	doc.Graph.Attributes = []gexfAttributes{nodeAttrs, edgeAttrs}
+
+	for _, n := range g.Nodes {
+		node := gexfNode{
+			ID:    n.ID,
+			Label: n.Label,
+			// GEXF's y axis points up, the board's points down
+			Position: gexfPosition{X: n.X + n.W/2, Y: -(n.Y + n.H/2)},
+			Size:     &gexfSize{Value: (n.W + n.H) / 4},
+			Color:    gexfColorOf(n.Color),
+		}
+		for i, attr := range g.NodeAttrs {
+			if value, ok := n.Attrs[attr.Name]; ok {
+				node.AttValues = append(node.AttValues, gexfAttValue{For: strconv.Itoa(i), Value: value})
+			}
+		}
+		doc.Graph.Nodes = append(doc.Graph.Nodes, node)
+	}
+	for _, e := range g.Edges {
+		edge := gexfEdge{
+			ID:     e.ID,
+			Source: e.Source,
+			Target: e.Target,
+			Type:   gexfEdgeTypes[e.Direction],
+			Label:  e.Label,
+			Color:  gexfColorOf(e.Color),
+		}
+		for i, attr := range g.EdgeAttrs {
+			if value, ok := e.Attrs[attr.Name]; ok {
+				edge.AttValues = append(edge.AttValues, gexfAttValue{For: strconv.Itoa(i), Value: value})
+			}
+		}
+		doc.Graph.Edges = append(doc.Graph.Edges, edge)
+	}
+
+	return marshalXML(doc)
+}
+
+func marshalXML(doc interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return nil, fmt.Errorf("failed to encode graph: %w", err)
+	}
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
+
+// Graphviz DOT. Positions are emitted in points with y flipped and pinned
+// ("!") so neato -n / fdp keep the board layout. The graph is undirected;
+// directed connections get arrowheads through the dir attribute.
+
+func renderDOT(g *boardGraph) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "graph %s {\n", dotQuote("board"))
+	fmt.Fprintf(&buf, "  label=%s;\n", dotQuote(g.Title))
+	buf.WriteString("  node [shape=box, style=filled];\n")
+
+	for _, n := range g.Nodes {
+		attrs := []string{
+			"label=" + dotQuote(n.Label),
+			"pos=" + dotQuote(fmt.Sprintf("%s,%s!", formatFloat(n.X+n.W/2), formatFloat(-(n.Y+n.H/2)))),
+			"width=" + formatFloat(n.W/72),
+			"height=" + formatFloat(n.H/72),
+		}
+		if n.Color != "" {
+			attrs = append(attrs, "fillcolor="+dotQuote(n.Color))
+		}
+		for _, attr := range g.NodeAttrs {
+			if value, ok := n.Attrs[attr.Name]; ok && attr.Name != "color" {
+				attrs = append(attrs, dotQuote(attr.Name)+"="+dotQuote(value))
+			}
+		}
+		fmt.Fprintf(&buf, "  %s [%s];\n", dotQuote(n.ID), strings.Join(attrs, ", "))
+	}
+	for _, e := range g.Edges {
+		var attrs []string
+		if e.Label != "" {
+			attrs = append(attrs, "label="+dotQuote(e.Label))
+		}
+		if e.Direction == models.DirectionForward || e.Direction == models.DirectionBoth {
+			attrs = append(attrs, "dir="+string(e.Direction))
+		}
+		for _, attr := range g.EdgeAttrs {
+			if value, ok := e.Attrs[attr.Name]; ok && attr.Name != "label" && attr.Name != "direction" {
+				attrs = append(attrs, dotQuote(attr.Name)+"="+dotQuote(value))
+			}
+		}
+		attrs = append(attrs, "id="+dotQuote(e.ID))
+		fmt.Fprintf(&buf, "  %s -- %s [%s];\n", dotQuote(e.Source), dotQuote(e.Target), strings.Join(attrs, ", "))
+	}
+
+	buf.WriteString("}\n")
+	return buf.Bytes(), nil
+}
+
+// dotQuote renders s as a DOT double-quoted string
+func dotQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+	return `"` + r.Replace(s) + `"`
+}
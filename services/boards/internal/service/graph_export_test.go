@@ -0,0 +1,147 @@
+package service
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func graphTestBoard() (*models.Board, uuid.UUID, uuid.UUID) {
+	boardID := uuid.New()
+	suspectID := uuid.New()
+	noteID := uuid.New()
+	return &models.Board{
+		ID:    boardID,
+		Title: "Op &quot;Nightjar&quot;",
+		Items: []models.BoardItem{
+			{ID: suspectID, Type: "suspect-card", X: 100, Y: 50, Width: 280, Height: 400, ZIndex: 1,
+				Content: "John &lt;Smithy&gt; Smith\nDOB: 1980",
+				Style:   []byte(`{"color":"#f5f5f5","metadata":{"variant":"suspect-card","alias":"Smithy","age":44}}`)},
+			{ID: noteID, Type: "post-it", X: 500, Y: 50, Width: 200, Height: 200, ZIndex: 2,
+				Content: "Docks",
+				Style:   []byte(`{"color":"#ffeb3b","metadata":{"variant":"post-it"}}`)},
+		},
+		Connections: []models.BoardConnection{
+			{ID: uuid.New(), FromItemID: suspectID, ToItemID: noteID, Direction: models.DirectionForward,
+				RelationshipType: "was at", Confidence: models.ConfidenceMedium, Style: `{"color":"#ff0000","label":"seen at"}`},
+		},
+	}, suspectID, noteID
+}
+
+func newGraphTestService(board *models.Board, userID uuid.UUID) *BoardService {
+	mockBoardRepo := new(MockBoardRepository)
+	mockBoardRepo.On("GetByIDWithContents", board.ID, userID).Return(board, models.PermissionRead, nil)
+	return NewBoardService(mockBoardRepo, new(MockBoardUserRepository), new(MockBoardItemRepository), new(MockBoardConnectionRepository), nil, nil, nil, nil)
+}
+
+func TestBoardService_ExportGraphML(t *testing.T) {
+	userID := uuid.New()
+	board, suspectID, noteID := graphTestBoard()
+	svc := newGraphTestService(board, userID)
+
+	data, err := svc.ExportGraph(board.ID, userID, GraphFormatGraphML)
+	assert.NoError(t, err)
+
+	var doc graphMLDoc
+	assert.NoError(t, xml.Unmarshal(data, &doc))
+	keys := map[string]string{}
+	for _, key := range doc.Keys {
+		keys[key.ID] = key.For + ":" + key.AttrName + ":" + key.AttrType
+	}
+	assert.Contains(t, keys, "n0")
+	assert.Equal(t, "node:type:string", keys["n0"])
+	assert.Contains(t, keysValues(keys), "node:metadata.alias:string")
+	assert.Contains(t, keysValues(keys), "edge:label:string")
+
+	if assert.Len(t, doc.Graph.Nodes, 2) && assert.Len(t, doc.Graph.Edges, 1) {
+		suspect := graphMLValues(doc.Graph.Nodes[0].Data, doc.Keys)
+		assert.Equal(t, suspectID.String(), doc.Graph.Nodes[0].ID)
+		assert.Equal(t, "suspect-card", suspect["type"])
+		assert.Equal(t, "John <Smithy> Smith\nDOB: 1980", suspect["content"])
+		assert.Equal(t, "100", suspect["x"])
+		assert.Equal(t, "Smithy", suspect["metadata.alias"])
+		assert.Equal(t, "44", suspect["metadata.age"])
+
+		edge := doc.Graph.Edges[0]
+		assert.Equal(t, suspectID.String(), edge.Source)
+		assert.Equal(t, noteID.String(), edge.Target)
+		assert.Equal(t, "true", edge.Directed)
+		values := graphMLValues(edge.Data, doc.Keys)
+		assert.Equal(t, "seen at", values["label"])
+		assert.Equal(t, "was at", values["relationship_type"])
+		assert.Equal(t, "forward", values["direction"])
+		assert.Equal(t, "medium", values["confidence"])
+	}
+}
+
+func TestBoardService_ExportGEXF(t *testing.T) {
+	userID := uuid.New()
+	board, _, _ := graphTestBoard()
+	svc := newGraphTestService(board, userID)
+
+	data, err := svc.ExportGraph(board.ID, userID, GraphFormatGEXF)
+	assert.NoError(t, err)
+	out := string(data)
+	assert.Contains(t, out, `xmlns="http://gexf.net/1.3"`)
+	assert.Contains(t, out, `<viz:position x="240" y="-250" z="0"></viz:position>`)
+	assert.Contains(t, out, `<viz:color r="255" g="0" b="0"></viz:color>`)
+	assert.Contains(t, out, `label="John &lt;Smithy&gt; Smith"`)
+	assert.Contains(t, out, `<description>Op &#34;Nightjar&#34;</description>`)
+	assert.Contains(t, out, `type="directed" label="seen at"`)
+
+	// Must be well-formed XML
+	var doc struct {
+		XMLName xml.Name `xml:"gexf"`
+	}
+	assert.NoError(t, xml.Unmarshal(data, &doc))
+}
+
+func TestBoardService_ExportDOT(t *testing.T) {
+	userID := uuid.New()
+	board, suspectID, noteID := graphTestBoard()
+	svc := newGraphTestService(board, userID)
+
+	data, err := svc.ExportGraph(board.ID, userID, GraphFormatDOT)
+	assert.NoError(t, err)
+	out := string(data)
+	assert.True(t, strings.HasPrefix(out, `graph "board" {`))
+	assert.Contains(t, out, `label="Op \"Nightjar\"";`)
+	assert.Contains(t, out, `"`+suspectID.String()+`" [label="John <Smithy> Smith", pos="240,-250!"`)
+	assert.Contains(t, out, `"content"="John <Smithy> Smith\nDOB: 1980"`)
+	assert.Contains(t, out, `fillcolor="#f5f5f5"`)
+	assert.Contains(t, out, `"`+suspectID.String()+`" -- "`+noteID.String()+`" [label="seen at", dir=forward, "relationship_type"="was at", "confidence"="medium", "color"="#ff0000"`)
+}
+
+func TestBoardService_ExportGraphUnsupportedFormat(t *testing.T) {
+	userID := uuid.New()
+	board, _, _ := graphTestBoard()
+	svc := newGraphTestService(board, userID)
+
+	_, err := svc.ExportGraph(board.ID, userID, GraphFormat("pajek"))
+	assert.Equal(t, ErrUnsupportedFormat, err)
+}
+
+func keysValues(m map[string]string) []string {
+	values := make([]string, 0, len(m))
+	for _, v := range m {
+		values = append(values, v)
+	}
+	return values
+}
+
+func graphMLValues(data []graphMLData, keys []graphMLKey) map[string]string {
+	names := map[string]string{}
+	for _, key := range keys {
+		names[key.ID] = key.AttrName
+	}
+	values := map[string]string{}
+	for _, d := range data {
+		values[names[d.Key]] = d.Value
+	}
+	return values
+}
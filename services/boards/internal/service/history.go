@@ -0,0 +1,727 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"sync"
+	"time"
+
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	ErrNothingToUndo   = errors.New("nothing to undo")
+	ErrNothingToRedo   = errors.New("nothing to redo")
+	ErrHistoryConflict = errors.New("target changed since the operation was recorded")
+	ErrHistoryBusy     = errors.New("another undo or redo is in progress")
+)
+
+// History limits
+const (
+	MaxHistoryEntries = 100
+	HistoryTTL        = 24 * time.Hour
+	// HistoryLockTTL bounds how long a crashed replica can hold a user's
+	// history lock; HistoryLockWait is how long a replay waits for it
+	HistoryLockTTL  = 30 * time.Second
+	HistoryLockWait = 5 * time.Second
+)
+
+// HistoryKind identifies the entity a history change applies to
+type HistoryKind string
+
+const (
+	HistoryKindBoard      HistoryKind = "board"
+	HistoryKindItem       HistoryKind = "item"
+	HistoryKindConnection HistoryKind = "connection"
+)
+
+// HistoryStack selects the undo or redo stack of a user on a board
+type HistoryStack string
+
+const (
+	HistoryStackUndo HistoryStack = "undo"
+	HistoryStackRedo HistoryStack = "redo"
+)
+
+// HistoryChange records one entity transition. A nil Before means the entity
+// was created, a nil After means it was deleted.
+type HistoryChange struct {
+	Kind   HistoryKind     `json:"kind"`
+	ID     uuid.UUID       `json:"id"`
+	Before json.RawMessage `json:"before,omitempty"`
+	After  json.RawMessage `json:"after,omitempty"`
+}
+
+// HistoryEntry is one undoable user action, possibly spanning several entities
+type HistoryEntry struct {
+	Action    string          `json:"action"`
+	Changes   []HistoryChange `json:"changes"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// HistoryResult is returned to clients after an undo or redo
+type HistoryResult struct {
+	Action  string          `json:"action"`
+	Changes []HistoryChange `json:"changes"`
+}
+
+// HistoryStore persists per-user, per-board undo and redo stacks
+type HistoryStore interface {
+	Push(ctx context.Context, stack HistoryStack, boardID, userID uuid.UUID, entry HistoryEntry) error
+	Pop(ctx context.Context, stack HistoryStack, boardID, userID uuid.UUID) (*HistoryEntry, error)
+	Clear(ctx context.Context, stack HistoryStack, boardID, userID uuid.UUID) error
+	// Lock serializes undo and redo of a user on a board and returns the
+	// function releasing the lock
+	Lock(ctx context.Context, boardID, userID uuid.UUID) (func(), error)
+}
+
+// RedisHistoryStore keeps history stacks in Redis lists so every service
+// replica sees the same stacks
+type RedisHistoryStore struct {
+	client *redis.Client
+}
+
+// NewRedisHistoryStore creates a Redis backed history store
+func NewRedisHistoryStore(client *redis.Client) *RedisHistoryStore {
+	return &RedisHistoryStore{client: client}
+}
+
+func historyKey(stack HistoryStack, boardID, userID uuid.UUID) string {
+	return fmt.Sprintf("history:%s:%s:%s", stack, boardID, userID)
+}
+
+// Push adds an entry on top of the stack, trimming it to MaxHistoryEntries
+func (r *RedisHistoryStore) Push(ctx context.Context, stack HistoryStack, boardID, userID uuid.UUID, entry HistoryEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	key := historyKey(stack, boardID, userID)
+	pipe := r.client.TxPipeline()
+	pipe.LPush(ctx, key, data)
+	pipe.LTrim(ctx, key, 0, MaxHistoryEntries-1)
+	pipe.Expire(ctx, key, HistoryTTL)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// Pop removes and returns the top entry of the stack, or nil when it is empty
+func (r *RedisHistoryStore) Pop(ctx context.Context, stack HistoryStack, boardID, userID uuid.UUID) (*HistoryEntry, error) {
+	data, err := r.client.LPop(ctx, historyKey(stack, boardID, userID)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var entry HistoryEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// Clear empties the stack
+func (r *RedisHistoryStore) Clear(ctx context.Context, stack HistoryStack, boardID, userID uuid.UUID) error {
+	return r.client.Del(ctx, historyKey(stack, boardID, userID)).Err()
+}
+
+// unlockScript deletes a lock only while it still holds the caller's token,
+// so a lock that expired and was taken by another replica is left alone
+var unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// Lock takes a lock shared by every replica, waiting up to HistoryLockWait.
+// The lock expires after HistoryLockTTL in case its holder dies.
+func (r *RedisHistoryStore) Lock(ctx context.Context, boardID, userID uuid.UUID) (func(), error) {
+	key := fmt.Sprintf("history:lock:%s:%s", boardID, userID)
+	token := uuid.NewString()
+	deadline := time.Now().Add(HistoryLockWait)
+	for {
+		ok, err := r.client.SetNX(ctx, key, token, HistoryLockTTL).Result()
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			return nil, ErrHistoryBusy
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return func() {
+		if err := unlockScript.Run(context.Background(), r.client, []string{key}, token).Err(); err != nil {
+			log.Printf("Failed to release history lock %s: %v", key, err)
+		}
+	}, nil
+}
+
+// MemoryHistoryStore keeps history stacks in process memory. It is used when
+// no Redis client is configured (tests, single instance development).
+type MemoryHistoryStore struct {
+	mu     sync.Mutex
+	stacks map[string][]HistoryEntry
+	locks  map[string]*sync.Mutex
+}
+
+// NewMemoryHistoryStore creates an in-memory history store
+func NewMemoryHistoryStore() *MemoryHistoryStore {
+	return &MemoryHistoryStore{stacks: make(map[string][]HistoryEntry), locks: make(map[string]*sync.Mutex)}
+}
+
+// Push adds an entry on top of the stack, trimming it to MaxHistoryEntries
+func (m *MemoryHistoryStore) Push(ctx context.Context, stack HistoryStack, boardID, userID uuid.UUID, entry HistoryEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := historyKey(stack, boardID, userID)
+	entries := append(m.stacks[key], entry)
+	if len(entries) > MaxHistoryEntries {
+		entries = entries[len(entries)-MaxHistoryEntries:]
+	}
+	m.stacks[key] = entries
+	return nil
+}
+
+// Pop removes and returns the top entry of the stack, or nil when it is empty
+func (m *MemoryHistoryStore) Pop(ctx context.Context, stack HistoryStack, boardID, userID uuid.UUID) (*HistoryEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := historyKey(stack, boardID, userID)
+	entries := m.stacks[key]
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	entry := entries[len(entries)-1]
+	m.stacks[key] = entries[:len(entries)-1]
+	return &entry, nil
+}
+
+// Clear empties the stack
+func (m *MemoryHistoryStore) Clear(ctx context.Context, stack HistoryStack, boardID, userID uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.stacks, historyKey(stack, boardID, userID))
+	return nil
+}
+
+// Lock takes a lock held in process memory
+func (m *MemoryHistoryStore) Lock(ctx context.Context, boardID, userID uuid.UUID) (func(), error) {
+	key := fmt.Sprintf("%s:%s", boardID, userID)
+	m.mu.Lock()
+	lock, ok := m.locks[key]
+	if !ok {
+		lock = &sync.Mutex{}
+		m.locks[key] = lock
+	}
+	m.mu.Unlock()
+	lock.Lock()
+	return lock.Unlock, nil
+}
+
+// snapshot marshals an entity for storage in a history change
+func snapshot(v interface{}) json.RawMessage {
+	if v == nil || reflect.ValueOf(v).IsNil() {
+		return nil
+	}
+	data, _ := json.Marshal(v)
+	return data
+}
+
+func itemChange(before, after *models.BoardItem) HistoryChange {
+	id := uuid.Nil
+	if after != nil {
+		id = after.ID
+	} else if before != nil {
+		id = before.ID
+	}
+	return HistoryChange{Kind: HistoryKindItem, ID: id, Before: snapshot(stripItem(before)), After: snapshot(stripItem(after))}
+}
+
+func connectionChange(before, after *models.BoardConnection) HistoryChange {
+	id := uuid.Nil
+	if after != nil {
+		id = after.ID
+	} else if before != nil {
+		id = before.ID
+	}
+	return HistoryChange{Kind: HistoryKindConnection, ID: id, Before: snapshot(stripConnection(before)), After: snapshot(stripConnection(after))}
+}
+
+func boardChange(before, after *models.Board) HistoryChange {
+	return HistoryChange{Kind: HistoryKindBoard, ID: after.ID, Before: snapshot(stripBoard(before)), After: snapshot(stripBoard(after))}
+}
+
+// stripItem copies an item without its relationships so snapshots stay small
+func stripItem(item *models.BoardItem) *models.BoardItem {
+	if item == nil {
+		return nil
+	}
+	c := *item
+	c.Board = models.Board{}
+	c.Creator = models.User{}
+	c.Connections = nil
+	if item.Style != nil {
+		c.Style = append([]byte(nil), item.Style...)
+	}
+	if item.Fields != nil {
+		c.Fields = append(json.RawMessage(nil), item.Fields...)
+	}
+	if item.CustomValues != nil {
+		c.CustomValues = append(json.RawMessage(nil), item.CustomValues...)
+	}
+	return &c
+}
+
+func stripConnection(conn *models.BoardConnection) *models.BoardConnection {
+	if conn == nil {
+		return nil
+	}
+	c := *conn
+	c.Board = models.Board{}
+	c.FromItem = models.BoardItem{}
+	c.ToItem = models.BoardItem{}
+	c.Creator = models.User{}
+	return &c
+}
+
+func stripBoard(board *models.Board) *models.Board {
+	if board == nil {
+		return nil
+	}
+	return &models.Board{
+		ID:             board.ID,
+		Title:          board.Title,
+		Description:    board.Description,
+		Visibility:     board.Visibility,
+		OwnerID:        board.OwnerID,
+		CustomFields:   append([]models.CustomField(nil), board.CustomFields...),
+		Classification: board.Classification,
+		CreatedAt:      board.CreatedAt,
+		UpdatedAt:      board.UpdatedAt,
+	}
+}
+
+// jsonEqual compares two JSON documents semantically; jsonb columns do not
+// preserve key order or whitespace
+func jsonEqual(a, b []byte) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return len(a) == len(b)
+	}
+	var av, bv interface{}
+	if json.Unmarshal(a, &av) != nil || json.Unmarshal(b, &bv) != nil {
+		return string(a) == string(b)
+	}
+	return reflect.DeepEqual(av, bv)
+}
+
+func sameItemState(a, b *models.BoardItem) bool {
+	return a.BoardID == b.BoardID &&
+		sameID(a.ParentID, b.ParentID) &&
+		sameID(a.LayerID, b.LayerID) &&
+		a.Type == b.Type &&
+		a.X == b.X && a.Y == b.Y &&
+		a.Width == b.Width && a.Height == b.Height &&
+		a.Rotation == b.Rotation &&
+		a.ZIndex == b.ZIndex &&
+		a.Content == b.Content &&
+		jsonEqual(a.Style, b.Style) &&
+		jsonEqual(a.Fields, b.Fields) &&
+		jsonEqual(a.CustomValues, b.CustomValues) &&
+		a.Classification == b.Classification
+}
+
+// sameID compares optional references such as an item's frame or layer
+func sameID(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func sameConnectionState(a, b *models.BoardConnection) bool {
+	return a.BoardID == b.BoardID &&
+		a.FromItemID == b.FromItemID &&
+		a.ToItemID == b.ToItemID &&
+		sameID(a.LayerID, b.LayerID) &&
+		a.Label == b.Label &&
+		a.Direction == b.Direction &&
+		a.RelationshipType == b.RelationshipType &&
+		a.Confidence == b.Confidence &&
+		jsonEqual([]byte(a.Style), []byte(b.Style))
+}
+
+func sameBoardState(a, b *models.Board) bool {
+	return a.Title == b.Title && a.Description == b.Description && a.Visibility == b.Visibility &&
+		reflect.DeepEqual(a.CustomFields, b.CustomFields) && a.Classification == b.Classification
+}
+
+// recordHistory pushes a new undo entry for the user and invalidates their redo stack
+func (s *BoardService) recordHistory(boardID, userID uuid.UUID, action string, changes ...HistoryChange) {
+	if s.history == nil || len(changes) == 0 {
+		return
+	}
+	ctx := context.Background()
+	entry := HistoryEntry{Action: action, Changes: changes, CreatedAt: time.Now().UTC()}
+	if err := s.history.Push(ctx, HistoryStackUndo, boardID, userID, entry); err != nil {
+		return
+	}
+	s.history.Clear(ctx, HistoryStackRedo, boardID, userID)
+}
+
+// Undo reverts the user's most recent change on the board
+func (s *BoardService) Undo(boardID, userID uuid.UUID) (*HistoryResult, error) {
+	return s.replayHistory(boardID, userID, HistoryStackUndo)
+}
+
+// Redo re-applies the user's most recently undone change on the board
+func (s *BoardService) Redo(boardID, userID uuid.UUID) (*HistoryResult, error) {
+	return s.replayHistory(boardID, userID, HistoryStackRedo)
+}
+
+// replayHistory pops an entry from the given stack, verifies that the user
+// may still change its targets and that none of them were modified since it
+// was recorded, and applies it in the relevant direction in one transaction.
+// A conflicting or forbidden entry is discarded so it cannot block the stack;
+// one that fails to apply for any other reason is put back.
+func (s *BoardService) replayHistory(boardID, userID uuid.UUID, stack HistoryStack) (*HistoryResult, error) {
+	access, err := s.permissions.authorizeAccess(context.Background(), boardID, userID, models.PermissionWrite)
+	if err != nil {
+		return nil, err
+	}
+	if s.history == nil {
+		return nil, ErrNothingToUndo
+	}
+
+	// The stacks are shared by every replica, so a process lock is not enough
+	ctx := context.Background()
+	unlock, err := s.history.Lock(ctx, boardID, userID)
+	if err != nil {
+		if errors.Is(err, ErrHistoryBusy) {
+			return nil, ErrHistoryBusy
+		}
+		return nil, fmt.Errorf("failed to lock history: %w", err)
+	}
+	defer unlock()
+
+	entry, err := s.history.Pop(ctx, stack, boardID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	if entry == nil {
+		if stack == HistoryStackUndo {
+			return nil, ErrNothingToUndo
+		}
+		return nil, ErrNothingToRedo
+	}
+
+	undo := stack == HistoryStackUndo
+	// Undo walks the changes backwards (After -> Before), redo forwards
+	ordered := make([]HistoryChange, len(entry.Changes))
+	for i, ch := range entry.Changes {
+		if undo {
+			ordered[len(entry.Changes)-1-i] = ch
+		} else {
+			ordered[i] = ch
+		}
+	}
+
+	// Layers may have been locked or restricted since the entry was recorded
+	for _, ch := range ordered {
+		if err := checkHistoryChange(access, ch); err != nil {
+			return nil, err
+		}
+	}
+
+	var applied []HistoryChange
+	var published []func()
+	err = s.inHistoryTransaction(func(repos HistoryRepositories) error {
+		for _, ch := range ordered {
+			from, _ := transition(ch, undo)
+			ok, err := historyTargetMatches(repos, boardID, ch.Kind, ch.ID, from)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return ErrHistoryConflict
+			}
+		}
+
+		applied = make([]HistoryChange, 0, len(ordered))
+		published = make([]func(), 0, len(ordered))
+		for _, ch := range ordered {
+			from, to := transition(ch, undo)
+			result, publish, err := s.applyHistoryState(repos, boardID, ch.Kind, ch.ID, from, to)
+			if err != nil {
+				return err
+			}
+			// Record the persisted state so the opposite stack can detect later edits
+			if undo {
+				ch.Before = result
+			} else {
+				ch.After = result
+			}
+			applied = append(applied, ch)
+			published = append(published, publish)
+		}
+		return nil
+	})
+	if err != nil {
+		if err != ErrHistoryConflict {
+			// Nothing was committed, so the entry can be replayed again
+			if err := s.history.Push(ctx, stack, boardID, userID, *entry); err != nil {
+				log.Printf("Failed to restore history entry for board %s: %v", boardID, err)
+			}
+		}
+		return nil, err
+	}
+	// Only tell clients about changes once they are committed
+	for _, publish := range published {
+		publish()
+	}
+
+	// Store the entry on the opposite stack in its original order
+	reversed := make([]HistoryChange, len(applied))
+	for i, ch := range applied {
+		if undo {
+			reversed[len(applied)-1-i] = ch
+		} else {
+			reversed[i] = ch
+		}
+	}
+	opposite := HistoryStackRedo
+	if !undo {
+		opposite = HistoryStackUndo
+	}
+	s.history.Push(ctx, opposite, boardID, userID, HistoryEntry{Action: entry.Action, Changes: reversed, CreatedAt: time.Now().UTC()})
+
+	return &HistoryResult{Action: entry.Action, Changes: applied}, nil
+}
+
+// inHistoryTransaction runs fn in a database transaction when the service
+// has one, and on its own repositories otherwise
+func (s *BoardService) inHistoryTransaction(fn func(repos HistoryRepositories) error) error {
+	if s.transaction == nil {
+		return fn(HistoryRepositories{Boards: s.boardRepo, Items: s.boardItemRepo, Connections: s.connectionRepo})
+	}
+	return s.transaction(fn)
+}
+
+// checkHistoryChange verifies that the user may still make a change: items
+// and connections must be on layers they can see and change, and items
+// within their clearance, before and after it. Board changes need admin
+// permission, like UpdateBoard.
+func checkHistoryChange(access *boardAccess, ch HistoryChange) error {
+	for _, state := range []json.RawMessage{ch.Before, ch.After} {
+		if state == nil {
+			continue
+		}
+		switch ch.Kind {
+		case HistoryKindItem:
+			var item models.BoardItem
+			if err := json.Unmarshal(state, &item); err != nil {
+				return fmt.Errorf("failed to decode history: %w", err)
+			}
+			if err := access.checkItem(&item); err != nil {
+				return err
+			}
+		case HistoryKindConnection:
+			var conn models.BoardConnection
+			if err := json.Unmarshal(state, &conn); err != nil {
+				return fmt.Errorf("failed to decode history: %w", err)
+			}
+			if err := access.checkConnection(&conn); err != nil {
+				return err
+			}
+		case HistoryKindBoard:
+			if access.permission != models.PermissionAdmin {
+				return ErrUnauthorized
+			}
+		}
+	}
+	return nil
+}
+
+// transition returns the expected current state and the target state of a change
+func transition(ch HistoryChange, undo bool) (json.RawMessage, json.RawMessage) {
+	if undo {
+		return ch.After, ch.Before
+	}
+	return ch.Before, ch.After
+}
+
+// historyTargetMatches reports whether the entity currently matches the expected state
+func historyTargetMatches(repos HistoryRepositories, boardID uuid.UUID, kind HistoryKind, id uuid.UUID, expected json.RawMessage) (bool, error) {
+	switch kind {
+	case HistoryKindItem:
+		current, err := repos.Items.GetByID(id)
+		if err != nil {
+			return false, fmt.Errorf("failed to get item: %w", err)
+		}
+		if current != nil && current.BoardID != boardID {
+			return false, nil
+		}
+		if expected == nil {
+			return current == nil, nil
+		}
+		var want models.BoardItem
+		if err := json.Unmarshal(expected, &want); err != nil {
+			return false, fmt.Errorf("failed to decode history: %w", err)
+		}
+		return current != nil && sameItemState(current, &want), nil
+	case HistoryKindConnection:
+		current, err := repos.Connections.GetByID(id)
+		if err != nil {
+			return false, fmt.Errorf("failed to get connection: %w", err)
+		}
+		if current != nil && current.BoardID != boardID {
+			return false, nil
+		}
+		if expected == nil {
+			return current == nil, nil
+		}
+		var want models.BoardConnection
+		if err := json.Unmarshal(expected, &want); err != nil {
+			return false, fmt.Errorf("failed to decode history: %w", err)
+		}
+		return current != nil && sameConnectionState(current, &want), nil
+	case HistoryKindBoard:
+		current, err := repos.Boards.GetByID(id)
+		if err != nil {
+			return false, fmt.Errorf("failed to get board: %w", err)
+		}
+		if current == nil || expected == nil {
+			return false, nil
+		}
+		var want models.Board
+		if err := json.Unmarshal(expected, &want); err != nil {
+			return false, fmt.Errorf("failed to decode history: %w", err)
+		}
+		return sameBoardState(current, &want), nil
+	}
+	return false, ErrInvalidInput
+}
+
+// applyHistoryState moves an entity to the target state and returns the
+// persisted state and the function publishing the matching realtime event.
+// Attachments of items removed here are kept, so that redoing or undoing
+// back brings the item back with its evidence.
+func (s *BoardService) applyHistoryState(repos HistoryRepositories, boardID uuid.UUID, kind HistoryKind, id uuid.UUID, from, to json.RawMessage) (json.RawMessage, func(), error) {
+	switch kind {
+	case HistoryKindItem:
+		if to == nil {
+			if err := repos.Items.Delete(id); err != nil {
+				return nil, nil, fmt.Errorf("failed to delete item: %w", err)
+			}
+			return nil, func() {
+				s.publishLayerUpdate(boardID, snapshotLayer(from), "item_deleted", map[string]interface{}{"id": id})
+			}, nil
+		}
+		var target models.BoardItem
+		if err := json.Unmarshal(to, &target); err != nil {
+			return nil, nil, fmt.Errorf("failed to decode history: %w", err)
+		}
+		if from == nil {
+			if err := repos.Items.Create(&target); err != nil {
+				return nil, nil, fmt.Errorf("failed to create item: %w", err)
+			}
+			return snapshot(stripItem(&target)), func() { s.publishBoardUpdate(boardID, "item_created", &target) }, nil
+		}
+		current, err := repos.Items.GetByID(id)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get item: %w", err)
+		}
+		if current == nil {
+			return nil, nil, ErrItemNotFound
+		}
+		current.ParentID = target.ParentID
+		current.LayerID = target.LayerID
+		current.Type = target.Type
+		current.X, current.Y = target.X, target.Y
+		current.Width, current.Height = target.Width, target.Height
+		current.Rotation = target.Rotation
+		current.ZIndex = target.ZIndex
+		current.Content = target.Content
+		current.Style = target.Style
+		current.Fields = target.Fields
+		current.CustomValues = target.CustomValues
+		current.Classification = target.Classification
+		if err := repos.Items.Update(current); err != nil {
+			return nil, nil, fmt.Errorf("failed to update item: %w", err)
+		}
+		return snapshot(stripItem(current)), func() { s.publishBoardUpdate(boardID, "item_updated", current) }, nil
+
+	case HistoryKindConnection:
+		if to == nil {
+			if err := repos.Connections.Delete(id); err != nil {
+				return nil, nil, fmt.Errorf("failed to delete connection: %w", err)
+			}
+			return nil, func() {
+				s.publishLayerUpdate(boardID, snapshotLayer(from), "connection_deleted", map[string]interface{}{"id": id})
+			}, nil
+		}
+		var target models.BoardConnection
+		if err := json.Unmarshal(to, &target); err != nil {
+			return nil, nil, fmt.Errorf("failed to decode history: %w", err)
+		}
+		if from == nil {
+			if err := repos.Connections.Create(&target); err != nil {
+				return nil, nil, fmt.Errorf("failed to create connection: %w", err)
+			}
+			return snapshot(stripConnection(&target)), func() { s.publishBoardUpdate(boardID, "connection_created", &target) }, nil
+		}
+		current, err := repos.Connections.GetByID(id)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get connection: %w", err)
+		}
+		if current == nil {
+			return nil, nil, ErrConnectionNotFound
+		}
+		current.FromItemID = target.FromItemID
+		current.ToItemID = target.ToItemID
+		current.LayerID = target.LayerID
+		current.Label = target.Label
+		// Snapshots taken before connections had a direction leave it empty
+		current.Direction, _ = validateDirection(target.Direction)
+		current.RelationshipType = target.RelationshipType
+		current.Confidence = target.Confidence
+		current.Style = target.Style
+		if err := repos.Connections.Update(current); err != nil {
+			return nil, nil, fmt.Errorf("failed to update connection: %w", err)
+		}
+		return snapshot(stripConnection(current)), func() { s.publishBoardUpdate(boardID, "connection_updated", current) }, nil
+
+	case HistoryKindBoard:
+		var target models.Board
+		if err := json.Unmarshal(to, &target); err != nil {
+			return nil, nil, fmt.Errorf("failed to decode history: %w", err)
+		}
+		current, err := repos.Boards.GetByID(id)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get board: %w", err)
+		}
+		if current == nil {
+			return nil, nil, ErrBoardNotFound
+		}
+		current.Title = target.Title
+		current.Description = target.Description
+		current.Visibility = target.Visibility
+		current.CustomFields = target.CustomFields
+		current.Classification = target.Classification
+		if err := repos.Boards.Update(current); err != nil {
+			return nil, nil, fmt.Errorf("failed to update board: %w", err)
+		}
+		return snapshot(stripBoard(current)), func() { s.publishBoardUpdate(boardID, "board_updated", stripBoard(current)) }, nil
+	}
+	return nil, nil, ErrInvalidInput
+}
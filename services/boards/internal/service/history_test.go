@@ -0,0 +1,259 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newHistoryTestService() (*BoardService, *MockBoardRepository, *MockBoardItemRepository, *MockBoardConnectionRepository) {
+	mockBoardRepo := new(MockBoardRepository)
+	mockBoardUserRepo := new(MockBoardUserRepository)
+	mockBoardItemRepo := new(MockBoardItemRepository)
+	mockConnectionRepo := new(MockBoardConnectionRepository)
+	svc := NewBoardService(mockBoardRepo, mockBoardUserRepo, mockBoardItemRepo, mockConnectionRepo, nil, nil, nil, nil)
+	return svc, mockBoardRepo, mockBoardItemRepo, mockConnectionRepo
+}
+
+func TestBoardService_UndoRedoItemUpdate(t *testing.T) {
+	boardID := uuid.New()
+	userID := uuid.New()
+	itemID := uuid.New()
+
+	svc, mockBoardRepo, mockBoardItemRepo, _ := newHistoryTestService()
+
+	// The shared pointer plays the role of the stored row
+	current := &models.BoardItem{ID: itemID, BoardID: boardID, Type: "post-it", X: 10, Y: 20, Width: 200, Height: 200, Content: "note"}
+	mockBoardRepo.On("GetPermission", boardID, userID).Return(true, models.PermissionWrite, nil)
+	mockBoardRepo.On("GetRowByID", boardID).Return(&models.Board{ID: boardID}, nil)
+	mockBoardItemRepo.On("GetByID", itemID).Return(current, nil)
+	mockBoardItemRepo.On("Update", mock.AnythingOfType("*models.BoardItem")).Return(nil)
+
+	x := 50.0
+	_, err := svc.UpdateBoardItem(boardID, itemID, userID, UpdateItemRequest{X: &x})
+	assert.NoError(t, err)
+	assert.Equal(t, 50.0, current.X)
+
+	result, err := svc.Undo(boardID, userID)
+	assert.NoError(t, err)
+	assert.Equal(t, "item_updated", result.Action)
+	assert.Equal(t, 10.0, current.X)
+
+	result, err = svc.Redo(boardID, userID)
+	assert.NoError(t, err)
+	assert.Len(t, result.Changes, 1)
+	assert.Equal(t, 50.0, current.X)
+
+	// The redone change is undoable again
+	_, err = svc.Undo(boardID, userID)
+	assert.NoError(t, err)
+	assert.Equal(t, 10.0, current.X)
+
+	_, err = svc.Undo(boardID, userID)
+	assert.Equal(t, ErrNothingToUndo, err)
+}
+
+func TestBoardService_UndoConflict(t *testing.T) {
+	boardID := uuid.New()
+	userID := uuid.New()
+	itemID := uuid.New()
+
+	svc, mockBoardRepo, mockBoardItemRepo, _ := newHistoryTestService()
+
+	current := &models.BoardItem{ID: itemID, BoardID: boardID, Type: "post-it", X: 10, Y: 20, Content: "note"}
+	mockBoardRepo.On("GetPermission", boardID, userID).Return(true, models.PermissionWrite, nil)
+	mockBoardRepo.On("GetRowByID", boardID).Return(&models.Board{ID: boardID}, nil)
+	mockBoardItemRepo.On("GetByID", itemID).Return(current, nil)
+	mockBoardItemRepo.On("Update", mock.AnythingOfType("*models.BoardItem")).Return(nil)
+
+	_, err := svc.UpdateBoardItem(boardID, itemID, userID, UpdateItemRequest{Content: "edited"})
+	assert.NoError(t, err)
+
+	// Another user moves the item afterwards
+	current.X = 99
+
+	_, err = svc.Undo(boardID, userID)
+	assert.Equal(t, ErrHistoryConflict, err)
+	assert.Equal(t, "edited", current.Content)
+	mockBoardItemRepo.AssertNumberOfCalls(t, "Update", 1)
+
+	// The conflicting entry is discarded
+	_, err = svc.Undo(boardID, userID)
+	assert.Equal(t, ErrNothingToUndo, err)
+}
+
+func TestBoardService_UndoRedoItemCreate(t *testing.T) {
+	boardID := uuid.New()
+	userID := uuid.New()
+
+	svc, mockBoardRepo, mockBoardItemRepo, _ := newHistoryTestService()
+	attachments := &recordingItemAttachments{}
+	svc.SetAttachments(attachments)
+
+	mockBoardRepo.On("GetPermission", boardID, userID).Return(true, models.PermissionWrite, nil)
+	mockBoardRepo.On("GetRowByID", boardID).Return(&models.Board{ID: boardID}, nil)
+	var created *models.BoardItem
+	mockBoardItemRepo.On("Create", mock.AnythingOfType("*models.BoardItem")).Run(func(args mock.Arguments) {
+		created = args.Get(0).(*models.BoardItem)
+		if created.ID == uuid.Nil {
+			created.ID = uuid.New()
+		}
+	}).Return(nil)
+
+	item, err := svc.CreateBoardItem(boardID, userID, CreateItemRequest{Type: "post-it", Content: "hello", X: 1, Y: 2, Width: 100, Height: 100})
+	assert.NoError(t, err)
+
+	mockBoardItemRepo.On("GetByID", item.ID).Return(item, nil).Once()
+	mockBoardItemRepo.On("Delete", item.ID).Return(nil).Once()
+	_, err = svc.Undo(boardID, userID)
+	assert.NoError(t, err)
+	mockBoardItemRepo.AssertCalled(t, "Delete", item.ID)
+	assert.Empty(t, attachments.deleted, "undo keeps the item's attachments for a redo")
+
+	mockBoardItemRepo.On("GetByID", item.ID).Return(nil, nil).Once()
+	created = nil
+	_, err = svc.Redo(boardID, userID)
+	assert.NoError(t, err)
+	if assert.NotNil(t, created) {
+		assert.Equal(t, item.ID, created.ID)
+		assert.Equal(t, "hello", created.Content)
+	}
+
+	// A new change clears the redo stack
+	_, err = svc.CreateBoardItem(boardID, userID, CreateItemRequest{Type: "post-it", Content: "other", X: 1, Y: 2, Width: 100, Height: 100})
+	assert.NoError(t, err)
+	_, err = svc.Redo(boardID, userID)
+	assert.Equal(t, ErrNothingToRedo, err)
+}
+
+func TestApplyHistoryStateMissingTarget(t *testing.T) {
+	boardID := uuid.New()
+	svc, mockBoardRepo, mockBoardItemRepo, mockConnectionRepo := newHistoryTestService()
+	repos := HistoryRepositories{Boards: mockBoardRepo, Items: mockBoardItemRepo, Connections: mockConnectionRepo}
+	itemID, connID := uuid.New(), uuid.New()
+	mockBoardItemRepo.On("GetByID", itemID).Return(nil, nil)
+	mockConnectionRepo.On("GetByID", connID).Return(nil, nil)
+	mockBoardRepo.On("GetByID", boardID).Return(nil, nil)
+
+	state := []byte(`{}`)
+	_, _, err := svc.applyHistoryState(repos, boardID, HistoryKindItem, itemID, state, state)
+	assert.Equal(t, ErrItemNotFound, err)
+	_, _, err = svc.applyHistoryState(repos, boardID, HistoryKindConnection, connID, state, state)
+	assert.Equal(t, ErrConnectionNotFound, err)
+	_, _, err = svc.applyHistoryState(repos, boardID, HistoryKindBoard, boardID, state, state)
+	assert.Equal(t, ErrBoardNotFound, err)
+}
+
+func TestBoardService_UndoUnauthorized(t *testing.T) {
+	boardID := uuid.New()
+	userID := uuid.New()
+
+	svc, mockBoardRepo, _, _ := newHistoryTestService()
+	mockBoardRepo.On("GetPermission", boardID, userID).Return(true, models.PermissionRead, nil)
+
+	_, err := svc.Undo(boardID, userID)
+	assert.Equal(t, ErrUnauthorized, err)
+}
+
+func TestBoardService_UndoOnLockedOrRestrictedLayer(t *testing.T) {
+	f := newLayerFixture(models.PermissionWrite)
+
+	// Changes recorded before the layers were locked or restricted
+	for _, item := range []models.BoardItem{f.lockedItem, f.secretItem} {
+		before := item
+		before.X = 500
+		f.svc.recordHistory(f.board.ID, f.userID, "item_updated", itemChange(&before, &item))
+	}
+	moved := f.openItem
+	moved.LayerID = &f.locked.ID
+	f.svc.recordHistory(f.board.ID, f.userID, "item_updated", itemChange(&f.openItem, &moved))
+
+	_, err := f.svc.Undo(f.board.ID, f.userID)
+	assert.Equal(t, ErrLayerLocked, err, "the item was moved onto a locked layer")
+	_, err = f.svc.Undo(f.board.ID, f.userID)
+	assert.Equal(t, ErrItemNotFound, err)
+	_, err = f.svc.Undo(f.board.ID, f.userID)
+	assert.Equal(t, ErrLayerLocked, err)
+	f.itemRepo.AssertNotCalled(t, "Update", mock.Anything)
+
+	f.svc.recordHistory(f.board.ID, f.userID, "connection_deleted", connectionChange(&f.conn, nil))
+	_, err = f.svc.Redo(f.board.ID, f.userID)
+	assert.Equal(t, ErrNothingToRedo, err)
+	_, err = f.svc.Undo(f.board.ID, f.userID)
+	assert.Equal(t, ErrConnectionNotFound, err, "an end of the connection is on a restricted layer")
+	f.connRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestBoardService_UndoInTransaction(t *testing.T) {
+	boardID := uuid.New()
+	userID := uuid.New()
+	itemID := uuid.New()
+
+	svc, mockBoardRepo, mockBoardItemRepo, mockConnectionRepo := newHistoryTestService()
+	current := &models.BoardItem{ID: itemID, BoardID: boardID, Type: "post-it", X: 10, Y: 20, Content: "note"}
+	mockBoardRepo.On("GetPermission", boardID, userID).Return(true, models.PermissionWrite, nil)
+	mockBoardItemRepo.On("GetByID", itemID).Return(current, nil)
+	mockBoardItemRepo.On("Update", mock.AnythingOfType("*models.BoardItem")).Return(nil).Once()
+
+	// Undo reads and writes through the transaction's repositories
+	txItemRepo := new(MockBoardItemRepository)
+	txItemRepo.On("GetByID", itemID).Return(current, nil)
+	txItemRepo.On("Update", mock.AnythingOfType("*models.BoardItem")).Return(errors.New("connection reset")).Once()
+	txItemRepo.On("Update", mock.AnythingOfType("*models.BoardItem")).Return(nil).Once()
+	transactions := 0
+	svc.SetHistoryTransaction(func(fn func(repos HistoryRepositories) error) error {
+		transactions++
+		return fn(HistoryRepositories{Boards: mockBoardRepo, Items: txItemRepo, Connections: mockConnectionRepo})
+	})
+
+	x := 50.0
+	_, err := svc.UpdateBoardItem(boardID, itemID, userID, UpdateItemRequest{X: &x})
+	assert.NoError(t, err)
+
+	// A failed transaction leaves the entry on the stack to be retried
+	_, err = svc.Undo(boardID, userID)
+	assert.ErrorContains(t, err, "connection reset")
+	current.X = 50 // Rolled back
+
+	result, err := svc.Undo(boardID, userID)
+	assert.NoError(t, err)
+	assert.Equal(t, "item_updated", result.Action)
+	assert.Equal(t, 10.0, current.X)
+	assert.Equal(t, 2, transactions)
+	mockBoardItemRepo.AssertNumberOfCalls(t, "Update", 1)
+	txItemRepo.AssertExpectations(t)
+}
+
+func TestMemoryHistoryStore_Lock(t *testing.T) {
+	store := NewMemoryHistoryStore()
+	boardID, userID := uuid.New(), uuid.New()
+
+	unlock, err := store.Lock(context.Background(), boardID, userID)
+	assert.NoError(t, err)
+
+	// Another user's history is not held up
+	other, err := store.Lock(context.Background(), boardID, uuid.New())
+	assert.NoError(t, err)
+	other()
+
+	locked := make(chan struct{})
+	go func() {
+		again, _ := store.Lock(context.Background(), boardID, userID)
+		close(locked)
+		again()
+	}()
+	select {
+	case <-locked:
+		t.Fatal("lock taken twice")
+	case <-time.After(20 * time.Millisecond):
+	}
+	unlock()
+	<-locked
+}
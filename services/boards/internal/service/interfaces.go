@@ -1,6 +1,9 @@
 package service
 
 import (
+	"context"
+	"io"
+
 	"evidence-wall/shared/models"
 
 	"github.com/google/uuid"
@@ -10,13 +13,43 @@ import (
 type BoardRepositoryInterface interface {
 	Create(board *models.Board) error
 	GetByID(id uuid.UUID) (*models.Board, error)
+	GetRowByID(id uuid.UUID) (*models.Board, error)
 	GetByIDWithPermission(boardID, userID uuid.UUID) (*models.Board, models.PermissionLevel, error)
+	GetPermission(boardID, userID uuid.UUID) (bool, models.PermissionLevel, error)
+	GetByIDWithContents(boardID, userID uuid.UUID) (*models.Board, models.PermissionLevel, error)
 	ListByUser(userID uuid.UUID, offset, limit int) ([]models.Board, int64, error)
+	ListByUserAndTags(userID uuid.UUID, tagIDs []uuid.UUID, offset, limit int) ([]models.Board, int64, error)
 	ListPublic(offset, limit int) ([]models.Board, int64, error)
 	Update(board *models.Board) error
 	Delete(id uuid.UUID) error
 }
 
+// HistoryRepositories are the repositories undo and redo read and write
+// their targets through
+type HistoryRepositories struct {
+	Boards      BoardRepositoryInterface
+	Items       BoardItemRepositoryInterface
+	Connections BoardConnectionRepositoryInterface
+}
+
+// HistoryTransaction runs fn in one database transaction with repositories
+// bound to it, rolling back when fn returns an error
+type HistoryTransaction func(fn func(repos HistoryRepositories) error) error
+
+// ContentRepositories are the repositories board copies and imports create
+// the new board and its sharing, layers, items and connections through
+type ContentRepositories struct {
+	Boards      BoardRepositoryInterface
+	BoardUsers  BoardUserRepositoryInterface
+	Items       BoardItemRepositoryInterface
+	Connections BoardConnectionRepositoryInterface
+	Layers      LayerRepositoryInterface
+}
+
+// ContentTransaction runs fn in one database transaction with repositories
+// bound to it, rolling back when fn returns an error
+type ContentTransaction func(fn func(repos ContentRepositories) error) error
+
 // BoardUserRepositoryInterface defines the interface for board user repository operations
 type BoardUserRepositoryInterface interface {
 	Create(boardUser *models.BoardUser) error
@@ -31,8 +64,21 @@ type BoardItemRepositoryInterface interface {
 	Create(item *models.BoardItem) error
 	GetByID(id uuid.UUID) (*models.BoardItem, error)
 	ListByBoard(boardID uuid.UUID) ([]models.BoardItem, error)
+	ListByBoardInBBox(boardID uuid.UUID, box models.BBox) ([]models.BoardItem, error)
+	ListByIDs(boardID uuid.UUID, ids []uuid.UUID) ([]models.BoardItem, error)
+	ListDescendants(frameID uuid.UUID) ([]models.BoardItem, error)
+	ListFrameMembership(boardID uuid.UUID) ([]models.BoardItem, error)
+	ListIDsByLayers(boardID uuid.UUID, layerIDs []uuid.UUID) ([]uuid.UUID, error)
+	ListClassified(boardID uuid.UUID) ([]models.BoardItem, error)
+	SetParent(ids []uuid.UUID, parentID *uuid.UUID) error
+	SetFrameMembers(frameID uuid.UUID, added, removed []uuid.UUID, parentID *uuid.UUID) error
+	SetLayer(ids []uuid.UUID, layerID *uuid.UUID) error
 	Update(item *models.BoardItem) error
+	UpdatePositions(items []models.BoardItem) error
+	UpdateWithPositions(item *models.BoardItem, moved []models.BoardItem) error
+	UpdateEvidenceMetadata(id uuid.UUID, metadata *models.EvidenceMetadata) error
 	Delete(id uuid.UUID) error
+	DeleteItems(ids []uuid.UUID) error
 	DeleteByBoard(boardID uuid.UUID) error
 }
 
@@ -41,8 +87,89 @@ type BoardConnectionRepositoryInterface interface {
 	Create(connection *models.BoardConnection) error
 	GetByID(id uuid.UUID) (*models.BoardConnection, error)
 	ListByBoard(boardID uuid.UUID) ([]models.BoardConnection, error)
+	ListByBoardFiltered(boardID uuid.UUID, filter models.ConnectionFilter) ([]models.BoardConnection, error)
+	ListByBoardInBBox(boardID uuid.UUID, box models.BBox) ([]models.BoardConnection, error)
+	ListByItems(boardID uuid.UUID, itemIDs []uuid.UUID) ([]models.BoardConnection, error)
+	SetLayer(ids []uuid.UUID, layerID *uuid.UUID) error
 	Update(connection *models.BoardConnection) error
 	Delete(id uuid.UUID) error
 	DeleteByBoard(boardID uuid.UUID) error
 	DeleteByItem(itemID uuid.UUID) error
 }
+
+// TemplateRepositoryInterface defines the interface for board template repository operations
+type TemplateRepositoryInterface interface {
+	Create(template *models.BoardTemplate) error
+	GetByID(id uuid.UUID) (*models.BoardTemplate, error)
+	ListVisible(userID uuid.UUID) ([]models.BoardTemplate, error)
+	Delete(id uuid.UUID) error
+}
+
+// TagRepositoryInterface defines the interface for tag repository operations
+type TagRepositoryInterface interface {
+	Create(tag *models.Tag) error
+	GetByID(id uuid.UUID) (*models.Tag, error)
+	ListByIDs(ids []uuid.UUID) ([]models.Tag, error)
+	ListByOwner(userID uuid.UUID) ([]models.Tag, error)
+	ListByBoard(boardID uuid.UUID) ([]models.Tag, error)
+	FindByName(scope models.TagScope, scopeID uuid.UUID, name string) (*models.Tag, error)
+	Update(tag *models.Tag) error
+	Delete(id uuid.UUID) error
+	Merge(fromID, intoID uuid.UUID) error
+	Assign(assignments []models.TagAssignment) error
+	Unassign(tagIDs, targetIDs []uuid.UUID) error
+	ListAssignments(boardID uuid.UUID, tagIDs []uuid.UUID) ([]models.TagAssignment, error)
+	DeleteByBoard(boardID uuid.UUID) error
+}
+
+// LayerRepositoryInterface defines the interface for board layer repository operations
+type LayerRepositoryInterface interface {
+	Create(layer *models.Layer) error
+	GetByID(id uuid.UUID) (*models.Layer, error)
+	ListByBoard(boardID uuid.UUID) ([]models.Layer, error)
+	Update(layer *models.Layer) error
+	CountContents(id uuid.UUID) (int64, error)
+	Delete(id uuid.UUID) error
+	DeleteByBoard(boardID uuid.UUID) error
+}
+
+// AttachmentRepositoryInterface defines the interface for attachment repository operations
+type AttachmentRepositoryInterface interface {
+	Create(attachment *models.Attachment) error
+	GetByID(id uuid.UUID) (*models.Attachment, error)
+	ListByItem(itemID uuid.UUID) ([]models.Attachment, error)
+	ListByBoard(boardID uuid.UUID) ([]models.Attachment, error)
+	ListPendingPreviews(limit int) ([]models.Attachment, error)
+	UpdatePreview(attachment *models.Attachment) error
+	TotalSizeByBoard(boardID uuid.UUID) (int64, error)
+	Delete(id uuid.UUID) error
+	DeleteByBoard(boardID uuid.UUID) error
+}
+
+// CustodyRepositoryInterface defines the interface for the append-only custody log
+type CustodyRepositoryInterface interface {
+	Create(event *models.CustodyEvent) error
+	Last(itemID uuid.UUID) (*models.CustodyEvent, error)
+	ListByItem(itemID uuid.UUID) ([]models.CustodyEvent, error)
+	DeleteByBoard(boardID uuid.UUID) error
+}
+
+// SearchRepositoryInterface defines the interface for full-text search
+type SearchRepositoryInterface interface {
+	SearchBoards(userID uuid.UUID, filter models.SearchFilter) ([]models.BoardSearchHit, error)
+	SearchItems(userID uuid.UUID, filter models.SearchFilter) ([]models.ItemSearchHit, int64, error)
+	ItemFacets(userID uuid.UUID, filter models.SearchFilter) ([]models.SearchFacet, []models.SearchFacet, error)
+}
+
+// ItemAttachments removes the attachments of deleted items and boards
+type ItemAttachments interface {
+	DeleteItemAttachments(ctx context.Context, itemIDs []uuid.UUID, userID uuid.UUID) error
+	DeleteBoardAttachments(ctx context.Context, boardID uuid.UUID) error
+}
+
+// BlobStore stores attachment contents by key
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"evidence-wall/boards-service/internal/itemtype"
+	"evidence-wall/shared/models"
+)
+
+// ItemTypes lists the registered item types with their field schemas
+func (s *BoardService) ItemTypes() []*itemtype.Type {
+	return itemtype.All()
+}
+
+// resolveItemType maps a requested type to a registered one. The generic
+// "note" type takes its concrete type from metadata.variant, defaulting to a
+// post-it as the frontend has always done.
+func resolveItemType(requested string, metadata map[string]interface{}) (string, error) {
+	if requested == models.ItemTypeNote {
+		if variant, ok := metadata["variant"].(string); ok {
+			if _, known := itemtype.Lookup(variant); known {
+				return variant, nil
+			}
+		}
+		return string(models.ItemTypePostIt), nil
+	}
+	if _, ok := itemtype.Lookup(requested); !ok {
+		return "", fmt.Errorf("%w: unknown item type %q", ErrInvalidInput, requested)
+	}
+	return requested, nil
+}
+
+// validateFields checks field values against the item type's schema and
+// returns them sanitized for storage; no fields are stored as NULL
+func validateFields(itemType string, fields map[string]interface{}) (json.RawMessage, error) {
+	if err := itemtype.Validate(itemType, fields); err != nil {
+		return nil, fmt.Errorf("%w: fields.%v", ErrInvalidInput, err)
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	sanitized := make(map[string]interface{}, len(fields))
+	for key, value := range fields {
+		if value == nil {
+			continue
+		}
+		sanitized[key] = sanitizeFieldValue(value)
+	}
+	if len(sanitized) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(sanitized)
+	if err != nil {
+		return nil, fmt.Errorf("%w: fields: %v", ErrInvalidInput, err)
+	}
+	return data, nil
+}
+
+// sanitizeFieldValue escapes text the same way as item content
+func sanitizeFieldValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		sanitized, _ := validateAndSanitizeString(v, len(v), "field")
+		return sanitized
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i := range v {
+			out[i] = sanitizeFieldValue(v[i])
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key := range v {
+			out[key] = sanitizeFieldValue(v[key])
+		}
+		return out
+	}
+	return value
+}
+
+// decodeFields unmarshals stored field values; invalid or empty data yields nil
+func decodeFields(data json.RawMessage) map[string]interface{} {
+	var fields map[string]interface{}
+	if len(data) > 0 {
+		json.Unmarshal(data, &fields)
+	}
+	return fields
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestBoardService_CreateBoardItemFields(t *testing.T) {
+	boardID := uuid.New()
+	userID := uuid.New()
+
+	tests := []struct {
+		name           string
+		request        CreateItemRequest
+		expectedType   string
+		expectedFields string
+		expectedErr    error
+	}{
+		{
+			name:         "note defaults to a post-it",
+			request:      CreateItemRequest{Type: models.ItemTypeNote, Content: "Lead"},
+			expectedType: string(models.ItemTypePostIt),
+		},
+		{
+			name: "note variant selects a registered type",
+			request: CreateItemRequest{
+				Type:     models.ItemTypeNote,
+				Metadata: map[string]interface{}{"variant": "location"},
+				Fields:   map[string]interface{}{"lat": 51.5, "lon": -0.12},
+			},
+			expectedType:   string(models.ItemTypeLocation),
+			expectedFields: `{"lat":51.5,"lon":-0.12}`,
+		},
+		{
+			name: "text fields are sanitized",
+			request: CreateItemRequest{
+				Type:   string(models.ItemTypeDocument),
+				Fields: map[string]interface{}{"title": "Lease <i>draft</i> & notes"},
+			},
+			expectedType:   string(models.ItemTypeDocument),
+			expectedFields: `{"title":"Lease draft &amp; notes"}`,
+		},
+		{
+			name:        "unknown type",
+			request:     CreateItemRequest{Type: "hologram"},
+			expectedErr: ErrInvalidInput,
+		},
+		{
+			name: "missing required field",
+			request: CreateItemRequest{
+				Type:   string(models.ItemTypeLocation),
+				Fields: map[string]interface{}{"lat": 51.5},
+			},
+			expectedErr: ErrInvalidInput,
+		},
+		{
+			name: "undeclared field",
+			request: CreateItemRequest{
+				Type:   string(models.ItemTypeVehicle),
+				Fields: map[string]interface{}{"wheels": 4.0},
+			},
+			expectedErr: ErrInvalidInput,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockBoardRepo := new(MockBoardRepository)
+			mockBoardItemRepo := new(MockBoardItemRepository)
+			svc := NewBoardService(mockBoardRepo, new(MockBoardUserRepository), mockBoardItemRepo, new(MockBoardConnectionRepository), nil, nil, nil, nil)
+
+			mockBoardRepo.On("GetPermission", boardID, userID).Return(true, models.PermissionWrite, nil)
+			mockBoardRepo.On("GetRowByID", boardID).Return(&models.Board{ID: boardID}, nil)
+			mockBoardItemRepo.On("Create", mock.AnythingOfType("*models.BoardItem")).Return(nil)
+
+			item, err := svc.CreateBoardItem(boardID, userID, tt.request)
+			if tt.expectedErr != nil {
+				assert.True(t, errors.Is(err, tt.expectedErr), "got %v", err)
+				mockBoardItemRepo.AssertNotCalled(t, "Create", mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedType, item.Type)
+			if tt.expectedFields == "" {
+				assert.Nil(t, item.Fields)
+			} else {
+				assert.JSONEq(t, tt.expectedFields, string(item.Fields))
+			}
+		})
+	}
+}
+
+func TestBoardService_UpdateBoardItemFields(t *testing.T) {
+	boardID := uuid.New()
+	userID := uuid.New()
+	itemID := uuid.New()
+
+	setup := func() (*BoardService, *MockBoardItemRepository) {
+		mockBoardRepo := new(MockBoardRepository)
+		mockBoardItemRepo := new(MockBoardItemRepository)
+		mockBoardRepo.On("GetPermission", boardID, userID).Return(true, models.PermissionWrite, nil)
+		mockBoardItemRepo.On("GetByID", itemID).Return(&models.BoardItem{
+			ID:      itemID,
+			BoardID: boardID,
+			Type:    string(models.ItemTypeEvent),
+			Fields:  []byte(`{"timestamp":"2024-05-01T21:30:00Z","approximate":true}`),
+		}, nil)
+		mockBoardItemRepo.On("Update", mock.AnythingOfType("*models.BoardItem")).Return(nil)
+		return NewBoardService(mockBoardRepo, new(MockBoardUserRepository), mockBoardItemRepo, new(MockBoardConnectionRepository), nil, nil, nil, nil), mockBoardItemRepo
+	}
+
+	t.Run("omitted fields are kept", func(t *testing.T) {
+		svc, _ := setup()
+		x := 10.0
+		item, err := svc.UpdateBoardItem(boardID, itemID, userID, UpdateItemRequest{X: &x})
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"timestamp":"2024-05-01T21:30:00Z","approximate":true}`, string(item.Fields))
+	})
+
+	t.Run("fields are replaced", func(t *testing.T) {
+		svc, _ := setup()
+		item, err := svc.UpdateBoardItem(boardID, itemID, userID, UpdateItemRequest{
+			Fields: map[string]interface{}{"timestamp": "2024-05-02T08:00:00+01:00"},
+		})
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"timestamp":"2024-05-02T08:00:00+01:00"}`, string(item.Fields))
+	})
+
+	t.Run("invalid fields are rejected", func(t *testing.T) {
+		svc, mockBoardItemRepo := setup()
+		_, err := svc.UpdateBoardItem(boardID, itemID, userID, UpdateItemRequest{
+			Fields: map[string]interface{}{"end": "2024-05-02T08:00:00Z"},
+		})
+		assert.True(t, errors.Is(err, ErrInvalidInput))
+		mockBoardItemRepo.AssertNotCalled(t, "Update", mock.Anything)
+	})
+}
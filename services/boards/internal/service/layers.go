@@ -0,0 +1,703 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+)
+
+// Layer limits
+const (
+	MaxLayersPerBoard   = 50
+	MaxLayerNameLength  = 100
+	MaxLayerMoveTargets = 500 // Items and connections moved onto a layer in one request
+)
+
+// Layer errors
+var (
+	ErrLayerNotFound = errors.New("layer not found")
+	ErrLayerLocked   = errors.New("layer is locked")
+	ErrLayerNotEmpty = errors.New("layer is not empty")
+)
+
+// CreateLayerRequest represents a layer creation request. Restricting the
+// layer to a minimum permission or to some users requires admin permission.
+type CreateLayerRequest struct {
+	Name          string                 `json:"name" binding:"required"`
+	Position      *int                   `json:"position"` // On top of the other layers when omitted
+	Visible       *bool                  `json:"visible"`  // Shown unless false
+	Locked        bool                   `json:"locked"`
+	MinPermission models.PermissionLevel `json:"min_permission"` // read, write or admin
+	UserIDs       []uuid.UUID            `json:"user_ids"`       // Board users allowed to see the layer
+}
+
+// UpdateLayerRequest represents a layer update request. Omitted fields are
+// left unchanged; an empty min_permission or user_ids lifts that restriction.
+type UpdateLayerRequest struct {
+	Name          *string                 `json:"name"`
+	Position      *int                    `json:"position"`
+	Visible       *bool                   `json:"visible"`
+	Locked        *bool                   `json:"locked"`
+	MinPermission *models.PermissionLevel `json:"min_permission"`
+	UserIDs       *[]uuid.UUID            `json:"user_ids"`
+}
+
+// MoveToLayerRequest moves items and connections onto a layer, or back onto
+// the base layer when LayerID is null
+type MoveToLayerRequest struct {
+	LayerID       *uuid.UUID  `json:"layer_id"`
+	ItemIDs       []uuid.UUID `json:"item_ids"`
+	ConnectionIDs []uuid.UUID `json:"connection_ids"`
+}
+
+// MoveToLayerResult holds the moved items and connections
+type MoveToLayerResult struct {
+	Items       []models.BoardItem       `json:"items"`
+	Connections []models.BoardConnection `json:"connections"`
+}
+
+// layerAllows reports whether a user with the given board permission can see
+// the layer. Board admins see every layer so that they can manage them.
+func layerAllows(layer *models.Layer, userID uuid.UUID, permission models.PermissionLevel) bool {
+	if permission == models.PermissionAdmin {
+		return true
+	}
+	if layer.MinPermission != "" && !permissionAllows(permission, layer.MinPermission) {
+		return false
+	}
+	if len(layer.UserIDs) == 0 {
+		return true
+	}
+	for _, id := range layer.UserIDs {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// boardAccess holds a board's layers and which of them a user can see, and
+// what the user is cleared for (see classification.go). Items and
+// connections on the base layer are always visible; connections are hidden
+// along with either of their ends.
+type boardAccess struct {
+	layers      map[uuid.UUID]*models.Layer
+	allowed     map[uuid.UUID]bool
+	visible     []models.Layer // Layers the user can see, in stacking order
+	hiddenItems map[uuid.UUID]bool
+
+	boardID          uuid.UUID
+	userID           uuid.UUID
+	permission       models.PermissionLevel
+	resolver         *PermissionResolver
+	clearance        *models.ClassificationLevel // Looked up when classified content is first met
+	redacted         map[uuid.UUID]bool          // Items redacted for the user so far
+	classifiedLoaded bool                        // Whether redacted includes every item, see loadClassified
+}
+
+func (a *boardAccess) canSeeLayer(layerID *uuid.UUID) bool {
+	return layerID == nil || a.allowed[*layerID]
+}
+
+func (a *boardAccess) canSeeItem(item *models.BoardItem) bool {
+	return a.canSeeLayer(item.LayerID)
+}
+
+func (a *boardAccess) canSeeConnection(conn *models.BoardConnection) bool {
+	return a.canSeeLayer(conn.LayerID) && !a.hiddenItems[conn.FromItemID] && !a.hiddenItems[conn.ToItemID]
+}
+
+// shown reports whether a visible layer is also toggled on
+func (a *boardAccess) shown(layerID *uuid.UUID) bool {
+	return layerID == nil || (a.allowed[*layerID] && a.layers[*layerID].Visible)
+}
+
+func (a *boardAccess) filterItems(items []models.BoardItem) []models.BoardItem {
+	if len(a.hiddenItems) == 0 && len(a.layers) == len(a.allowed) {
+		return items
+	}
+	visible := make([]models.BoardItem, 0, len(items))
+	for i := range items {
+		if a.canSeeItem(&items[i]) {
+			visible = append(visible, items[i])
+		}
+	}
+	return visible
+}
+
+func (a *boardAccess) filterConnections(connections []models.BoardConnection) []models.BoardConnection {
+	if len(a.hiddenItems) == 0 && len(a.layers) == len(a.allowed) {
+		return connections
+	}
+	visible := make([]models.BoardConnection, 0, len(connections))
+	for i := range connections {
+		if a.canSeeConnection(&connections[i]) {
+			visible = append(visible, connections[i])
+		}
+	}
+	return visible
+}
+
+// restricted reports whether the layer is hidden from some users of the board
+func (a *boardAccess) restricted(layerID *uuid.UUID) bool {
+	if layerID == nil {
+		return false
+	}
+	layer := a.layers[*layerID]
+	return layer != nil && layer.Restricted()
+}
+
+// checkLayer verifies that the user can put things on the layer
+func (a *boardAccess) checkLayer(layerID *uuid.UUID) error {
+	if !a.canSeeLayer(layerID) {
+		return ErrLayerNotFound
+	}
+	if layerID != nil && a.layers[*layerID].Locked {
+		return ErrLayerLocked
+	}
+	return nil
+}
+
+// checkItem verifies that the user can see and change the item
+func (a *boardAccess) checkItem(item *models.BoardItem) error {
+	if !a.canSeeItem(item) {
+		return ErrItemNotFound
+	}
+	if !a.cleared(item.Classification) {
+		return ErrUnauthorized
+	}
+	if item.LayerID != nil && a.layers[*item.LayerID].Locked {
+		return ErrLayerLocked
+	}
+	return nil
+}
+
+// checkConnection verifies that the user can see and change the connection
+func (a *boardAccess) checkConnection(conn *models.BoardConnection) error {
+	if !a.canSeeConnection(conn) {
+		return ErrConnectionNotFound
+	}
+	if conn.LayerID != nil && a.layers[*conn.LayerID].Locked {
+		return ErrLayerLocked
+	}
+	return nil
+}
+
+// boardAccess loads the board's layers and works out which the user can
+// see with the given board permission. Anonymous viewers of public boards
+// are passed as uuid.Nil with read permission.
+func (r *PermissionResolver) boardAccess(boardID, userID uuid.UUID, permission models.PermissionLevel) (*boardAccess, error) {
+	access := &boardAccess{
+		layers:     map[uuid.UUID]*models.Layer{},
+		allowed:    map[uuid.UUID]bool{},
+		boardID:    boardID,
+		userID:     userID,
+		permission: permission,
+		resolver:   r,
+	}
+	if r.layerRepo == nil {
+		return access, nil
+	}
+	layers, err := r.layerRepo.ListByBoard(boardID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list layers: %w", err)
+	}
+
+	var hidden []uuid.UUID
+	for i := range layers {
+		layer := &layers[i]
+		access.layers[layer.ID] = layer
+		if layerAllows(layer, userID, permission) {
+			access.allowed[layer.ID] = true
+			access.visible = append(access.visible, *layer)
+		} else {
+			hidden = append(hidden, layer.ID)
+		}
+	}
+	if len(hidden) > 0 {
+		ids, err := r.boardItemRepo.ListIDsByLayers(boardID, hidden)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list items: %w", err)
+		}
+		access.hiddenItems = make(map[uuid.UUID]bool, len(ids))
+		for _, id := range ids {
+			access.hiddenItems[id] = true
+		}
+	}
+	return access, nil
+}
+
+// authorizeAccess checks the user's permission on the board like Authorize
+// and returns their access to its layers
+func (r *PermissionResolver) authorizeAccess(ctx context.Context, boardID, userID uuid.UUID, required models.PermissionLevel) (*boardAccess, error) {
+	exists, permission, err := r.Resolve(ctx, boardID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get board: %w", err)
+	}
+	if !exists || permission == "" {
+		return nil, ErrBoardNotFound
+	}
+	if !permissionAllows(permission, required) {
+		return nil, ErrUnauthorized
+	}
+	return r.boardAccess(boardID, userID, permission)
+}
+
+// getBoardContents loads a board with the items and connections the user
+// can see, redacted above their clearance, and the layers they can see in
+// board.Layers
+func (s *BoardService) getBoardContents(boardID, userID uuid.UUID) (*models.Board, models.PermissionLevel, *boardAccess, error) {
+	board, permission, err := s.boardRepo.GetByIDWithContents(boardID, userID)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("failed to get board: %w", err)
+	}
+	if board == nil || permission == "" {
+		return nil, "", nil, ErrBoardNotFound
+	}
+	access, err := s.permissions.boardAccess(boardID, userID, permission)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	board.Items = access.filterItems(board.Items)
+	board.Connections = access.filterConnections(board.Connections)
+	board.Layers = access.visible
+	access.redactContents(board)
+	return board, permission, access, nil
+}
+
+// hideToggledOffLayers leaves out the contents of layers that are switched
+// off, for outputs that show the wall as it looks
+func hideToggledOffLayers(board *models.Board, access *boardAccess) {
+	items := make([]models.BoardItem, 0, len(board.Items))
+	shown := make(map[uuid.UUID]bool, len(board.Items))
+	for _, item := range board.Items {
+		if access.shown(item.LayerID) {
+			items = append(items, item)
+			shown[item.ID] = true
+		}
+	}
+	connections := make([]models.BoardConnection, 0, len(board.Connections))
+	for _, conn := range board.Connections {
+		if access.shown(conn.LayerID) && shown[conn.FromItemID] && shown[conn.ToItemID] {
+			connections = append(connections, conn)
+		}
+	}
+	board.Items, board.Connections = items, connections
+}
+
+// ListLayers returns the layers of a board the user can see, bottom first
+func (s *BoardService) ListLayers(boardID, userID uuid.UUID) ([]models.Layer, error) {
+	access, err := s.permissions.authorizeAccess(context.Background(), boardID, userID, models.PermissionRead)
+	if err != nil {
+		return nil, err
+	}
+	if access.visible == nil {
+		return []models.Layer{}, nil
+	}
+	return access.visible, nil
+}
+
+// validateLayerRestriction checks a layer's minimum permission and allowed
+// users, which must have access to the board
+func (s *BoardService) validateLayerRestriction(boardID uuid.UUID, minPermission models.PermissionLevel, userIDs []uuid.UUID) ([]uuid.UUID, error) {
+	if minPermission != "" {
+		if _, ok := permissionRank[minPermission]; !ok {
+			return nil, fmt.Errorf("%w: min_permission must be read, write or admin", ErrInvalidInput)
+		}
+	}
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+
+	boardUsers, err := s.boardUserRepo.ListByBoard(boardID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list board users: %w", err)
+	}
+	onBoard := make(map[uuid.UUID]bool, len(boardUsers))
+	for _, bu := range boardUsers {
+		onBoard[bu.UserID] = true
+	}
+	seen := make(map[uuid.UUID]bool, len(userIDs))
+	unique := make([]uuid.UUID, 0, len(userIDs))
+	for _, id := range userIDs {
+		if !onBoard[id] {
+			return nil, fmt.Errorf("%w: user %s has no access to the board", ErrInvalidInput, id)
+		}
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique, nil
+}
+
+// CreateLayer adds a layer to a board
+func (s *BoardService) CreateLayer(boardID, userID uuid.UUID, req CreateLayerRequest) (*models.Layer, error) {
+	if s.layerRepo == nil {
+		return nil, ErrInvalidInput
+	}
+	exists, permission, err := s.permissions.Resolve(context.Background(), boardID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get board: %w", err)
+	}
+	if !exists || permission == "" {
+		return nil, ErrBoardNotFound
+	}
+	if !permissionAllows(permission, models.PermissionWrite) {
+		return nil, ErrUnauthorized
+	}
+	if (req.MinPermission != "" || len(req.UserIDs) > 0) && permission != models.PermissionAdmin {
+		return nil, ErrUnauthorized
+	}
+
+	name, err := validateAndSanitizeString(req.Name, MaxLayerNameLength, "name")
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		return nil, fmt.Errorf("%w: a layer name is required", ErrInvalidInput)
+	}
+	userIDs, err := s.validateLayerRestriction(boardID, req.MinPermission, req.UserIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	layers, err := s.layerRepo.ListByBoard(boardID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list layers: %w", err)
+	}
+	if len(layers) >= MaxLayersPerBoard {
+		return nil, fmt.Errorf("%w: a board can have at most %d layers", ErrInvalidInput, MaxLayersPerBoard)
+	}
+
+	layer := &models.Layer{
+		BoardID:       boardID,
+		Name:          name,
+		Visible:       req.Visible == nil || *req.Visible,
+		Locked:        req.Locked,
+		MinPermission: req.MinPermission,
+		UserIDs:       userIDs,
+		CreatedBy:     userID,
+	}
+	if req.Position != nil {
+		layer.Position = *req.Position
+	} else {
+		for _, l := range layers {
+			if l.Position >= layer.Position {
+				layer.Position = l.Position + 1
+			}
+		}
+	}
+
+	if err := s.layerRepo.Create(layer); err != nil {
+		return nil, fmt.Errorf("failed to create layer: %w", err)
+	}
+	s.publishLayerEvent(boardID, layer, "layer_created", layer)
+	return layer, nil
+}
+
+// UpdateLayer renames, reorders, toggles, locks or restricts a layer.
+// Changing who can see the layer requires admin permission.
+func (s *BoardService) UpdateLayer(boardID, layerID, userID uuid.UUID, req UpdateLayerRequest) (*models.Layer, error) {
+	if s.layerRepo == nil {
+		return nil, ErrLayerNotFound
+	}
+	exists, permission, err := s.permissions.Resolve(context.Background(), boardID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get board: %w", err)
+	}
+	if !exists || permission == "" {
+		return nil, ErrBoardNotFound
+	}
+	if !permissionAllows(permission, models.PermissionWrite) {
+		return nil, ErrUnauthorized
+	}
+	if (req.MinPermission != nil || req.UserIDs != nil) && permission != models.PermissionAdmin {
+		return nil, ErrUnauthorized
+	}
+
+	layer, err := s.layerRepo.GetByID(layerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get layer: %w", err)
+	}
+	if layer == nil || layer.BoardID != boardID || !layerAllows(layer, userID, permission) {
+		return nil, ErrLayerNotFound
+	}
+	before := *layer
+
+	if req.Name != nil {
+		name, err := validateAndSanitizeString(*req.Name, MaxLayerNameLength, "name")
+		if err != nil {
+			return nil, err
+		}
+		if name == "" {
+			return nil, fmt.Errorf("%w: a layer name is required", ErrInvalidInput)
+		}
+		layer.Name = name
+	}
+	if req.Position != nil {
+		layer.Position = *req.Position
+	}
+	if req.Visible != nil {
+		layer.Visible = *req.Visible
+	}
+	if req.Locked != nil {
+		layer.Locked = *req.Locked
+	}
+	if req.MinPermission != nil || req.UserIDs != nil {
+		minPermission, userIDs := layer.MinPermission, layer.UserIDs
+		if req.MinPermission != nil {
+			minPermission = *req.MinPermission
+		}
+		if req.UserIDs != nil {
+			userIDs = *req.UserIDs
+		}
+		if userIDs, err = s.validateLayerRestriction(boardID, minPermission, userIDs); err != nil {
+			return nil, err
+		}
+		layer.MinPermission, layer.UserIDs = minPermission, userIDs
+	}
+
+	if err := s.layerRepo.Update(layer); err != nil {
+		return nil, fmt.Errorf("failed to update layer: %w", err)
+	}
+
+	// Users who can no longer see the layer are told it is gone
+	if before.Restricted() || layer.Restricted() {
+		s.publishLayerRevoked(boardID, &before, layer)
+	}
+	s.publishLayerEvent(boardID, layer, "layer_updated", layer)
+	return layer, nil
+}
+
+// DeleteLayer deletes an empty layer
+func (s *BoardService) DeleteLayer(boardID, layerID, userID uuid.UUID) error {
+	if s.layerRepo == nil {
+		return ErrLayerNotFound
+	}
+	access, err := s.permissions.authorizeAccess(context.Background(), boardID, userID, models.PermissionWrite)
+	if err != nil {
+		return err
+	}
+	layer := access.layers[layerID]
+	if layer == nil || !access.allowed[layerID] {
+		return ErrLayerNotFound
+	}
+
+	count, err := s.layerRepo.CountContents(layerID)
+	if err != nil {
+		return fmt.Errorf("failed to count layer contents: %w", err)
+	}
+	if count > 0 {
+		return ErrLayerNotEmpty
+	}
+	if err := s.layerRepo.Delete(layerID); err != nil {
+		return fmt.Errorf("failed to delete layer: %w", err)
+	}
+	s.publishLayerEvent(boardID, layer, "layer_deleted", map[string]interface{}{"id": layerID})
+	return nil
+}
+
+// MoveToLayer moves items and connections onto a layer, as one undoable
+// change. Neither the layer nor the moved items and connections may be locked.
+func (s *BoardService) MoveToLayer(boardID, userID uuid.UUID, req MoveToLayerRequest) (*MoveToLayerResult, error) {
+	access, err := s.permissions.authorizeAccess(context.Background(), boardID, userID, models.PermissionWrite)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.ItemIDs) == 0 && len(req.ConnectionIDs) == 0 {
+		return nil, fmt.Errorf("%w: no items or connections to move", ErrInvalidInput)
+	}
+	if len(req.ItemIDs)+len(req.ConnectionIDs) > MaxLayerMoveTargets {
+		return nil, fmt.Errorf("%w: at most %d items and connections can be moved at once", ErrInvalidInput, MaxLayerMoveTargets)
+	}
+	if err := access.checkLayer(req.LayerID); err != nil {
+		return nil, err
+	}
+
+	result := &MoveToLayerResult{Items: []models.BoardItem{}, Connections: []models.BoardConnection{}}
+	var changes []HistoryChange
+	var itemIDs, connectionIDs []uuid.UUID
+	var previous []*uuid.UUID // Former layers, items first
+	for _, id := range req.ItemIDs {
+		item, err := s.boardItemRepo.GetByID(id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get item: %w", err)
+		}
+		if item == nil || item.BoardID != boardID {
+			return nil, ErrItemNotFound
+		}
+		if err := access.checkItem(item); err != nil {
+			return nil, err
+		}
+		if sameID(item.LayerID, req.LayerID) {
+			continue
+		}
+		before := stripItem(item)
+		previous = append(previous, item.LayerID)
+		item.LayerID = req.LayerID
+		itemIDs = append(itemIDs, id)
+		result.Items = append(result.Items, *item)
+		changes = append(changes, itemChange(before, item))
+	}
+	for _, id := range req.ConnectionIDs {
+		conn, err := s.connectionRepo.GetByID(id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get connection: %w", err)
+		}
+		if conn == nil || conn.BoardID != boardID {
+			return nil, ErrConnectionNotFound
+		}
+		if err := access.checkConnection(conn); err != nil {
+			return nil, err
+		}
+		if sameID(conn.LayerID, req.LayerID) {
+			continue
+		}
+		before := stripConnection(conn)
+		previous = append(previous, conn.LayerID)
+		conn.LayerID = req.LayerID
+		connectionIDs = append(connectionIDs, id)
+		result.Connections = append(result.Connections, *conn)
+		changes = append(changes, connectionChange(before, conn))
+	}
+
+	if err := s.boardItemRepo.SetLayer(itemIDs, req.LayerID); err != nil {
+		return nil, fmt.Errorf("failed to move items: %w", err)
+	}
+	if err := s.connectionRepo.SetLayer(connectionIDs, req.LayerID); err != nil {
+		return nil, fmt.Errorf("failed to move connections: %w", err)
+	}
+
+	var target *models.Layer
+	if req.LayerID != nil {
+		target = access.layers[*req.LayerID]
+	}
+	for i := range result.Items {
+		item := &result.Items[i]
+		s.publishMovedOut(boardID, previous[i], target, "item_deleted", item.ID)
+		s.publishBoardUpdate(boardID, "item_updated", item)
+	}
+	for i := range result.Connections {
+		conn := &result.Connections[i]
+		s.publishMovedOut(boardID, previous[len(result.Items)+i], target, "connection_deleted", conn.ID)
+		s.publishBoardUpdate(boardID, "connection_updated", conn)
+	}
+	s.recordHistory(boardID, userID, "layer_changed", changes...)
+	return result, nil
+}
+
+// snapshotLayer returns the layer recorded in a history snapshot
+func snapshotLayer(snapshot json.RawMessage) *uuid.UUID {
+	var state struct {
+		LayerID *uuid.UUID `json:"layer_id"`
+	}
+	if len(snapshot) == 0 || json.Unmarshal(snapshot, &state) != nil {
+		return nil
+	}
+	return state.LayerID
+}
+
+// publishLayerUpdate publishes a realtime update about something on a
+// layer. Updates on restricted layers only go to the users who can see them,
+// on their own channels; the rest go to everyone on the board.
+func (s *BoardService) publishLayerUpdate(boardID uuid.UUID, layerID *uuid.UUID, event string, data interface{}) {
+	if s.redis == nil {
+		return
+	}
+	if layerID == nil || s.layerRepo == nil {
+		s.publish(boardChannel(boardID), boardID, event, data)
+		return
+	}
+	layer, err := s.layerRepo.GetByID(*layerID)
+	if err != nil || layer == nil {
+		log.Printf("publishLayerUpdate: Error getting layer %s: %v", *layerID, err)
+		return
+	}
+	s.publishLayerEvent(boardID, layer, event, data)
+}
+
+// publishLayerEvent publishes a realtime update to the users who can see
+// the layer
+func (s *BoardService) publishLayerEvent(boardID uuid.UUID, layer *models.Layer, event string, data interface{}) {
+	if s.redis == nil {
+		return
+	}
+	if !layer.Restricted() {
+		s.publish(boardChannel(boardID), boardID, event, data)
+		return
+	}
+	allowed, err := s.layerAudience(boardID, layer)
+	if err != nil {
+		log.Printf("publishLayerEvent: Error listing board users: %v", err)
+		return
+	}
+	for _, userID := range allowed {
+		s.publish(userChannel(boardID, userID), boardID, event, data)
+	}
+}
+
+// publishLayerRevoked tells the board users who could see a layer before a
+// change, and no longer can, that it is gone
+func (s *BoardService) publishLayerRevoked(boardID uuid.UUID, before, after *models.Layer) {
+	if s.redis == nil {
+		return
+	}
+	users, err := s.boardUserRepo.ListByBoard(boardID)
+	if err != nil {
+		log.Printf("publishLayerRevoked: Error listing board users: %v", err)
+		return
+	}
+	for _, bu := range users {
+		if layerAllows(before, bu.UserID, bu.Permission) && !layerAllows(after, bu.UserID, bu.Permission) {
+			s.publish(userChannel(boardID, bu.UserID), boardID, "layer_deleted", map[string]interface{}{"id": after.ID})
+		}
+	}
+}
+
+// publishMovedOut tells the board users who could see something on its
+// former layer, and cannot on the target layer, that it was deleted
+func (s *BoardService) publishMovedOut(boardID uuid.UUID, from *uuid.UUID, to *models.Layer, event string, id uuid.UUID) {
+	if s.redis == nil || to == nil || !to.Restricted() {
+		return
+	}
+	var before *models.Layer
+	if from != nil && s.layerRepo != nil {
+		layer, err := s.layerRepo.GetByID(*from)
+		if err != nil || layer == nil {
+			return
+		}
+		before = layer
+	}
+	users, err := s.boardUserRepo.ListByBoard(boardID)
+	if err != nil {
+		log.Printf("publishMovedOut: Error listing board users: %v", err)
+		return
+	}
+	for _, bu := range users {
+		if (before == nil || layerAllows(before, bu.UserID, bu.Permission)) && !layerAllows(to, bu.UserID, bu.Permission) {
+			s.publish(userChannel(boardID, bu.UserID), boardID, event, map[string]interface{}{"id": id})
+		}
+	}
+}
+
+// layerAudience lists the board users who can see the layer
+func (s *BoardService) layerAudience(boardID uuid.UUID, layer *models.Layer) ([]uuid.UUID, error) {
+	users, err := s.boardUserRepo.ListByBoard(boardID)
+	if err != nil {
+		return nil, err
+	}
+	var allowed []uuid.UUID
+	for _, bu := range users {
+		if layerAllows(layer, bu.UserID, bu.Permission) {
+			allowed = append(allowed, bu.UserID)
+		}
+	}
+	return allowed, nil
+}
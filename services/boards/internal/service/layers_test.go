@@ -0,0 +1,311 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockLayerRepository is a mock implementation of LayerRepository
+type MockLayerRepository struct {
+	mock.Mock
+}
+
+func (m *MockLayerRepository) Create(layer *models.Layer) error {
+	args := m.Called(layer)
+	return args.Error(0)
+}
+
+func (m *MockLayerRepository) GetByID(id uuid.UUID) (*models.Layer, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Layer), args.Error(1)
+}
+
+func (m *MockLayerRepository) ListByBoard(boardID uuid.UUID) ([]models.Layer, error) {
+	args := m.Called(boardID)
+	return args.Get(0).([]models.Layer), args.Error(1)
+}
+
+func (m *MockLayerRepository) Update(layer *models.Layer) error {
+	args := m.Called(layer)
+	return args.Error(0)
+}
+
+func (m *MockLayerRepository) CountContents(id uuid.UUID) (int64, error) {
+	args := m.Called(id)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockLayerRepository) Delete(id uuid.UUID) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockLayerRepository) DeleteByBoard(boardID uuid.UUID) error {
+	args := m.Called(boardID)
+	return args.Error(0)
+}
+
+// layerFixture is a board with an open layer, a locked layer and a secret
+// layer only another user can see. There is an item on the base layer and
+// on each of the layers, and a connection from the base item to the secret
+// one.
+type layerFixture struct {
+	*serviceFixture
+	informant  uuid.UUID
+	open       models.Layer
+	locked     models.Layer
+	secret     models.Layer
+	baseItem   models.BoardItem
+	openItem   models.BoardItem
+	lockedItem models.BoardItem
+	secretItem models.BoardItem
+	conn       models.BoardConnection
+}
+
+func newLayerFixture(permission models.PermissionLevel) *layerFixture {
+	f := &layerFixture{informant: uuid.New()}
+	board := &models.Board{ID: uuid.New(), Title: "Case"}
+	boardID := board.ID
+	f.open = models.Layer{ID: uuid.New(), BoardID: boardID, Name: "Timeline", Position: 0, Visible: true}
+	f.locked = models.Layer{ID: uuid.New(), BoardID: boardID, Name: "Scene", Position: 1, Visible: true, Locked: true}
+	f.secret = models.Layer{ID: uuid.New(), BoardID: boardID, Name: "Informants", Position: 2, Visible: true, UserIDs: []uuid.UUID{f.informant}}
+	f.baseItem = models.BoardItem{ID: uuid.New(), BoardID: boardID, Type: "note", Content: "Base"}
+	f.openItem = models.BoardItem{ID: uuid.New(), BoardID: boardID, Type: "note", Content: "Open", LayerID: &f.open.ID}
+	f.lockedItem = models.BoardItem{ID: uuid.New(), BoardID: boardID, Type: "note", Content: "Locked", LayerID: &f.locked.ID}
+	f.secretItem = models.BoardItem{ID: uuid.New(), BoardID: boardID, Type: "note", Content: "Secret", LayerID: &f.secret.ID}
+	f.conn = models.BoardConnection{ID: uuid.New(), BoardID: boardID, FromItemID: f.baseItem.ID, ToItemID: f.secretItem.ID}
+
+	board.Items = []models.BoardItem{f.baseItem, f.openItem, f.lockedItem, f.secretItem}
+	board.Connections = []models.BoardConnection{f.conn}
+	f.serviceFixture = newServiceFixture(board, permission, "", f.open, f.locked, f.secret)
+	f.layerRepo.On("GetByID", f.open.ID).Return(&f.open, nil)
+	f.itemRepo.On("ListIDsByLayers", boardID, []uuid.UUID{f.secret.ID}).Return([]uuid.UUID{f.secretItem.ID}, nil)
+	for _, item := range []*models.BoardItem{&f.baseItem, &f.openItem, &f.lockedItem, &f.secretItem} {
+		f.itemRepo.On("GetByID", item.ID).Return(item, nil)
+	}
+	f.connRepo.On("GetByID", f.conn.ID).Return(&f.conn, nil)
+	return f
+}
+
+func TestLayerAllows(t *testing.T) {
+	userID := uuid.New()
+	tests := []struct {
+		name       string
+		layer      models.Layer
+		permission models.PermissionLevel
+		want       bool
+	}{
+		{"unrestricted", models.Layer{}, models.PermissionRead, true},
+		{"below minimum permission", models.Layer{MinPermission: models.PermissionWrite}, models.PermissionRead, false},
+		{"at minimum permission", models.Layer{MinPermission: models.PermissionWrite}, models.PermissionWrite, true},
+		{"allowed user", models.Layer{UserIDs: []uuid.UUID{userID}}, models.PermissionRead, true},
+		{"other users only", models.Layer{UserIDs: []uuid.UUID{uuid.New()}}, models.PermissionWrite, false},
+		{"allowed user below minimum", models.Layer{MinPermission: models.PermissionWrite, UserIDs: []uuid.UUID{userID}}, models.PermissionRead, false},
+		{"admin", models.Layer{MinPermission: models.PermissionAdmin, UserIDs: []uuid.UUID{uuid.New()}}, models.PermissionAdmin, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, layerAllows(&tt.layer, userID, tt.permission))
+		})
+	}
+}
+
+func TestBoardService_GetBoard_HidesRestrictedLayers(t *testing.T) {
+	f := newLayerFixture(models.PermissionWrite)
+	f.itemRepo.On("ListFrameMembership", f.board.ID).Return([]models.BoardItem{}, nil)
+
+	board, err := f.svc.GetBoard(f.board.ID, f.userID)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.BoardItem{f.baseItem, f.openItem, f.lockedItem}, board.Items)
+	assert.Empty(t, board.Connections, "connections to hidden items are hidden too")
+	assert.Equal(t, []models.Layer{f.open, f.locked}, board.Layers)
+
+	admin := newLayerFixture(models.PermissionAdmin)
+	admin.itemRepo.On("ListFrameMembership", admin.board.ID).Return([]models.BoardItem{}, nil)
+	board, err = admin.svc.GetBoard(admin.board.ID, admin.userID)
+	assert.NoError(t, err)
+	assert.Len(t, board.Items, 4)
+	assert.Len(t, board.Connections, 1)
+	assert.Len(t, board.Layers, 3)
+}
+
+func TestBoardService_GetBoard_CollapsedFrameOnRestrictedLayer(t *testing.T) {
+	f := newLayerFixture(models.PermissionWrite)
+	frame := newFrame(f.board.ID, nil, true)
+	frame.LayerID = &f.secret.ID
+	f.openItem.ParentID = &frame.ID
+	conn := models.BoardConnection{ID: uuid.New(), BoardID: f.board.ID, FromItemID: f.baseItem.ID, ToItemID: f.openItem.ID}
+	f.board.Items = append(f.board.Items, *frame)
+	f.board.Items[1] = f.openItem
+	f.board.Connections = []models.BoardConnection{conn}
+	f.itemRepo.On("ListFrameMembership", f.board.ID).Return([]models.BoardItem{*frame, f.openItem}, nil)
+
+	// The frame hides its contents without giving itself away
+	board, err := f.svc.GetBoard(f.board.ID, f.userID)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.BoardItem{f.baseItem, f.lockedItem}, board.Items)
+	assert.Empty(t, board.Connections)
+	assert.Empty(t, board.AggregatedConnections)
+}
+
+func TestBoardService_ListBoardItems_HidesRestrictedLayers(t *testing.T) {
+	f := newLayerFixture(models.PermissionRead)
+	f.itemRepo.On("ListByBoard", f.board.ID).Return(f.board.Items, nil)
+	f.connRepo.On("ListByBoardFiltered", f.board.ID, mock.Anything).Return(f.board.Connections, nil)
+	f.itemRepo.On("ListFrameMembership", f.board.ID).Return([]models.BoardItem{}, nil)
+
+	items, err := f.svc.ListBoardItems(f.board.ID, f.userID, ItemQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.BoardItem{f.baseItem, f.openItem, f.lockedItem}, items)
+
+	connections, err := f.svc.ListBoardConnections(f.board.ID, f.userID, ConnectionQuery{})
+	assert.NoError(t, err)
+	assert.Empty(t, connections)
+}
+
+func TestBoardService_ItemsOnRestrictedOrLockedLayers(t *testing.T) {
+	f := newLayerFixture(models.PermissionWrite)
+	content := "Changed"
+
+	_, err := f.svc.UpdateBoardItem(f.board.ID, f.lockedItem.ID, f.userID, UpdateItemRequest{Content: content})
+	assert.Equal(t, ErrLayerLocked, err)
+
+	_, err = f.svc.UpdateBoardItem(f.board.ID, f.secretItem.ID, f.userID, UpdateItemRequest{Content: content})
+	assert.Equal(t, ErrItemNotFound, err)
+
+	err = f.svc.DeleteBoardItem(f.board.ID, f.secretItem.ID, f.userID, false)
+	assert.Equal(t, ErrItemNotFound, err)
+
+	err = f.svc.DeleteBoardConnection(f.board.ID, f.conn.ID, f.userID)
+	assert.Equal(t, ErrConnectionNotFound, err)
+
+	_, err = f.svc.CreateBoardItem(f.board.ID, f.userID, CreateItemRequest{Type: "note", Content: "New", LayerID: &f.locked.ID})
+	assert.Equal(t, ErrLayerLocked, err)
+
+	_, err = f.svc.CreateBoardItem(f.board.ID, f.userID, CreateItemRequest{Type: "note", Content: "New", LayerID: &f.secret.ID})
+	assert.Equal(t, ErrLayerNotFound, err)
+
+	f.itemRepo.On("Create", mock.AnythingOfType("*models.BoardItem")).Return(nil)
+	item, err := f.svc.CreateBoardItem(f.board.ID, f.userID, CreateItemRequest{Type: "note", Content: "New", LayerID: &f.open.ID})
+	assert.NoError(t, err)
+	assert.Equal(t, f.open.ID, *item.LayerID)
+
+	_, err = f.svc.CreateBoardConnection(f.board.ID, f.userID, CreateConnectionRequest{FromItemID: f.baseItem.ID, ToItemID: f.secretItem.ID})
+	assert.Equal(t, ErrInvalidInput, err)
+	f.itemRepo.AssertNotCalled(t, "Update", mock.Anything)
+	f.itemRepo.AssertNotCalled(t, "Delete", mock.Anything)
+}
+
+func TestBoardService_CreateLayer(t *testing.T) {
+	f := newLayerFixture(models.PermissionWrite)
+	f.layerRepo.On("Create", mock.AnythingOfType("*models.Layer")).Return(nil)
+
+	layer, err := f.svc.CreateLayer(f.board.ID, f.userID, CreateLayerRequest{Name: " Surveillance "})
+	assert.NoError(t, err)
+	assert.Equal(t, "Surveillance", layer.Name)
+	assert.Equal(t, 3, layer.Position, "new layers go on top")
+	assert.True(t, layer.Visible)
+
+	_, err = f.svc.CreateLayer(f.board.ID, f.userID, CreateLayerRequest{Name: "Sources", UserIDs: []uuid.UUID{f.userID}})
+	assert.Equal(t, ErrUnauthorized, err, "restricting a layer needs admin permission")
+
+	admin := newLayerFixture(models.PermissionAdmin)
+	admin.layerRepo.On("Create", mock.AnythingOfType("*models.Layer")).Return(nil)
+	admin.userRepo.On("ListByBoard", admin.board.ID).Return([]models.BoardUser{
+		{BoardID: admin.board.ID, UserID: admin.userID, Permission: models.PermissionAdmin},
+		{BoardID: admin.board.ID, UserID: admin.informant, Permission: models.PermissionRead},
+	}, nil)
+
+	layer, err = admin.svc.CreateLayer(admin.board.ID, admin.userID, CreateLayerRequest{Name: "Sources", UserIDs: []uuid.UUID{admin.informant, admin.informant}})
+	assert.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{admin.informant}, layer.UserIDs)
+
+	_, err = admin.svc.CreateLayer(admin.board.ID, admin.userID, CreateLayerRequest{Name: "Sources", UserIDs: []uuid.UUID{uuid.New()}})
+	assert.True(t, errors.Is(err, ErrInvalidInput), "allowed users must be on the board")
+
+	_, err = admin.svc.CreateLayer(admin.board.ID, admin.userID, CreateLayerRequest{Name: "Sources", MinPermission: "owner"})
+	assert.True(t, errors.Is(err, ErrInvalidInput))
+}
+
+func TestBoardService_UpdateLayer(t *testing.T) {
+	f := newLayerFixture(models.PermissionWrite)
+	f.layerRepo.On("GetByID", f.locked.ID).Return(&f.locked, nil)
+	f.layerRepo.On("GetByID", f.secret.ID).Return(&f.secret, nil)
+	f.layerRepo.On("Update", mock.AnythingOfType("*models.Layer")).Return(nil)
+
+	unlocked := false
+	layer, err := f.svc.UpdateLayer(f.board.ID, f.locked.ID, f.userID, UpdateLayerRequest{Locked: &unlocked})
+	assert.NoError(t, err)
+	assert.False(t, layer.Locked)
+
+	_, err = f.svc.UpdateLayer(f.board.ID, f.secret.ID, f.userID, UpdateLayerRequest{Locked: &unlocked})
+	assert.Equal(t, ErrLayerNotFound, err)
+
+	public := []uuid.UUID{}
+	_, err = f.svc.UpdateLayer(f.board.ID, f.open.ID, f.userID, UpdateLayerRequest{UserIDs: &public})
+	assert.Equal(t, ErrUnauthorized, err)
+}
+
+func TestBoardService_DeleteLayer(t *testing.T) {
+	f := newLayerFixture(models.PermissionWrite)
+	f.layerRepo.On("CountContents", f.open.ID).Return(int64(1), nil)
+	f.layerRepo.On("CountContents", f.locked.ID).Return(int64(0), nil)
+	f.layerRepo.On("Delete", f.locked.ID).Return(nil)
+
+	assert.Equal(t, ErrLayerNotEmpty, f.svc.DeleteLayer(f.board.ID, f.open.ID, f.userID))
+	assert.Equal(t, ErrLayerNotFound, f.svc.DeleteLayer(f.board.ID, f.secret.ID, f.userID))
+	assert.NoError(t, f.svc.DeleteLayer(f.board.ID, f.locked.ID, f.userID))
+	f.layerRepo.AssertNotCalled(t, "Delete", f.open.ID)
+}
+
+func TestBoardService_MoveToLayer(t *testing.T) {
+	f := newLayerFixture(models.PermissionWrite)
+	f.itemRepo.On("SetLayer", []uuid.UUID{f.baseItem.ID}, &f.open.ID).Return(nil)
+	f.connRepo.On("SetLayer", []uuid.UUID(nil), &f.open.ID).Return(nil)
+
+	result, err := f.svc.MoveToLayer(f.board.ID, f.userID, MoveToLayerRequest{LayerID: &f.open.ID, ItemIDs: []uuid.UUID{f.baseItem.ID, f.openItem.ID}})
+	assert.NoError(t, err)
+	assert.Len(t, result.Items, 1, "items already on the layer are left alone")
+	assert.Equal(t, f.open.ID, *result.Items[0].LayerID)
+
+	_, err = f.svc.MoveToLayer(f.board.ID, f.userID, MoveToLayerRequest{LayerID: &f.locked.ID, ItemIDs: []uuid.UUID{f.baseItem.ID}})
+	assert.Equal(t, ErrLayerLocked, err)
+
+	_, err = f.svc.MoveToLayer(f.board.ID, f.userID, MoveToLayerRequest{ItemIDs: []uuid.UUID{f.lockedItem.ID}})
+	assert.Equal(t, ErrLayerLocked, err)
+
+	_, err = f.svc.MoveToLayer(f.board.ID, f.userID, MoveToLayerRequest{ConnectionIDs: []uuid.UUID{f.conn.ID}})
+	assert.Equal(t, ErrConnectionNotFound, err)
+
+	_, err = f.svc.MoveToLayer(f.board.ID, f.userID, MoveToLayerRequest{LayerID: &f.secret.ID, ItemIDs: []uuid.UUID{f.baseItem.ID}})
+	assert.Equal(t, ErrLayerNotFound, err)
+}
+
+func TestBoardService_PublishTemplate_RestrictedLayersArePlaceholders(t *testing.T) {
+	f := newLayerFixture(models.PermissionAdmin)
+	secretConn := models.BoardConnection{ID: uuid.New(), BoardID: f.board.ID, FromItemID: f.baseItem.ID, ToItemID: f.openItem.ID, LayerID: &f.secret.ID, Label: "Tipped off"}
+	f.board.Connections = append(f.board.Connections, secretConn)
+	templateRepo := new(MockTemplateRepository)
+	templateRepo.On("Create", mock.AnythingOfType("*models.BoardTemplate")).Return(nil)
+	svc := NewBoardService(f.boardRepo, f.userRepo, f.itemRepo, f.connRepo, templateRepo, nil, f.layerRepo, nil)
+
+	template, err := svc.PublishTemplate(f.userID, PublishTemplateRequest{BoardID: f.board.ID, Name: "Case"})
+	assert.NoError(t, err)
+	if assert.Len(t, template.Content.Items, 4) {
+		assert.Equal(t, "Locked", template.Content.Items[2].Content)
+		secret := template.Content.Items[3]
+		assert.True(t, secret.Placeholder)
+		assert.Empty(t, secret.Content)
+	}
+	// The connection to the secret item is kept; the one on the secret layer is not
+	assert.Equal(t, []models.TemplateConnection{{From: f.baseItem.ID.String(), To: f.secretItem.ID.String()}}, template.Content.Connections)
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"evidence-wall/boards-service/internal/layout"
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+)
+
+// LayoutBoardRequest selects a layout algorithm and the items to arrange
+type LayoutBoardRequest struct {
+	Algorithm layout.Algorithm `json:"algorithm" binding:"required,oneof=force hierarchical circular grid"`
+	ItemIDs   []uuid.UUID      `json:"item_ids"`                                  // Items to arrange; empty means every item
+	Spacing   float64          `json:"spacing" binding:"omitempty,gt=0,lte=1000"` // Gap between items, default 40
+	Apply     bool             `json:"apply"`                                     // Save the result instead of previewing it
+}
+
+// LayoutResult holds the computed positions of the arranged items
+type LayoutResult struct {
+	Algorithm layout.Algorithm  `json:"algorithm"`
+	Applied   bool              `json:"applied"`
+	Positions []layout.Position `json:"positions"`
+}
+
+// LayoutBoard computes new positions for all or some items based on their
+// connections. A preview needs read access; applying needs write access and
+// saves every move in one transaction, recorded as a single undoable change.
+func (s *BoardService) LayoutBoard(boardID, userID uuid.UUID, req LayoutBoardRequest) (*LayoutResult, error) {
+	board, permission, access, err := s.getBoardContents(boardID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Apply && permission == models.PermissionRead {
+		return nil, ErrUnauthorized
+	}
+
+	byID := make(map[uuid.UUID]*models.BoardItem, len(board.Items))
+	for i := range board.Items {
+		byID[board.Items[i].ID] = &board.Items[i]
+	}
+	selected := board.Items
+	if req.Apply && len(req.ItemIDs) == 0 {
+		// Items on locked layers stay where they are
+		selected = make([]models.BoardItem, 0, len(board.Items))
+		for i := range board.Items {
+			if access.checkItem(&board.Items[i]) == nil {
+				selected = append(selected, board.Items[i])
+			}
+		}
+	}
+	if len(req.ItemIDs) > 0 {
+		selected = make([]models.BoardItem, 0, len(req.ItemIDs))
+		seen := make(map[uuid.UUID]bool, len(req.ItemIDs))
+		for _, id := range req.ItemIDs {
+			item, ok := byID[id]
+			if !ok {
+				return nil, ErrItemNotFound
+			}
+			if req.Apply {
+				if err := access.checkItem(item); err != nil {
+					return nil, err
+				}
+			}
+			if !seen[id] {
+				seen[id] = true
+				selected = append(selected, *item)
+			}
+		}
+	}
+
+	nodes := make([]layout.Node, len(selected))
+	for i, item := range selected {
+		nodes[i] = layout.Node{ID: item.ID, X: item.X, Y: item.Y, Width: item.Width, Height: item.Height}
+	}
+	edges := make([]layout.Edge, len(board.Connections))
+	for i, conn := range board.Connections {
+		edges[i] = layout.Edge{From: conn.FromItemID, To: conn.ToItemID}
+	}
+
+	positions, err := layout.Compute(req.Algorithm, nodes, edges, layout.Options{Spacing: req.Spacing})
+	if errors.Is(err, layout.ErrUnknownAlgorithm) {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute layout: %w", err)
+	}
+
+	result := &LayoutResult{Algorithm: req.Algorithm, Positions: positions}
+	if !req.Apply {
+		return result, nil
+	}
+
+	var moved []models.BoardItem
+	var changes []HistoryChange
+	for _, pos := range positions {
+		item := byID[pos.ID]
+		if item.X == pos.X && item.Y == pos.Y {
+			continue
+		}
+		before := stripItem(item)
+		item.X, item.Y = pos.X, pos.Y
+		moved = append(moved, *item)
+		changes = append(changes, itemChange(before, item))
+	}
+	if len(moved) > 0 {
+		if err := s.boardItemRepo.UpdatePositions(moved); err != nil {
+			return nil, fmt.Errorf("failed to update item positions: %w", err)
+		}
+		for i := range moved {
+			s.publishBoardUpdate(boardID, "item_updated", &moved[i])
+		}
+		s.recordHistory(boardID, userID, "layout_applied", changes...)
+	}
+	result.Applied = true
+	return result, nil
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"evidence-wall/boards-service/internal/layout"
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// layoutTestBoard returns a board with three connected items piled on one spot;
+// rows plays the role of the stored items
+func layoutTestBoard() (*models.Board, map[uuid.UUID]*models.BoardItem) {
+	board := &models.Board{ID: uuid.New()}
+	rows := make(map[uuid.UUID]*models.BoardItem)
+	for i := 0; i < 3; i++ {
+		item := models.BoardItem{ID: uuid.New(), BoardID: board.ID, Type: "post-it", X: 100, Y: 100, Width: 200, Height: 200}
+		board.Items = append(board.Items, item)
+		row := item
+		rows[item.ID] = &row
+	}
+	board.Connections = []models.BoardConnection{
+		{ID: uuid.New(), BoardID: board.ID, FromItemID: board.Items[0].ID, ToItemID: board.Items[1].ID},
+		{ID: uuid.New(), BoardID: board.ID, FromItemID: board.Items[1].ID, ToItemID: board.Items[2].ID},
+	}
+	return board, rows
+}
+
+func TestBoardService_LayoutPreview(t *testing.T) {
+	userID := uuid.New()
+	board, _ := layoutTestBoard()
+	svc, mockBoardRepo, mockItemRepo, _ := newHistoryTestService()
+	mockBoardRepo.On("GetByIDWithContents", board.ID, userID).Return(board, models.PermissionRead, nil)
+
+	result, err := svc.LayoutBoard(board.ID, userID, LayoutBoardRequest{Algorithm: layout.Grid})
+	assert.NoError(t, err)
+	assert.False(t, result.Applied)
+	assert.Len(t, result.Positions, 3)
+	mockItemRepo.AssertNotCalled(t, "UpdatePositions", mock.Anything)
+
+	// A selection only moves the chosen items
+	result, err = svc.LayoutBoard(board.ID, userID, LayoutBoardRequest{Algorithm: layout.Circular, ItemIDs: []uuid.UUID{board.Items[2].ID, board.Items[0].ID}})
+	assert.NoError(t, err)
+	assert.Equal(t, board.Items[2].ID, result.Positions[0].ID)
+	assert.Len(t, result.Positions, 2)
+
+	_, err = svc.LayoutBoard(board.ID, userID, LayoutBoardRequest{Algorithm: layout.Grid, ItemIDs: []uuid.UUID{uuid.New()}})
+	assert.Equal(t, ErrItemNotFound, err)
+
+	_, err = svc.LayoutBoard(board.ID, userID, LayoutBoardRequest{Algorithm: "spiral"})
+	assert.True(t, errors.Is(err, ErrInvalidInput))
+
+	// Read access cannot apply
+	_, err = svc.LayoutBoard(board.ID, userID, LayoutBoardRequest{Algorithm: layout.Grid, Apply: true})
+	assert.Equal(t, ErrUnauthorized, err)
+}
+
+func TestBoardService_LayoutApplyAndUndo(t *testing.T) {
+	userID := uuid.New()
+	board, rows := layoutTestBoard()
+	svc, mockBoardRepo, mockItemRepo, _ := newHistoryTestService()
+	mockBoardRepo.On("GetByIDWithContents", board.ID, userID).Return(board, models.PermissionWrite, nil)
+	mockBoardRepo.On("GetPermission", board.ID, userID).Return(true, models.PermissionWrite, nil)
+	for id, row := range rows {
+		mockItemRepo.On("GetByID", id).Return(row, nil)
+	}
+	mockItemRepo.On("Update", mock.AnythingOfType("*models.BoardItem")).Return(nil)
+	mockItemRepo.On("UpdatePositions", mock.Anything).Run(func(args mock.Arguments) {
+		for _, item := range args.Get(0).([]models.BoardItem) {
+			rows[item.ID].X, rows[item.ID].Y = item.X, item.Y
+		}
+	}).Return(nil).Once()
+
+	result, err := svc.LayoutBoard(board.ID, userID, LayoutBoardRequest{Algorithm: layout.Hierarchical, Apply: true})
+	assert.NoError(t, err)
+	assert.True(t, result.Applied)
+	mockItemRepo.AssertNumberOfCalls(t, "UpdatePositions", 1)
+	for _, pos := range result.Positions {
+		assert.Equal(t, pos.X, rows[pos.ID].X)
+		assert.Equal(t, pos.Y, rows[pos.ID].Y)
+	}
+
+	// One undo restores every item
+	undone, err := svc.Undo(board.ID, userID)
+	assert.NoError(t, err)
+	assert.Equal(t, "layout_applied", undone.Action)
+	for _, row := range rows {
+		assert.Equal(t, 100.0, row.X)
+		assert.Equal(t, 100.0, row.Y)
+	}
+}
@@ -0,0 +1,244 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// PermissionTTL bounds how long a resolved permission is served from cache.
+// Sharing changes invalidate the cache right away; the TTL only limits how
+// long a change made outside this service can go unnoticed.
+const PermissionTTL = 30 * time.Second
+
+// noPermission is cached for users without access to an existing board
+const noPermission = "none"
+
+// permissionRank orders permission levels so checks can ask for a minimum
+var permissionRank = map[models.PermissionLevel]int{
+	models.PermissionRead:  1,
+	models.PermissionWrite: 2,
+	models.PermissionAdmin: 3,
+}
+
+// permissionAllows reports whether a permission grants at least the required level
+func permissionAllows(permission, required models.PermissionLevel) bool {
+	rank, ok := permissionRank[permission]
+	return ok && rank >= permissionRank[required]
+}
+
+// PermissionCache stores resolved permissions per board and user
+type PermissionCache interface {
+	// Get returns the cached permission and whether one was cached. An empty
+	// permission means the user has no access.
+	Get(ctx context.Context, boardID, userID uuid.UUID) (models.PermissionLevel, bool, error)
+	Set(ctx context.Context, boardID, userID uuid.UUID, permission models.PermissionLevel) error
+	// Invalidate drops the given users' entries, or the whole board's when no
+	// users are given
+	Invalidate(ctx context.Context, boardID uuid.UUID, userIDs ...uuid.UUID) error
+}
+
+// RedisPermissionCache keeps each board's permissions in a Redis hash keyed
+// by user, so every service replica sees the same entries and a board can be
+// invalidated at once. The hash expires PermissionTTL after its first entry.
+type RedisPermissionCache struct {
+	client *redis.Client
+}
+
+// NewRedisPermissionCache creates a Redis backed permission cache
+func NewRedisPermissionCache(client *redis.Client) *RedisPermissionCache {
+	return &RedisPermissionCache{client: client}
+}
+
+func permissionKey(boardID uuid.UUID) string {
+	return fmt.Sprintf("permissions:%s", boardID)
+}
+
+// Get returns the cached permission of the user on the board
+func (r *RedisPermissionCache) Get(ctx context.Context, boardID, userID uuid.UUID) (models.PermissionLevel, bool, error) {
+	value, err := r.client.HGet(ctx, permissionKey(boardID), userID.String()).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	if value == noPermission {
+		return "", true, nil
+	}
+	return models.PermissionLevel(value), true, nil
+}
+
+// Set caches the permission of the user on the board
+func (r *RedisPermissionCache) Set(ctx context.Context, boardID, userID uuid.UUID, permission models.PermissionLevel) error {
+	value := string(permission)
+	if value == "" {
+		value = noPermission
+	}
+	key := permissionKey(boardID)
+	pipe := r.client.TxPipeline()
+	pipe.HSet(ctx, key, userID.String(), value)
+	pipe.ExpireNX(ctx, key, PermissionTTL)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Invalidate drops cached permissions of the board
+func (r *RedisPermissionCache) Invalidate(ctx context.Context, boardID uuid.UUID, userIDs ...uuid.UUID) error {
+	if len(userIDs) == 0 {
+		return r.client.Del(ctx, permissionKey(boardID)).Err()
+	}
+	fields := make([]string, len(userIDs))
+	for i, id := range userIDs {
+		fields[i] = id.String()
+	}
+	return r.client.HDel(ctx, permissionKey(boardID), fields...).Err()
+}
+
+// MemoryPermissionCache keeps permissions in process memory. It is used when
+// no Redis client is configured (tests, single instance development).
+type MemoryPermissionCache struct {
+	mu      sync.Mutex
+	entries map[uuid.UUID]map[uuid.UUID]cachedPermission
+	now     func() time.Time
+}
+
+type cachedPermission struct {
+	permission models.PermissionLevel
+	expires    time.Time
+}
+
+// NewMemoryPermissionCache creates an in-memory permission cache
+func NewMemoryPermissionCache() *MemoryPermissionCache {
+	return &MemoryPermissionCache{entries: make(map[uuid.UUID]map[uuid.UUID]cachedPermission), now: time.Now}
+}
+
+// Get returns the cached permission of the user on the board
+func (m *MemoryPermissionCache) Get(ctx context.Context, boardID, userID uuid.UUID) (models.PermissionLevel, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.entries[boardID][userID]
+	if !ok {
+		return "", false, nil
+	}
+	if !m.now().Before(entry.expires) {
+		delete(m.entries[boardID], userID)
+		return "", false, nil
+	}
+	return entry.permission, true, nil
+}
+
+// Set caches the permission of the user on the board
+func (m *MemoryPermissionCache) Set(ctx context.Context, boardID, userID uuid.UUID, permission models.PermissionLevel) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.entries[boardID] == nil {
+		m.entries[boardID] = make(map[uuid.UUID]cachedPermission)
+	}
+	m.entries[boardID][userID] = cachedPermission{permission: permission, expires: m.now().Add(PermissionTTL)}
+	return nil
+}
+
+// Invalidate drops cached permissions of the board
+func (m *MemoryPermissionCache) Invalidate(ctx context.Context, boardID uuid.UUID, userIDs ...uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(userIDs) == 0 {
+		delete(m.entries, boardID)
+		return nil
+	}
+	for _, id := range userIDs {
+		delete(m.entries[boardID], id)
+	}
+	return nil
+}
+
+// PermissionResolver answers whether a board exists for a user and with which
+// permission, without loading the board. Mutations that only need a
+// permission check go through it instead of GetByIDWithPermission.
+type PermissionResolver struct {
+	boardRepo     BoardRepositoryInterface
+	cache         PermissionCache
+	layerRepo     LayerRepositoryInterface
+	boardItemRepo BoardItemRepositoryInterface
+	boardUserRepo BoardUserRepositoryInterface
+}
+
+// NewPermissionResolver creates a permission resolver, cached in Redis when
+// a client is given and in process memory otherwise
+func NewPermissionResolver(boardRepo BoardRepositoryInterface, client *redis.Client) *PermissionResolver {
+	var cache PermissionCache = NewMemoryPermissionCache()
+	if client != nil {
+		cache = NewRedisPermissionCache(client)
+	}
+	return &PermissionResolver{boardRepo: boardRepo, cache: cache}
+}
+
+// WithLayers lets the resolver answer which layers of a board a user can
+// see. Without layers every item is treated as being on the base layer.
+func (r *PermissionResolver) WithLayers(layerRepo LayerRepositoryInterface, boardItemRepo BoardItemRepositoryInterface) *PermissionResolver {
+	r.layerRepo = layerRepo
+	r.boardItemRepo = boardItemRepo
+	return r
+}
+
+// WithClearances lets the resolver look up board users' clearances. Without
+// them every user other than an admin is treated as uncleared.
+func (r *PermissionResolver) WithClearances(boardUserRepo BoardUserRepositoryInterface) *PermissionResolver {
+	r.boardUserRepo = boardUserRepo
+	return r
+}
+
+// Resolve returns whether the board exists and the user's permission on it,
+// empty when the user has no access. Missing boards are not cached. Cache
+// failures fall back to the database.
+func (r *PermissionResolver) Resolve(ctx context.Context, boardID, userID uuid.UUID) (bool, models.PermissionLevel, error) {
+	permission, ok, err := r.cache.Get(ctx, boardID, userID)
+	if err != nil {
+		log.Printf("Failed to read cached permission for board %s: %v", boardID, err)
+	} else if ok {
+		return true, permission, nil
+	}
+
+	exists, permission, err := r.boardRepo.GetPermission(boardID, userID)
+	if err != nil || !exists {
+		return exists, permission, err
+	}
+	if err := r.cache.Set(ctx, boardID, userID, permission); err != nil {
+		log.Printf("Failed to cache permission for board %s: %v", boardID, err)
+	}
+	return true, permission, nil
+}
+
+// Authorize checks that the board exists and the user holds at least the
+// required permission. Boards the user cannot access are reported as not
+// found, like GetByIDWithPermission does.
+func (r *PermissionResolver) Authorize(ctx context.Context, boardID, userID uuid.UUID, required models.PermissionLevel) error {
+	exists, permission, err := r.Resolve(ctx, boardID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get board: %w", err)
+	}
+	if !exists || permission == "" {
+		return ErrBoardNotFound
+	}
+	if !permissionAllows(permission, required) {
+		return ErrUnauthorized
+	}
+	return nil
+}
+
+// Invalidate drops the cached permissions of the given users on the board,
+// or of everyone when no users are given
+func (r *PermissionResolver) Invalidate(ctx context.Context, boardID uuid.UUID, userIDs ...uuid.UUID) {
+	if err := r.cache.Invalidate(ctx, boardID, userIDs...); err != nil {
+		log.Printf("Failed to invalidate cached permissions for board %s: %v", boardID, err)
+	}
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPermissionAllows(t *testing.T) {
+	assert.True(t, permissionAllows(models.PermissionAdmin, models.PermissionWrite))
+	assert.True(t, permissionAllows(models.PermissionWrite, models.PermissionWrite))
+	assert.True(t, permissionAllows(models.PermissionRead, models.PermissionRead))
+	assert.False(t, permissionAllows(models.PermissionRead, models.PermissionWrite))
+	assert.False(t, permissionAllows(models.PermissionWrite, models.PermissionAdmin))
+	assert.False(t, permissionAllows("", models.PermissionRead))
+}
+
+func TestPermissionResolver_Cache(t *testing.T) {
+	ctx := context.Background()
+	boardID := uuid.New()
+	userID := uuid.New()
+	strangerID := uuid.New()
+	missingID := uuid.New()
+
+	mockBoardRepo := new(MockBoardRepository)
+	mockBoardRepo.On("GetPermission", boardID, userID).Return(true, models.PermissionWrite, nil).Once()
+	mockBoardRepo.On("GetPermission", boardID, strangerID).Return(true, models.PermissionLevel(""), nil).Once()
+	mockBoardRepo.On("GetPermission", missingID, userID).Return(false, models.PermissionLevel(""), nil).Twice()
+	resolver := NewPermissionResolver(mockBoardRepo, nil)
+
+	// Resolved permissions, including no access at all, are served from cache
+	for i := 0; i < 2; i++ {
+		exists, permission, err := resolver.Resolve(ctx, boardID, userID)
+		assert.NoError(t, err)
+		assert.True(t, exists)
+		assert.Equal(t, models.PermissionWrite, permission)
+
+		assert.Equal(t, ErrBoardNotFound, resolver.Authorize(ctx, boardID, strangerID, models.PermissionRead))
+	}
+	assert.Equal(t, ErrUnauthorized, resolver.Authorize(ctx, boardID, userID, models.PermissionAdmin))
+
+	// Missing boards are not
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, ErrBoardNotFound, resolver.Authorize(ctx, missingID, userID, models.PermissionRead))
+	}
+
+	// Invalidation and expiry go back to the database
+	mockBoardRepo.On("GetPermission", boardID, userID).Return(true, models.PermissionRead, nil).Once()
+	resolver.Invalidate(ctx, boardID, userID)
+	assert.Equal(t, ErrUnauthorized, resolver.Authorize(ctx, boardID, userID, models.PermissionWrite))
+
+	cache := resolver.cache.(*MemoryPermissionCache)
+	now := time.Now()
+	cache.now = func() time.Time { return now.Add(PermissionTTL) }
+	mockBoardRepo.On("GetPermission", boardID, strangerID).Return(true, models.PermissionRead, nil).Once()
+	assert.NoError(t, resolver.Authorize(ctx, boardID, strangerID, models.PermissionRead))
+
+	mockBoardRepo.AssertExpectations(t)
+}
+
+func TestBoardService_SharingInvalidatesPermissions(t *testing.T) {
+	boardID := uuid.New()
+	ownerID := uuid.New()
+	userID := uuid.New()
+	itemID := uuid.New()
+
+	mockBoardRepo := new(MockBoardRepository)
+	mockBoardUserRepo := new(MockBoardUserRepository)
+	mockBoardItemRepo := new(MockBoardItemRepository)
+	mockConnectionRepo := new(MockBoardConnectionRepository)
+	svc := NewBoardService(mockBoardRepo, mockBoardUserRepo, mockBoardItemRepo, mockConnectionRepo, nil, nil, nil, nil)
+
+	mockBoardRepo.On("GetPermission", boardID, ownerID).Return(true, models.PermissionAdmin, nil)
+	mockBoardItemRepo.On("GetByID", itemID).Return(&models.BoardItem{ID: itemID, BoardID: boardID}, nil)
+	mockBoardItemRepo.On("Delete", itemID).Return(nil)
+	mockConnectionRepo.On("ListByBoard", boardID).Return([]models.BoardConnection{}, nil)
+	mockConnectionRepo.On("DeleteByItem", itemID).Return(nil)
+
+	mockBoardRepo.On("GetPermission", boardID, userID).Return(true, models.PermissionRead, nil).Once()
+	assert.Equal(t, ErrUnauthorized, svc.DeleteBoardItem(boardID, itemID, userID, false))
+
+	// Sharing with write access takes effect on the next request
+	mockBoardUserRepo.On("GetByBoardAndUser", boardID, userID).Return(&models.BoardUser{BoardID: boardID, UserID: userID, Permission: models.PermissionRead}, nil).Once()
+	mockBoardUserRepo.On("Update", mock.AnythingOfType("*models.BoardUser")).Return(nil).Once()
+	assert.NoError(t, svc.ShareBoard(boardID, ownerID, ShareBoardRequest{UserID: userID, Permission: models.PermissionWrite}))
+	mockBoardRepo.On("GetPermission", boardID, userID).Return(true, models.PermissionWrite, nil).Once()
+	assert.NoError(t, svc.DeleteBoardItem(boardID, itemID, userID, false))
+
+	// And so does removing it
+	mockBoardUserRepo.On("Delete", boardID, userID).Return(nil).Once()
+	assert.NoError(t, svc.UnshareBoard(boardID, ownerID, userID))
+	mockBoardRepo.On("GetPermission", boardID, userID).Return(true, models.PermissionLevel(""), nil).Once()
+	assert.Equal(t, ErrBoardNotFound, svc.DeleteBoardItem(boardID, itemID, userID, false))
+
+	mockBoardRepo.AssertExpectations(t)
+	mockBoardUserRepo.AssertExpectations(t)
+}
+
+// BenchmarkPermissionResolver_Resolve measures a cached permission check; see
+// the repository benchmarks for the database side
+func BenchmarkPermissionResolver_Resolve(b *testing.B) {
+	ctx := context.Background()
+	boardID := uuid.New()
+	userID := uuid.New()
+	mockBoardRepo := new(MockBoardRepository)
+	mockBoardRepo.On("GetPermission", boardID, userID).Return(true, models.PermissionWrite, nil)
+	resolver := NewPermissionResolver(mockBoardRepo, nil)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := resolver.Authorize(ctx, boardID, userID, models.PermissionWrite); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,390 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"evidence-wall/boards-service/internal/imaging"
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrPreviewPending     = errors.New("preview not ready yet")
+	ErrPreviewUnavailable = errors.New("no preview for this attachment")
+)
+
+// Preview defaults used when the options leave them unset
+var DefaultThumbnailSizes = []int{128, 512, 1600}
+
+const (
+	DefaultThumbnailSize    = 512
+	DefaultThumbnailQuality = 85
+	// DefaultMaxPreviewPixels bounds the memory a single decode may take
+	DefaultMaxPreviewPixels = 40_000_000
+
+	maxThumbnailSize = 4096
+
+	// previewQueueSize is how many attachments may wait for a worker; the
+	// periodic sweep picks up any that did not fit
+	previewQueueSize     = 256
+	previewSweepInterval = time.Minute
+	previewSweepBatch    = 100
+)
+
+// previewableTypes are the attachment types previews are made from
+var previewableTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+// PreviewQueue receives attachments that need previews
+type PreviewQueue interface {
+	Enqueue(attachmentID uuid.UUID)
+}
+
+// PreviewOptions configures thumbnail generation
+type PreviewOptions struct {
+	Sizes     []int // Longest side of each thumbnail in pixels
+	Quality   int   // JPEG quality, 1-100
+	MaxPixels int   // Larger images are marked failed instead of decoded
+
+	// PreserveEXIF copies the original's EXIF block into thumbnails. By
+	// default thumbnails carry no metadata, so sharing a preview does not
+	// leak where a photo was taken.
+	PreserveEXIF bool
+}
+
+// PreviewWorker makes thumbnails of attached photos in the background and
+// copies their EXIF metadata to the attachment and its item. The original
+// file is never modified, so its custody digest stays valid.
+type PreviewWorker struct {
+	attachmentRepo AttachmentRepositoryInterface
+	boardItemRepo  BoardItemRepositoryInterface
+	store          BlobStore
+	options        PreviewOptions
+
+	queue   chan uuid.UUID
+	mu      sync.Mutex
+	waiting map[uuid.UUID]bool // Queued or being processed
+}
+
+// NewPreviewWorker creates a preview worker; zero options fall back to the
+// defaults
+func NewPreviewWorker(
+	attachmentRepo AttachmentRepositoryInterface,
+	boardItemRepo BoardItemRepositoryInterface,
+	store BlobStore,
+	options PreviewOptions,
+) *PreviewWorker {
+	if len(options.Sizes) == 0 {
+		options.Sizes = DefaultThumbnailSizes
+	}
+	// Largest first, so each thumbnail is scaled from the previous one
+	options.Sizes = append([]int(nil), options.Sizes...)
+	sort.Sort(sort.Reverse(sort.IntSlice(options.Sizes)))
+	if options.Quality <= 0 || options.Quality > 100 {
+		options.Quality = DefaultThumbnailQuality
+	}
+	if options.MaxPixels <= 0 {
+		options.MaxPixels = DefaultMaxPreviewPixels
+	}
+	return &PreviewWorker{
+		attachmentRepo: attachmentRepo,
+		boardItemRepo:  boardItemRepo,
+		store:          store,
+		options:        options,
+		queue:          make(chan uuid.UUID, previewQueueSize),
+		waiting:        make(map[uuid.UUID]bool),
+	}
+}
+
+// Start runs the given number of workers until ctx is done. Pending
+// attachments left over from a restart, or dropped from a full queue, are
+// picked up by a periodic sweep.
+func (w *PreviewWorker) Start(ctx context.Context, workers int) {
+	for i := 0; i < max(workers, 1); i++ {
+		go w.run(ctx)
+	}
+	go w.sweep(ctx)
+}
+
+// Enqueue schedules an attachment for processing without blocking
+func (w *PreviewWorker) Enqueue(attachmentID uuid.UUID) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.waiting[attachmentID] {
+		return
+	}
+	select {
+	case w.queue <- attachmentID:
+		w.waiting[attachmentID] = true
+	default:
+	}
+}
+
+func (w *PreviewWorker) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case id := <-w.queue:
+			if err := w.Process(ctx, id); err != nil {
+				log.Printf("Failed to make previews for attachment %s: %v", id, err)
+			}
+			w.mu.Lock()
+			delete(w.waiting, id)
+			w.mu.Unlock()
+		}
+	}
+}
+
+func (w *PreviewWorker) sweep(ctx context.Context) {
+	ticker := time.NewTicker(previewSweepInterval)
+	defer ticker.Stop()
+	for {
+		pending, err := w.attachmentRepo.ListPendingPreviews(previewSweepBatch)
+		if err != nil {
+			log.Printf("Failed to list pending previews: %v", err)
+		}
+		for _, a := range pending {
+			w.Enqueue(a.ID)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Process makes the previews of one attachment. Attachments that are gone or
+// no longer pending are skipped; on failure the attachment is marked failed.
+func (w *PreviewWorker) Process(ctx context.Context, attachmentID uuid.UUID) error {
+	attachment, err := w.attachmentRepo.GetByID(attachmentID)
+	if err != nil {
+		return fmt.Errorf("failed to get attachment: %w", err)
+	}
+	if attachment == nil || attachment.PreviewStatus != models.PreviewPending {
+		return nil
+	}
+
+	exif, err := w.generate(ctx, attachment)
+	if err != nil {
+		attachment.PreviewStatus = models.PreviewFailed
+		attachment.Thumbnails = nil
+		if updateErr := w.attachmentRepo.UpdatePreview(attachment); updateErr != nil {
+			log.Printf("Failed to mark preview of attachment %s as failed: %v", attachment.ID, updateErr)
+		}
+		return err
+	}
+
+	attachment.PreviewStatus = models.PreviewReady
+	if err := w.attachmentRepo.UpdatePreview(attachment); err != nil {
+		return fmt.Errorf("failed to update attachment: %w", err)
+	}
+	if exif != nil {
+		if err := w.fillItemMetadata(attachment, exif); err != nil {
+			return fmt.Errorf("failed to update item metadata: %w", err)
+		}
+	}
+	return nil
+}
+
+// generate stores the thumbnails of an attachment, setting its Thumbnails and
+// Metadata. It returns the EXIF data found, if any.
+func (w *PreviewWorker) generate(ctx context.Context, attachment *models.Attachment) (*imaging.EXIF, error) {
+	rc, err := w.store.Get(ctx, attachment.StorageKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open attachment: %w", err)
+	}
+	data, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read attachment: %w", err)
+	}
+
+	// Only JPEG files carry EXIF; a damaged block does not stop the previews
+	var exif *imaging.EXIF
+	if attachment.ContentType == "image/jpeg" {
+		if exif, err = imaging.ReadEXIF(data); err != nil {
+			if !errors.Is(err, imaging.ErrNoEXIF) {
+				log.Printf("Ignoring EXIF data of attachment %s: %v", attachment.ID, err)
+			}
+			exif = nil
+		}
+	}
+
+	img, err := imaging.Decode(data, w.options.MaxPixels)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	orientation := 0
+	var embedded *imaging.EXIF
+	if exif != nil {
+		orientation = exif.Orientation
+		if w.options.PreserveEXIF {
+			embedded = exif
+		}
+	}
+
+	current := imaging.Flatten(img)
+	width, height := current.Bounds().Dx(), current.Bounds().Dy()
+	metadata := &models.ImageMetadata{Width: width, Height: height, Orientation: orientation}
+	if orientation >= 5 {
+		// Report the size the image is displayed at
+		metadata.Width, metadata.Height = height, width
+	}
+	if exif != nil {
+		metadata.CapturedAt = exif.CapturedAt
+		metadata.Latitude, metadata.Longitude, metadata.Altitude = exif.Latitude, exif.Longitude, exif.Altitude
+		metadata.Make, metadata.Model = exif.Make, exif.Model
+	}
+
+	var sizes []int
+	lastWidth, lastHeight := 0, 0
+	for _, size := range w.options.Sizes {
+		tw, th := imaging.Fit(width, height, size)
+		if tw == lastWidth && th == lastHeight {
+			// The image is smaller than this size too; the previous
+			// thumbnail already is the full image
+			continue
+		}
+		current = imaging.Resize(current, tw, th)
+		lastWidth, lastHeight = tw, th
+
+		var buf bytes.Buffer
+		if err := imaging.EncodeJPEG(&buf, imaging.Orient(current, orientation), w.options.Quality, embedded); err != nil {
+			return nil, fmt.Errorf("failed to encode thumbnail: %w", err)
+		}
+		key := thumbnailKey(attachment.StorageKey, size)
+		if err := w.store.Put(ctx, key, &buf, int64(buf.Len()), "image/jpeg"); err != nil {
+			return nil, fmt.Errorf("failed to store thumbnail: %w", err)
+		}
+		sizes = append(sizes, size)
+	}
+
+	sort.Ints(sizes)
+	attachment.Thumbnails = sizes
+	attachment.Metadata = metadata
+	return exif, nil
+}
+
+// fillItemMetadata records where and when a photo was taken on its item. The
+// first photo with such data wins; later photos do not overwrite it.
+func (w *PreviewWorker) fillItemMetadata(attachment *models.Attachment, exif *imaging.EXIF) error {
+	if exif.CapturedAt == "" && exif.Latitude == nil {
+		return nil
+	}
+	item, err := w.boardItemRepo.GetByID(attachment.ItemID)
+	if err != nil {
+		return err
+	}
+	if item == nil || item.EvidenceMetadata != nil {
+		return nil
+	}
+
+	source := attachment.ID
+	metadata := &models.EvidenceMetadata{
+		CapturedAt:         exif.CapturedAt,
+		Latitude:           exif.Latitude,
+		Longitude:          exif.Longitude,
+		Camera:             cameraName(exif.Make, exif.Model),
+		SourceAttachmentID: &source,
+	}
+	return w.boardItemRepo.UpdateEvidenceMetadata(item.ID, metadata)
+}
+
+// cameraName joins make and model, which often already repeats the make
+func cameraName(maker, model string) string {
+	switch {
+	case model == "":
+		return maker
+	case maker == "" || strings.HasPrefix(strings.ToLower(model), strings.ToLower(maker)):
+		return model
+	default:
+		return maker + " " + model
+	}
+}
+
+// thumbnailKey is where the thumbnail of the given size is stored
+func thumbnailKey(storageKey string, size int) string {
+	return fmt.Sprintf("%s.thumb-%d", storageKey, size)
+}
+
+// Thumbnail is a preview image of an attachment; Content is nil when the
+// client's cached copy is still current
+type Thumbnail struct {
+	Size        int
+	ETag        string
+	NotModified bool
+	Content     io.ReadCloser
+}
+
+// OpenThumbnail returns the smallest thumbnail at least size pixels on its
+// longest side, or the largest there is. Thumbnails never change once made,
+// so a matching ifNoneMatch is answered without opening the blob. Previews
+// are derivatives and are not recorded in the custody log.
+func (s *AttachmentService) OpenThumbnail(ctx context.Context, boardID, itemID, attachmentID, userID uuid.UUID, size int, ifNoneMatch string) (*Thumbnail, error) {
+	if size == 0 {
+		size = DefaultThumbnailSize
+	}
+	if size < 0 || size > maxThumbnailSize {
+		return nil, fmt.Errorf("%w: size must be between 1 and %d", ErrInvalidInput, maxThumbnailSize)
+	}
+	attachment, err := s.getAttachment(boardID, itemID, attachmentID, userID, false)
+	if err != nil {
+		return nil, err
+	}
+	switch attachment.PreviewStatus {
+	case models.PreviewReady:
+	case models.PreviewPending:
+		return nil, ErrPreviewPending
+	default:
+		return nil, ErrPreviewUnavailable
+	}
+	if len(attachment.Thumbnails) == 0 {
+		return nil, ErrPreviewUnavailable
+	}
+
+	chosen := attachment.Thumbnails[len(attachment.Thumbnails)-1]
+	for _, available := range attachment.Thumbnails {
+		if available >= size {
+			chosen = available
+			break
+		}
+	}
+	thumbnail := &Thumbnail{Size: chosen, ETag: fmt.Sprintf(`"%s-%d"`, attachment.ID, chosen)}
+	if etagMatches(ifNoneMatch, thumbnail.ETag) {
+		thumbnail.NotModified = true
+		return thumbnail, nil
+	}
+
+	thumbnail.Content, err = s.store.Get(ctx, thumbnailKey(attachment.StorageKey, chosen))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open thumbnail: %w", err)
+	}
+	return thumbnail, nil
+}
+
+// etagMatches reports whether an If-None-Match header lists etag
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
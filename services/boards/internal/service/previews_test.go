@@ -0,0 +1,283 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/jpeg"
+	"io"
+	"strings"
+	"testing"
+
+	"evidence-wall/boards-service/internal/imaging"
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type exifEntry struct {
+	tag, typ uint16
+	count    uint32
+	data     []byte
+}
+
+func exifASCII(tag uint16, s string) exifEntry {
+	return exifEntry{tag, 2, uint32(len(s) + 1), append([]byte(s), 0)}
+}
+
+func exifLong(tag uint16, v uint32) exifEntry {
+	return exifEntry{tag, 4, 1, binary.BigEndian.AppendUint32(nil, v)}
+}
+
+func exifRationals(tag uint16, values ...uint32) exifEntry {
+	var data []byte
+	for _, v := range values {
+		data = binary.BigEndian.AppendUint32(data, v)
+		data = binary.BigEndian.AppendUint32(data, 1)
+	}
+	return exifEntry{tag, 5, uint32(len(values)), data}
+}
+
+// exifIFD lays out a big-endian directory at offset start
+func exifIFD(start int, entries []exifEntry) []byte {
+	table := binary.BigEndian.AppendUint16(nil, uint16(len(entries)))
+	var extra []byte
+	for _, e := range entries {
+		table = binary.BigEndian.AppendUint16(table, e.tag)
+		table = binary.BigEndian.AppendUint16(table, e.typ)
+		table = binary.BigEndian.AppendUint32(table, e.count)
+		if len(e.data) <= 4 {
+			table = append(table, append(e.data, make([]byte, 4-len(e.data))...)...)
+		} else {
+			table = binary.BigEndian.AppendUint32(table, uint32(start+2+12*len(entries)+4+len(extra)))
+			extra = append(extra, e.data...)
+		}
+	}
+	table = append(table, 0, 0, 0, 0)
+	return append(table, extra...)
+}
+
+// photo returns a width x height JPEG taken by a Canon at the Eiffel Tower,
+// stored sideways with orientation 6
+func photo(t *testing.T, width, height int) []byte {
+	exifEntries := []exifEntry{exifASCII(0x9003, "2024:03:15 22:41:07"), exifASCII(0x9011, "+01:00")}
+	gpsEntries := []exifEntry{
+		exifASCII(0x0001, "N"), exifRationals(0x0002, 48, 51, 30),
+		exifASCII(0x0003, "E"), exifRationals(0x0004, 2, 17, 40),
+	}
+	ifd0 := func(exifAt, gpsAt int) []exifEntry {
+		return []exifEntry{
+			exifASCII(0x010F, "Canon"), exifASCII(0x0110, "Canon EOS 5D"),
+			{0x0112, 3, 1, []byte{0, 6}},
+			exifLong(0x8769, uint32(exifAt)), exifLong(0x8825, uint32(gpsAt)),
+		}
+	}
+	exifAt := 8 + len(exifIFD(8, ifd0(0, 0)))
+	exifBytes := exifIFD(exifAt, exifEntries)
+	gpsAt := exifAt + len(exifBytes)
+
+	payload := []byte("Exif\x00\x00MM\x00\x2a\x00\x00\x00\x08")
+	payload = append(payload, exifIFD(8, ifd0(exifAt, gpsAt))...)
+	payload = append(payload, exifBytes...)
+	payload = append(payload, exifIFD(gpsAt, gpsEntries)...)
+
+	var buf bytes.Buffer
+	assert.NoError(t, jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height)), nil))
+	data := append([]byte{0xFF, 0xD8, 0xFF, 0xE1}, binary.BigEndian.AppendUint16(nil, uint16(len(payload)+2))...)
+	data = append(data, payload...)
+	return append(data, buf.Bytes()[2:]...)
+}
+
+type previewFixture struct {
+	*attachmentFixture
+	worker     *PreviewWorker
+	attachment *models.Attachment
+	updates    []models.Attachment
+}
+
+func newPreviewFixture(t *testing.T, content []byte, contentType string, options PreviewOptions) *previewFixture {
+	f := &previewFixture{attachmentFixture: newAttachmentFixture(t, models.PermissionRead, AttachmentLimits{})}
+	f.worker = NewPreviewWorker(f.attachmentRepo, f.itemRepo, f.store, options)
+	f.attachment = &models.Attachment{
+		ID: uuid.New(), BoardID: f.board.ID, ItemID: f.item.ID, ContentType: contentType,
+		Size: int64(len(content)), StorageKey: "photo", PreviewStatus: models.PreviewPending,
+	}
+	assert.NoError(t, f.store.Put(context.Background(), f.attachment.StorageKey, bytes.NewReader(content), int64(len(content)), contentType))
+	f.attachmentRepo.On("GetByID", f.attachment.ID).Return(f.attachment, nil)
+	f.attachmentRepo.On("UpdatePreview", mock.Anything).Run(func(args mock.Arguments) {
+		f.updates = append(f.updates, *args.Get(0).(*models.Attachment))
+	}).Return(nil)
+	return f
+}
+
+func (f *previewFixture) thumbnail(t *testing.T, size int) []byte {
+	rc, err := f.store.Get(context.Background(), thumbnailKey(f.attachment.StorageKey, size))
+	if !assert.NoError(t, err) {
+		return nil
+	}
+	defer rc.Close()
+	data, _ := io.ReadAll(rc)
+	return data
+}
+
+func TestPreviewWorker_Process(t *testing.T) {
+	f := newPreviewFixture(t, photo(t, 40, 20), "image/jpeg", PreviewOptions{Sizes: []int{8, 64, 16}})
+	var recorded *models.EvidenceMetadata
+	f.itemRepo.On("UpdateEvidenceMetadata", f.item.ID, mock.Anything).Run(func(args mock.Arguments) {
+		recorded = args.Get(1).(*models.EvidenceMetadata)
+	}).Return(nil)
+
+	assert.NoError(t, f.worker.Process(context.Background(), f.attachment.ID))
+
+	if assert.Len(t, f.updates, 1) {
+		updated := f.updates[0]
+		assert.Equal(t, models.PreviewReady, updated.PreviewStatus)
+		assert.Equal(t, []int{8, 16, 64}, updated.Thumbnails)
+		if assert.NotNil(t, updated.Metadata) {
+			assert.Equal(t, 20, updated.Metadata.Width)
+			assert.Equal(t, 40, updated.Metadata.Height)
+			assert.Equal(t, 6, updated.Metadata.Orientation)
+			assert.Equal(t, "2024-03-15T22:41:07+01:00", updated.Metadata.CapturedAt)
+			assert.Equal(t, "Canon", updated.Metadata.Make)
+		}
+	}
+
+	// Thumbnails are upright and carry no metadata
+	for size, bounds := range map[int]image.Rectangle{64: image.Rect(0, 0, 20, 40), 16: image.Rect(0, 0, 8, 16), 8: image.Rect(0, 0, 4, 8)} {
+		data := f.thumbnail(t, size)
+		cfg, err := jpeg.DecodeConfig(bytes.NewReader(data))
+		assert.NoError(t, err)
+		assert.Equal(t, bounds.Dx(), cfg.Width, size)
+		assert.Equal(t, bounds.Dy(), cfg.Height, size)
+		_, err = imaging.ReadEXIF(data)
+		assert.True(t, errors.Is(err, imaging.ErrNoEXIF))
+	}
+
+	if assert.NotNil(t, recorded) {
+		assert.Equal(t, "2024-03-15T22:41:07+01:00", recorded.CapturedAt)
+		assert.InDelta(t, 48.8583333, *recorded.Latitude, 1e-6)
+		assert.InDelta(t, 2.2944444, *recorded.Longitude, 1e-6)
+		assert.Equal(t, "Canon EOS 5D", recorded.Camera)
+		assert.Equal(t, f.attachment.ID, *recorded.SourceAttachmentID)
+	}
+
+	// Done attachments are not processed again
+	assert.NoError(t, f.worker.Process(context.Background(), f.attachment.ID))
+	assert.Len(t, f.updates, 1)
+}
+
+func TestPreviewWorker_PreserveEXIF(t *testing.T) {
+	f := newPreviewFixture(t, photo(t, 40, 20), "image/jpeg", PreviewOptions{Sizes: []int{16}, PreserveEXIF: true})
+	// The item already has metadata from an earlier photo
+	f.item.EvidenceMetadata = &models.EvidenceMetadata{CapturedAt: "2020-01-01T00:00:00Z"}
+
+	assert.NoError(t, f.worker.Process(context.Background(), f.attachment.ID))
+	f.itemRepo.AssertNotCalled(t, "UpdateEvidenceMetadata", mock.Anything, mock.Anything)
+
+	exif, err := imaging.ReadEXIF(f.thumbnail(t, 16))
+	if assert.NoError(t, err) {
+		assert.Equal(t, 1, exif.Orientation)
+		assert.Equal(t, "2024-03-15T22:41:07+01:00", exif.CapturedAt)
+		assert.NotNil(t, exif.Latitude)
+	}
+}
+
+func TestPreviewWorker_Failures(t *testing.T) {
+	f := newPreviewFixture(t, append([]byte{0xFF, 0xD8}, bytes.Repeat([]byte{0}, 100)...), "image/jpeg", PreviewOptions{})
+	assert.Error(t, f.worker.Process(context.Background(), f.attachment.ID))
+	if assert.Len(t, f.updates, 1) {
+		assert.Equal(t, models.PreviewFailed, f.updates[0].PreviewStatus)
+	}
+
+	// Images over the pixel limit are not decoded
+	big := newPreviewFixture(t, photo(t, 100, 100), "image/jpeg", PreviewOptions{MaxPixels: 9999})
+	err := big.worker.Process(context.Background(), big.attachment.ID)
+	assert.True(t, errors.Is(err, imaging.ErrTooLarge))
+	assert.Equal(t, models.PreviewFailed, big.updates[0].PreviewStatus)
+}
+
+func TestPreviewWorker_EnqueueSkipsWaiting(t *testing.T) {
+	f := newPreviewFixture(t, photo(t, 4, 4), "image/jpeg", PreviewOptions{})
+	f.worker.Enqueue(f.attachment.ID)
+	f.worker.Enqueue(f.attachment.ID)
+	assert.Len(t, f.worker.queue, 1)
+}
+
+type recordingQueue struct {
+	ids []uuid.UUID
+}
+
+func (q *recordingQueue) Enqueue(id uuid.UUID) {
+	q.ids = append(q.ids, id)
+}
+
+func TestAttachmentService_UploadQueuesPreviews(t *testing.T) {
+	ctx := context.Background()
+	f := newAttachmentFixture(t, models.PermissionWrite, AttachmentLimits{})
+	queue := &recordingQueue{}
+	f.attachments.previews = queue
+	f.attachmentRepo.On("TotalSizeByBoard", f.board.ID).Return(int64(0), nil)
+	f.attachmentRepo.On("Create", mock.Anything).Return(nil)
+
+	content := photo(t, 8, 8)
+	uploaded, err := f.attachments.UploadAttachment(ctx, f.board.ID, f.item.ID, uuid.New(), UploadAttachmentRequest{
+		Filename: "scene.jpg", Size: int64(len(content)), Content: bytes.NewReader(content),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, models.PreviewPending, uploaded.PreviewStatus)
+	assert.Equal(t, []uuid.UUID{uploaded.ID}, queue.ids)
+
+	text := f.upload(t, uuid.New(), "statement")
+	assert.Equal(t, models.PreviewStatus(""), text.PreviewStatus)
+	assert.Len(t, queue.ids, 1)
+}
+
+func TestAttachmentService_OpenThumbnail(t *testing.T) {
+	ctx := context.Background()
+	f := newPreviewFixture(t, photo(t, 40, 20), "image/jpeg", PreviewOptions{Sizes: []int{8, 16}})
+	userID := uuid.New()
+
+	_, err := f.attachments.OpenThumbnail(ctx, f.board.ID, f.item.ID, f.attachment.ID, userID, 0, "")
+	assert.Equal(t, ErrPreviewPending, err)
+
+	f.itemRepo.On("UpdateEvidenceMetadata", mock.Anything, mock.Anything).Return(nil)
+	assert.NoError(t, f.worker.Process(ctx, f.attachment.ID))
+
+	// The smallest thumbnail at least as large as asked for, else the largest
+	for requested, want := range map[int]int{1: 8, 8: 8, 9: 16, 0: 16, 2000: 16} {
+		thumbnail, err := f.attachments.OpenThumbnail(ctx, f.board.ID, f.item.ID, f.attachment.ID, userID, requested, "")
+		if assert.NoError(t, err, requested) {
+			assert.Equal(t, want, thumbnail.Size, requested)
+			data, _ := io.ReadAll(thumbnail.Content)
+			thumbnail.Content.Close()
+			assert.Equal(t, f.thumbnail(t, want), data)
+		}
+	}
+
+	etag := `"` + f.attachment.ID.String() + `-8"`
+	thumbnail, err := f.attachments.OpenThumbnail(ctx, f.board.ID, f.item.ID, f.attachment.ID, userID, 8, `W/"other", `+etag)
+	assert.NoError(t, err)
+	assert.True(t, thumbnail.NotModified)
+	assert.Nil(t, thumbnail.Content)
+
+	_, err = f.attachments.OpenThumbnail(ctx, f.board.ID, f.item.ID, f.attachment.ID, userID, -1, "")
+	assert.True(t, errors.Is(err, ErrInvalidInput))
+
+	f.attachment.PreviewStatus = models.PreviewUnsupported
+	_, err = f.attachments.OpenThumbnail(ctx, f.board.ID, f.item.ID, f.attachment.ID, userID, 0, "")
+	assert.Equal(t, ErrPreviewUnavailable, err)
+
+	// Deleting the attachment removes its thumbnails too
+	writer := newAttachmentFixture(t, models.PermissionWrite, AttachmentLimits{})
+	attachment := &models.Attachment{ID: uuid.New(), BoardID: writer.board.ID, ItemID: writer.item.ID, StorageKey: "deleted", Thumbnails: []int{8}}
+	assert.NoError(t, writer.store.Put(ctx, thumbnailKey("deleted", 8), strings.NewReader("x"), 1, "image/jpeg"))
+	writer.attachmentRepo.On("GetByID", attachment.ID).Return(attachment, nil)
+	writer.attachmentRepo.On("Delete", attachment.ID).Return(nil)
+	assert.NoError(t, writer.attachments.DeleteAttachment(ctx, writer.board.ID, writer.item.ID, attachment.ID, userID, ""))
+	_, err = writer.store.Get(ctx, thumbnailKey("deleted", 8))
+	assert.Error(t, err)
+}
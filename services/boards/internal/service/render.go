@@ -0,0 +1,54 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"evidence-wall/boards-service/internal/render"
+
+	"github.com/google/uuid"
+)
+
+// RenderBoardRequest selects the image format and the part of the board to draw.
+// Without width and height the image fits all items.
+type RenderBoardRequest struct {
+	Format string  `form:"format" binding:"omitempty,oneof=svg png"`
+	X      float64 `form:"x"`
+	Y      float64 `form:"y"`
+	Width  float64 `form:"width" binding:"omitempty,gt=0"`
+	Height float64 `form:"height" binding:"omitempty,gt=0"`
+	Scale  float64 `form:"scale" binding:"omitempty,gt=0,lte=8"` // Pixels per board unit, default 1
+}
+
+// RenderBoard draws a board as SVG (the default) or PNG. Read access is sufficient.
+func (s *BoardService) RenderBoard(boardID, userID uuid.UUID, req RenderBoardRequest) ([]byte, error) {
+	if req.Format != "" && req.Format != "svg" && req.Format != "png" {
+		return nil, ErrUnsupportedFormat
+	}
+
+	board, _, access, err := s.getBoardContents(boardID, userID)
+	if err != nil {
+		return nil, err
+	}
+	hideToggledOffLayers(board, access)
+
+	opts := render.Options{Scale: req.Scale}
+	if req.Width > 0 && req.Height > 0 {
+		opts.Viewport = &render.Rect{X: req.X, Y: req.Y, Width: req.Width, Height: req.Height}
+	}
+
+	var buf bytes.Buffer
+	if req.Format == "png" {
+		err = render.PNG(&buf, board, opts)
+	} else {
+		err = render.SVG(&buf, board, opts)
+	}
+	if errors.Is(err, render.ErrTooLarge) {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to render board: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBoardService_RenderBoard(t *testing.T) {
+	userID := uuid.New()
+	board, _, _ := graphTestBoard()
+	svc := newGraphTestService(board, userID)
+
+	svg, err := svc.RenderBoard(board.ID, userID, RenderBoardRequest{})
+	assert.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(svg, []byte("<svg ")))
+
+	png, err := svc.RenderBoard(board.ID, userID, RenderBoardRequest{Format: "png", Width: 200, Height: 100, Scale: 0.5})
+	assert.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(png, []byte("\x89PNG")))
+
+	_, err = svc.RenderBoard(board.ID, userID, RenderBoardRequest{Format: "png", Width: 5000, Height: 5000, Scale: 4})
+	assert.True(t, errors.Is(err, ErrInvalidInput))
+
+	_, err = svc.RenderBoard(board.ID, userID, RenderBoardRequest{Format: "gif"})
+	assert.Equal(t, ErrUnsupportedFormat, err)
+}
+
+func TestBoardService_RenderBoardNoAccess(t *testing.T) {
+	boardID := uuid.New()
+	userID := uuid.New()
+	mockBoardRepo := new(MockBoardRepository)
+	mockBoardRepo.On("GetByIDWithContents", boardID, userID).Return(nil, models.PermissionLevel(""), nil)
+	svc := NewBoardService(mockBoardRepo, new(MockBoardUserRepository), new(MockBoardItemRepository), new(MockBoardConnectionRepository), nil, nil, nil, nil)
+
+	_, err := svc.RenderBoard(boardID, userID, RenderBoardRequest{})
+	assert.Equal(t, ErrBoardNotFound, err)
+}
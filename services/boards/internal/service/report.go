@@ -0,0 +1,26 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"evidence-wall/boards-service/internal/report"
+
+	"github.com/google/uuid"
+)
+
+// BoardReport generates the PDF case report for a board. Read access is sufficient.
+func (s *BoardService) BoardReport(boardID, userID uuid.UUID) ([]byte, error) {
+	board, _, access, err := s.getBoardContents(boardID, userID)
+	if err != nil {
+		return nil, err
+	}
+	hideToggledOffLayers(board, access)
+
+	var buf bytes.Buffer
+	if err := report.Write(&buf, board, time.Now()); err != nil {
+		return nil, fmt.Errorf("failed to generate report: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"bytes"
+	"testing"
+
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBoardService_BoardReport(t *testing.T) {
+	userID := uuid.New()
+	board, _, _ := graphTestBoard()
+	svc := newGraphTestService(board, userID)
+
+	pdf, err := svc.BoardReport(board.ID, userID)
+	assert.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(pdf, []byte("%PDF-")))
+	assert.True(t, bytes.HasSuffix(pdf, []byte("%%EOF\n")))
+}
+
+func TestBoardService_BoardReportNoAccess(t *testing.T) {
+	boardID := uuid.New()
+	userID := uuid.New()
+	mockBoardRepo := new(MockBoardRepository)
+	mockBoardRepo.On("GetByIDWithContents", boardID, userID).Return(nil, models.PermissionLevel(""), nil)
+	svc := NewBoardService(mockBoardRepo, new(MockBoardUserRepository), new(MockBoardItemRepository), new(MockBoardConnectionRepository), nil, nil, nil, nil)
+
+	_, err := svc.BoardReport(boardID, userID)
+	assert.Equal(t, ErrBoardNotFound, err)
+}
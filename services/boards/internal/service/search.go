@@ -0,0 +1,164 @@
+package service
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"unicode/utf8"
+
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+)
+
+// Search limits
+const (
+	MaxSearchQueryLength = 200
+	MaxSearchFilters     = 50 // Boards or item types in one filter
+	DefaultSearchLimit   = 20
+	MaxSearchLimit       = 100
+	maxBoardSearchHits   = 10
+)
+
+// SearchQuery is a full-text search over the caller's boards, as given in
+// the query string. Board IDs and item types may be repeated or
+// comma-separated.
+type SearchQuery struct {
+	Q       string   `form:"q"`
+	BoardID []string `form:"board_id"`
+	Type    []string `form:"type"`
+	Page    int      `form:"page"`
+	Limit   int      `form:"limit"`
+}
+
+// filter validates the query and converts it to a repository filter
+func (q SearchQuery) filter() (models.SearchFilter, error) {
+	f := models.SearchFilter{Query: strings.TrimSpace(q.Q), ItemTypes: splitValues(q.Type)}
+	if f.Query == "" {
+		return f, fmt.Errorf("%w: a search query is required", ErrInvalidInput)
+	}
+	if utf8.RuneCountInString(f.Query) > MaxSearchQueryLength {
+		return f, ErrInputTooLong
+	}
+	// The markers would otherwise turn into highlights
+	f.Query = strings.NewReplacer(models.HighlightStart, "", models.HighlightStop, "").Replace(f.Query)
+
+	for _, value := range splitValues(q.BoardID) {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return f, fmt.Errorf("%w: invalid board ID %q", ErrInvalidInput, value)
+		}
+		f.BoardIDs = append(f.BoardIDs, id)
+	}
+	if len(f.BoardIDs) > MaxSearchFilters || len(f.ItemTypes) > MaxSearchFilters {
+		return f, fmt.Errorf("%w: at most %d boards or item types can be filtered on", ErrInvalidInput, MaxSearchFilters)
+	}
+
+	f.Limit = q.Limit
+	if f.Limit <= 0 || f.Limit > MaxSearchLimit {
+		f.Limit = DefaultSearchLimit
+	}
+	f.Offset = (max(q.Page, 1) - 1) * f.Limit
+	return f, nil
+}
+
+// SearchResults holds the matching boards and a page of matching items.
+// Snippets are HTML-escaped text with the matched terms in <mark> elements.
+type SearchResults struct {
+	Query  string                  `json:"query"`
+	Boards []models.BoardSearchHit `json:"boards"`
+	Items  []models.ItemSearchHit  `json:"items"`
+	Total  int64                   `json:"total"` // Matching items across all pages
+	Page   int                     `json:"page"`
+	Limit  int                     `json:"limit"`
+	Facets SearchFacets            `json:"facets"`
+}
+
+// SearchFacets counts the matching items by board and by item type
+type SearchFacets struct {
+	Boards []models.SearchFacet `json:"boards"`
+	Types  []models.SearchFacet `json:"types"`
+}
+
+// SearchService searches the boards and items a user can access
+type SearchService struct {
+	searchRepo SearchRepositoryInterface
+}
+
+// NewSearchService creates a new search service
+func NewSearchService(searchRepo SearchRepositoryInterface) *SearchService {
+	return &SearchService{searchRepo: searchRepo}
+}
+
+// Search finds boards and items matching the query on the boards the user
+// owns or was shared. Boards are only returned with the first page of items,
+// and not when the search is narrowed to item types.
+func (s *SearchService) Search(userID uuid.UUID, query SearchQuery) (*SearchResults, error) {
+	filter, err := query.filter()
+	if err != nil {
+		return nil, err
+	}
+	results := &SearchResults{
+		Query:  filter.Query,
+		Page:   filter.Offset/filter.Limit + 1,
+		Limit:  filter.Limit,
+		Boards: []models.BoardSearchHit{},
+		Facets: SearchFacets{Boards: []models.SearchFacet{}, Types: []models.SearchFacet{}},
+	}
+
+	if filter.Offset == 0 && len(filter.ItemTypes) == 0 {
+		boards, err := s.searchRepo.SearchBoards(userID, models.SearchFilter{Query: filter.Query, BoardIDs: filter.BoardIDs, Limit: maxBoardSearchHits})
+		if err != nil {
+			return nil, fmt.Errorf("failed to search boards: %w", err)
+		}
+		for i := range boards {
+			boards[i].Snippet = highlightSnippet(boards[i].Snippet)
+		}
+		results.Boards = append(results.Boards, boards...)
+	}
+
+	items, total, err := s.searchRepo.SearchItems(userID, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search items: %w", err)
+	}
+	for i := range items {
+		items[i].Snippet = highlightSnippet(items[i].Snippet)
+	}
+	results.Items = append([]models.ItemSearchHit{}, items...)
+	results.Total = total
+
+	boardFacets, typeFacets, err := s.searchRepo.ItemFacets(userID, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count search facets: %w", err)
+	}
+	results.Facets.Boards = append(results.Facets.Boards, boardFacets...)
+	results.Facets.Types = append(results.Facets.Types, typeFacets...)
+	return results, nil
+}
+
+// highlightSnippet escapes a snippet for HTML and turns the highlight
+// markers into <mark> elements. A marker left open by a cut is closed.
+func highlightSnippet(snippet string) string {
+	var b strings.Builder
+	open := false
+	for _, r := range html.EscapeString(snippet) {
+		switch string(r) {
+		case models.HighlightStart:
+			if !open {
+				b.WriteString("<mark>")
+				open = true
+			}
+		case models.HighlightStop:
+			if open {
+				b.WriteString("</mark>")
+				open = false
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+	if open {
+		b.WriteString("</mark>")
+	}
+	return b.String()
+}
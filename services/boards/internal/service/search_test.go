@@ -0,0 +1,115 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockSearchRepository is a mock implementation of SearchRepository
+type MockSearchRepository struct {
+	mock.Mock
+}
+
+func (m *MockSearchRepository) SearchBoards(userID uuid.UUID, filter models.SearchFilter) ([]models.BoardSearchHit, error) {
+	args := m.Called(userID, filter)
+	return args.Get(0).([]models.BoardSearchHit), args.Error(1)
+}
+
+func (m *MockSearchRepository) SearchItems(userID uuid.UUID, filter models.SearchFilter) ([]models.ItemSearchHit, int64, error) {
+	args := m.Called(userID, filter)
+	return args.Get(0).([]models.ItemSearchHit), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockSearchRepository) ItemFacets(userID uuid.UUID, filter models.SearchFilter) ([]models.SearchFacet, []models.SearchFacet, error) {
+	args := m.Called(userID, filter)
+	return args.Get(0).([]models.SearchFacet), args.Get(1).([]models.SearchFacet), args.Error(2)
+}
+
+func TestSearchService_Search(t *testing.T) {
+	userID := uuid.New()
+	boardID := uuid.New()
+	itemID := uuid.New()
+	repo := new(MockSearchRepository)
+	svc := NewSearchService(repo)
+
+	mark := func(s string) string { return models.HighlightStart + s + models.HighlightStop }
+	repo.On("SearchBoards", userID, models.SearchFilter{Query: "blue van", Limit: 10}).
+		Return([]models.BoardSearchHit{{BoardID: boardID, Title: "Harbour thefts", Snippet: "Harbour thefts — the " + mark("blue") + " " + mark("van")}}, nil)
+	filter := models.SearchFilter{Query: "blue van", Limit: DefaultSearchLimit}
+	repo.On("SearchItems", userID, filter).
+		Return([]models.ItemSearchHit{{ItemID: itemID, BoardID: boardID, Type: "vehicle", Snippet: "<b>" + mark("Blue") + " " + mark("van") + " & driver"}}, int64(1), nil)
+	repo.On("ItemFacets", userID, filter).
+		Return([]models.SearchFacet{{Value: boardID.String(), Label: "Harbour thefts", Count: 1}}, []models.SearchFacet{{Value: "vehicle", Count: 1}}, nil)
+
+	results, err := svc.Search(userID, SearchQuery{Q: "  blue van "})
+	assert.NoError(t, err)
+	assert.Equal(t, "blue van", results.Query)
+	assert.Equal(t, 1, results.Page)
+	if assert.Len(t, results.Boards, 1) {
+		assert.Equal(t, "Harbour thefts — the <mark>blue</mark> <mark>van</mark>", results.Boards[0].Snippet)
+	}
+	if assert.Len(t, results.Items, 1) {
+		// User text is escaped, only the highlights are markup
+		assert.Equal(t, "&lt;b&gt;<mark>Blue</mark> <mark>van</mark> &amp; driver", results.Items[0].Snippet)
+	}
+	assert.Equal(t, int64(1), results.Total)
+	assert.Equal(t, "vehicle", results.Facets.Types[0].Value)
+	assert.Equal(t, "Harbour thefts", results.Facets.Boards[0].Label)
+}
+
+func TestSearchService_Filters(t *testing.T) {
+	userID := uuid.New()
+	boardA, boardB := uuid.New(), uuid.New()
+	repo := new(MockSearchRepository)
+	svc := NewSearchService(repo)
+
+	filter := models.SearchFilter{
+		Query:     "van",
+		BoardIDs:  []uuid.UUID{boardA, boardB},
+		ItemTypes: []string{"vehicle", "event"},
+		Offset:    10,
+		Limit:     5,
+	}
+	repo.On("SearchItems", userID, filter).Return([]models.ItemSearchHit{}, int64(11), nil)
+	repo.On("ItemFacets", userID, filter).Return([]models.SearchFacet{}, []models.SearchFacet{}, nil)
+
+	results, err := svc.Search(userID, SearchQuery{
+		Q: "van", BoardID: []string{boardA.String() + "," + boardB.String()}, Type: []string{"vehicle", "event"}, Page: 3, Limit: 5,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, results.Page)
+	assert.Empty(t, results.Items)
+	assert.NotNil(t, results.Boards)
+	// Later pages and item type searches leave boards out
+	repo.AssertNotCalled(t, "SearchBoards", mock.Anything, mock.Anything)
+}
+
+func TestSearchService_InvalidQueries(t *testing.T) {
+	svc := NewSearchService(new(MockSearchRepository))
+	userID := uuid.New()
+
+	_, err := svc.Search(userID, SearchQuery{Q: "   "})
+	assert.True(t, errors.Is(err, ErrInvalidInput))
+	_, err = svc.Search(userID, SearchQuery{Q: strings.Repeat("a", MaxSearchQueryLength+1)})
+	assert.Equal(t, ErrInputTooLong, err)
+	_, err = svc.Search(userID, SearchQuery{Q: "van", BoardID: []string{"not-a-uuid"}})
+	assert.True(t, errors.Is(err, ErrInvalidInput))
+	_, err = svc.Search(userID, SearchQuery{Q: "van", Type: []string{strings.Repeat("x,", MaxSearchFilters+1)}})
+	assert.True(t, errors.Is(err, ErrInvalidInput))
+}
+
+func TestHighlightSnippet(t *testing.T) {
+	start, stop := models.HighlightStart, models.HighlightStop
+	assert.Equal(t, "a <mark>b</mark> c", highlightSnippet("a "+start+"b"+stop+" c"))
+	// Unbalanced markers never leave an element open
+	assert.Equal(t, "<mark>b</mark>", highlightSnippet(start+start+"b"))
+	assert.Equal(t, "b", highlightSnippet("b"+stop))
+	assert.Equal(t, `&#34;x&#34; &lt;script&gt;`, highlightSnippet(`"x" <script>`))
+}
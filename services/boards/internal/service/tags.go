@@ -0,0 +1,575 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrTagNotFound = errors.New("tag not found")
+	ErrTagExists   = errors.New("tag already exists")
+)
+
+// Tag limits
+const (
+	MaxTagNameLength  = 50
+	MaxTagFilters     = 20  // Tags in one listing filter
+	MaxBulkTagTargets = 500 // Items in one bulk tagging request
+	DefaultTagColor   = "#9e9e9e"
+)
+
+var tagColorRegex = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// CreateTagRequest represents a request to create a personal or board tag
+type CreateTagRequest struct {
+	Name  string `json:"name" binding:"required,min=1,max=50"`
+	Color string `json:"color"` // #rrggbb, defaults to grey
+}
+
+// UpdateTagRequest represents a request to rename or recolor a tag
+type UpdateTagRequest struct {
+	Name  *string `json:"name" binding:"omitempty,min=1,max=50"`
+	Color *string `json:"color"`
+}
+
+// MergeTagsRequest represents a request to merge a tag into another tag of
+// the same scope
+type MergeTagsRequest struct {
+	IntoID uuid.UUID `json:"into_id" binding:"required"`
+}
+
+// BulkTagRequest adds and removes tags on a selection of a board's items,
+// and on the board itself when Board is set
+type BulkTagRequest struct {
+	Add     []uuid.UUID `json:"add"`
+	Remove  []uuid.UUID `json:"remove"`
+	ItemIDs []uuid.UUID `json:"item_ids"`
+	Board   bool        `json:"board"`
+}
+
+// BoardTags lists the tags usable on a board, the board's shared tags
+// followed by the user's personal tags, and where they are assigned on the
+// board and its items
+type BoardTags struct {
+	Tags        []models.Tag           `json:"tags"`
+	Assignments []models.TagAssignment `json:"assignments"`
+}
+
+// validateTagName sanitizes a tag name like other user-provided text
+func validateTagName(name string) (string, error) {
+	name, err := validateAndSanitizeString(name, MaxTagNameLength, "name")
+	if err != nil {
+		return "", err
+	}
+	if name == "" {
+		return "", fmt.Errorf("%w: tag name is required", ErrInvalidInput)
+	}
+	return name, nil
+}
+
+// validateTagColor checks a #rrggbb color, defaulting an empty one
+func validateTagColor(color string) (string, error) {
+	if color == "" {
+		return DefaultTagColor, nil
+	}
+	if !tagColorRegex.MatchString(color) {
+		return "", fmt.Errorf("%w: tag color must be #rrggbb", ErrInvalidInput)
+	}
+	return strings.ToLower(color), nil
+}
+
+// ListTags returns the user's personal tags
+func (s *BoardService) ListTags(userID uuid.UUID) ([]models.Tag, error) {
+	if s.tagRepo == nil {
+		return []models.Tag{}, nil
+	}
+
+	tags, err := s.tagRepo.ListByOwner(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+	return append([]models.Tag{}, tags...), nil
+}
+
+// CreateTag creates a personal tag, or a board tag when boardID is given.
+// Board tags require write permission on the board.
+func (s *BoardService) CreateTag(userID uuid.UUID, boardID *uuid.UUID, req CreateTagRequest) (*models.Tag, error) {
+	if s.tagRepo == nil {
+		return nil, ErrInvalidInput
+	}
+
+	name, err := validateTagName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	color, err := validateTagColor(req.Color)
+	if err != nil {
+		return nil, err
+	}
+
+	tag := &models.Tag{Name: name, Color: color, Scope: models.TagScopeUser, OwnerID: &userID, CreatedBy: userID}
+	scopeID := userID
+	if boardID != nil {
+		if err := s.permissions.Authorize(context.Background(), *boardID, userID, models.PermissionWrite); err != nil {
+			return nil, err
+		}
+		tag.Scope, tag.OwnerID, tag.BoardID = models.TagScopeBoard, nil, boardID
+		scopeID = *boardID
+	}
+
+	existing, err := s.tagRepo.FindByName(tag.Scope, scopeID, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check tag name: %w", err)
+	}
+	if existing != nil {
+		return nil, ErrTagExists
+	}
+
+	if err := s.tagRepo.Create(tag); err != nil {
+		return nil, fmt.Errorf("failed to create tag: %w", err)
+	}
+
+	s.publishTagUpdate(tag, "tag_created", tag)
+
+	return tag, nil
+}
+
+// ListBoardTags returns the tags usable on a board and their assignments on
+// the board and its items. Other users' personal tags are never included.
+func (s *BoardService) ListBoardTags(boardID, userID uuid.UUID) (*BoardTags, error) {
+	access, err := s.permissions.authorizeAccess(context.Background(), boardID, userID, models.PermissionRead)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.boardTags(boardID, userID, access)
+}
+
+// boardTags lists the tags usable on a board and their assignments, leaving
+// out assignments to items on layers the user cannot see
+func (s *BoardService) boardTags(boardID, userID uuid.UUID, access *boardAccess) (*BoardTags, error) {
+	result := &BoardTags{Tags: []models.Tag{}, Assignments: []models.TagAssignment{}}
+	if s.tagRepo == nil {
+		return result, nil
+	}
+
+	shared, err := s.tagRepo.ListByBoard(boardID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list board tags: %w", err)
+	}
+	personal, err := s.tagRepo.ListByOwner(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+	result.Tags = append(append(result.Tags, shared...), personal...)
+
+	ids := make([]uuid.UUID, 0, len(result.Tags))
+	for _, tag := range result.Tags {
+		ids = append(ids, tag.ID)
+	}
+	assignments, err := s.tagRepo.ListAssignments(boardID, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tag assignments: %w", err)
+	}
+	for _, assignment := range assignments {
+		if !access.hiddenItems[assignment.TargetID] {
+			result.Assignments = append(result.Assignments, assignment)
+		}
+	}
+	return result, nil
+}
+
+// UpdateTag renames or recolors a tag. Personal tags can only be changed by
+// their owner, board tags by users with write permission on the board.
+func (s *BoardService) UpdateTag(tagID, userID uuid.UUID, req UpdateTagRequest) (*models.Tag, error) {
+	tag, err := s.getTagForWrite(tagID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		name, err := validateTagName(*req.Name)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.EqualFold(name, tag.Name) {
+			existing, err := s.tagRepo.FindByName(tag.Scope, tagScopeID(tag), name)
+			if err != nil {
+				return nil, fmt.Errorf("failed to check tag name: %w", err)
+			}
+			if existing != nil && existing.ID != tag.ID {
+				// Renaming onto another tag is a merge
+				return nil, ErrTagExists
+			}
+		}
+		tag.Name = name
+	}
+	if req.Color != nil {
+		color, err := validateTagColor(*req.Color)
+		if err != nil {
+			return nil, err
+		}
+		tag.Color = color
+	}
+
+	if err := s.tagRepo.Update(tag); err != nil {
+		return nil, fmt.Errorf("failed to update tag: %w", err)
+	}
+
+	s.publishTagUpdate(tag, "tag_updated", tag)
+
+	return tag, nil
+}
+
+// DeleteTag deletes a tag and removes it from everything it was assigned to
+func (s *BoardService) DeleteTag(tagID, userID uuid.UUID) error {
+	tag, err := s.getTagForWrite(tagID, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.tagRepo.Delete(tag.ID); err != nil {
+		return fmt.Errorf("failed to delete tag: %w", err)
+	}
+
+	s.publishTagUpdate(tag, "tag_deleted", map[string]interface{}{"id": tag.ID})
+
+	return nil
+}
+
+// MergeTags moves everything tagged with one tag to another tag of the same
+// scope and deletes the first tag. The tag merged into is returned.
+func (s *BoardService) MergeTags(tagID, userID uuid.UUID, req MergeTagsRequest) (*models.Tag, error) {
+	from, err := s.getTagForWrite(tagID, userID)
+	if err != nil {
+		return nil, err
+	}
+	into, err := s.getTagForWrite(req.IntoID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if from.ID == into.ID {
+		return nil, fmt.Errorf("%w: cannot merge a tag into itself", ErrInvalidInput)
+	}
+	if from.Scope != into.Scope || tagScopeID(from) != tagScopeID(into) {
+		return nil, fmt.Errorf("%w: only tags of the same user or board can be merged", ErrInvalidInput)
+	}
+
+	if err := s.tagRepo.Merge(from.ID, into.ID); err != nil {
+		return nil, fmt.Errorf("failed to merge tags: %w", err)
+	}
+
+	s.publishTagUpdate(into, "tags_merged", map[string]interface{}{"from": from.ID, "into": into.ID})
+
+	return into, nil
+}
+
+// BulkTag adds and removes tags on a selection of a board's items and the
+// board itself. Personal tags can be applied with read access; board tags
+// require write permission and only apply to their own board.
+func (s *BoardService) BulkTag(boardID, userID uuid.UUID, req BulkTagRequest) (*BoardTags, error) {
+	if s.tagRepo == nil {
+		return nil, ErrInvalidInput
+	}
+
+	access, err := s.permissions.authorizeAccess(context.Background(), boardID, userID, models.PermissionRead)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(req.Add) == 0 && len(req.Remove) == 0 {
+		return nil, fmt.Errorf("%w: no tags to add or remove", ErrInvalidInput)
+	}
+	if len(req.ItemIDs) == 0 && !req.Board {
+		return nil, fmt.Errorf("%w: no items or board selected", ErrInvalidInput)
+	}
+	if len(req.ItemIDs) > MaxBulkTagTargets {
+		return nil, fmt.Errorf("%w: at most %d items can be tagged at once", ErrInvalidInput, MaxBulkTagTargets)
+	}
+
+	tags, err := s.resolveTags(userID, &boardID, append(append([]uuid.UUID{}, req.Add...), req.Remove...))
+	if err != nil {
+		return nil, err
+	}
+	sharedTags := make(map[uuid.UUID]bool)
+	for _, tag := range tags {
+		if tag.Scope == models.TagScopeBoard {
+			if access.permission == models.PermissionRead {
+				return nil, ErrUnauthorized
+			}
+			sharedTags[tag.ID] = true
+		}
+	}
+
+	var items []models.BoardItem
+	if len(req.ItemIDs) > 0 {
+		found, err := s.boardItemRepo.ListByIDs(boardID, req.ItemIDs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list items: %w", err)
+		}
+		items = access.filterItems(found)
+		onBoard := make(map[uuid.UUID]bool, len(items))
+		for _, item := range items {
+			onBoard[item.ID] = true
+		}
+		for _, id := range req.ItemIDs {
+			if !onBoard[id] {
+				return nil, ErrItemNotFound
+			}
+		}
+	}
+
+	targetIDs := append([]uuid.UUID{}, req.ItemIDs...)
+	if req.Board {
+		targetIDs = append(targetIDs, boardID)
+	}
+
+	var assignments []models.TagAssignment
+	for _, tagID := range req.Add {
+		for _, targetID := range targetIDs {
+			targetType := models.TagTargetItem
+			if targetID == boardID {
+				targetType = models.TagTargetBoard
+			}
+			assignments = append(assignments, models.TagAssignment{
+				TagID:      tagID,
+				TargetID:   targetID,
+				TargetType: targetType,
+				BoardID:    boardID,
+				CreatedBy:  userID,
+			})
+		}
+	}
+	if err := s.tagRepo.Assign(assignments); err != nil {
+		return nil, fmt.Errorf("failed to assign tags: %w", err)
+	}
+	if err := s.tagRepo.Unassign(req.Remove, targetIDs); err != nil {
+		return nil, fmt.Errorf("failed to unassign tags: %w", err)
+	}
+
+	// Personal tags stay private, so only board tag changes are broadcast.
+	// Items on restricted layers are only listed to the users who can see
+	// them, like their own updates.
+	open, restricted := tagTargetsByLayer(items, access)
+	publish := func(event string, tagIDs []uuid.UUID) {
+		shared := make([]uuid.UUID, 0, len(tagIDs))
+		for _, id := range tagIDs {
+			if sharedTags[id] {
+				shared = append(shared, id)
+			}
+		}
+		if len(shared) == 0 {
+			return
+		}
+		if len(open) > 0 || req.Board {
+			s.publishBoardUpdate(boardID, event, map[string]interface{}{
+				"tag_ids":  shared,
+				"item_ids": open,
+				"board":    req.Board,
+			})
+		}
+		for _, targets := range restricted {
+			s.publishLayerEvent(boardID, targets.layer, event, map[string]interface{}{
+				"tag_ids":  shared,
+				"item_ids": targets.itemIDs,
+				"board":    false,
+			})
+		}
+	}
+	publish("tags_assigned", req.Add)
+	publish("tags_unassigned", req.Remove)
+
+	return s.boardTags(boardID, userID, access)
+}
+
+// layerTargets are the tagged items on one restricted layer
+type layerTargets struct {
+	layer   *models.Layer
+	itemIDs []uuid.UUID
+}
+
+// tagTargetsByLayer splits tagged items into those everyone on the board can
+// see and those on each restricted layer, in stacking order
+func tagTargetsByLayer(items []models.BoardItem, access *boardAccess) ([]uuid.UUID, []layerTargets) {
+	open := []uuid.UUID{}
+	byLayer := make(map[uuid.UUID][]uuid.UUID)
+	for _, item := range items {
+		var layer *models.Layer
+		if item.LayerID != nil {
+			layer = access.layers[*item.LayerID]
+		}
+		if layer == nil || !layer.Restricted() {
+			open = append(open, item.ID)
+			continue
+		}
+		byLayer[layer.ID] = append(byLayer[layer.ID], item.ID)
+	}
+
+	var restricted []layerTargets
+	for _, layer := range access.visible {
+		if ids := byLayer[layer.ID]; len(ids) > 0 {
+			restricted = append(restricted, layerTargets{layer: access.layers[layer.ID], itemIDs: ids})
+		}
+	}
+	return open, restricted
+}
+
+// ListTaggedBoards lists the boards accessible by a user that carry any of
+// the given tags. Tags may be repeated or comma-separated.
+func (s *BoardService) ListTaggedBoards(userID uuid.UUID, tags []string, offset, limit int) ([]models.BoardResponse, int64, error) {
+	ids, err := parseTagFilter(tags)
+	if err != nil {
+		return nil, 0, err
+	}
+	if _, err := s.resolveTags(userID, nil, ids); err != nil {
+		return nil, 0, err
+	}
+
+	boards, total, err := s.boardRepo.ListByUserAndTags(userID, ids, offset, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list boards: %w", err)
+	}
+	return boardResponses(boards, userID), total, nil
+}
+
+// itemsWithTags keeps the items carrying any of the given tags
+func (s *BoardService) itemsWithTags(boardID, userID uuid.UUID, items []models.BoardItem, tags []string) ([]models.BoardItem, error) {
+	ids, err := parseTagFilter(tags)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.resolveTags(userID, &boardID, ids); err != nil {
+		return nil, err
+	}
+
+	assignments, err := s.tagRepo.ListAssignments(boardID, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tag assignments: %w", err)
+	}
+	tagged := make(map[uuid.UUID]bool, len(assignments))
+	for _, a := range assignments {
+		if a.TargetType == models.TagTargetItem {
+			tagged[a.TargetID] = true
+		}
+	}
+
+	filtered := make([]models.BoardItem, 0, len(items))
+	for _, item := range items {
+		if tagged[item.ID] {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered, nil
+}
+
+// parseTagFilter parses the tag IDs of a listing filter
+func parseTagFilter(values []string) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	for _, value := range splitValues(values) {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid tag ID %q", ErrInvalidInput, value)
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) > MaxTagFilters {
+		return nil, fmt.Errorf("%w: at most %d tags can be filtered on", ErrInvalidInput, MaxTagFilters)
+	}
+	return ids, nil
+}
+
+// resolveTags loads tags the user may use: their own personal tags, and
+// board tags of the given board, or of any board when boardID is nil. Tags
+// the user may not see are reported as not found.
+func (s *BoardService) resolveTags(userID uuid.UUID, boardID *uuid.UUID, ids []uuid.UUID) ([]models.Tag, error) {
+	if s.tagRepo == nil {
+		return nil, ErrTagNotFound
+	}
+
+	tags, err := s.tagRepo.ListByIDs(ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags: %w", err)
+	}
+	found := make(map[uuid.UUID]bool, len(tags))
+	for _, tag := range tags {
+		switch tag.Scope {
+		case models.TagScopeUser:
+			if tag.OwnerID == nil || *tag.OwnerID != userID {
+				return nil, ErrTagNotFound
+			}
+		case models.TagScopeBoard:
+			if boardID != nil && (tag.BoardID == nil || *tag.BoardID != *boardID) {
+				return nil, ErrTagNotFound
+			}
+		}
+		found[tag.ID] = true
+	}
+	for _, id := range ids {
+		if !found[id] {
+			return nil, ErrTagNotFound
+		}
+	}
+	return tags, nil
+}
+
+// getTagForWrite loads a tag the user may change
+func (s *BoardService) getTagForWrite(tagID, userID uuid.UUID) (*models.Tag, error) {
+	if s.tagRepo == nil {
+		return nil, ErrTagNotFound
+	}
+
+	tag, err := s.tagRepo.GetByID(tagID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tag: %w", err)
+	}
+	if tag == nil {
+		return nil, ErrTagNotFound
+	}
+
+	if tag.Scope != models.TagScopeBoard {
+		if tag.OwnerID == nil || *tag.OwnerID != userID {
+			// Don't reveal other users' tags
+			return nil, ErrTagNotFound
+		}
+		return tag, nil
+	}
+
+	exists, permission, err := s.permissions.Resolve(context.Background(), *tag.BoardID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get board: %w", err)
+	}
+	if !exists || permission == "" {
+		return nil, ErrTagNotFound
+	}
+	if permission == models.PermissionRead {
+		return nil, ErrUnauthorized
+	}
+	return tag, nil
+}
+
+// tagScopeID is the user or board a tag belongs to
+func tagScopeID(tag *models.Tag) uuid.UUID {
+	if tag.Scope == models.TagScopeBoard && tag.BoardID != nil {
+		return *tag.BoardID
+	}
+	if tag.OwnerID != nil {
+		return *tag.OwnerID
+	}
+	return uuid.Nil
+}
+
+// publishTagUpdate broadcasts changes to board tags. Personal tags are
+// private to their owner and never published.
+func (s *BoardService) publishTagUpdate(tag *models.Tag, event string, data interface{}) {
+	if tag.Scope != models.TagScopeBoard || tag.BoardID == nil {
+		return
+	}
+	s.publishBoardUpdate(*tag.BoardID, event, data)
+}
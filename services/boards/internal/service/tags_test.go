@@ -0,0 +1,288 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockTagRepository is a mock implementation of TagRepository
+type MockTagRepository struct {
+	mock.Mock
+}
+
+func (m *MockTagRepository) Create(tag *models.Tag) error {
+	args := m.Called(tag)
+	return args.Error(0)
+}
+
+func (m *MockTagRepository) GetByID(id uuid.UUID) (*models.Tag, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Tag), args.Error(1)
+}
+
+func (m *MockTagRepository) ListByIDs(ids []uuid.UUID) ([]models.Tag, error) {
+	args := m.Called(ids)
+	return args.Get(0).([]models.Tag), args.Error(1)
+}
+
+func (m *MockTagRepository) ListByOwner(userID uuid.UUID) ([]models.Tag, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.Tag), args.Error(1)
+}
+
+func (m *MockTagRepository) ListByBoard(boardID uuid.UUID) ([]models.Tag, error) {
+	args := m.Called(boardID)
+	return args.Get(0).([]models.Tag), args.Error(1)
+}
+
+func (m *MockTagRepository) FindByName(scope models.TagScope, scopeID uuid.UUID, name string) (*models.Tag, error) {
+	args := m.Called(scope, scopeID, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Tag), args.Error(1)
+}
+
+func (m *MockTagRepository) Update(tag *models.Tag) error {
+	args := m.Called(tag)
+	return args.Error(0)
+}
+
+func (m *MockTagRepository) Delete(id uuid.UUID) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockTagRepository) Merge(fromID, intoID uuid.UUID) error {
+	args := m.Called(fromID, intoID)
+	return args.Error(0)
+}
+
+func (m *MockTagRepository) Assign(assignments []models.TagAssignment) error {
+	args := m.Called(assignments)
+	return args.Error(0)
+}
+
+func (m *MockTagRepository) Unassign(tagIDs, targetIDs []uuid.UUID) error {
+	args := m.Called(tagIDs, targetIDs)
+	return args.Error(0)
+}
+
+func (m *MockTagRepository) ListAssignments(boardID uuid.UUID, tagIDs []uuid.UUID) ([]models.TagAssignment, error) {
+	args := m.Called(boardID, tagIDs)
+	return args.Get(0).([]models.TagAssignment), args.Error(1)
+}
+
+func (m *MockTagRepository) DeleteByBoard(boardID uuid.UUID) error {
+	args := m.Called(boardID)
+	return args.Error(0)
+}
+
+// tagFixture is a board with a personal tag of the user, a board tag and
+// another user's personal tag
+type tagFixture struct {
+	svc       *BoardService
+	boardRepo *MockBoardRepository
+	itemRepo  *MockBoardItemRepository
+	tagRepo   *MockTagRepository
+	userID    uuid.UUID
+	board     *models.Board
+	personal  models.Tag
+	shared    models.Tag
+	foreign   models.Tag
+}
+
+func newTagFixture(permission models.PermissionLevel) *tagFixture {
+	f := &tagFixture{
+		boardRepo: new(MockBoardRepository),
+		itemRepo:  new(MockBoardItemRepository),
+		tagRepo:   new(MockTagRepository),
+		userID:    uuid.New(),
+		board:     &models.Board{ID: uuid.New(), Title: "Case"},
+	}
+	other := uuid.New()
+	f.personal = models.Tag{ID: uuid.New(), Name: "Follow up", Scope: models.TagScopeUser, OwnerID: &f.userID}
+	f.shared = models.Tag{ID: uuid.New(), Name: "Alibi", Scope: models.TagScopeBoard, BoardID: &f.board.ID}
+	f.foreign = models.Tag{ID: uuid.New(), Name: "Mine", Scope: models.TagScopeUser, OwnerID: &other}
+	f.boardRepo.On("GetPermission", f.board.ID, f.userID).Return(true, permission, nil)
+	f.svc = NewBoardService(f.boardRepo, new(MockBoardUserRepository), f.itemRepo, new(MockBoardConnectionRepository), nil, f.tagRepo, nil, nil)
+	return f
+}
+
+func TestBoardService_CreateTag(t *testing.T) {
+	f := newTagFixture(models.PermissionWrite)
+	f.tagRepo.On("FindByName", models.TagScopeUser, f.userID, "Suspect").Return(nil, nil)
+	f.tagRepo.On("FindByName", models.TagScopeBoard, f.board.ID, "Suspect").Return(&f.shared, nil)
+	f.tagRepo.On("Create", mock.AnythingOfType("*models.Tag")).Return(nil)
+
+	tag, err := f.svc.CreateTag(f.userID, nil, CreateTagRequest{Name: " Suspect ", Color: "#FF0000"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Suspect", tag.Name)
+	assert.Equal(t, "#ff0000", tag.Color)
+	assert.Equal(t, models.TagScopeUser, tag.Scope)
+	assert.Equal(t, f.userID, *tag.OwnerID)
+
+	_, err = f.svc.CreateTag(f.userID, &f.board.ID, CreateTagRequest{Name: "Suspect"})
+	assert.Equal(t, ErrTagExists, err)
+
+	_, err = f.svc.CreateTag(f.userID, nil, CreateTagRequest{Name: "Suspect", Color: "red"})
+	assert.True(t, errors.Is(err, ErrInvalidInput))
+
+	readOnly := newTagFixture(models.PermissionRead)
+	_, err = readOnly.svc.CreateTag(readOnly.userID, &readOnly.board.ID, CreateTagRequest{Name: "Suspect"})
+	assert.Equal(t, ErrUnauthorized, err)
+}
+
+func TestBoardService_BulkTag(t *testing.T) {
+	f := newTagFixture(models.PermissionWrite)
+	itemA, itemB := uuid.New(), uuid.New()
+	f.itemRepo.On("ListByIDs", f.board.ID, []uuid.UUID{itemA, itemB}).Return([]models.BoardItem{{ID: itemA}, {ID: itemB}}, nil)
+	f.tagRepo.On("ListByIDs", []uuid.UUID{f.personal.ID, f.shared.ID}).Return([]models.Tag{f.personal, f.shared}, nil)
+	f.tagRepo.On("ListByIDs", []uuid.UUID{f.personal.ID}).Return([]models.Tag{f.personal}, nil)
+	f.tagRepo.On("Assign", mock.Anything).Return(nil)
+	f.tagRepo.On("Unassign", []uuid.UUID{f.shared.ID}, []uuid.UUID{itemA, itemB, f.board.ID}).Return(nil)
+	f.tagRepo.On("ListByBoard", f.board.ID).Return([]models.Tag{f.shared}, nil)
+	f.tagRepo.On("ListByOwner", f.userID).Return([]models.Tag{f.personal}, nil)
+	f.tagRepo.On("ListAssignments", f.board.ID, []uuid.UUID{f.shared.ID, f.personal.ID}).Return([]models.TagAssignment{}, nil)
+
+	result, err := f.svc.BulkTag(f.board.ID, f.userID, BulkTagRequest{
+		Add:     []uuid.UUID{f.personal.ID},
+		Remove:  []uuid.UUID{f.shared.ID},
+		ItemIDs: []uuid.UUID{itemA, itemB},
+		Board:   true,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, result.Tags, 2)
+
+	assigned := f.tagRepo.Calls[1].Arguments.Get(0).([]models.TagAssignment)
+	if assert.Len(t, assigned, 3) {
+		assert.Equal(t, models.TagTargetItem, assigned[0].TargetType)
+		assert.Equal(t, f.board.ID, assigned[2].TargetID)
+		assert.Equal(t, models.TagTargetBoard, assigned[2].TargetType)
+		assert.Equal(t, f.board.ID, assigned[0].BoardID)
+	}
+
+	missing := uuid.New()
+	f.itemRepo.On("ListByIDs", f.board.ID, []uuid.UUID{missing}).Return([]models.BoardItem{}, nil)
+	_, err = f.svc.BulkTag(f.board.ID, f.userID, BulkTagRequest{Add: []uuid.UUID{f.personal.ID}, ItemIDs: []uuid.UUID{missing}})
+	assert.Equal(t, ErrItemNotFound, err)
+	_, err = f.svc.BulkTag(f.board.ID, f.userID, BulkTagRequest{Add: []uuid.UUID{f.personal.ID}})
+	assert.True(t, errors.Is(err, ErrInvalidInput))
+}
+
+func TestBoardService_BulkTagPermissions(t *testing.T) {
+	f := newTagFixture(models.PermissionRead)
+	itemID := uuid.New()
+	f.itemRepo.On("ListByIDs", f.board.ID, []uuid.UUID{itemID}).Return([]models.BoardItem{{ID: itemID}}, nil)
+	f.tagRepo.On("ListByIDs", []uuid.UUID{f.shared.ID}).Return([]models.Tag{f.shared}, nil)
+	f.tagRepo.On("ListByIDs", []uuid.UUID{f.foreign.ID}).Return([]models.Tag{f.foreign}, nil)
+	f.tagRepo.On("ListByIDs", []uuid.UUID{f.personal.ID}).Return([]models.Tag{f.personal}, nil)
+	f.tagRepo.On("Assign", mock.Anything).Return(nil)
+	f.tagRepo.On("Unassign", mock.Anything, mock.Anything).Return(nil)
+	f.tagRepo.On("ListByBoard", f.board.ID).Return([]models.Tag{}, nil)
+	f.tagRepo.On("ListByOwner", f.userID).Return([]models.Tag{f.personal}, nil)
+	f.tagRepo.On("ListAssignments", f.board.ID, mock.Anything).Return([]models.TagAssignment{}, nil)
+
+	// Board tags need write permission
+	_, err := f.svc.BulkTag(f.board.ID, f.userID, BulkTagRequest{Add: []uuid.UUID{f.shared.ID}, ItemIDs: []uuid.UUID{itemID}})
+	assert.Equal(t, ErrUnauthorized, err)
+
+	// Other users' personal tags are hidden
+	_, err = f.svc.BulkTag(f.board.ID, f.userID, BulkTagRequest{Add: []uuid.UUID{f.foreign.ID}, ItemIDs: []uuid.UUID{itemID}})
+	assert.Equal(t, ErrTagNotFound, err)
+
+	// Personal tags only need read access
+	_, err = f.svc.BulkTag(f.board.ID, f.userID, BulkTagRequest{Add: []uuid.UUID{f.personal.ID}, ItemIDs: []uuid.UUID{itemID}})
+	assert.NoError(t, err)
+}
+
+func TestBoardService_RenameAndMergeTags(t *testing.T) {
+	f := newTagFixture(models.PermissionWrite)
+	other := models.Tag{ID: uuid.New(), Name: "Alibis", Scope: models.TagScopeBoard, BoardID: &f.board.ID}
+	f.tagRepo.On("GetByID", f.shared.ID).Return(&f.shared, nil)
+	f.tagRepo.On("GetByID", other.ID).Return(&other, nil)
+	f.tagRepo.On("GetByID", f.personal.ID).Return(&f.personal, nil)
+	f.tagRepo.On("GetByID", f.foreign.ID).Return(&f.foreign, nil)
+	f.tagRepo.On("FindByName", models.TagScopeBoard, f.board.ID, "Alibis").Return(&other, nil)
+	f.tagRepo.On("Update", mock.AnythingOfType("*models.Tag")).Return(nil)
+	f.tagRepo.On("Merge", f.shared.ID, other.ID).Return(nil)
+
+	// Renaming onto an existing tag suggests a merge instead
+	name := "Alibis"
+	_, err := f.svc.UpdateTag(f.shared.ID, f.userID, UpdateTagRequest{Name: &name})
+	assert.Equal(t, ErrTagExists, err)
+
+	// Changing only the case is a rename
+	name = "ALIBI"
+	tag, err := f.svc.UpdateTag(f.shared.ID, f.userID, UpdateTagRequest{Name: &name})
+	assert.NoError(t, err)
+	assert.Equal(t, "ALIBI", tag.Name)
+
+	into, err := f.svc.MergeTags(f.shared.ID, f.userID, MergeTagsRequest{IntoID: other.ID})
+	assert.NoError(t, err)
+	assert.Equal(t, other.ID, into.ID)
+
+	_, err = f.svc.MergeTags(f.shared.ID, f.userID, MergeTagsRequest{IntoID: f.personal.ID})
+	assert.True(t, errors.Is(err, ErrInvalidInput))
+	_, err = f.svc.MergeTags(f.shared.ID, f.userID, MergeTagsRequest{IntoID: f.foreign.ID})
+	assert.Equal(t, ErrTagNotFound, err)
+	assert.Equal(t, ErrTagNotFound, f.svc.DeleteTag(f.foreign.ID, f.userID))
+}
+
+func TestBoardService_ListByTag(t *testing.T) {
+	f := newTagFixture(models.PermissionRead)
+	tagged, untagged := uuid.New(), uuid.New()
+	f.itemRepo.On("ListByBoard", f.board.ID).Return([]models.BoardItem{{ID: tagged}, {ID: untagged}}, nil)
+	f.tagRepo.On("ListByIDs", []uuid.UUID{f.shared.ID}).Return([]models.Tag{f.shared}, nil)
+	f.tagRepo.On("ListByIDs", []uuid.UUID{f.foreign.ID}).Return([]models.Tag{f.foreign}, nil)
+	f.tagRepo.On("ListAssignments", f.board.ID, []uuid.UUID{f.shared.ID}).Return([]models.TagAssignment{
+		{TagID: f.shared.ID, TargetID: tagged, TargetType: models.TagTargetItem},
+		{TagID: f.shared.ID, TargetID: f.board.ID, TargetType: models.TagTargetBoard},
+	}, nil)
+	f.boardRepo.On("ListByUserAndTags", f.userID, []uuid.UUID{f.shared.ID}, 0, 20).
+		Return([]models.Board{{ID: f.board.ID, OwnerID: f.userID}}, int64(1), nil)
+
+	items, err := f.svc.ListBoardItems(f.board.ID, f.userID, ItemQuery{Tag: []string{f.shared.ID.String()}})
+	assert.NoError(t, err)
+	if assert.Len(t, items, 1) {
+		assert.Equal(t, tagged, items[0].ID)
+	}
+
+	_, err = f.svc.ListBoardItems(f.board.ID, f.userID, ItemQuery{Tag: []string{f.foreign.ID.String()}})
+	assert.Equal(t, ErrTagNotFound, err)
+	_, err = f.svc.ListBoardItems(f.board.ID, f.userID, ItemQuery{Tag: []string{"urgent"}})
+	assert.True(t, errors.Is(err, ErrInvalidInput))
+
+	boards, total, err := f.svc.ListTaggedBoards(f.userID, []string{f.shared.ID.String()}, 0, 20)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	if assert.Len(t, boards, 1) {
+		assert.Equal(t, models.PermissionAdmin, boards[0].Permission)
+	}
+
+	_, _, err = f.svc.ListTaggedBoards(f.userID, []string{f.foreign.ID.String()}, 0, 20)
+	assert.Equal(t, ErrTagNotFound, err)
+}
+
+func TestTagTargetsByLayer(t *testing.T) {
+	f := newLayerFixture(models.PermissionAdmin)
+	access, err := f.svc.permissions.boardAccess(f.board.ID, f.userID, models.PermissionAdmin)
+	assert.NoError(t, err)
+
+	// Only items on restricted layers are held back from the board channel
+	open, restricted := tagTargetsByLayer([]models.BoardItem{f.baseItem, f.secretItem, f.openItem, f.lockedItem}, access)
+	assert.Equal(t, []uuid.UUID{f.baseItem.ID, f.openItem.ID, f.lockedItem.ID}, open)
+	if assert.Len(t, restricted, 1) {
+		assert.Equal(t, f.secret.ID, restricted[0].layer.ID)
+		assert.Equal(t, []uuid.UUID{f.secretItem.ID}, restricted[0].itemIDs)
+	}
+}
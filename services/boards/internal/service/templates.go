@@ -0,0 +1,321 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+)
+
+var ErrTemplateNotFound = errors.New("template not found")
+
+// PublishTemplateRequest represents a request to publish a board as a template
+type PublishTemplateRequest struct {
+	BoardID            uuid.UUID            `json:"board_id" binding:"required"`
+	Name               string               `json:"name" binding:"required,min=1,max=100"`
+	Description        string               `json:"description" binding:"max=1000"`
+	Scope              models.TemplateScope `json:"scope" binding:"omitempty,oneof=user organization"`
+	PlaceholderItemIDs []uuid.UUID          `json:"placeholder_item_ids"` // Items whose content is cleared in the template
+}
+
+// ListTemplates returns the built-in templates followed by organization-wide
+// templates and the user's own templates
+func (s *BoardService) ListTemplates(userID uuid.UUID) ([]models.BoardTemplate, error) {
+	templates := builtinTemplates()
+	if s.templateRepo == nil {
+		return templates, nil
+	}
+
+	stored, err := s.templateRepo.ListVisible(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list templates: %w", err)
+	}
+	return append(templates, stored...), nil
+}
+
+// GetTemplate retrieves a template visible to the user
+func (s *BoardService) GetTemplate(templateID, userID uuid.UUID) (*models.BoardTemplate, error) {
+	for _, tpl := range builtinTemplates() {
+		if tpl.ID == templateID {
+			return &tpl, nil
+		}
+	}
+	if s.templateRepo == nil {
+		return nil, ErrTemplateNotFound
+	}
+
+	template, err := s.templateRepo.GetByID(templateID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get template: %w", err)
+	}
+	if template == nil {
+		return nil, ErrTemplateNotFound
+	}
+	if template.Scope != models.TemplateScopeOrganization && (template.OwnerID == nil || *template.OwnerID != userID) {
+		// Don't reveal other users' templates
+		return nil, ErrTemplateNotFound
+	}
+	return template, nil
+}
+
+// PublishTemplate captures a board's items and connections as a template.
+// Admin permission on the board is required. Classified items and items on
+// restricted layers become placeholders, as templates are not marked and
+// have no layers; connections on restricted layers are left out.
+func (s *BoardService) PublishTemplate(userID uuid.UUID, req PublishTemplateRequest) (*models.BoardTemplate, error) {
+	if s.templateRepo == nil {
+		return nil, ErrInvalidInput
+	}
+
+	board, permission, access, err := s.getBoardContents(req.BoardID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if permission != models.PermissionAdmin {
+		return nil, ErrUnauthorized
+	}
+
+	name, err := validateName(req.Name)
+	if err != nil {
+		return nil, fmt.Errorf("name validation failed: %w", err)
+	}
+	description, err := validateDescription(req.Description)
+	if err != nil {
+		return nil, fmt.Errorf("description validation failed: %w", err)
+	}
+
+	placeholders := make(map[uuid.UUID]bool, len(req.PlaceholderItemIDs))
+	for _, id := range req.PlaceholderItemIDs {
+		placeholders[id] = true
+	}
+
+	content := models.TemplateContent{
+		Items:        make([]models.TemplateItem, 0, len(board.Items)),
+		Connections:  make([]models.TemplateConnection, 0, len(board.Connections)),
+		CustomFields: board.CustomFields,
+	}
+	onBoard := make(map[uuid.UUID]bool, len(board.Items))
+	for _, item := range board.Items {
+		onBoard[item.ID] = true
+	}
+	for _, item := range board.Items {
+		tplItem := models.TemplateItem{
+			Key:          item.ID.String(),
+			Type:         item.Type,
+			X:            item.X,
+			Y:            item.Y,
+			Width:        item.Width,
+			Height:       item.Height,
+			Rotation:     item.Rotation,
+			ZIndex:       item.ZIndex,
+			Content:      item.Content,
+			Style:        json.RawMessage(item.Style),
+			Fields:       item.Fields,
+			CustomValues: templateCustomValues(board.CustomFields, item.CustomValues),
+		}
+		if item.ParentID != nil && onBoard[*item.ParentID] {
+			tplItem.Parent = item.ParentID.String()
+		}
+		if placeholders[item.ID] || item.Classification.Rank() > 0 || access.restricted(item.LayerID) {
+			tplItem.Placeholder = true
+			tplItem.Content = ""
+			tplItem.Fields = nil
+			tplItem.CustomValues = nil
+			tplItem.Style = placeholderStyle(item.Style)
+		}
+		content.Items = append(content.Items, tplItem)
+	}
+	for _, conn := range board.Connections {
+		if access.restricted(conn.LayerID) {
+			continue
+		}
+		content.Connections = append(content.Connections, models.TemplateConnection{
+			From:             conn.FromItemID.String(),
+			To:               conn.ToItemID.String(),
+			Label:            conn.Label,
+			Direction:        conn.Direction,
+			RelationshipType: conn.RelationshipType,
+			Confidence:       conn.Confidence,
+			Style:            conn.Style,
+		})
+	}
+
+	scope := req.Scope
+	if scope == "" {
+		scope = models.TemplateScopeUser
+	}
+	sourceID := board.ID
+	ownerID := userID
+	template := &models.BoardTemplate{
+		Name:          name,
+		Description:   description,
+		Scope:         scope,
+		OwnerID:       &ownerID,
+		SourceBoardID: &sourceID,
+		Content:       content,
+	}
+	if err := s.templateRepo.Create(template); err != nil {
+		return nil, fmt.Errorf("failed to create template: %w", err)
+	}
+
+	return template, nil
+}
+
+// DeleteTemplate deletes a template owned by the user. Built-in templates cannot be deleted.
+func (s *BoardService) DeleteTemplate(templateID, userID uuid.UUID) error {
+	template, err := s.GetTemplate(templateID, userID)
+	if err != nil {
+		return err
+	}
+	if template.Scope == models.TemplateScopeBuiltin || template.OwnerID == nil || *template.OwnerID != userID {
+		return ErrUnauthorized
+	}
+
+	if err := s.templateRepo.Delete(templateID); err != nil {
+		return fmt.Errorf("failed to delete template: %w", err)
+	}
+	return nil
+}
+
+// templateContents checks the template's items like CreateBoardItem checks
+// new ones and returns them with the connections between them, to be created
+// on a board by copyBoardContents. Items must have a registered type and
+// valid fields, and may only be placed in frames of the template.
+func templateContents(template *models.BoardTemplate) ([]models.BoardItem, []models.BoardConnection, error) {
+	keys := make(map[string]uuid.UUID, len(template.Content.Items))
+	types := make(map[string]string, len(template.Content.Items))
+	items := make([]models.BoardItem, 0, len(template.Content.Items))
+	for i, tplItem := range template.Content.Items {
+		if _, ok := keys[tplItem.Key]; ok || tplItem.Key == "" {
+			return nil, nil, fmt.Errorf("%w: template item %d has a missing or duplicate key", ErrInvalidInput, i)
+		}
+		var style struct {
+			Metadata map[string]interface{} `json:"metadata"`
+		}
+		_ = json.Unmarshal(tplItem.Style, &style)
+		itemType, err := resolveItemType(tplItem.Type, style.Metadata)
+		if err != nil {
+			return nil, nil, err
+		}
+		// Only checked: the stored values were sanitized when the template was published
+		if _, err := validateFields(itemType, decodeFields(tplItem.Fields)); err != nil {
+			return nil, nil, err
+		}
+
+		// Temporary IDs only link connections to items; copyBoardContents assigns real ones
+		id := uuid.New()
+		keys[tplItem.Key] = id
+		types[tplItem.Key] = itemType
+
+		styleData := []byte(tplItem.Style)
+		if tplItem.Placeholder {
+			styleData = placeholderStyle(styleData)
+		}
+		items = append(items, models.BoardItem{
+			ID:           id,
+			Type:         itemType,
+			X:            tplItem.X,
+			Y:            tplItem.Y,
+			Width:        tplItem.Width,
+			Height:       tplItem.Height,
+			Rotation:     tplItem.Rotation,
+			ZIndex:       tplItem.ZIndex,
+			Content:      tplItem.Content,
+			Style:        styleData,
+			Fields:       tplItem.Fields,
+			CustomValues: tplItem.CustomValues,
+		})
+	}
+
+	parents := make(map[string]string, len(template.Content.Items))
+	for _, tplItem := range template.Content.Items {
+		parents[tplItem.Key] = tplItem.Parent
+	}
+	for i, tplItem := range template.Content.Items {
+		if tplItem.Parent == "" {
+			continue
+		}
+		// Frames count themselves towards the depth, as in checkFrameParent
+		depth := 0
+		if types[tplItem.Key] == string(models.ItemTypeFrame) {
+			depth = 1
+		}
+		for parent := tplItem.Parent; parent != ""; parent = parents[parent] {
+			if types[parent] != string(models.ItemTypeFrame) {
+				return nil, nil, fmt.Errorf("%w: template item %d is not in a frame of the template", ErrInvalidInput, i)
+			}
+			// A cycle never reaches the board, so it ends up too deep as well
+			if depth++; depth > MaxFrameDepth {
+				return nil, nil, fmt.Errorf("%w: frames can be nested at most %d deep", ErrInvalidInput, MaxFrameDepth)
+			}
+		}
+		parentID := keys[tplItem.Parent]
+		items[i].ParentID = &parentID
+	}
+
+	connections := make([]models.BoardConnection, 0, len(template.Content.Connections))
+	for _, tplConn := range template.Content.Connections {
+		from, okFrom := keys[tplConn.From]
+		to, okTo := keys[tplConn.To]
+		if !okFrom || !okTo {
+			continue
+		}
+		connections = append(connections, models.BoardConnection{
+			FromItemID:       from,
+			ToItemID:         to,
+			Label:            tplConn.Label,
+			Direction:        tplConn.Direction,
+			RelationshipType: tplConn.RelationshipType,
+			Confidence:       tplConn.Confidence,
+			Style:            tplConn.Style,
+		})
+	}
+
+	return items, connections, nil
+}
+
+// templateCustomValues drops the values of user fields, which refer to
+// members of the source board rather than of boards created from the template
+func templateCustomValues(fields []models.CustomField, data json.RawMessage) json.RawMessage {
+	values := decodeFields(data)
+	if len(values) == 0 {
+		return nil
+	}
+	for _, field := range fields {
+		if field.Type == models.CustomFieldUser {
+			delete(values, field.Key)
+		}
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	out, _ := json.Marshal(values)
+	return out
+}
+
+// placeholderStyle blanks the string values in an item's style metadata
+// (keeping the keys as empty fields to fill in) and flags it as a placeholder
+func placeholderStyle(style []byte) []byte {
+	styleData := make(map[string]interface{})
+	if len(style) > 0 {
+		json.Unmarshal(style, &styleData)
+	}
+
+	metadata, _ := styleData["metadata"].(map[string]interface{})
+	if metadata == nil {
+		metadata = make(map[string]interface{})
+	}
+	for key, value := range metadata {
+		if _, ok := value.(string); ok && key != "variant" {
+			metadata[key] = ""
+		}
+	}
+	metadata["placeholder"] = true
+	styleData["metadata"] = metadata
+
+	data, _ := json.Marshal(styleData)
+	return data
+}
@@ -0,0 +1,319 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockTemplateRepository is a mock implementation of TemplateRepository
+type MockTemplateRepository struct {
+	mock.Mock
+}
+
+func (m *MockTemplateRepository) Create(template *models.BoardTemplate) error {
+	args := m.Called(template)
+	return args.Error(0)
+}
+
+func (m *MockTemplateRepository) GetByID(id uuid.UUID) (*models.BoardTemplate, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.BoardTemplate), args.Error(1)
+}
+
+func (m *MockTemplateRepository) ListVisible(userID uuid.UUID) ([]models.BoardTemplate, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.BoardTemplate), args.Error(1)
+}
+
+func (m *MockTemplateRepository) Delete(id uuid.UUID) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func TestBoardService_CreateBoardFromBuiltinTemplate(t *testing.T) {
+	userID := uuid.New()
+
+	mockBoardRepo := new(MockBoardRepository)
+	mockBoardUserRepo := new(MockBoardUserRepository)
+	mockBoardItemRepo := new(MockBoardItemRepository)
+	mockConnectionRepo := new(MockBoardConnectionRepository)
+	mockTemplateRepo := new(MockTemplateRepository)
+	svc := NewBoardService(mockBoardRepo, mockBoardUserRepo, mockBoardItemRepo, mockConnectionRepo, mockTemplateRepo, nil, nil, nil)
+
+	mockBoardRepo.On("Create", mock.AnythingOfType("*models.Board")).Run(func(args mock.Arguments) {
+		args.Get(0).(*models.Board).ID = uuid.New()
+	}).Return(nil)
+	mockBoardUserRepo.On("Create", mock.AnythingOfType("*models.BoardUser")).Return(nil)
+	var items []*models.BoardItem
+	mockBoardItemRepo.On("Create", mock.AnythingOfType("*models.BoardItem")).Run(func(args mock.Arguments) {
+		items = append(items, args.Get(0).(*models.BoardItem))
+	}).Return(nil)
+	var conns []*models.BoardConnection
+	mockConnectionRepo.On("Create", mock.AnythingOfType("*models.BoardConnection")).Run(func(args mock.Arguments) {
+		conns = append(conns, args.Get(0).(*models.BoardConnection))
+	}).Return(nil)
+
+	templateID := builtinTemplateID("link-analysis")
+	board, err := svc.CreateBoard(userID, CreateBoardRequest{Title: "Op Nightjar", Visibility: models.VisibilityPrivate, TemplateID: &templateID})
+	assert.NoError(t, err)
+	assert.Len(t, items, 5)
+	assert.Len(t, conns, 4)
+
+	itemIDs := map[uuid.UUID]bool{}
+	for _, item := range items {
+		assert.Equal(t, board.ID, item.BoardID)
+		assert.Equal(t, userID, item.CreatedBy)
+		itemIDs[item.ID] = true
+	}
+	for _, conn := range conns {
+		assert.True(t, itemIDs[conn.FromItemID])
+		assert.True(t, itemIDs[conn.ToItemID])
+	}
+
+	// The subject suspect card is a placeholder with empty fields
+	assert.Equal(t, string(models.ItemTypeSuspectCard), items[0].Type)
+	var style map[string]interface{}
+	assert.NoError(t, json.Unmarshal(items[0].Style, &style))
+	metadata := style["metadata"].(map[string]interface{})
+	assert.Equal(t, true, metadata["placeholder"])
+	assert.Equal(t, "suspect-card", metadata["variant"])
+}
+
+func TestBoardService_CreateBoardUnknownTemplate(t *testing.T) {
+	userID := uuid.New()
+	templateID := uuid.New()
+
+	mockBoardRepo := new(MockBoardRepository)
+	mockTemplateRepo := new(MockTemplateRepository)
+	svc := NewBoardService(mockBoardRepo, new(MockBoardUserRepository), new(MockBoardItemRepository), new(MockBoardConnectionRepository), mockTemplateRepo, nil, nil, nil)
+
+	mockTemplateRepo.On("GetByID", templateID).Return(nil, nil)
+
+	board, err := svc.CreateBoard(userID, CreateBoardRequest{Title: "Case", Visibility: models.VisibilityPrivate, TemplateID: &templateID})
+	assert.Equal(t, ErrTemplateNotFound, err)
+	assert.Nil(t, board)
+	mockBoardRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestBoardService_CreateBoardInvalidTemplate(t *testing.T) {
+	userID := uuid.New()
+	valid := func() []models.TemplateItem {
+		return []models.TemplateItem{
+			{Key: "frame", Type: string(models.ItemTypeFrame), Width: 800, Height: 600},
+			{Key: "suspect", Parent: "frame", Type: string(models.ItemTypeSuspectCard), Width: 280, Height: 400, Fields: json.RawMessage(`{"name":"Tom"}`)},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(items []models.TemplateItem) []models.TemplateItem
+	}{
+		{name: "unknown item type", modify: func(items []models.TemplateItem) []models.TemplateItem {
+			items[1].Type = "photo"
+			return items
+		}},
+		{name: "invalid field value", modify: func(items []models.TemplateItem) []models.TemplateItem {
+			items[1].Fields = json.RawMessage(`{"dob":"last year"}`)
+			return items
+		}},
+		{name: "duplicate key", modify: func(items []models.TemplateItem) []models.TemplateItem {
+			items[1].Key = "frame"
+			return items
+		}},
+		{name: "unknown parent", modify: func(items []models.TemplateItem) []models.TemplateItem {
+			items[1].Parent = "other"
+			return items
+		}},
+		{name: "parent not a frame", modify: func(items []models.TemplateItem) []models.TemplateItem {
+			return append(items, models.TemplateItem{Key: "note", Parent: "suspect", Type: string(models.ItemTypePostIt), Width: 200, Height: 200})
+		}},
+		{name: "frame cycle", modify: func(items []models.TemplateItem) []models.TemplateItem {
+			items[0].Parent = "inner"
+			return append(items, models.TemplateItem{Key: "inner", Parent: "frame", Type: string(models.ItemTypeFrame), Width: 400, Height: 300})
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockBoardRepo := new(MockBoardRepository)
+			mockTemplateRepo := new(MockTemplateRepository)
+			svc := NewBoardService(mockBoardRepo, new(MockBoardUserRepository), new(MockBoardItemRepository), new(MockBoardConnectionRepository), mockTemplateRepo, nil, nil, nil)
+
+			templateID := uuid.New()
+			mockTemplateRepo.On("GetByID", templateID).Return(&models.BoardTemplate{
+				ID: templateID, Scope: models.TemplateScopeOrganization,
+				Content: models.TemplateContent{Items: tt.modify(valid())},
+			}, nil)
+
+			board, err := svc.CreateBoard(userID, CreateBoardRequest{Title: "Case", Visibility: models.VisibilityPrivate, TemplateID: &templateID})
+			assert.True(t, errors.Is(err, ErrInvalidInput), "unexpected error: %v", err)
+			assert.Nil(t, board)
+			mockBoardRepo.AssertNotCalled(t, "Create", mock.Anything)
+		})
+	}
+
+	// The valid template passes
+	items, _, err := templateContents(&models.BoardTemplate{Content: models.TemplateContent{Items: valid()}})
+	assert.NoError(t, err)
+	if assert.Len(t, items, 2) && assert.NotNil(t, items[1].ParentID) {
+		assert.Equal(t, items[0].ID, *items[1].ParentID)
+	}
+}
+
+func TestBoardService_GetTemplate(t *testing.T) {
+	userID := uuid.New()
+	otherID := uuid.New()
+	ownTemplate := &models.BoardTemplate{ID: uuid.New(), Name: "Mine", Scope: models.TemplateScopeUser, OwnerID: &userID}
+	orgTemplate := &models.BoardTemplate{ID: uuid.New(), Name: "Org", Scope: models.TemplateScopeOrganization, OwnerID: &otherID}
+	privateTemplate := &models.BoardTemplate{ID: uuid.New(), Name: "Theirs", Scope: models.TemplateScopeUser, OwnerID: &otherID}
+
+	mockTemplateRepo := new(MockTemplateRepository)
+	svc := NewBoardService(new(MockBoardRepository), new(MockBoardUserRepository), new(MockBoardItemRepository), new(MockBoardConnectionRepository), mockTemplateRepo, nil, nil, nil)
+	mockTemplateRepo.On("GetByID", ownTemplate.ID).Return(ownTemplate, nil)
+	mockTemplateRepo.On("GetByID", orgTemplate.ID).Return(orgTemplate, nil)
+	mockTemplateRepo.On("GetByID", privateTemplate.ID).Return(privateTemplate, nil)
+
+	tpl, err := svc.GetTemplate(builtinTemplateID("case-timeline"), userID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.TemplateScopeBuiltin, tpl.Scope)
+
+	tpl, err = svc.GetTemplate(ownTemplate.ID, userID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Mine", tpl.Name)
+
+	tpl, err = svc.GetTemplate(orgTemplate.ID, userID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Org", tpl.Name)
+
+	_, err = svc.GetTemplate(privateTemplate.ID, userID)
+	assert.Equal(t, ErrTemplateNotFound, err)
+
+	// Only the owner may delete, and built-ins never
+	assert.Equal(t, ErrUnauthorized, svc.DeleteTemplate(orgTemplate.ID, userID))
+	assert.Equal(t, ErrUnauthorized, svc.DeleteTemplate(builtinTemplateID("case-timeline"), userID))
+}
+
+func TestBoardService_PublishTemplate(t *testing.T) {
+	boardID := uuid.New()
+	userID := uuid.New()
+	suspectID := uuid.New()
+	noteID := uuid.New()
+
+	customFields := []models.CustomField{
+		{Key: "case_number", Label: "Case number", Type: models.CustomFieldText},
+		{Key: "handler", Label: "Handler", Type: models.CustomFieldUser},
+	}
+	board := &models.Board{
+		ID:           boardID,
+		CustomFields: customFields,
+		Items: []models.BoardItem{
+			{ID: suspectID, BoardID: boardID, Type: "suspect-card", Content: "John Smith", Style: []byte(`{"color":"#f5f5f5","metadata":{"variant":"suspect-card","alias":"Smithy"}}`),
+				CustomValues: []byte(`{"case_number":"117"}`)},
+			{ID: noteID, BoardID: boardID, Type: "post-it", Content: "Seen at docks",
+				CustomValues: []byte(`{"case_number":"117","handler":"` + userID.String() + `"}`)},
+		},
+		Connections: []models.BoardConnection{{FromItemID: suspectID, ToItemID: noteID}},
+	}
+
+	tests := []struct {
+		name        string
+		permission  models.PermissionLevel
+		expectedErr error
+	}{
+		{name: "admin publishes", permission: models.PermissionAdmin},
+		{name: "writer cannot publish", permission: models.PermissionWrite, expectedErr: ErrUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockBoardRepo := new(MockBoardRepository)
+			mockTemplateRepo := new(MockTemplateRepository)
+			svc := NewBoardService(mockBoardRepo, new(MockBoardUserRepository), new(MockBoardItemRepository), new(MockBoardConnectionRepository), mockTemplateRepo, nil, nil, nil)
+
+			mockBoardRepo.On("GetByIDWithContents", boardID, userID).Return(board, tt.permission, nil)
+			mockTemplateRepo.On("Create", mock.AnythingOfType("*models.BoardTemplate")).Return(nil)
+
+			template, err := svc.PublishTemplate(userID, PublishTemplateRequest{
+				BoardID:            boardID,
+				Name:               "Suspect + note",
+				Scope:              models.TemplateScopeOrganization,
+				PlaceholderItemIDs: []uuid.UUID{suspectID},
+			})
+			if tt.expectedErr != nil {
+				assert.Equal(t, tt.expectedErr, err)
+				mockTemplateRepo.AssertNotCalled(t, "Create", mock.Anything)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, models.TemplateScopeOrganization, template.Scope)
+			assert.Equal(t, userID, *template.OwnerID)
+			if assert.Len(t, template.Content.Items, 2) {
+				placeholder := template.Content.Items[0]
+				assert.True(t, placeholder.Placeholder)
+				assert.Empty(t, placeholder.Content)
+				assert.JSONEq(t, `{"color":"#f5f5f5","metadata":{"variant":"suspect-card","alias":"","placeholder":true}}`, string(placeholder.Style))
+				assert.Nil(t, placeholder.CustomValues)
+				assert.Equal(t, "Seen at docks", template.Content.Items[1].Content)
+				// User references only make sense on the source board
+				assert.JSONEq(t, `{"case_number":"117"}`, string(template.Content.Items[1].CustomValues))
+			}
+			assert.Equal(t, customFields, template.Content.CustomFields)
+			assert.Equal(t, []models.TemplateConnection{{From: suspectID.String(), To: noteID.String()}}, template.Content.Connections)
+		})
+	}
+}
+
+func TestBoardService_TemplateKeepsFrames(t *testing.T) {
+	boardID := uuid.New()
+	userID := uuid.New()
+	frameID := uuid.New()
+	hiddenID := uuid.New() // A frame the publisher cannot see
+	board := &models.Board{
+		ID: boardID,
+		Items: []models.BoardItem{
+			{ID: uuid.New(), BoardID: boardID, Type: "post-it", ParentID: &frameID},
+			{ID: frameID, BoardID: boardID, Type: "frame"},
+			{ID: uuid.New(), BoardID: boardID, Type: "post-it", ParentID: &hiddenID},
+		},
+	}
+
+	mockBoardRepo := new(MockBoardRepository)
+	mockBoardItemRepo := new(MockBoardItemRepository)
+	mockTemplateRepo := new(MockTemplateRepository)
+	svc := NewBoardService(mockBoardRepo, new(MockBoardUserRepository), mockBoardItemRepo, new(MockBoardConnectionRepository), mockTemplateRepo, nil, nil, nil)
+	mockBoardRepo.On("GetByIDWithContents", boardID, userID).Return(board, models.PermissionAdmin, nil)
+	mockTemplateRepo.On("Create", mock.AnythingOfType("*models.BoardTemplate")).Return(nil)
+
+	template, err := svc.PublishTemplate(userID, PublishTemplateRequest{BoardID: boardID, Name: "Framed", Scope: models.TemplateScopeUser})
+	assert.NoError(t, err)
+	if !assert.Len(t, template.Content.Items, 3) {
+		return
+	}
+	assert.Equal(t, frameID.String(), template.Content.Items[0].Parent)
+	assert.Empty(t, template.Content.Items[2].Parent)
+
+	var items []*models.BoardItem
+	mockBoardItemRepo.On("Create", mock.AnythingOfType("*models.BoardItem")).Run(func(args mock.Arguments) {
+		items = append(items, args.Get(0).(*models.BoardItem))
+	}).Return(nil)
+	mockBoardItemRepo.On("SetParent", mock.Anything, mock.Anything).Return(nil)
+	tplItems, tplConns, err := templateContents(template)
+	assert.NoError(t, err)
+	_, err = copyBoardContents(svc.contentRepositories(), uuid.New(), userID, tplItems, tplConns, nil)
+	assert.NoError(t, err)
+	if assert.Len(t, items, 3) {
+		mockBoardItemRepo.AssertCalled(t, "SetParent", []uuid.UUID{items[0].ID}, &items[1].ID)
+		mockBoardItemRepo.AssertNumberOfCalls(t, "SetParent", 1)
+	}
+}
@@ -0,0 +1,492 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"time"
+
+	"evidence-wall/boards-service/internal/graph"
+	"evidence-wall/boards-service/internal/itemtype"
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+)
+
+// TimelineGrouping selects how timeline events are split into lanes
+type TimelineGrouping string
+
+const (
+	TimelineByType       TimelineGrouping = "type"
+	TimelineByTag        TimelineGrouping = "tag"
+	TimelineByConnection TimelineGrouping = "connection" // Items linked by any chain of connections share a lane
+)
+
+// Keys of the lanes holding the events that fit no other lane
+const (
+	untaggedLane    = "untagged"
+	unconnectedLane = "unconnected"
+)
+
+// TimelineQuery selects the events of a board's timeline. From and To are
+// RFC 3339 times or dates; events overlapping them are kept.
+type TimelineQuery struct {
+	GroupBy          TimelineGrouping `form:"group_by"`
+	Type             []string         `form:"type"`
+	From             string           `form:"from"`
+	To               string           `form:"to"`
+	RelationshipType []string         `form:"relationship_type"` // Connections that make up swimlanes, when grouped by connection
+}
+
+// TimelineEvent is a dated item. All-day events have their start and end
+// at midnight UTC, the end being the last day of the event.
+type TimelineEvent struct {
+	ItemID      uuid.UUID  `json:"item_id"`
+	Type        string     `json:"type"`
+	Title       string     `json:"title"`
+	Start       time.Time  `json:"start"`
+	End         *time.Time `json:"end,omitempty"`
+	AllDay      bool       `json:"all_day,omitempty"`
+	Approximate bool       `json:"approximate,omitempty"`
+	Lanes       []string   `json:"lanes"` // Keys of the lanes the event is in
+}
+
+// TimelineLane is a row of the timeline
+type TimelineLane struct {
+	Key   string `json:"key"`
+	Label string `json:"label"`
+	Color string `json:"color,omitempty"`
+	Count int    `json:"count"`
+}
+
+// Timeline lists a board's dated items in chronological order. Start and End
+// span every event; Undated counts the items left out for having no time.
+type Timeline struct {
+	GroupBy TimelineGrouping `json:"group_by"`
+	Start   *time.Time       `json:"start,omitempty"`
+	End     *time.Time       `json:"end,omitempty"`
+	Events  []TimelineEvent  `json:"events"`
+	Lanes   []TimelineLane   `json:"lanes"`
+	Undated int              `json:"undated"`
+}
+
+// timelineItem holds the time fields an item may carry, either as typed
+// fields (events, documents) or in its style metadata
+type timelineItem struct {
+	Timestamp   string `json:"timestamp"`
+	End         string `json:"end"`
+	Date        string `json:"date"`
+	Approximate bool   `json:"approximate"`
+}
+
+// GetTimeline returns the board's dated items in chronological order, split
+// into lanes. An item is dated by its timestamp and end fields (events), its
+// date field (documents), timestamp and end in its metadata, or failing
+// those the time its photo was taken. Read access is sufficient.
+func (s *BoardService) GetTimeline(boardID, userID uuid.UUID, query TimelineQuery) (*Timeline, error) {
+	timeline, _, _, err := s.timeline(boardID, userID, query)
+	return timeline, err
+}
+
+// TimelineICS exports the board's timeline as an iCalendar file with one
+// event per dated item, categorized by its lanes
+func (s *BoardService) TimelineICS(boardID, userID uuid.UUID, query TimelineQuery) ([]byte, error) {
+	timeline, board, labels, err := s.timeline(boardID, userID, query)
+	if err != nil {
+		return nil, err
+	}
+	return renderICS(board, timeline, labels, time.Now()), nil
+}
+
+// timeline builds the timeline along with the board it was built from and
+// the lane labels by key
+func (s *BoardService) timeline(boardID, userID uuid.UUID, query TimelineQuery) (*Timeline, *models.Board, map[string]string, error) {
+	if query.GroupBy == "" {
+		query.GroupBy = TimelineByType
+	}
+	switch query.GroupBy {
+	case TimelineByType, TimelineByTag, TimelineByConnection:
+	default:
+		return nil, nil, nil, fmt.Errorf("%w: group_by must be type, tag or connection", ErrInvalidInput)
+	}
+	from, to, err := timelineWindow(query.From, query.To)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	filter, err := ConnectionQuery{RelationshipType: query.RelationshipType}.filter()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	board, _, access, err := s.getBoardContents(boardID, userID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	types := make(map[string]bool)
+	for _, t := range splitValues(query.Type) {
+		types[t] = true
+	}
+	timeline := &Timeline{GroupBy: query.GroupBy, Events: []TimelineEvent{}, Lanes: []TimelineLane{}}
+	for _, item := range board.Items {
+		if len(types) > 0 && !types[item.Type] {
+			continue
+		}
+		event, ok := timelineEvent(item)
+		if !ok {
+			timeline.Undated++
+			continue
+		}
+		if (from != nil && event.last().Before(*from)) || (to != nil && event.Start.After(*to)) {
+			continue
+		}
+		timeline.Events = append(timeline.Events, event)
+	}
+	sort.SliceStable(timeline.Events, func(i, j int) bool {
+		return timeline.Events[i].Start.Before(timeline.Events[j].Start)
+	})
+
+	var lanes []TimelineLane
+	switch query.GroupBy {
+	case TimelineByType:
+		lanes = typeLanes(timeline.Events)
+	case TimelineByTag:
+		tags, err := s.boardTags(boardID, userID, access)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		lanes = tagLanes(timeline.Events, tags)
+	case TimelineByConnection:
+		lanes = connectionLanes(timeline.Events, board, filter.RelationshipTypes)
+	}
+
+	labels := make(map[string]string, len(lanes))
+	for _, lane := range lanes {
+		if lane.Count > 0 {
+			timeline.Lanes = append(timeline.Lanes, lane)
+			labels[lane.Key] = lane.Label
+		}
+	}
+	for i := range timeline.Events {
+		event := &timeline.Events[i]
+		if timeline.Start == nil || event.Start.Before(*timeline.Start) {
+			start := event.Start
+			timeline.Start = &start
+		}
+		if last := event.last(); timeline.End == nil || last.After(*timeline.End) {
+			timeline.End = &last
+		}
+	}
+	return timeline, board, labels, nil
+}
+
+// last returns when the event ends, at the end of its last day when all-day
+func (e *TimelineEvent) last() time.Time {
+	last := e.Start
+	if e.End != nil {
+		last = *e.End
+	}
+	if e.AllDay {
+		last = last.Add(24*time.Hour - time.Nanosecond)
+	}
+	return last
+}
+
+// timelineWindow parses the from and to bounds of a timeline query. A date
+// as upper bound includes the whole day.
+func timelineWindow(fromValue, toValue string) (*time.Time, *time.Time, error) {
+	var from, to *time.Time
+	if fromValue != "" {
+		t, _, ok := parseTimelineTime(fromValue)
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: from must be an RFC 3339 time or a date", ErrInvalidInput)
+		}
+		from = &t
+	}
+	if toValue != "" {
+		t, allDay, ok := parseTimelineTime(toValue)
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: to must be an RFC 3339 time or a date", ErrInvalidInput)
+		}
+		if allDay {
+			t = t.Add(24*time.Hour - time.Nanosecond)
+		}
+		to = &t
+	}
+	return from, to, nil
+}
+
+// parseTimelineTime parses an RFC 3339 time, a time without offset (taken
+// as UTC) or a date, reporting whether it was a date
+func parseTimelineTime(value string) (time.Time, bool, bool) {
+	value = strings.TrimSpace(value)
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), false, true
+	}
+	if t, err := time.Parse("2006-01-02T15:04:05", value); err == nil {
+		return t, false, true
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, true, true
+	}
+	return time.Time{}, false, false
+}
+
+// timelineEvent works out when an item happened. Typed fields win over
+// metadata, which wins over the capture time of the item's photo.
+func timelineEvent(item models.BoardItem) (TimelineEvent, bool) {
+	event := TimelineEvent{ItemID: item.ID, Type: item.Type, Title: timelineTitle(item)}
+	if item.Redacted {
+		return event, false
+	}
+
+	var fields timelineItem
+	var style struct {
+		Metadata timelineItem `json:"metadata"`
+	}
+	if len(item.Fields) > 0 {
+		json.Unmarshal(item.Fields, &fields)
+	}
+	if len(item.Style) > 0 {
+		json.Unmarshal(item.Style, &style)
+	}
+	var candidates []timelineItem
+	for _, c := range []timelineItem{fields, style.Metadata} {
+		if c.Timestamp != "" {
+			candidates = append(candidates, c)
+		}
+	}
+	if fields.Date != "" {
+		candidates = append(candidates, timelineItem{Timestamp: fields.Date})
+	}
+	if item.EvidenceMetadata != nil && item.EvidenceMetadata.CapturedAt != "" {
+		candidates = append(candidates, timelineItem{Timestamp: item.EvidenceMetadata.CapturedAt})
+	}
+
+	for _, c := range candidates {
+		start, allDay, ok := parseTimelineTime(c.Timestamp)
+		if !ok {
+			continue
+		}
+		event.Start = start
+		event.AllDay = allDay
+		event.Approximate = c.Approximate
+		if end, endAllDay, ok := parseTimelineTime(c.End); ok && !end.Before(start) {
+			if allDay && !endAllDay {
+				event.AllDay = false
+			}
+			event.End = &end
+		}
+		return event, true
+	}
+	return event, false
+}
+
+// timelineTitle is the first line of an item's content, or its type's title
+func timelineTitle(item models.BoardItem) string {
+	for _, line := range strings.Split(html.UnescapeString(item.Content), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			if runes := []rune(line); len(runes) > 100 {
+				line = string(runes[:99]) + "…"
+			}
+			return line
+		}
+	}
+	if t, ok := itemtype.Lookup(item.Type); ok {
+		return t.Title
+	}
+	return item.Type
+}
+
+// typeLanes puts each event in the lane of its item type, in the order of
+// the item type registry
+func typeLanes(events []TimelineEvent) []TimelineLane {
+	counts := make(map[string]int)
+	for i := range events {
+		events[i].Lanes = []string{events[i].Type}
+		counts[events[i].Type]++
+	}
+	var lanes []TimelineLane
+	for _, t := range itemtype.All() {
+		if counts[t.Name] > 0 {
+			lanes = append(lanes, TimelineLane{Key: t.Name, Label: t.Title, Count: counts[t.Name]})
+			delete(counts, t.Name)
+		}
+	}
+	// Types no longer registered go last
+	rest := make([]string, 0, len(counts))
+	for name := range counts {
+		rest = append(rest, name)
+	}
+	sort.Strings(rest)
+	for _, name := range rest {
+		lanes = append(lanes, TimelineLane{Key: name, Label: name, Count: counts[name]})
+	}
+	return lanes
+}
+
+// tagLanes puts each event in the lane of every tag on its item, board tags
+// before personal ones. Untagged events share a lane.
+func tagLanes(events []TimelineEvent, tags *BoardTags) []TimelineLane {
+	itemTags := make(map[uuid.UUID][]uuid.UUID)
+	for _, a := range tags.Assignments {
+		if a.TargetType == models.TagTargetItem {
+			itemTags[a.TargetID] = append(itemTags[a.TargetID], a.TagID)
+		}
+	}
+	lanes := make([]TimelineLane, 0, len(tags.Tags)+1)
+	index := make(map[uuid.UUID]int, len(tags.Tags))
+	for _, tag := range tags.Tags {
+		index[tag.ID] = len(lanes)
+		lanes = append(lanes, TimelineLane{Key: tag.ID.String(), Label: tag.Name, Color: tag.Color})
+	}
+	untagged := TimelineLane{Key: untaggedLane, Label: "Untagged"}
+	for i := range events {
+		events[i].Lanes = []string{}
+		for _, tagID := range itemTags[events[i].ItemID] {
+			if n, ok := index[tagID]; ok {
+				lanes[n].Count++
+				events[i].Lanes = append(events[i].Lanes, lanes[n].Key)
+			}
+		}
+		if len(events[i].Lanes) == 0 {
+			untagged.Count++
+			events[i].Lanes = append(events[i].Lanes, untagged.Key)
+		}
+	}
+	return append(lanes, untagged)
+}
+
+// connectionLanes puts events whose items are linked by any chain of
+// connections, through dated items or not, in one swimlane. Lanes are
+// ordered and labelled by their first event; events linked to no other
+// event share a lane. Only connections of relationshipTypes are followed
+// when any are given.
+func connectionLanes(events []TimelineEvent, board *models.Board, relationshipTypes []string) []TimelineLane {
+	followed := make(map[string]bool, len(relationshipTypes))
+	for _, t := range relationshipTypes {
+		followed[t] = true
+	}
+	nodes := make([]uuid.UUID, len(board.Items))
+	for i, item := range board.Items {
+		nodes[i] = item.ID
+	}
+	var edges [][2]uuid.UUID
+	for _, conn := range board.Connections {
+		if len(followed) > 0 && (conn.Redacted || !followed[conn.RelationshipType]) {
+			continue
+		}
+		edges = append(edges, [2]uuid.UUID{conn.FromItemID, conn.ToItemID})
+	}
+	component := make(map[uuid.UUID]int, len(nodes))
+	for n, ids := range graph.New(nodes, edges).Components() {
+		for _, id := range ids {
+			component[id] = n
+		}
+	}
+
+	size := make(map[int]int)
+	for _, event := range events {
+		size[component[event.ItemID]]++
+	}
+	var lanes []TimelineLane
+	index := make(map[int]int)
+	unconnected := TimelineLane{Key: unconnectedLane, Label: "Unconnected"}
+	// Events are chronological, so lanes come out ordered by their first event
+	for i := range events {
+		n := component[events[i].ItemID]
+		if size[n] < 2 {
+			unconnected.Count++
+			events[i].Lanes = []string{unconnected.Key}
+			continue
+		}
+		if _, ok := index[n]; !ok {
+			index[n] = len(lanes)
+			lanes = append(lanes, TimelineLane{Key: fmt.Sprintf("chain-%d", len(lanes)+1), Label: events[i].Title})
+		}
+		lane := &lanes[index[n]]
+		lane.Count++
+		events[i].Lanes = []string{lane.Key}
+	}
+	return append(lanes, unconnected)
+}
+
+// renderICS writes a timeline as an iCalendar (RFC 5545) calendar.
+// Approximate events are marked tentative.
+func renderICS(board *models.Board, timeline *Timeline, labels map[string]string, generatedAt time.Time) []byte {
+	content := make(map[uuid.UUID]string, len(board.Items))
+	for _, item := range board.Items {
+		content[item.ID] = strings.TrimSpace(html.UnescapeString(item.Content))
+	}
+
+	var buf bytes.Buffer
+	line := func(name, value string) {
+		writeICSLine(&buf, name+":"+value)
+	}
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//Evidence Wall//Timeline//EN")
+	line("CALSCALE", "GREGORIAN")
+	line("X-WR-CALNAME", icsText(html.UnescapeString(board.Title)))
+	stamp := generatedAt.UTC().Format("20060102T150405Z")
+	for _, event := range timeline.Events {
+		line("BEGIN", "VEVENT")
+		line("UID", event.ItemID.String()+"@evidence-wall")
+		line("DTSTAMP", stamp)
+		if event.AllDay {
+			line("DTSTART;VALUE=DATE", event.Start.Format("20060102"))
+			if event.End != nil {
+				// DTEND of an all-day event is the day after it ends
+				line("DTEND;VALUE=DATE", event.End.AddDate(0, 0, 1).Format("20060102"))
+			}
+		} else {
+			line("DTSTART", event.Start.Format("20060102T150405Z"))
+			if event.End != nil {
+				line("DTEND", event.End.Format("20060102T150405Z"))
+			}
+		}
+		line("SUMMARY", icsText(event.Title))
+		if text := content[event.ItemID]; text != "" {
+			line("DESCRIPTION", icsText(text))
+		}
+		categories := make([]string, 0, len(event.Lanes))
+		for _, key := range event.Lanes {
+			categories = append(categories, icsText(labels[key]))
+		}
+		if len(categories) > 0 {
+			line("CATEGORIES", strings.Join(categories, ","))
+		}
+		if event.Approximate {
+			line("STATUS", "TENTATIVE")
+		}
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+	return buf.Bytes()
+}
+
+// icsText escapes a TEXT value
+func icsText(value string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`).Replace(value)
+}
+
+// writeICSLine writes a content line folded at 75 octets, without splitting
+// UTF-8 sequences
+func writeICSLine(buf *bytes.Buffer, line string) {
+	width := 75
+	for len(line) > width {
+		cut := width
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		buf.WriteString(line[:cut])
+		buf.WriteString("\r\n ")
+		line = line[cut:]
+		width = 74 // The leading space counts
+	}
+	buf.WriteString(line)
+	buf.WriteString("\r\n")
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// timelineFixture is a board with a call, a meeting linked to it through a
+// suspect, a document, a photo, an undated note and a redacted event
+type timelineFixture struct {
+	svc     *BoardService
+	tagRepo *MockTagRepository
+	userID  uuid.UUID
+	board   *models.Board
+	call    models.BoardItem
+	meeting models.BoardItem
+	report  models.BoardItem
+	photo   models.BoardItem
+}
+
+func newTimelineFixture() *timelineFixture {
+	boardRepo := new(MockBoardRepository)
+	f := &timelineFixture{
+		tagRepo: new(MockTagRepository),
+		userID:  uuid.New(),
+		board:   &models.Board{ID: uuid.New(), Title: "Docks, case 117"},
+	}
+	f.call = models.BoardItem{ID: uuid.New(), Type: "event", Content: "Call to burner\nLasted 2 minutes",
+		Fields: []byte(`{"timestamp":"2024-03-15T22:41:00+01:00","end":"2024-03-15T22:43:00+01:00"}`)}
+	f.meeting = models.BoardItem{ID: uuid.New(), Type: "post-it", Content: "Meeting at the docks",
+		Style: []byte(`{"color":"#ffeb3b","metadata":{"timestamp":"2024-03-16","approximate":true}}`)}
+	f.report = models.BoardItem{ID: uuid.New(), Type: "document", Content: "Witness statement",
+		Fields: []byte(`{"title":"Statement","date":"2024-03-01"}`)}
+	f.photo = models.BoardItem{ID: uuid.New(), Type: "post-it", Content: "CCTV still",
+		EvidenceMetadata: &models.EvidenceMetadata{CapturedAt: "2024-03-20T08:00:00"}}
+	suspect := models.BoardItem{ID: uuid.New(), Type: "suspect-card", Content: "John Smith"}
+	redacted := models.BoardItem{ID: uuid.New(), Type: "event", Classification: models.ClassificationSecret, Redacted: true}
+	f.board.Items = []models.BoardItem{f.photo, f.meeting, f.call, suspect, f.report, redacted}
+	f.board.Connections = []models.BoardConnection{
+		{ID: uuid.New(), FromItemID: f.call.ID, ToItemID: suspect.ID, RelationshipType: "called"},
+		{ID: uuid.New(), FromItemID: suspect.ID, ToItemID: f.meeting.ID, RelationshipType: "was at"},
+	}
+	boardRepo.On("GetByIDWithContents", f.board.ID, f.userID).Return(f.board, models.PermissionRead, nil)
+	userRepo := new(MockBoardUserRepository)
+	userRepo.On("GetByBoardAndUser", f.board.ID, f.userID).Return(&models.BoardUser{Permission: models.PermissionRead}, nil)
+	f.svc = NewBoardService(boardRepo, userRepo, new(MockBoardItemRepository), new(MockBoardConnectionRepository), nil, f.tagRepo, nil, nil)
+	return f
+}
+
+func eventIDs(timeline *Timeline) []uuid.UUID {
+	ids := make([]uuid.UUID, len(timeline.Events))
+	for i, event := range timeline.Events {
+		ids[i] = event.ItemID
+	}
+	return ids
+}
+
+func TestBoardService_GetTimeline(t *testing.T) {
+	f := newTimelineFixture()
+
+	timeline, err := f.svc.GetTimeline(f.board.ID, f.userID, TimelineQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, TimelineByType, timeline.GroupBy)
+	assert.Equal(t, []uuid.UUID{f.report.ID, f.call.ID, f.meeting.ID, f.photo.ID}, eventIDs(timeline))
+	assert.Equal(t, 2, timeline.Undated, "the suspect and the redacted event")
+
+	report, call, meeting, photo := timeline.Events[0], timeline.Events[1], timeline.Events[2], timeline.Events[3]
+	assert.True(t, report.AllDay)
+	assert.Equal(t, "Call to burner", call.Title)
+	assert.Equal(t, time.Date(2024, 3, 15, 21, 41, 0, 0, time.UTC), call.Start)
+	assert.Equal(t, time.Date(2024, 3, 15, 21, 43, 0, 0, time.UTC), *call.End)
+	assert.True(t, meeting.AllDay)
+	assert.True(t, meeting.Approximate)
+	assert.Equal(t, time.Date(2024, 3, 20, 8, 0, 0, 0, time.UTC), photo.Start)
+	assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), *timeline.Start)
+	assert.Equal(t, photo.Start, *timeline.End)
+
+	assert.Equal(t, []TimelineLane{
+		{Key: "post-it", Label: "Post-it", Count: 2},
+		{Key: "event", Label: "Timeline event", Count: 1},
+		{Key: "document", Label: "Document", Count: 1},
+	}, timeline.Lanes)
+	assert.Equal(t, []string{"event"}, call.Lanes)
+
+	// The meeting lasts the whole day, so a window starting in it keeps it
+	timeline, err = f.svc.GetTimeline(f.board.ID, f.userID, TimelineQuery{From: "2024-03-16T12:00:00Z", To: "2024-03-16"})
+	assert.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{f.meeting.ID}, eventIDs(timeline))
+
+	timeline, err = f.svc.GetTimeline(f.board.ID, f.userID, TimelineQuery{Type: []string{"event,document"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{f.report.ID, f.call.ID}, eventIDs(timeline))
+
+	_, err = f.svc.GetTimeline(f.board.ID, f.userID, TimelineQuery{GroupBy: "color"})
+	assert.True(t, errors.Is(err, ErrInvalidInput))
+	_, err = f.svc.GetTimeline(f.board.ID, f.userID, TimelineQuery{From: "last week"})
+	assert.True(t, errors.Is(err, ErrInvalidInput))
+}
+
+func TestBoardService_GetTimeline_Lanes(t *testing.T) {
+	f := newTimelineFixture()
+
+	timeline, err := f.svc.GetTimeline(f.board.ID, f.userID, TimelineQuery{GroupBy: TimelineByConnection})
+	assert.NoError(t, err)
+	if assert.Len(t, timeline.Lanes, 2) {
+		// The call and the meeting are linked through the suspect
+		assert.Equal(t, TimelineLane{Key: "chain-1", Label: "Call to burner", Count: 2}, timeline.Lanes[0])
+		assert.Equal(t, TimelineLane{Key: unconnectedLane, Label: "Unconnected", Count: 2}, timeline.Lanes[1])
+	}
+	assert.Equal(t, []string{"chain-1"}, timeline.Events[2].Lanes)
+
+	timeline, err = f.svc.GetTimeline(f.board.ID, f.userID, TimelineQuery{GroupBy: TimelineByConnection, RelationshipType: []string{"called"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []TimelineLane{{Key: unconnectedLane, Label: "Unconnected", Count: 4}}, timeline.Lanes)
+
+	alibi := models.Tag{ID: uuid.New(), Name: "Alibi", Color: "#00ff00", Scope: models.TagScopeBoard, BoardID: &f.board.ID}
+	unused := models.Tag{ID: uuid.New(), Name: "Unused", Scope: models.TagScopeUser, OwnerID: &f.userID}
+	f.tagRepo.On("ListByBoard", f.board.ID).Return([]models.Tag{alibi}, nil)
+	f.tagRepo.On("ListByOwner", f.userID).Return([]models.Tag{unused}, nil)
+	f.tagRepo.On("ListAssignments", f.board.ID, []uuid.UUID{alibi.ID, unused.ID}).Return([]models.TagAssignment{
+		{TagID: alibi.ID, TargetID: f.call.ID, TargetType: models.TagTargetItem},
+		{TagID: alibi.ID, TargetID: f.board.ID, TargetType: models.TagTargetBoard},
+	}, nil)
+
+	timeline, err = f.svc.GetTimeline(f.board.ID, f.userID, TimelineQuery{GroupBy: TimelineByTag})
+	assert.NoError(t, err)
+	assert.Equal(t, []TimelineLane{
+		{Key: alibi.ID.String(), Label: "Alibi", Color: "#00ff00", Count: 1},
+		{Key: untaggedLane, Label: "Untagged", Count: 3},
+	}, timeline.Lanes)
+	assert.Equal(t, []string{alibi.ID.String()}, timeline.Events[1].Lanes)
+}
+
+func TestBoardService_TimelineICS(t *testing.T) {
+	f := newTimelineFixture()
+
+	data, err := f.svc.TimelineICS(f.board.ID, f.userID, TimelineQuery{})
+	assert.NoError(t, err)
+	out := string(data)
+
+	assert.True(t, strings.HasPrefix(out, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.True(t, strings.HasSuffix(out, "END:VCALENDAR\r\n"))
+	assert.Equal(t, 4, strings.Count(out, "BEGIN:VEVENT"))
+	assert.Contains(t, out, `X-WR-CALNAME:Docks\, case 117`)
+	assert.Contains(t, out, "UID:"+f.call.ID.String()+"@evidence-wall\r\n")
+	assert.Contains(t, out, "DTSTART:20240315T214100Z\r\nDTEND:20240315T214300Z\r\n")
+	assert.Contains(t, out, `DESCRIPTION:Call to burner\nLasted 2 minutes`)
+	assert.Contains(t, out, "CATEGORIES:Timeline event\r\n")
+	assert.Contains(t, out, "DTSTART;VALUE=DATE:20240316\r\nSUMMARY:Meeting at the docks\r\n")
+	assert.Contains(t, out, "STATUS:TENTATIVE")
+	for _, line := range strings.Split(out, "\r\n") {
+		assert.LessOrEqual(t, len(line), 75)
+	}
+}
+
+func TestWriteICSLine(t *testing.T) {
+	var buf bytes.Buffer
+	long := "DESCRIPTION:" + strings.Repeat("é", 50)
+	writeICSLine(&buf, long)
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n")
+	assert.Len(t, lines, 2)
+	assert.True(t, strings.HasPrefix(lines[1], " é"), "folding does not split characters")
+	assert.Equal(t, long, lines[0]+strings.TrimPrefix(lines[1], " "))
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+)
+
+// Viewport holds the items overlapping a box of a board and the connections
+// with either end on one of them. The other end of a connection may lie
+// outside the box. Items inside collapsed frames are left out; connections
+// to them are merged into AggregatedConnections to the frames.
+type Viewport struct {
+	BBox                  models.BBox                   `json:"bbox"`
+	Items                 []models.BoardItem            `json:"items"`
+	Connections           []models.BoardConnection      `json:"connections"`
+	AggregatedConnections []models.AggregatedConnection `json:"aggregated_connections"`
+}
+
+// parseBBox parses a "minX,minY,maxX,maxY" box in board coordinates
+func parseBBox(value string) (models.BBox, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 4 {
+		return models.BBox{}, fmt.Errorf("%w: bbox must be minX,minY,maxX,maxY", ErrInvalidInput)
+	}
+	var coords [4]float64
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+			return models.BBox{}, fmt.Errorf("%w: invalid bbox coordinate %q", ErrInvalidInput, part)
+		}
+		coords[i] = v
+	}
+	box := models.BBox{MinX: coords[0], MinY: coords[1], MaxX: coords[2], MaxY: coords[3]}
+	if box.MinX > box.MaxX || box.MinY > box.MaxY {
+		return models.BBox{}, fmt.Errorf("%w: bbox minimum exceeds its maximum", ErrInvalidInput)
+	}
+	return box, nil
+}
+
+// ListViewport retrieves what is visible in a box of a board: the items
+// overlapping it, narrowed by the other item filters, and the connections
+// touching those items
+func (s *BoardService) ListViewport(boardID, userID uuid.UUID, query ItemQuery) (*Viewport, error) {
+	box, err := parseBBox(query.BBox)
+	if err != nil {
+		return nil, err
+	}
+
+	items, access, err := s.listBoardItems(boardID, userID, query)
+	if err != nil {
+		return nil, err
+	}
+
+	connections, err := s.connectionRepo.ListByBoardInBBox(boardID, box)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list connections: %w", err)
+	}
+	hidden, err := s.collapsedFrames(boardID, access)
+	if err != nil {
+		return nil, err
+	}
+
+	// A collapsed frame in view stands in for its contents, wherever they lie
+	inBox := make(map[uuid.UUID]bool, len(items))
+	for _, item := range items {
+		inBox[item.ID] = true
+	}
+	var inside []uuid.UUID
+	for id, frameID := range hidden {
+		if inBox[frameID] {
+			inside = append(inside, id)
+		}
+	}
+	if len(inside) > 0 {
+		more, err := s.connectionRepo.ListByItems(boardID, inside)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list connections: %w", err)
+		}
+		connections = append(connections, more...)
+	}
+
+	// Ends outside the box may be redacted too
+	if err := access.loadClassified(); err != nil {
+		return nil, err
+	}
+	connections = access.redactConnections(connections)
+
+	seen := make(map[uuid.UUID]bool, len(connections))
+	unique := make([]models.BoardConnection, 0, len(connections))
+	for _, conn := range connections {
+		if !seen[conn.ID] && access.canSeeConnection(&conn) {
+			seen[conn.ID] = true
+			unique = append(unique, conn)
+		}
+	}
+
+	viewport := &Viewport{BBox: box}
+	viewport.Items, viewport.Connections, viewport.AggregatedConnections = collapseFrames(items, unique, hidden)
+	return viewport, nil
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"evidence-wall/shared/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBBox(t *testing.T) {
+	box, err := parseBBox("-100.5, 0,1920,1080")
+	assert.NoError(t, err)
+	assert.Equal(t, models.BBox{MinX: -100.5, MinY: 0, MaxX: 1920, MaxY: 1080}, box)
+
+	for _, value := range []string{"", "0,0,10", "0,0,10,x", "10,0,0,10", "0,NaN,10,10", "0,0,Inf,10"} {
+		_, err := parseBBox(value)
+		assert.True(t, errors.Is(err, ErrInvalidInput), value)
+	}
+}
+
+func TestBoardService_ListViewport(t *testing.T) {
+	userID := uuid.New()
+	boardID := uuid.New()
+	note := models.BoardItem{ID: uuid.New(), BoardID: boardID, Type: models.ItemTypeNote}
+	suspect := models.BoardItem{ID: uuid.New(), BoardID: boardID, Type: string(models.ItemTypeSuspectCard)}
+	offscreen := uuid.New()
+	box := models.BBox{MinX: 0, MinY: 0, MaxX: 1920, MaxY: 1080}
+
+	mockBoardRepo := new(MockBoardRepository)
+	mockItemRepo := new(MockBoardItemRepository)
+	mockConnRepo := new(MockBoardConnectionRepository)
+	svc := NewBoardService(mockBoardRepo, new(MockBoardUserRepository), mockItemRepo, mockConnRepo, nil, nil, nil, nil)
+
+	mockBoardRepo.On("GetPermission", boardID, userID).Return(true, models.PermissionRead, nil)
+	mockItemRepo.On("ListByBoardInBBox", boardID, box).Return([]models.BoardItem{note, suspect}, nil)
+	mockItemRepo.On("ListFrameMembership", boardID).Return([]models.BoardItem{}, nil)
+	mockItemRepo.On("ListClassified", boardID).Return([]models.BoardItem{}, nil)
+	toNote := models.BoardConnection{ID: uuid.New(), FromItemID: offscreen, ToItemID: note.ID}
+	toSuspect := models.BoardConnection{ID: uuid.New(), FromItemID: suspect.ID, ToItemID: offscreen}
+	mockConnRepo.On("ListByBoardInBBox", boardID, box).Return([]models.BoardConnection{toNote, toSuspect}, nil)
+
+	viewport, err := svc.ListViewport(boardID, userID, ItemQuery{BBox: "0,0,1920,1080"})
+	assert.NoError(t, err)
+	assert.Equal(t, box, viewport.BBox)
+	assert.Len(t, viewport.Items, 2)
+	assert.Len(t, viewport.Connections, 2)
+
+	// Connections only to items the filters leave out are dropped too
+	viewport, err = svc.ListViewport(boardID, userID, ItemQuery{BBox: "0,0,1920,1080", Type: []string{models.ItemTypeNote}})
+	assert.NoError(t, err)
+	if assert.Len(t, viewport.Items, 1) {
+		assert.Equal(t, note.ID, viewport.Items[0].ID)
+	}
+	if assert.Len(t, viewport.Connections, 1) {
+		assert.Equal(t, toNote.ID, viewport.Connections[0].ID)
+	}
+	mockItemRepo.AssertNotCalled(t, "ListByBoard", boardID)
+
+	_, err = svc.ListViewport(boardID, userID, ItemQuery{BBox: "1,2,3"})
+	assert.True(t, errors.Is(err, ErrInvalidInput))
+}
@@ -313,9 +313,15 @@ func subscribeToRedis(rdb *redis.Client, hub *Hub) {
 
 	for msg := range pubsub.Channel() {
 
-		// Extract board ID from channel name (board:uuid)
+		// Extract board ID from channel name (board:uuid), and the user ID
+		// from updates meant for one user only (board:uuid:user:uuid)
 		parts := strings.Split(msg.Channel, ":")
-		if len(parts) != 2 {
+		var userID string
+		switch {
+		case len(parts) == 2:
+		case len(parts) == 4 && parts[2] == "user":
+			userID = parts[3]
+		default:
 			log.Printf("Invalid channel format: %s", msg.Channel)
 			continue
 		}
@@ -338,6 +344,9 @@ func subscribeToRedis(rdb *redis.Client, hub *Hub) {
 		hub.mutex.RLock()
 		if room, exists := hub.boardRooms[boardID]; exists {
 			for client := range room {
+				if userID != "" && client.userID != userID {
+					continue
+				}
 				select {
 				case client.send <- messageBytes:
 				default:
@@ -69,6 +69,12 @@ func AutoMigrate(db *gorm.DB) error {
 		&models.BoardUser{},
 		&models.BoardItem{},
 		&models.BoardConnection{},
+		&models.BoardTemplate{},
+		&models.Attachment{},
+		&models.CustodyEvent{},
+		&models.Tag{},
+		&models.TagAssignment{},
+		&models.Layer{},
 	)
 
 	if err != nil {
@@ -79,6 +85,61 @@ func AutoMigrate(db *gorm.DB) error {
 	return nil
 }
 
+// ProtectCustodyLog makes the custody_events table append-only: updates and
+// deletes are rejected by the database itself, not just left out of the
+// application code.
+func ProtectCustodyLog(db *gorm.DB) error {
+	statements := []string{
+		`CREATE OR REPLACE FUNCTION reject_custody_change() RETURNS trigger AS $$
+		BEGIN
+			RAISE EXCEPTION 'custody_events is append-only';
+		END;
+		$$ LANGUAGE plpgsql`,
+		"DROP TRIGGER IF EXISTS custody_events_append_only ON custody_events",
+		`CREATE TRIGGER custody_events_append_only
+		BEFORE UPDATE OR DELETE ON custody_events
+		FOR EACH ROW EXECUTE FUNCTION reject_custody_change()`,
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			return fmt.Errorf("failed to protect custody log: %w", err)
+		}
+	}
+	return nil
+}
+
+// CreateSearchIndexes adds generated tsvector columns to boards and items,
+// with GIN indexes for full-text search. The english configuration stems
+// words, so "vans" finds "van". Titles and item content weigh more than
+// descriptions and structured fields, which weigh more than metadata taken
+// from attached photos.
+func CreateSearchIndexes(db *gorm.DB) error {
+	log.Println("Creating search indexes...")
+
+	statements := []string{
+		`ALTER TABLE boards ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+			setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+			setweight(to_tsvector('english', coalesce(description, '')), 'B')
+		) STORED`,
+		`ALTER TABLE board_items ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+			setweight(to_tsvector('english', coalesce(content, '')), 'A') ||
+			setweight(jsonb_to_tsvector('english', coalesce(fields, '{}'), '["string", "numeric"]'), 'B') ||
+			setweight(jsonb_to_tsvector('english', coalesce(custom_values, '{}'), '["string", "numeric"]'), 'B') ||
+			setweight(jsonb_to_tsvector('english', coalesce(evidence_metadata, '{}'), '["string"]'), 'C')
+		) STORED`,
+		"CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_boards_search ON boards USING GIN (search_vector)",
+		"CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_board_items_search ON board_items USING GIN (search_vector)",
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			return fmt.Errorf("failed to create search index: %w", err)
+		}
+	}
+
+	log.Println("Search indexes created successfully")
+	return nil
+}
+
 // CreateIndexes creates additional database indexes for performance
 func CreateIndexes(db *gorm.DB) error {
 	log.Println("Creating database indexes...")
@@ -90,9 +151,12 @@ func CreateIndexes(db *gorm.DB) error {
 		"CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_board_users_board_id ON board_users(board_id)",
 		"CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_board_users_user_id ON board_users(user_id)",
 		"CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_board_items_board_id ON board_items(board_id)",
+		// Item bounding boxes, for viewport queries on large boards
+		"CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_board_items_bbox ON board_items USING GIST (box(point(x, y), point(x + width, y + height)))",
 		"CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_board_connections_board_id ON board_connections(board_id)",
 		"CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_board_connections_from_item_id ON board_connections(from_item_id)",
 		"CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_board_connections_to_item_id ON board_connections(to_item_id)",
+		"CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_board_templates_scope ON board_templates(scope)",
 	}
 
 	for _, index := range indexes {
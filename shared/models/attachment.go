@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Attachment is a file (photo, PDF, ...) pinned to a board item. The content
+// lives in blob storage under StorageKey.
+type Attachment struct {
+	ID          uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	BoardID     uuid.UUID `json:"board_id" gorm:"type:uuid;not null;index"`
+	ItemID      uuid.UUID `json:"item_id" gorm:"type:uuid;not null;index"`
+	Filename    string    `json:"filename" gorm:"size:255;not null"`
+	ContentType string    `json:"content_type" gorm:"size:100;not null"` // Sniffed from the content, not taken from the client
+	Size        int64     `json:"size" gorm:"not null"`
+	SHA256      string    `json:"sha256" gorm:"size:64"` // Hex digest taken on ingest, for chain of custody
+	StorageKey  string    `json:"-" gorm:"size:500;not null"`
+
+	// Previews are made in the background for photos; Thumbnails lists the
+	// sizes available, by longest side in pixels
+	PreviewStatus PreviewStatus  `json:"preview_status,omitempty" gorm:"size:20;index"`
+	Thumbnails    []int          `json:"thumbnails,omitempty" gorm:"type:jsonb;serializer:json"`
+	Metadata      *ImageMetadata `json:"metadata,omitempty" gorm:"type:jsonb;serializer:json"`
+
+	UploadedBy uuid.UUID      `json:"uploaded_by" gorm:"type:uuid;not null"`
+	CreatedAt  time.Time      `json:"created_at"`
+	DeletedAt  gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// PreviewStatus tracks thumbnail generation for an attachment
+type PreviewStatus string
+
+const (
+	PreviewPending     PreviewStatus = "pending"
+	PreviewReady       PreviewStatus = "ready"
+	PreviewFailed      PreviewStatus = "failed"
+	PreviewUnsupported PreviewStatus = "unsupported" // An image format previews cannot be made from
+)
+
+// ImageMetadata is read from a photo's EXIF block
+type ImageMetadata struct {
+	Width       int      `json:"width"`
+	Height      int      `json:"height"`
+	CapturedAt  string   `json:"captured_at,omitempty"` // RFC 3339, without a zone when the camera recorded none
+	Latitude    *float64 `json:"lat,omitempty"`
+	Longitude   *float64 `json:"lon,omitempty"`
+	Altitude    *float64 `json:"altitude,omitempty"`
+	Make        string   `json:"make,omitempty"`
+	Model       string   `json:"model,omitempty"`
+	Orientation int      `json:"orientation,omitempty"`
+}
+
+// BeforeCreate hook to generate UUID
+func (a *Attachment) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
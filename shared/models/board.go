@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -27,20 +28,61 @@ const (
 
 // Board represents an evidence board
 type Board struct {
-	ID          uuid.UUID       `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	Title       string          `json:"title" gorm:"not null"`
-	Description string          `json:"description"`
-	Visibility  BoardVisibility `json:"visibility" gorm:"default:'private'"`
-	OwnerID     uuid.UUID       `json:"owner_id" gorm:"type:uuid;not null"`
-	CreatedAt   time.Time       `json:"created_at"`
-	UpdatedAt   time.Time       `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt  `json:"-" gorm:"index"`
+	ID            uuid.UUID       `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Title         string          `json:"title" gorm:"not null"`
+	Description   string          `json:"description"`
+	Visibility    BoardVisibility `json:"visibility" gorm:"default:'private'"`
+	OwnerID       uuid.UUID       `json:"owner_id" gorm:"type:uuid;not null"`
+	ParentBoardID *uuid.UUID      `json:"parent_board_id,omitempty" gorm:"type:uuid;index"` // Set when forked from another board
+	CustomFields  []CustomField   `json:"custom_fields,omitempty" gorm:"type:jsonb;serializer:json"`
+	// Classification marks the board and is the default for its new items
+	Classification ClassificationLevel `json:"classification,omitempty" gorm:"size:20"`
+	CreatedAt      time.Time           `json:"created_at"`
+	UpdatedAt      time.Time           `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt      `json:"-" gorm:"index"`
 
 	// Relationships
 	Owner       User              `json:"owner,omitempty" gorm:"foreignKey:OwnerID"`
 	Users       []BoardUser       `json:"users,omitempty" gorm:"foreignKey:BoardID"`
 	Items       []BoardItem       `json:"items,omitempty" gorm:"foreignKey:BoardID"`
 	Connections []BoardConnection `json:"connections,omitempty" gorm:"foreignKey:BoardID"`
+	Layers      []Layer           `json:"layers,omitempty" gorm:"foreignKey:BoardID"`
+}
+
+// CustomFieldType is the value type of a custom field
+type CustomFieldType string
+
+const (
+	CustomFieldText   CustomFieldType = "text"
+	CustomFieldNumber CustomFieldType = "number"
+	CustomFieldDate   CustomFieldType = "date" // YYYY-MM-DD
+	CustomFieldEnum   CustomFieldType = "enum"
+	CustomFieldUser   CustomFieldType = "user" // ID of a user with access to the board
+)
+
+// CustomField is a field board admins define for the items of their board,
+// e.g. "case number" or "informant reliability". Values are stored in the
+// item's custom values under Key.
+type CustomField struct {
+	Key       string          `json:"key"`
+	Label     string          `json:"label"`
+	Type      CustomFieldType `json:"type"`
+	ItemTypes []string        `json:"item_types,omitempty"` // Empty applies to every item type
+	Options   []string        `json:"options,omitempty"`    // Allowed values of enum fields
+	Required  bool            `json:"required,omitempty"`
+}
+
+// AppliesTo reports whether the field is defined for items of the given type
+func (f CustomField) AppliesTo(itemType string) bool {
+	if len(f.ItemTypes) == 0 {
+		return true
+	}
+	for _, t := range f.ItemTypes {
+		if t == itemType {
+			return true
+		}
+	}
+	return false
 }
 
 // BoardUser represents the many-to-many relationship between boards and users
@@ -49,8 +91,11 @@ type BoardUser struct {
 	BoardID    uuid.UUID       `json:"board_id" gorm:"type:uuid;not null"`
 	UserID     uuid.UUID       `json:"user_id" gorm:"type:uuid;not null"`
 	Permission PermissionLevel `json:"permission" gorm:"not null"`
-	CreatedAt  time.Time       `json:"created_at"`
-	UpdatedAt  time.Time       `json:"updated_at"`
+	// Clearance is the most sensitive classification the user may see on
+	// the board; empty is unclassified. Admins see everything.
+	Clearance ClassificationLevel `json:"clearance,omitempty" gorm:"size:20"`
+	CreatedAt time.Time           `json:"created_at"`
+	UpdatedAt time.Time           `json:"updated_at"`
 
 	// Relationships
 	Board Board `json:"board,omitempty" gorm:"foreignKey:BoardID"`
@@ -63,25 +108,61 @@ type ItemType string
 const (
 	ItemTypePostIt      ItemType = "post-it"
 	ItemTypeSuspectCard ItemType = "suspect-card"
+	ItemTypeLocation    ItemType = "location"
+	ItemTypeArea        ItemType = "area" // A region on the map, outlined by a polygon
+	ItemTypeEvent       ItemType = "event"
+	ItemTypeDocument    ItemType = "document"
+	ItemTypePhone       ItemType = "phone"
+	ItemTypeVehicle     ItemType = "vehicle"
+	ItemTypeFrame       ItemType = "frame" // Groups other items, see BoardItem.ParentID
 )
 
-// BoardItem represents an item on the board (post-it note or suspect card)
+// ItemTypeNote is the generic item type sent by the frontend; the concrete
+// variant (any registered item type, post-it by default) is carried in
+// metadata.variant.
+const ItemTypeNote = "note"
+
+// EvidenceMetadata holds facts about an item taken from its evidence rather
+// than entered by users, such as where and when an attached photo was taken.
+// It is separate from the style's metadata, which the frontend owns.
+type EvidenceMetadata struct {
+	CapturedAt         string     `json:"captured_at,omitempty"`
+	Latitude           *float64   `json:"lat,omitempty"`
+	Longitude          *float64   `json:"lon,omitempty"`
+	Camera             string     `json:"camera,omitempty"`
+	SourceAttachmentID *uuid.UUID `json:"source_attachment_id,omitempty"`
+}
+
+// BoardItem represents an item on the board. Fields holds the structured
+// values defined by the item type's schema (e.g. a suspect's name and date
+// of birth, or a location's coordinates); CustomValues holds the values of
+// the board's custom fields. Items inside a frame point to it with ParentID;
+// frames can be nested. LayerID places the item on one of the board's layers.
+// Items classified above a viewer's clearance are sent with Redacted set and
+// only their place on the board left.
 type BoardItem struct {
-	ID        uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	BoardID   uuid.UUID      `json:"board_id" gorm:"type:uuid;not null"`
-	Type      string         `json:"type" gorm:"not null"`
-	X         float64        `json:"x" gorm:"not null"`
-	Y         float64        `json:"y" gorm:"not null"`
-	Width     float64        `json:"width" gorm:"default:200"`
-	Height    float64        `json:"height" gorm:"default:200"`
-	Rotation  float64        `json:"rotation" gorm:"default:0"`
-	ZIndex    int            `json:"z_index" gorm:"default:1"`
-	Content   string         `json:"content"`
-	Style     []byte         `json:"style" gorm:"type:jsonb"` // JSON string for styling properties including color
-	CreatedBy uuid.UUID      `json:"created_by" gorm:"type:uuid;not null"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	ID               uuid.UUID           `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	BoardID          uuid.UUID           `json:"board_id" gorm:"type:uuid;not null"`
+	ParentID         *uuid.UUID          `json:"parent_id,omitempty" gorm:"type:uuid;index"` // The frame containing the item
+	LayerID          *uuid.UUID          `json:"layer_id,omitempty" gorm:"type:uuid;index"`  // Empty on the base layer
+	Type             string              `json:"type" gorm:"not null"`
+	X                float64             `json:"x" gorm:"not null"`
+	Y                float64             `json:"y" gorm:"not null"`
+	Width            float64             `json:"width" gorm:"default:200"`
+	Height           float64             `json:"height" gorm:"default:200"`
+	Rotation         float64             `json:"rotation" gorm:"default:0"`
+	ZIndex           int                 `json:"z_index" gorm:"default:1"`
+	Content          string              `json:"content"`
+	Style            []byte              `json:"style" gorm:"type:jsonb"` // JSON string for styling properties including color
+	Fields           json.RawMessage     `json:"fields,omitempty" gorm:"type:jsonb"`
+	CustomValues     json.RawMessage     `json:"custom_values,omitempty" gorm:"type:jsonb"`
+	EvidenceMetadata *EvidenceMetadata   `json:"evidence_metadata,omitempty" gorm:"type:jsonb;serializer:json"` // Filled in by the server from attached photos
+	Classification   ClassificationLevel `json:"classification,omitempty" gorm:"size:20;index"`                 // Empty is unclassified
+	Redacted         bool                `json:"redacted,omitempty" gorm:"-"`
+	CreatedBy        uuid.UUID           `json:"created_by" gorm:"type:uuid;not null"`
+	CreatedAt        time.Time           `json:"created_at"`
+	UpdatedAt        time.Time           `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt      `json:"-" gorm:"index"`
 
 	// Relationships
 	Board       Board             `json:"board,omitempty" gorm:"foreignKey:BoardID"`
@@ -89,17 +170,46 @@ type BoardItem struct {
 	Connections []BoardConnection `json:"connections,omitempty" gorm:"many2many:board_item_connections"`
 }
 
+// ConnectionDirection says which way a connection points
+type ConnectionDirection string
+
+const (
+	DirectionNone    ConnectionDirection = "none"
+	DirectionForward ConnectionDirection = "forward" // From the "from" item to the "to" item
+	DirectionBoth    ConnectionDirection = "both"
+)
+
+// ConfidenceLevel grades how well a relationship is established
+type ConfidenceLevel string
+
+const (
+	ConfidenceLow       ConfidenceLevel = "low"
+	ConfidenceMedium    ConfidenceLevel = "medium"
+	ConfidenceHigh      ConfidenceLevel = "high"
+	ConfidenceConfirmed ConfidenceLevel = "confirmed"
+)
+
+// RelationshipTypes are the suggested relationship types; any lower-case
+// type matching the same format is accepted
+var RelationshipTypes = []string{"knows", "called", "paid", "was at", "owns", "works for", "related to", "met"}
+
 // BoardConnection represents a connection between two board items
 type BoardConnection struct {
-	ID         uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	BoardID    uuid.UUID      `json:"board_id" gorm:"type:uuid;not null"`
-	FromItemID uuid.UUID      `json:"from_item_id" gorm:"type:uuid;not null"`
-	ToItemID   uuid.UUID      `json:"to_item_id" gorm:"type:uuid;not null"`
-	Style      string         `json:"style"` // JSON string for connection styling
-	CreatedBy  uuid.UUID      `json:"created_by" gorm:"type:uuid;not null"`
-	CreatedAt  time.Time      `json:"created_at"`
-	UpdatedAt  time.Time      `json:"updated_at"`
-	DeletedAt  gorm.DeletedAt `json:"-" gorm:"index"`
+	ID               uuid.UUID           `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	BoardID          uuid.UUID           `json:"board_id" gorm:"type:uuid;not null"`
+	FromItemID       uuid.UUID           `json:"from_item_id" gorm:"type:uuid;not null"`
+	ToItemID         uuid.UUID           `json:"to_item_id" gorm:"type:uuid;not null"`
+	LayerID          *uuid.UUID          `json:"layer_id,omitempty" gorm:"type:uuid;index"` // Empty on the base layer
+	Label            string              `json:"label" gorm:"size:200"`
+	Direction        ConnectionDirection `json:"direction" gorm:"size:10;not null;default:'none'"`
+	RelationshipType string              `json:"relationship_type" gorm:"size:50;index"` // Empty when untyped
+	Confidence       ConfidenceLevel     `json:"confidence" gorm:"size:20"`              // Empty when not assessed
+	Style            string              `json:"style"`                                  // JSON string for connection styling
+	Redacted         bool                `json:"redacted,omitempty" gorm:"-"`            // Set when an end is redacted, leaving out the label and type
+	CreatedBy        uuid.UUID           `json:"created_by" gorm:"type:uuid;not null"`
+	CreatedAt        time.Time           `json:"created_at"`
+	UpdatedAt        time.Time           `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt      `json:"-" gorm:"index"`
 
 	// Relationships
 	Board    Board     `json:"board,omitempty" gorm:"foreignKey:BoardID"`
@@ -108,6 +218,36 @@ type BoardConnection struct {
 	Creator  User      `json:"creator,omitempty" gorm:"foreignKey:CreatedBy"`
 }
 
+// ConnectionFilter narrows connection queries. Empty fields match everything;
+// multiple values in one field match any of them.
+type ConnectionFilter struct {
+	RelationshipTypes []string
+	Directions        []ConnectionDirection
+	Confidences       []ConfidenceLevel
+	ItemID            *uuid.UUID // Connections touching this item
+	Label             string     // Case-insensitive substring of the label
+}
+
+// BBox is an axis-aligned rectangle in board coordinates. Items match a
+// box when their unrotated bounds (X, Y, Width, Height) overlap it, edges
+// included.
+type BBox struct {
+	MinX float64 `json:"min_x"`
+	MinY float64 `json:"min_y"`
+	MaxX float64 `json:"max_x"`
+	MaxY float64 `json:"max_y"`
+}
+
+// AggregatedConnection stands in for the connections between two items when
+// an end is hidden in a collapsed frame. The ends are then the outermost
+// collapsed frames containing the hidden items.
+type AggregatedConnection struct {
+	FromItemID    uuid.UUID   `json:"from_item_id"`
+	ToItemID      uuid.UUID   `json:"to_item_id"`
+	Count         int         `json:"count"`
+	ConnectionIDs []uuid.UUID `json:"connection_ids"`
+}
+
 // BeforeCreate hooks
 func (b *Board) BeforeCreate(tx *gorm.DB) error {
 	if b.ID == uuid.Nil {
@@ -139,39 +279,52 @@ func (bc *BoardConnection) BeforeCreate(tx *gorm.DB) error {
 
 // BoardResponse represents the board data returned to clients
 type BoardResponse struct {
-	ID          uuid.UUID           `json:"id"`
-	Title       string              `json:"title"`
-	Description string              `json:"description"`
-	Visibility  BoardVisibility     `json:"visibility"`
-	OwnerID     uuid.UUID           `json:"owner_id"`
-	Permission  PermissionLevel     `json:"permission,omitempty"` // User's permission level
-	CreatedAt   time.Time           `json:"created_at"`
-	UpdatedAt   time.Time           `json:"updated_at"`
-	Items       []BoardItem         `json:"items,omitempty"`
-	Connections []BoardConnection   `json:"connections,omitempty"`
-	Users       []BoardUserResponse `json:"users,omitempty"`
+	ID             uuid.UUID           `json:"id"`
+	Title          string              `json:"title"`
+	Description    string              `json:"description"`
+	Visibility     BoardVisibility     `json:"visibility"`
+	OwnerID        uuid.UUID           `json:"owner_id"`
+	ParentBoardID  *uuid.UUID          `json:"parent_board_id,omitempty"`
+	CustomFields   []CustomField       `json:"custom_fields,omitempty"`
+	Classification ClassificationLevel `json:"classification,omitempty"`
+	Clearance      ClassificationLevel `json:"clearance,omitempty"`  // User's clearance, when below the board's contents
+	Permission     PermissionLevel     `json:"permission,omitempty"` // User's permission level
+	CreatedAt      time.Time           `json:"created_at"`
+	UpdatedAt      time.Time           `json:"updated_at"`
+	Items          []BoardItem         `json:"items,omitempty"`
+	Connections    []BoardConnection   `json:"connections,omitempty"`
+	Layers         []Layer             `json:"layers,omitempty"` // The layers the user can see
+	Users          []BoardUserResponse `json:"users,omitempty"`
+
+	// Connections into collapsed frames, whose contents are left out of Items
+	AggregatedConnections []AggregatedConnection `json:"aggregated_connections,omitempty"`
 }
 
 // BoardUserResponse represents board user data returned to clients
 type BoardUserResponse struct {
-	User       UserResponse    `json:"user"`
-	Permission PermissionLevel `json:"permission"`
-	CreatedAt  time.Time       `json:"created_at"`
+	User       UserResponse        `json:"user"`
+	Permission PermissionLevel     `json:"permission"`
+	Clearance  ClassificationLevel `json:"clearance,omitempty"`
+	CreatedAt  time.Time           `json:"created_at"`
 }
 
 // ToResponse converts Board to BoardResponse
 func (b *Board) ToResponse(userPermission PermissionLevel) BoardResponse {
 	response := BoardResponse{
-		ID:          b.ID,
-		Title:       b.Title,
-		Description: b.Description,
-		Visibility:  b.Visibility,
-		OwnerID:     b.OwnerID,
-		Permission:  userPermission,
-		CreatedAt:   b.CreatedAt,
-		UpdatedAt:   b.UpdatedAt,
-		Items:       b.Items,
-		Connections: b.Connections,
+		ID:             b.ID,
+		Title:          b.Title,
+		Description:    b.Description,
+		Visibility:     b.Visibility,
+		OwnerID:        b.OwnerID,
+		ParentBoardID:  b.ParentBoardID,
+		CustomFields:   b.CustomFields,
+		Classification: b.Classification,
+		Permission:     userPermission,
+		CreatedAt:      b.CreatedAt,
+		UpdatedAt:      b.UpdatedAt,
+		Items:          b.Items,
+		Connections:    b.Connections,
+		Layers:         b.Layers,
 	}
 
 	// Convert users
@@ -179,6 +332,7 @@ func (b *Board) ToResponse(userPermission PermissionLevel) BoardResponse {
 		response.Users = append(response.Users, BoardUserResponse{
 			User:       bu.User.ToResponse(),
 			Permission: bu.Permission,
+			Clearance:  bu.Clearance,
 			CreatedAt:  bu.CreatedAt,
 		})
 	}
@@ -0,0 +1,51 @@
+package models
+
+// ClassificationLevel marks how sensitive a board or item is, and is also
+// used for how much a board user is cleared to see
+type ClassificationLevel string
+
+const (
+	ClassificationUnclassified ClassificationLevel = "unclassified"
+	ClassificationRestricted   ClassificationLevel = "restricted"
+	ClassificationConfidential ClassificationLevel = "confidential"
+	ClassificationSecret       ClassificationLevel = "secret"
+	ClassificationTopSecret    ClassificationLevel = "top_secret"
+)
+
+// ClassificationLevels lists the levels from least to most sensitive
+var ClassificationLevels = []ClassificationLevel{
+	ClassificationUnclassified,
+	ClassificationRestricted,
+	ClassificationConfidential,
+	ClassificationSecret,
+	ClassificationTopSecret,
+}
+
+// Rank orders levels, 0 being unclassified. An empty level is unclassified.
+func (c ClassificationLevel) Rank() int {
+	for i, level := range ClassificationLevels {
+		if c == level {
+			return i
+		}
+	}
+	return 0
+}
+
+// Valid reports whether the level is one of ClassificationLevels or empty
+func (c ClassificationLevel) Valid() bool {
+	if c == "" {
+		return true
+	}
+	for _, level := range ClassificationLevels {
+		if c == level {
+			return true
+		}
+	}
+	return false
+}
+
+// Covers reports whether a clearance of this level may see material of the
+// given classification
+func (c ClassificationLevel) Covers(classification ClassificationLevel) bool {
+	return c.Rank() >= classification.Rank()
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CustodyAction is what happened to a piece of evidence
+type CustodyAction string
+
+const (
+	CustodyUploaded   CustodyAction = "uploaded"
+	CustodyDownloaded CustodyAction = "downloaded"
+	CustodyViewed     CustodyAction = "viewed"
+	CustodyVerified   CustodyAction = "verified"
+	CustodyDeleted    CustodyAction = "deleted"
+)
+
+// CustodyEvent is one append-only entry of an item's chain of custody. Events
+// of an item are numbered by Seq and linked by hash: Hash covers the event's
+// content and PrevHash, the Hash of the previous event, so any edit or removal
+// breaks the chain.
+type CustodyEvent struct {
+	ID           uuid.UUID     `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	BoardID      uuid.UUID     `json:"board_id" gorm:"type:uuid;not null;index"`
+	ItemID       uuid.UUID     `json:"item_id" gorm:"type:uuid;not null;uniqueIndex:idx_custody_item_seq"`
+	Seq          int64         `json:"seq" gorm:"not null;uniqueIndex:idx_custody_item_seq"`
+	AttachmentID uuid.UUID     `json:"attachment_id" gorm:"type:uuid;not null;index"`
+	Action       CustodyAction `json:"action" gorm:"size:20;not null"`
+	UserID       uuid.UUID     `json:"user_id" gorm:"type:uuid;not null"`
+	SHA256       string        `json:"sha256" gorm:"size:64"` // Digest of the file at the time of the event
+	ClientIP     string        `json:"client_ip,omitempty" gorm:"size:45"`
+	Detail       string        `json:"detail,omitempty" gorm:"size:500"`
+	PrevHash     string        `json:"prev_hash" gorm:"size:64"`
+	Hash         string        `json:"hash" gorm:"size:64;not null"`
+	CreatedAt    time.Time     `json:"created_at"`
+}
+
+// BeforeCreate hook to generate UUID
+func (e *CustodyEvent) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
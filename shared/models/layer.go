@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Layer is a named stratum of a board's items and connections that can be
+// shown or hidden, locked against edits and restricted to some users. Items
+// and connections without a layer are on the board's base layer, which
+// everyone with access to the board sees.
+type Layer struct {
+	ID            uuid.UUID       `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	BoardID       uuid.UUID       `json:"board_id" gorm:"type:uuid;not null;index"`
+	Name          string          `json:"name" gorm:"not null;size:100"`
+	Position      int             `json:"position" gorm:"not null;default:0"` // Stacking order, bottom first
+	Visible       bool            `json:"visible" gorm:"not null"`
+	Locked        bool            `json:"locked" gorm:"not null;default:false"`                 // Contents cannot be changed while locked
+	MinPermission PermissionLevel `json:"min_permission,omitempty" gorm:"size:20"`              // Empty lets every board user see the layer
+	UserIDs       []uuid.UUID     `json:"user_ids,omitempty" gorm:"type:jsonb;serializer:json"` // When set, only these users see the layer
+	CreatedBy     uuid.UUID       `json:"created_by" gorm:"type:uuid;not null"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
+
+// Restricted reports whether some users of the board cannot see the layer
+func (l *Layer) Restricted() bool {
+	return len(l.UserIDs) > 0 || (l.MinPermission != "" && l.MinPermission != PermissionRead)
+}
+
+// BeforeCreate hook to generate UUID
+func (l *Layer) BeforeCreate(tx *gorm.DB) error {
+	if l.ID == uuid.Nil {
+		l.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package models
+
+import "github.com/google/uuid"
+
+// SearchFilter narrows a full-text search. Query uses web search syntax:
+// "quoted phrases", OR, and -excluded words. Empty BoardIDs and ItemTypes
+// match everything.
+type SearchFilter struct {
+	Query     string
+	BoardIDs  []uuid.UUID
+	ItemTypes []string
+	Offset    int
+	Limit     int
+}
+
+// Snippets wrap matched terms in these private-use characters; the service
+// replaces them with markup once the rest of the text is escaped
+const (
+	HighlightStart = "\uE000"
+	HighlightStop  = "\uE001"
+)
+
+// BoardSearchHit is a board whose title or description matches a search
+type BoardSearchHit struct {
+	BoardID uuid.UUID `json:"board_id"`
+	Title   string    `json:"title"`
+	Snippet string    `json:"snippet"`
+	Rank    float64   `json:"rank"`
+}
+
+// ItemSearchHit is an item whose content, fields or metadata match a search
+type ItemSearchHit struct {
+	ItemID     uuid.UUID `json:"item_id"`
+	BoardID    uuid.UUID `json:"board_id"`
+	BoardTitle string    `json:"board_title"`
+	Type       string    `json:"type"`
+	Snippet    string    `json:"snippet"`
+	Rank       float64   `json:"rank"`
+}
+
+// SearchFacet counts the matching items sharing a board or an item type
+type SearchFacet struct {
+	Value string `json:"value"`           // Board ID or item type
+	Label string `json:"label,omitempty"` // Board title
+	Count int64  `json:"count"`
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TagScope represents who can see and apply a tag
+type TagScope string
+
+const (
+	TagScopeUser  TagScope = "user"  // Personal tag, only visible to its owner
+	TagScopeBoard TagScope = "board" // Shared with everyone who can access the board
+)
+
+// TagTargetType is the kind of record a tag is assigned to
+type TagTargetType string
+
+const (
+	TagTargetBoard TagTargetType = "board"
+	TagTargetItem  TagTargetType = "item"
+)
+
+// Tag is a colored label for boards and items. Personal tags have an owner
+// and can be applied anywhere the owner has access; board tags belong to a
+// board and only apply to that board and its items.
+type Tag struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Name      string     `json:"name" gorm:"not null;size:50"`
+	Color     string     `json:"color" gorm:"size:7;not null"` // #rrggbb
+	Scope     TagScope   `json:"scope" gorm:"not null;default:'user';index"`
+	OwnerID   *uuid.UUID `json:"owner_id,omitempty" gorm:"type:uuid;index"` // Personal tags only
+	BoardID   *uuid.UUID `json:"board_id,omitempty" gorm:"type:uuid;index"` // Board tags only
+	CreatedBy uuid.UUID  `json:"created_by" gorm:"type:uuid;not null"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// BeforeCreate hook to generate UUID
+func (t *Tag) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
+
+// TagAssignment applies a tag to a board or an item. BoardID is the board
+// itself or the item's board, so a board's assignments can be listed at once.
+type TagAssignment struct {
+	TagID      uuid.UUID     `json:"tag_id" gorm:"type:uuid;primaryKey"`
+	TargetID   uuid.UUID     `json:"target_id" gorm:"type:uuid;primaryKey;index"`
+	TargetType TagTargetType `json:"target_type" gorm:"not null"`
+	BoardID    uuid.UUID     `json:"board_id" gorm:"type:uuid;not null;index"`
+	CreatedBy  uuid.UUID     `json:"created_by" gorm:"type:uuid;not null"`
+	CreatedAt  time.Time     `json:"created_at"`
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TemplateScope represents who can see and use a board template
+type TemplateScope string
+
+const (
+	TemplateScopeUser         TemplateScope = "user"
+	TemplateScopeOrganization TemplateScope = "organization"
+	TemplateScopeBuiltin      TemplateScope = "builtin"
+)
+
+// TemplateItem is an item blueprint inside a template. Key identifies the
+// item for connections within the same template.
+type TemplateItem struct {
+	Key          string          `json:"key"`
+	Parent       string          `json:"parent,omitempty"` // Key of the frame containing the item
+	Type         string          `json:"type"`
+	X            float64         `json:"x"`
+	Y            float64         `json:"y"`
+	Width        float64         `json:"width"`
+	Height       float64         `json:"height"`
+	Rotation     float64         `json:"rotation,omitempty"`
+	ZIndex       int             `json:"z_index"`
+	Content      string          `json:"content"`
+	Style        json.RawMessage `json:"style,omitempty"`
+	Fields       json.RawMessage `json:"fields,omitempty"`
+	CustomValues json.RawMessage `json:"custom_values,omitempty"`
+	Placeholder  bool            `json:"placeholder,omitempty"` // Item is meant to be filled in after instantiation
+}
+
+// TemplateConnection connects two template items by key
+type TemplateConnection struct {
+	From             string              `json:"from"`
+	To               string              `json:"to"`
+	Label            string              `json:"label,omitempty"`
+	Direction        ConnectionDirection `json:"direction,omitempty"`
+	RelationshipType string              `json:"relationship_type,omitempty"`
+	Confidence       ConfidenceLevel     `json:"confidence,omitempty"`
+	Style            string              `json:"style,omitempty"`
+}
+
+// TemplateContent holds the items and connections a template instantiates,
+// and the custom fields of boards created from it
+type TemplateContent struct {
+	Items        []TemplateItem       `json:"items"`
+	Connections  []TemplateConnection `json:"connections"`
+	CustomFields []CustomField        `json:"custom_fields,omitempty"`
+}
+
+// BoardTemplate represents a reusable board layout
+type BoardTemplate struct {
+	ID            uuid.UUID       `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Name          string          `json:"name" gorm:"not null"`
+	Description   string          `json:"description"`
+	Scope         TemplateScope   `json:"scope" gorm:"not null;default:'user'"`
+	OwnerID       *uuid.UUID      `json:"owner_id,omitempty" gorm:"type:uuid;index"` // Nil for built-in templates
+	SourceBoardID *uuid.UUID      `json:"source_board_id,omitempty" gorm:"type:uuid"`
+	Content       TemplateContent `json:"content" gorm:"type:jsonb;serializer:json"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt  `json:"-" gorm:"index"`
+}
+
+// BeforeCreate hook to generate UUID
+func (t *BoardTemplate) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}